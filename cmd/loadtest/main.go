@@ -0,0 +1,117 @@
+// ============================================================================
+// LOAD TEST
+// ============================================================================
+// loadtest sends a mix of task requests to a running API from several
+// workers at once and reports throughput, latency percentiles and errors
+// per operation, so a change can be measured before and after:
+//
+//	go run ./cmd/loadtest -url http://localhost:8080 -c 20 -d 30s
+//	go run ./cmd/loadtest -mix list=80,get=20 -n 5000
+//
+// It only touches tasks it created itself: a few are created before the run
+// (-seed) so get/update/delete have something to work on, and whatever is
+// left is deleted at the end (unless -cleanup=false).
+//
+// Note: the API's rate limiter (internal/middleware/rateLimit.go) allows
+// 10 requests per second per IP by default and answers 429 beyond that. 429s
+// are counted apart from other errors, so you can tell "the limiter kicked in"
+// from "the API is failing". Start the API with RATE_LIMIT_DISABLED=true to
+// measure without it.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// config is everything set with flags
+type config struct {
+	baseURL     string
+	apiKey      string
+	concurrency int
+	duration    time.Duration
+	requests    int
+	mix         []weightedOp
+	seed        int
+	timeout     time.Duration
+	cleanup     bool
+}
+
+func main() {
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadtest:", err)
+		os.Exit(2)
+	}
+
+	client := newClient(cfg)
+
+	// Seed tasks so get/update/delete have something to work on
+	for i := 0; i < cfg.seed; i++ {
+		if _, status, err := client.do(opCreate); err != nil || status != 201 {
+			fmt.Fprintf(os.Stderr, "loadtest: seeding failed (status %d): %v\n", status, err)
+			fmt.Fprintln(os.Stderr, "Is the API running at", cfg.baseURL, "and is -api-key right?")
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Load testing %s with %d workers (%s)\n", cfg.baseURL, cfg.concurrency, describeStop(cfg))
+	results := run(cfg, client)
+	results.print(os.Stdout)
+
+	if cfg.cleanup {
+		if n := client.deleteAll(); n > 0 {
+			fmt.Printf("\nDeleted %d tasks created by the load test\n", n)
+		}
+	}
+
+	if results.failed() {
+		os.Exit(1)
+	}
+}
+
+// parseFlags reads the command line
+func parseFlags(args []string) (config, error) {
+	var cfg config
+	var mix string
+
+	flags := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	flags.StringVar(&cfg.baseURL, "url", "http://localhost:8080", "base URL of the running API (include the base path, e.g. /api)")
+	flags.StringVar(&cfg.apiKey, "api-key", os.Getenv("API_KEY"), "value for the X-API-Key header (default $API_KEY)")
+	flags.IntVar(&cfg.concurrency, "c", 10, "number of concurrent workers")
+	flags.DurationVar(&cfg.duration, "d", 10*time.Second, "how long to run (ignored when -n is set)")
+	flags.IntVar(&cfg.requests, "n", 0, "total number of requests to send (0 = run for -d)")
+	flags.StringVar(&mix, "mix", "list=40,get=30,create=15,update=10,delete=5", "weights of each operation: list, get, create, update, delete")
+	flags.IntVar(&cfg.seed, "seed", 20, "tasks to create before the run")
+	flags.DurationVar(&cfg.timeout, "timeout", 10*time.Second, "timeout for each request")
+	flags.BoolVar(&cfg.cleanup, "cleanup", true, "delete the tasks created by the load test afterwards")
+	if err := flags.Parse(args); err != nil {
+		return config{}, err
+	}
+
+	if cfg.concurrency < 1 {
+		return config{}, fmt.Errorf("-c must be at least 1")
+	}
+	if cfg.requests == 0 && cfg.duration <= 0 {
+		return config{}, fmt.Errorf("set -d or -n")
+	}
+	cfg.baseURL = strings.TrimRight(cfg.baseURL, "/")
+
+	var err error
+	if cfg.mix, err = parseMix(mix); err != nil {
+		return config{}, err
+	}
+	return cfg, nil
+}
+
+// describeStop says when the run ends, for the first line of output
+func describeStop(cfg config) string {
+	if cfg.requests > 0 {
+		return fmt.Sprintf("%d requests", cfg.requests)
+	}
+	return cfg.duration.String()
+}
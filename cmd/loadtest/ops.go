@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// op is one kind of request the load test sends
+type op string
+
+const (
+	opList   op = "list"   // GET /tasks
+	opGet    op = "get"    // GET /tasks/{id}
+	opCreate op = "create" // POST /tasks
+	opUpdate op = "update" // PUT /tasks/{id}
+	opDelete op = "delete" // DELETE /tasks/{id}
+)
+
+// allOps is the order operations are reported in
+var allOps = []op{opList, opGet, opCreate, opUpdate, opDelete}
+
+// weightedOp is one entry of -mix
+type weightedOp struct {
+	op     op
+	weight int
+}
+
+// parseMix reads -mix, e.g. "list=40,get=30,create=15,update=10,delete=5"
+// Weights are relative: "list=1,get=1" sends as many lists as gets
+func parseMix(raw string) ([]weightedOp, error) {
+	var mix []weightedOp
+	total := 0
+	for _, part := range strings.Split(raw, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid -mix entry %q: use name=weight", part)
+		}
+		o := op(strings.TrimSpace(name))
+		if !isOp(o) {
+			return nil, fmt.Errorf("invalid -mix operation %q: use list, get, create, update or delete", name)
+		}
+		w, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid -mix weight %q for %s", weight, name)
+		}
+		if w > 0 {
+			mix = append(mix, weightedOp{op: o, weight: w})
+			total += w
+		}
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("-mix needs at least one operation with a weight above 0")
+	}
+	return mix, nil
+}
+
+// isOp reports whether o is a known operation
+func isOp(o op) bool {
+	for _, known := range allOps {
+		if o == known {
+			return true
+		}
+	}
+	return false
+}
+
+// pick chooses an operation at random, following the weights
+func pick(mix []weightedOp) op {
+	total := 0
+	for _, w := range mix {
+		total += w.weight
+	}
+	n := rand.IntN(total)
+	for _, w := range mix {
+		if n < w.weight {
+			return w.op
+		}
+		n -= w.weight
+	}
+	return mix[len(mix)-1].op
+}
+
+// ============================================================================
+// API CLIENT
+// ============================================================================
+
+// client sends the requests and remembers the tasks it created
+type client struct {
+	cfg  config
+	http *http.Client
+
+	// created numbers the task titles
+	created atomic.Int64
+
+	// ids are the tasks the load test created and hasn't deleted yet
+	mu  sync.Mutex
+	ids []string
+}
+
+func newClient(cfg config) *client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Keep a connection per worker instead of opening new ones all the time
+	transport.MaxIdleConnsPerHost = cfg.concurrency
+
+	return &client{
+		cfg:  cfg,
+		http: &http.Client{Timeout: cfg.timeout, Transport: transport},
+	}
+}
+
+// do sends one request for o and returns the operation actually performed
+// get/update/delete need an existing task; with none left they create one.
+// err is set when no response came back (timeout, connection refused...)
+func (c *client) do(o op) (op, int, error) {
+	var id string
+	switch o {
+	case opGet, opUpdate:
+		if id = c.anyID(); id == "" {
+			o = opCreate
+		}
+	case opDelete:
+		// Taken out of the pool first, so two workers never delete the same task
+		if id = c.takeID(); id == "" {
+			o = opCreate
+		}
+	}
+
+	switch o {
+	case opList:
+		status, _, err := c.send(http.MethodGet, "/tasks", nil)
+		return o, status, err
+	case opGet:
+		status, _, err := c.send(http.MethodGet, "/tasks/"+id, nil)
+		return o, status, err
+	case opUpdate:
+		body := map[string]any{"completed": rand.IntN(2) == 0}
+		status, _, err := c.send(http.MethodPut, "/tasks/"+id, body)
+		return o, status, err
+	case opDelete:
+		status, _, err := c.send(http.MethodDelete, "/tasks/"+id, nil)
+		// Still there (e.g. rate limited or timed out): keep it for later requests
+		if err != nil || (status != http.StatusOK && status != http.StatusNotFound) {
+			c.addID(id)
+		}
+		return o, status, err
+	default:
+		body := map[string]any{
+			"title":       fmt.Sprintf("loadtest task %d", c.created.Add(1)),
+			"description": "Created by cmd/loadtest",
+		}
+		status, respBody, err := c.send(http.MethodPost, "/tasks", body)
+		if err == nil && status == http.StatusCreated {
+			var task struct {
+				ID string `json:"id"`
+			}
+			if json.Unmarshal(respBody, &task) == nil && task.ID != "" {
+				c.addID(task.ID)
+			}
+		}
+		return opCreate, status, err
+	}
+}
+
+// deleteAll deletes the tasks the load test created and returns how many went
+// It slows down when the rate limiter answers 429, rather than leaving tasks behind
+func (c *client) deleteAll() int {
+	deleted := 0
+	for id := c.takeID(); id != ""; id = c.takeID() {
+		for attempt := 0; attempt < 5; attempt++ {
+			status, _, err := c.send(http.MethodDelete, "/tasks/"+id, nil)
+			if err == nil && status == http.StatusOK {
+				deleted++
+			}
+			if err != nil || status != http.StatusTooManyRequests {
+				break
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+	}
+	return deleted
+}
+
+// send makes one request and reads the whole response body
+func (c *client) send(method, path string, body any) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, c.cfg.baseURL+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.cfg.apiKey != "" {
+		req.Header.Set("X-API-Key", c.cfg.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	// Reading the body is part of the request's latency
+	respBody, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody, err
+}
+
+// anyID returns a random task created by the load test ("" if none)
+func (c *client) anyID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.ids) == 0 {
+		return ""
+	}
+	return c.ids[rand.IntN(len(c.ids))]
+}
+
+// takeID removes a random task from the pool and returns it ("" if none)
+func (c *client) takeID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.ids) == 0 {
+		return ""
+	}
+	i := rand.IntN(len(c.ids))
+	id := c.ids[i]
+	c.ids[i] = c.ids[len(c.ids)-1]
+	c.ids = c.ids[:len(c.ids)-1]
+	return id
+}
+
+// addID puts a created task in the pool
+func (c *client) addID(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ids = append(c.ids, id)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// opStats collects the results of one operation
+type opStats struct {
+	latencies   []time.Duration // every request that got a response
+	ok          int             // 2xx
+	rateLimited int             // 429
+	clientErr   int             // other 4xx
+	serverErr   int             // 5xx
+	failed      int             // no response at all (timeout, connection refused...)
+}
+
+// results is the outcome of a run
+type results struct {
+	elapsed time.Duration
+	ops     map[op]*opStats
+}
+
+// run starts the workers and waits until -n requests were sent or -d passed
+func run(cfg config, c *client) *results {
+	deadline := time.Now().Add(cfg.duration)
+	var sent atomic.Int64
+
+	// Each worker keeps its own stats, so recording needs no locks
+	perWorker := make([]map[op]*opStats, cfg.concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	for w := range cfg.concurrency {
+		perWorker[w] = map[op]*opStats{}
+		wg.Add(1)
+		go func(stats map[op]*opStats) {
+			defer wg.Done()
+			for {
+				if cfg.requests > 0 {
+					if sent.Add(1) > int64(cfg.requests) {
+						return
+					}
+				} else if time.Now().After(deadline) {
+					return
+				}
+
+				begin := time.Now()
+				performed, status, err := c.do(pick(cfg.mix))
+				record(stats, performed, status, err, time.Since(begin))
+			}
+		}(perWorker[w])
+	}
+	wg.Wait()
+
+	res := &results{elapsed: time.Since(start), ops: map[op]*opStats{}}
+	for _, stats := range perWorker {
+		for o, s := range stats {
+			res.merge(o, s)
+		}
+	}
+	return res
+}
+
+// record adds one request to the worker's stats
+func record(stats map[op]*opStats, o op, status int, err error, latency time.Duration) {
+	s := stats[o]
+	if s == nil {
+		s = &opStats{}
+		stats[o] = s
+	}
+
+	if err != nil {
+		s.failed++
+		return
+	}
+	s.latencies = append(s.latencies, latency)
+	switch {
+	case status == http.StatusTooManyRequests:
+		s.rateLimited++
+	case status >= 500:
+		s.serverErr++
+	case status >= 400:
+		s.clientErr++
+	default:
+		s.ok++
+	}
+}
+
+// merge adds a worker's stats for one operation to the totals
+func (r *results) merge(o op, s *opStats) {
+	total := r.ops[o]
+	if total == nil {
+		total = &opStats{}
+		r.ops[o] = total
+	}
+	total.latencies = append(total.latencies, s.latencies...)
+	total.ok += s.ok
+	total.rateLimited += s.rateLimited
+	total.clientErr += s.clientErr
+	total.serverErr += s.serverErr
+	total.failed += s.failed
+}
+
+// failed reports whether the API failed any request (5xx or no response)
+func (r *results) failed() bool {
+	for _, s := range r.ops {
+		if s.serverErr > 0 || s.failed > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// print writes the report table
+//
+//	op      requests  rps    ok    429  4xx  5xx  failed  err%   p50     p90     p99     max
+//	list    4000      400.0  4000  0    0    0    0       0.00   2.1ms   4.3ms   9.8ms   21ms
+func (r *results) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nop\trequests\trps\tok\t429\t4xx\t5xx\tfailed\terr%\tp50\tp90\tp99\tmax")
+
+	all := &opStats{}
+	for _, o := range allOps {
+		if s := r.ops[o]; s != nil {
+			r.printRow(tw, string(o), s)
+			all.latencies = append(all.latencies, s.latencies...)
+			all.ok += s.ok
+			all.rateLimited += s.rateLimited
+			all.clientErr += s.clientErr
+			all.serverErr += s.serverErr
+			all.failed += s.failed
+		}
+	}
+	r.printRow(tw, "total", all)
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nRan for %s. err%% counts 5xx and requests with no response; 429 and 4xx are listed apart.\n",
+		r.elapsed.Round(time.Millisecond))
+}
+
+// printRow writes one line of the table
+func (r *results) printRow(w io.Writer, name string, s *opStats) {
+	requests := s.ok + s.rateLimited + s.clientErr + s.serverErr + s.failed
+	errRate := 0.0
+	if requests > 0 {
+		errRate = 100 * float64(s.serverErr+s.failed) / float64(requests)
+	}
+
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	fmt.Fprintf(w, "%s\t%d\t%.1f\t%d\t%d\t%d\t%d\t%d\t%.2f\t%s\t%s\t%s\t%s\n",
+		name, requests, float64(requests)/r.elapsed.Seconds(),
+		s.ok, s.rateLimited, s.clientErr, s.serverErr, s.failed, errRate,
+		percentile(s.latencies, 0.50), percentile(s.latencies, 0.90),
+		percentile(s.latencies, 0.99), percentile(s.latencies, 1),
+	)
+}
+
+// percentile returns the latency below which p of the (sorted) requests fall
+func percentile(sorted []time.Duration, p float64) string {
+	if len(sorted) == 0 {
+		return "-"
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return roundLatency(sorted[i]).String()
+}
+
+// roundLatency keeps two or three significant digits, e.g. 2.13ms, 21.4ms, 213ms
+func roundLatency(d time.Duration) time.Duration {
+	switch {
+	case d >= 100*time.Millisecond:
+		return d.Round(time.Millisecond)
+	case d >= 10*time.Millisecond:
+		return d.Round(100 * time.Microsecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
@@ -0,0 +1,63 @@
+// ============================================================================
+// MULTI-MODE ENTRY POINT
+// ============================================================================
+// One binary for every way the application runs, so a container image or
+// SAM template only has to ship one file:
+//
+//	todo serve [--banner]   HTTP server with job workers, the scheduler and the task counter
+//	todo lambda             AWS Lambda function
+//	todo worker             job workers, the scheduler and the task counter, no HTTP
+//	todo migrate            create MongoDB indexes and exit
+//	todo seed               insert sample tasks into an empty database and exit
+//
+// Without an argument the mode comes from APP_MODE, then defaults to
+// "lambda" inside AWS Lambda and "serve" everywhere else.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"go-todo-api/internal/bootstrap"
+)
+
+func main() {
+	mode, args := selectMode(os.Args[1:])
+
+	switch mode {
+	case "serve":
+		flags := flag.NewFlagSet("serve", flag.ExitOnError)
+		showBanner := flags.Bool("banner", false, "print a human-friendly startup banner")
+		flags.Parse(args)
+		bootstrap.Serve(*showBanner)
+	case "lambda":
+		bootstrap.Lambda()
+	case "worker":
+		bootstrap.Worker()
+	case "migrate":
+		bootstrap.Migrate()
+	case "seed":
+		bootstrap.Seed()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown mode %q\n\nusage: todo [serve|lambda|worker|migrate|seed]\n", mode)
+		os.Exit(2)
+	}
+}
+
+// selectMode returns the mode and the arguments left for it
+// The first argument wins unless it's a flag (e.g. "todo --banner")
+func selectMode(args []string) (string, []string) {
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		return args[0], args[1:]
+	}
+	if mode := os.Getenv("APP_MODE"); mode != "" {
+		return mode, args
+	}
+	// The Lambda runtime always sets AWS_LAMBDA_RUNTIME_API
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		return "lambda", args
+	}
+	return "serve", args
+}
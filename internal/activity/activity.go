@@ -0,0 +1,150 @@
+// Package activity keeps a per-user record of what the server did to their
+// tasks on its own, so they can see why something moved
+// Entries are written by background work (like auto-archiving) and read at
+// GET /me/activity, newest first.
+package activity
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Actions recorded in Entry.Action
+const (
+	ActionArchived = "archived" // A completed task was moved to the archive
+)
+
+// Entry is one thing that happened to one of a user's tasks
+type Entry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    string             `bson:"user_id"`
+	Action    string             `bson:"action"`
+	TaskID    string             `bson:"task_id"`
+	TaskTitle string             `bson:"task_title"`
+	Reason    string             `bson:"reason"` // Why, in words for the user
+	At        time.Time          `bson:"at"`
+}
+
+// Store persists the entries
+// MongoStore is used in production; MemoryStore in tests
+type Store interface {
+	// Record adds an entry and sets its ID (and At, when it's zero)
+	Record(ctx context.Context, e *Entry) error
+
+	// List returns a user's most recent entries, newest first
+	List(ctx context.Context, userID string, limit int) ([]Entry, error)
+}
+
+// ============================================================================
+// MONGODB STORE
+// ============================================================================
+
+// MongoStore keeps entries in a MongoDB collection
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store on the given collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// EnsureIndexes creates the index for listing a user's recent entries
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "at", Value: -1}},
+	})
+	return err
+}
+
+// Record inserts an entry
+func (s *MongoStore) Record(ctx context.Context, e *Entry) error {
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+	e.ID = primitive.NewObjectID()
+	_, err := s.collection.InsertOne(ctx, e)
+	return err
+}
+
+// List returns a user's most recent entries, newest first
+func (s *MongoStore) List(ctx context.Context, userID string, limit int) ([]Entry, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "at", Value: -1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []Entry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ============================================================================
+// IN-MEMORY STORE
+// ============================================================================
+
+// MemoryStore keeps entries in a slice - use it in tests
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Record adds an entry
+func (s *MemoryStore) Record(ctx context.Context, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+	e.ID = primitive.NewObjectID()
+	s.entries = append(s.entries, *e)
+	return nil
+}
+
+// List returns a user's most recent entries, newest first
+func (s *MemoryStore) List(ctx context.Context, userID string, limit int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := []Entry{}
+	for _, e := range slices.Backward(s.entries) {
+		if e.UserID == userID && len(entries) < limit {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// ============================================================================
+// DEFAULT STORE
+// ============================================================================
+
+// defaultStore is where entries are recorded and read from
+// It's the same store the archive writes to, set next to it by initArchive
+var defaultStore Store
+
+// Init sets the default store; Init(nil) turns the activity record off
+func Init(s Store) Store {
+	defaultStore = s
+	return s
+}
+
+// Default returns the store set by Init (nil before Init)
+func Default() Store {
+	return defaultStore
+}
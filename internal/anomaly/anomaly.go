@@ -0,0 +1,367 @@
+// Package anomaly watches each caller's usage and alerts when it's unusual:
+// a sudden burst of requests (by default 10 times what the caller usually
+// sends) or many tasks deleted at once - a leaked key, a runaway script, a
+// sync gone wrong.
+//
+// The Detector counts each caller's requests and deletions in one-minute
+// windows. When a window closes it compares the counts with the thresholds
+// in the organization's settings (PUT /admin/settings) and sends an Alert to
+// every Channel: the log, and email and a webhook when they're configured
+// (see ChannelsFromEnv).
+//
+// Counts are kept per instance, for the requests it serves. Behind a load
+// balancer a burst shows on every instance all the same, but MaxDeletions
+// applies to each instance on its own.
+package anomaly
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/settings"
+)
+
+// Alert kinds
+const (
+	KindRequestSpike = "request_spike" // Many more requests than the caller usually sends
+	KindMassDeletion = "mass_deletion" // Many tasks deleted in one window
+)
+
+// Window is how long requests and deletions are counted before they're compared
+const Window = time.Minute
+
+// historyWindows is how many earlier windows a caller's usual request count
+// is averaged over; callers seen for less aren't checked for bursts yet
+const historyWindows = 10
+
+// alertCooldown is how long a caller isn't alerted on again for the same kind
+const alertCooldown = time.Hour
+
+// Defaults for the thresholds the settings leave at 0
+const (
+	DefaultSpikeFactor  = 10
+	DefaultMinRequests  = 100
+	DefaultMaxDeletions = 50
+)
+
+// Caller is who usage is counted for: a managed API key, or a user
+// authenticated another way (the shared API_KEY, a JWT)
+type Caller struct {
+	KeyID  string // The managed API key, if any
+	UserID string
+}
+
+// String names the caller in alerts, e.g. "API key 6900d4... (alice)"
+func (c Caller) String() string {
+	if c.KeyID == "" {
+		return "user " + c.UserID
+	}
+	return "API key " + c.KeyID + " (" + c.UserID + ")"
+}
+
+// Alert is unusual usage by one caller
+type Alert struct {
+	Kind      string    `json:"kind"`
+	KeyID     string    `json:"key_id,omitempty"`
+	UserID    string    `json:"user_id"`
+	Count     int       `json:"count"`           // Requests or deletions in the window
+	Usual     float64   `json:"usual,omitempty"` // Requests per window before it, on average (request_spike)
+	Threshold int       `json:"threshold"`       // The count that alerts
+	At        time.Time `json:"at"`              // When the window closed
+}
+
+// Caller returns who the alert is about
+func (a Alert) Caller() Caller {
+	return Caller{KeyID: a.KeyID, UserID: a.UserID}
+}
+
+// Message describes the alert in one sentence
+func (a Alert) Message() string {
+	var b strings.Builder
+	b.WriteString(a.Caller().String())
+	switch a.Kind {
+	case KindRequestSpike:
+		b.WriteString(" sent " + strconv.Itoa(a.Count) + " requests in a minute, against " + strconv.Itoa(int(math.Round(a.Usual))) + " usually")
+	case KindMassDeletion:
+		b.WriteString(" deleted " + strconv.Itoa(a.Count) + " tasks in a minute")
+	}
+	b.WriteString(" (alerts at " + strconv.Itoa(a.Threshold) + ")")
+	return b.String()
+}
+
+// Channel delivers alerts
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, a Alert) error
+}
+
+// Options configures a Detector
+type Options struct {
+	Channels []Channel   // Where alerts go (the log always gets them too)
+	Clock    clock.Clock // nil = the real clock
+	// Thresholds reads the thresholds at the end of each window (nil = the
+	// organization's settings, see settings.Default)
+	Thresholds func(ctx context.Context) (settings.AnomalyThresholds, error)
+}
+
+// usage is one caller's counts
+type usage struct {
+	requests  int   // In the current window
+	deletions int   // In the current window
+	history   []int // Requests in the earlier windows, oldest first
+}
+
+// alertKey is what the cooldown is kept for
+type alertKey struct {
+	caller Caller
+	kind   string
+}
+
+// ============================================================================
+// DETECTOR
+// ============================================================================
+
+// Detector counts usage and alerts on what's unusual
+type Detector struct {
+	channels   []Channel
+	clock      clock.Clock
+	thresholds func(ctx context.Context) (settings.AnomalyThresholds, error)
+
+	mu      sync.Mutex
+	usage   map[Caller]*usage
+	alerted map[alertKey]time.Time // When each caller was last alerted on, per kind
+
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// New creates a detector; call Start to check every Window
+func New(opts Options) *Detector {
+	d := &Detector{
+		channels:   append([]Channel{logChannel{}}, opts.Channels...),
+		clock:      clock.OrReal(opts.Clock),
+		thresholds: opts.Thresholds,
+		usage:      make(map[Caller]*usage),
+		alerted:    make(map[alertKey]time.Time),
+	}
+	if d.thresholds == nil {
+		d.thresholds = orgThresholds
+	}
+	return d
+}
+
+// Request counts a request by c
+func (d *Detector) Request(c Caller) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.of(c).requests++
+}
+
+// Deleted counts a task deleted by c
+func (d *Detector) Deleted(c Caller) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.of(c).deletions++
+}
+
+// of returns c's counts, creating them; call it with d.mu held
+func (d *Detector) of(c Caller) *usage {
+	u, ok := d.usage[c]
+	if !ok {
+		u = &usage{}
+		d.usage[c] = u
+	}
+	return u
+}
+
+// Check closes the current window: it compares each caller's counts with
+// the thresholds, sends the alerts, and starts counting again
+// Start calls it every Window; it returns the alerts sent, for tests.
+func (d *Detector) Check(ctx context.Context) []Alert {
+	t, err := d.thresholds(ctx)
+	if err != nil {
+		// Alert with the defaults rather than not at all
+		logger.WithTrace(ctx).Warn("Failed to read the anomaly thresholds, using the defaults", slog.Any("error", err))
+		t = settings.AnomalyThresholds{}
+	}
+	t = withDefaults(t)
+
+	alerts := d.close(t)
+	for _, a := range alerts {
+		for _, ch := range d.channels {
+			if err := ch.Send(ctx, a); err != nil {
+				logger.WithTrace(ctx).Error("Failed to send an anomaly alert",
+					slog.String("channel", ch.Name()), slog.String("kind", a.Kind), slog.Any("error", err))
+			}
+		}
+	}
+	return alerts
+}
+
+// close compares the window's counts with t and starts a new window,
+// returning the alerts that aren't cooling down
+func (d *Detector) close(t settings.AnomalyThresholds) []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.clock.Now().UTC()
+	var alerts []Alert
+	for caller, u := range d.usage {
+		if !t.Off {
+			alerts = append(alerts, unusual(caller, u, t, now)...)
+		}
+
+		u.history = append(u.history, u.requests)
+		if len(u.history) > historyWindows {
+			u.history = u.history[1:]
+		}
+		u.requests, u.deletions = 0, 0
+		if !slices.ContainsFunc(u.history, func(n int) bool { return n > 0 }) {
+			delete(d.usage, caller) // Quiet for historyWindows: forget them
+		}
+	}
+
+	// Once per caller and kind per alertCooldown
+	alerts = slices.DeleteFunc(alerts, func(a Alert) bool {
+		key := alertKey{caller: a.Caller(), kind: a.Kind}
+		if last, ok := d.alerted[key]; ok && now.Sub(last) < alertCooldown {
+			return true
+		}
+		d.alerted[key] = now
+		return false
+	})
+	for key, last := range d.alerted {
+		if now.Sub(last) >= alertCooldown {
+			delete(d.alerted, key)
+		}
+	}
+
+	slices.SortFunc(alerts, func(a, b Alert) int {
+		return strings.Compare(a.UserID+a.KeyID+a.Kind, b.UserID+b.KeyID+b.Kind)
+	})
+	return alerts
+}
+
+// unusual returns what's unusual in u's current window
+func unusual(c Caller, u *usage, t settings.AnomalyThresholds, now time.Time) []Alert {
+	var alerts []Alert
+	if u.deletions >= t.MaxDeletions {
+		alerts = append(alerts, Alert{Kind: KindMassDeletion, KeyID: c.KeyID, UserID: c.UserID,
+			Count: u.deletions, Threshold: t.MaxDeletions, At: now})
+	}
+	// A burst needs something to compare with: the caller's earlier windows
+	if len(u.history) == historyWindows {
+		usual := mean(u.history)
+		threshold := max(t.MinRequests, int(math.Ceil(float64(t.SpikeFactor)*max(usual, 1))))
+		if u.requests >= threshold {
+			alerts = append(alerts, Alert{Kind: KindRequestSpike, KeyID: c.KeyID, UserID: c.UserID,
+				Count: u.requests, Usual: usual, Threshold: threshold, At: now})
+		}
+	}
+	return alerts
+}
+
+// Start checks every Window until Shutdown
+func (d *Detector) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done.Add(1)
+	go func() {
+		defer d.done.Done()
+		ticker := time.NewTicker(Window)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.Check(ctx)
+			}
+		}
+	}()
+}
+
+// Shutdown stops checking and waits for a check in progress to finish
+func (d *Detector) Shutdown(ctx context.Context) error {
+	if d.cancel == nil {
+		return nil
+	}
+	d.cancel()
+
+	finished := make(chan struct{})
+	go func() {
+		d.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ============================================================================
+// THRESHOLDS
+// ============================================================================
+
+// orgThresholds reads the thresholds from the organization's settings
+func orgThresholds(ctx context.Context) (settings.AnomalyThresholds, error) {
+	s := settings.Default()
+	if s == nil {
+		return settings.AnomalyThresholds{}, nil
+	}
+	org, err := s.Get(ctx)
+	return org.Anomaly, err
+}
+
+// withDefaults fills in the thresholds left at 0
+func withDefaults(t settings.AnomalyThresholds) settings.AnomalyThresholds {
+	if t.SpikeFactor <= 0 {
+		t.SpikeFactor = DefaultSpikeFactor
+	}
+	if t.MinRequests <= 0 {
+		t.MinRequests = DefaultMinRequests
+	}
+	if t.MaxDeletions <= 0 {
+		t.MaxDeletions = DefaultMaxDeletions
+	}
+	return t
+}
+
+// mean is the average of counts
+func mean(counts []int) float64 {
+	sum := 0
+	for _, n := range counts {
+		sum += n
+	}
+	return float64(sum) / float64(len(counts))
+}
+
+// ============================================================================
+// DEFAULT DETECTOR
+// ============================================================================
+
+// defaultDetector counts the requests the API serves
+// bootstrap's initAnomalies sets and starts it; while it's nil, requests
+// aren't counted at all
+var defaultDetector *Detector
+
+// Init sets the default detector; Init(nil) turns detection off
+func Init(d *Detector) *Detector {
+	defaultDetector = d
+	return d
+}
+
+// Default returns the detector set by Init (nil when detection is off)
+func Default() *Detector {
+	return defaultDetector
+}
@@ -0,0 +1,216 @@
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/email"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/settings"
+)
+
+// recordingChannel keeps the alerts it's sent
+type recordingChannel struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (c *recordingChannel) Name() string { return "test" }
+
+func (c *recordingChannel) Send(ctx context.Context, a Alert) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.alerts = append(c.alerts, a)
+	return nil
+}
+
+// newTestDetector creates a detector with thresholds t on a fake clock
+func newTestDetector(t settings.AnomalyThresholds) (*Detector, *recordingChannel, *clock.Fake) {
+	logger.Init()
+	ch := &recordingChannel{}
+	fake := clock.NewFake(time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC))
+	d := New(Options{
+		Channels:   []Channel{ch},
+		Clock:      fake,
+		Thresholds: func(context.Context) (settings.AnomalyThresholds, error) { return t, nil },
+	})
+	return d, ch, fake
+}
+
+// minute counts n requests by c and closes the window
+func minute(d *Detector, fake *clock.Fake, c Caller, n int) []Alert {
+	for range n {
+		d.Request(c)
+	}
+	fake.Advance(Window)
+	return d.Check(context.Background())
+}
+
+// TestDetector_RequestSpike tests that a burst of 10 times a caller's usual
+// requests alerts, once, and only after there's a usual to compare with
+func TestDetector_RequestSpike(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	d, ch, fake := newTestDetector(settings.AnomalyThresholds{})
+	key := Caller{KeyID: "6900d436e231fdbb964c3c1c", UserID: "alice"}
+	steady := Caller{UserID: "bob"}
+
+	// Act + Assert: a new caller bursting straight away has nothing to compare with
+	if alerts := minute(d, fake, key, 500); len(alerts) != 0 {
+		t.Errorf("Expected no alert without a history, got %+v", alerts)
+	}
+	for range historyWindows {
+		for range 150 {
+			d.Request(steady)
+		}
+		minute(d, fake, key, 20)
+	}
+
+	// 10x the usual 20 (the first burst has dropped out of the history) is 200
+	for range 150 {
+		d.Request(steady)
+	}
+	alerts := minute(d, fake, key, 500)
+	if len(alerts) != 1 || alerts[0].Kind != KindRequestSpike || alerts[0].Caller() != key ||
+		alerts[0].Count != 500 || alerts[0].Threshold != 200 {
+		t.Fatalf("Expected a request spike for alice's key at 200, got %+v", alerts)
+	}
+	if !strings.Contains(alerts[0].Message(), "sent 500 requests in a minute, against 20 usually") {
+		t.Errorf("Unexpected message %q", alerts[0].Message())
+	}
+	if len(ch.alerts) != 1 {
+		t.Errorf("Expected the alert on the channel, got %+v", ch.alerts)
+	}
+
+	// Still bursting: not alerted again within the cooldown
+	if alerts := minute(d, fake, key, 5000); len(alerts) != 0 {
+		t.Errorf("Expected no repeat within the cooldown, got %+v", alerts)
+	}
+
+	// Quiet callers are forgotten after historyWindows empty windows
+	for range historyWindows {
+		fake.Advance(Window)
+		d.Check(ctx)
+	}
+	if n := len(d.usage); n != 0 {
+		t.Errorf("Expected quiet callers to be forgotten, %d left", n)
+	}
+
+	t.Log("✅ Request spike alerted once")
+}
+
+// TestDetector_MinRequests tests that a quiet caller's burst below
+// MinRequests doesn't alert, however many times its usual it is
+func TestDetector_MinRequests(t *testing.T) {
+	// Arrange
+	d, _, fake := newTestDetector(settings.AnomalyThresholds{SpikeFactor: 5, MinRequests: 200})
+	key := Caller{KeyID: "k1", UserID: "alice"}
+	for range historyWindows {
+		minute(d, fake, key, 2)
+	}
+
+	// Act + Assert
+	if alerts := minute(d, fake, key, 150); len(alerts) != 0 {
+		t.Errorf("Expected no alert under min_requests, got %+v", alerts)
+	}
+	if alerts := minute(d, fake, key, 250); len(alerts) != 1 || alerts[0].Threshold != 200 {
+		t.Errorf("Expected an alert at min_requests, got %+v", alerts)
+	}
+}
+
+// TestDetector_MassDeletion tests the deletions threshold, and that Off
+// turns every alert off
+func TestDetector_MassDeletion(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	thresholds := settings.AnomalyThresholds{MaxDeletions: 3}
+	d, _, fake := newTestDetector(thresholds)
+	key := Caller{KeyID: "k1", UserID: "alice"}
+
+	// Act + Assert
+	d.Deleted(key)
+	d.Deleted(key)
+	fake.Advance(Window)
+	if alerts := d.Check(ctx); len(alerts) != 0 {
+		t.Errorf("Expected no alert for 2 deletions, got %+v", alerts)
+	}
+	for range 3 {
+		d.Deleted(key)
+	}
+	fake.Advance(Window)
+	if alerts := d.Check(ctx); len(alerts) != 1 || alerts[0].Kind != KindMassDeletion || alerts[0].Count != 3 {
+		t.Errorf("Expected a mass deletion alert, got %+v", alerts)
+	}
+
+	off, _, fake := newTestDetector(settings.AnomalyThresholds{MaxDeletions: 3, Off: true})
+	for range 10 {
+		off.Deleted(key)
+	}
+	fake.Advance(Window)
+	if alerts := off.Check(ctx); len(alerts) != 0 {
+		t.Errorf("Expected no alerts when off, got %+v", alerts)
+	}
+
+	t.Log("✅ Mass deletions alerted")
+}
+
+// fakeSender keeps the emails it's sent
+type fakeSender struct{ sent []email.Message }
+
+func (s *fakeSender) Send(ctx context.Context, msg email.Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+// TestChannelsFromEnv tests the email and webhook channels
+func TestChannelsFromEnv(t *testing.T) {
+	// Arrange
+	var got map[string]any
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer hook.Close()
+	sender := &fakeSender{}
+	t.Setenv("ANOMALY_ALERT_EMAILS", "ops@example.com, security@example.com")
+	t.Setenv("ANOMALY_ALERT_WEBHOOK_URL", hook.URL)
+	alert := Alert{Kind: KindMassDeletion, KeyID: "k1", UserID: "alice", Count: 80, Threshold: 50}
+
+	// Act
+	channels, err := ChannelsFromEnv(sender, hook.Client())
+	if err != nil || len(channels) != 2 {
+		t.Fatalf("Expected email and webhook channels, got %v, %v", channels, err)
+	}
+	for _, ch := range channels {
+		if err := ch.Send(context.Background(), alert); err != nil {
+			t.Errorf("%s failed: %v", ch.Name(), err)
+		}
+	}
+
+	// Assert
+	if len(sender.sent) != 2 || sender.sent[1].To != "security@example.com" ||
+		sender.sent[0].Subject != "Unusual API usage by API key k1 (alice)" {
+		t.Errorf("Expected an email to each address, got %+v", sender.sent)
+	}
+	if got["type"] != "anomaly.mass_deletion" || !strings.Contains(got["text"].(string), "deleted 80 tasks") {
+		t.Errorf("Expected the alert posted to the webhook, got %v", got)
+	}
+
+	// Emails need a mail server; a webhook needs an http(s) URL
+	if _, err := ChannelsFromEnv(nil, hook.Client()); err == nil {
+		t.Error("Expected an error for emails without a mail server")
+	}
+	t.Setenv("ANOMALY_ALERT_EMAILS", "")
+	t.Setenv("ANOMALY_ALERT_WEBHOOK_URL", "ftp://example.com")
+	if _, err := ChannelsFromEnv(nil, hook.Client()); err == nil {
+		t.Error("Expected an error for a non-http webhook URL")
+	}
+
+	t.Log("✅ Alerts emailed and posted")
+}
@@ -0,0 +1,141 @@
+package anomaly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"go-todo-api/internal/email"
+	"go-todo-api/internal/logger"
+)
+
+// ChannelsFromEnv returns the channels configured besides the log:
+//
+//	ANOMALY_ALERT_EMAILS=ops@example.com,security@example.com   (needs SMTP_HOST, see internal/email)
+//	ANOMALY_ALERT_WEBHOOK_URL=https://hooks.slack.com/services/...
+//
+// sender is nil when email is off; the webhook is posted with client.
+func ChannelsFromEnv(sender email.Sender, client *http.Client) ([]Channel, error) {
+	var channels []Channel
+	if to := splitList(os.Getenv("ANOMALY_ALERT_EMAILS")); len(to) > 0 {
+		if sender == nil {
+			return nil, fmt.Errorf("ANOMALY_ALERT_EMAILS needs a mail server (SMTP_HOST)")
+		}
+		channels = append(channels, EmailChannel{Sender: sender, To: to})
+	}
+	if raw := strings.TrimSpace(os.Getenv("ANOMALY_ALERT_WEBHOOK_URL")); raw != "" {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("invalid ANOMALY_ALERT_WEBHOOK_URL %q: want an http(s) URL", raw)
+		}
+		channels = append(channels, WebhookChannel{Client: client, URL: raw})
+	}
+	return channels, nil
+}
+
+// splitList splits a comma-separated setting, dropping empty items
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// ============================================================================
+// LOG
+// ============================================================================
+
+// logChannel writes alerts to the log, at warn, so log-based alerting
+// (a CloudWatch metric filter, a Loki rule) can pick them up on event=anomaly
+type logChannel struct{}
+
+func (logChannel) Name() string { return "log" }
+
+func (logChannel) Send(ctx context.Context, a Alert) error {
+	logger.WithTrace(ctx).Warn("Unusual API usage: "+a.Message(),
+		slog.String("event", "anomaly"), slog.String("kind", a.Kind),
+		slog.String("key_id", a.KeyID), slog.String("user_id", a.UserID),
+		slog.Int("count", a.Count), slog.Int("threshold", a.Threshold))
+	return nil
+}
+
+// ============================================================================
+// EMAIL
+// ============================================================================
+
+// EmailChannel emails each alert to a list of addresses
+type EmailChannel struct {
+	Sender email.Sender
+	To     []string
+}
+
+func (c EmailChannel) Name() string { return "email" }
+
+// Send emails the alert to every address, and returns the first failure
+func (c EmailChannel) Send(ctx context.Context, a Alert) error {
+	msg := email.Message{
+		Subject: "Unusual API usage by " + a.Caller().String(),
+		Text: a.Message() + ".\n\n" +
+			"Disable the key with POST /admin/apikeys/{id}/disable if it shouldn't be doing this.\n" +
+			"The thresholds are in the organization's settings (PUT /admin/settings).\n",
+	}
+	var first error
+	for _, to := range c.To {
+		msg.To = to
+		if err := c.Sender.Send(ctx, msg); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// ============================================================================
+// WEBHOOK
+// ============================================================================
+
+// WebhookChannel posts each alert as JSON to a URL
+// The body has a "text" field, so a Slack or Mattermost incoming webhook
+// shows the alert as it is:
+//
+//	{"type": "anomaly.mass_deletion", "text": "API key ... deleted 80 tasks in a minute (alerts at 50)", "alert": {...}}
+type WebhookChannel struct {
+	Client *http.Client
+	URL    string
+}
+
+func (c WebhookChannel) Name() string { return "webhook" }
+
+// Send posts the alert; any status but 2xx is an error
+func (c WebhookChannel) Send(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(struct {
+		Type  string `json:"type"`
+		Text  string `json:"text"`
+		Alert Alert  `json:"alert"`
+	}{Type: "anomaly." + a.Kind, Text: "Unusual API usage: " + a.Message(), Alert: a})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("anomaly webhook answered %s", resp.Status)
+	}
+	return nil
+}
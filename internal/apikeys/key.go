@@ -0,0 +1,95 @@
+// Package apikeys manages the API keys callers authenticate with
+// Besides the shared API_KEY, operators can hand out keys of their own to
+// each user (or integration). Each key belongs to a user, has a role, and can
+// be disabled or deleted on its own - so an abusive client can be locked out
+// without rotating the key everyone else uses.
+//
+// A key looks like tk_<id>_<secret>. Only a SHA-256 hash of it is stored;
+// the key itself is shown once, when it's created.
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-todo-api/internal/domainerrors"
+)
+
+// Roles a key can have
+const (
+	// RoleAdmin may use the /admin/* endpoints
+	RoleAdmin = "admin"
+	// RoleUser may use the task endpoints only
+	RoleUser = "user"
+)
+
+var (
+	// ErrNotFound is returned when no key (or no key of a user) has the given ID
+	ErrNotFound = domainerrors.New(domainerrors.ErrNotFound, "API key not found")
+
+	// ErrInvalid is returned by Authenticate for anything that isn't a valid key
+	ErrInvalid = errors.New("apikeys: invalid key")
+
+	// ErrDisabled is returned by Authenticate for a key that has been disabled
+	ErrDisabled = errors.New("apikeys: key disabled")
+)
+
+// Key is a stored API key
+type Key struct {
+	ID        string       `bson:"_id"`
+	Name      string       `bson:"name"`    // What it's for, e.g. "mobile app"
+	UserID    string       `bson:"user_id"` // Who it belongs to
+	Role      string       `bson:"role"`
+	Hash      string       `bson:"hash"` // SHA-256 of the whole key, hex encoded
+	Disabled  bool         `bson:"disabled"`
+	Sandbox   bool         `bson:"sandbox,omitempty"` // Its tasks are kept apart from everyone's real ones
+	List      ListDefaults `bson:"list"`              // How GET /tasks pages its lists when the request doesn't say
+	CreatedAt time.Time    `bson:"created_at"`
+}
+
+// ListDefaults are a key's own defaults for GET /tasks, for clients that
+// can't easily send ?limit= or a client hint (zero value = the server's)
+type ListDefaults struct {
+	Mobile bool `bson:"mobile,omitempty"` // A mobile app's key: its lists get MOBILE_LIST_LIMIT tasks
+	Limit  int  `bson:"limit,omitempty"`  // Tasks per list (0 = the default for the kind of client)
+}
+
+// keyPrefix starts every managed key, so they're easy to spot (and to tell
+// apart from the shared API_KEY) in configs and secret scanners
+const keyPrefix = "tk_"
+
+// newSecret returns a new key for the key ID: tk_<id>_<64 hex characters>
+func newSecret(id string) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return keyPrefix + id + "_" + hex.EncodeToString(random), nil
+}
+
+// parseSecret returns the key ID in a key, so it can be looked up directly
+// Key IDs are ObjectIDs: anything else can't be a key, and isn't looked up.
+func parseSecret(secret string) (string, bool) {
+	rest, ok := strings.CutPrefix(secret, keyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, random, ok := strings.Cut(rest, "_")
+	if !ok || !primitive.IsValidObjectID(id) || random == "" {
+		return "", false
+	}
+	return id, true
+}
+
+// hashSecret is what's stored instead of the key
+// Keys are long and random, so a fast hash is enough (no bcrypt needed)
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,300 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-todo-api/internal/clock"
+)
+
+// authCacheTTL is how long Authenticate trusts a key it has looked up
+// Every request is authenticated, so without it each one would cost a
+// database read. Changes made through this process apply at once; another
+// replica notices a disabled or deleted key within authCacheTTL.
+const authCacheTTL = 10 * time.Second
+
+// maxCachedKeys bounds the cache: past it, lookups of IDs that don't exist
+// aren't cached, so requests with made-up keys can't grow it
+const maxCachedKeys = 10_000
+
+// Keys creates, checks and manages API keys
+type Keys struct {
+	store Store
+	clock clock.Clock
+
+	mu      sync.Mutex
+	cache   map[string]cachedKey // Key ID → last lookup
+	swept   time.Time            // When expired lookups were last dropped from cache
+	lastIPs map[string]string    // Key ID → client IP it was last used from
+}
+
+// cachedKey is one Authenticate lookup
+type cachedKey struct {
+	key     *Key // nil: no key has this ID
+	expires time.Time
+}
+
+// User is everything known about a user: their keys
+// There's no separate user store - a user exists while they have keys
+type User struct {
+	ID           string
+	Keys         int
+	DisabledKeys int
+	Roles        []string // Roles of the user's keys, sorted
+}
+
+// Disabled reports whether none of the user's keys work any more
+func (u User) Disabled() bool {
+	return u.DisabledKeys == u.Keys
+}
+
+// New creates a key manager on store (clk nil = the real clock)
+func New(store Store, clk clock.Clock) *Keys {
+	return &Keys{
+		store:   store,
+		clock:   clock.OrReal(clk),
+		cache:   make(map[string]cachedKey),
+		lastIPs: make(map[string]string),
+	}
+}
+
+// ============================================================================
+// AUTHENTICATION
+// ============================================================================
+
+// Authenticate returns the key for secret, or ErrInvalid / ErrDisabled
+// clientIP is remembered so the key's rate limit can be reset (see LastIPs).
+// Any other error means the store couldn't be read.
+func (k *Keys) Authenticate(ctx context.Context, secret, clientIP string) (*Key, error) {
+	id, ok := parseSecret(secret)
+	if !ok {
+		return nil, ErrInvalid
+	}
+
+	key, err := k.lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Compare hashes in constant time, so the response time doesn't tell an
+	// attacker how much of a guessed key was right
+	if key == nil || subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashSecret(secret))) != 1 {
+		return nil, ErrInvalid
+	}
+	if key.Disabled {
+		return nil, ErrDisabled
+	}
+
+	k.mu.Lock()
+	k.lastIPs[key.ID] = clientIP
+	k.mu.Unlock()
+	return key, nil
+}
+
+// lookup reads a key through the cache; nil means there's no such key
+func (k *Keys) lookup(ctx context.Context, id string) (*Key, error) {
+	now := k.clock.Now()
+
+	k.mu.Lock()
+	cached, ok := k.cache[id]
+	k.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	key, err := k.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		key, err = nil, nil // Cached too, so a retried wrong key doesn't reach the database each time
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if now.Sub(k.swept) >= authCacheTTL {
+		k.swept = now
+		for cachedID, cached := range k.cache {
+			if !now.Before(cached.expires) {
+				delete(k.cache, cachedID)
+			}
+		}
+	}
+	if key != nil || len(k.cache) < maxCachedKeys {
+		k.cache[id] = cachedKey{key: key, expires: now.Add(authCacheTTL)}
+	}
+	return key, nil
+}
+
+// forget drops cached lookups after a change (all of them: a user's keys
+// can be changed at once, and the cache is small)
+func (k *Keys) forget() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	clear(k.cache)
+}
+
+// ============================================================================
+// MANAGEMENT
+// ============================================================================
+
+// Create makes a new key and returns it with its secret
+// The secret is only available now: the store keeps its hash.
+func (k *Keys) Create(ctx context.Context, name, userID, role string) (*Key, string, error) {
+	return k.create(ctx, &Key{Name: name, UserID: userID, Role: role})
+}
+
+// CreateLike makes a new key with template's name, user, role, sandbox and
+// list defaults; the rest is filled in as by Create
+func (k *Keys) CreateLike(ctx context.Context, template Key) (*Key, string, error) {
+	return k.create(ctx, &Key{
+		Name: template.Name, UserID: template.UserID, Role: template.Role,
+		Sandbox: template.Sandbox, List: template.List,
+	})
+}
+
+// CreateSandbox creates a user key whose tasks live in a sandbox of their
+// own (see repository.Sandboxes), for integrators to test against
+func (k *Keys) CreateSandbox(ctx context.Context, name, userID string) (*Key, string, error) {
+	return k.create(ctx, &Key{Name: name, UserID: userID, Role: RoleUser, Sandbox: true})
+}
+
+// create gives key an ID and a secret, and stores it
+func (k *Keys) create(ctx context.Context, key *Key) (*Key, string, error) {
+	key.ID = primitive.NewObjectID().Hex()
+	key.CreatedAt = k.clock.Now().UTC()
+	secret, err := newSecret(key.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	key.Hash = hashSecret(secret)
+
+	if err := k.store.Insert(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// Get returns a key by ID, or ErrNotFound
+func (k *Keys) Get(ctx context.Context, id string) (*Key, error) {
+	return k.store.Get(ctx, id)
+}
+
+// List returns the keys of a user, or every key when userID is empty
+func (k *Keys) List(ctx context.Context, userID string) ([]Key, error) {
+	return k.store.List(ctx, userID)
+}
+
+// SetDisabled disables or enables a key, or returns ErrNotFound
+func (k *Keys) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return k.change(k.store.SetDisabled(ctx, id, false, disabled))
+}
+
+// Delete removes a key, or returns ErrNotFound
+func (k *Keys) Delete(ctx context.Context, id string) error {
+	return k.change(k.store.Delete(ctx, id, false))
+}
+
+// SetUserDisabled disables or enables every key of a user, or returns
+// ErrNotFound when the user has none
+func (k *Keys) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	return k.change(k.store.SetDisabled(ctx, userID, true, disabled))
+}
+
+// DeleteUser removes every key of a user, or returns ErrNotFound when the
+// user has none
+func (k *Keys) DeleteUser(ctx context.Context, userID string) error {
+	return k.change(k.store.Delete(ctx, userID, true))
+}
+
+// change turns a store write into ErrNotFound when nothing matched, and
+// makes sure this process stops trusting cached keys
+func (k *Keys) change(matched int64, err error) error {
+	k.forget()
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Users lists everyone who has keys, sorted by user ID
+func (k *Keys) Users(ctx context.Context) ([]User, error) {
+	keys, err := k.store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]*User{}
+	for _, key := range keys {
+		u, ok := byID[key.UserID]
+		if !ok {
+			u = &User{ID: key.UserID}
+			byID[key.UserID] = u
+		}
+		u.Keys++
+		if key.Disabled {
+			u.DisabledKeys++
+		}
+		if !slices.Contains(u.Roles, key.Role) {
+			u.Roles = append(u.Roles, key.Role)
+		}
+	}
+
+	users := make([]User, 0, len(byID))
+	for _, u := range byID {
+		slices.Sort(u.Roles)
+		users = append(users, *u)
+	}
+	slices.SortFunc(users, func(a, b User) int { return strings.Compare(a.ID, b.ID) })
+	return users, nil
+}
+
+// LastIPs returns the client IPs the given keys were last used from on this
+// process (the rate limiter counts requests per IP, see POST
+// /admin/apikeys/{id}/reset-limits)
+func (k *Keys) LastIPs(keys []Key) []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var ips []string
+	for _, key := range keys {
+		if ip, ok := k.lastIPs[key.ID]; ok && !slices.Contains(ips, ip) {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// ============================================================================
+// DEFAULT MANAGER
+// ============================================================================
+
+// defaultKeys is used by the auth middleware and the admin endpoints
+// bootstrap sets it before the router is built (and the Lambda handler on
+// a cold start), so every request sees the same keys and cache
+var defaultKeys *Keys
+
+// Init creates the default key manager
+// Call it once at startup, after database.Connect(). Until then only the
+// shared API_KEY is accepted. Init(nil) goes back to that (for tests).
+func Init(store Store) *Keys {
+	defaultKeys = nil
+	if store != nil {
+		defaultKeys = New(store, nil)
+	}
+	return defaultKeys
+}
+
+// Default returns the manager created by Init (nil before Init)
+func Default() *Keys {
+	return defaultKeys
+}
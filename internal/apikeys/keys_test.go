@@ -0,0 +1,203 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-todo-api/internal/clock"
+)
+
+// TestKeys_CreateAndAuthenticate tests that a new key works and only its hash is stored
+func TestKeys_CreateAndAuthenticate(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	store := NewMemoryStore()
+	keys := New(store, nil)
+
+	// Act
+	key, secret, err := keys.Create(ctx, "mobile app", "alice", RoleUser)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	got, err := keys.Authenticate(ctx, secret, "10.0.0.1")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the new key to authenticate, got %v", err)
+	}
+	if got.ID != key.ID || got.UserID != "alice" || got.Role != RoleUser {
+		t.Errorf("Expected alice's user key, got %+v", got)
+	}
+	if !strings.HasPrefix(secret, "tk_"+key.ID+"_") {
+		t.Errorf("Expected the key to start with tk_<id>_, got %q", secret)
+	}
+	stored, _ := store.Get(ctx, key.ID)
+	if stored.Hash == secret || strings.Contains(stored.Hash, secret) {
+		t.Error("Expected only a hash of the key to be stored")
+	}
+	if ips := keys.LastIPs([]Key{*key}); len(ips) != 1 || ips[0] != "10.0.0.1" {
+		t.Errorf("Expected the key's last IP to be 10.0.0.1, got %v", ips)
+	}
+
+	t.Logf("✅ Key %s authenticates as %s", key.ID, got.UserID)
+}
+
+// TestKeys_AuthenticateRejects tests wrong, malformed, disabled and deleted keys
+func TestKeys_AuthenticateRejects(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	keys := New(NewMemoryStore(), nil)
+	key, secret, _ := keys.Create(ctx, "ci", "bob", RoleUser)
+
+	// Act + Assert: a guessed secret for a real ID, and keys that aren't ours
+	for _, bad := range []string{"tk_" + key.ID + "_guess", "tk_unknown_abc", "not-a-key", ""} {
+		if _, err := keys.Authenticate(ctx, bad, "ip"); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected ErrInvalid for %q, got %v", bad, err)
+		}
+	}
+
+	// Act + Assert: disabled, enabled again, then deleted
+	if err := keys.SetDisabled(ctx, key.ID, true); err != nil {
+		t.Fatalf("SetDisabled failed: %v", err)
+	}
+	if _, err := keys.Authenticate(ctx, secret, "ip"); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Expected ErrDisabled straight after disabling, got %v", err)
+	}
+	if err := keys.SetDisabled(ctx, key.ID, false); err != nil {
+		t.Fatalf("SetDisabled failed: %v", err)
+	}
+	if _, err := keys.Authenticate(ctx, secret, "ip"); err != nil {
+		t.Errorf("Expected the key to work again once enabled, got %v", err)
+	}
+	if err := keys.Delete(ctx, key.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := keys.Authenticate(ctx, secret, "ip"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected ErrInvalid after deleting, got %v", err)
+	}
+	if err := keys.Delete(ctx, key.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+// TestKeys_CacheExpires tests that a change made elsewhere applies once the cache expires
+func TestKeys_CacheExpires(t *testing.T) {
+	// Arrange: two managers on one store, like two replicas on one database
+	ctx := context.Background()
+	store := NewMemoryStore()
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	replica := New(store, fake)
+	other := New(store, nil)
+	key, secret, _ := other.Create(ctx, "ci", "carol", RoleUser)
+	if _, err := replica.Authenticate(ctx, secret, "ip"); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+
+	// Act
+	_ = other.SetDisabled(ctx, key.ID, true)
+	_, cachedErr := replica.Authenticate(ctx, secret, "ip")
+	fake.Advance(authCacheTTL)
+	_, expiredErr := replica.Authenticate(ctx, secret, "ip")
+
+	// Assert
+	if cachedErr != nil {
+		t.Errorf("Expected the cached key to still work, got %v", cachedErr)
+	}
+	if !errors.Is(expiredErr, ErrDisabled) {
+		t.Errorf("Expected ErrDisabled once the cache expired, got %v", expiredErr)
+	}
+}
+
+// countingStore counts the keys read from a store
+type countingStore struct {
+	Store
+	gets int
+}
+
+func (s *countingStore) Get(ctx context.Context, id string) (*Key, error) {
+	s.gets++
+	return s.Store.Get(ctx, id)
+}
+
+// TestKeys_UnknownKeys tests that made-up keys neither reach the store for
+// IDs that can't exist nor stay in the cache once they've expired
+func TestKeys_UnknownKeys(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	store := &countingStore{Store: NewMemoryStore()}
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	keys := New(store, fake)
+
+	// Act: IDs that aren't ObjectIDs
+	for _, bad := range []string{"tk_unknown_abc", "tk_" + strings.Repeat("z", 24) + "_abc", "tk_0123456789abcdef01234567aa_abc"} {
+		if _, err := keys.Authenticate(ctx, bad, "ip"); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected ErrInvalid for %q, got %v", bad, err)
+		}
+	}
+
+	// Assert
+	if store.gets != 0 {
+		t.Errorf("Expected keys that can't exist not to be looked up, got %d reads", store.gets)
+	}
+
+	// Act: well-formed IDs that don't exist, then one more after they expire
+	for range 3 {
+		_, _ = keys.Authenticate(ctx, "tk_"+primitive.NewObjectID().Hex()+"_abc", "ip")
+	}
+	fake.Advance(authCacheTTL)
+	_, _ = keys.Authenticate(ctx, "tk_"+primitive.NewObjectID().Hex()+"_abc", "ip")
+
+	// Assert
+	keys.mu.Lock()
+	cached := len(keys.cache)
+	keys.mu.Unlock()
+	if cached != 1 {
+		t.Errorf("Expected only the latest miss to stay cached, got %d entries", cached)
+	}
+	if store.gets != 4 {
+		t.Errorf("Expected one read per unknown ID, got %d", store.gets)
+	}
+}
+
+// TestKeys_Users tests that users are grouped from their keys and managed together
+func TestKeys_Users(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	keys := New(NewMemoryStore(), nil)
+	_, aliceSecret, _ := keys.Create(ctx, "laptop", "alice", RoleUser)
+	_, _, _ = keys.Create(ctx, "ops", "alice", RoleAdmin)
+	_, _, _ = keys.Create(ctx, "ci", "bob", RoleUser)
+
+	// Act
+	if err := keys.SetUserDisabled(ctx, "alice", true); err != nil {
+		t.Fatalf("SetUserDisabled failed: %v", err)
+	}
+	users, err := keys.Users(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Users failed: %v", err)
+	}
+	if len(users) != 2 || users[0].ID != "alice" || users[1].ID != "bob" {
+		t.Fatalf("Expected alice and bob, got %+v", users)
+	}
+	if alice := users[0]; alice.Keys != 2 || !alice.Disabled() || strings.Join(alice.Roles, ",") != "admin,user" {
+		t.Errorf("Expected alice disabled with 2 keys and both roles, got %+v", alice)
+	}
+	if users[1].Disabled() {
+		t.Error("Expected bob to be unaffected")
+	}
+	if _, err := keys.Authenticate(ctx, aliceSecret, "ip"); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Expected alice's keys to be disabled, got %v", err)
+	}
+	if err := keys.DeleteUser(ctx, "nobody"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a user without keys, got %v", err)
+	}
+
+	t.Logf("✅ %d users derived from their keys", len(users))
+}
@@ -0,0 +1,199 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Store persists the keys
+// MongoStore is used in production; MemoryStore in tests
+type Store interface {
+	// Insert adds a new key
+	Insert(ctx context.Context, key *Key) error
+
+	// Get returns a key by ID, or ErrNotFound
+	Get(ctx context.Context, id string) (*Key, error)
+
+	// List returns the keys of a user, or every key when userID is empty,
+	// oldest first
+	List(ctx context.Context, userID string) ([]Key, error)
+
+	// SetDisabled disables or enables the keys with the given ID (or, with
+	// byUser, every key of the user with that ID) and returns how many matched
+	SetDisabled(ctx context.Context, id string, byUser, disabled bool) (int64, error)
+
+	// Delete removes the key with the given ID (or, with byUser, every key of
+	// the user with that ID) and returns how many were removed
+	Delete(ctx context.Context, id string, byUser bool) (int64, error)
+}
+
+// ============================================================================
+// MONGODB STORE
+// ============================================================================
+
+// MongoStore keeps keys in a MongoDB collection
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store on the given collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// EnsureIndexes creates the index for listing a user's keys
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+	})
+	return err
+}
+
+// Insert adds a new key
+func (s *MongoStore) Insert(ctx context.Context, key *Key) error {
+	_, err := s.collection.InsertOne(ctx, key)
+	return err
+}
+
+// Get returns a key by ID
+func (s *MongoStore) Get(ctx context.Context, id string) (*Key, error) {
+	var key Key
+	err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List returns the keys of a user (or all of them), oldest first
+// IDs are ObjectIDs, which start with their creation time
+func (s *MongoStore) List(ctx context.Context, userID string) ([]Key, error) {
+	filter := bson.M{}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []Key{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+// SetDisabled disables or enables one key or all of a user's keys
+func (s *MongoStore) SetDisabled(ctx context.Context, id string, byUser, disabled bool) (int64, error) {
+	result, err := s.collection.UpdateMany(ctx, match(id, byUser), bson.M{"$set": bson.M{"disabled": disabled}})
+	if err != nil {
+		return 0, err
+	}
+	return result.MatchedCount, nil
+}
+
+// Delete removes one key or all of a user's keys
+func (s *MongoStore) Delete(ctx context.Context, id string, byUser bool) (int64, error) {
+	result, err := s.collection.DeleteMany(ctx, match(id, byUser))
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// match selects a key by ID, or a user's keys by user ID
+func match(id string, byUser bool) bson.M {
+	if byUser {
+		return bson.M{"user_id": id}
+	}
+	return bson.M{"_id": id}
+}
+
+// ============================================================================
+// IN-MEMORY STORE
+// ============================================================================
+
+// MemoryStore keeps keys in a map
+// Keys are lost on restart and not shared between processes - use it in tests
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys map[string]Key
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]Key)}
+}
+
+// Insert adds a new key
+func (s *MemoryStore) Insert(ctx context.Context, key *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = *key
+	return nil
+}
+
+// Get returns a key by ID
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &key, nil
+}
+
+// List returns the keys of a user (or all of them), oldest first
+func (s *MemoryStore) List(ctx context.Context, userID string) ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := []Key{}
+	for _, key := range s.keys {
+		if userID == "" || key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+// SetDisabled disables or enables one key or all of a user's keys
+func (s *MemoryStore) SetDisabled(ctx context.Context, id string, byUser, disabled bool) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched int64
+	for keyID, key := range s.keys {
+		if (byUser && key.UserID == id) || (!byUser && keyID == id) {
+			key.Disabled = disabled
+			s.keys[keyID] = key
+			matched++
+		}
+	}
+	return matched, nil
+}
+
+// Delete removes one key or all of a user's keys
+func (s *MemoryStore) Delete(ctx context.Context, id string, byUser bool) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for keyID, key := range s.keys {
+		if (byUser && key.UserID == id) || (!byUser && keyID == id) {
+			delete(s.keys, keyID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
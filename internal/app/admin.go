@@ -0,0 +1,53 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"go-todo-api/internal/handlers"
+	"go-todo-api/internal/middleware"
+	"go-todo-api/internal/version"
+)
+
+// NewAdmin builds the handler for the admin listener (ADMIN_PORT)
+// It serves the operational endpoints that must never be on the public port:
+//
+//	/admin/*      log level, SLO report, scheduler status, DB pool,
+//	              API keys and their users
+//	/debug/pprof  CPU, heap and goroutine profiles
+//	/debug/vars   runtime counters (expvar)
+//	/docs         docs for the admin endpoints only
+//
+// The listener is bound to localhost by default; an admin API key is still
+// required in case it's exposed (e.g. ADMIN_HOST=0.0.0.0 inside a container).
+// limiter is the public router's (Options.RateLimiter), whose counts the
+// reset-limits endpoints reset; nil leaves nothing to reset.
+func NewAdmin(limiter *middleware.RateLimiter) http.Handler {
+	router := chi.NewMux()
+
+	// A smaller stack than the public router: no rate limiting, CORS or
+	// request timeout (a 30s CPU profile has to be allowed to finish)
+	router.Use(middleware.RequestIDChi)
+	router.Use(middleware.LoggingChi)
+	router.Use(middleware.RecoverChi)
+	router.Use(middleware.AuthChi)
+	router.Use(middleware.AdminOnly)
+
+	// Go's built-in profiler, e.g.
+	//   curl -H "X-API-Key: $API_KEY" localhost:9090/debug/pprof/heap > heap.out
+	//   go tool pprof heap.out
+	router.Mount("/debug", chimiddleware.Profiler())
+
+	api := humachi.New(router, huma.DefaultConfig("TODO API Admin", version.Version))
+	api.OpenAPI().Info.Description = "Operational endpoints, served on the admin listener only"
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		next(huma.WithContext(ctx, handlers.WithRateLimiter(ctx.Context(), limiter)))
+	})
+	registerAdminEndpoints(api)
+
+	return router
+}
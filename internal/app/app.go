@@ -0,0 +1,359 @@
+// Package app builds the HTTP handler shared by every entry point
+// cmd/api (a long-running server) and cmd/lambda (AWS Lambda) both serve the
+// exact same router, middleware stack and endpoints from here, so a feature
+// like rate limiting or auth can't be added to one and forgotten in the other.
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+
+	"go-todo-api/internal/config"
+	"go-todo-api/internal/docs"
+	"go-todo-api/internal/handlers"
+	"go-todo-api/internal/metrics"
+	"go-todo-api/internal/middleware"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/version"
+	"go-todo-api/internal/web"
+)
+
+// Options are the settings that differ between entry points
+type Options struct {
+	// ServerURL is the public URL of the API shown in the OpenAPI docs,
+	// including the base path (e.g. http://localhost:8080/api). Optional.
+	ServerURL string
+
+	// BasePath mounts every route under a prefix, e.g. "/api" (empty = root)
+	BasePath string
+
+	// RequestTimeout is the deadline for each request (default 15s)
+	RequestTimeout time.Duration
+
+	// ExportTimeout is the deadline for GET /export (default 5m)
+	// The export streams, so it isn't buffered like other responses
+	ExportTimeout time.Duration
+
+	// Profile holds the APP_ENV dependent settings (CORS origins, error detail)
+	// The zero value is the strict one: same-origin only, terse errors
+	Profile config.Profile
+
+	// SeparateAdmin leaves the /admin/* endpoints out of the public API
+	// Set it when they're served by NewAdmin on their own listener
+	SeparateAdmin bool
+
+	// LazyDatabase connects to MongoDB on the first request instead of at
+	// startup, answering 503 while it's unreachable (Lambda cold starts)
+	LazyDatabase bool
+
+	// RateLimit sets the per-IP request limit (zero value = 10/s, bursts of 20)
+	// Every router gets a limiter of its own
+	RateLimit middleware.RateLimitConfig
+
+	// RateLimiter replaces the limiter built from RateLimit when set, so the
+	// admin listener (NewAdmin) can reset counts in the same one
+	RateLimiter *middleware.RateLimiter
+
+	// TaskCacheTTL keeps GET /tasks results in memory this long (0 = off)
+	// e.g. 1s absorbs dashboards polling all at once (TASKS_CACHE_TTL)
+	TaskCacheTTL time.Duration
+
+	// StatsCacheTTL serves GET /stats from a snapshot this long before it's
+	// refreshed in the background (0 = off; STATS_CACHE_TTL, 1s by default)
+	StatsCacheTTL time.Duration
+
+	// Quotas limit what each user may own (zero value = no limits)
+	// Admins are never limited (QUOTA_MAX_TASKS)
+	Quotas handlers.Quotas
+
+	// ListDefaults are GET /tasks's page sizes for clients that don't give
+	// ?limit= (zero value = whole lists; MOBILE_LIST_LIMIT)
+	ListDefaults handlers.ListDefaults
+
+	// BulkGuard makes requests that update or delete many tasks at once
+	// confirm the count (zero value = off; BULK_CONFIRM_ABOVE)
+	BulkGuard handlers.BulkGuard
+
+	// LenientJSON drops unknown request body fields instead of answering 422
+	// Clients can still choose per request with Prefer: handling=strict|lenient
+	// (JSON_UNKNOWN_FIELDS=ignore; see middleware/unknownfields.go)
+	LenientJSON bool
+
+	// AuthExempt are requests served without an API key, with paths
+	// relative to BasePath (zero value = every API route needs one)
+	// e.g. GET /health for load balancer probes (AUTH_EXEMPT)
+	AuthExempt []middleware.AuthExemption
+
+	// TrustedProxies may set the client IP with X-Forwarded-For (zero value =
+	// none: the connection's address is used for rate limits and logs)
+	TrustedProxies []netip.Prefix
+
+	// TaskRepository replaces MongoDB for the task endpoints when set
+	// testutil/testserver uses it to run the whole app on an in-memory store
+	TaskRepository repository.TaskRepository
+
+	// Sandboxes replaces MongoDB for sandbox API keys when set
+	Sandboxes repository.Sandboxes
+}
+
+// New builds the router with every middleware and endpoint registered
+// It returns the handler to serve and the Huma API (for its OpenAPI spec)
+func New(opts Options) (http.Handler, huma.API) {
+	// ------------------------------------------------------------------------
+	// STEP 1: CREATE HTTP ROUTER
+	// ------------------------------------------------------------------------
+	// A router decides which function (handler) to call based on the URL
+	// For example: GET /tasks → calls GetAllTasks handler
+	//              POST /tasks → calls CreateTask handler
+	// Chi is a popular, fast router for Go
+	router := chi.NewMux() // NewMux() creates a new router (Mux = "HTTP request multiplexer")
+
+	// ------------------------------------------------------------------------
+	// STEP 2: ADD MIDDLEWARE
+	// ------------------------------------------------------------------------
+	// Middleware is code that runs BEFORE your handlers
+
+	// Client IPs (rate limits, access logs) only come from X-Forwarded-For
+	// when the request came through one of our own proxies
+	middleware.TrustedProxies = opts.TrustedProxies
+
+	// Add tracing middleware - creates spans for every request
+	// This shold be first so it measures the full request duration
+	router.Use(middleware.TracingChi)
+
+	// Add request ID middleware - tags every request with an X-Request-ID
+	// so its log lines can be found together
+	router.Use(middleware.RequestIDChi)
+
+	// Add logging middleware - writes a JSON access log for every request
+	// (method, path, status, bytes, duration, request ID, client IP)
+	router.Use(middleware.LoggingChi)
+
+	// Add panic recovery - a panicking handler returns a 500 problem+json
+	// (and is logged with its stack trace) instead of killing the connection
+	router.Use(middleware.RecoverChi)
+
+	// Add request timeout - every request gets a deadline (REQUEST_TIMEOUT)
+	// that handlers pass on to MongoDB; past it the client gets a 504
+	// GET /export streams its response, so it gets a longer deadline
+	// (EXPORT_TIMEOUT) and no buffering instead
+	router.Use(middleware.TimeoutExcept(opts.RequestTimeout, opts.ExportTimeout, opts.BasePath+"/export"))
+
+	// Lambda connects to MongoDB here rather than at cold start
+	if opts.LazyDatabase {
+		router.Use(middleware.RequireDatabase)
+	}
+
+	// Add rate limiting middleware - prevents API abuse
+	// Limits to 10 requests/second per IP with burst capacity of 20 by default
+	// (RATE_LIMIT_RPS, RATE_LIMIT_BURST; RATE_LIMIT_DISABLED=true turns it off)
+	// The OpenAPI spec isn't counted: docs tools poll it (see spec.go)
+	limiter := opts.RateLimiter
+	if limiter == nil {
+		limiter = middleware.NewRateLimiter(opts.RateLimit)
+	}
+	router.Use(limiter.HandlerExcept(opts.BasePath + specPath))
+
+	// Add security headers - protects against common attacks
+	router.Use(middleware.SecurityHeadersChi)
+
+	// Add CORS middleware - allows browsers from other domains to access your API
+	// CORS = Cross-Origin Resource Sharing
+	// Without this, browsers block requests from other websites for security
+	// Dev and staging allow any origin; prod only CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORSWithOrigins(opts.Profile.CORSOrigins))
+
+	// Only dev shows the underlying error in 500 responses (see middleware/problem.go)
+	middleware.VerboseErrors = opts.Profile.VerboseErrors
+	huma.NewError = middleware.NewError
+
+	// ------------------------------------------------------------------------
+	// STEP 3: CREATE HUMA API WITH OPENAPI DOCUMENTATION
+	// ------------------------------------------------------------------------
+	// Huma is a framework that wraps your router and adds superpowers:
+	// - Automatic OpenAPI documentation generation
+	// - Automatic request validation
+	// - Automatic JSON encoding/decoding
+	// - Better error handling
+
+	// Create Huma config with custom context tranformer
+	// This ensures OpenTelemetry spac context is passed from HTTP middleware to handlers
+	humaConfig := huma.DefaultConfig("TODO API", version.Version)
+
+	// Encode and decode JSON with the library picked at build time
+	// (encoding/json, or github.com/goccy/go-json with -tags gojson)
+	// A new map, because the default one is shared by every Huma API
+	humaConfig.Formats = map[string]huma.Format{
+		"application/json": jsonFormat,
+		"json":             jsonFormat,
+	}
+
+	// Tell clients (and the /docs page) where the API lives, including BASE_PATH
+	// e.g. http://localhost:8080/api - Huma uses the path part for its doc links
+	if opts.ServerURL != "" {
+		humaConfig.Servers = []*huma.Server{{URL: opts.ServerURL}}
+	}
+
+	// Mount the API under BASE_PATH (or at the root when it's empty)
+	// The middleware added above still runs for every request
+	var apiRouter chi.Router = router
+	if opts.BasePath != "" {
+		apiRouter = chi.NewRouter()
+		router.Mount(opts.BasePath, apiRouter)
+	}
+
+	// Serve the web UI at / (it has no data of its own, so no API key needed -
+	// the page asks for the key and sends it with every API call)
+	ui := web.Handler(opts.BasePath)
+	apiRouter.Handle("/", ui)
+	apiRouter.Handle("/ui/*", ui)
+
+	// DOCS_UI=swagger serves Swagger UI from embedded files instead of Huma's
+	// default page, which loads Stoplight Elements from a CDN - use it when
+	// the server (or its users) can't reach the internet
+	if docs.RendererFromEnv() == docs.RendererSwagger {
+		humaConfig.DocsPath = ""
+		swagger := docs.SwaggerHandler(humaConfig.Info.Title, opts.BasePath)
+		apiRouter.Handle("/docs", swagger)
+		apiRouter.Handle("/docs/assets/*", swagger)
+	}
+
+	// Routes added to public later (the OpenAPI spec) don't need the API key
+	public := apiRouter
+
+	// Add authentication middleware - requires valid API key for every API route
+	// Every request must include header: X-API-Key: your-key-here
+	// except the exempt ones (health probes, docs, CORS preflights by default)
+	exempt := make([]middleware.AuthExemption, 0, len(opts.AuthExempt))
+	for _, e := range opts.AuthExempt {
+		if e.Path != "*" {
+			e.Path = opts.BasePath + e.Path
+		}
+		exempt = append(exempt, e)
+	}
+	apiRouter = apiRouter.With(middleware.AuthExcept(exempt...))
+
+	// Save the full exchanges of callers an admin is recording
+	// (POST /admin/recordings/targets); after Auth, which says who they are
+	apiRouter = apiRouter.With(middleware.RecordUnder(opts.BasePath))
+
+	// Count each caller's requests, so a sudden burst raises an alert
+	// (see internal/anomaly); after Auth too
+	apiRouter = apiRouter.With(middleware.WatchUsage)
+
+	// Create Huma API instance with default configuration
+	// "TODO API" = API name, "1.0.0" = version number
+	api := humachi.New(apiRouter, humaConfig)
+
+	// Add metadata to the API documentation
+	// This shows up in the /docs page that users can see
+	api.OpenAPI().Info.Description = "A production-ready REST API for managing TODO tasks"
+	api.OpenAPI().Info.Contact = &huma.Contact{
+		Name: "Your Name",
+		URL:  "https://github.com/yourusername/go-todo-api",
+	}
+
+	// Track latency and 5xx errors per operation for GET /admin/slo
+	// This is a Huma middleware, so it knows which operation matched
+	api.UseMiddleware(middleware.SLOTracking(metrics.SLO()))
+
+	// Operations marked with middleware.Deprecate answer with Deprecation,
+	// Sunset and Link headers, and their callers are counted for
+	// GET /admin/deprecations
+	api.UseMiddleware(middleware.DeprecationHeaders(metrics.Deprecations()))
+
+	// Unknown body fields ("descripton") are a 422 unless the deployment or
+	// the request (Prefer: handling=lenient) asks for them to be dropped
+	api.UseMiddleware(middleware.UnknownFields(api.OpenAPI().Components.Schemas, opts.LenientJSON))
+
+	// Identical list queries running at the same time share one database
+	// query, so a burst of dashboards refreshing together costs one
+	reads := repository.NewReadGroup()
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		next(huma.WithContext(ctx, handlers.WithReadGroup(ctx.Context(), reads)))
+	})
+
+	// Answer repeated task lists from memory for a moment
+	if opts.TaskCacheTTL > 0 {
+		cache := repository.NewTaskCache(opts.TaskCacheTTL, nil)
+		api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+			next(huma.WithContext(ctx, handlers.WithTaskCache(ctx.Context(), cache)))
+		})
+	}
+
+	// Answer GET /stats from a snapshot, refreshed in the background
+	if opts.StatsCacheTTL > 0 {
+		statsCache := repository.NewStatsCache(opts.StatsCacheTTL, nil)
+		api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+			next(huma.WithContext(ctx, handlers.WithStatsCache(ctx.Context(), statsCache)))
+		})
+	}
+
+	// POST /admin/apikeys/{id}/reset-limits resets counts in this router's
+	// limiter (see handlers/apikeys.go)
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		next(huma.WithContext(ctx, handlers.WithRateLimiter(ctx.Context(), limiter)))
+	})
+
+	// Per-user limits checked by the handlers (see handlers/usage.go)
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		next(huma.WithContext(ctx, handlers.WithQuotas(ctx.Context(), opts.Quotas)))
+	})
+
+	// Shorter task lists for mobile clients (see handlers/pages.go)
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		next(huma.WithContext(ctx, handlers.WithListDefaults(ctx.Context(), opts.ListDefaults)))
+	})
+
+	// Bulk changes held back until confirmed (see handlers/bulk.go)
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		next(huma.WithContext(ctx, handlers.WithBulkGuard(ctx.Context(), opts.BulkGuard)))
+	})
+
+	// Location headers point under BASE_PATH (e.g. /api/tasks/{id})
+	if opts.BasePath != "" {
+		api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+			next(huma.WithContext(ctx, handlers.WithBasePath(ctx.Context(), opts.BasePath)))
+		})
+	}
+
+	// Serve the task endpoints from the given store instead of MongoDB
+	if opts.TaskRepository != nil {
+		api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+			next(huma.WithContext(ctx, handlers.WithTaskRepository(ctx.Context(), opts.TaskRepository)))
+		})
+	}
+	if opts.Sandboxes != nil {
+		api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+			next(huma.WithContext(ctx, handlers.WithSandboxes(ctx.Context(), opts.Sandboxes)))
+		})
+	}
+
+	// ------------------------------------------------------------------------
+	// STEP 4: REGISTER API ENDPOINTS (ROUTES)
+	// ------------------------------------------------------------------------
+	registerEndpoints(api)
+	if !opts.SeparateAdmin {
+		registerAdminEndpoints(api)
+	}
+
+	// ------------------------------------------------------------------------
+	// STEP 5: SERVE THE OPENAPI SPEC FROM MEMORY
+	// ------------------------------------------------------------------------
+	// Encoded once now that every endpoint is in it, then served with an ETag
+	// and gzip, without the API key. Registered after Huma's own handler for
+	// the same path, so it replaces it (the .yaml variants are still Huma's)
+	spec, err := newSpecDocument(api.OpenAPI())
+	if err != nil {
+		panic(fmt.Sprintf("encode OpenAPI spec: %v", err)) // A programming error, like a bad route
+	}
+	public.Method(http.MethodGet, specPath, spec)
+
+	return router, api
+}
@@ -0,0 +1,778 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/activity"
+	"go-todo-api/internal/apikeys"
+	"go-todo-api/internal/archive"
+	"go-todo-api/internal/config"
+	"go-todo-api/internal/handlers"
+	"go-todo-api/internal/hooks"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/middleware"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/profile"
+	"go-todo-api/internal/push"
+	"go-todo-api/internal/recording"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/settings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// testApp is the app under test, built by newTestApp
+type testApp struct {
+	http.Handler
+	api  huma.API
+	keys *apikeys.Keys // Managed API keys, kept in memory
+}
+
+// newTestApp builds the app with opts, accepting the shared admin key
+// "test-key" and managed keys from an in-memory store
+func newTestApp(t *testing.T, opts Options) testApp {
+	t.Helper()
+	logger.Init()
+	t.Setenv("API_KEY", "test-key")
+	keys := apikeys.Init(apikeys.NewMemoryStore())
+	t.Cleanup(func() { apikeys.Init(nil) })
+	handler, api := New(opts)
+	return testApp{Handler: handler, api: api, keys: keys}
+}
+
+// serve sends a request to h and returns the response
+// key is sent as X-API-Key and body as JSON, unless they're empty; with
+// changes the request's headers or address first.
+func serve(t *testing.T, h http.Handler, method, path, key, body string, with ...func(*http.Request)) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, change := range with {
+		change(req)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// header sets a request header for serve
+func header(name, value string) func(*http.Request) {
+	return func(req *http.Request) { req.Header.Set(name, value) }
+}
+
+// fromIP sends the request from ip for serve
+func fromIP(ip string) func(*http.Request) {
+	return func(req *http.Request) { req.RemoteAddr = ip + ":1234" }
+}
+
+// TestNew_BasePath tests that routes are served under the base path with the full middleware stack
+func TestNew_BasePath(t *testing.T) {
+	// Arrange
+	server := newTestApp(t, Options{ServerURL: "http://localhost:8080/api", BasePath: "/api"})
+
+	// Act
+	rec := serve(t, server, http.MethodGet, "/api/healthz", "test-key", "")
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for /api/healthz, got %d", rec.Code)
+	}
+	if rec.Header().Get("X-Request-ID") == "" {
+		t.Error("Expected the request ID middleware to run")
+	}
+	if server.api.OpenAPI().Paths["/tasks"] == nil {
+		t.Error("Expected /tasks to be registered")
+	}
+
+	t.Logf("✅ /api/healthz served with %d paths registered", len(server.api.OpenAPI().Paths))
+}
+
+// TestNew_RequiresAPIKey tests that auth applies to every entry point using the shared router
+func TestNew_RequiresAPIKey(t *testing.T) {
+	// Arrange
+	server := newTestApp(t, Options{})
+
+	// Act
+	rec := serve(t, server, http.MethodGet, "/healthz", "", "")
+
+	// Assert
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without an API key, got %d", rec.Code)
+	}
+}
+
+// TestNew_AuthExempt tests that exempt routes are served without an API key, under the base path
+func TestNew_AuthExempt(t *testing.T) {
+	// Arrange
+	server := newTestApp(t, Options{BasePath: "/api", AuthExempt: []middleware.AuthExemption{{Method: http.MethodGet, Path: "/healthz"}}})
+
+	// Act + Assert
+	if code := serve(t, server, http.MethodGet, "/api/healthz", "", "").Code; code != http.StatusOK {
+		t.Errorf("Expected status 200 for exempt GET /api/healthz, got %d", code)
+	}
+	if code := serve(t, server, http.MethodGet, "/api/tasks", "", "").Code; code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for GET /api/tasks, got %d", code)
+	}
+}
+
+// TestNew_PublicProbesHideErrors tests that an anonymous /readyz says a
+// dependency is down, but not why, unless error details are on
+func TestNew_PublicProbesHideErrors(t *testing.T) {
+	// Arrange: no database connection, so the MongoDB check fails
+	public := []middleware.AuthExemption{{Method: http.MethodGet, Path: "/readyz"}}
+	quiet := newTestApp(t, Options{AuthExempt: public})
+	rec := serve(t, quiet, http.MethodGet, "/readyz", "", "")
+	verbose := newTestApp(t, Options{AuthExempt: public, Profile: config.Profile{VerboseErrors: true}})
+	t.Cleanup(func() { middleware.VerboseErrors = false })
+
+	// Act
+	detailed := serve(t, verbose, http.MethodGet, "/readyz", "", "")
+
+	// Assert
+	var body struct {
+		Checks map[string]models.DependencyCheck `json:"checks"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	if check := body.Checks["mongodb"]; rec.Code != http.StatusServiceUnavailable || check.Status != "down" || check.Error != "" {
+		t.Errorf("Expected 503 with mongodb down and no error, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(detailed.Body.String(), `"error"`) {
+		t.Errorf("Expected the error with VerboseErrors, got %s", detailed.Body.String())
+	}
+
+	t.Log("✅ /readyz reported mongodb down without the driver error")
+}
+
+// TestNew_ServesUIWithoutAPIKey tests that the web UI loads without an API key
+func TestNew_ServesUIWithoutAPIKey(t *testing.T) {
+	// Arrange
+	server := newTestApp(t, Options{BasePath: "/api"})
+
+	for _, path := range []string{"/api/", "/api/ui/app.js"} {
+		// Act
+		rec := serve(t, server, http.MethodGet, path, "", "")
+
+		// Assert
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for %s, got %d", path, rec.Code)
+		}
+		if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'self'") {
+			t.Errorf("Expected the UI content security policy for %s, got '%s'", path, csp)
+		}
+	}
+}
+
+// TestNew_SwaggerDocs tests that DOCS_UI=swagger serves the embedded docs page
+func TestNew_SwaggerDocs(t *testing.T) {
+	// Arrange
+	t.Setenv("DOCS_UI", "swagger")
+	server := newTestApp(t, Options{})
+
+	for _, path := range []string{"/docs", "/docs/assets/swagger-ui-bundle.js"} {
+		// Act
+		rec := serve(t, server, http.MethodGet, path, "", "")
+
+		// Assert
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for %s, got %d", path, rec.Code)
+		}
+	}
+}
+
+// TestNewAdmin_SeparateListener tests that /admin/* moves off the public API
+func TestNewAdmin_SeparateListener(t *testing.T) {
+	// Arrange
+	public := newTestApp(t, Options{SeparateAdmin: true})
+	admin := NewAdmin(nil)
+
+	// Act
+	publicCode := serve(t, public, http.MethodGet, "/admin/log-level", "test-key", "").Code
+	adminCode := serve(t, admin, http.MethodGet, "/admin/log-level", "test-key", "").Code
+	pprofCode := serve(t, admin, http.MethodGet, "/debug/pprof/", "test-key", "").Code
+
+	// Assert
+	if publicCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for /admin/log-level on the public API, got %d", publicCode)
+	}
+	if adminCode != http.StatusOK {
+		t.Errorf("Expected 200 for /admin/log-level on the admin listener, got %d", adminCode)
+	}
+	if pprofCode != http.StatusOK {
+		t.Errorf("Expected 200 for /debug/pprof/ on the admin listener, got %d", pprofCode)
+	}
+}
+
+// TestNew_ResetLimitsOwnLimiter tests that reset-limits resets the limiter
+// of the router it's called on, whichever router was built last
+func TestNew_ResetLimitsOwnLimiter(t *testing.T) {
+	// Arrange: a key that used up its one request, and a second router
+	server := newTestApp(t, Options{RateLimit: middleware.RateLimitConfig{RequestsPerSecond: 0.001, Burst: 1}})
+	_, _ = New(Options{})
+	key, secret, _ := server.keys.Create(context.Background(), "ci", "alice", apikeys.RoleUser)
+	serve(t, server, http.MethodGet, "/healthz", secret, "", fromIP("192.0.2.1"))
+
+	// Act
+	limited := serve(t, server, http.MethodGet, "/healthz", secret, "", fromIP("192.0.2.1")).Code
+	reset := serve(t, server, http.MethodPost, "/admin/apikeys/"+key.ID+"/reset-limits", "test-key", "", fromIP("192.0.2.2")).Code
+	after := serve(t, server, http.MethodGet, "/healthz", secret, "", fromIP("192.0.2.1")).Code
+
+	// Assert
+	if limited != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 past the burst, got %d", limited)
+	}
+	if reset != http.StatusOK || after != http.StatusOK {
+		t.Errorf("Expected the reset to lift this router's limit, got %d then %d", reset, after)
+	}
+
+	t.Logf("✅ Limited %d, reset %d, then %d", limited, reset, after)
+}
+
+// TestNew_AdminRequiresAdminKey tests that only admin keys reach /admin/*, and that they can hand out keys
+func TestNew_AdminRequiresAdminKey(t *testing.T) {
+	// Arrange
+	server := newTestApp(t, Options{})
+
+	// Act: the shared (admin) key creates a user key, which then tries /admin
+	created := serve(t, server, http.MethodPost, "/admin/apikeys", "test-key", `{"name": "ci", "user_id": "alice"}`)
+	var key struct {
+		ID   string `json:"id"`
+		Key  string `json:"key"`
+		Role string `json:"role"`
+	}
+	_ = json.Unmarshal(created.Body.Bytes(), &key)
+	taskCode := serve(t, server, http.MethodGet, "/healthz", key.Key, "").Code
+	admin := serve(t, server, http.MethodGet, "/admin/apikeys", key.Key, "")
+	disabled := serve(t, server, http.MethodPost, "/admin/apikeys/"+key.ID+"/disable", "test-key", "")
+	afterCode := serve(t, server, http.MethodGet, "/healthz", key.Key, "").Code
+
+	// Assert
+	if created.Code != http.StatusCreated || key.Key == "" || key.Role != apikeys.RoleUser {
+		t.Fatalf("Expected a new user key, got %d: %s", created.Code, created.Body.String())
+	}
+	if taskCode != http.StatusOK {
+		t.Errorf("Expected the user key to be accepted outside /admin, got %d", taskCode)
+	}
+	var problem middleware.Problem
+	_ = json.Unmarshal(admin.Body.Bytes(), &problem)
+	if admin.Code != http.StatusForbidden || problem.Code != middleware.CodeAdminRequired {
+		t.Errorf("Expected status 403 %s for a user key on /admin, got %d: %s", middleware.CodeAdminRequired, admin.Code, admin.Body.String())
+	}
+	if disabled.Code != http.StatusOK || !strings.Contains(disabled.Body.String(), `"disabled":true`) {
+		t.Errorf("Expected the key to be disabled, got %d: %s", disabled.Code, disabled.Body.String())
+	}
+	if afterCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 with the disabled key, got %d", afterCode)
+	}
+
+	t.Logf("✅ User key: healthz %d, admin %d, after disabling %d", taskCode, admin.Code, afterCode)
+}
+
+// TestNew_TaskQuota tests that a user past QUOTA_MAX_TASKS gets a quota problem, and sees it in /me/usage
+func TestNew_TaskQuota(t *testing.T) {
+	// Arrange: a user allowed 2 tasks, who already has them
+	server := newTestApp(t, Options{
+		Quotas:         handlers.Quotas{MaxTasks: 2},
+		TaskRepository: repository.NewMemoryTaskRepository(),
+	})
+	_, secret, _ := server.keys.Create(context.Background(), "ci", "alice", apikeys.RoleUser)
+	for i := 0; i < 2; i++ {
+		if rec := serve(t, server, http.MethodPost, "/tasks", secret, `{"title": "Mine"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("Expected task %d to be created, got %d: %s", i+1, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Act
+	over := serve(t, server, http.MethodPost, "/tasks", secret, `{"title": "One too many"}`)
+	admin := serve(t, server, http.MethodPost, "/tasks", "test-key", `{"title": "Admins aren't limited"}`)
+	usage := serve(t, server, http.MethodGet, "/me/usage", secret, "")
+
+	// Assert
+	if over.Code != http.StatusForbidden || !strings.Contains(over.Body.String(), "quota.tasks") {
+		t.Errorf("Expected a 403 quota problem, got %d: %s", over.Code, over.Body.String())
+	}
+	if admin.Code != http.StatusCreated {
+		t.Errorf("Expected the admin's task to be created, got %d", admin.Code)
+	}
+	var got models.Usage
+	_ = json.Unmarshal(usage.Body.Bytes(), &got)
+	if usage.Code != http.StatusOK || got.UserID != "alice" || got.Tasks.Used != 2 || got.Tasks.Limit != 2 {
+		t.Errorf("Expected alice at 2 of 2 tasks, got %d: %s", usage.Code, usage.Body.String())
+	}
+
+	t.Logf("✅ Quota: 2 of 2 tasks, then %d", over.Code)
+}
+
+// TestNew_MobileListDefaults tests that mobile clients get a short first page
+// unless they ask for one, and that a key's own page size comes first
+func TestNew_MobileListDefaults(t *testing.T) {
+	// Arrange: 5 tasks, a mobile page of 2, and a key whose lists have 3
+	repo := repository.NewMemoryTaskRepository()
+	for range 5 {
+		_ = repo.Create(context.Background(), &models.Task{Title: "Read"})
+	}
+	server := newTestApp(t, Options{ListDefaults: handlers.ListDefaults{MobileLimit: 2}, TaskRepository: repo})
+	_, tablet, _ := server.keys.CreateLike(context.Background(), apikeys.Key{
+		Name: "tablet app", UserID: "alice", Role: apikeys.RoleUser, List: apikeys.ListDefaults{Mobile: true, Limit: 3},
+	})
+
+	list := func(path, key string, with ...func(*http.Request)) (int, string, *httptest.ResponseRecorder) {
+		rec := serve(t, server, http.MethodGet, path, key, "", with...)
+		var tasks []models.Task
+		_ = json.Unmarshal(rec.Body.Bytes(), &tasks)
+		return len(tasks), rec.Header().Get("X-Total-Count"), rec
+	}
+	mobile := header("Sec-CH-UA-Mobile", "?1")
+
+	// Act
+	desktop, _, _ := list("/tasks", "test-key", header("Sec-CH-UA-Mobile", "?0"))
+	phone, total, phoneResp := list("/tasks", "test-key", mobile)
+	next, _, nextResp := list("/tasks?offset=2&limit=2", "test-key", mobile)
+	asked, _, _ := list("/tasks?limit=4", "test-key", mobile)
+	keyed, _, _ := list("/tasks", tablet)
+	revalidated := serve(t, server, http.MethodGet, "/tasks", "test-key", "", mobile, header("If-None-Match", phoneResp.Header().Get("ETag")))
+
+	// Assert
+	if desktop != 5 || phone != 2 || total != "5" || next != 2 || asked != 4 || keyed != 3 {
+		t.Errorf("Expected 5 tasks on desktop, 2 of 5 on a phone, 2 on the next page, 4 asked for and 3 with the key's default; got %d, %d of %s, %d, %d and %d",
+			desktop, phone, total, next, asked, keyed)
+	}
+	if phoneResp.Header().Get("ETag") == nextResp.Header().Get("ETag") {
+		t.Errorf("Expected each page to have its own ETag, both %s", phoneResp.Header().Get("ETag"))
+	}
+	if revalidated.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for the phone's own ETag, got %d", revalidated.Code)
+	}
+	for _, rec := range []*httptest.ResponseRecorder{phoneResp, revalidated} {
+		if !slices.Contains(rec.Header().Values("Vary"), "Sec-CH-UA-Mobile") || rec.Header().Get("Accept-CH") != "Sec-CH-UA-Mobile" {
+			t.Errorf("Expected Vary and Accept-CH to name Sec-CH-UA-Mobile on a %d, got %v", rec.Code, rec.Header())
+		}
+	}
+
+	t.Logf("✅ Pages: desktop %d, phone %d of %s, key %d", desktop, phone, total, keyed)
+}
+
+// TestNew_SandboxKeys tests that a sandbox key's tasks are kept apart and can be reset
+func TestNew_SandboxKeys(t *testing.T) {
+	// Arrange: a real task, and a sandbox key with a task of its own
+	server := newTestApp(t, Options{
+		TaskRepository: repository.NewMemoryTaskRepository(),
+		Sandboxes:      repository.NewMemorySandboxes(),
+	})
+	count := func(key string) int {
+		var tasks []models.Task
+		_ = json.Unmarshal(serve(t, server, http.MethodGet, "/tasks", key, "").Body.Bytes(), &tasks)
+		return len(tasks)
+	}
+
+	created := serve(t, server, http.MethodPost, "/admin/apikeys", "test-key", `{"name": "integration", "user_id": "alice", "sandbox": true}`)
+	var key struct {
+		Key     string `json:"key"`
+		Sandbox bool   `json:"sandbox"`
+	}
+	_ = json.Unmarshal(created.Body.Bytes(), &key)
+	if created.Code != http.StatusCreated || !key.Sandbox {
+		t.Fatalf("Expected a new sandbox key, got %d: %s", created.Code, created.Body.String())
+	}
+	serve(t, server, http.MethodPost, "/tasks", "test-key", `{"title": "Real"}`)
+	serve(t, server, http.MethodPost, "/tasks", key.Key, `{"title": "Test data"}`)
+
+	// Act
+	realBefore, sandboxBefore := count("test-key"), count(key.Key)
+	notSandbox := serve(t, server, http.MethodPost, "/sandbox/reset", "test-key", "")
+	reset := serve(t, server, http.MethodPost, "/sandbox/reset", key.Key, "")
+	realAfter, sandboxAfter := count("test-key"), count(key.Key)
+	admin := serve(t, server, http.MethodPost, "/admin/apikeys", "test-key", `{"name": "x", "user_id": "alice", "role": "admin", "sandbox": true}`)
+
+	// Assert
+	if realBefore != 1 || sandboxBefore != 1 {
+		t.Errorf("Expected 1 real and 1 sandbox task, got %d and %d", realBefore, sandboxBefore)
+	}
+	if notSandbox.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 resetting without a sandbox key, got %d", notSandbox.Code)
+	}
+	if reset.Code != http.StatusOK || realAfter != 1 || sandboxAfter != 0 {
+		t.Errorf("Expected only the sandbox emptied, got %d with %d real and %d sandbox tasks", reset.Code, realAfter, sandboxAfter)
+	}
+	if admin.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for an admin sandbox key, got %d", admin.Code)
+	}
+
+	t.Logf("✅ Sandbox: %d task(s) kept apart, reset to %d", sandboxBefore, sandboxAfter)
+}
+
+// TestNew_PushSubscriptions tests a browser subscribing, listing and unsubscribing
+func TestNew_PushSubscriptions(t *testing.T) {
+	// Arrange: a server with Web Push on (no FCM)
+	webPush, err := push.NewWebPush("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw", "mailto:ops@example.com", nil)
+	if err != nil {
+		t.Fatalf("NewWebPush failed: %v", err)
+	}
+	push.Init(push.NewDispatcher(push.NewMemoryStore(), nil, webPush, nil))
+	t.Cleanup(func() { push.Init(nil) })
+	server := newTestApp(t, Options{})
+	// What PushSubscription.toJSON() gives, plus the type
+	browser := `{"type": "webpush", "endpoint": "https://push.example.com/abc", "expirationTime": null,
+		"keys": {"p256dh": "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4", "auth": "BTBZMqHH6r4Tts7J_aSIgg"}}`
+
+	// Act
+	created := serve(t, server, http.MethodPost, "/me/push-subscriptions", "test-key", browser)
+	fcm := serve(t, server, http.MethodPost, "/me/push-subscriptions", "test-key", `{"type": "fcm", "token": "device-token"}`)
+	listed := serve(t, server, http.MethodGet, "/me/push-subscriptions", "test-key", "")
+	var sub models.PushSubscription
+	_ = json.Unmarshal(created.Body.Bytes(), &sub)
+	deleted := serve(t, server, http.MethodDelete, "/me/push-subscriptions/"+sub.ID, "test-key", "")
+	again := serve(t, server, http.MethodDelete, "/me/push-subscriptions/"+sub.ID, "test-key", "")
+
+	// Assert
+	if created.Code != http.StatusCreated || sub.ID == "" {
+		t.Fatalf("Expected the browser to subscribe, got %d: %s", created.Code, created.Body.String())
+	}
+	if fcm.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for FCM when it isn't configured, got %d", fcm.Code)
+	}
+	var list models.ListPushSubscriptionsOutput
+	_ = json.Unmarshal(listed.Body.Bytes(), &list.Body)
+	if listed.Code != http.StatusOK || len(list.Body.Subscriptions) != 1 || list.Body.VAPIDPublicKey != webPush.PublicKey() {
+		t.Errorf("Expected one subscription and the VAPID key, got %d: %s", listed.Code, listed.Body.String())
+	}
+	if deleted.Code != http.StatusNoContent || again.Code != http.StatusNotFound {
+		t.Errorf("Expected 204 then 404, got %d then %d", deleted.Code, again.Code)
+	}
+
+	t.Logf("✅ Subscribed, listed and unsubscribed %s", sub.ID)
+}
+
+// TestNew_NotificationSettings tests reading the defaults and replacing them
+func TestNew_NotificationSettings(t *testing.T) {
+	// Arrange
+	push.Init(push.NewDispatcher(push.NewMemoryStore(), nil, nil, nil))
+	t.Cleanup(func() { push.Init(nil) })
+	server := newTestApp(t, Options{})
+
+	request := func(method, body string) (int, models.NotificationSettings) {
+		rec := serve(t, server, method, "/me/notification-settings", "test-key", body)
+		var settings models.NotificationSettings
+		_ = json.Unmarshal(rec.Body.Bytes(), &settings)
+		return rec.Code, settings
+	}
+
+	// Act
+	defaultCode, defaults := request(http.MethodGet, "")
+	putCode, _ := request(http.MethodPut, `{"channels": {"email": false, "push": true, "slack": false},
+		"events": {"reminders": true, "assignments": false, "mentions": false}}`)
+	partialCode, _ := request(http.MethodPut, `{"channels": {"push": true}}`)
+	noAddressCode, _ := request(http.MethodPut, `{"channels": {"email": true, "push": true, "slack": false},
+		"events": {"reminders": true, "assignments": true, "mentions": true, "daily_digest": true}}`)
+	_, saved := request(http.MethodGet, "")
+
+	// Assert
+	if defaultCode != http.StatusOK || !defaults.Channels.Push || !defaults.Events.Mentions || defaults.UpdatedAt != nil {
+		t.Errorf("Expected everything on by default, got %d: %+v", defaultCode, defaults)
+	}
+	if putCode != http.StatusOK {
+		t.Errorf("Expected the settings to be replaced, got %d", putCode)
+	}
+	if partialCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for incomplete settings, got %d", partialCode)
+	}
+	if noAddressCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a digest without an address, got %d", noAddressCode)
+	}
+	if saved.Channels.Email || !saved.Channels.Push || saved.Events.Assignments || saved.UpdatedAt == nil {
+		t.Errorf("Expected the saved settings back, got %+v", saved)
+	}
+
+	t.Log("✅ Notification settings replaced")
+}
+
+// TestNew_OrgSettings tests that admins replace the settings, and that the
+// allowed email domains apply to notification settings
+func TestNew_OrgSettings(t *testing.T) {
+	// Arrange
+	settings.Init(settings.New(settings.NewMemoryStore(), 0, nil))
+	push.Init(push.NewDispatcher(push.NewMemoryStore(), nil, nil, nil))
+	t.Cleanup(func() { settings.Init(nil); push.Init(nil) })
+	server := newTestApp(t, Options{})
+
+	notifyAt := func(address string) int {
+		return serve(t, server, http.MethodPut, "/me/notification-settings", "test-key", `{"email": "`+address+`",
+			"channels": {"email": true, "push": true, "slack": false},
+			"events": {"reminders": true, "assignments": true, "mentions": true}}`).Code
+	}
+
+	// Act
+	badDomain := serve(t, server, http.MethodPut, "/admin/settings", "test-key", `{"allowed_email_domains": ["@example.com"]}`)
+	saved := serve(t, server, http.MethodPut, "/admin/settings", "test-key", `{"reminder_lead_minutes": 30, "allowed_tags": ["Work", "work", "home"],
+		"allowed_email_domains": [" Example.com "], "anomaly": {"max_deletions": 200}}`)
+	read := serve(t, server, http.MethodGet, "/admin/settings", "test-key", "")
+	inside, outside := notifyAt("alice@example.com"), notifyAt("alice@gmail.com")
+
+	// Assert
+	if badDomain.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a domain with an @, got %d", badDomain.Code)
+	}
+	var got models.OrgSettings
+	_ = json.Unmarshal(read.Body.Bytes(), &got)
+	if saved.Code != http.StatusOK || got.ReminderLeadMinutes != 30 || !slices.Equal(got.AllowedTags, []string{"work", "home"}) ||
+		!slices.Equal(got.AllowedEmailDomains, []string{"example.com"}) || got.Anomaly.MaxDeletions != 200 || got.UpdatedAt == nil {
+		t.Errorf("Expected the cleaned-up settings back, got %d: %+v", saved.Code, got)
+	}
+	if inside != http.StatusOK || outside != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 200 inside the allowed domain and 422 outside, got %d and %d", inside, outside)
+	}
+
+	t.Log("✅ Organization settings saved and applied")
+}
+
+// TestNew_ProfileLocaleExport tests that the CSV export writes dates in the
+// time zone and locale set at /me/profile, unless Accept-Language asks otherwise
+func TestNew_ProfileLocaleExport(t *testing.T) {
+	// Arrange: alice lives in Tokyo and reads French
+	profile.Init(profile.New(profile.NewMemoryStore()))
+	t.Cleanup(func() { profile.Init(nil) })
+	server := newTestApp(t, Options{TaskRepository: repository.NewMemoryTaskRepository()})
+	_, secret, _ := server.keys.Create(context.Background(), "ci", "alice", apikeys.RoleUser)
+	badLocale := serve(t, server, http.MethodPut, "/me/profile", secret, `{"locale": "ja"}`)
+	saved := serve(t, server, http.MethodPut, "/me/profile", secret, `{"time_zone": "Asia/Tokyo", "locale": "fr-CA"}`)
+	serve(t, server, http.MethodPost, "/tasks", secret, `{"title": "File taxes", "due": "2020-04-15"}`)
+
+	// Act
+	fromProfile := serve(t, server, http.MethodGet, "/export?format=csv", secret, "")
+	fromHeader := serve(t, server, http.MethodGet, "/export?format=csv", secret, "", header("Accept-Language", "en-US"))
+
+	// Assert
+	if badLocale.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for an unsupported locale, got %d", badLocale.Code)
+	}
+	var got models.Profile
+	_ = json.Unmarshal(saved.Body.Bytes(), &got)
+	if saved.Code != http.StatusOK || got.Locale != "fr" {
+		t.Errorf("Expected fr-CA to be saved as fr, got %d: %+v", saved.Code, got)
+	}
+	if body := fromProfile.Body.String(); !strings.Contains(body, ",15/04/2020 23:59") ||
+		fromProfile.Header().Get("Content-Language") != "fr" {
+		t.Errorf("Expected the due date in Tokyo time, the French way, got %q", body)
+	}
+	if body := fromHeader.Body.String(); !strings.Contains(body, ",04/15/2020 11:59 PM") {
+		t.Errorf("Expected Accept-Language to win over the profile, got %q", body)
+	}
+
+	t.Log("✅ CSV export written in the caller's time zone and locale")
+}
+
+// TestNew_ProfileAndDueDates tests that due dates are read in the time zone
+// set at /me/profile, and listed with ?due=today and ?due=overdue
+func TestNew_ProfileAndDueDates(t *testing.T) {
+	// Arrange: alice lives in Tokyo
+	profile.Init(profile.New(profile.NewMemoryStore()))
+	t.Cleanup(func() { profile.Init(nil) })
+	server := newTestApp(t, Options{TaskRepository: repository.NewMemoryTaskRepository()})
+	_, secret, _ := server.keys.Create(context.Background(), "ci", "alice", apikeys.RoleUser)
+	badZone := serve(t, server, http.MethodPut, "/me/profile", secret, `{"time_zone": "Mars/Olympus"}`)
+	saved := serve(t, server, http.MethodPut, "/me/profile", secret, `{"time_zone": "Asia/Tokyo"}`)
+	if saved.Code != http.StatusOK {
+		t.Fatalf("Expected the time zone to be saved, got %d: %s", saved.Code, saved.Body.String())
+	}
+
+	// Act
+	today := serve(t, server, http.MethodPost, "/tasks", secret, `{"title": "Pay rent", "due": "today"}`)
+	serve(t, server, http.MethodPost, "/tasks", secret, `{"title": "Book flights", "due": "tomorrow at 9am"}`)
+	serve(t, server, http.MethodPost, "/tasks", secret, `{"title": "File taxes", "due": "2020-04-15"}`)
+	serve(t, server, http.MethodPost, "/tasks", secret, `{"title": "Someday"}`)
+	badDue := serve(t, server, http.MethodPost, "/tasks", secret, `{"title": "Never", "due": "when pigs fly"}`)
+	dueToday := serve(t, server, http.MethodGet, "/tasks?due=today", secret, "")
+	overdue := serve(t, server, http.MethodGet, "/tasks?due=overdue", secret, "")
+
+	// Assert
+	if badZone.Code != http.StatusUnprocessableEntity || badDue.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for an unknown time zone and due date, got %d and %d", badZone.Code, badDue.Code)
+	}
+	var created models.Task
+	_ = json.Unmarshal(today.Body.Bytes(), &created)
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	if created.DueAt == nil || created.DueAt.In(tokyo).Hour() != 23 || created.DueAt.In(tokyo).Day() != time.Now().In(tokyo).Day() {
+		t.Errorf("Expected \"today\" to be the end of the day in Tokyo, got %v", created.DueAt)
+	}
+	titles := func(rec *httptest.ResponseRecorder) []string {
+		var tasks []models.Task
+		_ = json.Unmarshal(rec.Body.Bytes(), &tasks)
+		var out []string
+		for _, task := range tasks {
+			out = append(out, task.Title)
+		}
+		return out
+	}
+	if got := titles(dueToday); len(got) != 1 || got[0] != "Pay rent" {
+		t.Errorf("Expected only \"Pay rent\" due today, got %v", got)
+	}
+	if got := titles(overdue); len(got) != 1 || got[0] != "File taxes" {
+		t.Errorf("Expected only \"File taxes\" overdue, got %v", got)
+	}
+
+	t.Log("✅ Due dates read in Asia/Tokyo and filtered by ?due=")
+}
+
+// TestNew_UnknownFields tests that unknown body fields are a 422 unless the
+// deployment or the request asks for them to be dropped
+func TestNew_UnknownFields(t *testing.T) {
+	// Arrange: one strict app (the default) and one lenient one
+	strict := newTestApp(t, Options{TaskRepository: repository.NewMemoryTaskRepository()})
+	lenient := newTestApp(t, Options{TaskRepository: repository.NewMemoryTaskRepository(), LenientJSON: true})
+	typo := `{"title": "Buy milk", "descripton": "2 litres"}`
+
+	// Act
+	rejected := serve(t, strict, http.MethodPost, "/tasks", "test-key", typo)
+	askedLenient := serve(t, strict, http.MethodPost, "/tasks", "test-key", typo, header("Prefer", "respond-async, handling=lenient"))
+	ignored := serve(t, lenient, http.MethodPost, "/tasks", "test-key", typo)
+	askedStrict := serve(t, lenient, http.MethodPost, "/tasks", "test-key", typo, header("Prefer", "handling=strict"))
+
+	// Assert
+	var problem huma.ErrorModel
+	_ = json.Unmarshal(rejected.Body.Bytes(), &problem)
+	if rejected.Code != http.StatusUnprocessableEntity || len(problem.Errors) != 1 || problem.Errors[0].Location != "body.descripton" {
+		t.Errorf("Expected a 422 listing body.descripton, got %d: %s", rejected.Code, rejected.Body.String())
+	}
+	if askedLenient.Code != http.StatusCreated || askedLenient.Header().Get("Preference-Applied") != "handling=lenient" {
+		t.Errorf("Expected Prefer: handling=lenient to drop the field, got %d: %s", askedLenient.Code, askedLenient.Body.String())
+	}
+	var task models.Task
+	_ = json.Unmarshal(ignored.Body.Bytes(), &task)
+	if ignored.Code != http.StatusCreated || task.Title != "Buy milk" || task.Description != "" {
+		t.Errorf("Expected the lenient app to create the task without the typo, got %d: %s", ignored.Code, ignored.Body.String())
+	}
+	if askedStrict.Code != http.StatusUnprocessableEntity || askedStrict.Header().Get("Preference-Applied") != "handling=strict" {
+		t.Errorf("Expected Prefer: handling=strict to reject the field, got %d", askedStrict.Code)
+	}
+
+	t.Log("✅ Unknown fields rejected by default, dropped on request")
+}
+
+// TestNew_TaskHooks tests that registered hooks run around creates, updates and deletes
+func TestNew_TaskHooks(t *testing.T) {
+	// Arrange: titles need a ticket number; updates and deletes are recorded
+	registry := hooks.Init(nil)
+	t.Cleanup(func() { hooks.Init(nil) })
+	registry.Register("ticket", hooks.TitlePattern(regexp.MustCompile(`^[A-Z]+-[0-9]+`), "must start with a ticket number"))
+	var updated, deleted []string
+	registry.Register("recorder", hooks.Funcs{
+		AfterUpdateFunc: func(ctx context.Context, task models.Task) error {
+			updated = append(updated, task.Title)
+			return nil
+		},
+		AfterDeleteFunc: func(ctx context.Context, id string) error {
+			deleted = append(deleted, id)
+			return nil
+		},
+	})
+	server := newTestApp(t, Options{TaskRepository: repository.NewMemoryTaskRepository()})
+
+	// Act
+	rejected := serve(t, server, http.MethodPost, "/tasks", "test-key", `{"title": "Rotate keys"}`)
+	created := serve(t, server, http.MethodPost, "/tasks", "test-key", `{"title": "OPS-12 Rotate keys"}`)
+	var task models.Task
+	_ = json.Unmarshal(created.Body.Bytes(), &task)
+	serve(t, server, http.MethodPut, "/tasks/"+task.ID.String(), "test-key", `{"completed": true}`)
+	serve(t, server, http.MethodDelete, "/tasks/"+task.ID.String(), "test-key", "")
+
+	// Assert
+	if rejected.Code != http.StatusUnprocessableEntity || !strings.Contains(rejected.Body.String(), "body.title") {
+		t.Errorf("Expected a 422 on body.title, got %d: %s", rejected.Code, rejected.Body.String())
+	}
+	if created.Code != http.StatusCreated {
+		t.Fatalf("Expected the task to be created, got %d: %s", created.Code, created.Body.String())
+	}
+	if len(updated) != 1 || updated[0] != "OPS-12 Rotate keys" || len(deleted) != 1 || deleted[0] != task.ID.String() {
+		t.Errorf("Expected one update and one delete, got %v and %v", updated, deleted)
+	}
+
+	t.Log("✅ Hooks ran before create, after update and after delete")
+}
+
+// TestNew_ArchivedTasksAndActivity tests turning auto-archiving on and seeing what it moved, and why
+func TestNew_ArchivedTasksAndActivity(t *testing.T) {
+	// Arrange: alice has a task completed 40 days ago
+	ctx := context.Background()
+	profiles := profile.Init(profile.New(profile.NewMemoryStore()))
+	record := activity.Init(activity.NewMemoryStore())
+	tasks := repository.NewMemoryTaskRepository()
+	archiver := archive.Init(archive.New(profiles, tasks, archive.NewMemoryStore(), record))
+	t.Cleanup(func() { profile.Init(nil); activity.Init(nil); archive.Init(nil) })
+	completed := time.Now().AddDate(0, 0, -40)
+	_ = tasks.Create(ctx, &models.Task{Title: "Old and done", OwnerID: "alice", Completed: true, CompletedAt: &completed})
+	server := newTestApp(t, Options{TaskRepository: tasks})
+	_, secret, _ := server.keys.Create(ctx, "ci", "alice", apikeys.RoleUser)
+
+	// Act
+	negative := serve(t, server, http.MethodPut, "/me/profile", secret, `{"auto_archive_days": -1}`)
+	saved := serve(t, server, http.MethodPut, "/me/profile", secret, `{"auto_archive_days": 30}`)
+	users, _ := profiles.AutoArchivers(ctx)
+	for _, user := range users {
+		_, _ = archiver.ArchiveUser(ctx, user.UserID, user.AutoArchiveDays) // What the daily run does
+	}
+	listed := serve(t, server, http.MethodGet, "/me/archived-tasks", secret, "")
+	log := serve(t, server, http.MethodGet, "/me/activity?limit=10", secret, "")
+
+	// Assert
+	if negative.Code != http.StatusUnprocessableEntity || saved.Code != http.StatusOK {
+		t.Fatalf("Expected 422 for -1 days and 200 for 30, got %d and %d", negative.Code, saved.Code)
+	}
+	var archived []models.ArchivedTask
+	_ = json.Unmarshal(listed.Body.Bytes(), &archived)
+	if listed.Code != http.StatusOK || len(archived) != 1 || archived[0].Title != "Old and done" || archived[0].Reason == "" {
+		t.Errorf("Expected the archived task with its reason, got %d: %s", listed.Code, listed.Body.String())
+	}
+	var entries []models.ActivityEntry
+	_ = json.Unmarshal(log.Body.Bytes(), &entries)
+	if log.Code != http.StatusOK || len(entries) != 1 || entries[0].Action != "archived" {
+		t.Errorf("Expected one archived entry, got %d: %s", log.Code, log.Body.String())
+	}
+
+	t.Logf("✅ %s", archived[0].Reason)
+}
+
+// TestNew_RecordingUnderBasePath tests that a new API key isn't recorded
+// when the API is mounted under BASE_PATH
+func TestNew_RecordingUnderBasePath(t *testing.T) {
+	// Arrange: record requests sent with X-Request-ID: ticket-42
+	store := recording.NewMemoryStore()
+	recorder := recording.Init(recording.New(store, nil))
+	t.Cleanup(func() { recording.Init(nil) })
+	_, _ = recorder.Start(recording.Target{RequestID: "ticket-42"}, time.Minute)
+	server := newTestApp(t, Options{BasePath: "/api"})
+
+	// Act
+	created := serve(t, server, http.MethodPost, "/api/admin/apikeys", "test-key", `{"name": "ci", "user_id": "alice"}`,
+		header(middleware.RequestIDHeader, "ticket-42"))
+
+	// Assert
+	if created.Code != http.StatusCreated || !strings.Contains(created.Body.String(), "tk_") {
+		t.Fatalf("Expected the key created, got %d: %s", created.Code, created.Body.String())
+	}
+	saved, _ := store.List(context.Background(), "", 10)
+	if len(saved) != 1 {
+		t.Fatalf("Expected the request recorded, got %d", len(saved))
+	}
+	if got := saved[0]; got.RequestBody != "[redacted]" || got.ResponseBody != "[redacted]" {
+		t.Errorf("Expected both bodies redacted under /api, got %q and %q", got.RequestBody, got.ResponseBody)
+	}
+
+	t.Log("✅ Recorded the key's creation under /api, without the key")
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/testutil"
+)
+
+// These benchmarks encode a large task list the way GET /tasks does, with
+// the standard library and with the encoder this binary was built with
+// (jsonFormat). Run them with and without the gojson build tag to compare:
+//
+//	make bench-json
+//	go test ./internal/app -run '^$' -bench JSON -benchmem
+//	go test ./internal/app -run '^$' -bench JSON -benchmem -tags gojson
+
+// largeTaskList returns n tasks with realistic titles and descriptions
+func largeTaskList(n int) []models.Task {
+	tasks := make([]models.Task, n)
+	for i := range tasks {
+		tasks[i] = testutil.NewTask(
+			testutil.WithID(testutil.TaskID(i)),
+			testutil.WithTitle(fmt.Sprintf("Task %d: follow up with the supplier", i)),
+			testutil.WithDescription("Check the delivery dates, confirm the quantities & update the <shared> sheet"),
+		)
+	}
+	return tasks
+}
+
+// benchmarkEncode measures format.Marshal on a list of 10,000 tasks
+func benchmarkEncode(b *testing.B, format huma.Format) {
+	tasks := largeTaskList(10_000)
+
+	// Report MB/s as well as ns/op
+	var buf bytes.Buffer
+	if err := format.Marshal(&buf, tasks); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(buf.Len()))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := format.Marshal(io.Discard, tasks); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkJSONEncode_Std is the baseline: encoding/json
+func BenchmarkJSONEncode_Std(b *testing.B) {
+	benchmarkEncode(b, huma.DefaultJSONFormat)
+}
+
+// BenchmarkJSONEncode_Selected is the encoder named by JSONEncoder
+func BenchmarkJSONEncode_Selected(b *testing.B) {
+	b.Logf("JSON encoder: %s", JSONEncoder)
+	benchmarkEncode(b, jsonFormat)
+}
+
+// TestJSONFormat_MatchesStd tests that the selected encoder writes exactly
+// what encoding/json writes, so switching can't change a response
+func TestJSONFormat_MatchesStd(t *testing.T) {
+	tasks := largeTaskList(3)
+
+	var std, selected bytes.Buffer
+	if err := huma.DefaultJSONFormat.Marshal(&std, tasks); err != nil {
+		t.Fatal(err)
+	}
+	if err := jsonFormat.Marshal(&selected, tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	if std.String() != selected.String() {
+		t.Errorf("%s output differs from encoding/json:\n%s\n%s", JSONEncoder, selected.String(), std.String())
+	}
+}
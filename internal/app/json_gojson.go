@@ -0,0 +1,27 @@
+//go:build gojson
+
+package app
+
+import (
+	"io"
+
+	"github.com/danielgtaylor/huma/v2"
+	gojson "github.com/goccy/go-json"
+)
+
+// jsonFormat encodes and decodes JSON bodies with github.com/goccy/go-json,
+// a drop-in replacement for encoding/json that is roughly 2x faster at
+// encoding large task lists (make bench-json)
+// It's only compiled in with: go build -tags gojson ./cmd/api
+var jsonFormat = huma.Format{
+	Marshal: func(w io.Writer, v any) error {
+		// Same settings as huma.DefaultJSONFormat, so responses are byte-for-byte equal
+		enc := gojson.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(v)
+	},
+	Unmarshal: gojson.Unmarshal,
+}
+
+// JSONEncoder names the JSON library this binary was built with
+const JSONEncoder = "github.com/goccy/go-json"
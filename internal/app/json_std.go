@@ -0,0 +1,12 @@
+//go:build !gojson
+
+package app
+
+import "github.com/danielgtaylor/huma/v2"
+
+// jsonFormat encodes and decodes JSON bodies with the standard library
+// Build with -tags gojson to use github.com/goccy/go-json instead (json_gojson.go)
+var jsonFormat = huma.DefaultJSONFormat
+
+// JSONEncoder names the JSON library this binary was built with
+const JSONEncoder = "encoding/json"
@@ -0,0 +1,226 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// update rewrites the golden files instead of comparing against them:
+//
+//	go test ./internal/app -run TestOpenAPI_Golden -update
+var update = flag.Bool("update", false, "rewrite testdata golden files")
+
+// goldenOpenAPI is the committed copy of the API contract
+var goldenOpenAPI = filepath.Join("testdata", "openapi.json")
+
+// newContractAPI builds the API the way cmd/api does, admin endpoints included
+func newContractAPI(t *testing.T) huma.API {
+	t.Helper()
+	return newTestApp(t, Options{}).api
+}
+
+// ============================================================================
+// GOLDEN FILE - THE CONTRACT ONLY CHANGES ON PURPOSE
+// ============================================================================
+
+// TestOpenAPI_Golden fails when the generated openapi.json differs from testdata/openapi.json
+// Renaming a field, tightening a validation rule or dropping an endpoint all
+// break clients; this makes such a change show up in review as a diff of the
+// golden file. If the change is intended, run the test with -update.
+func TestOpenAPI_Golden(t *testing.T) {
+	// Arrange
+	api := newContractAPI(t)
+
+	// Act
+	got, err := json.MarshalIndent(api.OpenAPI(), "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal the OpenAPI spec: %v", err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err := os.WriteFile(goldenOpenAPI, got, 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", goldenOpenAPI, err)
+		}
+		t.Logf("✅ Updated %s", goldenOpenAPI)
+		return
+	}
+
+	// Assert
+	want, err := os.ReadFile(goldenOpenAPI)
+	if err != nil {
+		t.Fatalf("Failed to read %s (create it with -update): %v", goldenOpenAPI, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("The OpenAPI contract changed:\n%s\n\nIf this is intended, run: go test ./internal/app -run TestOpenAPI_Golden -update",
+			firstDifference(string(want), string(got)))
+	}
+
+	t.Log("✅ OpenAPI contract unchanged")
+}
+
+// firstDifference shows the first line where the golden file and the new spec differ
+func firstDifference(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return "line " + strconv.Itoa(i+1) + ":\n  golden: " + strings.TrimSpace(w) + "\n  now:    " + strings.TrimSpace(g)
+		}
+	}
+	return "(no line differs)"
+}
+
+// ============================================================================
+// CONTRACT RULES - EVERY OPERATION IS DOCUMENTED PROPERLY
+// ============================================================================
+
+// TestOpenAPI_Contract checks every registered operation declares its error
+// responses and has examples for its parameters, request body and response body
+func TestOpenAPI_Contract(t *testing.T) {
+	// Arrange
+	api := newContractAPI(t)
+	registry := api.OpenAPI().Components.Schemas
+
+	paths := make([]string, 0, len(api.OpenAPI().Paths))
+	for path := range api.OpenAPI().Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	checked := 0
+	for _, path := range paths {
+		for method, op := range operations(api.OpenAPI().Paths[path]) {
+			t.Run(method+" "+path, func(t *testing.T) {
+				// Error responses: at least one explicit 4xx/5xx (Huma's
+				// catch-all "default" response doesn't tell clients anything)
+				if !hasErrorResponse(op) {
+					t.Errorf("%s declares no error responses: set Errors in its huma.Operation", op.OperationID)
+				}
+
+				// Parameters: every path/query/header parameter has an example
+				for _, param := range op.Parameters {
+					if param.Example == nil && len(param.Examples) == 0 && (param.Schema == nil || len(param.Schema.Examples) == 0) {
+						t.Errorf("%s: parameter %q has no example: add an example tag", op.OperationID, param.Name)
+					}
+				}
+
+				// Request body: every field has an example
+				if op.RequestBody != nil {
+					for _, media := range op.RequestBody.Content {
+						for _, field := range fieldsWithoutExamples(registry, media.Schema, "body") {
+							t.Errorf("%s: request %s has no example: add an example tag", op.OperationID, field)
+						}
+					}
+				}
+
+				// Success responses: every field has an example
+				for status, resp := range op.Responses {
+					if !strings.HasPrefix(status, "2") {
+						continue
+					}
+					for _, media := range resp.Content {
+						for _, field := range fieldsWithoutExamples(registry, media.Schema, "body") {
+							t.Errorf("%s: %s response %s has no example: add an example tag", op.OperationID, status, field)
+						}
+					}
+				}
+			})
+			checked++
+		}
+	}
+
+	if checked == 0 {
+		t.Fatal("No operations registered")
+	}
+	t.Logf("✅ Checked %d operations", checked)
+}
+
+// operations returns the operations of a path by HTTP method
+func operations(item *huma.PathItem) map[string]*huma.Operation {
+	ops := map[string]*huma.Operation{}
+	for method, op := range map[string]*huma.Operation{
+		"GET": item.Get, "PUT": item.Put, "POST": item.Post, "DELETE": item.Delete, "PATCH": item.Patch,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// hasErrorResponse reports whether op declares a 4xx or 5xx response
+func hasErrorResponse(op *huma.Operation) bool {
+	for status := range op.Responses {
+		if code, err := strconv.Atoi(status); err == nil && code >= 400 {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldsWithoutExamples lists the scalar fields of a schema (following $refs,
+// arrays and maps) that have no example
+func fieldsWithoutExamples(registry huma.Registry, schema *huma.Schema, name string) []string {
+	return collectMissing(registry, schema, name, map[string]bool{})
+}
+
+func collectMissing(registry huma.Registry, schema *huma.Schema, name string, seen map[string]bool) []string {
+	if schema == nil {
+		return nil
+	}
+	if schema.Ref != "" {
+		// Each component is only checked once, which also stops at self-references
+		if seen[schema.Ref] {
+			return nil
+		}
+		seen[schema.Ref] = true
+		schema = registry.SchemaFromRef(schema.Ref)
+	}
+
+	// An example of the whole value (e.g. a list of strings) covers its parts
+	if len(schema.Examples) > 0 {
+		return nil
+	}
+
+	var missing []string
+	switch schema.Type {
+	case huma.TypeObject:
+		names := make([]string, 0, len(schema.Properties))
+		for prop := range schema.Properties {
+			names = append(names, prop)
+		}
+		sort.Strings(names)
+		for _, prop := range names {
+			// $schema is the link to the JSON schema Huma adds to every body
+			if prop == "$schema" {
+				continue
+			}
+			missing = append(missing, collectMissing(registry, schema.Properties[prop], name+"."+prop, seen)...)
+		}
+		if values, ok := schema.AdditionalProperties.(*huma.Schema); ok {
+			missing = append(missing, collectMissing(registry, values, name+"[*]", seen)...)
+		}
+	case huma.TypeArray:
+		missing = append(missing, collectMissing(registry, schema.Items, name+"[]", seen)...)
+	default:
+		missing = append(missing, name)
+	}
+	return missing
+}
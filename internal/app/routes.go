@@ -0,0 +1,695 @@
+package app
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"go-todo-api/internal/handlers"
+	"go-todo-api/internal/middleware"
+	"go-todo-api/internal/models"
+)
+
+// registerEndpoints registers every API endpoint
+// Each huma.Register() call tells Huma:
+// "When someone makes a [METHOD] request to [PATH], call this [HANDLER]"
+// Huma automatically generates OpenAPI documentation from these registrations
+//
+// Errors lists the error statuses an endpoint can answer with, so clients see
+// them in the docs. 401 is on every one because every route checks the API
+// key; 422 is Huma's validation error (e.g. an ID that isn't 24 characters).
+// TestOpenAPI_Contract fails if an endpoint declares none.
+func registerEndpoints(api huma.API) {
+	// HEALTH CHECK ENDPOINT
+	// GET /health → Returns { "status": "healthy", "message": "...", "database": {...} }
+	// Used to check if the server is running (monitoring tools use this)
+	// Pings MongoDB and returns 503 when it is unreachable
+	huma.Register(api, huma.Operation{
+		OperationID: "get-health",                                     // Unique ID for this operation (used in docs)
+		Method:      http.MethodGet,                                   // HTTP method: GET, POST, PUT, DELETE, etc.
+		Path:        "/health",                                        // URL path: http://localhost:8080/health
+		Summary:     "Health check",                                   // Short description (shows in docs)
+		Description: "Check if the API server is running and healthy", // Long description
+		Tags:        []string{"System"},                               // Groups this endpoint under "System" in docs
+		Errors:      []int{http.StatusUnauthorized},
+		Responses: map[string]*huma.Response{
+			"503": {Description: "MongoDB is unreachable"},
+		},
+	}, handlers.Health) // handlers.Health is the function that handles this request
+
+	// LIVENESS PROBE ENDPOINT
+	// GET /healthz → Returns { "status": "ok" } while the process is up
+	// Kubernetes restarts the container if this stops answering
+	huma.Register(api, huma.Operation{
+		OperationID: "get-liveness",
+		Method:      http.MethodGet,
+		Path:        "/healthz",
+		Summary:     "Liveness probe",
+		Description: "Report that the process is up. Does not check dependencies.",
+		Tags:        []string{"System"},
+		Errors:      []int{http.StatusUnauthorized},
+	}, handlers.Liveness)
+
+	// READINESS PROBE ENDPOINT
+	// GET /readyz → Pings MongoDB, returns 503 if it is unreachable
+	// Load balancers stop sending traffic here until it returns 200 again
+	huma.Register(api, huma.Operation{
+		OperationID: "get-readiness",
+		Method:      http.MethodGet,
+		Path:        "/readyz",
+		Summary:     "Readiness probe",
+		Description: "Check every dependency (MongoDB) and report whether this instance can serve traffic",
+		Tags:        []string{"System"},
+		Errors:      []int{http.StatusUnauthorized},
+		Responses: map[string]*huma.Response{
+			"503": {Description: "A dependency is unavailable"},
+		},
+	}, handlers.Readiness)
+
+	// DEEP HEALTH ENDPOINT
+	// GET /health/details → ok/degraded/down for every registered component
+	huma.Register(api, huma.Operation{
+		OperationID: "get-health-details",
+		Method:      http.MethodGet,
+		Path:        "/health/details",
+		Summary:     "Detailed health",
+		Description: "Report ok/degraded/down for each component (database, background workers) with the last error seen",
+		Tags:        []string{"System"},
+		Errors:      []int{http.StatusUnauthorized},
+		Responses: map[string]*huma.Response{
+			"503": {Description: "At least one component is down"},
+		},
+	}, handlers.HealthDetails)
+
+	// VERSION ENDPOINT
+	// GET /version → git SHA, build time, Go version, feature flags
+	huma.Register(api, huma.Operation{
+		OperationID: "get-version",
+		Method:      http.MethodGet,
+		Path:        "/version",
+		Summary:     "Build information",
+		Description: "Return the version, git commit, build time, Go version and enabled feature flags of the running binary",
+		Tags:        []string{"System"},
+		Errors:      []int{http.StatusUnauthorized},
+	}, handlers.Version)
+
+	// GET ALL TASKS ENDPOINT
+	// GET /tasks → Returns array of all tasks from database
+	huma.Register(api, huma.Operation{
+		OperationID: "list-tasks",
+		Method:      http.MethodGet,
+		Path:        "/tasks",
+		Summary:     "List all tasks",
+		Description: "Retrieve all TODO tasks from the database. ?due=today or ?due=overdue lists only the open tasks " +
+			"due later today or already past due, in your time zone (see /me/profile), soonest first.",
+		Tags:   []string{"Tasks"}, // Groups under "Tasks" section in docs
+		Errors: []int{http.StatusUnauthorized, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+	}, handlers.GetAllTasks)
+
+	// TASK COUNTS ENDPOINT
+	// GET /stats → { "open": 12, "completed": 30, "total": 42, ... }
+	huma.Register(api, huma.Operation{
+		OperationID: "get-task-stats",
+		Method:      http.MethodGet,
+		Path:        "/stats",
+		Summary:     "Count tasks",
+		Description: "Return how many tasks are open and completed. The counts are refreshed whenever tasks change, " +
+			"so this is cheap enough to poll for badges.",
+		Tags:   []string{"Tasks"},
+		Errors: []int{http.StatusUnauthorized, http.StatusInternalServerError},
+	}, handlers.GetStats)
+
+	// GET SINGLE TASK BY ID ENDPOINT
+	// GET /tasks/6900d436e231fdbb964c3c1c → Returns one specific task
+	// {id} in the path means "this is a variable"
+	// The ID from the URL is passed to the handler
+	huma.Register(api, huma.Operation{
+		OperationID: "get-task",
+		Method:      http.MethodGet,
+		Path:        "/tasks/{id}", // {id} = path parameter (captures value from URL)
+		Summary:     "Get a task by ID",
+		Description: "Retrieve a specific task using its unique identifier",
+		Tags:        []string{"Tasks"},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+	}, handlers.GetTaskByID)
+
+	// CREATE NEW TASK ENDPOINT
+	// POST /tasks with body: {"title": "Buy milk", "description": "..."}
+	// Creates a new task in the database
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-task",
+		Method:        http.MethodPost, // POST = create new resource
+		Path:          "/tasks",
+		Summary:       "Create a new task",
+		Description:   "Add a new TODO task to the database. due takes a date or a phrase like \"tomorrow at 5pm\", read in your time zone (see /me/profile).",
+		Tags:          []string{"Tasks"},
+		Errors:        []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		DefaultStatus: http.StatusCreated, // Return 201 Created (not 200 OK)
+	}, handlers.CreateTask)
+
+	// UPDATE EXISTING TASK ENDPOINT
+	// PUT /tasks/6900d436e231fdbb964c3c1c with body: {"completed": true}
+	// Updates an existing task's fields
+	huma.Register(api, huma.Operation{
+		OperationID: "update-task",
+		Method:      http.MethodPut, // PUT = update existing resource
+		Path:        "/tasks/{id}",
+		Summary:     "Update a task",
+		Description: "Update an existing task's title, description, or completion status",
+		Tags:        []string{"Tasks"},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+	}, handlers.UpdateTask)
+
+	// COMPLETE / REOPEN TASK ENDPOINTS
+	// POST /tasks/6900d436e231fdbb964c3c1c/complete (no body)
+	// The most common update, without building a PUT body
+	huma.Register(api, huma.Operation{
+		OperationID: "complete-task",
+		Method:      http.MethodPost,
+		Path:        "/tasks/{id}/complete",
+		Summary:     "Complete a task",
+		Description: "Mark a task as done and stamp completed_at. Completing a task that is already done changes nothing.",
+		Tags:        []string{"Tasks"},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+	}, handlers.CompleteTask)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "reopen-task",
+		Method:      http.MethodPost,
+		Path:        "/tasks/{id}/reopen",
+		Summary:     "Reopen a task",
+		Description: "Mark a completed task as open again and clear completed_at",
+		Tags:        []string{"Tasks"},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+	}, handlers.ReopenTask)
+
+	// DELETE TASK ENDPOINT
+	// DELETE /tasks/6900d436e231fdbb964c3c1c
+	// Removes a task from the database permanently
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-task",
+		Method:      http.MethodDelete, // DELETE = remove resource
+		Path:        "/tasks/{id}",
+		Summary:     "Delete a task",
+		Description: "Remove a task from the database",
+		Tags:        []string{"Tasks"},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+	}, handlers.DeleteTask)
+
+	// SYNC ENDPOINT
+	// POST /sync with the changes an offline client made and its last token
+	// Applies them in order (last write wins) and sends back what it's missing
+	huma.Register(api, huma.Operation{
+		OperationID: "sync-tasks",
+		Method:      http.MethodPost,
+		Path:        "/sync",
+		Summary:     "Sync an offline client",
+		Description: "Apply a client's offline changes in order, each as its own endpoint would (last write wins), with one result per change. " +
+			"Send the token from the previous sync: if anything else changed since, the answer has every task (full: true) to replace the client's copy with. " +
+			"A sync that updates or deletes more tasks than BULK_CONFIRM_ABOVE saves nothing and answers 428 with the tasks it would change; send it again with ?confirm=<how many> to apply it.",
+		Tags:   []string{"Tasks"},
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusUnprocessableEntity, http.StatusPreconditionRequired, http.StatusInternalServerError},
+	}, handlers.Sync)
+
+	// SANDBOX RESET ENDPOINT
+	// POST /sandbox/reset with a sandbox API key → its tasks are all deleted
+	huma.Register(api, huma.Operation{
+		OperationID: "sandbox-reset",
+		Method:      http.MethodPost,
+		Path:        "/sandbox/reset",
+		Summary:     "Reset a sandbox",
+		Description: "Delete every task in the calling sandbox API key's sandbox. Sandbox keys' tasks are kept apart from everyone's real ones; other keys get 403.",
+		Tags:        []string{"Tasks"},
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+	}, handlers.ResetSandbox)
+
+	// EXPORT TASKS ENDPOINT
+	// GET /export → every task, one JSON object per line (NDJSON), streamed
+	// from the database cursor so exports of any size use little memory
+	// GET /export?format=csv → the same as CSV rows, dates in the caller's locale
+	csvExample := "id,title,description,completed,completed_at,due_at\n" +
+		"6900d436e231fdbb964c3c1c,Buy milk,,false,,31/01/2025 17:00\n"
+	huma.Register(api, huma.Operation{
+		OperationID: "export-tasks",
+		Method:      http.MethodGet,
+		Path:        "/export",
+		Summary:     "Export tasks as NDJSON or CSV",
+		Description: "Stream every task (or only completed or incomplete ones) as newline-delimited JSON. " +
+			"Use it instead of GET /tasks for very large lists: nothing is loaded into memory first. " +
+			"With format=csv the tasks are CSV rows for spreadsheets, with dates in your profile's time zone, " +
+			"written the way Accept-Language (or your profile's locale) writes them.",
+		Tags:   []string{"Tasks"},
+		Errors: []int{http.StatusUnauthorized, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		Responses: map[string]*huma.Response{
+			"200": {
+				Description: "One task per line (after a header row, for CSV)",
+				Content: map[string]*huma.MediaType{
+					handlers.NDJSONContentType: {Schema: api.OpenAPI().Components.Schemas.Schema(reflect.TypeOf(models.Task{}), true, "")},
+					handlers.CSVContentType:    {Schema: &huma.Schema{Type: huma.TypeString, Examples: []any{csvExample}}},
+				},
+			},
+		},
+	}, handlers.ExportTasks)
+
+	// GET JOB STATUS ENDPOINT
+	// GET /jobs/6900d436e231fdbb964c3c1c → status of a background job
+	huma.Register(api, huma.Operation{
+		OperationID: "get-job",
+		Method:      http.MethodGet,
+		Path:        "/jobs/{id}",
+		Summary:     "Get a background job",
+		Description: "Report the status, attempts and last error of a background job (webhook delivery, email, import)",
+		Tags:        []string{"Jobs"},
+		Errors:      []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+	}, handlers.GetJob)
+
+	// GET YOUR OWN USAGE ENDPOINT
+	// GET /me/usage → { "user_id": "alice", "tasks": { "used": 42, "limit": 100 } }
+	huma.Register(api, huma.Operation{
+		OperationID: "get-my-usage",
+		Method:      http.MethodGet,
+		Path:        "/me/usage",
+		Summary:     "Get your quota usage",
+		Description: "Report how many tasks you have and how many your quota allows (limit 0 = no limit). " +
+			"Creating a task past the limit returns 403.",
+		Tags:   []string{"Account"},
+		Errors: []int{http.StatusUnauthorized, http.StatusInternalServerError},
+	}, handlers.GetUsage)
+
+	// YOUR PROFILE ENDPOINTS
+	// GET/PUT /me/profile → { "user_id": "alice", "time_zone": "Europe/Paris" }
+	// The time zone decides what "today" means for due dates and the digest
+	huma.Register(api, huma.Operation{
+		OperationID: "get-my-profile",
+		Method:      http.MethodGet,
+		Path:        "/me/profile",
+		Summary:     "Get your profile",
+		Description: "Your time zone (UTC until you set one).",
+		Tags:        []string{"Account"},
+		Errors:      []int{http.StatusUnauthorized, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	}, handlers.GetProfile)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "update-my-profile",
+		Method:      http.MethodPut,
+		Path:        "/me/profile",
+		Summary:     "Replace your profile",
+		Description: "Set your time zone (an IANA name like Europe/Paris). Due dates like \"tomorrow\", ?due=today and " +
+			"the daily digest use it. Existing due dates don't move.",
+		Tags:   []string{"Account"},
+		Errors: []int{http.StatusUnauthorized, http.StatusUnprocessableEntity, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	}, handlers.UpdateProfile)
+
+	// ARCHIVE AND ACTIVITY ENDPOINTS
+	// Completed tasks move to the archive auto_archive_days after completion
+	// (see internal/archive); the activity says when and why
+	huma.Register(api, huma.Operation{
+		OperationID: "list-archived-tasks",
+		Method:      http.MethodGet,
+		Path:        "/me/archived-tasks",
+		Summary:     "List your archived tasks",
+		Description: "Tasks moved out of the task list because auto_archive_days is set in your profile, most recently archived first.",
+		Tags:        []string{"Account"},
+		Errors:      []int{http.StatusUnauthorized, http.StatusUnprocessableEntity, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	}, handlers.ListArchivedTasks)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-my-activity",
+		Method:      http.MethodGet,
+		Path:        "/me/activity",
+		Summary:     "List your activity",
+		Description: "What the server did to your tasks on its own (like archiving them), and why. Newest first.",
+		Tags:        []string{"Account"},
+		Errors:      []int{http.StatusUnauthorized, http.StatusUnprocessableEntity, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	}, handlers.ListActivity)
+
+	// PUSH NOTIFICATION ENDPOINTS
+	// Browsers (Web Push) and mobile apps (FCM) register here to get reminders
+	// and assignments; the job workers send them (see internal/push)
+	huma.Register(api, huma.Operation{
+		OperationID: "list-push-subscriptions",
+		Method:      http.MethodGet,
+		Path:        "/me/push-subscriptions",
+		Summary:     "List your push notification devices",
+		Description: "List the browsers and devices your notifications go to, with the VAPID public key a browser needs to subscribe.",
+		Tags:        []string{"Notifications"},
+		Errors:      []int{http.StatusUnauthorized, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	}, handlers.ListPushSubscriptions)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "create-push-subscription",
+		Method:      http.MethodPost,
+		Path:        "/me/push-subscriptions",
+		Summary:     "Subscribe a device to push notifications",
+		Description: "Register a browser's Web Push subscription (PushSubscription.toJSON() plus \"type\": \"webpush\") " +
+			"or a Firebase Cloud Messaging token. Subscribing the same device again replaces it.",
+		Tags:          []string{"Notifications"},
+		Errors:        []int{http.StatusUnauthorized, http.StatusUnprocessableEntity, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		DefaultStatus: http.StatusCreated,
+	}, handlers.CreatePushSubscription)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-push-subscription",
+		Method:        http.MethodDelete,
+		Path:          "/me/push-subscriptions/{id}",
+		Summary:       "Unsubscribe a device",
+		Tags:          []string{"Notifications"},
+		Errors:        []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		DefaultStatus: http.StatusNoContent,
+	}, handlers.DeletePushSubscription)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "test-push-notification",
+		Method:        http.MethodPost,
+		Path:          "/me/push-subscriptions/test",
+		Summary:       "Send yourself a test notification",
+		Description:   "Queue a test notification to every device you've subscribed. Poll GET /jobs/{id} for the outcome.",
+		Tags:          []string{"Notifications"},
+		Errors:        []int{http.StatusUnauthorized, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		DefaultStatus: http.StatusAccepted,
+	}, handlers.TestPushNotification)
+
+	// NOTIFICATION SETTINGS
+	// Which channels and events a user wants; checked before anything is sent
+	huma.Register(api, huma.Operation{
+		OperationID: "get-notification-settings",
+		Method:      http.MethodGet,
+		Path:        "/me/notification-settings",
+		Summary:     "Get your notification settings",
+		Description: "Which channels you're notified on, and about what. Everything is on until you change it.",
+		Tags:        []string{"Notifications"},
+		Errors:      []int{http.StatusUnauthorized, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	}, handlers.GetNotificationSettings)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "update-notification-settings",
+		Method:      http.MethodPut,
+		Path:        "/me/notification-settings",
+		Summary:     "Replace your notification settings",
+		Description: "Choose the channels (email, push, Slack) and events (reminders, assignments, mentions) you're notified about. " +
+			"Applies to notifications not yet sent. Only push is delivered today.",
+		Tags:   []string{"Notifications"},
+		Errors: []int{http.StatusUnauthorized, http.StatusUnprocessableEntity, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	}, handlers.UpdateNotificationSettings)
+}
+
+// registerAdminEndpoints registers the operational /admin/* endpoints
+// cmd/api serves them on the admin listener (see NewAdmin); Lambda has no
+// second port, so it keeps them on the public API behind the API key
+func registerAdminEndpoints(api huma.API) {
+	// Only admins: the shared API_KEY, admin keys, or a JWT with the admin scope
+	adminOnly := huma.Middlewares{middleware.RequireAdmin(api)}
+
+	// ADMIN: READ LOG LEVEL
+	// GET /admin/log-level → { "level": "info" }
+	huma.Register(api, huma.Operation{
+		OperationID: "get-log-level",
+		Method:      http.MethodGet,
+		Path:        "/admin/log-level",
+		Summary:     "Get log level",
+		Description: "Return the minimum level the logger is currently writing",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	}, handlers.GetLogLevel)
+
+	// ADMIN: CHANGE LOG LEVEL
+	// PUT /admin/log-level with body: {"level": "debug"}
+	// Switches logging verbosity at runtime without a redeploy
+	huma.Register(api, huma.Operation{
+		OperationID: "set-log-level",
+		Method:      http.MethodPut,
+		Path:        "/admin/log-level",
+		Summary:     "Set log level",
+		Description: "Change the minimum log level (debug, info, warn, error) for this process until it restarts",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity},
+	}, handlers.SetLogLevel)
+
+	// ADMIN: ORGANIZATION SETTINGS
+	// GET /admin/settings → defaults and limits for every user
+	huma.Register(api, huma.Operation{
+		OperationID: "get-settings",
+		Method:      http.MethodGet,
+		Path:        "/admin/settings",
+		Summary:     "Get organization settings",
+		Description: "Return the organization-wide settings: reminder lead time, allowed tags, retention period and allowed email domains",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	}, handlers.GetOrgSettings)
+
+	// PUT /admin/settings with body: {"allowed_email_domains": ["example.com"]}
+	huma.Register(api, huma.Operation{
+		OperationID: "set-settings",
+		Method:      http.MethodPut,
+		Path:        "/admin/settings",
+		Summary:     "Replace organization settings",
+		Description: "Replace every organization-wide setting (fields left out go back to their defaults). Other instances pick the change up within 30 seconds.",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	}, handlers.SetOrgSettings)
+
+	// ADMIN: SLO REPORT
+	// GET /admin/slo → p50/p95/p99 and error budget burn per operation
+	huma.Register(api, huma.Operation{
+		OperationID: "get-slo-report",
+		Method:      http.MethodGet,
+		Path:        "/admin/slo",
+		Summary:     "SLO report",
+		Description: "Latency percentiles and error-budget burn per operation against the configured objectives",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	}, handlers.SLOReport)
+
+	// ADMIN: DEPRECATED ENDPOINT USAGE
+	// GET /admin/deprecations → who still calls operations marked with
+	// middleware.Deprecate
+	huma.Register(api, huma.Operation{
+		OperationID: "get-deprecations",
+		Method:      http.MethodGet,
+		Path:        "/admin/deprecations",
+		Summary:     "Deprecated endpoint usage",
+		Description: "Count calls to deprecated operations per caller on this instance, to see who still has to move before they're removed",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	}, handlers.Deprecations)
+
+	// ADMIN: SCHEDULER STATUS
+	// GET /admin/scheduler → periodic tasks, their runs, failures and next tick
+	huma.Register(api, huma.Operation{
+		OperationID: "get-scheduler-status",
+		Method:      http.MethodGet,
+		Path:        "/admin/scheduler",
+		Summary:     "Scheduler status",
+		Description: "List periodic tasks with their schedule, run and failure counts on this instance, last error and next run",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	}, handlers.SchedulerStatus)
+
+	// ADMIN: DATABASE CONNECTION POOL
+	// GET /admin/db-pool → checkouts, wait time and connection churn
+	huma.Register(api, huma.Operation{
+		OperationID: "get-db-pool",
+		Method:      http.MethodGet,
+		Path:        "/admin/db-pool",
+		Summary:     "Database connection pool",
+		Description: "MongoDB connection checkouts, time spent waiting for a connection and connections opened and closed on this instance",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden},
+	}, handlers.DBPool)
+
+	registerAPIKeyEndpoints(api, adminOnly)
+	registerRecordingEndpoints(api, adminOnly)
+}
+
+// registerAPIKeyEndpoints registers /admin/apikeys and /admin/users
+// Operators use them to hand out keys and to lock out an abusive one
+// without touching MongoDB
+func registerAPIKeyEndpoints(api huma.API, adminOnly huma.Middlewares) {
+	keyErrors := []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusServiceUnavailable}
+
+	// GET /admin/apikeys?user_id=alice → the keys (never the secrets)
+	huma.Register(api, huma.Operation{
+		OperationID: "list-api-keys",
+		Method:      http.MethodGet,
+		Path:        "/admin/apikeys",
+		Summary:     "List API keys",
+		Description: "List managed API keys, optionally only one user's",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusServiceUnavailable},
+	}, handlers.ListAPIKeys)
+
+	// POST /admin/apikeys with body: {"name": "mobile app", "user_id": "alice"}
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-api-key",
+		Method:        http.MethodPost,
+		Path:          "/admin/apikeys",
+		Summary:       "Create an API key",
+		Description:   "Create a key for a user; the response is the only time the key itself is shown",
+		Tags:          []string{"Admin"},
+		DefaultStatus: http.StatusCreated,
+		Middlewares:   adminOnly,
+		Errors:        []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity, http.StatusServiceUnavailable},
+	}, handlers.CreateAPIKey)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "disable-api-key",
+		Method:      http.MethodPost,
+		Path:        "/admin/apikeys/{id}/disable",
+		Summary:     "Disable an API key",
+		Description: "Refuse requests with the key (403) until it's enabled again",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      keyErrors,
+	}, handlers.DisableAPIKey)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "enable-api-key",
+		Method:      http.MethodPost,
+		Path:        "/admin/apikeys/{id}/enable",
+		Summary:     "Enable an API key",
+		Description: "Accept a disabled key again",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      keyErrors,
+	}, handlers.EnableAPIKey)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-api-key",
+		Method:      http.MethodDelete,
+		Path:        "/admin/apikeys/{id}",
+		Summary:     "Delete an API key",
+		Description: "Delete a key for good",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      keyErrors,
+	}, handlers.DeleteAPIKey)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "reset-api-key-limits",
+		Method:      http.MethodPost,
+		Path:        "/admin/apikeys/{id}/reset-limits",
+		Summary:     "Reset an API key's rate limit",
+		Description: "Forget the rate limit counts of the client IPs the key was last used from on this instance",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      keyErrors,
+	}, handlers.ResetAPIKeyLimits)
+
+	// GET /admin/users → everyone with keys
+	huma.Register(api, huma.Operation{
+		OperationID: "list-users",
+		Method:      http.MethodGet,
+		Path:        "/admin/users",
+		Summary:     "List users",
+		Description: "List the users who have API keys, with how many are disabled",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusServiceUnavailable},
+	}, handlers.ListUsers)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "disable-user",
+		Method:      http.MethodPost,
+		Path:        "/admin/users/{id}/disable",
+		Summary:     "Disable a user",
+		Description: "Disable every API key of the user",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      keyErrors,
+	}, handlers.DisableUser)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "enable-user",
+		Method:      http.MethodPost,
+		Path:        "/admin/users/{id}/enable",
+		Summary:     "Enable a user",
+		Description: "Enable every API key of the user",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      keyErrors,
+	}, handlers.EnableUser)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-user",
+		Method:      http.MethodDelete,
+		Path:        "/admin/users/{id}",
+		Summary:     "Delete a user",
+		Description: "Delete every API key of the user",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      keyErrors,
+	}, handlers.DeleteUser)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "reset-user-limits",
+		Method:      http.MethodPost,
+		Path:        "/admin/users/{id}/reset-limits",
+		Summary:     "Reset a user's rate limits",
+		Description: "Forget the rate limit counts of the client IPs the user's keys were last used from on this instance",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      keyErrors,
+	}, handlers.ResetUserLimits)
+}
+
+// registerRecordingEndpoints registers /admin/recordings
+// Support uses them to see exactly what a client sent and got back
+func registerRecordingEndpoints(api huma.API, adminOnly huma.Middlewares) {
+	recordingErrors := []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusServiceUnavailable}
+
+	// POST /admin/recordings/targets with body: {"api_key_id": "...", "minutes": 15}
+	huma.Register(api, huma.Operation{
+		OperationID: "start-recording",
+		Method:      http.MethodPost,
+		Path:        "/admin/recordings/targets",
+		Summary:     "Start recording a caller",
+		Description: "Save the full requests and responses of one API key, or of requests sent with one X-Request-ID, on this instance for the next few minutes. Secret headers, and the bodies of /admin/apikeys requests (which carry new keys), are redacted.",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      append(recordingErrors, http.StatusUnprocessableEntity),
+	}, handlers.StartRecording)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-recording-targets",
+		Method:      http.MethodGet,
+		Path:        "/admin/recordings/targets",
+		Summary:     "List recorded callers",
+		Description: "The callers being recorded on this instance",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      recordingErrors,
+	}, handlers.ListRecordingTargets)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "stop-recording",
+		Method:      http.MethodDelete,
+		Path:        "/admin/recordings/targets/{id}",
+		Summary:     "Stop recording a caller",
+		Description: "Stop recording early; what was recorded is kept",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      append(recordingErrors, http.StatusNotFound),
+	}, handlers.StopRecording)
+
+	// GET /admin/recordings?target_id=... → the exchanges, newest first
+	huma.Register(api, huma.Operation{
+		OperationID: "list-recordings",
+		Method:      http.MethodGet,
+		Path:        "/admin/recordings",
+		Summary:     "List recordings",
+		Description: "Recorded requests and responses, newest first. The oldest are dropped once the store is full.",
+		Tags:        []string{"Admin"},
+		Middlewares: adminOnly,
+		Errors:      append(recordingErrors, http.StatusInternalServerError),
+	}, handlers.ListRecordings)
+}
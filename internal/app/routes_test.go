@@ -0,0 +1,1046 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/handlers"
+	"go-todo-api/internal/hooks"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/testutil"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+// These tests send real HTTP requests through Huma (routing, parameter and
+// body validation, status codes, error responses) to the task handlers,
+// which use an in-memory repository instead of MongoDB
+
+// newTaskAPI registers every endpoint on a test API whose handlers use repo
+func newTaskAPI(t *testing.T, repo repository.TaskRepository) humatest.TestAPI {
+	t.Helper()
+	logger.Init()
+
+	_, api := humatest.New(t)
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		next(huma.WithContext(ctx, handlers.WithTaskRepository(ctx.Context(), repo)))
+	})
+	registerEndpoints(api)
+	return api
+}
+
+// seedTask adds a task to the repository and returns its ID
+func seedTask(t *testing.T, repo repository.TaskRepository, opts ...testutil.TaskOption) string {
+	t.Helper()
+	return testutil.CreateTasks(t, repo, testutil.NewTask(opts...))[0].ID.String()
+}
+
+// decode reads a JSON response body into target
+func decode(t *testing.T, body string, target any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(body), target); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", body, err)
+	}
+}
+
+// failingRepository fails every call, like a repository whose database is down
+type failingRepository struct{}
+
+var errUnreachable = errors.New("connection refused")
+
+func (failingRepository) List(context.Context, *bool, repository.Fields, repository.Page) ([]models.Task, error) {
+	return nil, errUnreachable
+}
+func (failingRepository) Count(context.Context, *bool) (int64, error) {
+	return 0, errUnreachable
+}
+func (failingRepository) Stream(context.Context, *bool) (iter.Seq2[models.Task, error], error) {
+	return nil, errUnreachable
+}
+func (failingRepository) Stats(context.Context) (models.TaskStats, error) {
+	return models.TaskStats{}, errUnreachable
+}
+func (failingRepository) CountOwned(context.Context, string) (int64, error) {
+	return 0, errUnreachable
+}
+func (failingRepository) ListDue(context.Context, time.Time, time.Time, repository.Fields, repository.Page) ([]models.Task, error) {
+	return nil, errUnreachable
+}
+func (failingRepository) CountDue(context.Context, time.Time, time.Time) (int64, error) {
+	return 0, errUnreachable
+}
+func (failingRepository) ListOwned(context.Context, string) ([]models.Task, error) {
+	return nil, errUnreachable
+}
+func (failingRepository) Get(context.Context, models.TaskID) (*models.Task, error) {
+	return nil, errUnreachable
+}
+func (failingRepository) Create(context.Context, *models.Task) error { return errUnreachable }
+func (failingRepository) Update(context.Context, models.TaskID, repository.TaskChanges) (*models.Task, error) {
+	return nil, errUnreachable
+}
+func (failingRepository) Delete(context.Context, models.TaskID) error { return errUnreachable }
+func (failingRepository) Version(context.Context) (int64, error)      { return 0, errUnreachable }
+
+// ============================================================================
+// LIST TASKS - GET /tasks
+// ============================================================================
+
+// TestTasksAPI_List tests listing, filtering and filter validation
+func TestTasksAPI_List(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+
+	// Act + Assert: empty list is [] (not null)
+	resp := api.Get("/tasks")
+	if resp.Code != http.StatusOK || strings.TrimSpace(resp.Body.String()) != "[]" {
+		t.Errorf("Expected 200 [], got %d %s", resp.Code, resp.Body.String())
+	}
+
+	seedTask(t, repo, testutil.WithTitle("Open"))
+	seedTask(t, repo, testutil.WithTitle("Done"), testutil.Completed())
+
+	resp = api.Get("/tasks")
+	var all []models.Task
+	decode(t, resp.Body.String(), &all)
+	if resp.Code != http.StatusOK || len(all) != 2 {
+		t.Errorf("Expected 200 with 2 tasks, got %d with %d", resp.Code, len(all))
+	}
+
+	resp = api.Get("/tasks?completed=true")
+	var done []models.Task
+	decode(t, resp.Body.String(), &done)
+	if len(done) != 1 || done[0].Title != "Done" {
+		t.Errorf("Expected only 'Done', got %+v", done)
+	}
+
+	resp = api.Get("/tasks?completed=false")
+	var open []models.Task
+	decode(t, resp.Body.String(), &open)
+	if len(open) != 1 || open[0].Title != "Open" {
+		t.Errorf("Expected only 'Open', got %+v", open)
+	}
+
+	// Not a boolean → 422, not the unfiltered list
+	for _, value := range []string{"maybe", "yes"} {
+		resp = api.Get("/tasks?completed=" + value)
+		if resp.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected 422 for completed=%s, got %d", value, resp.Code)
+		}
+	}
+
+	t.Log("✅ GET /tasks passed")
+}
+
+// TestTasksAPI_ListInclude tests that lists leave descriptions out unless
+// ?include=description asks for them, with a different ETag for each
+func TestTasksAPI_ListInclude(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+	seedTask(t, repo, testutil.WithTitle("Buy milk"), testutil.WithDescription("Two litres"))
+
+	// Act + Assert: the summary has no description at all
+	summary := api.Get("/tasks")
+	if strings.Contains(summary.Body.String(), "description") {
+		t.Errorf("Expected no description in the summary list, got %s", summary.Body.String())
+	}
+
+	full := api.Get("/tasks?include=description")
+	var tasks []models.Task
+	decode(t, full.Body.String(), &tasks)
+	if len(tasks) != 1 || tasks[0].Description != "Two litres" {
+		t.Errorf("Expected the description with include=description, got %+v", tasks)
+	}
+
+	// The two are different representations, so a cache mustn't mix them up
+	if summary.Header().Get("ETag") == full.Header().Get("ETag") {
+		t.Errorf("Expected different ETags, both %s", summary.Header().Get("ETag"))
+	}
+
+	// Only known fields can be asked for
+	if resp := api.Get("/tasks?include=subtasks"); resp.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for include=subtasks, got %d", resp.Code)
+	}
+
+	t.Logf("✅ Summary %d bytes, with descriptions %d bytes", summary.Body.Len(), full.Body.Len())
+}
+
+// slowQueryRepository is a repository whose lists MongoDB stops at maxTimeMS
+type slowQueryRepository struct {
+	*repository.MemoryTaskRepository
+}
+
+func (slowQueryRepository) List(context.Context, *bool, repository.Fields, repository.Page) ([]models.Task, error) {
+	return nil, errors.Join(repository.ErrQueryTimeout, errors.New("operation exceeded time limit"))
+}
+
+// TestTasksAPI_ListLimits tests that a list over the query guardrails is a
+// 422 or 504 saying what to do instead, and that exports aren't capped
+func TestTasksAPI_ListLimits(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	repo.SetLimits(repository.Limits{MaxResults: 2})
+	api := newTaskAPI(t, repo)
+	seedTask(t, repo, testutil.WithTitle("Open"))
+	seedTask(t, repo, testutil.WithTitle("Also open"))
+	seedTask(t, repo, testutil.WithTitle("Done"), testutil.Completed())
+
+	// Act
+	tooMany := api.Get("/tasks")
+	narrowed := api.Get("/tasks?completed=true")
+	paged := api.Get("/tasks?limit=2&offset=2")
+	exported := api.Get("/export")
+	slow := newTaskAPI(t, slowQueryRepository{repository.NewMemoryTaskRepository()}).Get("/tasks")
+
+	// Assert
+	if tooMany.Code != http.StatusUnprocessableEntity || !strings.Contains(tooMany.Body.String(), "GET /export") {
+		t.Errorf("Expected 422 pointing at GET /export, got %d %s", tooMany.Code, tooMany.Body.String())
+	}
+	if narrowed.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a list within the limit, got %d", narrowed.Code)
+	}
+	if paged.Code != http.StatusOK || paged.Header().Get("X-Total-Count") != "3" {
+		t.Errorf("Expected 200 with X-Total-Count 3 for a page of a list over the limit, got %d %q",
+			paged.Code, paged.Header().Get("X-Total-Count"))
+	}
+	if tasks := exportedLines(t, exported.Body.String()); len(tasks) != 3 {
+		t.Errorf("Expected all 3 tasks exported, got %d", len(tasks))
+	}
+	if slow.Code != http.StatusGatewayTimeout || !strings.Contains(slow.Body.String(), "?completed=") {
+		t.Errorf("Expected 504 with guidance, got %d %s", slow.Code, slow.Body.String())
+	}
+
+	t.Log("✅ Query guardrails passed")
+}
+
+// ============================================================================
+// GET TASK - GET /tasks/{id}
+// ============================================================================
+
+// TestTasksAPI_Get tests fetching one task and the ID checks
+func TestTasksAPI_Get(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+	id := seedTask(t, repo, testutil.WithTitle("Buy milk"))
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"existing task", "/tasks/" + id, http.StatusOK},
+		{"unknown ID", "/tasks/" + testutil.TaskID(1).String(), http.StatusNotFound},
+		{"short ID", "/tasks/123", http.StatusUnprocessableEntity},            // minLength:"24"
+		{"not hex", "/tasks/zzzzzzzzzzzzzzzzzzzzzzzz", http.StatusBadRequest}, // right length, not an ObjectID
+		{"long ID", "/tasks/" + id + "0", http.StatusUnprocessableEntity},     // maxLength:"24"
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			resp := api.Get(tt.path)
+
+			// Assert
+			if resp.Code != tt.want {
+				t.Errorf("GET %s: expected %d, got %d: %s", tt.path, tt.want, resp.Code, resp.Body.String())
+			}
+		})
+	}
+
+	var task models.Task
+	decode(t, api.Get("/tasks/"+id).Body.String(), &task)
+	if task.Title != "Buy milk" || task.ID.String() != id {
+		t.Errorf("Expected the seeded task, got %+v", task)
+	}
+}
+
+// TestTasksAPI_GetRenderHTML tests ?render=html, and that the HTML is only
+// added when asked for
+func TestTasksAPI_GetRenderHTML(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+	id := seedTask(t, repo, testutil.WithDescription("Buy **oat** milk <script>alert(1)</script>"))
+
+	// Act
+	var rendered, plain models.Task
+	decode(t, api.Get("/tasks/"+id+"?render=html").Body.String(), &rendered)
+	decode(t, api.Get("/tasks/"+id).Body.String(), &plain)
+	invalid := api.Get("/tasks/" + id + "?render=pdf")
+
+	// Assert
+	want := "<p>Buy <strong>oat</strong> milk &lt;script&gt;alert(1)&lt;/script&gt;</p>\n"
+	if rendered.DescriptionHTML != want || rendered.Description != plain.Description {
+		t.Errorf("Expected %q next to the Markdown, got %q", want, rendered.DescriptionHTML)
+	}
+	if plain.DescriptionHTML != "" {
+		t.Errorf("Expected no HTML without render=html, got %q", plain.DescriptionHTML)
+	}
+	if invalid.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for render=pdf, got %d", invalid.Code)
+	}
+
+	t.Log("✅ GET /tasks/{id}?render=html passed")
+}
+
+// ============================================================================
+// CREATE TASK - POST /tasks
+// ============================================================================
+
+// TestTasksAPI_Create tests creating a task and body validation
+func TestTasksAPI_Create(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+
+	// Act + Assert: valid body → 201 with the new task
+	resp := api.Post("/tasks", map[string]any{"title": "Write tests", "description": "With humatest"})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var created models.Task
+	decode(t, resp.Body.String(), &created)
+	if created.ID.IsZero() || created.Title != "Write tests" || created.Completed {
+		t.Errorf("Expected a new incomplete task with an ID, got %+v", created)
+	}
+	if want := "/tasks/" + created.ID.String(); resp.Header().Get("Location") != want {
+		t.Errorf("Expected Location %s, got %q", want, resp.Header().Get("Location"))
+	}
+
+	tests := []struct {
+		name string
+		body any
+		want int
+	}{
+		{"empty title", map[string]any{"title": ""}, http.StatusUnprocessableEntity},
+		{"missing title", map[string]any{"description": "No title"}, http.StatusUnprocessableEntity},
+		{"title too long", map[string]any{"title": strings.Repeat("x", 201)}, http.StatusUnprocessableEntity},
+		{"wrong type", map[string]any{"title": 42}, http.StatusUnprocessableEntity},
+		{"bad JSON", strings.NewReader(`{"title": "unclosed`), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := api.Post("/tasks", tt.body)
+			if resp.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, resp.Code, resp.Body.String())
+			}
+		})
+	}
+
+	// Only the valid request created a task
+	if tasks, _ := repo.List(context.Background(), nil, repository.Fields{}, repository.Page{}); len(tasks) != 1 {
+		t.Errorf("Expected 1 task after the invalid requests, got %d", len(tasks))
+	}
+
+	t.Log("✅ POST /tasks passed")
+}
+
+// ============================================================================
+// UPDATE TASK - PUT /tasks/{id}
+// ============================================================================
+
+// TestTasksAPI_Update tests partial updates and their errors
+func TestTasksAPI_Update(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+	id := seedTask(t, repo, testutil.WithTitle("Buy milk"))
+
+	// Act + Assert: only "completed" is sent, the title stays the same
+	resp := api.Put("/tasks/"+id, map[string]any{"completed": true})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var updated models.Task
+	decode(t, resp.Body.String(), &updated)
+	if !updated.Completed || updated.Title != "Buy milk" {
+		t.Errorf("Expected completed 'Buy milk', got %+v", updated)
+	}
+
+	// "description": "" clears it; leaving it out (or null) keeps it
+	describedID := seedTask(t, repo, testutil.WithTitle("Call Sam"), testutil.WithDescription("About the lease"))
+	resp = api.Put("/tasks/"+describedID, map[string]any{"description": nil, "completed": true})
+	decode(t, resp.Body.String(), &updated)
+	if updated.Description != "About the lease" || !updated.Completed {
+		t.Errorf("Expected the description kept when it isn't sent, got %+v", updated)
+	}
+	resp = api.Put("/tasks/"+describedID, map[string]any{"description": ""})
+	var cleared models.Task
+	decode(t, resp.Body.String(), &cleared)
+	if resp.Code != http.StatusOK || cleared.Description != "" || !cleared.Completed || cleared.Title != "Call Sam" {
+		t.Errorf("Expected an empty description to clear it and keep the rest, got %d %+v", resp.Code, cleared)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		body any
+		want int
+	}{
+		{"no fields", "/tasks/" + id, map[string]any{}, http.StatusBadRequest},
+		{"empty title", "/tasks/" + id, map[string]any{"title": ""}, http.StatusUnprocessableEntity},
+		{"bad JSON", "/tasks/" + id, strings.NewReader(`{"completed": tru`), http.StatusBadRequest},
+		{"unknown ID", "/tasks/" + testutil.TaskID(1).String(), map[string]any{"completed": true}, http.StatusNotFound},
+		{"short ID", "/tasks/123", map[string]any{"completed": true}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := api.Put(tt.path, tt.body)
+			if resp.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, resp.Code, resp.Body.String())
+			}
+		})
+	}
+
+	t.Log("✅ PUT /tasks/{id} passed")
+}
+
+// ============================================================================
+// COMPLETE / REOPEN - POST /tasks/{id}/complete, POST /tasks/{id}/reopen
+// ============================================================================
+
+// TestTasksAPI_CompleteReopen tests that completing stamps completed_at (once),
+// reopening clears it, and both run the AfterUpdate hooks
+func TestTasksAPI_CompleteReopen(t *testing.T) {
+	// Arrange
+	var updates []models.Task
+	registry := hooks.Init(nil)
+	t.Cleanup(func() { hooks.Init(nil) })
+	registry.Register("recorder", hooks.Funcs{AfterUpdateFunc: func(_ context.Context, task models.Task) error {
+		updates = append(updates, task)
+		return nil
+	}})
+
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+	id := seedTask(t, repo, testutil.WithTitle("Buy milk"))
+
+	// Act + Assert: completing stamps completed_at
+	resp := api.Post("/tasks/" + id + "/complete")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var completed models.Task
+	decode(t, resp.Body.String(), &completed)
+	if !completed.Completed || completed.CompletedAt == nil || completed.Title != "Buy milk" {
+		t.Fatalf("Expected a completed task with completed_at, got %+v", completed)
+	}
+
+	// Completing it again keeps the first stamp
+	resp = api.Post("/tasks/" + id + "/complete")
+	var again models.Task
+	decode(t, resp.Body.String(), &again)
+	if again.CompletedAt == nil || !again.CompletedAt.Equal(*completed.CompletedAt) {
+		t.Errorf("Expected completed_at %v kept, got %v", completed.CompletedAt, again.CompletedAt)
+	}
+
+	// Reopening clears it
+	resp = api.Post("/tasks/" + id + "/reopen")
+	var reopened models.Task
+	decode(t, resp.Body.String(), &reopened)
+	if resp.Code != http.StatusOK || reopened.Completed || reopened.CompletedAt != nil {
+		t.Errorf("Expected an open task without completed_at, got %d %+v", resp.Code, reopened)
+	}
+
+	if len(updates) != 3 || !updates[0].Completed || updates[2].Completed {
+		t.Errorf("Expected AfterUpdate for each of the 3 changes, got %+v", updates)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"unknown ID", "/tasks/" + testutil.TaskID(1).String() + "/complete", http.StatusNotFound},
+		{"short ID", "/tasks/123/reopen", http.StatusUnprocessableEntity},
+		{"bad ID", "/tasks/zzzzzzzzzzzzzzzzzzzzzzzz/complete", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := api.Post(tt.path); resp.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, resp.Code, resp.Body.String())
+			}
+		})
+	}
+
+	t.Log("✅ POST /tasks/{id}/complete and /reopen passed")
+}
+
+// ============================================================================
+// SYNC - POST /sync
+// ============================================================================
+
+// syncResponse is the body of a POST /sync answer
+type syncResponse struct {
+	Token   string              `json:"token"`
+	Results []models.SyncResult `json:"results"`
+	Full    bool                `json:"full"`
+	Tasks   []models.Task       `json:"tasks"`
+}
+
+// TestTasksAPI_Sync tests a first sync, an up-to-date one with changes, and
+// one that has to catch up on someone else's change
+func TestTasksAPI_Sync(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+	id := seedTask(t, repo, testutil.WithTitle("Buy milk"))
+	goneID := seedTask(t, repo, testutil.WithTitle("Old task"))
+
+	// Act + Assert: a first sync (no token) gets every task
+	var first syncResponse
+	resp := api.Post("/sync", map[string]any{"changes": []any{}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	decode(t, resp.Body.String(), &first)
+	if !first.Full || len(first.Tasks) != 2 || first.Token == "" {
+		t.Fatalf("Expected every task and a token on a first sync, got %+v", first)
+	}
+
+	// The server deletes a task while the client is offline...
+	api.Delete("/tasks/" + goneID)
+
+	// ...and the client, offline, completed one, created one and changed the deleted one
+	resp = api.Post("/sync", map[string]any{
+		"token": first.Token,
+		"changes": []map[string]any{
+			{"op": "update", "id": id, "completed": true},
+			{"op": "create", "client_id": "local-1", "title": "Call Sam", "completed": true},
+			{"op": "update", "id": goneID, "title": "Renamed"},
+			{"op": "create", "client_id": "local-2"},
+		},
+	})
+	var second syncResponse
+	decode(t, resp.Body.String(), &second)
+	if resp.Code != http.StatusOK || len(second.Results) != 4 {
+		t.Fatalf("Expected 200 with 4 results, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if r := second.Results[0]; r.Status != http.StatusOK || r.Task == nil || !r.Task.Completed {
+		t.Errorf("Expected the update applied, got %+v", r)
+	}
+	if r := second.Results[1]; r.Status != http.StatusCreated || r.ClientID != "local-1" || r.ID == "" || r.Task == nil || !r.Task.Completed {
+		t.Errorf("Expected a completed task created for local-1, got %+v", r)
+	}
+	if r := second.Results[2]; r.Status != http.StatusNotFound {
+		t.Errorf("Expected 404 changing a task deleted on the server, got %+v", r)
+	}
+	if r := second.Results[3]; r.Status != http.StatusUnprocessableEntity || r.Error == "" {
+		t.Errorf("Expected 422 creating a task without a title, got %+v", r)
+	}
+	// The delete happened after the token: the client gets the full list
+	if !second.Full || len(second.Tasks) != 2 {
+		t.Errorf("Expected the full list after a change on the server, got %+v", second)
+	}
+
+	// Nothing else changed: only the results come back
+	resp = api.Post("/sync", map[string]any{
+		"token":   second.Token,
+		"changes": []map[string]any{{"op": "delete", "id": id}},
+	})
+	var third syncResponse
+	decode(t, resp.Body.String(), &third)
+	if third.Full || len(third.Tasks) != 0 || third.Results[0].Status != http.StatusOK || third.Token == second.Token {
+		t.Errorf("Expected an up-to-date sync with a new token, got %+v", third)
+	}
+
+	if resp := api.Post("/sync", map[string]any{"changes": []map[string]any{{"op": "rename"}}}); resp.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for an unknown op, got %d", resp.Code)
+	}
+
+	t.Log("✅ POST /sync passed")
+}
+
+// TestTasksAPI_SyncConflicts tests each strategy against a task the server
+// changed since the client's base copy
+func TestTasksAPI_SyncConflicts(t *testing.T) {
+	tests := []struct {
+		name       string
+		strategy   string
+		change     map[string]any // Fields the client changed offline
+		wantStatus int
+		wantTitle  string // Title on the server afterwards
+		wantClient bool   // The title conflict carries the client's value
+	}{
+		{"last write wins", "last-write-wins", map[string]any{"title": "Buy eggs"}, http.StatusOK, "Buy eggs", true},
+		{"merge, other fields", "merge", map[string]any{"completed": true}, http.StatusOK, "Buy oat milk", false},
+		{"merge, same field", "merge", map[string]any{"title": "Buy eggs"}, http.StatusConflict, "Buy oat milk", true},
+		{"merge, same value", "merge", map[string]any{"title": "Buy oat milk"}, http.StatusOK, "Buy oat milk", false},
+		{"reject", "reject", map[string]any{"completed": true}, http.StatusConflict, "Buy oat milk", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: the client got "Buy milk", then the server renamed it
+			repo := repository.NewMemoryTaskRepository()
+			api := newTaskAPI(t, repo)
+			id := seedTask(t, repo, testutil.WithTitle("Buy milk"))
+			var base models.Task
+			decode(t, api.Get("/tasks/"+id).Body.String(), &base)
+			api.Put("/tasks/"+id, map[string]any{"title": "Buy oat milk"})
+
+			change := map[string]any{"op": "update", "id": id, "base": base}
+			for k, v := range tt.change {
+				change[k] = v
+			}
+
+			// Act
+			resp := api.Post("/sync", map[string]any{"strategy": tt.strategy, "changes": []any{change}})
+
+			// Assert
+			var got syncResponse
+			decode(t, resp.Body.String(), &got)
+			result := got.Results[0]
+			if result.Status != tt.wantStatus {
+				t.Fatalf("Expected a %d result, got %+v", tt.wantStatus, result)
+			}
+			if len(result.Conflicts) != 1 || result.Conflicts[0].Field != "title" || result.Conflicts[0].Server != "Buy oat milk" {
+				t.Fatalf("Expected the title reported as changed on the server, got %+v", result.Conflicts)
+			}
+			if gotClient := result.Conflicts[0].Client != nil; gotClient != tt.wantClient {
+				t.Errorf("Expected client value reported: %v, got %+v", tt.wantClient, result.Conflicts[0])
+			}
+			var task models.Task
+			decode(t, api.Get("/tasks/"+id).Body.String(), &task)
+			if task.Title != tt.wantTitle {
+				t.Errorf("Expected title %q on the server, got %q", tt.wantTitle, task.Title)
+			}
+		})
+	}
+
+	// merge and reject can't tell without a base
+	api := newTaskAPI(t, repository.NewMemoryTaskRepository())
+	resp := api.Post("/sync", map[string]any{
+		"strategy": "reject",
+		"changes":  []map[string]any{{"op": "update", "id": testutil.TaskID(1).String(), "completed": true}},
+	})
+	var got syncResponse
+	decode(t, resp.Body.String(), &got)
+	if got.Results[0].Status != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for an update without a base, got %+v", got.Results[0])
+	}
+
+	t.Log("✅ POST /sync conflict strategies passed")
+}
+
+// TestTasksAPI_SyncConfirm tests that a sync changing more tasks than the
+// guard allows saves nothing until it's sent with ?confirm=<count>
+func TestTasksAPI_SyncConfirm(t *testing.T) {
+	// Arrange: a guard at 2 tasks, and a sync that touches 3 (one twice)
+	logger.Init()
+	repo := repository.NewMemoryTaskRepository()
+	_, api := humatest.New(t)
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		ctx = huma.WithContext(ctx, handlers.WithTaskRepository(ctx.Context(), repo))
+		next(huma.WithContext(ctx, handlers.WithBulkGuard(ctx.Context(), handlers.BulkGuard{ConfirmAbove: 2})))
+	})
+	registerEndpoints(api)
+	a := seedTask(t, repo, testutil.WithTitle("Buy milk"))
+	b := seedTask(t, repo, testutil.WithTitle("Call Sam"))
+	c := seedTask(t, repo, testutil.WithTitle("Pay rent"))
+	sync := map[string]any{"changes": []map[string]any{
+		{"op": "update", "id": a, "completed": true},
+		{"op": "delete", "id": b},
+		{"op": "create", "title": "Water plants"},
+		{"op": "update", "id": c, "title": "Pay rent today"},
+		{"op": "delete", "id": a},
+	}}
+
+	// Act + Assert: held back, with the tasks it would change
+	resp := api.Post("/sync", sync)
+	if resp.Code != http.StatusPreconditionRequired {
+		t.Fatalf("Expected 428, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var problem huma.ErrorModel
+	decode(t, resp.Body.String(), &problem)
+	if !strings.Contains(problem.Detail, "?confirm=3") || len(problem.Errors) != 3 ||
+		problem.Errors[0].Value != a || problem.Errors[0].Message != "would delete this task" ||
+		problem.Errors[2].Location != "body.changes[3].id" {
+		t.Fatalf("Expected the 3 tasks listed, got %+v", problem)
+	}
+	if stats, _ := repo.Stats(context.Background()); stats.Total != 3 || stats.Completed != 0 {
+		t.Fatalf("Expected nothing saved, got %+v", stats)
+	}
+
+	// A dry run previews it, and a confirm of another count is held back too
+	if resp := api.Post("/sync?dry_run=true", sync); resp.Code != http.StatusOK {
+		t.Errorf("Expected a dry run to go through, got %d", resp.Code)
+	}
+	if resp := api.Post("/sync?confirm=2", sync); resp.Code != http.StatusPreconditionRequired {
+		t.Errorf("Expected 428 for the wrong count, got %d", resp.Code)
+	}
+
+	// Confirmed: applied
+	resp = api.Post("/sync?confirm=3", sync)
+	var got syncResponse
+	decode(t, resp.Body.String(), &got)
+	if resp.Code != http.StatusOK || len(got.Results) != 5 || got.Results[1].Status != http.StatusOK {
+		t.Fatalf("Expected the sync applied, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if stats, _ := repo.Stats(context.Background()); stats.Total != 2 {
+		t.Errorf("Expected 2 tasks left, got %+v", stats)
+	}
+
+	t.Log("✅ POST /sync bulk confirmation passed")
+}
+
+// TestTasksAPI_SyncAfterWriting tests that a sync whose changes were saved
+// answers with their results, even when the task list can't be read
+func TestTasksAPI_SyncAfterWriting(t *testing.T) {
+	create := map[string]any{"changes": []map[string]any{{"op": "create", "client_id": "c1", "title": "New"}}}
+
+	t.Run("over QUERY_MAX_RESULTS", func(t *testing.T) {
+		// Arrange: a list cap the tasks are already at
+		repo := repository.NewMemoryTaskRepository()
+		repo.SetLimits(repository.Limits{MaxResults: 2})
+		api := newTaskAPI(t, repo)
+		seedTask(t, repo, testutil.WithTitle("One"))
+		seedTask(t, repo, testutil.WithTitle("Two"))
+
+		// Act
+		resp := api.Post("/sync", create)
+
+		// Assert
+		var got syncResponse
+		_ = json.Unmarshal(resp.Body.Bytes(), &got)
+		if resp.Code != http.StatusOK || !got.Full || len(got.Tasks) != 3 || got.Token == "" {
+			t.Errorf("Expected all 3 tasks and a token, got %d: %s", resp.Code, resp.Body.String())
+		}
+	})
+
+	t.Run("read fails", func(t *testing.T) {
+		// Arrange: a cursor that breaks half way through
+		repo := repository.NewMemoryTaskRepository()
+		api := newTaskAPI(t, brokenCursorRepository{repo})
+		seedTask(t, repo, testutil.WithTitle("One"))
+
+		// Act
+		resp := api.Post("/sync", map[string]any{"token": "stale", "changes": create["changes"]})
+		again := api.Post("/sync", map[string]any{"token": "stale", "changes": []any{}})
+
+		// Assert
+		var got syncResponse
+		_ = json.Unmarshal(resp.Body.Bytes(), &got)
+		if resp.Code != http.StatusOK || len(got.Results) != 1 || got.Results[0].Status != http.StatusCreated {
+			t.Fatalf("Expected the create's result, got %d: %s", resp.Code, resp.Body.String())
+		}
+		if got.Token != "stale" || got.Full {
+			t.Errorf("Expected the client's own token back and no tasks, got %+v", got)
+		}
+		if tasks, _ := repo.List(context.Background(), nil, repository.AllFields, repository.Page{}); len(tasks) != 2 {
+			t.Errorf("Expected the task saved once, got %d tasks", len(tasks))
+		}
+		if again.Code != http.StatusInternalServerError {
+			t.Errorf("Expected a sync that saved nothing to fail, got %d", again.Code)
+		}
+	})
+
+	t.Log("✅ POST /sync after writing passed")
+}
+
+// ============================================================================
+// DELETE TASK - DELETE /tasks/{id}
+// ============================================================================
+
+// TestTasksAPI_Delete tests deleting a task, twice
+func TestTasksAPI_Delete(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+	id := seedTask(t, repo, testutil.WithTitle("Buy milk"))
+
+	// Act + Assert: first delete works, the second finds nothing
+	resp := api.Delete("/tasks/" + id)
+	if resp.Code != http.StatusOK || !strings.Contains(resp.Body.String(), id) {
+		t.Errorf("Expected 200 echoing the ID, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	if resp := api.Delete("/tasks/" + id); resp.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting twice, got %d", resp.Code)
+	}
+
+	if resp := api.Delete("/tasks/123"); resp.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a short ID, got %d", resp.Code)
+	}
+
+	t.Log("✅ DELETE /tasks/{id} passed")
+}
+
+// ============================================================================
+// DRY RUN - ?dry_run=true OR Dry-Run: true
+// ============================================================================
+
+// TestTasksAPI_DryRun tests that dry runs answer as usual but save nothing
+func TestTasksAPI_DryRun(t *testing.T) {
+	// Arrange
+	var after int
+	registry := hooks.Init(nil)
+	t.Cleanup(func() { hooks.Init(nil) })
+	registry.Register("recorder", hooks.Funcs{
+		AfterUpdateFunc: func(context.Context, models.Task) error { after++; return nil },
+		AfterDeleteFunc: func(context.Context, string) error { after++; return nil },
+	})
+
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+	id := seedTask(t, repo, testutil.WithTitle("Buy milk"))
+	missing := testutil.TaskID(1).String()
+	version, _ := repo.Version(context.Background())
+
+	tests := []struct {
+		name string
+		send func() *httptest.ResponseRecorder
+		want int
+		body string // Part of the would-be response
+	}{
+		{"create", func() *httptest.ResponseRecorder {
+			return api.Post("/tasks?dry_run=true", map[string]any{"title": "Buy bread"})
+		}, http.StatusOK, `"title":"Buy bread"`},
+		{"create, invalid", func() *httptest.ResponseRecorder {
+			return api.Post("/tasks?dry_run=true", map[string]any{"title": ""})
+		}, http.StatusUnprocessableEntity, "title"},
+		{"update with the header", func() *httptest.ResponseRecorder {
+			return api.Put("/tasks/"+id, "Dry-Run: true", map[string]any{"title": "Buy oat milk"})
+		}, http.StatusOK, `"title":"Buy oat milk"`},
+		{"complete", func() *httptest.ResponseRecorder {
+			return api.Post("/tasks/" + id + "/complete?dry_run=true")
+		}, http.StatusOK, `"completed":true`},
+		{"delete", func() *httptest.ResponseRecorder {
+			return api.Delete("/tasks/" + id + "?dry_run=true")
+		}, http.StatusOK, id},
+		{"delete, unknown ID", func() *httptest.ResponseRecorder {
+			return api.Delete("/tasks/" + missing + "?dry_run=true")
+		}, http.StatusNotFound, "not found"},
+		{"sync", func() *httptest.ResponseRecorder {
+			return api.Post("/sync?dry_run=true", map[string]any{"changes": []map[string]any{
+				{"op": "create", "client_id": "c1", "title": "Call mum", "completed": true},
+				{"op": "delete", "id": id},
+			}})
+		}, http.StatusOK, `"completed":true`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			resp := tt.send()
+
+			// Assert
+			if resp.Code != tt.want || !strings.Contains(resp.Body.String(), tt.body) {
+				t.Errorf("Expected %d with %s, got %d: %s", tt.want, tt.body, resp.Code, resp.Body.String())
+			}
+			if resp.Header().Get("Location") != "" {
+				t.Errorf("Expected no Location on a dry run, got %s", resp.Header().Get("Location"))
+			}
+		})
+	}
+
+	// Nothing was saved, and no AfterUpdate/AfterDelete hook ran
+	task, err := repo.Get(context.Background(), models.MustParseTaskID(id))
+	now, _ := repo.Version(context.Background())
+	tasks, _ := repo.List(context.Background(), nil, repository.AllFields, repository.Page{})
+	if err != nil || task.Title != "Buy milk" || task.Completed || len(tasks) != 1 || now != version {
+		t.Errorf("Expected the tasks untouched, got %+v (%v), %d tasks, version %d→%d", task, err, len(tasks), version, now)
+	}
+	if after != 0 {
+		t.Errorf("Expected no after hooks on a dry run, got %d", after)
+	}
+
+	t.Log("✅ Dry runs answered without saving")
+}
+
+// ============================================================================
+// EXPORT TASKS - GET /export
+// ============================================================================
+
+// brokenCursorRepository fails after the first task of a stream, like a
+// MongoDB cursor losing its connection half way through
+type brokenCursorRepository struct {
+	*repository.MemoryTaskRepository
+}
+
+func (r brokenCursorRepository) Stream(ctx context.Context, completed *bool) (iter.Seq2[models.Task, error], error) {
+	tasks, err := r.MemoryTaskRepository.List(ctx, completed, repository.AllFields, repository.Page{})
+	return func(yield func(models.Task, error) bool) {
+		if yield(tasks[0], nil) {
+			yield(models.Task{}, errUnreachable)
+		}
+	}, err
+}
+
+// exportedLines splits an NDJSON body into one task per line
+func exportedLines(t *testing.T, body string) []models.Task {
+	t.Helper()
+	var tasks []models.Task
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		var task models.Task
+		decode(t, line, &task)
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// TestTasksAPI_Export tests that every task comes back on a line of its own
+func TestTasksAPI_Export(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+
+	// Act + Assert: nothing to export is an empty body, not an error
+	resp := api.Get("/export")
+	if resp.Code != http.StatusOK || resp.Body.Len() != 0 {
+		t.Errorf("Expected 200 with an empty body, got %d %q", resp.Code, resp.Body.String())
+	}
+
+	seedTask(t, repo, testutil.WithTitle("Open"))
+	seedTask(t, repo, testutil.WithTitle("Done"), testutil.Completed())
+
+	resp = api.Get("/export")
+	if ct := resp.Header().Get("Content-Type"); ct != handlers.NDJSONContentType {
+		t.Errorf("Expected Content-Type %s, got %q", handlers.NDJSONContentType, ct)
+	}
+	if tasks := exportedLines(t, resp.Body.String()); len(tasks) != 2 || tasks[0].Title != "Open" {
+		t.Errorf("Expected 2 lines starting with 'Open', got %+v", tasks)
+	}
+
+	// The same filter as GET /tasks
+	resp = api.Get("/export?completed=true")
+	if tasks := exportedLines(t, resp.Body.String()); len(tasks) != 1 || tasks[0].Title != "Done" {
+		t.Errorf("Expected only 'Done', got %+v", tasks)
+	}
+
+	t.Log("✅ GET /export passed")
+}
+
+// TestTasksAPI_ExportCSV tests that ?format=csv writes rows for a
+// spreadsheet, with dates written the way Accept-Language writes them
+func TestTasksAPI_ExportCSV(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	due := time.Date(2025, time.January, 31, 16, 0, 0, 0, time.UTC)
+	seedTask(t, repo, testutil.WithTitle("=HYPERLINK(\"x\")"), func(task *models.Task) { task.DueAt = &due })
+	api := newTaskAPI(t, repo)
+
+	// Act
+	resp := api.Get("/export?format=csv", "Accept-Language: de-DE,de;q=0.9")
+
+	// Assert
+	if ct := resp.Header().Get("Content-Type"); ct != handlers.CSVContentType {
+		t.Errorf("Expected Content-Type %s, got %q", handlers.CSVContentType, ct)
+	}
+	if lang := resp.Header().Get("Content-Language"); lang != "de" {
+		t.Errorf("Expected Content-Language de, got %q", lang)
+	}
+	lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+	if len(lines) != 2 || lines[0] != "id,title,description,completed,completed_at,due_at" {
+		t.Fatalf("Expected a header row and one task, got %q", resp.Body.String())
+	}
+	if !strings.HasSuffix(lines[1], ",false,,31.01.2025 16:00") {
+		t.Errorf("Expected the due date written the German way, got %q", lines[1])
+	}
+	if !strings.Contains(lines[1], `"'=HYPERLINK(""x"")"`) {
+		t.Errorf("Expected the formula kept as text, got %q", lines[1])
+	}
+
+	// Without a locale the default (day first, 24-hour clock) is used
+	resp = api.Get("/export?format=csv")
+	if !strings.Contains(resp.Body.String(), "31/01/2025 16:00") {
+		t.Errorf("Expected the default date format, got %q", resp.Body.String())
+	}
+
+	t.Log("✅ GET /export?format=csv passed")
+}
+
+// TestTasksAPI_ExportFailsPartWay tests that a cursor error ends the stream
+// after the lines already sent (the 200 can't be taken back)
+func TestTasksAPI_ExportFailsPartWay(t *testing.T) {
+	// Arrange
+	repo := brokenCursorRepository{repository.NewMemoryTaskRepository()}
+	seedTask(t, repo, testutil.WithTitle("First"))
+	seedTask(t, repo, testutil.WithTitle("Never sent"))
+	api := newTaskAPI(t, repo)
+
+	// Act
+	resp := api.Get("/export")
+
+	// Assert
+	if tasks := exportedLines(t, resp.Body.String()); len(tasks) != 1 || tasks[0].Title != "First" {
+		t.Errorf("Expected the stream to stop after 'First', got %+v", tasks)
+	}
+}
+
+// ============================================================================
+// TASK COUNTS - GET /stats
+// ============================================================================
+
+// TestTasksAPI_Stats tests the counts by status
+func TestTasksAPI_Stats(t *testing.T) {
+	// Arrange
+	repo := repository.NewMemoryTaskRepository()
+	api := newTaskAPI(t, repo)
+	seedTask(t, repo)
+	seedTask(t, repo, testutil.Completed())
+	seedTask(t, repo, testutil.Completed())
+
+	// Act
+	resp := api.Get("/stats")
+
+	// Assert
+	var stats models.TaskStats
+	decode(t, resp.Body.String(), &stats)
+	if resp.Code != http.StatusOK || stats.Open != 1 || stats.Completed != 2 || stats.Total != 3 {
+		t.Errorf("Expected 200 with 1 open, 2 completed, 3 total, got %d %+v", resp.Code, stats)
+	}
+}
+
+// ============================================================================
+// DATABASE DOWN → 500 PROBLEM RESPONSE
+// ============================================================================
+
+// TestTasksAPI_DatabaseDown tests that repository failures become 500 problem responses
+func TestTasksAPI_DatabaseDown(t *testing.T) {
+	// Arrange
+	api := newTaskAPI(t, failingRepository{})
+	id := testutil.TaskID(1).String()
+
+	requests := map[string]func() int{
+		"list":   func() int { return api.Get("/tasks").Code },
+		"get":    func() int { return api.Get("/tasks/" + id).Code },
+		"create": func() int { return api.Post("/tasks", map[string]any{"title": "x"}).Code },
+		"update": func() int { return api.Put("/tasks/"+id, map[string]any{"completed": true}).Code },
+		"delete": func() int { return api.Delete("/tasks/" + id).Code },
+		"export": func() int { return api.Get("/export").Code },
+		"stats":  func() int { return api.Get("/stats").Code },
+	}
+
+	for name, request := range requests {
+		t.Run(name, func(t *testing.T) {
+			if code := request(); code != http.StatusInternalServerError {
+				t.Errorf("Expected 500, got %d", code)
+			}
+		})
+	}
+
+	resp := api.Get("/tasks")
+	if ct := resp.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected a problem+json body, got %q", ct)
+	}
+}
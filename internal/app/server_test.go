@@ -0,0 +1,342 @@
+package app_test
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/app"
+	"go-todo-api/internal/config"
+	"go-todo-api/internal/middleware"
+	"go-todo-api/internal/testutil"
+	"go-todo-api/internal/testutil/testserver"
+)
+
+// These tests start the whole app on a random port (see testutil/testserver)
+// and only talk to it over HTTP, so they check what the middleware does
+// together: which one answers first, and which headers every response gets
+// Run with: go test ./internal/app -run TestServer -v
+
+// ============================================================================
+// TASKS OVER HTTP
+// ============================================================================
+
+// TestServer_TaskLifecycle tests create → get → list → delete through the full stack
+func TestServer_TaskLifecycle(t *testing.T) {
+	// Arrange
+	srv := testserver.Start(t, app.Options{BasePath: "/api"})
+
+	// Act + Assert: Create
+	resp := srv.Request(t, http.MethodPost, "/tasks", map[string]any{"title": "Buy milk"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+	var created struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	}
+	testserver.DecodeJSON(t, resp, &created)
+	if want := "/api/tasks/" + created.ID; resp.Header.Get("Location") != want {
+		t.Errorf("Expected Location %s, got %q", want, resp.Header.Get("Location"))
+	}
+
+	// Get
+	resp = srv.Request(t, http.MethodGet, "/tasks/"+created.ID, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	// List: the task is in the server's store
+	resp = srv.Request(t, http.MethodGet, "/tasks", nil)
+	var listed []map[string]any
+	testserver.DecodeJSON(t, resp, &listed)
+	if len(listed) != 1 {
+		t.Fatalf("Expected 1 task, got %d", len(listed))
+	}
+
+	// Delete, then the task is gone
+	resp = srv.Request(t, http.MethodDelete, "/tasks/"+created.ID, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	resp = srv.Request(t, http.MethodGet, "/tasks/"+created.ID, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+		t.Errorf("Expected a problem+json 404, got %q", ct)
+	}
+
+	t.Logf("✅ Task %s created, read, listed and deleted over HTTP", created.ID)
+}
+
+// TestServer_ArrangedTasks tests that tasks added to Server.Tasks are served
+func TestServer_ArrangedTasks(t *testing.T) {
+	// Arrange
+	srv := testserver.Start(t, app.Options{})
+	testutil.CreateTasks(t, srv.Tasks,
+		testutil.NewTask(testutil.WithTitle("Open")),
+		testutil.NewTask(testutil.WithTitle("Done"), testutil.Completed()),
+	)
+
+	// Act
+	resp := srv.Request(t, http.MethodGet, "/tasks?completed=true", nil)
+
+	// Assert
+	var listed []struct {
+		Title string `json:"title"`
+	}
+	testserver.DecodeJSON(t, resp, &listed)
+	if len(listed) != 1 || listed[0].Title != "Done" {
+		t.Errorf("Expected only the completed task, got %+v", listed)
+	}
+}
+
+// TestServer_ListETag tests that a client sending back the list's ETag gets
+// 304 Not Modified until a task changes
+func TestServer_ListETag(t *testing.T) {
+	// Arrange: the micro-cache is on, so this also checks writes clear it
+	srv := testserver.Start(t, app.Options{TaskCacheTTL: time.Minute})
+	testutil.CreateTasks(t, srv.Tasks, testutil.NewTask())
+
+	conditionalGet := func(etag string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/tasks", nil)
+		req.Header.Set("If-None-Match", etag)
+		return srv.Do(t, srv.Client, req)
+	}
+
+	// Act + Assert: the first list has an ETag and Cache-Control
+	resp := srv.Request(t, http.MethodGet, "/tasks", nil)
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag header")
+	}
+	if got := resp.Header.Get("Cache-Control"); got != "private, no-cache" {
+		t.Errorf("Expected Cache-Control %q, got %q", "private, no-cache", got)
+	}
+
+	// Sending it back gets 304 with no body
+	resp = conditionalGet(etag)
+	if resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("Expected status 304, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("ETag") != etag {
+		t.Errorf("Expected the 304 to repeat ETag %s, got %q", etag, resp.Header.Get("ETag"))
+	}
+
+	// After a write the old ETag no longer matches
+	srv.Request(t, http.MethodPost, "/tasks", map[string]any{"title": "Buy milk"})
+	resp = conditionalGet(etag)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 after a write, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("ETag") == etag {
+		t.Error("Expected a new ETag after a write")
+	}
+
+	t.Logf("✅ ETag %s revalidated, then replaced after a write", etag)
+}
+
+// TestServer_Export tests that GET /export streams NDJSON through the whole
+// middleware stack, under BASE_PATH (where the unbuffered timeout applies)
+func TestServer_Export(t *testing.T) {
+	// Arrange
+	srv := testserver.Start(t, app.Options{BasePath: "/api"})
+	testutil.CreateTasks(t, srv.Tasks,
+		testutil.NewTask(testutil.WithTitle("One")),
+		testutil.NewTask(testutil.WithTitle("Two")),
+	)
+
+	// Act
+	resp := srv.Request(t, http.MethodGet, "/export", nil)
+
+	// Assert
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected application/x-ndjson, got %q", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"title":"Two"`) {
+		t.Errorf("Expected one line per task, got %q", body)
+	}
+}
+
+// TestServer_OpenAPISpec tests that /openapi.json is served without an API key
+// or rate limiting, gzipped, and revalidated with its ETag
+func TestServer_OpenAPISpec(t *testing.T) {
+	// Arrange
+	srv := testserver.Start(t, app.Options{BasePath: "/api"})
+
+	get := func(header, value string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/openapi.json", nil)
+		req.Header.Set(header, value)
+		return srv.Do(t, srv.Anonymous, req)
+	}
+
+	// Act + Assert: no API key, gzipped when asked for
+	resp := get("Accept-Encoding", "gzip")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 without an API key, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected a gzipped spec, got Content-Encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("Expected a gzip body: %v", err)
+	}
+	var spec struct {
+		Paths map[string]any `json:"paths"`
+	}
+	if err := json.NewDecoder(zr).Decode(&spec); err != nil || spec.Paths["/tasks"] == nil {
+		t.Errorf("Expected the spec with /tasks, got %v (error %v)", spec.Paths, err)
+	}
+
+	// Sending the ETag back gets 304
+	etag := resp.Header.Get("ETag")
+	if resp = get("If-None-Match", etag); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected status 304 for ETag %s, got %d", etag, resp.StatusCode)
+	}
+
+	// Polling it doesn't use up the rate limit (a burst of 20)
+	for i := 0; i < 30; i++ {
+		if resp = get("Accept-Encoding", "gzip"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected every poll to get 200, request %d got %d", i+1, resp.StatusCode)
+		}
+	}
+
+	t.Logf("✅ Spec served gzipped with ETag %s, 30 polls not rate limited", etag)
+}
+
+// ============================================================================
+// MIDDLEWARE TOGETHER
+// ============================================================================
+
+// TestServer_Auth tests that requests without a valid key are refused, and
+// that the middleware before auth still ran for them
+func TestServer_Auth(t *testing.T) {
+	// Arrange
+	srv := testserver.Start(t, app.Options{})
+
+	tests := []struct {
+		name   string
+		apiKey string
+		want   int
+	}{
+		{"missing key", "", http.StatusUnauthorized},
+		{"wrong key", "not-the-key", http.StatusForbidden},
+		{"valid key", testserver.APIKey, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/tasks", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+
+			// Act
+			resp := srv.Do(t, srv.Anonymous, req)
+
+			// Assert
+			if resp.StatusCode != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, resp.StatusCode)
+			}
+			// Request IDs and security headers come before auth, so even a
+			// refused request can be traced and is safe for browsers
+			if resp.Header.Get("X-Request-ID") == "" {
+				t.Error("Expected an X-Request-ID header")
+			}
+			if resp.Header.Get("X-Content-Type-Options") != "nosniff" {
+				t.Error("Expected the security headers")
+			}
+		})
+	}
+}
+
+// TestServer_CORS tests that browser preflights are answered before auth
+// (browsers never send the API key with them) and only for allowed origins
+func TestServer_CORS(t *testing.T) {
+	// Arrange
+	const allowed = "https://todo.example.com"
+	srv := testserver.Start(t, app.Options{Profile: config.Profile{CORSOrigins: []string{allowed}}})
+
+	preflight := func(origin string) *http.Response {
+		req, _ := http.NewRequest(http.MethodOptions, srv.URL+"/tasks", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		return srv.Do(t, srv.Anonymous, req)
+	}
+
+	// Act + Assert: allowed origin, no API key
+	resp := preflight(allowed)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the preflight to get 200 without an API key, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != allowed {
+		t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", allowed, got)
+	}
+
+	// Other origins get no CORS headers, so the browser blocks them
+	resp = preflight("https://evil.example.com")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no Access-Control-Allow-Origin for another origin, got %q", got)
+	}
+
+	// The real request from the allowed origin carries the header too
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/tasks", nil)
+	req.Header.Set("Origin", allowed)
+	resp = srv.Do(t, srv.Client, req)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != allowed {
+		t.Errorf("Expected 200 with CORS headers, got %d %q", resp.StatusCode, resp.Header.Get("Access-Control-Allow-Origin"))
+	}
+}
+
+// TestServer_RateLimitBeforeAuth tests that the rate limiter counts requests
+// before auth looks at them, so guessing keys is rate limited too
+func TestServer_RateLimitBeforeAuth(t *testing.T) {
+	// Arrange
+	srv := testserver.Start(t, app.Options{})
+
+	// Act: more than the burst of 20, all with a wrong key
+	limited := 0
+	for i := 0; i < 30; i++ {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/tasks", nil)
+		req.Header.Set("X-API-Key", "guess")
+		if srv.Do(t, srv.Anonymous, req).StatusCode == http.StatusTooManyRequests {
+			limited++
+		}
+	}
+
+	// Assert
+	if limited == 0 {
+		t.Error("Expected some of 30 rapid requests to get 429")
+	}
+	t.Logf("✅ %d of 30 requests rate limited", limited)
+}
+
+// TestServer_RateLimitDisabled tests that RateLimit.Disabled lets every request through
+func TestServer_RateLimitDisabled(t *testing.T) {
+	// Arrange
+	srv := testserver.Start(t, app.Options{RateLimit: middleware.RateLimitConfig{Disabled: true}})
+
+	// Act
+	limited := 0
+	for i := 0; i < 30; i++ {
+		if srv.Request(t, http.MethodGet, "/tasks", nil).StatusCode == http.StatusTooManyRequests {
+			limited++
+		}
+	}
+
+	// Assert
+	if limited != 0 {
+		t.Errorf("Expected no 429s with rate limiting disabled, got %d", limited)
+	}
+}
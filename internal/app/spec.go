@@ -0,0 +1,111 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// ============================================================================
+// CACHED OPENAPI SPEC
+// ============================================================================
+// Docs tools, client generators and API gateways poll /openapi.json, often
+// every few seconds. The spec only changes with a deploy, so it's encoded and
+// gzipped once when the router is built, and served with an ETag so pollers
+// get a 304 with no body. It describes the API, not its data, so it's served
+// without an API key and isn't counted by the rate limiter.
+
+// specPath is where the cached spec is served, under BASE_PATH
+const specPath = "/openapi.json"
+
+// specContentType is what Huma serves its own spec as
+const specContentType = "application/vnd.oai.openapi+json"
+
+// specDocument is the spec encoded once, plain and gzipped
+type specDocument struct {
+	body    []byte
+	gzipped []byte
+	etag    string
+}
+
+// newSpecDocument encodes the spec
+// Call it after every endpoint is registered, or they won't be in it.
+func newSpecDocument(spec *huma.OpenAPI) (*specDocument, error) {
+	body, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var gzipped bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&gzipped, gzip.BestCompression) // Only done once
+	zw.Write(body)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body)
+	return &specDocument{
+		body:    body,
+		gzipped: gzipped.Bytes(),
+		etag:    `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}, nil
+}
+
+// ServeHTTP writes the spec, gzipped if the client accepts it, or 304 if the
+// client already has this version
+func (d *specDocument) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Set("ETag", d.etag)
+	h.Set("Cache-Control", "public, no-cache") // Cache, but ask each time
+	h.Set("Vary", "Accept-Encoding")
+
+	if specETagMatches(r.Header.Get("If-None-Match"), d.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body := d.body
+	if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		body = d.gzipped
+		h.Set("Content-Encoding", "gzip")
+	}
+	h.Set("Content-Type", specContentType)
+	w.Write(body)
+}
+
+// specETagMatches reports whether If-None-Match lists etag (or is "*")
+// Gzipped and plain responses share the ETag: they're the same document.
+func specETagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip reports whether Accept-Encoding allows gzip
+// e.g. "gzip, deflate, br" yes; "gzip;q=0" or "identity" no
+func acceptsGzip(acceptEncoding string) bool {
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		q, found := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !found {
+			return true
+		}
+		weight, err := strconv.ParseFloat(q, 64)
+		return err == nil && weight > 0
+	}
+	return false
+}
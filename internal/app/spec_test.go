@@ -0,0 +1,24 @@
+package app
+
+import "testing"
+
+// TestAcceptsGzip tests reading Accept-Encoding for the OpenAPI spec
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"gzip, deflate, br", true},
+		{"br;q=1.0, GZIP;q=0.5", true},
+		{"gzip;q=0", false},
+		{"identity", false},
+	}
+
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.acceptEncoding); got != tt.want {
+			t.Errorf("acceptsGzip(%q): expected %v, got %v", tt.acceptEncoding, tt.want, got)
+		}
+	}
+}
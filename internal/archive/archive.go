@@ -0,0 +1,241 @@
+// Package archive moves completed tasks out of the task list once they're old
+// Users turn it on in their profile (auto_archive_days). Once a day the
+// scheduler finds each of their tasks completed at least that many days ago,
+// copies it into the archive (the "archived_tasks" collection), removes it
+// from the tasks and records why in their activity (see internal/activity).
+// Archived tasks are listed at GET /me/archived-tasks.
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/activity"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/profile"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/scheduler"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Task is an archived task: the task as it was, and when and why it moved
+type Task struct {
+	models.Task `bson:",inline"`
+	ArchivedAt  time.Time `bson:"archived_at"`
+	Reason      string    `bson:"archive_reason"`
+}
+
+// Store persists the archived tasks
+// MongoStore is used in production; MemoryStore in tests
+type Store interface {
+	// Save adds an archived task, or replaces it (archiving is retried)
+	Save(ctx context.Context, t *Task) error
+
+	// List returns a user's archived tasks, most recently archived first
+	List(ctx context.Context, ownerID string, limit int) ([]Task, error)
+}
+
+// ============================================================================
+// ARCHIVER
+// ============================================================================
+
+// Archiver archives the tasks of the users who turned it on
+type Archiver struct {
+	profiles *profile.Profiles
+	tasks    repository.TaskRepository
+	store    Store
+	activity activity.Store   // nil = no activity record
+	now      func() time.Time // time.Now, except in tests
+}
+
+// New creates an archiver that reads the settings from profiles, moves
+// tasks from tasks to store, and records what it did in record
+func New(profiles *profile.Profiles, tasks repository.TaskRepository, store Store, record activity.Store) *Archiver {
+	return &Archiver{profiles: profiles, tasks: tasks, store: store, activity: record, now: time.Now}
+}
+
+// Schedule adds the daily run (at 03:30 UTC, when few people are using the API)
+func (a *Archiver) Schedule(s *scheduler.Scheduler) error {
+	return s.Register("auto-archive", "30 3 * * *", a.run, scheduler.TaskOptions{Jitter: time.Minute, Timeout: 30 * time.Minute})
+}
+
+// Archived returns a user's archived tasks, most recently archived first
+func (a *Archiver) Archived(ctx context.Context, ownerID string, limit int) ([]Task, error) {
+	return a.store.List(ctx, ownerID, limit)
+}
+
+// run archives every opted-in user's old completed tasks
+// A user whose tasks can't be archived is logged and skipped, so one bad
+// document doesn't hold up everyone else.
+func (a *Archiver) run(ctx context.Context) error {
+	users, err := a.profiles.AutoArchivers(ctx)
+	if err != nil {
+		return err
+	}
+	archived := 0
+	for _, user := range users {
+		n, err := a.ArchiveUser(ctx, user.UserID, user.AutoArchiveDays)
+		archived += n
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			logger.WithTrace(ctx).Error("Failed to auto-archive tasks",
+				slog.String("user_id", user.UserID), slog.Any("error", err))
+		}
+	}
+	logger.WithTrace(ctx).Info("Auto-archived tasks", slog.Int("users", len(users)), slog.Int("archived", archived))
+	return nil
+}
+
+// ArchiveUser archives a user's tasks completed at least days ago, and
+// returns how many it moved
+func (a *Archiver) ArchiveUser(ctx context.Context, ownerID string, days int) (int, error) {
+	now := a.now().UTC()
+	cutoff := now.AddDate(0, 0, -days)
+	tasks, err := a.tasks.ListOwned(ctx, ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	archived := 0
+	for _, task := range tasks {
+		if !task.Completed || task.CompletedAt == nil || task.CompletedAt.After(cutoff) {
+			continue
+		}
+		age := int(now.Sub(*task.CompletedAt).Hours() / 24)
+		reason := fmt.Sprintf("Completed %d days ago; your profile archives completed tasks after %d days", age, days)
+
+		// Copy first, then delete: if we stop in between, the next run
+		// saves the same copy again and finishes the move
+		if err := a.store.Save(ctx, &Task{Task: task, ArchivedAt: now, Reason: reason}); err != nil {
+			return archived, err
+		}
+		if err := a.tasks.Delete(ctx, task.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return archived, err
+		}
+		archived++
+
+		if a.activity != nil {
+			entry := &activity.Entry{
+				UserID: ownerID, Action: activity.ActionArchived,
+				TaskID: task.ID.String(), TaskTitle: task.Title, Reason: reason, At: now,
+			}
+			if err := a.activity.Record(ctx, entry); err != nil {
+				// The task moved either way; don't archive it twice over a log line
+				logger.WithTrace(ctx).Warn("Failed to record archive activity",
+					slog.String("task_id", task.ID.String()), slog.Any("error", err))
+			}
+		}
+	}
+	return archived, nil
+}
+
+// ============================================================================
+// MONGODB STORE
+// ============================================================================
+
+// MongoStore keeps archived tasks in a MongoDB collection, under the ID they
+// had as tasks
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store on the given collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// EnsureIndexes creates the index for listing a user's archive
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "archived_at", Value: -1}},
+	})
+	return err
+}
+
+// Save upserts by task ID
+func (s *MongoStore) Save(ctx context.Context, t *Task) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": t.ID}, t, options.Replace().SetUpsert(true))
+	return err
+}
+
+// List returns a user's archived tasks, most recently archived first
+func (s *MongoStore) List(ctx context.Context, ownerID string, limit int) ([]Task, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"owner_id": ownerID},
+		options.Find().SetSort(bson.D{{Key: "archived_at", Value: -1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ============================================================================
+// IN-MEMORY STORE
+// ============================================================================
+
+// MemoryStore keeps archived tasks in a slice - use it in tests
+type MemoryStore struct {
+	mu    sync.Mutex
+	tasks []Task
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Save adds or replaces an archived task
+func (s *MemoryStore) Save(ctx context.Context, t *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = slices.DeleteFunc(s.tasks, func(old Task) bool { return old.ID == t.ID })
+	s.tasks = append(s.tasks, *t)
+	return nil
+}
+
+// List returns a user's archived tasks, most recently archived first
+func (s *MemoryStore) List(ctx context.Context, ownerID string, limit int) ([]Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks := []Task{}
+	for _, t := range slices.Backward(s.tasks) {
+		if t.OwnerID == ownerID && len(tasks) < limit {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+// ============================================================================
+// DEFAULT ARCHIVER
+// ============================================================================
+
+// defaultArchiver is scheduled at startup and read by GET /me/archived-tasks
+var defaultArchiver *Archiver
+
+// Init sets the default archiver; Init(nil) turns archiving off
+func Init(a *Archiver) *Archiver {
+	defaultArchiver = a
+	return a
+}
+
+// Default returns the archiver set by Init (nil before Init)
+func Default() *Archiver {
+	return defaultArchiver
+}
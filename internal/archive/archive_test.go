@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/activity"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/profile"
+	"go-todo-api/internal/repository"
+)
+
+// TestArchiver_Run tests that only opted-in users' old completed tasks are archived, with a reason
+func TestArchiver_Run(t *testing.T) {
+	// Arrange: alice archives after 30 days, bob never does
+	logger.Init()
+	ctx := context.Background()
+	now := time.Date(2025, 3, 2, 3, 30, 0, 0, time.UTC)
+	profiles := profile.New(profile.NewMemoryStore())
+	_, _ = profiles.Save(ctx, profile.Profile{UserID: "alice", AutoArchiveDays: 30})
+	_, _ = profiles.Save(ctx, profile.Profile{UserID: "bob"})
+
+	tasks := repository.NewMemoryTaskRepository()
+	longAgo := now.AddDate(0, 0, -40)
+	lastWeek := now.AddDate(0, 0, -7)
+	for _, task := range []models.Task{
+		{Title: "Old and done", OwnerID: "alice", Completed: true, CompletedAt: &longAgo},
+		{Title: "Recently done", OwnerID: "alice", Completed: true, CompletedAt: &lastWeek},
+		{Title: "Still open", OwnerID: "alice"},
+		{Title: "Bob's old task", OwnerID: "bob", Completed: true, CompletedAt: &longAgo},
+	} {
+		_ = tasks.Create(ctx, &task)
+	}
+
+	store := NewMemoryStore()
+	record := activity.NewMemoryStore()
+	a := New(profiles, tasks, store, record)
+	a.now = func() time.Time { return now }
+
+	// Act
+	if err := a.run(ctx); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	// Assert
+	archived, _ := a.Archived(ctx, "alice", 10)
+	if len(archived) != 1 || archived[0].Title != "Old and done" || !archived[0].ArchivedAt.Equal(now) {
+		t.Fatalf("Expected alice's old task archived, got %+v", archived)
+	}
+	left, _ := tasks.ListOwned(ctx, "alice")
+	if len(left) != 2 {
+		t.Errorf("Expected 2 of alice's tasks left, got %d", len(left))
+	}
+	if bobs, _ := tasks.ListOwned(ctx, "bob"); len(bobs) != 1 {
+		t.Errorf("Expected bob's task to stay, got %d", len(bobs))
+	}
+	entries, _ := record.List(ctx, "alice", 10)
+	if len(entries) != 1 || entries[0].Action != activity.ActionArchived || !strings.Contains(entries[0].Reason, "40 days ago") {
+		t.Errorf("Expected an activity entry saying why, got %+v", entries)
+	}
+
+	t.Logf("✅ Archived %q: %s", archived[0].Title, archived[0].Reason)
+}
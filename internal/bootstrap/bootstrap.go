@@ -0,0 +1,437 @@
+// Package bootstrap starts the application in each of its modes
+//
+//	serve    HTTP server with job workers, the scheduler and the task counter (serve.go)
+//	lambda   AWS Lambda function (lambda.go)
+//	worker   job workers, the scheduler and the task counter, no HTTP (worker.go)
+//	migrate  create MongoDB indexes and exit (migrate.go)
+//	seed     insert sample tasks and exit (migrate.go)
+//
+// cmd/todo picks the mode from its first argument or APP_MODE; cmd/api and
+// cmd/lambda are kept as single-mode entry points. The steps every mode
+// shares (settings, job queue, scheduler, task counter) live in this file.
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-todo-api/internal/activity"
+	"go-todo-api/internal/anomaly"
+	"go-todo-api/internal/apikeys"
+	"go-todo-api/internal/archive"
+	"go-todo-api/internal/config"
+	"go-todo-api/internal/database"
+	"go-todo-api/internal/digest"
+	"go-todo-api/internal/email"
+	"go-todo-api/internal/events"
+	"go-todo-api/internal/health"
+	"go-todo-api/internal/hooks"
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/linkpreview"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/middleware"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/outbound"
+	"go-todo-api/internal/profile"
+	"go-todo-api/internal/push"
+	"go-todo-api/internal/recording"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/scheduler"
+	"go-todo-api/internal/settings"
+	"go-todo-api/internal/stats"
+)
+
+// loadSettings reads the server settings and the environment profile
+// A typo like PORT=80a stops the program here instead of being ignored.
+// Call it after logger.Init().
+func loadSettings() (config.Server, config.Profile) {
+	// Read where to listen (HOST, PORT) and the route prefix (BASE_PATH)
+	serverConfig, err := config.Load()
+	if err != nil {
+		logger.Log.Error("Invalid configuration", "error", err)
+		log.Fatal(err)
+	}
+
+	// Read the environment profile (APP_ENV=dev, staging or prod)
+	// It already picked the log format in logger.Init(); the router uses the rest
+	profile, err := config.LoadProfile()
+	if err != nil {
+		logger.Log.Error("Invalid configuration", "error", err)
+		log.Fatal(err)
+	}
+	logger.Log.Info("Environment profile loaded", "env", profile.Env)
+
+	// Every task query from now on is bound by QUERY_MAX_RESULTS and
+	// QUERY_MAX_TIME
+	repository.InitLimits(repository.Limits{
+		MaxResults: serverConfig.QueryLimits.MaxResults,
+		MaxTime:    serverConfig.QueryLimits.MaxTime,
+	})
+
+	// New tasks get ObjectIDs, or UUIDv7s with TASK_ID_FORMAT=uuid
+	models.InitTaskIDFormat(serverConfig.TaskIDFormat)
+
+	return serverConfig, profile
+}
+
+// registerHooks adds the built-in task hooks the settings turn on
+// Hooks a deployment compiles in register themselves (see internal/hooks).
+func registerHooks(serverConfig config.Server) {
+	if pattern := serverConfig.TitlePattern; pattern != nil {
+		hooks.Register("title-pattern", hooks.TitlePattern(pattern, "must match "+pattern.String()))
+	}
+	if n := hooks.Default().Len(); n > 0 {
+		logger.Log.Info("Task hooks registered", "count", n)
+	}
+}
+
+// initEvents picks where the task event subscribers run (EVENTS_BACKEND)
+// Lambda has no goroutines left running between invocations, so async runs
+// inline there.
+func initEvents(serverConfig config.Server, lambda bool) {
+	backend := serverConfig.EventsBackend
+	if backend == "async" && lambda {
+		logger.Log.Warn("EVENTS_BACKEND=async doesn't work on Lambda: task events run inline")
+		backend = "inline"
+	}
+	switch backend {
+	case "async":
+		events.Init(events.NewAsync(4, 1024))
+	case "jobs":
+		events.Init(events.NewJobs(nil))
+	default:
+		events.Init(events.Inline{})
+	}
+	logger.Log.Info("Task events ready", "backend", backend, "subscribers", events.Default().Len())
+}
+
+// rateLimitConfig turns the RATE_LIMIT_* settings into the router's limiter config
+// A disabled limiter is logged, since production should never run without one
+// With RATE_LIMIT_STORE=mongo the counts are kept in the "rate_limits"
+// collection, so every replica enforces the same limit; call it after
+// MongoDB is connected.
+func rateLimitConfig(serverConfig config.Server) middleware.RateLimitConfig {
+	rl := serverConfig.RateLimit
+	if rl.Disabled {
+		logger.Log.Warn("Rate limiting disabled (RATE_LIMIT_DISABLED=true)")
+	}
+	cfg := middleware.RateLimitConfig{
+		RequestsPerSecond: rl.RequestsPerSecond,
+		Burst:             rl.Burst,
+		Disabled:          rl.Disabled,
+		MaxClients:        rl.MaxClients,
+	}
+	if rl.Shared && !rl.Disabled {
+		collection := database.GetNamedCollection("rate_limits")
+		if collection == nil {
+			logger.Log.Warn("MongoDB not connected: rate limits are counted per instance (RATE_LIMIT_STORE=mongo)")
+			return cfg
+		}
+		store := middleware.NewMongoRateLimitStore(collection)
+		indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := store.EnsureIndexes(indexCtx); err != nil {
+			logger.Log.Warn("Failed to create rate limit indexes", "error", err)
+		}
+		cancelIndexes()
+		cfg.Store = store
+	}
+	return cfg
+}
+
+// authExemptions converts the AUTH_EXEMPT settings for middleware.AuthExcept
+func authExemptions(serverConfig config.Server) []middleware.AuthExemption {
+	exempt := make([]middleware.AuthExemption, 0, len(serverConfig.AuthExempt))
+	for _, e := range serverConfig.AuthExempt {
+		exempt = append(exempt, middleware.AuthExemption{Method: e.Method, Path: e.Path})
+	}
+	return exempt
+}
+
+// startJobs sets up the background job queue (in the "jobs" collection)
+// and starts its workers. Job types are registered by the features that use
+// them, before Start(). Call it after connectDatabase().
+func startJobs() *jobs.Pool {
+	var jobStore jobs.Store = jobs.NewMemoryStore()
+	if memory == nil {
+		mongoStore := jobs.NewMongoStore(database.GetNamedCollection("jobs"))
+		indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := mongoStore.EnsureIndexes(indexCtx); err != nil {
+			logger.Log.Warn("Failed to create job indexes", "error", err)
+		}
+		cancelIndexes()
+		jobStore = mongoStore
+	}
+
+	jobPool := jobs.Init(jobStore, jobs.OptionsFromEnv())
+
+	// Job types, registered before the workers start
+	if d := push.Default(); d != nil {
+		d.Register(jobPool)
+	}
+	if d := digest.Default(); d != nil {
+		d.Register(jobPool)
+	}
+	if p := linkpreview.Default(); p != nil {
+		p.Register(jobPool)
+	}
+	events.Default().Register(jobPool) // Task events queued by EVENTS_BACKEND=jobs
+
+	health.Register("jobs", jobPool.HealthCheck)
+	jobPool.Start()
+	return jobPool
+}
+
+// initAPIKeys sets up the managed API keys (in the "apikeys" collection)
+// Until it runs only the shared API_KEY is accepted. Call it after
+// connectDatabase().
+func initAPIKeys() {
+	if memory != nil {
+		apikeys.Init(apikeys.NewMemoryStore())
+		return
+	}
+
+	keyStore := apikeys.NewMongoStore(database.GetNamedCollection("apikeys"))
+	indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := keyStore.EnsureIndexes(indexCtx); err != nil {
+		logger.Log.Warn("Failed to create API key indexes", "error", err)
+	}
+	cancelIndexes()
+
+	apikeys.Init(keyStore)
+}
+
+// ensureTaskIndexes creates the tasks collection's indexes
+// Failing isn't fatal: quota checks still work, just slower
+func ensureTaskIndexes() {
+	if memory != nil {
+		return
+	}
+	indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelIndexes()
+	if err := repository.NewMongoTaskRepository(database.GetCollection()).EnsureIndexes(indexCtx); err != nil {
+		logger.Log.Warn("Failed to create task indexes", "error", err)
+	}
+}
+
+// initPush sets up push notifications (subscriptions in the
+// "push_subscriptions" collection, preferences in "notification_settings"). Web Push needs VAPID_PRIVATE_KEY and
+// VAPID_SUBJECT, FCM needs FCM_CREDENTIALS_FILE; without either, devices
+// can't subscribe. Call it after connectDatabase() and before startJobs().
+func initPush() {
+	var store push.Store = push.NewMemoryStore()
+	if memory == nil {
+		mongoStore := push.NewMongoStore(database.GetNamedCollection("push_subscriptions"))
+		indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := mongoStore.EnsureIndexes(indexCtx); err != nil {
+			logger.Log.Warn("Failed to create push subscription indexes", "error", err)
+		}
+		cancelIndexes()
+		store = mongoStore
+	}
+
+	dispatcher, err := pushDispatcherFromEnv(store, notificationSettings())
+	if err != nil {
+		logger.Log.Error("Invalid push notification settings", "error", err)
+		log.Fatal(err)
+	}
+	push.Init(dispatcher)
+	logger.Log.Info("Push notifications ready",
+		"webpush", dispatcher.Enabled(push.TypeWebPush), "fcm", dispatcher.Enabled(push.TypeFCM))
+}
+
+// notificationSettings is where users' notification preferences are kept
+func notificationSettings() push.PreferenceStore {
+	if memory != nil {
+		return memory.preferences
+	}
+	return push.NewMongoPreferenceStore(database.GetNamedCollection("notification_settings"))
+}
+
+// initProfiles sets up the users' profiles (the "profiles" collection),
+// which hold the time zone due dates and the daily digest are read in
+func initProfiles() {
+	var store profile.Store = profile.NewMemoryStore()
+	if memory == nil {
+		store = profile.NewMongoStore(database.GetNamedCollection("profiles"))
+	}
+	profile.Init(profile.New(store))
+}
+
+// initSettings sets up the organization-wide settings (the "settings"
+// collection), cached in-process for settings.DefaultTTL
+func initSettings() {
+	var store settings.Store = settings.NewMemoryStore()
+	if memory == nil {
+		store = settings.NewMongoStore(database.GetNamedCollection("settings"))
+	}
+	settings.Init(settings.New(store, 0, nil))
+}
+
+// initRecording lets admins record callers' requests and responses (the
+// capped "recordings" collection, see internal/recording)
+func initRecording() {
+	if memory != nil {
+		recording.Init(recording.New(recording.NewMemoryStore(), nil))
+		return
+	}
+
+	store := recording.NewMongoStore(database.GetNamedCollection("recordings"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := store.EnsureCollection(ctx); err != nil {
+		logger.Log.Warn("Failed to create the recordings collection", "error", err)
+	}
+	recording.Init(recording.New(store, nil))
+}
+
+// initLinkPreviews keeps the previews of the links in task descriptions (the
+// "link_previews" collection, see internal/linkpreview). Call it after
+// initPush(), which sets up the outbound client the pages are fetched with.
+func initLinkPreviews() {
+	var store linkpreview.Store = linkpreview.NewMemoryStore()
+	if memory == nil {
+		store = linkpreview.NewMongoStore(database.GetNamedCollection("link_previews"))
+	}
+	linkpreview.Init(linkpreview.New(store, outbound.Client(), nil))
+}
+
+// initAnomalies starts watching each caller's usage for bursts and mass
+// deletions (thresholds in the settings, channels from ANOMALY_ALERT_*)
+// Call it after initPush() and initDigest(), which set up the outbound
+// client and the mail server, and pass the detector to drain().
+func initAnomalies() *anomaly.Detector {
+	channels, err := anomaly.ChannelsFromEnv(email.Default(), outbound.Client())
+	if err != nil {
+		logger.Log.Error("Invalid anomaly alert settings", "error", err)
+		log.Fatal(err)
+	}
+	detector := anomaly.Init(anomaly.New(anomaly.Options{Channels: channels}))
+	detector.Start()
+	logger.Log.Info("Anomaly detection ready", "channels", len(channels)+1) // +1: the log
+	return detector
+}
+
+// initArchive sets up auto-archiving ("archived_tasks" collection) and the
+// activity record it writes ("activity"). Call it after initProfiles() and
+// before startScheduler(), which schedules the daily run.
+func initArchive() {
+	var store archive.Store = archive.NewMemoryStore()
+	var record activity.Store = activity.NewMemoryStore()
+	if memory == nil {
+		mongoStore := archive.NewMongoStore(database.GetNamedCollection("archived_tasks"))
+		mongoRecord := activity.NewMongoStore(database.GetNamedCollection("activity"))
+		indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := mongoStore.EnsureIndexes(indexCtx); err != nil {
+			logger.Log.Warn("Failed to create archive indexes", "error", err)
+		}
+		if err := mongoRecord.EnsureIndexes(indexCtx); err != nil {
+			logger.Log.Warn("Failed to create activity indexes", "error", err)
+		}
+		cancelIndexes()
+		store, record = mongoStore, mongoRecord
+	}
+
+	activity.Init(record)
+	archive.Init(archive.New(profile.Default(), taskStore(), store, record))
+}
+
+// initDigest sets up the daily digest email, when there's a mail server
+// (SMTP_HOST). Call it after initProfiles() and before startJobs() and startScheduler(), which
+// register its job and its hourly check.
+func initDigest() {
+	sender, err := email.FromEnv()
+	if err != nil {
+		logger.Log.Error("Invalid email settings", "error", err)
+		log.Fatal(err)
+	}
+	if sender == nil {
+		logger.Log.Info("Daily digest off: SMTP_HOST not set")
+		return
+	}
+	email.Init(sender)
+
+	opts := digest.OptionsFromEnv()
+	digest.Init(digest.New(notificationSettings(), profile.Default(), taskStore(), sender, opts))
+	logger.Log.Info("Daily digest ready", "hour", opts.Hour)
+}
+
+// pushDispatcherFromEnv creates the dispatcher with the senders that are configured
+// Browsers pick their own push endpoints, so deliveries go through the
+// outbound client, which won't connect to the private network
+func pushDispatcherFromEnv(store push.Store, prefs push.PreferenceStore) (*push.Dispatcher, error) {
+	client := outbound.Init(outbound.OptionsFromEnv())
+	webPush, err := push.WebPushFromEnv(client)
+	if err != nil {
+		return nil, err
+	}
+	fcm, err := push.FCMFromEnv(client)
+	if err != nil {
+		return nil, err
+	}
+	return push.NewDispatcher(store, prefs, webPush, fcm), nil
+}
+
+// startScheduler starts the periodic task scheduler
+// The "scheduler_runs" collection makes sure that, with several replicas,
+// each tick of a task runs on only one of them
+func startScheduler() *scheduler.Scheduler {
+	var locker scheduler.Locker = scheduler.NewMemoryLocker()
+	if memory == nil {
+		mongoLocker := scheduler.NewMongoLocker(database.GetNamedCollection("scheduler_runs"))
+		indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := mongoLocker.EnsureIndexes(indexCtx); err != nil {
+			logger.Log.Warn("Failed to create scheduler indexes", "error", err)
+		}
+		cancelIndexes()
+		locker = mongoLocker
+	}
+
+	taskScheduler := scheduler.Init(locker)
+
+	// Periodic tasks, registered before the scheduler starts
+	if d := digest.Default(); d != nil {
+		if err := d.Schedule(taskScheduler); err != nil {
+			logger.Log.Error("Failed to schedule the daily digest", "error", err)
+		}
+	}
+	if a := archive.Default(); a != nil {
+		if err := a.Schedule(taskScheduler); err != nil {
+			logger.Log.Error("Failed to schedule auto-archiving", "error", err)
+		}
+	}
+
+	health.Register("scheduler", taskScheduler.HealthCheck)
+	taskScheduler.Start()
+	return taskScheduler
+}
+
+// startStatsCounter keeps the task counts behind GET /stats up to date from
+// the tasks collection's change stream (see internal/stats)
+// The in-memory tasks count themselves, so there's no counter for them (nil).
+func startStatsCounter() *stats.Counter {
+	if memory != nil {
+		return nil
+	}
+	counter := stats.NewCounter(database.GetCollection(), stats.Options{})
+	counter.Start()
+	return counter
+}
+
+// drain lets running tasks, jobs and anomaly checks finish, then closes the
+// database connection. Call it once nothing can enqueue new jobs any more.
+// detector is nil where initAnomalies() didn't run (todo worker).
+func drain(taskScheduler *scheduler.Scheduler, jobPool *jobs.Pool, counter *stats.Counter, detector *anomaly.Detector) {
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelDrain()
+	_ = events.Default().Shutdown(drainCtx) // May still queue jobs
+	if detector != nil {
+		_ = detector.Shutdown(drainCtx) // A check may be sending an alert (email, webhook)
+	}
+	if counter != nil {
+		_ = counter.Shutdown(drainCtx)
+	}
+	_ = taskScheduler.Shutdown(drainCtx)
+	_ = jobPool.Shutdown(drainCtx)
+	database.Close()
+}
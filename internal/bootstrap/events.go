@@ -0,0 +1,154 @@
+// ============================================================================
+// EVENT DETECTION
+// ============================================================================
+// The same function can sit behind four AWS HTTP front ends, and each one
+// sends a differently shaped event:
+//
+//	API Gateway HTTP API (payload v2)  {"version": "2.0", "rawPath": "/tasks", ...}
+//	Lambda Function URL (payload v2)   same, with "domainName": "<id>.lambda-url.<region>.on.aws"
+//	API Gateway REST API (payload v1)  {"httpMethod": "GET", "path": "/tasks", ...}
+//	ALB target group                   {"httpMethod": "GET", ..., "requestContext": {"elb": {...}}}
+//
+// Scheduled warm-up pings ({"warmer": true} or an EventBridge "Scheduled Event")
+// aren't HTTP requests at all and are answered by warmUp (warmup.go).
+//
+// detectEvent looks at the raw JSON, then proxyEvent decodes it into the
+// right type and replies in the matching response format.
+
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// eventKind is the front end that sent an event
+type eventKind string
+
+const (
+	eventHTTPAPI eventKind = "apigw-v2" // API Gateway HTTP API
+	eventFuncURL eventKind = "url"      // Lambda Function URL
+	eventRESTAPI eventKind = "apigw-v1" // API Gateway REST API
+	eventALB     eventKind = "alb"      // Application Load Balancer
+	eventWarmer  eventKind = "warmer"   // Scheduled warm-up ping
+)
+
+// eventProbe holds just the fields needed to tell the event kinds apart
+type eventProbe struct {
+	Version        string `json:"version"`
+	HTTPMethod     string `json:"httpMethod"`
+	Warmer         bool   `json:"warmer"`
+	Source         string `json:"source"`
+	DetailType     string `json:"detail-type"`
+	RequestContext struct {
+		ELB        *struct{} `json:"elb"`
+		DomainName string    `json:"domainName"`
+	} `json:"requestContext"`
+}
+
+// detectEvent works out which front end sent the payload
+func detectEvent(payload []byte) (eventKind, error) {
+	var probe eventProbe
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return "", fmt.Errorf("decode event: %w", err)
+	}
+
+	switch {
+	case probe.Warmer, probe.Source == "serverless-plugin-warmup",
+		probe.Source == "aws.events" && probe.DetailType == "Scheduled Event":
+		return eventWarmer, nil
+	case probe.RequestContext.ELB != nil:
+		return eventALB, nil
+	case probe.Version == "2.0" && strings.Contains(probe.RequestContext.DomainName, ".lambda-url."):
+		return eventFuncURL, nil
+	case probe.Version == "2.0":
+		return eventHTTPAPI, nil
+	case probe.HTTPMethod != "":
+		return eventRESTAPI, nil
+	default:
+		return "", fmt.Errorf("unsupported event: not from API Gateway or an ALB")
+	}
+}
+
+// proxyEvent runs an HTTP event of the given kind through httpHandler
+// It returns the response to send back (already in the front end's format)
+// and its status code for the invocation metrics.
+func proxyEvent(ctx context.Context, kind eventKind, payload []byte, traceID string) (any, int, error) {
+	switch kind {
+	case eventALB:
+		var req events.ALBTargetGroupRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, 0, err
+		}
+		req.Headers, req.MultiValueHeaders = addTraceHeader(req.Headers, req.MultiValueHeaders, traceID)
+		resp, err := httpadapter.NewALB(httpHandler).ProxyWithContext(ctx, req)
+		return resp, resp.StatusCode, err
+
+	case eventRESTAPI:
+		var req events.APIGatewayProxyRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, 0, err
+		}
+		req.Headers, req.MultiValueHeaders = addTraceHeader(req.Headers, req.MultiValueHeaders, traceID)
+		resp, err := httpadapter.New(httpHandler).ProxyWithContext(ctx, req)
+		return resp, resp.StatusCode, err
+
+	default:
+		// HTTP API and Function URL events have the same shape; Function URLs
+		// have no stage, so the path is already what the router expects
+		var req events.APIGatewayV2HTTPRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, 0, err
+		}
+		if kind == eventFuncURL {
+			useFunctionURL(req.RequestContext.DomainName)
+		}
+		req.Headers, _ = addTraceHeader(req.Headers, nil, traceID)
+		resp, err := httpadapter.NewV2(httpHandler).ProxyWithContext(ctx, req)
+		return resp, resp.StatusCode, err
+	}
+}
+
+// functionURLOnce makes sure the OpenAPI servers list is only filled in once
+var functionURLOnce sync.Once
+
+// useFunctionURL lists https://<domain> as the server in the OpenAPI docs
+// The Function URL isn't known until the function is deployed, so it's taken
+// from the first request - unless API_BASE_URL already names a server
+// (e.g. a custom domain in front of the Function URL).
+func useFunctionURL(domain string) {
+	functionURLOnce.Do(func() {
+		if lambdaAPI == nil || len(lambdaAPI.OpenAPI().Servers) > 0 {
+			return
+		}
+		lambdaAPI.OpenAPI().Servers = []*huma.Server{{URL: "https://" + domain}}
+	})
+}
+
+// addTraceHeader adds the X-Ray trace ID as an x-amzn-trace-id header
+// unless the front end already forwarded one. v1 and ALB events may carry
+// headers in either map (ALB only fills multiValueHeaders when that's
+// turned on for the target group), so both are updated.
+func addTraceHeader(headers map[string]string, multi map[string][]string, traceID string) (map[string]string, map[string][]string) {
+	if traceID == "" {
+		return headers, multi
+	}
+
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	if headers["x-amzn-trace-id"] == "" {
+		headers["x-amzn-trace-id"] = traceID
+	}
+	if multi != nil && len(multi["x-amzn-trace-id"]) == 0 {
+		multi["x-amzn-trace-id"] = []string{traceID}
+	}
+	return headers, multi
+}
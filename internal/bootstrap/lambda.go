@@ -0,0 +1,242 @@
+// ============================================================================
+// LAMBDA MODE
+// ============================================================================
+// This file runs the API as an AWS Lambda function ("todo lambda", cmd/lambda)
+// It wraps the HTTP server to work with API Gateway (HTTP and REST API),
+// Lambda Function URL and ALB events
+
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	// AWS Lambda libraries
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/danielgtaylor/huma/v2"
+
+	// Our packages
+	"go-todo-api/internal/apikeys"
+	"go-todo-api/internal/app"
+	"go-todo-api/internal/database"
+	"go-todo-api/internal/handlers"
+	"go-todo-api/internal/health"
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/metrics"
+	"go-todo-api/internal/push"
+	"go-todo-api/internal/remoteconfig"
+	"go-todo-api/internal/tracing"
+)
+
+var (
+	// httpHandler is initialized once and reused across Lambda invocations
+	httpHandler http.Handler
+
+	// lambdaAPI is the Huma API behind httpHandler, kept so the OpenAPI servers
+	// list can be filled in from the first Function URL request (events.go)
+	lambdaAPI huma.API
+
+	// emf writes CloudWatch Embedded Metric Format lines to stdout
+	// CloudWatch turns them into metrics without a Prometheus stack
+	emf *metrics.EMFLogger
+
+	// coldStart is true until the first invocation of this container finishes
+	coldStart = true
+
+	// remoteConfig loads secrets from SSM and flags from AppConfig
+	// (nil when SSM_PARAMETERS and APPCONFIG_* aren't set)
+	remoteConfig *remoteconfig.Loader
+)
+
+// Lambda initializes the function and hands control to the Lambda runtime
+// It never returns.
+func Lambda() {
+	shutdown := initLambda()
+	defer shutdown()
+
+	// Start Lambda runtime
+	lambda.Start(handleInvocation)
+}
+
+// initLambda runs once when Lambda container starts (cold start)
+// This is where we do expensive initialization
+// It returns the tracing cleanup function.
+func initLambda() func() {
+	// Initialize logger
+	logger.Init()
+	logger.Log.Info("Lambda: Initializing...")
+
+	// Initialize CloudWatch EMF metrics
+	// Namespace defaults to GoTodoAPI; metrics are split by function name
+	namespace := os.Getenv("METRICS_NAMESPACE")
+	if namespace == "" {
+		namespace = "GoTodoAPI"
+	}
+	emf = metrics.NewEMFLogger(os.Stdout, namespace, map[string]string{
+		"FunctionName": os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+	})
+
+	// Load secrets (MONGO_URI, API_KEY...) from Parameter Store and feature
+	// flags from AppConfig before anything reads them from the environment
+	if opts := remoteconfig.OptionsFromEnv(); opts.Enabled() {
+		remoteConfig = remoteconfig.New(opts)
+		if err := remoteConfig.Load(context.Background()); err != nil {
+			logger.Log.Error("Lambda: Failed to load remote configuration", "error", err)
+			log.Fatal(err)
+		}
+	}
+
+	// MongoDB is connected lazily (app.Options.LazyDatabase): the first
+	// request that needs it connects, with retries, and gets a 503 if Atlas
+	// is briefly unreachable - a failed cold start would fail every request
+	health.Register("mongodb", database.HealthCheck)
+
+	// Jobs can be enqueued and queried from Lambda, but no workers run here:
+	// a Lambda container is frozen between invocations
+	database.OnConnect(func() {
+		jobs.Init(jobs.NewMongoStore(database.GetNamedCollection("jobs")), jobs.Options{})
+		// Managed API keys are accepted once MongoDB is connected
+		apikeys.Init(apikeys.NewMongoStore(database.GetNamedCollection("apikeys")))
+		// Users' time zones, for due dates, and their archived tasks
+		// (archiving itself runs on the workers)
+		initProfiles()
+		initArchive()
+		// Organization-wide settings (edited on the admin listener)
+		initSettings()
+		// Devices can subscribe, and notifications are queued for the workers
+		dispatcher, err := pushDispatcherFromEnv(
+			push.NewMongoStore(database.GetNamedCollection("push_subscriptions")),
+			notificationSettings(),
+		)
+		if err != nil {
+			logger.Log.Error("Push notifications disabled: invalid settings", "error", err)
+			return
+		}
+		push.Init(dispatcher)
+		// Link previews are read here, and fetched on the workers
+		initLinkPreviews()
+	})
+
+	// Try once now so a healthy cold start doesn't make the first request wait
+	connectCtx, cancelConnect := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := database.EnsureConnected(connectCtx); err != nil {
+		logger.Log.Warn("Lambda: MongoDB not reachable at cold start, will retry on first request", "error", err)
+	}
+	cancelConnect()
+
+	// Initialize OpenTelemetry tracing
+	shutdown := tracing.Init(tracing.ServiceName)
+
+	// Read the shared settings (only the request timeout, rate limit,
+	// cache and quotas matter here - API Gateway does the listening)
+	serverConfig, profile := loadSettings()
+	requireMongo(serverConfig, "lambda") // Every container would have tasks of its own
+	registerHooks(serverConfig)
+	initEvents(serverConfig, true)
+
+	// Build the same router, middleware and endpoints as the regular server
+	// API_BASE_URL is the API Gateway URL shown in the OpenAPI docs
+	// (leave it empty with a Function URL - it's picked up from the first request)
+	httpHandler, lambdaAPI = app.New(app.Options{
+		ServerURL:      os.Getenv("API_BASE_URL"),
+		RequestTimeout: serverConfig.Limits.RequestTimeout,
+		ExportTimeout:  serverConfig.Limits.ExportTimeout,
+		RateLimit:      rateLimitConfig(serverConfig),
+		TaskCacheTTL:   serverConfig.TaskCacheTTL,
+		StatsCacheTTL:  serverConfig.StatsCacheTTL,
+		Quotas:         handlers.Quotas{MaxTasks: serverConfig.Quotas.MaxTasks},
+		ListDefaults:   handlers.ListDefaults{MobileLimit: serverConfig.MobileListLimit},
+		BulkGuard:      handlers.BulkGuard{ConfirmAbove: serverConfig.BulkConfirmAbove},
+		LenientJSON:    serverConfig.LenientJSON,
+		AuthExempt:     authExemptions(serverConfig),
+		TrustedProxies: serverConfig.TrustedProxies,
+		Profile:        profile,
+		LazyDatabase:   true,
+	})
+
+	logger.Log.Info("Lambda: Initialization complete")
+	return shutdown
+}
+
+// handleInvocation is called for each Lambda invocation
+// It accepts API Gateway (HTTP and REST API), Function URL and ALB events
+// (see events.go), reuses the httpHandler initialized in initLambda() and
+// publishes metrics for every invocation
+func handleInvocation(ctx context.Context, payload json.RawMessage) (any, error) {
+	start := time.Now()
+	dbFailuresBefore := database.CommandFailures()
+	poolBefore := database.GetPoolStats()
+
+	// Lambda hands us the X-Ray trace ID in the invocation context. If the
+	// front end didn't forward it as a header, it's added so the tracing
+	// middleware continues the X-Ray trace instead of starting a new root
+	traceID, _ := ctx.Value("x-amzn-trace-id").(string)
+
+	// Pick up rotated secrets and changed flags once REMOTE_CONFIG_TTL has passed
+	if remoteConfig != nil {
+		remoteConfig.Refresh(ctx)
+	}
+
+	kind, err := detectEvent(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Warm-up pings skip the HTTP stack and the request metrics
+	if kind == eventWarmer {
+		return warmUp(ctx), nil
+	}
+
+	resp, status, err := proxyEvent(ctx, kind, payload, traceID)
+
+	poolAfter := database.GetPoolStats()
+	recordInvocation(time.Since(start), status, database.CommandFailures()-dbFailuresBefore,
+		poolAfter.CheckoutWait-poolBefore.CheckoutWait, poolAfter.Created-poolBefore.Created)
+	return resp, err
+}
+
+// recordInvocation emits the per-invocation EMF metrics:
+// - InvocationLatency: time spent handling the request
+// - ColdStart: 1 on the first invocation of a new container, 0 after
+// - DBErrors: MongoDB commands that failed during this invocation
+// - Throttles: 1 if the request was rejected by the rate limiter (429)
+// - ServerErrors: 1 if the response was a 5xx
+// - DBPoolWait: time spent waiting for a MongoDB connection
+// - DBConnectionsOpened: MongoDB connections opened during this invocation
+//
+// A container handles one invocation at a time, so the before/after
+// differences of the database counters belong to this invocation alone
+func recordInvocation(latency time.Duration, status int, dbErrors int64, poolWait time.Duration, connectionsOpened int64) {
+	cold := 0.0
+	if coldStart {
+		cold = 1
+		coldStart = false
+	}
+
+	throttled := 0.0
+	if status == http.StatusTooManyRequests {
+		throttled = 1
+	}
+
+	serverError := 0.0
+	if status >= 500 {
+		serverError = 1
+	}
+
+	if err := emf.Emit(
+		metrics.Metric{Name: "InvocationLatency", Unit: metrics.UnitMilliseconds, Value: float64(latency.Microseconds()) / 1000},
+		metrics.Metric{Name: "ColdStart", Unit: metrics.UnitCount, Value: cold},
+		metrics.Metric{Name: "DBErrors", Unit: metrics.UnitCount, Value: float64(dbErrors)},
+		metrics.Metric{Name: "Throttles", Unit: metrics.UnitCount, Value: throttled},
+		metrics.Metric{Name: "ServerErrors", Unit: metrics.UnitCount, Value: serverError},
+		metrics.Metric{Name: "DBPoolWait", Unit: metrics.UnitMilliseconds, Value: float64(poolWait.Microseconds()) / 1000},
+		metrics.Metric{Name: "DBConnectionsOpened", Unit: metrics.UnitCount, Value: float64(connectionsOpened)},
+	); err != nil {
+		logger.Log.Warn("Failed to emit EMF metrics", "error", err)
+	}
+}
@@ -0,0 +1,101 @@
+// ============================================================================
+// MIGRATE AND SEED MODES
+// ============================================================================
+// One-off commands that prepare a database and exit:
+//   todo migrate  creates the MongoDB indexes (run it before a deploy)
+//   todo seed     adds a few sample tasks to an empty database (for demos)
+
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"go-todo-api/internal/activity"
+	"go-todo-api/internal/apikeys"
+	"go-todo-api/internal/archive"
+	"go-todo-api/internal/database"
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/push"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/scheduler"
+)
+
+// Migrate creates every index the API relies on
+// Index creation is idempotent, so running it twice is harmless.
+// ("serve" also creates them at startup; this lets a deploy do it up front.)
+func Migrate() {
+	logger.Init()
+	database.Connect()
+	defer database.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := repository.NewMongoTaskRepository(database.GetCollection()).EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create task indexes: ", err)
+	}
+	if err := jobs.NewMongoStore(database.GetNamedCollection("jobs")).EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create job indexes: ", err)
+	}
+	if err := scheduler.NewMongoLocker(database.GetNamedCollection("scheduler_runs")).EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create scheduler indexes: ", err)
+	}
+	if err := apikeys.NewMongoStore(database.GetNamedCollection("apikeys")).EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create API key indexes: ", err)
+	}
+	if err := push.NewMongoStore(database.GetNamedCollection("push_subscriptions")).EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create push subscription indexes: ", err)
+	}
+	if err := archive.NewMongoStore(database.GetNamedCollection("archived_tasks")).EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create archive indexes: ", err)
+	}
+	if err := activity.NewMongoStore(database.GetNamedCollection("activity")).EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create activity indexes: ", err)
+	}
+
+	logger.Log.Info("Migrations complete", "collections", []string{"tasks", "jobs", "scheduler_runs", "apikeys", "push_subscriptions", "archived_tasks", "activity"})
+}
+
+// sampleTasks are inserted by Seed
+var sampleTasks = []models.Task{
+	{Title: "Read the README", Description: "Find out how the API is put together"},
+	{Title: "Open the API docs", Description: "Try the endpoints at /docs"},
+	{Title: "Create your first task", Description: "POST /tasks with a title", Completed: true},
+}
+
+// Seed inserts the sample tasks when the tasks collection is empty
+// It never touches a database that already has tasks.
+func Seed() {
+	logger.Init()
+	database.Connect()
+	defer database.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection()
+	count, err := collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		log.Fatal("Failed to count tasks: ", err)
+	}
+	if count > 0 {
+		logger.Log.Info("Tasks collection is not empty, skipping seed", "tasks", count)
+		return
+	}
+
+	docs := make([]interface{}, len(sampleTasks))
+	for i, task := range sampleTasks {
+		docs[i] = task
+	}
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		log.Fatal("Failed to insert sample tasks: ", err)
+	}
+
+	logger.Log.Info("Seeded sample tasks", "tasks", len(docs))
+}
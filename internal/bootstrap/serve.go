@@ -0,0 +1,276 @@
+// ============================================================================
+// SERVE MODE
+// ============================================================================
+// This file runs the long-lived HTTP server ("todo serve", cmd/api)
+
+package bootstrap
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES (built into Go)
+	"context"   // context = for cancelling work when the server stops
+	"fmt"       // fmt = "format" - for printing text to the console (like console.log)
+	"log"       // log = for error messages and logging
+	"os"        // os = for operating system signals
+	"os/signal" // os/signal = for catching Ctrl+C and SIGTERM
+	"syscall"   // syscall = for the SIGTERM signal constant
+
+	// OUR OWN PACKAGES (code we wrote in this project)
+	"go-todo-api/internal/app"        // Router, middleware and endpoints (shared with Lambda)
+	"go-todo-api/internal/handlers"   // Per-user quota settings
+	"go-todo-api/internal/logger"     // Our structured logged setup
+	"go-todo-api/internal/middleware" // The rate limiter shared with the admin listener
+	"go-todo-api/internal/server"     // HTTP/HTTPS listeners (TLS, autocert, redirect)
+	"go-todo-api/internal/tracing"    // Our tracing code setup
+	"go-todo-api/internal/version"    // Build version for the startup log
+)
+
+// ============================================================================
+// SERVE FUNCTION
+// ============================================================================
+// Serve starts the API server and blocks until Ctrl+C or SIGTERM
+// banner prints the emoji startup banner for humans (--banner)
+func Serve(banner bool) {
+
+	// ------------------------------------------------------------------------
+	// STEP 0: INITIALIZE STRUCTURED LOGGING
+	// ------------------------------------------------------------------------
+	// Set up JSON structured logging for better observability
+	// This creates a global logger that all parts of the app can use
+	logger.Init()
+
+	// Read where to listen (HOST, PORT), the route prefix (BASE_PATH)
+	// and the environment profile (APP_ENV)
+	serverConfig, profile := loadSettings()
+
+	// Task hooks turned on by the settings (TASK_TITLE_PATTERN), and where
+	// the task event subscribers run (EVENTS_BACKEND)
+	registerHooks(serverConfig)
+	initEvents(serverConfig, false)
+
+	// ------------------------------------------------------------------------
+	// STEP 1: CONNECT TO DATABASE
+	// ------------------------------------------------------------------------
+	// Before we can handle any requests, we need to connect to MongoDB
+	// database.Connect() (internal/database/mongo.go) reads the MONGO_URI
+	// from .env and connects; with DB_DRIVER=memory the data is kept in
+	// this process instead, and there's nothing to connect to (storage.go)
+	connectDatabase(serverConfig)
+	// After this line, we have somewhere to keep the tasks!
+
+	// Accept the API keys managed at /admin/apikeys besides API_KEY
+	initAPIKeys()
+
+	// Index the task owners, so quota checks stay cheap
+	ensureTaskIndexes()
+
+	// Users' time zones and auto-archive settings, and the archive itself
+	initProfiles()
+	initArchive()
+
+	// Organization-wide settings, managed at /admin/settings
+	initSettings()
+
+	// Request/response recording, started at /admin/recordings/targets
+	initRecording()
+
+	// Push notifications and the daily digest email (their job types and
+	// periodic tasks are registered by startJobs and startScheduler)
+	initPush()
+	initDigest()
+
+	// Previews of the pages linked from task descriptions (fetched on the
+	// workers)
+	initLinkPreviews()
+
+	// Alerts on unusual usage per API key: sudden bursts of requests, many
+	// tasks deleted at once (counted by the requests this server handles)
+	detector := initAnomalies()
+
+	// Start the background job workers, the periodic task scheduler and the
+	// task counter behind GET /stats
+	// (see bootstrap.go - "todo worker" runs the same three without HTTP)
+	jobPool := startJobs()
+	taskScheduler := startScheduler()
+	counter := startStatsCounter()
+
+	// ------------------------------------------------------------------------
+	// STEP 2: INITIALIZE TRACING
+	// ------------------------------------------------------------------------
+	// Set up OpenTelemetry tracing to track request performance
+	// This returns a cleanup function that we'll call when the server shuts down
+	shutdown := tracing.Init("todo-api")
+	defer shutdown() // Call shutdown when Serve() exits to flush traces
+
+	// ------------------------------------------------------------------------
+	// STEP 3: BUILD THE ROUTER, MIDDLEWARE AND ENDPOINTS
+	// ------------------------------------------------------------------------
+	// The router, the middleware stack (tracing, logging, rate limiting, auth...)
+	// and every endpoint live in internal/app, shared with the Lambda entry point
+	// so the two can't drift apart
+	// The admin listener resets counts in the same limiter (see app.NewAdmin)
+	limiter := middleware.NewRateLimiter(rateLimitConfig(serverConfig))
+	router, _ := app.New(app.Options{
+		ServerURL: serverConfig.URL(),    // Shown in the /docs page
+		BasePath:  serverConfig.BasePath, // e.g. /api → GET /api/tasks
+		// Deadline for each request (REQUEST_TIMEOUT)
+		RequestTimeout: serverConfig.Limits.RequestTimeout,
+		// Longer deadline for streaming GET /export (EXPORT_TIMEOUT)
+		ExportTimeout: serverConfig.Limits.ExportTimeout,
+		// Requests per IP (RATE_LIMIT_*)
+		RateLimiter: limiter,
+		// Micro-cache for GET /tasks (TASKS_CACHE_TTL)
+		TaskCacheTTL: serverConfig.TaskCacheTTL,
+		// Snapshot behind GET /stats (STATS_CACHE_TTL)
+		StatsCacheTTL: serverConfig.StatsCacheTTL,
+		// Where the tasks are kept with DB_DRIVER=memory (nil = MongoDB)
+		TaskRepository: apiTaskStore(),
+		Sandboxes:      apiSandboxes(),
+		// Per-user limits (QUOTA_MAX_TASKS)
+		Quotas: handlers.Quotas{MaxTasks: serverConfig.Quotas.MaxTasks},
+		// Page size for mobile clients (MOBILE_LIST_LIMIT)
+		ListDefaults: handlers.ListDefaults{MobileLimit: serverConfig.MobileListLimit},
+		// Bulk changes that need ?confirm=<count> (BULK_CONFIRM_ABOVE)
+		BulkGuard: handlers.BulkGuard{ConfirmAbove: serverConfig.BulkConfirmAbove},
+		// Drop unknown body fields instead of a 422 (JSON_UNKNOWN_FIELDS)
+		LenientJSON: serverConfig.LenientJSON,
+		// Requests served without an API key (AUTH_EXEMPT)
+		AuthExempt: authExemptions(serverConfig),
+		// Proxies allowed to set X-Forwarded-For (TRUSTED_PROXIES)
+		TrustedProxies: serverConfig.TrustedProxies,
+		// CORS origins and error detail for this environment
+		Profile: profile,
+		// /admin/* moves to the admin listener unless ADMIN_PORT=0
+		SeparateAdmin: serverConfig.AdminAddr() != "",
+	})
+
+	// ------------------------------------------------------------------------
+	// STEP 4: LOG STARTUP INFORMATION
+	// ------------------------------------------------------------------------
+	// One structured line with everything needed to find the server, so
+	// production logs stay parseable. The server logs event=ready once the
+	// port is bound. Run with --banner for the friendly version.
+	baseURL := serverConfig.URL()
+	logger.Log.Info("Server starting",
+		"url", baseURL,
+		"addr", serverConfig.Addr(),
+		"env", profile.Env,
+		"version", version.Version,
+		"docs", baseURL+"/docs",
+	)
+	if banner {
+		printBanner(baseURL, serverConfig.Addr())
+	}
+
+	// ------------------------------------------------------------------------
+	// STEP 5: START THE HTTP SERVER
+	// ------------------------------------------------------------------------
+	// This is the most important line - it actually starts the web server!
+
+	// The address comes from HOST and PORT, e.g. ":8080" or "127.0.0.1:8080"
+	// ":8080" means "listen on all network interfaces on port 8080"
+	// "127.0.0.1:8080" means "only accept connections from this machine"
+	//
+	// With TLS_CERT_FILE/TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS set, the server
+	// speaks HTTPS and a second listener (HTTP_REDIRECT_PORT) redirects to it
+
+	// ctx is cancelled when we receive Ctrl+C (SIGINT) or SIGTERM
+	// (what Docker, Kubernetes and systemd send to stop a process)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The admin listener (/admin/*, /debug/pprof) runs next to it on
+	// ADMIN_HOST:ADMIN_PORT, localhost:9090 by default. If it can't start,
+	// stop() takes the main server down with it
+	adminErr := make(chan error, 1)
+	go func() {
+		err := server.ListenAndServeAdmin(ctx, serverConfig, app.NewAdmin(limiter))
+		if err != nil {
+			stop()
+		}
+		adminErr <- err
+	}()
+
+	// server.ListenAndServe() starts the server and BLOCKS until ctx is cancelled
+	// This means the program doesn't exit - it keeps running, waiting for requests
+	err := server.ListenAndServe(ctx, serverConfig, router)
+	stop()
+	if errAdmin := <-adminErr; err == nil {
+		err = errAdmin
+	}
+
+	// ------------------------------------------------------------------------
+	// STEP 6: SHUT DOWN GRACEFULLY
+	// ------------------------------------------------------------------------
+	// No new requests can enqueue jobs now - let running tasks, jobs and
+	// anomaly checks finish, then close the database connection
+	drain(taskScheduler, jobPool, counter, detector)
+
+	// log.Fatal() means "if the server failed to start, print the error and exit"
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger.Log.Info("Server stopped")
+}
+
+// ============================================================================
+// STARTUP BANNER
+// ============================================================================
+// printBanner prints the human-friendly startup message (--banner)
+// fmt.Println() prints text to the console (like console.log in JavaScript)
+func printBanner(baseURL, addr string) {
+	fmt.Printf("🚀 Server starting on %s (listening on %s)\n", baseURL, addr)
+	fmt.Println("✨ Framework: Huma v2 with Chi router")
+	fmt.Println("✨ Middleware enabled: Logging, CORS, Authentication")
+	fmt.Println("📁 Production structure: cmd/ and internal/ packages")
+	fmt.Printf("🖥️  Web UI: %s/\n", baseURL)
+	fmt.Println("📚 OpenAPI Documentation available at:")
+	fmt.Printf("  - %s/docs (Interactive API docs)\n", baseURL)
+	fmt.Printf("  - %s/openapi.json (OpenAPI spec)\n", baseURL)
+	fmt.Printf("  - %s/openapi.yaml (OpenAPI spec)\n", baseURL)
+	fmt.Println("\n🎯 Try these endpoints:")
+	fmt.Println("  - GET    /health")
+	fmt.Println("  - GET    /healthz")
+	fmt.Println("  - GET    /readyz")
+	fmt.Println("  - GET    /tasks")
+	fmt.Println("  - POST   /tasks")
+	fmt.Println("  - GET    /tasks/{id}")
+	fmt.Println("  - PUT    /tasks/{id}")
+	fmt.Println("  - DELETE /tasks/{id}")
+}
+
+// ============================================================================
+// HOW THIS ALL WORKS TOGETHER
+// ============================================================================
+//
+// 1. Program starts → main() calls Serve()
+// 2. Connect to MongoDB database
+// 3. Initialize tracing requests
+// 4. Create a router (Chi) to handle different URLs
+// 5. Add middleware (tracing, logging, CORS) that runs before every request
+// 6. Wrap router with Huma for automatic docs and validation
+// 7. Register 6 endpoints (health check + 5 CRUD operations)
+// 8. Log where the server is (or print the emoji banner with --banner)
+// 9. Start HTTP server on HOST:PORT (default :8080, blocks forever, handling requests)
+//
+// When a request comes in:
+// Request → Middleware (logging, CORS) → Router (finds matching handler)
+//        → Handler (your code) → Response back to client
+//
+// Example flow for "GET /tasks":
+// 1. Browser sends: GET http://localhost:8080/tasks
+// 2. Server receives request
+// 3. Tracing middleware creates a span for the request
+// 4. Logging middleware logs: "GET /tasks"
+// 5. Cors middleware adds Cors headers
+// 6. Auth middleware checks API key
+// 7. Router sees "/tasks" with GET method
+// 8. Router calls handlers.GetAllTasks()
+// 9. Handler queries MongoDB for all tasks
+// 10. Huma converts tasks to JSON
+// 11. Response sent back: [{"id": "...", "title": "..."}]
+// 12. Logging middleware logs: "GET /tasks 5ms"
+//
+// ============================================================================
@@ -0,0 +1,89 @@
+// ============================================================================
+// STORAGE
+// ============================================================================
+// DB_DRIVER picks where the data is kept: MongoDB (the default), or with
+// DB_DRIVER=memory the in-memory stores each package already has for its
+// tests, so the server runs without a database - for trying the API out and
+// for working on it. Everything is lost when the server stops, and only
+// "todo serve" can use it: the workers and Lambda run in other processes,
+// which can't see the server's memory.
+
+package bootstrap
+
+import (
+	"fmt"
+	"log"
+
+	"go-todo-api/internal/config"
+	"go-todo-api/internal/database"
+	"go-todo-api/internal/health"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/push"
+	"go-todo-api/internal/repository"
+)
+
+// memoryStores are the stores more than one part of the server shares with
+// DB_DRIVER=memory; the rest are created where they're used
+type memoryStores struct {
+	tasks       *repository.MemoryTaskRepository // The API, the archive and the digest
+	sandboxes   *repository.MemorySandboxes
+	preferences *push.MemoryPreferenceStore // Push notifications and the digest
+}
+
+// memory is set by connectDatabase with DB_DRIVER=memory (nil with MongoDB)
+var memory *memoryStores
+
+// connectDatabase connects to MongoDB, or sets up the in-memory stores
+// Call it after loadSettings(), which sets the query limits the task stores
+// are created with.
+func connectDatabase(serverConfig config.Server) {
+	if serverConfig.DBDriver == "memory" {
+		database.UseMemory()
+		memory = &memoryStores{
+			tasks:       repository.NewMemoryTaskRepository(),
+			sandboxes:   repository.NewMemorySandboxes(),
+			preferences: push.NewMemoryPreferenceStore(),
+		}
+		logger.Log.Warn("Data is kept in memory and lost when the server stops (DB_DRIVER=memory)")
+		return
+	}
+
+	database.Connect()
+	// Register MongoDB with the health registry so /health/details reports it
+	health.Register("mongodb", database.HealthCheck)
+}
+
+// requireMongo stops a mode that runs outside the server process, which
+// can't share its memory
+func requireMongo(serverConfig config.Server, mode string) {
+	if serverConfig.DBDriver == "memory" {
+		err := fmt.Errorf("DB_DRIVER=memory only works with todo serve, not todo %s", mode)
+		logger.Log.Error("Invalid configuration", "error", err)
+		log.Fatal(err)
+	}
+}
+
+// taskStore returns where the tasks are kept
+func taskStore() repository.TaskRepository {
+	if memory != nil {
+		return memory.tasks
+	}
+	return repository.NewMongoTaskRepository(database.GetCollection())
+}
+
+// apiTaskStore is the router's TaskRepository option: the in-memory tasks,
+// or nil for the handlers' MongoDB default
+func apiTaskStore() repository.TaskRepository {
+	if memory == nil {
+		return nil
+	}
+	return memory.tasks
+}
+
+// apiSandboxes is the router's Sandboxes option, like apiTaskStore
+func apiSandboxes() repository.Sandboxes {
+	if memory == nil {
+		return nil
+	}
+	return memory.sandboxes
+}
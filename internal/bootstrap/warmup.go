@@ -0,0 +1,43 @@
+// ============================================================================
+// WARM-UP PINGS
+// ============================================================================
+// A schedule (see serverless.yml) invokes the function every few minutes so
+// AWS keeps a container around and requests don't pay for a cold start.
+// Those pings only need to touch MongoDB so the connection pool stays open -
+// routing them through the router would log, trace and count them as traffic.
+
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"go-todo-api/internal/database"
+	"go-todo-api/internal/logger"
+)
+
+// warmUpResponse is returned to the scheduler (it only shows up in the
+// invocation logs, nobody waits on it)
+type warmUpResponse struct {
+	Warm      bool   `json:"warm"`
+	ColdStart bool   `json:"cold_start"` // This ping started the container
+	Database  string `json:"database"`   // "ok" or the ping error
+}
+
+// warmUp pings MongoDB (connecting if the cold start couldn't) and reports
+// whether this container was cold
+func warmUp(ctx context.Context) warmUpResponse {
+	resp := warmUpResponse{Warm: true, ColdStart: coldStart, Database: "ok"}
+	coldStart = false
+
+	pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := database.EnsureConnected(pingCtx); err != nil {
+		resp.Database = err.Error()
+		logger.Log.Warn("Lambda: Warm-up ping failed", "error", err)
+	} else {
+		logger.Log.Debug("Lambda: Warm-up ping", "cold_start", resp.ColdStart)
+	}
+
+	return resp
+}
@@ -0,0 +1,49 @@
+// ============================================================================
+// WORKER MODE
+// ============================================================================
+// This file runs the background job workers, the scheduler and the task
+// counter on their own ("todo worker"), so they can be scaled separately
+// from the HTTP servers
+
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/tracing"
+)
+
+// Worker processes jobs and runs periodic tasks until Ctrl+C or SIGTERM
+func Worker() {
+	logger.Init()
+	serverConfig, _ := loadSettings()
+	registerHooks(serverConfig) // They run for the task events queued as jobs
+
+	requireMongo(serverConfig, "worker") // The jobs are queued in the server's memory
+	connectDatabase(serverConfig)
+
+	shutdown := tracing.Init(tracing.ServiceName)
+	defer shutdown()
+
+	initProfiles()
+	initArchive()      // Archives old completed tasks (scheduled by startScheduler)
+	initPush()         // Sends the push notifications queued by the API
+	initDigest()       // Emails the daily digests
+	initLinkPreviews() // Fetches the pages linked from task descriptions
+	jobPool := startJobs()
+	taskScheduler := startScheduler()
+	counter := startStatsCounter()
+	logger.Log.Info("Worker ready", "event", "ready")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	logger.Log.Info("Worker stopping")
+	drain(taskScheduler, jobPool, counter, nil) // No API requests to watch
+	logger.Log.Info("Worker stopped")
+}
@@ -0,0 +1,41 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFake_AdvanceAndSet tests that a fake clock only moves when told to
+func TestFake_AdvanceAndSet(t *testing.T) {
+	// Arrange
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+
+	// Act + Assert
+	if got := fake.Now(); !got.Equal(start) {
+		t.Fatalf("Expected %v, got %v", start, got)
+	}
+
+	fake.Advance(90 * time.Second)
+	if got := fake.Now(); !got.Equal(start.Add(90 * time.Second)) {
+		t.Errorf("Expected 90s after start, got %v", got)
+	}
+
+	fake.Set(start)
+	if got := fake.Now(); !got.Equal(start) {
+		t.Errorf("Expected Set to move the clock back to %v, got %v", start, got)
+	}
+
+	t.Log("✅ Fake clock advanced and set")
+}
+
+// TestOrReal tests the default for a nil clock
+func TestOrReal(t *testing.T) {
+	if OrReal(nil) != Real {
+		t.Error("Expected nil to fall back to the real clock")
+	}
+	fake := NewFake(time.Time{})
+	if OrReal(fake) != Clock(fake) {
+		t.Error("Expected a set clock to be kept")
+	}
+}
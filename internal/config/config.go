@@ -0,0 +1,630 @@
+// Package config reads the server settings from environment variables
+// Everything has a sensible default, so the API runs with no configuration at all
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server holds where and how the HTTP server listens
+type Server struct {
+	// Host is the address to bind to
+	// Empty (the default) means every network interface.
+	// Set HOST=127.0.0.1 to only accept connections from this machine
+	// (e.g. when a reverse proxy runs next to the API).
+	Host string
+
+	// Port is the TCP port to listen on (default 8080)
+	Port int
+
+	// BasePath is a prefix for every route, e.g. "/api" → GET /api/tasks
+	// Empty (the default) serves routes at the root.
+	// Always starts with "/" and never ends with one.
+	BasePath string
+
+	// PublicURL is the URL clients use to reach the server, without the base path
+	// It's shown in the OpenAPI docs. Defaults to http://localhost:<port>
+	// (https://<first autocert domain> when autocert is on)
+	PublicURL string
+
+	// TLS configures native HTTPS (zero value = plain HTTP)
+	TLS TLS
+
+	// Limits protects the server from slow or oversized requests
+	Limits Limits
+
+	// RateLimit is how many requests each client IP may make
+	RateLimit RateLimit
+
+	// TaskCacheTTL is how long GET /tasks results are kept in memory
+	// 0 (the default) turns the micro-cache off
+	TaskCacheTTL time.Duration
+
+	// StatsCacheTTL is how long GET /stats answers from its snapshot before
+	// refreshing it in the background (default 1s; 0 turns it off)
+	StatsCacheTTL time.Duration
+
+	// EventsBackend is where the subscribers to task events run (see
+	// internal/events): "inline" in the request (the default), "async" on
+	// goroutines after the response, "jobs" on the job workers
+	EventsBackend string
+
+	// TaskIDFormat is the kind of ID new tasks get: "objectid" (the default)
+	// or "uuid" (UUIDv7). Existing tasks keep theirs (see models.TaskID).
+	TaskIDFormat string
+
+	// DBDriver is where the data is kept: "mongo" (the default) or "memory",
+	// maps in the server process that are lost when it stops (todo serve only)
+	DBDriver string
+
+	// Quotas are the per-user limits (zero value = no limits)
+	Quotas Quotas
+
+	// MobileListLimit is how many tasks GET /tasks returns to mobile clients
+	// that don't give ?limit= (default 50; 0 = every task, like other clients)
+	MobileListLimit int
+
+	// BulkConfirmAbove is how many tasks one request may update or delete
+	// before it needs ?confirm=<count> (default 100; 0 = any number)
+	BulkConfirmAbove int
+
+	// QueryLimits bound what one database query may cost
+	QueryLimits QueryLimits
+
+	// TitlePattern is a regular expression every new task's title must match
+	// (TASK_TITLE_PATTERN, e.g. ^[A-Z]+-[0-9]+ for a ticket number; nil = any title)
+	TitlePattern *regexp.Regexp
+
+	// LenientJSON drops unknown fields in request bodies instead of
+	// rejecting them with a 422 (JSON_UNKNOWN_FIELDS=ignore; default reject)
+	LenientJSON bool
+
+	// AuthExempt are the requests served without an API key
+	// (AUTH_EXEMPT; default DefaultAuthExempt: probes, docs and CORS preflights)
+	AuthExempt []AuthExemption
+
+	// TrustedProxies are the networks of the proxies in front of the API
+	// Only they may set the client IP with X-Forwarded-For (TRUSTED_PROXIES;
+	// default none: the connection's address is the client)
+	TrustedProxies []netip.Prefix
+
+	// Admin is the second listener for /admin/* and /debug/*
+	Admin Admin
+}
+
+// AuthExemption is one AUTH_EXEMPT entry: "GET /health", "/docs" or "OPTIONS *"
+// Paths are relative to BASE_PATH; a trailing "/*" matches everything below
+type AuthExemption struct {
+	Method string // HTTP method, "*" when the entry has none
+	Path   string // Path, prefix ending in "/*", or "*" for every path
+}
+
+// DefaultAuthExempt is used when AUTH_EXEMPT isn't set
+// Load balancers and Kubernetes can't send an API key with their probes,
+// the docs page and spec are public anyway, and browsers never send
+// credentials with a CORS preflight.
+const DefaultAuthExempt = "GET /health,GET /healthz,GET /readyz,GET /docs,GET /docs/assets/*,GET /openapi.json,GET /openapi.yaml,OPTIONS *"
+
+// Admin holds where the admin listener binds
+// It's plain HTTP on localhost by default, so operational endpoints are only
+// reachable from the machine itself (or through an SSH tunnel / kubectl port-forward)
+type Admin struct {
+	Host string // Address to bind to (default 127.0.0.1)
+	Port int    // TCP port (default 9090, 0 = off: admin endpoints stay on the public API)
+}
+
+// Limits holds the http.Server timeouts and header size limit
+// Go's defaults are "no timeout", which lets a client hold a connection open
+// forever by sending headers one byte at a time (a "slowloris" attack)
+type Limits struct {
+	ReadHeaderTimeout time.Duration // Time to read the request headers (default 5s)
+	ReadTimeout       time.Duration // Time to read the whole request, body included (default 15s)
+	WriteTimeout      time.Duration // Time from the end of the headers to the end of the response (default 30s)
+	IdleTimeout       time.Duration // How long a keep-alive connection may sit idle (default 120s)
+	MaxHeaderBytes    int           // Maximum size of the request headers (default 1 MiB)
+	ShutdownTimeout   time.Duration // How long to wait for in-flight requests on shutdown (default 15s)
+	RequestTimeout    time.Duration // Deadline for handling one request; 504 after that (default 15s)
+	ExportTimeout     time.Duration // Deadline for streaming GET /export, which isn't buffered (default 5m)
+}
+
+// RateLimit holds the per-IP request limit
+type RateLimit struct {
+	RequestsPerSecond float64 // Sustained rate per IP (default 10)
+	Burst             int     // Requests allowed at once before the rate applies (default 20)
+	Disabled          bool    // Turns the limit off (local dev, seed scripts, load tests)
+	MaxClients        int     // How many IPs are remembered at once; bounds memory (default 10,000)
+	Shared            bool    // Counts kept in MongoDB, shared by every instance (RATE_LIMIT_STORE=mongo)
+}
+
+// QueryLimits holds the database query guardrails (see repository.Limits)
+type QueryLimits struct {
+	MaxResults int           // Most tasks one list may return; more is a 422 (default 10,000; 0 = no limit)
+	MaxTime    time.Duration // Longest MongoDB may work on one query; then a 504 (default 5s; 0 = no limit)
+}
+
+// Quotas holds the per-user limits
+type Quotas struct {
+	MaxTasks int64 // Tasks one user may own (default 0 = no limit; admins are never limited)
+}
+
+// TLS holds the HTTPS settings
+// Use either a certificate/key pair OR Let's Encrypt autocert, not both
+type TLS struct {
+	// CertFile and KeyFile are PEM files for a certificate you already have
+	CertFile string
+	KeyFile  string
+
+	// AutocertDomains turns on Let's Encrypt for these host names
+	// Certificates are requested on the first HTTPS request and renewed automatically
+	AutocertDomains []string
+
+	// AutocertCacheDir is where issued certificates are stored (default "certs")
+	// Keep it on a persistent volume - Let's Encrypt rate limits re-issuing
+	AutocertCacheDir string
+
+	// AutocertEmail is given to Let's Encrypt for expiry notices (optional)
+	AutocertEmail string
+
+	// RedirectPort is a plain HTTP port that redirects to HTTPS (0 = off)
+	// With autocert it also answers the HTTP-01 challenge, so it defaults to 80
+	RedirectPort int
+}
+
+// Enabled reports whether the server should serve HTTPS
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.Autocert()
+}
+
+// Autocert reports whether certificates come from Let's Encrypt
+func (t TLS) Autocert() bool {
+	return len(t.AutocertDomains) > 0
+}
+
+// Load reads the server settings from the environment:
+//
+//	HOST=127.0.0.1
+//	PORT=8080
+//	BASE_PATH=/api
+//	PUBLIC_URL=https://todo.example.com
+//	TLS_CERT_FILE=/etc/todo/cert.pem      TLS_KEY_FILE=/etc/todo/key.pem
+//	TLS_AUTOCERT_DOMAINS=todo.example.com TLS_AUTOCERT_CACHE_DIR=/var/lib/todo/certs
+//	TLS_AUTOCERT_EMAIL=ops@example.com    HTTP_REDIRECT_PORT=80
+//	HTTP_READ_HEADER_TIMEOUT=5s HTTP_READ_TIMEOUT=15s HTTP_WRITE_TIMEOUT=30s
+//	HTTP_IDLE_TIMEOUT=120s      HTTP_MAX_HEADER_BYTES=1048576
+//	HTTP_SHUTDOWN_TIMEOUT=15s   REQUEST_TIMEOUT=15s   EXPORT_TIMEOUT=5m
+//	RATE_LIMIT_RPS=10           RATE_LIMIT_BURST=20   RATE_LIMIT_DISABLED=false
+//	RATE_LIMIT_MAX_CLIENTS=10000  RATE_LIMIT_STORE=memory
+//	TASKS_CACHE_TTL=1s          STATS_CACHE_TTL=1s
+//	EVENTS_BACKEND=inline       TASK_ID_FORMAT=objectid
+//	DB_DRIVER=mongo
+//	QUOTA_MAX_TASKS=1000        MOBILE_LIST_LIMIT=50
+//	BULK_CONFIRM_ABOVE=100
+//	QUERY_MAX_RESULTS=10000     QUERY_MAX_TIME=5s
+//	JSON_UNKNOWN_FIELDS=reject
+//	TASK_TITLE_PATTERN=^[A-Z]+-[0-9]+
+//	AUTH_EXEMPT=GET /health,GET /healthz,OPTIONS *
+//	TRUSTED_PROXIES=10.0.0.0/8,192.0.2.10
+//	ADMIN_HOST=127.0.0.1        ADMIN_PORT=9090
+func Load() (Server, error) {
+	cfg := Server{
+		Host: strings.TrimSpace(os.Getenv("HOST")),
+		Port: 8080,
+	}
+
+	if v := strings.TrimSpace(os.Getenv("PORT")); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port < 1 || port > 65535 {
+			return Server{}, fmt.Errorf("invalid PORT %q: must be a number between 1 and 65535", v)
+		}
+		cfg.Port = port
+	}
+
+	basePath, err := normalizeBasePath(os.Getenv("BASE_PATH"))
+	if err != nil {
+		return Server{}, err
+	}
+	cfg.BasePath = basePath
+
+	tlsConfig, err := loadTLS()
+	if err != nil {
+		return Server{}, err
+	}
+	cfg.TLS = tlsConfig
+
+	cfg.PublicURL = strings.TrimRight(strings.TrimSpace(os.Getenv("PUBLIC_URL")), "/")
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = cfg.defaultPublicURL()
+	}
+
+	limits, err := loadLimits()
+	if err != nil {
+		return Server{}, err
+	}
+	cfg.Limits = limits
+
+	rateLimit, err := loadRateLimit()
+	if err != nil {
+		return Server{}, err
+	}
+	cfg.RateLimit = rateLimit
+
+	if v := strings.TrimSpace(os.Getenv("TASKS_CACHE_TTL")); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl < 0 {
+			return Server{}, fmt.Errorf("invalid TASKS_CACHE_TTL %q: must be a duration like 1s (0 = off)", v)
+		}
+		cfg.TaskCacheTTL = ttl
+	}
+
+	cfg.StatsCacheTTL = time.Second
+	if v := strings.TrimSpace(os.Getenv("STATS_CACHE_TTL")); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl < 0 {
+			return Server{}, fmt.Errorf("invalid STATS_CACHE_TTL %q: must be a duration like 1s (0 = off)", v)
+		}
+		cfg.StatsCacheTTL = ttl
+	}
+
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("EVENTS_BACKEND"))); v {
+	case "", "inline":
+		cfg.EventsBackend = "inline"
+	case "async", "jobs":
+		cfg.EventsBackend = v
+	default:
+		return Server{}, fmt.Errorf("invalid EVENTS_BACKEND %q: must be inline, async or jobs", v)
+	}
+
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("TASK_ID_FORMAT"))); v {
+	case "", "objectid":
+		cfg.TaskIDFormat = "objectid"
+	case "uuid":
+		cfg.TaskIDFormat = v
+	default:
+		return Server{}, fmt.Errorf("invalid TASK_ID_FORMAT %q: must be objectid or uuid", v)
+	}
+
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("DB_DRIVER"))); v {
+	case "", "mongo":
+		cfg.DBDriver = "mongo"
+	case "memory":
+		cfg.DBDriver = v
+	default:
+		return Server{}, fmt.Errorf("invalid DB_DRIVER %q: must be mongo or memory", v)
+	}
+
+	cfg.QueryLimits = QueryLimits{MaxResults: 10_000, MaxTime: 5 * time.Second}
+	if v := strings.TrimSpace(os.Getenv("QUERY_MAX_RESULTS")); v != "" {
+		maxResults, err := strconv.Atoi(v)
+		if err != nil || maxResults < 0 {
+			return Server{}, fmt.Errorf("invalid QUERY_MAX_RESULTS %q: must be a number of tasks (0 = no limit)", v)
+		}
+		cfg.QueryLimits.MaxResults = maxResults
+	}
+	if v := strings.TrimSpace(os.Getenv("QUERY_MAX_TIME")); v != "" {
+		maxTime, err := time.ParseDuration(v)
+		if err != nil || maxTime < 0 {
+			return Server{}, fmt.Errorf("invalid QUERY_MAX_TIME %q: must be a duration like 5s (0 = no limit)", v)
+		}
+		cfg.QueryLimits.MaxTime = maxTime
+	}
+
+	if v := strings.TrimSpace(os.Getenv("QUOTA_MAX_TASKS")); v != "" {
+		maxTasks, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || maxTasks < 0 {
+			return Server{}, fmt.Errorf("invalid QUOTA_MAX_TASKS %q: must be a number of tasks (0 = no limit)", v)
+		}
+		cfg.Quotas.MaxTasks = maxTasks
+	}
+
+	cfg.MobileListLimit = 50
+	if v := strings.TrimSpace(os.Getenv("MOBILE_LIST_LIMIT")); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return Server{}, fmt.Errorf("invalid MOBILE_LIST_LIMIT %q: must be a number of tasks (0 = no limit)", v)
+		}
+		cfg.MobileListLimit = limit
+	}
+
+	cfg.BulkConfirmAbove = 100
+	if v := strings.TrimSpace(os.Getenv("BULK_CONFIRM_ABOVE")); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return Server{}, fmt.Errorf("invalid BULK_CONFIRM_ABOVE %q: must be a number of tasks (0 = never ask)", v)
+		}
+		cfg.BulkConfirmAbove = limit
+	}
+
+	if v := strings.TrimSpace(os.Getenv("TASK_TITLE_PATTERN")); v != "" {
+		pattern, err := regexp.Compile(v)
+		if err != nil {
+			return Server{}, fmt.Errorf("invalid TASK_TITLE_PATTERN %q: %w", v, err)
+		}
+		cfg.TitlePattern = pattern
+	}
+
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("JSON_UNKNOWN_FIELDS"))); v {
+	case "", "reject":
+	case "ignore":
+		cfg.LenientJSON = true
+	default:
+		return Server{}, fmt.Errorf("invalid JSON_UNKNOWN_FIELDS %q: must be reject or ignore", v)
+	}
+
+	// Set but empty means every request needs an API key
+	authExempt, ok := os.LookupEnv("AUTH_EXEMPT")
+	if !ok {
+		authExempt = DefaultAuthExempt
+	}
+	cfg.AuthExempt, err = parseAuthExempt(authExempt)
+	if err != nil {
+		return Server{}, err
+	}
+
+	cfg.TrustedProxies, err = parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return Server{}, err
+	}
+
+	cfg.Admin = Admin{Host: "127.0.0.1", Port: 9090}
+	if v, ok := os.LookupEnv("ADMIN_HOST"); ok {
+		cfg.Admin.Host = strings.TrimSpace(v)
+	}
+	if v := strings.TrimSpace(os.Getenv("ADMIN_PORT")); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port < 0 || port > 65535 {
+			return Server{}, fmt.Errorf("invalid ADMIN_PORT %q: must be 0 (off) or a port number", v)
+		}
+		cfg.Admin.Port = port
+	}
+	if cfg.Admin.Port != 0 && cfg.Admin.Port == cfg.Port {
+		return Server{}, fmt.Errorf("ADMIN_PORT %d is the same as PORT", cfg.Admin.Port)
+	}
+
+	return cfg, nil
+}
+
+// loadLimits reads the HTTP_* timeout and header size variables
+// Durations use Go syntax: "500ms", "5s", "2m"
+func loadLimits() (Limits, error) {
+	l := Limits{
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		ShutdownTimeout:   15 * time.Second,
+		RequestTimeout:    15 * time.Second,
+		ExportTimeout:     5 * time.Minute,
+	}
+
+	durations := []struct {
+		env    string
+		target *time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", &l.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", &l.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", &l.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", &l.IdleTimeout},
+		{"HTTP_SHUTDOWN_TIMEOUT", &l.ShutdownTimeout},
+		{"REQUEST_TIMEOUT", &l.RequestTimeout},
+		{"EXPORT_TIMEOUT", &l.ExportTimeout},
+	}
+	for _, d := range durations {
+		v := strings.TrimSpace(os.Getenv(d.env))
+		if v == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(v)
+		if err != nil || parsed <= 0 {
+			return Limits{}, fmt.Errorf("invalid %s %q: must be a positive duration like 10s", d.env, v)
+		}
+		*d.target = parsed
+	}
+
+	if v := strings.TrimSpace(os.Getenv("HTTP_MAX_HEADER_BYTES")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1024 {
+			return Limits{}, fmt.Errorf("invalid HTTP_MAX_HEADER_BYTES %q: must be at least 1024", v)
+		}
+		l.MaxHeaderBytes = n
+	}
+
+	return l, nil
+}
+
+// loadRateLimit reads the RATE_LIMIT_* variables
+func loadRateLimit() (RateLimit, error) {
+	rl := RateLimit{RequestsPerSecond: 10, Burst: 20, MaxClients: 10_000}
+
+	if v := strings.TrimSpace(os.Getenv("RATE_LIMIT_RPS")); v != "" {
+		rps, err := strconv.ParseFloat(v, 64)
+		if err != nil || rps <= 0 {
+			return RateLimit{}, fmt.Errorf("invalid RATE_LIMIT_RPS %q: must be a positive number (use RATE_LIMIT_DISABLED=true to turn it off)", v)
+		}
+		rl.RequestsPerSecond = rps
+	}
+
+	if v := strings.TrimSpace(os.Getenv("RATE_LIMIT_BURST")); v != "" {
+		burst, err := strconv.Atoi(v)
+		if err != nil || burst < 1 {
+			return RateLimit{}, fmt.Errorf("invalid RATE_LIMIT_BURST %q: must be at least 1", v)
+		}
+		rl.Burst = burst
+	}
+
+	if v := strings.TrimSpace(os.Getenv("RATE_LIMIT_MAX_CLIENTS")); v != "" {
+		maxClients, err := strconv.Atoi(v)
+		if err != nil || maxClients < 1 {
+			return RateLimit{}, fmt.Errorf("invalid RATE_LIMIT_MAX_CLIENTS %q: must be at least 1", v)
+		}
+		rl.MaxClients = maxClients
+	}
+
+	if v := strings.TrimSpace(os.Getenv("RATE_LIMIT_DISABLED")); v != "" {
+		disabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return RateLimit{}, fmt.Errorf("invalid RATE_LIMIT_DISABLED %q: must be true or false", v)
+		}
+		rl.Disabled = disabled
+	}
+
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("RATE_LIMIT_STORE"))); v {
+	case "", "memory":
+	case "mongo":
+		rl.Shared = true
+	default:
+		return RateLimit{}, fmt.Errorf("invalid RATE_LIMIT_STORE %q: must be memory or mongo", v)
+	}
+
+	return rl, nil
+}
+
+// parseAuthExempt reads a comma-separated AUTH_EXEMPT list
+// Each entry is "METHOD /path" or just "/path" (any method)
+func parseAuthExempt(raw string) ([]AuthExemption, error) {
+	var exempt []AuthExemption
+	for _, entry := range strings.Split(raw, ",") {
+		fields := strings.Fields(entry)
+		e := AuthExemption{Method: "*"}
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			e.Path = fields[0]
+		case 2:
+			e.Method, e.Path = strings.ToUpper(fields[0]), fields[1]
+		default:
+			return nil, fmt.Errorf("invalid AUTH_EXEMPT entry %q: must be \"METHOD /path\" or \"/path\"", strings.TrimSpace(entry))
+		}
+		if e.Path != "*" && !strings.HasPrefix(e.Path, "/") {
+			return nil, fmt.Errorf("invalid AUTH_EXEMPT entry %q: path must start with / (or be *)", strings.TrimSpace(entry))
+		}
+		exempt = append(exempt, e)
+	}
+	return exempt, nil
+}
+
+// parseTrustedProxies reads a comma-separated TRUSTED_PROXIES list
+// Entries are CIDR ranges (10.0.0.0/8) or single addresses (192.0.2.10)
+func parseTrustedProxies(raw string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: must be an IP address or CIDR range like 10.0.0.0/8", entry)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// loadTLS reads the TLS_* and HTTP_REDIRECT_PORT variables
+func loadTLS() (TLS, error) {
+	t := TLS{
+		CertFile:         strings.TrimSpace(os.Getenv("TLS_CERT_FILE")),
+		KeyFile:          strings.TrimSpace(os.Getenv("TLS_KEY_FILE")),
+		AutocertCacheDir: strings.TrimSpace(os.Getenv("TLS_AUTOCERT_CACHE_DIR")),
+		AutocertEmail:    strings.TrimSpace(os.Getenv("TLS_AUTOCERT_EMAIL")),
+	}
+	for _, domain := range strings.Split(os.Getenv("TLS_AUTOCERT_DOMAINS"), ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			t.AutocertDomains = append(t.AutocertDomains, domain)
+		}
+	}
+
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return TLS{}, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if t.CertFile != "" && t.Autocert() {
+		return TLS{}, fmt.Errorf("use either TLS_CERT_FILE/TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS, not both")
+	}
+
+	if t.Autocert() {
+		if t.AutocertCacheDir == "" {
+			t.AutocertCacheDir = "certs"
+		}
+		t.RedirectPort = 80
+	}
+	if v := strings.TrimSpace(os.Getenv("HTTP_REDIRECT_PORT")); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port < 0 || port > 65535 {
+			return TLS{}, fmt.Errorf("invalid HTTP_REDIRECT_PORT %q: must be 0 (off) or a port number", v)
+		}
+		t.RedirectPort = port
+	}
+
+	return t, nil
+}
+
+// defaultPublicURL guesses the public URL when PUBLIC_URL isn't set
+func (s Server) defaultPublicURL() string {
+	if !s.TLS.Enabled() {
+		return fmt.Sprintf("http://localhost:%d", s.Port)
+	}
+
+	host := "localhost"
+	if s.TLS.Autocert() {
+		host = s.TLS.AutocertDomains[0]
+	}
+	if s.Port == 443 {
+		return "https://" + host
+	}
+	return fmt.Sprintf("https://%s:%d", host, s.Port)
+}
+
+// Addr returns the address for http.Server, e.g. ":8080" or "127.0.0.1:8080"
+func (s Server) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// RedirectAddr returns the address of the HTTP → HTTPS redirect listener
+// e.g. ":80" (empty when the redirect is off)
+func (s Server) RedirectAddr() string {
+	if !s.TLS.Enabled() || s.TLS.RedirectPort == 0 {
+		return ""
+	}
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.TLS.RedirectPort))
+}
+
+// AdminAddr returns the address of the admin listener, e.g. "127.0.0.1:9090"
+// (empty when ADMIN_PORT=0)
+func (s Server) AdminAddr() string {
+	if s.Admin.Port == 0 {
+		return ""
+	}
+	return net.JoinHostPort(s.Admin.Host, strconv.Itoa(s.Admin.Port))
+}
+
+// URL returns the public URL of the API including the base path
+// e.g. http://localhost:8080/api - this is the "server" in the OpenAPI spec
+func (s Server) URL() string {
+	return s.PublicURL + s.BasePath
+}
+
+// normalizeBasePath turns "api", "/api" and "/api/" into "/api"
+// Empty and "/" both mean no base path
+func normalizeBasePath(raw string) (string, error) {
+	p := strings.Trim(strings.TrimSpace(raw), "/")
+	if p == "" {
+		return "", nil
+	}
+	if strings.ContainsAny(p, " ?#{}") {
+		return "", fmt.Errorf("invalid BASE_PATH %q: must be a plain URL path like /api", raw)
+	}
+	return "/" + p, nil
+}
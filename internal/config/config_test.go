@@ -0,0 +1,536 @@
+package config
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestLoad_Defaults tests that no environment means :8080 at the root
+func TestLoad_Defaults(t *testing.T) {
+	// Arrange
+	t.Setenv("HOST", "")
+	t.Setenv("PORT", "")
+	t.Setenv("BASE_PATH", "")
+	t.Setenv("PUBLIC_URL", "")
+
+	// Act
+	cfg, err := Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Addr() != ":8080" {
+		t.Errorf("Expected address ':8080', got '%s'", cfg.Addr())
+	}
+	if cfg.URL() != "http://localhost:8080" {
+		t.Errorf("Expected URL 'http://localhost:8080', got '%s'", cfg.URL())
+	}
+
+	t.Logf("✅ Defaults: %s (%s)", cfg.Addr(), cfg.URL())
+}
+
+// TestLoad_LocalhostWithBasePath tests binding to localhost under a base path
+func TestLoad_LocalhostWithBasePath(t *testing.T) {
+	// Arrange
+	t.Setenv("HOST", "127.0.0.1")
+	t.Setenv("PORT", "9000")
+	t.Setenv("BASE_PATH", "api/v1/")
+	t.Setenv("PUBLIC_URL", "")
+
+	// Act
+	cfg, err := Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Addr() != "127.0.0.1:9000" {
+		t.Errorf("Expected address '127.0.0.1:9000', got '%s'", cfg.Addr())
+	}
+	if cfg.BasePath != "/api/v1" {
+		t.Errorf("Expected base path '/api/v1', got '%s'", cfg.BasePath)
+	}
+	if cfg.URL() != "http://localhost:9000/api/v1" {
+		t.Errorf("Expected URL 'http://localhost:9000/api/v1', got '%s'", cfg.URL())
+	}
+}
+
+// TestLoad_InvalidPort tests that a bad PORT is rejected instead of ignored
+func TestLoad_InvalidPort(t *testing.T) {
+	// Arrange
+	t.Setenv("PORT", "eighty")
+
+	// Act
+	_, err := Load()
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error for PORT=eighty")
+	}
+}
+
+// TestLoad_Autocert tests that autocert defaults to a cache dir and a port 80 redirect
+func TestLoad_Autocert(t *testing.T) {
+	// Arrange
+	t.Setenv("PORT", "443")
+	t.Setenv("PUBLIC_URL", "")
+	t.Setenv("TLS_AUTOCERT_DOMAINS", "todo.example.com, www.todo.example.com")
+
+	// Act
+	cfg, err := Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.TLS.AutocertDomains) != 2 || cfg.TLS.AutocertCacheDir != "certs" {
+		t.Errorf("Unexpected autocert settings: %+v", cfg.TLS)
+	}
+	if cfg.RedirectAddr() != ":80" {
+		t.Errorf("Expected redirect address ':80', got '%s'", cfg.RedirectAddr())
+	}
+	if cfg.URL() != "https://todo.example.com" {
+		t.Errorf("Expected URL 'https://todo.example.com', got '%s'", cfg.URL())
+	}
+}
+
+// TestLoad_CertWithoutKey tests that a certificate without its key is rejected
+func TestLoad_CertWithoutKey(t *testing.T) {
+	// Arrange
+	t.Setenv("TLS_CERT_FILE", "/etc/todo/cert.pem")
+	t.Setenv("TLS_KEY_FILE", "")
+
+	// Act
+	_, err := Load()
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error when TLS_KEY_FILE is missing")
+	}
+}
+
+// TestLoad_Limits tests that timeouts have safe defaults and can be overridden
+func TestLoad_Limits(t *testing.T) {
+	// Arrange
+	t.Setenv("HTTP_WRITE_TIMEOUT", "1m")
+
+	// Act
+	cfg, err := Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Limits.ReadHeaderTimeout != 5*time.Second {
+		t.Errorf("Expected default ReadHeaderTimeout 5s, got %v", cfg.Limits.ReadHeaderTimeout)
+	}
+	if cfg.Limits.WriteTimeout != time.Minute {
+		t.Errorf("Expected WriteTimeout 1m, got %v", cfg.Limits.WriteTimeout)
+	}
+	if cfg.Limits.MaxHeaderBytes != 1<<20 {
+		t.Errorf("Expected MaxHeaderBytes 1 MiB, got %d", cfg.Limits.MaxHeaderBytes)
+	}
+}
+
+// TestLoad_InvalidTimeout tests that a timeout without a unit is rejected
+func TestLoad_InvalidTimeout(t *testing.T) {
+	// Arrange: "30" is ambiguous - seconds? milliseconds?
+	t.Setenv("HTTP_READ_TIMEOUT", "30")
+
+	// Act
+	_, err := Load()
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error for HTTP_READ_TIMEOUT=30")
+	}
+}
+
+// TestLoad_RateLimit tests the rate limit defaults, overrides and invalid values
+func TestLoad_RateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    RateLimit
+		wantErr bool
+	}{
+		{"defaults", nil, RateLimit{RequestsPerSecond: 10, Burst: 20, MaxClients: 10_000}, false},
+		{"overrides", map[string]string{"RATE_LIMIT_RPS": "2.5", "RATE_LIMIT_BURST": "5", "RATE_LIMIT_MAX_CLIENTS": "500"}, RateLimit{RequestsPerSecond: 2.5, Burst: 5, MaxClients: 500}, false},
+		{"disabled", map[string]string{"RATE_LIMIT_DISABLED": "true"}, RateLimit{RequestsPerSecond: 10, Burst: 20, MaxClients: 10_000, Disabled: true}, false},
+		{"zero rate", map[string]string{"RATE_LIMIT_RPS": "0"}, RateLimit{}, true},
+		{"zero burst", map[string]string{"RATE_LIMIT_BURST": "0"}, RateLimit{}, true},
+		{"zero clients", map[string]string{"RATE_LIMIT_MAX_CLIENTS": "0"}, RateLimit{}, true},
+		{"not a bool", map[string]string{"RATE_LIMIT_DISABLED": "sometimes"}, RateLimit{}, true},
+		{"shared", map[string]string{"RATE_LIMIT_STORE": "mongo"}, RateLimit{RequestsPerSecond: 10, Burst: 20, MaxClients: 10_000, Shared: true}, false},
+		{"unknown store", map[string]string{"RATE_LIMIT_STORE": "redis"}, RateLimit{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			// Act
+			cfg, err := Load()
+
+			// Assert
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error for %v", tt.env)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load returned error: %v", err)
+			}
+			if cfg.RateLimit != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, cfg.RateLimit)
+			}
+		})
+	}
+}
+
+// TestLoad_TaskCacheTTL tests that the list micro-cache is off unless set
+func TestLoad_TaskCacheTTL(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.TaskCacheTTL != 0 {
+		t.Fatalf("Expected the cache off by default, got %v (err %v)", cfg.TaskCacheTTL, err)
+	}
+
+	t.Setenv("TASKS_CACHE_TTL", "1500ms")
+	if cfg, err = Load(); err != nil || cfg.TaskCacheTTL != 1500*time.Millisecond {
+		t.Errorf("Expected 1.5s, got %v (err %v)", cfg.TaskCacheTTL, err)
+	}
+
+	t.Setenv("TASKS_CACHE_TTL", "soon")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for TASKS_CACHE_TTL=soon")
+	}
+}
+
+// TestLoad_StatsCacheTTL tests that the counts snapshot lasts a second
+// unless set
+func TestLoad_StatsCacheTTL(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.StatsCacheTTL != time.Second {
+		t.Fatalf("Expected 1s by default, got %v (err %v)", cfg.StatsCacheTTL, err)
+	}
+
+	t.Setenv("STATS_CACHE_TTL", "0")
+	if cfg, err = Load(); err != nil || cfg.StatsCacheTTL != 0 {
+		t.Errorf("Expected 0 (off), got %v (err %v)", cfg.StatsCacheTTL, err)
+	}
+
+	t.Setenv("STATS_CACHE_TTL", "-1s")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for STATS_CACHE_TTL=-1s")
+	}
+}
+
+// TestLoad_EventsBackend tests that task event subscribers run inline unless
+// EVENTS_BACKEND says otherwise
+func TestLoad_EventsBackend(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.EventsBackend != "inline" {
+		t.Fatalf("Expected inline by default, got %q (err %v)", cfg.EventsBackend, err)
+	}
+
+	t.Setenv("EVENTS_BACKEND", "Jobs")
+	if cfg, err = Load(); err != nil || cfg.EventsBackend != "jobs" {
+		t.Errorf("Expected jobs, got %q (err %v)", cfg.EventsBackend, err)
+	}
+
+	t.Setenv("EVENTS_BACKEND", "kafka")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for EVENTS_BACKEND=kafka")
+	}
+}
+
+// TestLoad_TaskIDFormat tests that tasks get ObjectIDs unless TASK_ID_FORMAT=uuid
+func TestLoad_TaskIDFormat(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.TaskIDFormat != "objectid" {
+		t.Fatalf("Expected objectid by default, got %q (err %v)", cfg.TaskIDFormat, err)
+	}
+
+	t.Setenv("TASK_ID_FORMAT", "UUID")
+	if cfg, err = Load(); err != nil || cfg.TaskIDFormat != "uuid" {
+		t.Errorf("Expected uuid, got %q (err %v)", cfg.TaskIDFormat, err)
+	}
+
+	t.Setenv("TASK_ID_FORMAT", "int")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for TASK_ID_FORMAT=int")
+	}
+}
+
+// TestLoad_DBDriver tests the storage default and override
+func TestLoad_DBDriver(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.DBDriver != "mongo" {
+		t.Fatalf("Expected mongo by default, got %q (err %v)", cfg.DBDriver, err)
+	}
+
+	t.Setenv("DB_DRIVER", "Memory")
+	if cfg, err = Load(); err != nil || cfg.DBDriver != "memory" {
+		t.Errorf("Expected memory, got %q (err %v)", cfg.DBDriver, err)
+	}
+
+	t.Setenv("DB_DRIVER", "postgres")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for DB_DRIVER=postgres")
+	}
+}
+
+// TestLoad_MobileListLimit tests the mobile page size default and override
+func TestLoad_MobileListLimit(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.MobileListLimit != 50 {
+		t.Fatalf("Expected 50 tasks by default, got %d (err %v)", cfg.MobileListLimit, err)
+	}
+
+	t.Setenv("MOBILE_LIST_LIMIT", "0")
+	if cfg, err = Load(); err != nil || cfg.MobileListLimit != 0 {
+		t.Errorf("Expected no limit, got %d (err %v)", cfg.MobileListLimit, err)
+	}
+
+	t.Setenv("MOBILE_LIST_LIMIT", "-5")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for MOBILE_LIST_LIMIT=-5")
+	}
+}
+
+// TestLoad_BulkConfirmAbove tests the bulk change guard's default and override
+func TestLoad_BulkConfirmAbove(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.BulkConfirmAbove != 100 {
+		t.Fatalf("Expected 100 tasks by default, got %d (err %v)", cfg.BulkConfirmAbove, err)
+	}
+
+	t.Setenv("BULK_CONFIRM_ABOVE", "0")
+	if cfg, err = Load(); err != nil || cfg.BulkConfirmAbove != 0 {
+		t.Errorf("Expected the guard off, got %d (err %v)", cfg.BulkConfirmAbove, err)
+	}
+
+	t.Setenv("BULK_CONFIRM_ABOVE", "many")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for BULK_CONFIRM_ABOVE=many")
+	}
+}
+
+// TestLoad_QueryLimits tests the query guardrail defaults and overrides
+func TestLoad_QueryLimits(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.QueryLimits != (QueryLimits{MaxResults: 10_000, MaxTime: 5 * time.Second}) {
+		t.Fatalf("Expected 10,000 tasks and 5s by default, got %+v (err %v)", cfg.QueryLimits, err)
+	}
+
+	t.Setenv("QUERY_MAX_RESULTS", "0")
+	t.Setenv("QUERY_MAX_TIME", "750ms")
+	if cfg, err = Load(); err != nil || cfg.QueryLimits != (QueryLimits{MaxTime: 750 * time.Millisecond}) {
+		t.Errorf("Expected no result limit and 750ms, got %+v (err %v)", cfg.QueryLimits, err)
+	}
+
+	t.Setenv("QUERY_MAX_RESULTS", "lots")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for QUERY_MAX_RESULTS=lots")
+	}
+}
+
+// TestLoad_Quotas tests that users have no task limit unless QUOTA_MAX_TASKS is set
+func TestLoad_Quotas(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.Quotas.MaxTasks != 0 {
+		t.Fatalf("Expected no task quota by default, got %d (err %v)", cfg.Quotas.MaxTasks, err)
+	}
+
+	t.Setenv("QUOTA_MAX_TASKS", "500")
+	if cfg, err = Load(); err != nil || cfg.Quotas.MaxTasks != 500 {
+		t.Errorf("Expected 500, got %d (err %v)", cfg.Quotas.MaxTasks, err)
+	}
+
+	t.Setenv("QUOTA_MAX_TASKS", "-1")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for QUOTA_MAX_TASKS=-1")
+	}
+}
+
+// TestLoad_UnknownFields tests that unknown JSON fields are rejected unless JSON_UNKNOWN_FIELDS=ignore
+func TestLoad_UnknownFields(t *testing.T) {
+	cfg, err := Load()
+	if err != nil || cfg.LenientJSON {
+		t.Fatalf("Expected unknown fields to be rejected by default (err %v)", err)
+	}
+
+	t.Setenv("JSON_UNKNOWN_FIELDS", "ignore")
+	if cfg, err = Load(); err != nil || !cfg.LenientJSON {
+		t.Errorf("Expected unknown fields to be ignored (err %v)", err)
+	}
+
+	t.Setenv("JSON_UNKNOWN_FIELDS", "warn")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for JSON_UNKNOWN_FIELDS=warn")
+	}
+}
+
+// TestLoad_AuthExempt tests the AUTH_EXEMPT default, overrides and validation
+func TestLoad_AuthExempt(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if !slices.Contains(cfg.AuthExempt, AuthExemption{Method: "GET", Path: "/health"}) ||
+		!slices.Contains(cfg.AuthExempt, AuthExemption{Method: "OPTIONS", Path: "*"}) {
+		t.Errorf("Expected health probes and preflights to be exempt by default, got %+v", cfg.AuthExempt)
+	}
+
+	t.Setenv("AUTH_EXEMPT", "get /healthz, /docs/*")
+	cfg, err = Load()
+	want := []AuthExemption{{Method: "GET", Path: "/healthz"}, {Method: "*", Path: "/docs/*"}}
+	if err != nil || !slices.Equal(cfg.AuthExempt, want) {
+		t.Errorf("Expected %+v, got %+v (err %v)", want, cfg.AuthExempt, err)
+	}
+
+	t.Setenv("AUTH_EXEMPT", "")
+	if cfg, err = Load(); err != nil || len(cfg.AuthExempt) != 0 {
+		t.Errorf("Expected no exemptions for an empty AUTH_EXEMPT, got %+v (err %v)", cfg.AuthExempt, err)
+	}
+
+	t.Setenv("AUTH_EXEMPT", "GET health")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for a path without a leading /")
+	}
+}
+
+// TestLoad_TrustedProxies tests that TRUSTED_PROXIES accepts ranges and single addresses
+func TestLoad_TrustedProxies(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.10")
+	cfg, err := Load()
+	want := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.10/32")}
+	if err != nil || !slices.Equal(cfg.TrustedProxies, want) {
+		t.Errorf("Expected %v, got %v (err %v)", want, cfg.TrustedProxies, err)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/33")
+	if _, err = Load(); err == nil {
+		t.Error("Expected an error for an invalid CIDR range")
+	}
+}
+
+// TestLoadProfile_Defaults tests that each APP_ENV picks its own defaults
+func TestLoadProfile_Defaults(t *testing.T) {
+	tests := []struct {
+		appEnv      string
+		wantEnv     Env
+		wantFormat  string
+		wantVerbose bool
+		wantOrigins int
+		wantRatio   float64
+	}{
+		{"", EnvDev, "text", true, 1, 1},
+		{"staging", EnvStaging, "json", false, 1, 1},
+		{"production", EnvProd, "json", false, 0, 0.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.wantEnv), func(t *testing.T) {
+			// Arrange
+			t.Setenv("APP_ENV", tt.appEnv)
+			t.Setenv("LOG_FORMAT", "")
+			t.Setenv("VERBOSE_ERRORS", "")
+			t.Setenv("TRACE_SAMPLE_RATIO", "")
+
+			// Act
+			p, err := LoadProfile()
+
+			// Assert
+			if err != nil {
+				t.Fatalf("LoadProfile returned error: %v", err)
+			}
+			if p.Env != tt.wantEnv || p.LogFormat != tt.wantFormat || p.VerboseErrors != tt.wantVerbose {
+				t.Errorf("Expected %s/%s/verbose=%v, got %s/%s/verbose=%v",
+					tt.wantEnv, tt.wantFormat, tt.wantVerbose, p.Env, p.LogFormat, p.VerboseErrors)
+			}
+			if len(p.CORSOrigins) != tt.wantOrigins {
+				t.Errorf("Expected %d CORS origins, got %v", tt.wantOrigins, p.CORSOrigins)
+			}
+			if p.TraceSampleRatio != tt.wantRatio {
+				t.Errorf("Expected sample ratio %v, got %v", tt.wantRatio, p.TraceSampleRatio)
+			}
+		})
+	}
+}
+
+// TestLoadProfile_Overrides tests that single settings override the profile
+func TestLoadProfile_Overrides(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("LOG_FORMAT", "text")
+	t.Setenv("VERBOSE_ERRORS", "")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://todo.example.com/, https://admin.example.com")
+	t.Setenv("TRACE_SAMPLE_RATIO", "0.5")
+
+	// Act
+	p, err := LoadProfile()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoadProfile returned error: %v", err)
+	}
+	if p.LogFormat != "text" {
+		t.Errorf("Expected log format 'text', got '%s'", p.LogFormat)
+	}
+	if len(p.CORSOrigins) != 2 || p.CORSOrigins[0] != "https://todo.example.com" {
+		t.Errorf("Expected two trimmed origins, got %v", p.CORSOrigins)
+	}
+	if p.TraceSampleRatio != 0.5 {
+		t.Errorf("Expected sample ratio 0.5, got %v", p.TraceSampleRatio)
+	}
+}
+
+// TestLoadProfile_InvalidEnv tests that a typo in APP_ENV is rejected
+func TestLoadProfile_InvalidEnv(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "prdo")
+
+	// Act
+	_, err := LoadProfile()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error for APP_ENV=prdo, got nil")
+	}
+}
+
+// TestLoad_Admin tests the admin listener defaults to localhost:9090 and can be turned off
+func TestLoad_Admin(t *testing.T) {
+	// Arrange
+	t.Setenv("PORT", "")
+	t.Setenv("ADMIN_PORT", "")
+
+	// Act
+	cfg, err := Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.AdminAddr() != "127.0.0.1:9090" {
+		t.Errorf("Expected admin address '127.0.0.1:9090', got '%s'", cfg.AdminAddr())
+	}
+
+	// ADMIN_PORT=0 turns it off
+	t.Setenv("ADMIN_PORT", "0")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.AdminAddr() != "" {
+		t.Errorf("Expected no admin address with ADMIN_PORT=0, got '%s'", cfg.AdminAddr())
+	}
+}
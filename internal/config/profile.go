@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Env is the environment the API runs in, set with APP_ENV
+type Env string
+
+const (
+	EnvDev     Env = "dev"     // A laptop: readable logs, detailed errors, everything traced
+	EnvStaging Env = "staging" // Like prod, but with open CORS and every request traced
+	EnvProd    Env = "prod"    // The real thing: JSON logs, terse errors, strict CORS
+)
+
+// Profile holds the settings whose sensible default depends on the environment
+// APP_ENV picks the defaults; each one can still be overridden on its own
+type Profile struct {
+	// Env is the environment these settings are for
+	Env Env
+
+	// LogFormat is "text" (easy to read in a terminal) or "json" (for Loki/CloudWatch)
+	LogFormat string
+
+	// VerboseErrors adds the underlying error to 500 responses
+	// Handy while developing, but it leaks internals, so it's off outside dev
+	VerboseErrors bool
+
+	// CORSOrigins are the browser origins allowed to call the API
+	// ["*"] allows any origin; empty allows none (same-origin only)
+	CORSOrigins []string
+
+	// TraceSampleRatio is the share of new traces that are recorded (0 to 1)
+	// Requests that arrive with a sampled trace are always recorded
+	TraceSampleRatio float64
+}
+
+// profiles holds the defaults for each environment
+var profiles = map[Env]Profile{
+	EnvDev: {
+		LogFormat:        "text",
+		VerboseErrors:    true,
+		CORSOrigins:      []string{"*"},
+		TraceSampleRatio: 1,
+	},
+	EnvStaging: {
+		LogFormat:        "json",
+		CORSOrigins:      []string{"*"},
+		TraceSampleRatio: 1,
+	},
+	EnvProd: {
+		LogFormat:        "json",
+		CORSOrigins:      nil, // Only the origins listed in CORS_ALLOWED_ORIGINS
+		TraceSampleRatio: 0.1,
+	},
+}
+
+// LoadProfile reads APP_ENV and the per-setting overrides:
+//
+//	APP_ENV=prod                 (dev, staging or prod; default dev)
+//	LOG_FORMAT=json              (text or json)
+//	VERBOSE_ERRORS=false
+//	CORS_ALLOWED_ORIGINS=https://todo.example.com,https://admin.example.com
+//	TRACE_SAMPLE_RATIO=0.25
+func LoadProfile() (Profile, error) {
+	env, err := parseEnv(os.Getenv("APP_ENV"))
+	if err != nil {
+		return Profile{}, err
+	}
+	p := profiles[env]
+	p.Env = env
+
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT"))); v != "" {
+		if v != "text" && v != "json" {
+			return Profile{}, fmt.Errorf("invalid LOG_FORMAT %q: use text or json", v)
+		}
+		p.LogFormat = v
+	}
+
+	if v := strings.TrimSpace(os.Getenv("VERBOSE_ERRORS")); v != "" {
+		verbose, err := strconv.ParseBool(v)
+		if err != nil {
+			return Profile{}, fmt.Errorf("invalid VERBOSE_ERRORS %q: use true or false", v)
+		}
+		p.VerboseErrors = verbose
+	}
+
+	if v, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS"); ok {
+		p.CORSOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+				p.CORSOrigins = append(p.CORSOrigins, origin)
+			}
+		}
+	}
+
+	if v := strings.TrimSpace(os.Getenv("TRACE_SAMPLE_RATIO")); v != "" {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return Profile{}, fmt.Errorf("invalid TRACE_SAMPLE_RATIO %q: must be between 0 and 1", v)
+		}
+		p.TraceSampleRatio = ratio
+	}
+
+	return p, nil
+}
+
+// parseEnv turns APP_ENV into an Env
+// The long names (development, production) are accepted too; empty means dev
+func parseEnv(raw string) (Env, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "dev", "development", "local":
+		return EnvDev, nil
+	case "staging", "stage":
+		return EnvStaging, nil
+	case "prod", "production":
+		return EnvProd, nil
+	default:
+		return "", fmt.Errorf("invalid APP_ENV %q: use dev, staging or prod", raw)
+	}
+}
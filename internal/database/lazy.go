@@ -0,0 +1,116 @@
+// This file connects to MongoDB on demand, for AWS Lambda
+// Connect() runs once at startup and exits on failure, which in Lambda means
+// a failed cold start and a 502 from API Gateway every time Atlas is briefly
+// unreachable. EnsureConnected instead connects on the first request that
+// needs the database, retries a few times, and returns the error so the
+// request gets a 503 and the next one tries again.
+
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/logger"
+)
+
+const (
+	// connectAttempts is how many times EnsureConnected tries to dial
+	connectAttempts = 3
+
+	// connectBackoff is the wait before the second attempt (doubled each time)
+	connectBackoff = 200 * time.Millisecond
+
+	// pingCacheTTL is how long a successful ping is trusted
+	// Warm containers skip the round trip for requests close together
+	pingCacheTTL = 10 * time.Second
+
+	// failureCacheTTL is how long a failure is returned without trying again,
+	// so a burst of requests during an outage doesn't each wait for timeouts
+	failureCacheTTL = 2 * time.Second
+)
+
+var (
+	// lazyMu guards the cached check result and the connect hooks
+	lazyMu sync.Mutex
+
+	// checkedAt and checkErr are the last EnsureConnected result
+	checkedAt time.Time
+	checkErr  error
+
+	// onConnect are run once, after the first successful connection
+	onConnect []func()
+)
+
+// OnConnect registers fn to run once MongoDB is first connected
+// Use it for setup that needs a collection, e.g. jobs.Init. Registering after
+// the connection exists runs fn straight away.
+func OnConnect(fn func()) {
+	lazyMu.Lock()
+	defer lazyMu.Unlock()
+
+	if client != nil {
+		fn()
+		return
+	}
+	onConnect = append(onConnect, fn)
+}
+
+// EnsureConnected connects to MongoDB if needed and checks it's reachable
+// The result is cached for a few seconds either way (see pingCacheTTL and
+// failureCacheTTL). ctx bounds the whole call, retries included.
+func EnsureConnected(ctx context.Context) error {
+	lazyMu.Lock()
+	defer lazyMu.Unlock()
+
+	age := time.Since(checkedAt)
+	if checkErr == nil && client != nil && age < pingCacheTTL {
+		return nil
+	}
+	if checkErr != nil && age < failureCacheTTL {
+		return checkErr
+	}
+
+	checkErr = connectWithRetry(ctx)
+	checkedAt = time.Now()
+	return checkErr
+}
+
+// connectWithRetry dials (or pings an existing client) up to connectAttempts times
+// Must be called with lazyMu held.
+func connectWithRetry(ctx context.Context) error {
+	backoff := connectBackoff
+	var err error
+
+	for attempt := 1; attempt <= connectAttempts; attempt++ {
+		if client != nil {
+			err = Ping(ctx)
+		} else if err = dial(ctx); err == nil {
+			for _, fn := range onConnect {
+				fn()
+			}
+			onConnect = nil
+		}
+		if err == nil {
+			return nil
+		}
+
+		logger.Log.Warn("MongoDB not reachable",
+			"attempt", attempt,
+			"max_attempts", connectAttempts,
+			"error", err,
+		)
+		if attempt == connectAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
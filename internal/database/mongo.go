@@ -12,17 +12,21 @@ package database
 import (
 	// STANDARD LIBRARY PACKAGES
 	"context" // context = for managing timeouts and cancellation
+	"errors"  // errors = for defining sentinel errors
+	"fmt"     // fmt = for wrapping errors with context
 	"log"     // log = for error logging and fatal errors
 	"os"      // os = for reading environment variables
 	"time"    // time = for creating timeouts
 
-	// OUR OWN PACKAGE
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/health"        // Health check types
 	logger "go-todo-api/internal/logger" // Our structured logger
 
 	// THIRD-PARTY PACKAGES
-	"github.com/joho/godotenv"                  // godotenv = loads .env file into environment
-	"go.mongodb.org/mongo-driver/mongo"         // mongo = MongoDB driver for Go
-	"go.mongodb.org/mongo-driver/mongo/options" // options = MongoDB connection options
+	"github.com/joho/godotenv"                   // godotenv = loads .env file into environment
+	"go.mongodb.org/mongo-driver/mongo"          // mongo = MongoDB driver for Go
+	"go.mongodb.org/mongo-driver/mongo/options"  // options = MongoDB connection options
+	"go.mongodb.org/mongo-driver/mongo/readpref" // readpref = which replica set member to ping
 )
 
 // ============================================================================
@@ -42,6 +46,10 @@ var (
 	// Like SQL:   Database → Table → Rows
 	// Our collection is: "todoapi" database, "tasks" collection
 	collection *mongo.Collection
+
+	// inMemory is set by UseMemory (DB_DRIVER=memory): the data is kept in
+	// the server process and there's no MongoDB to connect to
+	inMemory bool
 )
 
 // ============================================================================
@@ -54,7 +62,33 @@ var (
 // 2. Connects to MongoDB using connection string
 // 3. Pings MongoDB to verify connection works
 // 4. Sets up the collection we'll use for all operations
+//
+// Any failure exits the program - the server is no use without its database.
+// Lambda uses EnsureConnected (lazy.go) instead, which retries and returns
+// the error so the request can get a 503.
 func Connect() {
+	// ----------------------------------------------------------------------------
+	// STEP 1: CREATE CONTEXT WITH TIMEOUT
+	// ----------------------------------------------------------------------------
+	// Create a context that will automatically timeout after 10 seconds
+	// This prevents the connection attempt from hanging forever
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel() // Clean up context when function exits
+
+	// ----------------------------------------------------------------------------
+	// STEP 2: CONNECT
+	// ----------------------------------------------------------------------------
+	// log.Fatal() prints the error and exits the program (like a crash)
+	// We first log the error with structured logging, then we use log.Fatal() to exit the program.
+	if err := dial(ctx); err != nil {
+		logger.Log.Error("Failed to connect to MongoDB", "error", err)
+		log.Fatal("Failed to connect to MongoDB: ", err)
+	}
+}
+
+// dial connects to MongoDB and pings it
+// On success the package-level client and collection are set.
+func dial(ctx context.Context) error {
 	// ----------------------------------------------------------------------------
 	// STEP 1: LOAD ENVIRONMENT VARIABLES FROM .env FILE
 	// ----------------------------------------------------------------------------
@@ -68,15 +102,7 @@ func Connect() {
 	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 2: CREATE CONTEXT WITH TIMEOUT
-	// ----------------------------------------------------------------------------
-	// Create a context that will automatically timeout after 10 seconds
-	// This prevents the connection attempt from hanging forever
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel() // Clean up context when function exits
-
-	// ----------------------------------------------------------------------------
-	// STEP 3: GET MONGODB CONNECTION STRING FROM ENVIRONMENT
+	// STEP 2: GET MONGODB CONNECTION STRING FROM ENVIRONMENT
 	// ----------------------------------------------------------------------------
 	// os.Getenv() reads an environment variable
 	// MONGO_URI format:
@@ -85,48 +111,55 @@ func Connect() {
 	mongoURI := os.Getenv("MONGO_URI")
 	if mongoURI == "" {
 		// If MONGO_URI is not set, we can't connect to database
-		// logger.log.error uses the structured logger we set up
-		// log.Fatal() prints error and exits the program (like a crash)
-		// We first log the error with structured logging, then we use log.fatal() to exit the program.
-		logger.Log.Error("MONGO_URI not found", "required", true)
-		log.Fatal("MONGO_URI not found. Please set it in your .env file")
+		return errors.New("MONGO_URI not found. Please set it in your .env file")
 	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 4: CREATE MONGODB CLIENT WITH CONNECTION OPTIONS
+	// STEP 3: CREATE MONGODB CLIENT WITH CONNECTION OPTIONS
 	// ----------------------------------------------------------------------------
 	// options.Client() creates a ClientOptions object
 	// .ApplyURI() tells it to use our connection string
-	clientOptions := options.Client().ApplyURI(mongoURI)
+	// .SetMonitor() traces every command the driver sends (see monitor.go)
+	// .SetPoolMonitor() counts connection checkouts and churn (see pool.go)
+	clientOptions := options.Client().ApplyURI(mongoURI).
+		SetMonitor(newCommandMonitor()).
+		SetPoolMonitor(newPoolMonitor())
+
+	// MONGO_WARM_CONNECTIONS keeps that many connections open (see pool.go)
+	warm := warmConnections()
+	if warm > 0 {
+		clientOptions.SetMinPoolSize(warm)
+	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 5: ACTUALLY CONNECT TO MONGODB
+	// STEP 4: ACTUALLY CONNECT TO MONGODB
 	// ----------------------------------------------------------------------------
 	// mongo.Connect() establishes the connection to MongoDB server
-	// Note: We're assigning to the package-level "client" variable (not creating a new one)
-	// That's why we use "var err error" first - to avoid shadowing with :=
-	var err error
-	client, err = mongo.Connect(ctx, clientOptions)
+	// (a local variable until the ping below succeeds)
+	newClient, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		// If connection fails (wrong URI, MongoDB not running, network issue)
-		logger.Log.Error("Failed to connect to MongoDB", "error", err)
-		log.Fatal("Failed to connect to MongoDB:")
+		return fmt.Errorf("connect: %w", err)
 	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 6: PING MONGODB TO VERIFY CONNECTION WORKS
+	// STEP 5: PING MONGODB TO VERIFY CONNECTION WORKS
 	// ----------------------------------------------------------------------------
 	// Just because Connect() succeeded doesn't mean we can actually talk to MongoDB
 	// Ping() sends a test message to verify the connection is working
-	err = client.Ping(ctx, nil)
-	if err != nil {
+	if err := newClient.Ping(ctx, nil); err != nil {
 		// If ping fails, the connection isn't working properly
-		logger.Log.Error("Failed to ping MongoDB", "error", err)
-		log.Fatal("Failed to ping MongoDB:")
+		_ = newClient.Disconnect(context.Background())
+		return fmt.Errorf("ping: %w", err)
+	}
+
+	// Open the warm connections now rather than on the first requests
+	if warm > 0 {
+		warmPool(ctx, newClient, warm)
 	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 7: SELECT DATABASE AND COLLECTION
+	// STEP 6: SELECT DATABASE AND COLLECTION
 	// ----------------------------------------------------------------------------
 	// MongoDB structure: Server → Database → Collection → Documents
 	// client.Database("todoapi") = selects the "todoapi" database
@@ -134,12 +167,15 @@ func Connect() {
 	//
 	// Note: MongoDB will automatically create the database and collection
 	// the first time we insert a document - we don't need to create them manually!
+	// Note: We're assigning to the package-level variables (not creating new ones)
+	client = newClient
 	collection = client.Database("todoapi").Collection("tasks")
 
 	// ----------------------------------------------------------------------------
-	// STEP 8: LOG SUCCESS
+	// STEP 7: LOG SUCCESS
 	// ----------------------------------------------------------------------------
 	logger.Log.Info("Connected to MongoDB", "database", "todoapi", "collection", "tasks")
+	return nil
 }
 
 // ============================================================================
@@ -161,6 +197,69 @@ func GetCollection() *mongo.Collection {
 	return collection // Return the package-level collection variable
 }
 
+// GetNamedCollection returns another collection in the same database
+// e.g. database.GetNamedCollection("jobs") for the background job queue
+// Returns nil before Connect()
+func GetNamedCollection(name string) *mongo.Collection {
+	if client == nil {
+		return nil
+	}
+	return collection.Database().Collection(name)
+}
+
+// ============================================================================
+// IN-MEMORY STORAGE (DB_DRIVER=memory)
+// ============================================================================
+// UseMemory is called instead of Connect() when the server keeps its data in
+// memory: the stores aren't MongoDB's, so Ping has nothing to check and the
+// health checks report the server as up
+func UseMemory() {
+	inMemory = true
+}
+
+// InMemory reports whether UseMemory was called
+func InMemory() bool {
+	return inMemory
+}
+
+// ============================================================================
+// PING (CONNECTIVITY CHECK)
+// ============================================================================
+// ErrNotConnected is returned when the database is used before Connect()
+var ErrNotConnected = errors.New("database: not connected")
+
+// Ping checks that MongoDB is reachable right now
+// Used by the readiness probe - callers should pass a context with a short
+// timeout so a hung database can't hang the probe too
+func Ping(ctx context.Context) error {
+	if inMemory {
+		return nil
+	}
+	if client == nil {
+		return ErrNotConnected
+	}
+	// readpref.Primary() = the probe only passes if we can reach a node that accepts writes
+	return client.Ping(ctx, readpref.Primary())
+}
+
+// slowPingThreshold is the ping latency above which MongoDB counts as degraded
+const slowPingThreshold = 500 * time.Millisecond
+
+// HealthCheck reports MongoDB health for the /health/details endpoint
+// - down:     ping failed (not connected, unreachable, timed out)
+// - degraded: ping succeeded but took longer than slowPingThreshold
+// - ok:       ping succeeded quickly
+func HealthCheck(ctx context.Context) health.Result {
+	start := time.Now()
+	if err := Ping(ctx); err != nil {
+		return health.Result{State: health.StateDown, Message: err.Error()}
+	}
+	if latency := time.Since(start); latency > slowPingThreshold {
+		return health.Result{State: health.StateDegraded, Message: "slow ping: " + latency.String()}
+	}
+	return health.Result{State: health.StateOK}
+}
+
 // ============================================================================
 // CLOSE CONNECTION (CLEANUP FUNCTION)
 // ============================================================================
@@ -170,13 +269,8 @@ func GetCollection() *mongo.Collection {
 // - Release system resources (file descriptors, memory)
 // - Allow pending operations to complete
 //
-// Note: In our current main.go, we don't call this because the server
-// runs forever (until killed). In production, you'd call this in a
-// shutdown handler that runs when the server receives a stop signal.
-//
-// Example usage (not currently used):
-//
-//	defer database.Close() // Call Close when main() exits
+// cmd/api/main.go calls this after the HTTP server and the job workers
+// have stopped (on Ctrl+C or SIGTERM).
 func Close() {
 	// Only try to disconnect if client was actually created
 	if client != nil {
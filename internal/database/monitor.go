@@ -0,0 +1,122 @@
+// This file traces every MongoDB command the driver sends
+// Instead of wrapping each collection call in a handler with its own span,
+// we hook the driver's CommandMonitor so nothing can be forgotten
+
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// commandTracer creates one span per MongoDB command
+// The driver calls Started, then either Succeeded or Failed, with the same
+// RequestID - so we park the span in a map between the two calls
+type commandTracer struct {
+	tracer trace.Tracer
+	spans  sync.Map // RequestID (int64) → trace.Span
+}
+
+// commandFailures counts every failed MongoDB command since startup
+// Read it with CommandFailures() (e.g. for the Lambda DB error metric)
+var commandFailures atomic.Int64
+
+// CommandFailures returns how many MongoDB commands have failed since startup
+func CommandFailures() int64 {
+	return commandFailures.Load()
+}
+
+// newCommandMonitor returns a CommandMonitor that traces every command
+// Pass it to options.Client().SetMonitor()
+func newCommandMonitor() *event.CommandMonitor {
+	ct := &commandTracer{tracer: otel.Tracer("mongodb")}
+	return &event.CommandMonitor{
+		Started:   ct.started,
+		Succeeded: ct.succeeded,
+		Failed:    ct.failed,
+	}
+}
+
+// started opens a span as a child of the span in ctx (the handler's span)
+// Span name follows the OTel convention "<command> <collection>", e.g. "find tasks"
+func (ct *commandTracer) started(ctx context.Context, evt *event.CommandStartedEvent) {
+	collection := commandCollection(evt.CommandName, evt.Command)
+
+	name := evt.CommandName
+	if collection != "" {
+		name += " " + collection
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "mongodb"),
+		attribute.String("db.name", evt.DatabaseName),
+		attribute.String("db.operation", evt.CommandName),
+		attribute.String("db.mongodb.connection_id", evt.ConnectionID),
+	}
+	if collection != "" {
+		attrs = append(attrs, attribute.String("db.collection", collection))
+	}
+
+	_, span := ct.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	ct.spans.Store(evt.RequestID, span)
+}
+
+// succeeded ends the span with the server-side latency
+func (ct *commandTracer) succeeded(ctx context.Context, evt *event.CommandSucceededEvent) {
+	span, ok := ct.finish(evt.RequestID)
+	if !ok {
+		return
+	}
+	span.SetAttributes(attribute.Float64("db.duration_ms", float64(evt.Duration.Microseconds())/1000))
+	span.End()
+}
+
+// failed ends the span and marks it as an error (shows red in Jaeger)
+func (ct *commandTracer) failed(ctx context.Context, evt *event.CommandFailedEvent) {
+	commandFailures.Add(1)
+
+	span, ok := ct.finish(evt.RequestID)
+	if !ok {
+		return
+	}
+	span.SetAttributes(attribute.Float64("db.duration_ms", float64(evt.Duration.Microseconds())/1000))
+	span.SetStatus(codes.Error, evt.Failure)
+	span.RecordError(errors.New(evt.Failure))
+	span.End()
+}
+
+// finish removes and returns the span opened for a request
+func (ct *commandTracer) finish(requestID int64) (trace.Span, bool) {
+	v, ok := ct.spans.LoadAndDelete(requestID)
+	if !ok {
+		return nil, false
+	}
+	return v.(trace.Span), true
+}
+
+// commandCollection extracts the collection name from a command document
+// For most commands the first element is {"<command>": "<collection>"},
+// e.g. {"find": "tasks", "filter": {...}}
+func commandCollection(commandName string, cmd bson.Raw) string {
+	value, err := cmd.LookupErr(commandName)
+	if err != nil {
+		return ""
+	}
+	collection, ok := value.StringValueOK()
+	if !ok {
+		return ""
+	}
+	return collection
+}
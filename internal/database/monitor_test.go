@@ -0,0 +1,22 @@
+package database
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestCommandCollection tests reading the collection name out of a command
+func TestCommandCollection(t *testing.T) {
+	find, _ := bson.Marshal(bson.D{{Key: "find", Value: "tasks"}, {Key: "filter", Value: bson.D{}}})
+	ping, _ := bson.Marshal(bson.D{{Key: "ping", Value: 1}})
+
+	if got := commandCollection("find", find); got != "tasks" {
+		t.Errorf("Expected 'tasks', got '%s'", got)
+	}
+
+	// ping has no collection - its value is a number
+	if got := commandCollection("ping", ping); got != "" {
+		t.Errorf("Expected no collection for ping, got '%s'", got)
+	}
+}
@@ -0,0 +1,163 @@
+// This file counts what happens in the driver's connection pool
+// Slow requests with fast MongoDB commands usually mean the request waited
+// for a connection, not for the server. The command spans (monitor.go) can't
+// show that wait, so we hook the driver's PoolMonitor and count checkouts,
+// how long they waited and how often connections are opened and closed.
+// Read the numbers with GetPoolStats() (GET /admin/db-pool, Lambda EMF).
+
+package database
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"go-todo-api/internal/logger"
+)
+
+// PoolStats is a snapshot of the connection pool counters since startup
+type PoolStats struct {
+	Checkouts        int64         // Connections handed to a command
+	CheckoutFailures int64         // Checkouts that gave up (timeout, pool closed)
+	CheckoutWait     time.Duration // Total time commands waited for a connection
+	MaxCheckoutWait  time.Duration // Longest single wait
+	Created          int64         // Connections opened
+	Closed           int64         // Connections closed (idle, stale or broken)
+	Open             int64         // Connections open right now
+	InUse            int64         // Connections checked out right now
+	Cleared          int64         // Times the pool was cleared, e.g. after a network error
+}
+
+// poolCounters receives the pool events and keeps the counts
+type poolCounters struct {
+	checkouts        atomic.Int64
+	checkoutFailures atomic.Int64
+	checkoutWait     atomic.Int64 // Nanoseconds
+	maxCheckoutWait  atomic.Int64 // Nanoseconds
+	created          atomic.Int64
+	closed           atomic.Int64
+	checkedIn        atomic.Int64
+	cleared          atomic.Int64
+}
+
+// pool counts the events of the client created by dial
+var pool poolCounters
+
+// GetPoolStats returns the connection pool counters since startup
+func GetPoolStats() PoolStats {
+	return pool.stats()
+}
+
+// newPoolMonitor returns a PoolMonitor feeding the package counters
+// Pass it to options.Client().SetPoolMonitor()
+func newPoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{Event: pool.event}
+}
+
+// event is called by the driver for every pool event, from many goroutines
+func (p *poolCounters) event(evt *event.PoolEvent) {
+	switch evt.Type {
+	case event.GetSucceeded:
+		p.checkouts.Add(1)
+		p.recordWait(evt.Duration)
+	case event.GetFailed:
+		p.checkoutFailures.Add(1)
+		p.recordWait(evt.Duration)
+	case event.ConnectionReturned:
+		p.checkedIn.Add(1)
+	case event.ConnectionCreated:
+		p.created.Add(1)
+	case event.ConnectionClosed:
+		p.closed.Add(1)
+	case event.PoolCleared:
+		p.cleared.Add(1)
+		logger.Log.Warn("MongoDB connection pool cleared", "address", evt.Address, "error", evt.Error)
+	}
+}
+
+// recordWait adds a checkout's wait to the total and keeps the maximum
+func (p *poolCounters) recordWait(d time.Duration) {
+	p.checkoutWait.Add(int64(d))
+	for {
+		longest := p.maxCheckoutWait.Load()
+		if int64(d) <= longest || p.maxCheckoutWait.CompareAndSwap(longest, int64(d)) {
+			return
+		}
+	}
+}
+
+// stats reads the counters
+// Each one is read on its own, so a snapshot taken under load can be off by
+// a checkout or two between fields - fine for a dashboard
+func (p *poolCounters) stats() PoolStats {
+	checkouts := p.checkouts.Load()
+	created := p.created.Load()
+	closed := p.closed.Load()
+	return PoolStats{
+		Checkouts:        checkouts,
+		CheckoutFailures: p.checkoutFailures.Load(),
+		CheckoutWait:     time.Duration(p.checkoutWait.Load()),
+		MaxCheckoutWait:  time.Duration(p.maxCheckoutWait.Load()),
+		Created:          created,
+		Closed:           closed,
+		Open:             created - closed,
+		InUse:            max(checkouts-p.checkedIn.Load(), 0),
+		Cleared:          p.cleared.Load(),
+	}
+}
+
+// ============================================================================
+// WARM-UP
+// ============================================================================
+// The pool opens connections as requests need them, so the first burst of
+// traffic after a start pays for TCP + TLS + auth handshakes. With
+// MONGO_WARM_CONNECTIONS=N the pool keeps at least N connections open
+// (the driver's minPoolSize) and N are opened at startup, before any request.
+
+// warmConnections reads MONGO_WARM_CONNECTIONS (default 0: no warm-up)
+func warmConnections() uint64 {
+	value := os.Getenv("MONGO_WARM_CONNECTIONS")
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		logger.Log.Warn("Ignoring invalid MONGO_WARM_CONNECTIONS", "value", value, "error", err)
+		return 0
+	}
+	return n
+}
+
+// warmPool opens up to n connections by pinging on n of them at once
+// The driver opens a couple of connections at a time and keeps filling the
+// pool up to minPoolSize in the background, so fewer may be open when this
+// returns. Failures are only logged: the pool still opens connections on demand.
+func warmPool(ctx context.Context, c *mongo.Client, n uint64) {
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	var failed atomic.Int64
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Ping(ctx, readpref.Primary()); err != nil {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	logger.Log.Info("Warmed MongoDB connection pool",
+		"requested", n,
+		"open", GetPoolStats().Open,
+		"failed_pings", failed.Load(),
+		"duration_ms", time.Since(start).Milliseconds())
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+
+	"go-todo-api/internal/logger"
+)
+
+// TestPoolCounters tests counting checkouts, waits and connection churn from
+// the events the driver sends
+func TestPoolCounters(t *testing.T) {
+	// Arrange
+	var p poolCounters
+	events := []*event.PoolEvent{
+		{Type: event.ConnectionCreated},
+		{Type: event.ConnectionCreated},
+		{Type: event.GetStarted},
+		{Type: event.GetSucceeded, Duration: 2 * time.Millisecond},
+		{Type: event.GetSucceeded, Duration: 10 * time.Millisecond},
+		{Type: event.ConnectionReturned},
+		{Type: event.GetFailed, Duration: 30 * time.Millisecond},
+		{Type: event.ConnectionClosed},
+	}
+
+	// Act
+	for _, evt := range events {
+		p.event(evt)
+	}
+	stats := p.stats()
+
+	// Assert
+	if stats.Checkouts != 2 || stats.CheckoutFailures != 1 {
+		t.Errorf("Expected 2 checkouts and 1 failure, got %d and %d", stats.Checkouts, stats.CheckoutFailures)
+	}
+	if stats.CheckoutWait != 42*time.Millisecond {
+		t.Errorf("Expected 42ms total wait, got %s", stats.CheckoutWait)
+	}
+	if stats.MaxCheckoutWait != 30*time.Millisecond {
+		t.Errorf("Expected 30ms longest wait, got %s", stats.MaxCheckoutWait)
+	}
+	if stats.Open != 1 || stats.InUse != 1 {
+		t.Errorf("Expected 1 open and 1 in use, got %d and %d", stats.Open, stats.InUse)
+	}
+
+	t.Logf("✅ %+v", stats)
+}
+
+// TestWarmConnections tests reading MONGO_WARM_CONNECTIONS
+func TestWarmConnections(t *testing.T) {
+	logger.Init()
+
+	tests := []struct {
+		value string
+		want  uint64
+	}{
+		{"", 0},
+		{"10", 10},
+		{"-1", 0}, // Invalid values are ignored, not fatal
+		{"many", 0},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("MONGO_WARM_CONNECTIONS", tt.value)
+		if got := warmConnections(); got != tt.want {
+			t.Errorf("MONGO_WARM_CONNECTIONS=%q: expected %d, got %d", tt.value, tt.want, got)
+		}
+	}
+}
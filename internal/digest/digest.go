@@ -0,0 +1,299 @@
+// Package digest emails users a morning summary of their tasks
+// Once an hour the scheduler finds the users who opted in (in their
+// notification settings) and for whom it's now the digest hour in their own
+// time zone (from their profile), and queues a job for each; the job workers render the email
+// from the templates in templates/ and send it. Dates and counts are written
+// the way the user's locale (also from their profile) writes them.
+package digest
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/template"
+	"time"
+
+	"go-todo-api/internal/duedate"
+	"go-todo-api/internal/email"
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/locale"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/profile"
+	"go-todo-api/internal/push"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/scheduler"
+)
+
+// JobSend renders and sends one user's digest
+const JobSend = "digest.send"
+
+// maxOpen is how many open tasks the email lists before "...and N more"
+const maxOpen = 20
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	textTemplate = template.Must(template.ParseFS(templates, "templates/digest.txt"))
+	// html/template escapes task titles, so a title can't inject markup
+	htmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/digest.html"))
+)
+
+// Options tunes the digest
+type Options struct {
+	// Hour is the local hour (0-23) users get their digest at (default 7)
+	Hour int
+}
+
+// OptionsFromEnv reads DIGEST_HOUR
+func OptionsFromEnv() Options {
+	opts := Options{Hour: 7}
+	if v, err := strconv.Atoi(os.Getenv("DIGEST_HOUR")); err == nil && v >= 0 && v <= 23 {
+		opts.Hour = v
+	}
+	return opts
+}
+
+// Digester queues and sends the daily digests
+type Digester struct {
+	prefs    push.PreferenceStore
+	profiles *profile.Profiles // nil = everyone is on UTC
+	tasks    repository.TaskRepository
+	sender   email.Sender
+	hour     int
+	pool     *jobs.Pool       // Set by Register; nil = the default pool
+	now      func() time.Time // time.Now, except in tests
+}
+
+// New creates a digester that reads who opted in from prefs, their time
+// zones from profiles and their tasks from tasks, and sends with sender
+func New(prefs push.PreferenceStore, profiles *profile.Profiles, tasks repository.TaskRepository, sender email.Sender, opts Options) *Digester {
+	return &Digester{prefs: prefs, profiles: profiles, tasks: tasks, sender: sender, hour: opts.Hour, now: time.Now}
+}
+
+// userProfile returns a user's profile, for their time zone and locale
+func (d *Digester) userProfile(ctx context.Context, userID string) (profile.Profile, error) {
+	if d.profiles == nil {
+		return profile.Profile{UserID: userID}, nil // UTC, default locale
+	}
+	return d.profiles.Get(ctx, userID)
+}
+
+// Register adds the digest job handler to a worker pool
+// Call it before pool.Start(), on every process that runs workers.
+func (d *Digester) Register(pool *jobs.Pool) {
+	d.pool = pool
+	pool.Register(JobSend, d.send, jobs.RetryPolicy{})
+}
+
+// Schedule adds the hourly check for whose morning it is
+// Hourly rather than once a day, because every time zone's morning comes
+// at a different UTC hour (at minute 0 - half-hour zones like India get it
+// at half past).
+func (d *Digester) Schedule(s *scheduler.Scheduler) error {
+	return s.Register("daily-digest", "0 * * * *", d.queue, scheduler.TaskOptions{Jitter: 30 * time.Second})
+}
+
+// sendPayload is the JobSend payload
+type sendPayload struct {
+	UserID string `json:"user_id"`
+	Date   string `json:"date"` // The user's local date, 2006-01-02
+}
+
+// queue enqueues a digest for each opted-in user whose local time is the digest hour
+func (d *Digester) queue(ctx context.Context) error {
+	recipients, err := d.prefs.DigestRecipients(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := d.now()
+	queued := 0
+	for _, prefs := range recipients {
+		user, err := d.userProfile(ctx, prefs.UserID)
+		if err != nil {
+			return err
+		}
+		local := now.In(user.Location())
+		if local.Hour() != d.hour {
+			continue
+		}
+		payload := sendPayload{UserID: prefs.UserID, Date: local.Format(time.DateOnly)}
+		if _, err := d.enqueue(ctx, payload); err != nil {
+			return err
+		}
+		queued++
+	}
+	logger.WithTrace(ctx).Info("Queued daily digests", slog.Int("queued", queued), slog.Int("opted_in", len(recipients)))
+	return nil
+}
+
+// enqueue adds a job to the registered pool, or the default one
+func (d *Digester) enqueue(ctx context.Context, payload sendPayload) (*jobs.Job, error) {
+	if d.pool != nil {
+		return d.pool.Enqueue(ctx, JobSend, payload)
+	}
+	return jobs.Enqueue(ctx, JobSend, payload)
+}
+
+// send handles JobSend
+func (d *Digester) send(ctx context.Context, job *jobs.Job) error {
+	var p sendPayload
+	if err := job.Decode(&p); err != nil {
+		return err
+	}
+
+	// The user may have turned it off since the job was queued
+	prefs, err := d.prefs.GetPreferences(ctx, p.UserID)
+	if errors.Is(err, push.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !prefs.Allows(push.ChannelEmail, push.EventDigest) || prefs.Email == "" {
+		return nil
+	}
+	user, err := d.userProfile(ctx, p.UserID)
+	if err != nil {
+		return err
+	}
+	loc := user.Location()
+	day, err := time.ParseInLocation(time.DateOnly, p.Date, loc)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := d.tasks.ListOwned(ctx, p.UserID)
+	if err != nil {
+		return err
+	}
+	data := summarize(p.UserID, day, d.now().In(loc), tasks)
+	data.formats = user.Formats()
+	if data.OpenCount == 0 && len(data.Completed) == 0 {
+		return nil // Nothing to say - don't send an empty email
+	}
+
+	msg, err := render(data)
+	if err != nil {
+		return err
+	}
+	msg.To = prefs.Email
+	if err := d.sender.Send(ctx, msg); err != nil {
+		return err
+	}
+	logger.WithTrace(ctx).Info("Sent daily digest",
+		slog.String("user_id", p.UserID), slog.Int("open", data.OpenCount), slog.Int("completed", len(data.Completed)),
+		slog.Int("due_today", len(data.DueToday)), slog.Int("overdue", len(data.Overdue)))
+	return nil
+}
+
+// ============================================================================
+// CONTENT
+// ============================================================================
+
+// Data is what the templates render
+type Data struct {
+	UserID    string
+	Day       time.Time     // Midnight at the start of the digest's date, in the user's time zone
+	Overdue   []models.Task // Open and past their due date
+	DueToday  []models.Task // Open and due later on the digest's date
+	Open      []models.Task // Oldest first, at most maxOpen (excluding the two above)
+	OpenCount int
+	MoreOpen  int           // Open tasks not listed
+	Completed []models.Task // Completed the day before, in the user's time zone
+
+	formats locale.Locale // The user's, for Date, Due and Number
+}
+
+// Date returns the digest's date in words, e.g. "Friday, 31 January"
+func (d Data) Date() string {
+	return d.formats.LongDate(d.Day)
+}
+
+// Due returns when an open task is due, in the user's time zone: the time
+// for one due on the digest's date, else the date and time
+func (d Data) Due(task models.Task) string {
+	if task.DueAt == nil {
+		return ""
+	}
+	due := task.DueAt.In(d.Day.Location())
+	if duedate.StartOfDay(due).Equal(d.Day) {
+		return d.formats.Time(due)
+	}
+	return d.formats.DateTime(due)
+}
+
+// Number writes a count, e.g. "1,204"
+func (d Data) Number(n int) string {
+	return d.formats.Number(n)
+}
+
+// summarize sorts the open tasks into overdue, due today and the rest, and
+// picks those completed the day before day
+// day is midnight at the start of the digest's date and now is the current
+// time, both in the user's time zone.
+func summarize(userID string, day, now time.Time, tasks []models.Task) Data {
+	data := Data{UserID: userID, Day: day}
+	yesterday := day.AddDate(0, 0, -1)
+	tomorrow := duedate.StartOfDay(day.AddDate(0, 0, 1))
+	for _, task := range tasks {
+		switch {
+		case !task.Completed && task.DueAt != nil && task.DueAt.Before(now):
+			data.OpenCount++
+			data.Overdue = append(data.Overdue, task)
+		case !task.Completed && task.DueAt != nil && task.DueAt.Before(tomorrow):
+			data.OpenCount++
+			data.DueToday = append(data.DueToday, task)
+		case !task.Completed:
+			data.OpenCount++
+			if len(data.Open) < maxOpen {
+				data.Open = append(data.Open, task)
+			}
+		case task.CompletedAt != nil && !task.CompletedAt.Before(yesterday) && task.CompletedAt.Before(day):
+			data.Completed = append(data.Completed, task)
+		}
+	}
+	data.MoreOpen = data.OpenCount - len(data.Overdue) - len(data.DueToday) - len(data.Open)
+	return data
+}
+
+// render fills in the subject and both bodies
+func render(data Data) (email.Message, error) {
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return email.Message{}, err
+	}
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return email.Message{}, err
+	}
+	return email.Message{
+		Subject: "Your tasks for " + data.Date(),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// ============================================================================
+// DEFAULT DIGESTER
+// ============================================================================
+
+// defaultDigester is registered with the job pool and scheduler at startup
+var defaultDigester *Digester
+
+// Init sets the default digester; Init(nil) turns the digest off
+func Init(d *Digester) *Digester {
+	defaultDigester = d
+	return d
+}
+
+// Default returns the digester set by Init (nil when email is off)
+func Default() *Digester {
+	return defaultDigester
+}
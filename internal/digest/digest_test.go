@@ -0,0 +1,160 @@
+package digest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/email"
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/locale"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/profile"
+	"go-todo-api/internal/push"
+	"go-todo-api/internal/repository"
+)
+
+// fakeSender records the emails instead of sending them
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []email.Message
+}
+
+func (f *fakeSender) Send(ctx context.Context, msg email.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func (f *fakeSender) messages() []email.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]email.Message(nil), f.sent...)
+}
+
+// TestDigester_Queue tests that users get their digest at 7am in their own time zone
+func TestDigester_Queue(t *testing.T) {
+	// Arrange: it's 7am in Paris and 1am in New York; carol turned the digest off
+	logger.Init()
+	ctx := context.Background()
+	paris, _ := time.LoadLocation("Europe/Paris")
+	prefs := push.NewMemoryPreferenceStore()
+	profiles := profile.New(profile.NewMemoryStore())
+	for _, p := range []push.Preferences{
+		{UserID: "alice", Email: "alice@example.com"},
+		{UserID: "bob", Email: "bob@example.com"},
+		{UserID: "carol", Email: "carol@example.com"},
+	} {
+		p.Channels.Email = true
+		p.Events.DailyDigest = p.UserID != "carol"
+		_ = prefs.SavePreferences(ctx, &p)
+	}
+	_, _ = profiles.Save(ctx, profile.Profile{UserID: "alice", TimeZone: "Europe/Paris"})
+	_, _ = profiles.Save(ctx, profile.Profile{UserID: "bob", TimeZone: "America/New_York"})
+	_, _ = profiles.Save(ctx, profile.Profile{UserID: "carol", TimeZone: "Europe/Paris"})
+
+	tasks := repository.NewMemoryTaskRepository()
+	yesterdayEvening := time.Date(2025, 1, 30, 22, 30, 0, 0, paris)
+	lastWeek := time.Date(2025, 1, 24, 12, 0, 0, 0, paris)
+	todayAt5 := time.Date(2025, 1, 31, 17, 0, 0, 0, paris)
+	tomorrow := time.Date(2025, 2, 1, 23, 59, 59, 0, paris)
+	for _, task := range []models.Task{
+		{Title: "Buy milk", OwnerID: "alice"},
+		{Title: "<b>Call</b> mom", OwnerID: "alice"},
+		{Title: "Pay rent", OwnerID: "alice", DueAt: &yesterdayEvening},
+		{Title: "Send invoice", OwnerID: "alice", DueAt: &todayAt5},
+		{Title: "Book flights", OwnerID: "alice", DueAt: &tomorrow},
+		{Title: "Write report", OwnerID: "alice", Completed: true, CompletedAt: &yesterdayEvening},
+		{Title: "Old news", OwnerID: "alice", Completed: true, CompletedAt: &lastWeek},
+		{Title: "Carol's task", OwnerID: "carol"},
+	} {
+		_ = tasks.Create(ctx, &task)
+	}
+
+	sender := &fakeSender{}
+	d := New(prefs, profiles, tasks, sender, Options{Hour: 7})
+	d.now = func() time.Time { return time.Date(2025, 1, 31, 6, 0, 0, 0, time.UTC) }
+	pool := jobs.NewPool(jobs.NewMemoryStore(), jobs.Options{Workers: 1, PollInterval: 5 * time.Millisecond})
+	d.Register(pool)
+	pool.Start()
+	t.Cleanup(func() { pool.Shutdown(context.Background()) })
+
+	// Act
+	if err := d.queue(ctx); err != nil {
+		t.Fatalf("queue failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) && len(sender.messages()) == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond) // Anything else queued would have been sent by now
+
+	// Assert
+	sent := sender.messages()
+	if len(sent) != 1 || sent[0].To != "alice@example.com" {
+		t.Fatalf("Expected one digest, to alice, got %+v", sent)
+	}
+	msg := sent[0]
+	if msg.Subject != "Your tasks for Friday, 31 January" {
+		t.Errorf("Expected alice's local date in the subject, got %q", msg.Subject)
+	}
+	for _, want := range []string{
+		"Overdue (1):\n  - Pay rent (due 30/01/2025 22:30)", "Due today (1):\n  - Send invoice (due 17:00)",
+		"Open tasks (5)", "Buy milk", "Book flights", "Completed yesterday (1)", "Write report",
+	} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Expected %q in the text:\n%s", want, msg.Text)
+		}
+	}
+	if strings.Contains(msg.Text, "Old news") {
+		t.Error("Expected only yesterday's completions")
+	}
+	if !strings.Contains(msg.HTML, "&lt;b&gt;Call&lt;/b&gt; mom") {
+		t.Errorf("Expected task titles to be escaped in the HTML:\n%s", msg.HTML)
+	}
+
+	t.Logf("✅ Digest sent to %s", msg.To)
+}
+
+// TestSummarize_Locale tests that the date, due times and counts are written
+// the way the user's locale writes them
+func TestSummarize_Locale(t *testing.T) {
+	// Arrange
+	paris, _ := time.LoadLocation("Europe/Paris")
+	day := time.Date(2025, 1, 31, 0, 0, 0, 0, paris)
+	due := time.Date(2025, 1, 31, 17, 0, 0, 0, time.UTC) // 18:00 in Paris
+	tasks := []models.Task{{Title: "Send invoice", DueAt: &due}}
+	for range 1500 {
+		tasks = append(tasks, models.Task{Title: "Someday"})
+	}
+	german, _ := locale.Parse("de-DE")
+	american, _ := locale.Parse("en-US")
+
+	// Act
+	data := summarize("alice", day, day.Add(7*time.Hour), tasks)
+	data.formats = german
+	msg, err := render(data)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	// Assert
+	if msg.Subject != "Your tasks for Freitag, 31. Januar" {
+		t.Errorf("Expected the German date in the subject, got %q", msg.Subject)
+	}
+	for _, want := range []string{"Send invoice (due 18:00)", "Open tasks (1.501)", "...and 1.480 more"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Expected %q in the text:\n%s", want, msg.Text)
+		}
+	}
+	data.formats = american
+	if got := data.Due(tasks[0]); got != "6:00 PM" {
+		t.Errorf("Expected an American due time, got %q", got)
+	}
+
+	t.Log("✅ Digest written in the user's locale")
+}
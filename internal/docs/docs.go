@@ -0,0 +1,82 @@
+// Package docs serves the interactive API documentation page
+// Huma's built-in /docs page (Stoplight Elements) loads its JavaScript from a
+// CDN, which doesn't work without internet access. This package serves
+// Swagger UI from files embedded in the binary instead.
+package docs
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+)
+
+//go:embed swagger/index.html swagger/init.js swagger/swagger-ui-bundle.js swagger/swagger-ui.css
+var swaggerFiles embed.FS
+
+// Renderer selects the docs page
+type Renderer string
+
+const (
+	// RendererStoplight is Huma's default page (Stoplight Elements, loaded from a CDN)
+	RendererStoplight Renderer = "stoplight"
+	// RendererSwagger is Swagger UI from embedded files (works offline)
+	RendererSwagger Renderer = "swagger"
+)
+
+// RendererFromEnv reads DOCS_UI (stoplight or swagger, default stoplight)
+// Unknown values fall back to stoplight.
+func RendererFromEnv() Renderer {
+	if Renderer(strings.ToLower(strings.TrimSpace(os.Getenv("DOCS_UI")))) == RendererSwagger {
+		return RendererSwagger
+	}
+	return RendererStoplight
+}
+
+// contentSecurityPolicy allows Swagger UI's own files and its inline styles
+const contentSecurityPolicy = "default-src 'none'; script-src 'self'; style-src 'self' 'unsafe-inline'; connect-src 'self'; img-src 'self' data:; frame-ancestors 'none'; base-uri 'none'"
+
+// SwaggerHandler serves Swagger UI
+// basePath is the API prefix (e.g. "/api"). Routes, relative to basePath:
+//
+//	GET /docs          → the page
+//	GET /docs/assets/* → embedded JavaScript and CSS
+func SwaggerHandler(title, basePath string) http.Handler {
+	page := renderPage(title, basePath)
+
+	assets, err := fs.Sub(swaggerFiles, "swagger")
+	if err != nil {
+		panic(err) // The files are embedded at build time, so this can't happen
+	}
+	fileServer := http.StripPrefix(basePath+"/docs/assets/", http.FileServer(http.FS(assets)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
+
+		if r.URL.Path == basePath+"/docs" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(page)
+			return
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// renderPage fills the asset and spec URLs into index.html once at startup
+func renderPage(title, basePath string) []byte {
+	tmpl := template.Must(template.ParseFS(swaggerFiles, "swagger/index.html"))
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, struct{ Title, AssetsPath, OpenAPIURL string }{
+		Title:      title,
+		AssetsPath: basePath + "/docs/assets",
+		OpenAPIURL: basePath + "/openapi.json",
+	})
+	if err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
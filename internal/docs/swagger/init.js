@@ -0,0 +1,25 @@
+// Starts Swagger UI on the embedded docs page
+// The spec and every "Try it out" call need the X-API-Key header; the key is
+// shared with the web UI through localStorage so it's only entered once.
+(function () {
+  "use strict";
+
+  function apiKey() {
+    var key = localStorage.getItem("todo-api-key");
+    if (!key) {
+      key = window.prompt("API key") || "";
+      if (key) { localStorage.setItem("todo-api-key", key); }
+    }
+    return key;
+  }
+
+  window.ui = SwaggerUIBundle({
+    url: document.querySelector('meta[name="openapi-url"]').content,
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    requestInterceptor: function (req) {
+      req.headers["X-API-Key"] = apiKey();
+      return req;
+    }
+  });
+})();
@@ -0,0 +1,88 @@
+// Package domainerrors holds the kinds of error the layers under the
+// handlers return - the repository, the stores and the services - and the
+// one place they're turned into HTTP status codes
+//
+// A package declares its errors as kinds of these:
+//
+//	var ErrNotFound = domainerrors.New(domainerrors.ErrNotFound, "Task not found")
+//
+// Callers still check errors.Is(err, repository.ErrNotFound), and the
+// handlers answer every error of the kind the same way without knowing the
+// package it came from (see Status).
+package domainerrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// The kinds of domain error
+var (
+	// ErrNotFound: the thing asked for doesn't exist (404)
+	ErrNotFound = errors.New("not found")
+
+	// ErrValidation: the request can't be carried out as asked (422)
+	ErrValidation = errors.New("invalid")
+
+	// ErrConflict: it clashes with what's already stored (409)
+	ErrConflict = errors.New("conflict")
+
+	// ErrQuotaExceeded: the caller has used up a limit (403)
+	ErrQuotaExceeded = errors.New("quota exceeded")
+)
+
+// statuses maps each kind to its HTTP status code
+var statuses = map[error]int{
+	ErrNotFound:      http.StatusNotFound,
+	ErrValidation:    http.StatusUnprocessableEntity,
+	ErrConflict:      http.StatusConflict,
+	ErrQuotaExceeded: http.StatusForbidden,
+}
+
+// Error is a domain error
+// Message is written for the API's callers; Field, Detail and Value point
+// at what caused it, when there's one thing to point at.
+type Error struct {
+	Kind    error  // ErrNotFound, ErrValidation, ErrConflict or ErrQuotaExceeded, or a sentinel of one
+	Message string // e.g. "Task not found"
+	Field   string // e.g. "quota.tasks" (optional)
+	Detail  string // What's wrong with Field, e.g. "delete tasks to create new ones" (optional)
+	Value   any    // The value at Field (optional)
+	Err     error  // The cause (optional)
+}
+
+// New creates an error of a kind, usually a package's sentinel
+func New(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Error returns the message, and the cause's if there is one
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap makes errors.Is match the kind as well as the cause
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// Status returns the HTTP status code for err's kind, and the domain error
+// it found in err; 0 and nil when err isn't a domain error
+func Status(err error) (int, *Error) {
+	var domainErr *Error
+	if !errors.As(err, &domainErr) {
+		return 0, nil
+	}
+	for kind, status := range statuses {
+		if errors.Is(domainErr, kind) {
+			return status, domainErr
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,57 @@
+package domainerrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestStatus tests each kind maps to its status, through wrapping
+func TestStatus(t *testing.T) {
+	errMissing := New(ErrNotFound, "Task not found")
+	cause := errors.New("E11000 duplicate key")
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", errMissing, http.StatusNotFound},
+		{"wrapped", fmt.Errorf("get task: %w", errMissing), http.StatusNotFound},
+		{"validation", New(ErrValidation, "Unknown time zone"), http.StatusUnprocessableEntity},
+		{"conflict with a cause", &Error{Kind: ErrConflict, Message: "Task already exists", Err: cause}, http.StatusConflict},
+		{"quota", &Error{Kind: ErrQuotaExceeded, Message: "Task quota exceeded", Field: "quota.tasks"}, http.StatusForbidden},
+		{"not a domain error", cause, 0},
+		{"nil", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := Status(tt.err); got != tt.want {
+				t.Errorf("Status(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	t.Log("✅ Kinds mapped to status codes")
+}
+
+// TestError_Is tests a domain error matches its sentinel, its kind and its
+// cause
+func TestError_Is(t *testing.T) {
+	errDuplicate := New(ErrConflict, "Task already exists")
+	cause := errors.New("E11000 duplicate key")
+	err := fmt.Errorf("create: %w", &Error{Kind: errDuplicate, Message: "Task already exists", Err: cause})
+
+	for _, target := range []error{errDuplicate, ErrConflict, cause} {
+		if !errors.Is(err, target) {
+			t.Errorf("errors.Is(%v, %v) = false", err, target)
+		}
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Errorf("errors.Is(%v, ErrNotFound) = true", err)
+	}
+
+	t.Log("✅ Sentinel, kind and cause all match")
+}
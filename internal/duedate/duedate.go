@@ -0,0 +1,140 @@
+// Package duedate turns what a user types as a due date into a time
+// Everything is read in the user's time zone, so "tomorrow" and "2025-02-01"
+// mean their tomorrow and their 1 February:
+//
+//	2025-02-01T17:00:00Z     an exact time (RFC 3339, with its own offset)
+//	2025-02-01 17:00         a local time
+//	2025-02-01               the end of that day
+//	today, tomorrow          the end of that day
+//	friday, next friday      the end of the next Friday (a week today, on a Friday)
+//	in 2 hours, in 3 days    from now (days and weeks: the end of that day)
+//
+// Day forms take an optional time: "tomorrow at 5pm", "friday at 9:30",
+// "2025-02-01 at noon".
+package duedate
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnrecognized is returned for text that isn't a due date we understand
+var ErrUnrecognized = errors.New(`unrecognized due date: use a date (2025-02-01), a time (2025-02-01 17:00 or RFC 3339), ` +
+	`or a phrase like "today", "tomorrow at 5pm", "friday" or "in 3 days"`)
+
+// EndOfDay is when a date without a time is due: the last second of the day
+func EndOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 23, 59, 59, 0, t.Location())
+}
+
+// StartOfDay is midnight at the start of t's day, in t's time zone
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// Parse reads s as a due date, relative to now in loc
+func Parse(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	s = strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	now = now.In(loc)
+
+	// Exact times first: RFC 3339 carries its own zone
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(s)); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02t15:04", "2006-01-02t15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	// "in N units"
+	if rest, ok := strings.CutPrefix(s, "in "); ok {
+		return parseIn(rest, now)
+	}
+
+	// A day, optionally "at" a time
+	dayText, timeText, hasTime := strings.Cut(s, " at ")
+	day, ok := parseDay(dayText, now, loc)
+	if !ok {
+		return time.Time{}, ErrUnrecognized
+	}
+	if !hasTime {
+		return EndOfDay(day), nil
+	}
+	hour, minute, ok := parseClock(timeText)
+	if !ok {
+		return time.Time{}, ErrUnrecognized
+	}
+	y, m, d := day.Date()
+	return time.Date(y, m, d, hour, minute, 0, 0, loc), nil
+}
+
+// parseIn reads "2 hours", "3 days", "1 week"...
+func parseIn(s string, now time.Time) (time.Time, error) {
+	count, unit, ok := strings.Cut(s, " ")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n < 0 || n > 3650 {
+		return time.Time{}, ErrUnrecognized
+	}
+	switch strings.TrimSuffix(unit, "s") {
+	case "minute", "min":
+		return now.Add(time.Duration(n) * time.Minute), nil
+	case "hour":
+		return now.Add(time.Duration(n) * time.Hour), nil
+	case "day":
+		return EndOfDay(now.AddDate(0, 0, n)), nil
+	case "week":
+		return EndOfDay(now.AddDate(0, 0, 7*n)), nil
+	}
+	return time.Time{}, ErrUnrecognized
+}
+
+// weekdays by the names and abbreviations users type
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// parseDay reads a date, "today", "tomorrow" or a weekday
+func parseDay(s string, now time.Time, loc *time.Location) (time.Time, bool) {
+	switch s {
+	case "today":
+		return now, true
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), true
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, loc); err == nil {
+		return t, true
+	}
+	if weekday, ok := weekdays[strings.TrimPrefix(s, "next ")]; ok {
+		// The next one after today: "friday" on a Friday is a week away
+		days := (int(weekday)-int(now.Weekday())+6)%7 + 1
+		return now.AddDate(0, 0, days), true
+	}
+	return time.Time{}, false
+}
+
+// parseClock reads "17:30", "5pm", "5:30pm", "noon" or "midnight"
+func parseClock(s string) (hour, minute int, ok bool) {
+	switch s {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+	for _, layout := range []string{"15:04", "3pm", "3:04pm", "3 pm", "3:04 pm"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Hour(), t.Minute(), true
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,58 @@
+package duedate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestParse tests each form, read in the user's time zone
+func TestParse(t *testing.T) {
+	// Arrange: Friday 31 January 2025, 10:15 in Paris
+	paris, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2025, 1, 31, 10, 15, 0, 0, paris)
+	at := func(month time.Month, day, hour, minute, second int) time.Time {
+		return time.Date(2025, month, day, hour, minute, second, 0, paris)
+	}
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2025-02-03T09:00:00Z", time.Date(2025, 2, 3, 9, 0, 0, 0, time.UTC)},
+		{"2025-02-03 09:00", at(time.February, 3, 9, 0, 0)},
+		{"2025-02-03", at(time.February, 3, 23, 59, 59)},
+		{"2025-02-03 at noon", at(time.February, 3, 12, 0, 0)},
+		{"today", at(time.January, 31, 23, 59, 59)},
+		{"Tomorrow at 5pm", at(time.February, 1, 17, 0, 0)},
+		{"tomorrow at 17:30", at(time.February, 1, 17, 30, 0)},
+		{"monday", at(time.February, 3, 23, 59, 59)},
+		{"friday", at(time.February, 7, 23, 59, 59)}, // Today is Friday: the next one
+		{"next wed at 9:30am", at(time.February, 5, 9, 30, 0)},
+		{"in 2 hours", at(time.January, 31, 12, 15, 0)},
+		{"in 3 days", at(time.February, 3, 23, 59, 59)},
+		{"in 1 week", at(time.February, 7, 23, 59, 59)},
+	}
+
+	for _, tt := range tests {
+		// Act
+		got, err := Parse(tt.input, now, paris)
+
+		// Assert
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "someday", "tomorrow at teatime", "in many days", "2025-13-01"} {
+		if _, err := Parse(bad, now, paris); !errors.Is(err, ErrUnrecognized) {
+			t.Errorf("Expected ErrUnrecognized for %q, got %v", bad, err)
+		}
+	}
+
+	t.Logf("✅ %d due date forms parsed", len(tests))
+}
@@ -0,0 +1,230 @@
+// Package email sends email over SMTP
+// Features that email users (the daily digest) build a Message and hand it
+// to the default Sender from the job workers, so a slow mail server never
+// holds up a request.
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-todo-api/internal/outbound"
+)
+
+// Message is one email to one recipient
+// Text is required; HTML is optional and sent as an alternative to it.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers a message
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ============================================================================
+// SMTP
+// ============================================================================
+
+// SMTP sends through a mail server (a relay like SES, Postmark, Mailgun...)
+type SMTP struct {
+	host string
+	port int
+	from *mail.Address
+	auth smtp.Auth // nil for a relay that doesn't need a login
+}
+
+// FromEnv reads the mail server settings:
+//
+//	SMTP_HOST=smtp.example.com
+//	SMTP_PORT=587                          (default; STARTTLS is used when offered)
+//	SMTP_USERNAME= / SMTP_PASSWORD=        (optional)
+//	SMTP_FROM="TODO <todo@example.com>"
+//
+// It returns nil (email off) when SMTP_HOST isn't set.
+func FromEnv() (*SMTP, error) {
+	host := strings.TrimSpace(os.Getenv("SMTP_HOST"))
+	if host == "" {
+		return nil, nil
+	}
+	port := 587
+	if v := strings.TrimSpace(os.Getenv("SMTP_PORT")); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p <= 0 || p > 65535 {
+			return nil, fmt.Errorf("email: invalid SMTP_PORT %q", v)
+		}
+		port = p
+	}
+	return NewSMTP(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"))
+}
+
+// NewSMTP creates a sender; username may be empty (no login)
+func NewSMTP(host string, port int, username, password, from string) (*SMTP, error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("email: invalid SMTP_FROM %q: %w", from, err)
+	}
+	s := &SMTP{host: host, port: port, from: fromAddr}
+	if username != "" {
+		// PlainAuth refuses to send the password without TLS (except to localhost)
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s, nil
+}
+
+// Send delivers msg, giving up when ctx is done
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("email: invalid recipient %q: %w", msg.To, err)
+	}
+	body, err := build(s.from, to, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// net/smtp has no context support: dial with it, and turn its deadline
+	// into one on the connection. The relay is ours (SMTP_HOST), so it may
+	// be on the private network.
+	dialer := outbound.NewDialer(outbound.Options{AllowPrivate: true})
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.host, strconv.Itoa(s.port)))
+	if err != nil {
+		return fmt.Errorf("email: connecting to %s: %w", s.host, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("email: STARTTLS: %w", err)
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return fmt.Errorf("email: login: %w", err)
+		}
+	}
+	if err := c.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("email: MAIL FROM: %w", err)
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("email: RCPT TO: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("email: DATA: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("email: writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("email: sending message: %w", err)
+	}
+	return c.Quit()
+}
+
+// build renders the message as RFC 5322 text: headers, then the text part,
+// plus an HTML alternative when there is one
+func build(from, to *mail.Address, msg Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	id := make([]byte, 12)
+	rand.Read(id)
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@"+domain+">")
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuoted(&buf, msg.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+	// Least preferred first: clients show the last part they understand
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuoted(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeQuoted writes s quoted-printable encoded, so long lines and
+// non-ASCII text survive any mail server
+func writeQuoted(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// ============================================================================
+// DEFAULT SENDER
+// ============================================================================
+
+// defaultSender is used by the features that send email
+// Only set when SMTP_HOST is, so nil means there's no mail server
+var defaultSender Sender
+
+// Init sets the default sender; Init(nil) turns email off (for tests)
+func Init(s Sender) Sender {
+	defaultSender = s
+	return s
+}
+
+// Default returns the sender set by Init (nil when email is off)
+func Default() Sender {
+	return defaultSender
+}
@@ -0,0 +1,126 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBuild tests that the message parses back into its text and HTML parts
+func TestBuild(t *testing.T) {
+	// Arrange
+	from, _ := mail.ParseAddress("TODO <todo@example.com>")
+	to, _ := mail.ParseAddress("alice@example.com")
+	msg := Message{To: to.Address, Subject: "Your day ☀️", Text: "3 tasks open", HTML: "<p>3 tasks open</p>"}
+
+	// Act
+	raw, err := build(from, to, msg, time.Date(2025, 1, 31, 7, 0, 0, 0, time.UTC))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("Expected a valid message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if subject != msg.Subject || parsed.Header.Get("Message-ID") == "" {
+		t.Errorf("Expected the subject and a Message-ID, got %q", parsed.Header)
+	}
+	_, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err != nil {
+			break
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(part))
+		bodies = append(bodies, string(body))
+	}
+	if len(bodies) != 2 || bodies[0] != msg.Text || bodies[1] != msg.HTML {
+		t.Errorf("Expected the text then the HTML part, got %q", bodies)
+	}
+
+	t.Logf("✅ %d-byte message with %d parts", len(raw), len(bodies))
+}
+
+// TestSMTP_Send tests the conversation with a mail server
+func TestSMTP_Send(t *testing.T) {
+	// Arrange: a mail server that accepts anything and records the commands
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+	commands := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var got []string
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+		reply("220 localhost ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			cmd := strings.TrimSpace(line)
+			got = append(got, cmd)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for line != ".\r\n" {
+					line, _ = r.ReadString('\n')
+				}
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				commands <- got
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+		commands <- got
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	s, err := NewSMTP("127.0.0.1", port, "", "", "TODO <todo@example.com>")
+	if err != nil {
+		t.Fatalf("NewSMTP failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Act
+	err = s.Send(ctx, Message{To: "alice@example.com", Subject: "Hi", Text: "Hello"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	got := strings.Join(<-commands, "\n")
+	for _, want := range []string{"MAIL FROM:<todo@example.com>", "RCPT TO:<alice@example.com>", "DATA", "QUIT"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the conversation, got:\n%s", want, got)
+		}
+	}
+
+	t.Log("✅ Message handed to the mail server")
+}
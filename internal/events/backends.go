@@ -0,0 +1,172 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/logger"
+)
+
+// ErrQueueFull is returned by Async when its queue has no room for an event
+var ErrQueueFull = errors.New("events: queue full")
+
+// ============================================================================
+// INLINE
+// ============================================================================
+
+// Inline runs the subscribers in the request that published the event,
+// before it answers - the same as calling them from the handler
+// A slow subscriber slows the request; a failed one is only logged.
+type Inline struct{}
+
+// Deliver runs handler now
+func (Inline) Deliver(ctx context.Context, name string, e Event, handler Handler) error {
+	return handler(ctx, e)
+}
+
+// ============================================================================
+// ASYNC
+// ============================================================================
+
+// Async runs the subscribers on a few goroutines of this process, so the
+// request doesn't wait for them
+// Events still queued when the process stops get until Shutdown's deadline;
+// past that, and when the queue is full, they're lost (and logged). Use Jobs
+// for subscribers that must not miss an event.
+type Async struct {
+	queue chan delivery
+	done  sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// delivery is one event waiting for one subscriber
+type delivery struct {
+	ctx     context.Context
+	name    string
+	event   Event
+	handler Handler
+}
+
+// NewAsync starts workers goroutines taking events from a queue of size
+func NewAsync(workers, size int) *Async {
+	a := &Async{queue: make(chan delivery, size)}
+	for range workers {
+		a.done.Add(1)
+		go a.work()
+	}
+	return a
+}
+
+// Deliver queues the event, or returns ErrQueueFull
+func (a *Async) Deliver(ctx context.Context, name string, e Event, handler Handler) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrQueueFull
+	}
+	// The request's context ends with the response; the trace goes on
+	d := delivery{ctx: context.WithoutCancel(ctx), name: name, event: e, handler: handler}
+	select {
+	case a.queue <- d:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// work runs queued events until the queue is closed
+func (a *Async) work() {
+	defer a.done.Done()
+	for d := range a.queue {
+		if err := d.handler(d.ctx, d.event); err != nil {
+			logger.WithTrace(d.ctx).Error("Event subscriber failed",
+				slog.String("subscriber", d.name), slog.String("event", d.event.Type),
+				slog.String("task_id", d.event.TaskID), slog.Any("error", err))
+		}
+	}
+}
+
+// Shutdown stops taking events and waits for the queued ones, or ctx
+func (a *Async) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		a.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		logger.Log.Warn("Stopped with events still queued", "queued", len(a.queue))
+		return ctx.Err()
+	}
+}
+
+// ============================================================================
+// JOBS
+// ============================================================================
+
+// JobDeliver is the job type Jobs queues: one event for one subscriber
+const JobDeliver = "events.deliver"
+
+// Jobs queues each event for each subscriber as a job, so subscribers run on
+// the job workers of any instance, and a failed one is retried
+// The workers' processes need the same subscribers, and Bus.Register.
+type Jobs struct {
+	pool *jobs.Pool // nil = the default pool
+}
+
+// NewJobs creates a backend queuing to pool, or to the default pool when
+// it's nil (Lambda queues without running workers)
+func NewJobs(pool *jobs.Pool) *Jobs {
+	return &Jobs{pool: pool}
+}
+
+// deliverPayload is the JobDeliver payload
+type deliverPayload struct {
+	Subscriber string `json:"subscriber"`
+	Event      Event  `json:"event"`
+}
+
+// Deliver queues the event for the subscriber named name
+func (j *Jobs) Deliver(ctx context.Context, name string, e Event, _ Handler) error {
+	payload := deliverPayload{Subscriber: name, Event: e}
+	var err error
+	if j.pool != nil {
+		_, err = j.pool.Enqueue(ctx, JobDeliver, payload)
+	} else {
+		_, err = jobs.Enqueue(ctx, JobDeliver, payload)
+	}
+	return err
+}
+
+// Register adds the JobDeliver handler to a worker pool
+// Call it before pool.Start(), on every process that runs workers, whatever
+// its own backend.
+func (b *Bus) Register(pool *jobs.Pool) {
+	pool.Register(JobDeliver, b.handleJob, jobs.RetryPolicy{})
+}
+
+// handleJob handles JobDeliver
+func (b *Bus) handleJob(ctx context.Context, job *jobs.Job) error {
+	var p deliverPayload
+	if err := job.Decode(&p); err != nil {
+		return err
+	}
+	if p.Event.Task != nil {
+		p.Event.Task.OwnerID = p.Event.OwnerID
+	}
+	return b.Handle(ctx, p.Subscriber, p.Event)
+}
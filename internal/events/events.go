@@ -0,0 +1,178 @@
+// Package events tells the features that react to task changes what changed,
+// so none of them has to hook the handlers
+// The task writes publish an Event to a Bus (see Wrap); features subscribe
+// to the types they care about, usually from init() functions:
+//
+//	func init() {
+//		events.Subscribe("crm-sync", func(ctx context.Context, e events.Event) error {
+//			return crm.Push(ctx, *e.Task)
+//		}, events.TaskCreated, events.TaskUpdated)
+//	}
+//
+// The Bus's Backend decides when subscribers run: Inline (in the request, the
+// default), Async (on goroutines after the response) or Jobs (on the job
+// workers, retried, and on any instance). EVENTS_BACKEND picks one.
+package events
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+)
+
+// Event types
+const (
+	TaskCreated   = "task.created"
+	TaskUpdated   = "task.updated"   // Any change but completing, including reopening
+	TaskCompleted = "task.completed" // An update marked the task completed
+	TaskDeleted   = "task.deleted"
+)
+
+// Event is one change to one task
+type Event struct {
+	Type    string       `json:"type"`
+	TaskID  string       `json:"task_id"`
+	Task    *models.Task `json:"task,omitempty"`     // The task as saved; nil for task.deleted
+	OwnerID string       `json:"owner_id,omitempty"` // Who created the task (the Task's OwnerID isn't serialized)
+	At      time.Time    `json:"at"`
+}
+
+// Handler reacts to an event
+// With the Jobs backend a returned error retries it; otherwise it's logged.
+type Handler func(ctx context.Context, e Event) error
+
+// Backend runs a subscriber's handler for an event, now or later
+type Backend interface {
+	// Deliver arranges for handler, the subscriber named name, to get e
+	Deliver(ctx context.Context, name string, e Event, handler Handler) error
+}
+
+// subscription is a subscriber and the event types it gets (none = every type)
+type subscription struct {
+	name    string
+	types   []string
+	handler Handler
+}
+
+// wants reports whether the subscription gets events of type typ
+func (s subscription) wants(typ string) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, typ)
+}
+
+// Bus hands published events to the subscribers through its backend
+type Bus struct {
+	mu      sync.RWMutex
+	backend Backend
+	subs    []subscription
+}
+
+// NewBus creates a bus without subscribers; a nil backend is Inline
+func NewBus(backend Backend) *Bus {
+	if backend == nil {
+		backend = Inline{}
+	}
+	return &Bus{backend: backend}
+}
+
+// Subscribe adds a handler for the given event types, or every type when
+// none are given; name identifies it in logs and jobs, so keep it stable
+func (b *Bus) Subscribe(name string, handler Handler, types ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, subscription{name: name, types: types, handler: handler})
+}
+
+// Len returns how many subscribers there are
+func (b *Bus) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// snapshot returns the backend and the subscribers, so handlers run
+// without holding the lock
+func (b *Bus) snapshot() (Backend, []subscription) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.backend, b.subs
+}
+
+// Publish hands e to every subscriber of its type
+// It never fails the write that published it: a subscriber that can't be
+// given the event is logged.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+	backend, subs := b.snapshot()
+	for _, s := range subs {
+		if !s.wants(e.Type) {
+			continue
+		}
+		if err := backend.Deliver(ctx, s.name, e, s.handler); err != nil {
+			logger.WithTrace(ctx).Error("Event subscriber failed",
+				slog.String("subscriber", s.name), slog.String("event", e.Type),
+				slog.String("task_id", e.TaskID), slog.Any("error", err))
+		}
+	}
+}
+
+// Handle runs the subscriber named name for e
+// Backends that deliver elsewhere (Jobs) call it where the event arrives; a
+// subscriber this process doesn't have is skipped.
+func (b *Bus) Handle(ctx context.Context, name string, e Event) error {
+	_, subs := b.snapshot()
+	for _, s := range subs {
+		if s.name == name {
+			return s.handler(ctx, e)
+		}
+	}
+	logger.WithTrace(ctx).Warn("Event for an unknown subscriber dropped",
+		slog.String("subscriber", name), slog.String("event", e.Type))
+	return nil
+}
+
+// Shutdown waits for the events the backend still holds, if it holds any
+func (b *Bus) Shutdown(ctx context.Context) error {
+	backend, _ := b.snapshot()
+	if s, ok := backend.(interface{ Shutdown(context.Context) error }); ok {
+		return s.Shutdown(ctx)
+	}
+	return nil
+}
+
+// ============================================================================
+// DEFAULT BUS
+// ============================================================================
+
+// defaultBus is the bus the task writes publish to
+// Subscribers are added with Subscribe, usually from init() functions, so
+// Init changes its backend rather than replacing it.
+var defaultBus = NewBus(nil)
+
+// Default returns the bus the task writes publish to
+func Default() *Bus {
+	return defaultBus
+}
+
+// Init sets the default bus's backend; nil is Inline
+// Call it at startup, before the first request.
+func Init(backend Backend) *Bus {
+	if backend == nil {
+		backend = Inline{}
+	}
+	defaultBus.mu.Lock()
+	defaultBus.backend = backend
+	defaultBus.mu.Unlock()
+	return defaultBus
+}
+
+// Subscribe adds a subscriber to the default bus
+func Subscribe(name string, handler Handler, types ...string) {
+	defaultBus.Subscribe(name, handler, types...)
+}
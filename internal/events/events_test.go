@@ -0,0 +1,158 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+)
+
+// recorder is a subscriber that remembers the events it got
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) handle(_ context.Context, e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []string
+	for _, e := range r.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// TestBus_Wrap tests that the task writes publish one event each, and a
+// failed write none
+func TestBus_Wrap(t *testing.T) {
+	// Arrange: one subscriber for everything, one for deletes only
+	logger.Init()
+	bus := NewBus(nil)
+	var all, deletes recorder
+	bus.Subscribe("all", all.handle)
+	bus.Subscribe("deletes", deletes.handle, TaskDeleted)
+	repo := bus.Wrap(repository.NewMemoryTaskRepository())
+	ctx := context.Background()
+
+	// Act
+	task := models.Task{Title: "Buy milk", OwnerID: "user-1"}
+	if err := repo.Create(ctx, &task); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	title, done := "Buy oat milk", true
+	_, _ = repo.Update(ctx, task.ID, repository.TaskChanges{Title: &title})
+	_, _ = repo.Update(ctx, task.ID, repository.TaskChanges{}) // Changes nothing: no event
+	_, _ = repo.Update(ctx, task.ID, repository.TaskChanges{Completed: &done})
+	_ = repo.Delete(ctx, task.ID)
+	if err := repo.Delete(ctx, models.GenerateTaskID()); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	// Assert
+	want := []string{TaskCreated, TaskUpdated, TaskCompleted, TaskDeleted}
+	if got := all.types(); len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("Event %d: expected %s, got %s", i, want[i], got[i])
+			}
+		}
+	}
+	if e := all.events[1]; e.Task == nil || e.Task.Title != title || e.OwnerID != "user-1" || e.At.IsZero() {
+		t.Errorf("Expected the updated task, its owner and a time, got %+v", e)
+	}
+	if got := deletes.types(); len(got) != 1 || deletes.events[0].TaskID != task.ID.String() {
+		t.Errorf("Expected one task.deleted for %s, got %+v", task.ID.String(), deletes.events)
+	}
+
+	t.Log("✅ Each write published once, only to its subscribers")
+}
+
+// TestAsync tests that async subscribers run after Publish returns, and that
+// Shutdown waits for them
+func TestAsync(t *testing.T) {
+	// Arrange: a subscriber that waits until it's let go
+	logger.Init()
+	async := NewAsync(1, 10)
+	bus := NewBus(async)
+	release := make(chan struct{})
+	var got recorder
+	bus.Subscribe("slow", func(ctx context.Context, e Event) error {
+		<-release
+		return got.handle(ctx, e)
+	})
+
+	// Act
+	published := make(chan struct{})
+	go func() {
+		bus.Publish(context.Background(), Event{Type: TaskCreated, TaskID: "1"})
+		close(published)
+	}()
+
+	// Assert: Publish didn't wait for the subscriber
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("Publish waited for an async subscriber")
+	}
+	close(release)
+	if err := bus.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if types := got.types(); len(types) != 1 {
+		t.Errorf("Expected the event delivered before Shutdown returned, got %v", types)
+	}
+	if err := async.Deliver(context.Background(), "slow", Event{}, got.handle); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull after Shutdown, got %v", err)
+	}
+
+	t.Log("✅ Async subscribers ran after the publish and were drained")
+}
+
+// TestJobs tests that an event queued as a job reaches the subscriber on
+// the workers, task and owner included
+func TestJobs(t *testing.T) {
+	// Arrange
+	logger.Init()
+	pool := jobs.NewPool(jobs.NewMemoryStore(), jobs.Options{Workers: 1, PollInterval: 5 * time.Millisecond})
+	bus := NewBus(NewJobs(pool))
+	received := make(chan Event, 1)
+	bus.Subscribe("crm", func(_ context.Context, e Event) error {
+		received <- e
+		return nil
+	}, TaskCreated)
+	bus.Register(pool)
+	pool.Start()
+	t.Cleanup(func() { _ = pool.Shutdown(context.Background()) })
+
+	// Act
+	task := models.Task{ID: models.GenerateTaskID(), Title: "Call Ana", OwnerID: "user-1"}
+	bus.Publish(context.Background(), taskEvent(TaskCreated, task))
+
+	// Assert
+	select {
+	case e := <-received:
+		if e.Task == nil || e.Task.ID != task.ID || e.Task.Title != task.Title || e.Task.OwnerID != "user-1" {
+			t.Errorf("Expected %+v, got %+v", task, e.Task)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("The event never reached the subscriber")
+	}
+
+	t.Log("✅ Event delivered through the job queue")
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+)
+
+// ============================================================================
+// PUBLISHING THE TASK WRITES
+// ============================================================================
+// The handlers don't publish anything: taskRepository wraps the store, so
+// every write that succeeds - from the tasks API, a sync, anywhere - is
+// published once. Dry runs never reach it, and neither do sandboxes.
+
+// Wrap returns a repository that publishes its successful writes to b
+func (b *Bus) Wrap(inner repository.TaskRepository) repository.TaskRepository {
+	return &publishingRepository{TaskRepository: inner, bus: b}
+}
+
+// publishingRepository is the repository returned by Bus.Wrap
+type publishingRepository struct {
+	repository.TaskRepository // Reads go straight through
+	bus                       *Bus
+}
+
+// Create inserts the task and publishes task.created
+func (r *publishingRepository) Create(ctx context.Context, task *models.Task) error {
+	if err := r.TaskRepository.Create(ctx, task); err != nil {
+		return err
+	}
+	r.bus.Publish(ctx, taskEvent(TaskCreated, *task))
+	return nil
+}
+
+// Update applies the changes and publishes task.completed when they
+// complete the task, task.updated otherwise, and nothing when there are none
+func (r *publishingRepository) Update(ctx context.Context, id models.TaskID, changes repository.TaskChanges) (*models.Task, error) {
+	task, err := r.TaskRepository.Update(ctx, id, changes)
+	if err != nil || changes.Empty() {
+		return task, err // Nothing changed, so nothing to tell the subscribers
+	}
+	typ := TaskUpdated
+	if changes.Completed != nil && *changes.Completed {
+		typ = TaskCompleted
+	}
+	r.bus.Publish(ctx, taskEvent(typ, *task))
+	return task, nil
+}
+
+// Delete removes the task and publishes task.deleted
+func (r *publishingRepository) Delete(ctx context.Context, id models.TaskID) error {
+	if err := r.TaskRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.bus.Publish(ctx, Event{Type: TaskDeleted, TaskID: id.String()})
+	return nil
+}
+
+// taskEvent creates an event carrying a copy of task
+func taskEvent(typ string, task models.Task) Event {
+	return Event{Type: typ, TaskID: task.ID.String(), Task: &task, OwnerID: task.OwnerID}
+}
@@ -0,0 +1,193 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context" // context = for managing request context
+	"time"    // time = for converting durations to milliseconds
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/database"  // Connection pool counters
+	"go-todo-api/internal/logger"    // Our structured logger (owns the level)
+	"go-todo-api/internal/metrics"   // Per-operation SLO tracking
+	"go-todo-api/internal/models"    // Our data structures
+	"go-todo-api/internal/scheduler" // Periodic task scheduler
+
+	// THIRD-PARTY PACKAGES
+	"github.com/danielgtaylor/huma/v2" // Huma = REST API framework with error helpers
+)
+
+// ============================================================================
+// LOG LEVEL - READ
+// ============================================================================
+// GetLogLevel handles GET /admin/log-level
+// Returns the minimum level the logger is currently writing
+//
+// Example response: {"level": "info"}
+func GetLogLevel(ctx context.Context, input *models.GetLogLevelInput) (*models.LogLevelOutput, error) {
+	out := &models.LogLevelOutput{}
+	out.Body.Level = logger.LevelName()
+	return out, nil
+}
+
+// ============================================================================
+// LOG LEVEL - CHANGE
+// ============================================================================
+// SetLogLevel handles PUT /admin/log-level
+// Switches the log level without restarting, e.g. to capture debug logs
+// during an incident. The change only affects this process - every replica
+// has to be switched separately, and a restart goes back to LOG_LEVEL.
+//
+// Example request:  PUT /admin/log-level with body: {"level": "debug"}
+// Example response: {"level": "debug"}
+func SetLogLevel(ctx context.Context, input *models.SetLogLevelInput) (*models.LogLevelOutput, error) {
+	previous := logger.LevelName()
+
+	if err := logger.SetLevel(input.Body.Level); err != nil {
+		// Huma's enum validation normally catches this first
+		return nil, huma.Error422UnprocessableEntity(err.Error())
+	}
+
+	// Logged at warn so the change is visible whatever the new level is
+	logger.WithTrace(ctx).Warn("Log level changed",
+		"from", previous,
+		"to", logger.LevelName())
+
+	out := &models.LogLevelOutput{}
+	out.Body.Level = logger.LevelName()
+	return out, nil
+}
+
+// ============================================================================
+// DEPRECATED ENDPOINT USAGE
+// ============================================================================
+// Deprecations handles GET /admin/deprecations
+// Lists who still calls deprecated operations (see middleware.Deprecate), on
+// this instance since it started, so they can be told before the sunset
+//
+// Example response:
+//
+//	{"uses": [{"operation_id": "list-tasks-legacy", "caller": "alice", "requests": 12, "last_seen": "..."}]}
+func Deprecations(ctx context.Context, input *models.DeprecationsInput) (*models.DeprecationsOutput, error) {
+	out := &models.DeprecationsOutput{}
+	out.Body.Uses = []models.DeprecatedUse{}
+
+	for _, u := range metrics.Deprecations().Report() {
+		out.Body.Uses = append(out.Body.Uses, models.DeprecatedUse{
+			OperationID: u.OperationID,
+			Caller:      u.Caller,
+			Requests:    u.Requests,
+			LastSeen:    u.LastSeen,
+		})
+	}
+
+	return out, nil
+}
+
+// ============================================================================
+// SLO REPORT
+// ============================================================================
+// SLOReport handles GET /admin/slo
+// Shows p50/p95/p99 latency per operation and how fast each one is burning
+// its error budget against the configured objectives (SLO_* env variables)
+//
+// Example response:
+//
+//	{"operations": [{"operation_id": "list-tasks", "requests": 1200, "p99_ms": 42.0, "error_budget_burn": 0.8, ...}]}
+func SLOReport(ctx context.Context, input *models.SLOReportInput) (*models.SLOReportOutput, error) {
+	out := &models.SLOReportOutput{}
+	out.Body.Operations = []models.OperationSLO{}
+
+	for _, r := range metrics.SLO().Report() {
+		out.Body.Operations = append(out.Body.Operations, models.OperationSLO{
+			OperationID:        r.OperationID,
+			Requests:           r.Requests,
+			Errors:             r.Errors,
+			P50Ms:              milliseconds(r.P50),
+			P95Ms:              milliseconds(r.P95),
+			P99Ms:              milliseconds(r.P99),
+			LatencyTargetMs:    milliseconds(r.LatencyTarget),
+			LatencyObjective:   r.LatencyObjective,
+			SlowRatio:          r.SlowRatio,
+			LatencyBudgetBurn:  r.LatencyBudgetBurn,
+			AvailabilityTarget: r.AvailabilityTarget,
+			ErrorRatio:         r.ErrorRatio,
+			ErrorBudgetBurn:    r.ErrorBudgetBurn,
+		})
+	}
+
+	return out, nil
+}
+
+// ============================================================================
+// SCHEDULER STATUS
+// ============================================================================
+// SchedulerStatus handles GET /admin/scheduler
+// Lists every periodic task with its run/failure counts on this instance
+// and when it runs next
+func SchedulerStatus(ctx context.Context, input *models.SchedulerInput) (*models.SchedulerOutput, error) {
+	out := &models.SchedulerOutput{}
+	out.Body.Tasks = []models.ScheduledTask{}
+
+	s := scheduler.Default()
+	if s == nil {
+		return out, nil // Not running the scheduler here (e.g. Lambda)
+	}
+
+	for _, t := range s.Stats() {
+		task := models.ScheduledTask{
+			Name:           t.Name,
+			Schedule:       t.Schedule,
+			Runs:           t.Runs,
+			Failures:       t.Failures,
+			Skipped:        t.Skipped,
+			LastDurationMs: milliseconds(t.LastDuration),
+			LastError:      t.LastError,
+		}
+		if !t.LastRun.IsZero() {
+			task.LastRun = &t.LastRun
+		}
+		if !t.NextRun.IsZero() {
+			task.NextRun = &t.NextRun
+		}
+		out.Body.Tasks = append(out.Body.Tasks, task)
+	}
+
+	return out, nil
+}
+
+// ============================================================================
+// DATABASE CONNECTION POOL
+// ============================================================================
+// DBPool handles GET /admin/db-pool
+// Shows how long commands waited for a MongoDB connection and how often
+// connections were opened and closed on this instance. A high wait with fast
+// commands (see the "find tasks" spans) means the pool is too small for the
+// load; a steady stream of new connections means they're being dropped.
+func DBPool(ctx context.Context, input *models.DBPoolInput) (*models.DBPoolOutput, error) {
+	stats := database.GetPoolStats()
+
+	out := &models.DBPoolOutput{}
+	out.Body.Checkouts = stats.Checkouts
+	out.Body.CheckoutFailures = stats.CheckoutFailures
+	if attempts := stats.Checkouts + stats.CheckoutFailures; attempts > 0 {
+		out.Body.AvgCheckoutWaitMs = milliseconds(stats.CheckoutWait / time.Duration(attempts))
+	}
+	out.Body.MaxCheckoutWaitMs = milliseconds(stats.MaxCheckoutWait)
+	out.Body.Created = stats.Created
+	out.Body.Closed = stats.Closed
+	out.Body.Open = stats.Open
+	out.Body.InUse = stats.InUse
+	out.Body.Cleared = stats.Cleared
+	return out, nil
+}
+
+// milliseconds converts a duration to fractional milliseconds for JSON
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
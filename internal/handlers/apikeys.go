@@ -0,0 +1,326 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context" // context = for managing request context
+	"errors"  // errors = for checking which error the key store returned
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/apikeys"    // Managed API keys
+	"go-todo-api/internal/logger"     // Our structured logger
+	"go-todo-api/internal/middleware" // The rate limiter whose counts can be reset
+	"go-todo-api/internal/models"     // Our data structures
+
+	// THIRD-PARTY PACKAGES
+	"github.com/danielgtaylor/huma/v2" // Huma = REST API framework with error helpers
+)
+
+// rateLimiterKey is the context key for the rate limiter
+type rateLimiterKey struct{}
+
+// WithRateLimiter lets the handlers called with ctx reset a client's limit
+// in l, the limiter in front of the API
+// app.New and app.NewAdmin add it to every request
+func WithRateLimiter(ctx context.Context, l *middleware.RateLimiter) context.Context {
+	return context.WithValue(ctx, rateLimiterKey{}, l)
+}
+
+// rateLimiter returns the limiter in front of the API (nil: there's
+// nothing to reset)
+func rateLimiter(ctx context.Context) *middleware.RateLimiter {
+	l, _ := ctx.Value(rateLimiterKey{}).(*middleware.RateLimiter)
+	return l
+}
+
+// keyManager returns the API key manager, or a 503 before it's set up
+// (it needs MongoDB - see apikeys.Init)
+func keyManager() (*apikeys.Keys, error) {
+	keys := apikeys.Default()
+	if keys == nil {
+		return nil, huma.Error503ServiceUnavailable("API key management is not available (no database)")
+	}
+	return keys, nil
+}
+
+// keyError turns a key store error into a problem response
+func keyError(ctx context.Context, err error, what string) error {
+	if errors.Is(err, apikeys.ErrNotFound) {
+		return huma.Error404NotFound(what + " not found")
+	}
+	logger.WithTrace(ctx).Error("API key store failed", "error", err)
+	return huma.Error500InternalServerError("Failed to read or change API keys", err)
+}
+
+// toAPIKey is the response form of a key (never with its hash)
+func toAPIKey(key apikeys.Key) models.APIKey {
+	return models.APIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		UserID:    key.UserID,
+		Role:      key.Role,
+		Disabled:  key.Disabled,
+		Sandbox:   key.Sandbox,
+		Mobile:    key.List.Mobile,
+		ListLimit: key.List.Limit,
+		CreatedAt: key.CreatedAt,
+	}
+}
+
+// ============================================================================
+// API KEYS - LIST AND CREATE
+// ============================================================================
+
+// ListAPIKeys handles GET /admin/apikeys
+// Lists every managed key (or one user's keys with ?user_id=)
+func ListAPIKeys(ctx context.Context, input *models.ListAPIKeysInput) (*models.ListAPIKeysOutput, error) {
+	keys, err := keyManager()
+	if err != nil {
+		return nil, err
+	}
+	list, err := keys.List(ctx, input.UserID)
+	if err != nil {
+		return nil, keyError(ctx, err, "API key")
+	}
+
+	out := &models.ListAPIKeysOutput{}
+	out.Body.Keys = make([]models.APIKey, 0, len(list))
+	for _, key := range list {
+		out.Body.Keys = append(out.Body.Keys, toAPIKey(key))
+	}
+	return out, nil
+}
+
+// CreateAPIKey handles POST /admin/apikeys
+// The response is the only time the key itself is shown - it isn't stored
+//
+// Example request:  POST /admin/apikeys with body: {"name": "mobile app", "user_id": "alice"}
+// Example response: {"id": "6900...", "key": "tk_6900..._9f86...", "role": "user", ...}
+func CreateAPIKey(ctx context.Context, input *models.CreateAPIKeyInput) (*models.CreateAPIKeyOutput, error) {
+	keys, err := keyManager()
+	if err != nil {
+		return nil, err
+	}
+
+	// An admin key would reach the admin endpoints, which aren't sandboxed
+	if input.Body.Sandbox && input.Body.Role == apikeys.RoleAdmin {
+		return nil, huma.Error422UnprocessableEntity("Sandbox keys can't be admin keys")
+	}
+	key, secret, err := keys.CreateLike(ctx, apikeys.Key{
+		Name:    input.Body.Name,
+		UserID:  input.Body.UserID,
+		Role:    input.Body.Role,
+		Sandbox: input.Body.Sandbox,
+		List:    apikeys.ListDefaults{Mobile: input.Body.Mobile, Limit: input.Body.ListLimit},
+	})
+	if err != nil {
+		return nil, keyError(ctx, err, "API key")
+	}
+
+	logger.WithTrace(ctx).Warn("API key created",
+		"key_id", key.ID, "user_id", key.UserID, "role", key.Role, "sandbox", key.Sandbox)
+
+	out := &models.CreateAPIKeyOutput{}
+	out.Body.APIKey = toAPIKey(*key)
+	out.Body.Key = secret
+	return out, nil
+}
+
+// ============================================================================
+// API KEYS - DISABLE, ENABLE, DELETE
+// ============================================================================
+// Changes apply at once on this instance and within a few seconds on the
+// others (they trust a key they've checked for 10 seconds)
+
+// DisableAPIKey handles POST /admin/apikeys/{id}/disable
+// Requests with the key get 403 until it's enabled again
+func DisableAPIKey(ctx context.Context, input *models.APIKeyIDInput) (*models.APIKeyOutput, error) {
+	return setKeyDisabled(ctx, input.ID, true)
+}
+
+// EnableAPIKey handles POST /admin/apikeys/{id}/enable
+func EnableAPIKey(ctx context.Context, input *models.APIKeyIDInput) (*models.APIKeyOutput, error) {
+	return setKeyDisabled(ctx, input.ID, false)
+}
+
+// setKeyDisabled disables or enables a key and returns it
+func setKeyDisabled(ctx context.Context, id string, disabled bool) (*models.APIKeyOutput, error) {
+	keys, err := keyManager()
+	if err != nil {
+		return nil, err
+	}
+	if err := keys.SetDisabled(ctx, id, disabled); err != nil {
+		return nil, keyError(ctx, err, "API key")
+	}
+	key, err := keys.Get(ctx, id)
+	if err != nil {
+		return nil, keyError(ctx, err, "API key")
+	}
+
+	// Logged at warn so locking someone out always shows up
+	logger.WithTrace(ctx).Warn("API key changed", "key_id", id, "disabled", disabled)
+	return &models.APIKeyOutput{Body: toAPIKey(*key)}, nil
+}
+
+// DeleteAPIKey handles DELETE /admin/apikeys/{id}
+func DeleteAPIKey(ctx context.Context, input *models.APIKeyIDInput) (*models.DeletedOutput, error) {
+	keys, err := keyManager()
+	if err != nil {
+		return nil, err
+	}
+	if err := keys.Delete(ctx, input.ID); err != nil {
+		return nil, keyError(ctx, err, "API key")
+	}
+	logger.WithTrace(ctx).Warn("API key deleted", "key_id", input.ID)
+
+	out := &models.DeletedOutput{}
+	out.Body.Message = "API key deleted"
+	out.Body.ID = input.ID
+	return out, nil
+}
+
+// ============================================================================
+// USERS
+// ============================================================================
+// A user is whoever owns keys (there are no accounts yet), so acting on a
+// user acts on all of their keys
+
+// ListUsers handles GET /admin/users
+func ListUsers(ctx context.Context, input *models.ListUsersInput) (*models.ListUsersOutput, error) {
+	keys, err := keyManager()
+	if err != nil {
+		return nil, err
+	}
+	users, err := keys.Users(ctx)
+	if err != nil {
+		return nil, keyError(ctx, err, "User")
+	}
+
+	out := &models.ListUsersOutput{}
+	out.Body.Users = make([]models.User, 0, len(users))
+	for _, u := range users {
+		out.Body.Users = append(out.Body.Users, toUser(u))
+	}
+	return out, nil
+}
+
+// DisableUser handles POST /admin/users/{id}/disable
+// Disables every key the user has
+func DisableUser(ctx context.Context, input *models.UserIDInput) (*models.UserOutput, error) {
+	return setUserDisabled(ctx, input.ID, true)
+}
+
+// EnableUser handles POST /admin/users/{id}/enable
+func EnableUser(ctx context.Context, input *models.UserIDInput) (*models.UserOutput, error) {
+	return setUserDisabled(ctx, input.ID, false)
+}
+
+// setUserDisabled disables or enables a user's keys and returns the user
+func setUserDisabled(ctx context.Context, userID string, disabled bool) (*models.UserOutput, error) {
+	keys, err := keyManager()
+	if err != nil {
+		return nil, err
+	}
+	if err := keys.SetUserDisabled(ctx, userID, disabled); err != nil {
+		return nil, keyError(ctx, err, "User")
+	}
+	logger.WithTrace(ctx).Warn("User's API keys changed", "user_id", userID, "disabled", disabled)
+
+	users, err := keys.Users(ctx)
+	if err != nil {
+		return nil, keyError(ctx, err, "User")
+	}
+	for _, u := range users {
+		if u.ID == userID {
+			return &models.UserOutput{Body: toUser(u)}, nil
+		}
+	}
+	return nil, huma.Error404NotFound("User not found") // Deleted meanwhile
+}
+
+// DeleteUser handles DELETE /admin/users/{id}
+// Deletes every key the user has
+func DeleteUser(ctx context.Context, input *models.UserIDInput) (*models.DeletedOutput, error) {
+	keys, err := keyManager()
+	if err != nil {
+		return nil, err
+	}
+	if err := keys.DeleteUser(ctx, input.ID); err != nil {
+		return nil, keyError(ctx, err, "User")
+	}
+	logger.WithTrace(ctx).Warn("User's API keys deleted", "user_id", input.ID)
+
+	out := &models.DeletedOutput{}
+	out.Body.Message = "User's API keys deleted"
+	out.Body.ID = input.ID
+	return out, nil
+}
+
+// toUser is the response form of a user
+func toUser(u apikeys.User) models.User {
+	return models.User{
+		ID:           u.ID,
+		Keys:         u.Keys,
+		DisabledKeys: u.DisabledKeys,
+		Disabled:     u.Disabled(),
+		Roles:        u.Roles,
+	}
+}
+
+// ============================================================================
+// RATE LIMITS - RESET
+// ============================================================================
+// The rate limiter counts requests per client IP, before the API key is
+// even checked. Resetting a key's limits forgets the counts of the IPs the
+// key was last used from, on this instance (each one counts on its own).
+
+// ResetAPIKeyLimits handles POST /admin/apikeys/{id}/reset-limits
+func ResetAPIKeyLimits(ctx context.Context, input *models.APIKeyIDInput) (*models.ResetLimitsOutput, error) {
+	keys, err := keyManager()
+	if err != nil {
+		return nil, err
+	}
+	key, err := keys.Get(ctx, input.ID)
+	if err != nil {
+		return nil, keyError(ctx, err, "API key")
+	}
+	return resetLimits(ctx, keys.LastIPs([]apikeys.Key{*key})), nil
+}
+
+// ResetUserLimits handles POST /admin/users/{id}/reset-limits
+func ResetUserLimits(ctx context.Context, input *models.UserIDInput) (*models.ResetLimitsOutput, error) {
+	keys, err := keyManager()
+	if err != nil {
+		return nil, err
+	}
+	list, err := keys.List(ctx, input.ID)
+	if err != nil {
+		return nil, keyError(ctx, err, "User")
+	}
+	if len(list) == 0 {
+		return nil, huma.Error404NotFound("User not found")
+	}
+	return resetLimits(ctx, keys.LastIPs(list)), nil
+}
+
+// resetLimits forgets the rate limiter's counts for ips
+func resetLimits(ctx context.Context, ips []string) *models.ResetLimitsOutput {
+	out := &models.ResetLimitsOutput{}
+	out.Body.ResetIPs = []string{}
+	limiter := rateLimiter(ctx)
+	if limiter == nil {
+		return out
+	}
+	for _, ip := range ips {
+		if limiter.Reset(ctx, ip) {
+			out.Body.ResetIPs = append(out.Body.ResetIPs, ip)
+		}
+	}
+	logger.WithTrace(ctx).Info("Rate limits reset", "ips", out.Body.ResetIPs)
+	return out
+}
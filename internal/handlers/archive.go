@@ -0,0 +1,79 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context" // context = for managing request context
+	"log/slog"
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/activity" // What the server did to the caller's tasks
+	"go-todo-api/internal/archive"  // Tasks moved out by auto-archiving
+	"go-todo-api/internal/logger"   // Our structured logger
+	"go-todo-api/internal/models"   // Our data structures
+
+	// THIRD-PARTY PACKAGES
+	"github.com/danielgtaylor/huma/v2" // Huma = REST API framework with error helpers
+)
+
+// ============================================================================
+// ARCHIVED TASKS - GET /me/archived-tasks
+// ============================================================================
+
+// ListArchivedTasks handles GET /me/archived-tasks
+// Tasks get here when auto_archive_days is set in the caller's profile.
+func ListArchivedTasks(ctx context.Context, input *models.ListArchivedTasksInput) (*models.ListArchivedTasksOutput, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	archiver := archive.Default()
+	if archiver == nil {
+		return nil, huma.Error503ServiceUnavailable("The archive is not available (no database)")
+	}
+
+	archived, err := archiver.Archived(ctx, caller.UserID, input.Limit)
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to list archived tasks", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to list your archived tasks", err)
+	}
+	out := make([]models.ArchivedTask, 0, len(archived))
+	for _, t := range archived {
+		out = append(out, models.ArchivedTask{Task: t.Task, ArchivedAt: t.ArchivedAt, Reason: t.Reason})
+	}
+	return &models.ListArchivedTasksOutput{Body: out}, nil
+}
+
+// ============================================================================
+// ACTIVITY - GET /me/activity
+// ============================================================================
+
+// ListActivity handles GET /me/activity
+func ListActivity(ctx context.Context, input *models.ListActivityInput) (*models.ListActivityOutput, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := activity.Default()
+	if store == nil {
+		return nil, huma.Error503ServiceUnavailable("Activity is not available (no database)")
+	}
+
+	entries, err := store.List(ctx, caller.UserID, input.Limit)
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to list activity", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to list your activity", err)
+	}
+	out := make([]models.ActivityEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, models.ActivityEntry{
+			ID: e.ID.Hex(), Action: e.Action, TaskID: e.TaskID, TaskTitle: e.TaskTitle, Reason: e.Reason, At: e.At,
+		})
+	}
+	return &models.ListActivityOutput{Body: out}, nil
+}
@@ -0,0 +1,90 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context"  // context = for managing request context
+	"fmt"      // fmt = for the confirmation message
+	"log/slog" // slog = for structured log attributes
+	"net/http" // net/http = for the 428 status code
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/logger" // Our structured logger
+
+	// THIRD-PARTY PACKAGES
+	"github.com/danielgtaylor/huma/v2" // Huma = REST API framework with error helpers
+)
+
+// ============================================================================
+// BULK CHANGES
+// ============================================================================
+// One request that updates or deletes many tasks at once - a sync from a
+// client whose local copy went wrong, a script with a bad filter - can undo
+// a lot of work before anyone notices. So a request that would touch more
+// than BULK_CONFIRM_ABOVE tasks saves nothing the first time: it answers
+// 428 Precondition Required listing the tasks it would touch, and goes
+// through when it's sent again with ?confirm=<that many>. The count is the
+// confirmation - a client can't confirm a batch bigger than the one it saw.
+//
+// Dry runs are never held back (they save nothing anyway), so ?dry_run=true
+// previews the whole result before confirming. Admins are held back too:
+// the guard is against mistakes, not for limiting anyone.
+
+// BulkGuard holds back requests that change many tasks at once
+// (zero value = off)
+type BulkGuard struct {
+	// ConfirmAbove is how many tasks one request may update or delete
+	// without ?confirm=<count> (BULK_CONFIRM_ABOVE; 0 = any number)
+	ConfirmAbove int
+}
+
+// bulkGuardKey is the context key for the bulk guard
+type bulkGuardKey struct{}
+
+// WithBulkGuard applies g to the handlers called with ctx
+// app.New adds it to every request
+func WithBulkGuard(ctx context.Context, g BulkGuard) context.Context {
+	return context.WithValue(ctx, bulkGuardKey{}, g)
+}
+
+// bulkGuard returns the guard for this request (off unless app.New set it)
+func bulkGuard(ctx context.Context) BulkGuard {
+	g, _ := ctx.Value(bulkGuardKey{}).(BulkGuard)
+	return g
+}
+
+// bulkTask is a task a bulk request would update or delete
+type bulkTask struct {
+	ID       string
+	Op       string // "update" or "delete"
+	Location string // Where the request names it, e.g. "body.changes[3].id"
+}
+
+// confirmBulk returns a 428 listing tasks when there are more of them than
+// the guard allows and confirm isn't how many there are
+func confirmBulk(ctx context.Context, confirm int, tasks []bulkTask) error {
+	limit := bulkGuard(ctx).ConfirmAbove
+	if limit == 0 || len(tasks) <= limit || confirm == len(tasks) || isDryRun(ctx) {
+		return nil
+	}
+
+	logger.WithTrace(ctx).Warn("Bulk change held back for confirmation",
+		slog.Int("tasks", len(tasks)), slog.Int("limit", limit), slog.Int("confirm", confirm))
+	details := make([]error, 0, len(tasks))
+	for _, task := range tasks {
+		details = append(details, &huma.ErrorDetail{
+			Location: task.Location,
+			Message:  "would " + task.Op + " this task",
+			Value:    task.ID,
+		})
+	}
+	return huma.NewError(http.StatusPreconditionRequired, fmt.Sprintf(
+		"This request would change %d tasks at once, more than %d: nothing was saved. "+
+			"Check the tasks listed (or the whole result with ?dry_run=true), then send it again with ?confirm=%d",
+		len(tasks), limit, len(tasks)), details...)
+}
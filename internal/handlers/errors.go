@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+
+	"go-todo-api/internal/domainerrors"
+	"go-todo-api/internal/logger"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// ============================================================================
+// DOMAIN ERRORS
+// ============================================================================
+// The repository and the services return errors of a kind (see
+// internal/domainerrors): not found, invalid, conflict, quota exceeded.
+// problem is where a kind becomes a status code, so a handler only says what
+// failed; anything that isn't a domain error is ours, and answers 500.
+
+// problem turns err into the response for a request that failed doing
+// message (e.g. "Failed to update task"); attrs are logged with a 500
+func problem(ctx context.Context, err error, message string, attrs ...any) error {
+	status, domainErr := domainerrors.Status(err)
+	if status == 0 {
+		logger.WithTrace(ctx).Error(message, append(attrs, slog.Any("error", err))...)
+		return huma.Error500InternalServerError(message, err)
+	}
+
+	var details []error
+	if domainErr.Field != "" || domainErr.Detail != "" {
+		details = append(details, &huma.ErrorDetail{Location: domainErr.Field, Message: domainErr.Detail, Value: domainErr.Value})
+	}
+	return huma.NewError(status, domainErr.Message, details...)
+}
@@ -0,0 +1,98 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"encoding/csv"  // csv = for the spreadsheet export
+	"encoding/json" // json = for the NDJSON export
+	"io"            // io = the response body the exports write to
+	"strconv"       // strconv = for the completed column
+	"strings"       // strings = for spotting spreadsheet formulas
+	"time"
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/locale" // How dates are written for people
+	"go-todo-api/internal/models" // Our data structures
+)
+
+// ============================================================================
+// EXPORT FORMATS
+// ============================================================================
+// GET /export writes NDJSON for programs (RFC 3339 dates, like the rest of
+// the API) or, with ?format=csv, CSV for spreadsheets and people: dates in
+// the caller's time zone, written the way their locale writes them (from
+// Accept-Language, else their profile).
+
+// CSVContentType is the media type of the CSV export
+const CSVContentType = "text/csv; charset=utf-8"
+
+// exportWriter writes the exported tasks to the response, one at a time
+type exportWriter interface {
+	Write(task models.Task) error
+	Flush() error // Hands what's buffered to the response
+}
+
+// ndjsonExport writes one JSON object per line
+type ndjsonExport struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONExport(w io.Writer) *ndjsonExport {
+	return &ndjsonExport{encoder: json.NewEncoder(w)} // Encode ends each task with a newline - exactly NDJSON
+}
+
+func (e *ndjsonExport) Write(task models.Task) error { return e.encoder.Encode(task) }
+func (e *ndjsonExport) Flush() error                 { return nil }
+
+// csvColumns is the CSV export's header row
+var csvColumns = []string{"id", "title", "description", "completed", "completed_at", "due_at"}
+
+// csvExport writes a header row, then one row per task
+type csvExport struct {
+	w       *csv.Writer
+	formats locale.Locale
+	loc     *time.Location
+}
+
+func newCSVExport(w io.Writer, formats locale.Locale, loc *time.Location) (*csvExport, error) {
+	e := &csvExport{w: csv.NewWriter(w), formats: formats, loc: loc}
+	return e, e.w.Write(csvColumns)
+}
+
+func (e *csvExport) Write(task models.Task) error {
+	return e.w.Write([]string{
+		task.ID.String(),
+		cell(task.Title),
+		cell(task.Description),
+		strconv.FormatBool(task.Completed),
+		e.date(task.CompletedAt),
+		e.date(task.DueAt),
+	})
+}
+
+func (e *csvExport) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// date writes a task's date for the caller ("" when there's none)
+func (e *csvExport) date(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return e.formats.DateTime(t.In(e.loc))
+}
+
+// cell keeps text a spreadsheet would run as a formula ("=HYPERLINK(...)")
+// as text, by starting it with a quote
+func cell(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
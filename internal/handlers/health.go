@@ -8,13 +8,33 @@ package handlers
 // IMPORTS
 // ============================================================================
 import (
-	// STANDARD LIBRARY PACKAGE
-	"context" // context = for managing request context
+	// STANDARD LIBRARY PACKAGES
+	"context"  // context = for managing request context
+	"log/slog" // slog = for structured log attributes
+	"net/http" // net/http = for HTTP status codes
+	"time"     // time = for probe timeouts and latency
 
-	// OUR OWN PACKAGE
-	"go-todo-api/internal/models" // Our data structures (HealthOutput)
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/database"   // Our database connection code
+	"go-todo-api/internal/health"     // Component health registry
+	"go-todo-api/internal/logger"     // Our structured logger
+	"go-todo-api/internal/middleware" // VerboseErrors
+	"go-todo-api/internal/models"     // Our data structures (HealthOutput)
+	"go-todo-api/internal/version"    // Build information
 )
 
+// readinessTimeout caps how long a single dependency check may take
+// Kubernetes probes usually time out after 1-5 seconds, so stay below that
+const readinessTimeout = 2 * time.Second
+
+// healthTimeout caps the MongoDB ping in /health
+// Monitors poll /health often, so keep it shorter than the readiness check
+const healthTimeout = 1 * time.Second
+
+// pingDatabase is the MongoDB check used by Readiness
+// It's a variable so tests can swap in a fake without a running database
+var pingDatabase = database.Ping
+
 // ============================================================================
 // HEALTH CHECK ENDPOINT
 // ============================================================================
@@ -25,26 +45,171 @@ import (
 // orchestration systems (like Kubernetes) to verify the service is running.
 // If this endpoint returns successfully, it means:
 // - The server is up and responding to requests
-// - The Go application is running without crashing
+// - MongoDB answered a ping within healthTimeout (always, with DB_DRIVER=memory)
+//
+// When the ping fails (database down, or never reachable since startup) the
+// response is 503, so a load balancer stops routing here. The error itself
+// is logged, and only sent with VerboseErrors (see checkDependency).
 //
 // Huma Handler Signature:
 // - Input: context.Context + *struct{} (no parameters needed)
-// - Output: *models.HealthOutput (contains status and message) + error
+// - Output: *models.HealthOutput (status, message, database check) + error
 //
 // Example request:  GET /health
-// Example response: {"status": "healthy", "message": "Server is running with MongoDB!"}
+// Example response: {"status": "healthy", "message": "Server is running with MongoDB!", "database": {"status": "up", "latency_ms": 1.2}}
 func Health(ctx context.Context, input *models.HealthInput) (*models.HealthOutput, error) {
+	out := &models.HealthOutput{Status: http.StatusOK}
+	out.Body.Database = checkDependency(ctx, healthTimeout, pingDatabase)
+
+	if out.Body.Database.Status != "up" {
+		out.Status = http.StatusServiceUnavailable
+		out.Body.Status = "unhealthy"
+		out.Body.Message = "Server is running but MongoDB is unreachable"
+		return out, nil
+	}
 
-	// Return a simple success response
-	return &models.HealthOutput{
-		Body: struct {
-			Status  string `json:"status" doc:"Health status" example:"healthy"`
-			Message string `json:"message" doc:"Health message" example:"Server is running with MongoDB!"`
-		}{
-			Status:  "healthy",
-			Message: "Server is running with MongoDB!",
-		},
-	}, nil
+	out.Body.Status = "healthy"
+	out.Body.Message = "Server is running with MongoDB!"
+	if database.InMemory() {
+		out.Body.Message = "Server is running with in-memory storage (DB_DRIVER=memory)"
+	}
+	return out, nil
+}
+
+// ============================================================================
+// LIVENESS PROBE
+// ============================================================================
+// Liveness handles GET /healthz
+// It answers "is the process up?" and deliberately checks nothing else.
+// If this fails, Kubernetes restarts the container - so a slow database must
+// NOT make it fail, otherwise a database blip would restart every pod.
+//
+// Example response: {"status": "ok"}
+func Liveness(ctx context.Context, input *models.HealthInput) (*models.LivenessOutput, error) {
+	out := &models.LivenessOutput{}
+	out.Body.Status = "ok"
+	return out, nil
+}
+
+// ============================================================================
+// READINESS PROBE
+// ============================================================================
+// Readiness handles GET /readyz
+// It answers "can this instance serve traffic right now?" by pinging every
+// dependency with a short timeout. Load balancers and Kubernetes stop routing
+// to the instance while it returns 503, without restarting it.
+//
+// Example response (200):
+//
+//	{"status": "ready", "checks": {"mongodb": {"status": "up", "latency_ms": 1.7}}}
+//
+// Example response (503):
+//
+//	{"status": "not_ready", "checks": {"mongodb": {"status": "down", "latency_ms": 2000}}}
+func Readiness(ctx context.Context, input *models.HealthInput) (*models.ReadinessOutput, error) {
+	out := &models.ReadinessOutput{Status: http.StatusOK}
+	out.Body.Status = "ready"
+	out.Body.Checks = map[string]models.DependencyCheck{
+		"mongodb": checkDependency(ctx, readinessTimeout, pingDatabase),
+	}
+
+	// Any dependency down → the whole instance is not ready
+	for _, check := range out.Body.Checks {
+		if check.Status != "up" {
+			out.Status = http.StatusServiceUnavailable
+			out.Body.Status = "not_ready"
+		}
+	}
+
+	return out, nil
+}
+
+// checkDependency runs one check with the given timeout and times it
+// The probes are public by default (AUTH_EXEMPT), and a driver error names
+// hosts and replica sets, so the error is only in the result with
+// VerboseErrors - like a 500's. It's always logged.
+func checkDependency(ctx context.Context, timeout time.Duration, check func(context.Context) error) models.DependencyCheck {
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(checkCtx)
+	result := models.DependencyCheck{
+		Status:    "up",
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = "down"
+		logger.WithTrace(ctx).Warn("Dependency check failed", slog.Float64("latency_ms", result.LatencyMs), slog.Any("error", err))
+		if middleware.VerboseErrors {
+			result.Error = err.Error()
+		}
+	}
+	return result
+}
+
+// ============================================================================
+// DEEP HEALTH CHECK
+// ============================================================================
+// HealthDetails handles GET /health/details
+// It runs every check registered with the health package (MongoDB, and any
+// background components that register themselves) and reports each one as
+// ok, degraded or down, along with the last error it saw. This lets
+// monitoring tell "one part is slow" apart from "everything is broken".
+//
+// The HTTP status is 503 only when something is down - degraded still
+// returns 200 because the API is serving requests.
+//
+// Example response:
+//
+//	{"status": "degraded", "components": {"mongodb": {"status": "degraded", "latency_ms": 812.4, "message": "slow ping: 812ms"}}}
+func HealthDetails(ctx context.Context, input *models.HealthInput) (*models.HealthDetailsOutput, error) {
+	report := health.Run(ctx)
+
+	out := &models.HealthDetailsOutput{Status: http.StatusOK}
+	if report.State == health.StateDown {
+		out.Status = http.StatusServiceUnavailable
+	}
+	out.Body.Status = string(report.State)
+	out.Body.Components = make(map[string]models.ComponentHealth, len(report.Components))
+
+	for _, c := range report.Components {
+		component := models.ComponentHealth{
+			Status:    string(c.State),
+			LatencyMs: float64(c.Latency.Microseconds()) / 1000,
+			Message:   c.Message,
+			LastError: c.LastError,
+		}
+		if !c.LastErrorAt.IsZero() {
+			at := c.LastErrorAt
+			component.LastErrorAt = &at
+		}
+		out.Body.Components[c.Name] = component
+	}
+
+	return out, nil
+}
+
+// ============================================================================
+// VERSION / BUILD INFO
+// ============================================================================
+// Version handles GET /version
+// Returns which build is running, so after a deploy you can confirm the new
+// commit is live (and match it to the build.* attributes on traces)
+//
+// Example response:
+//
+//	{"version": "1.0.0", "git_sha": "3cea4fa", "build_time": "2025-01-31T12:00:00Z", "go_version": "go1.24.0", "features": []}
+func Version(ctx context.Context, input *models.VersionInput) (*models.VersionOutput, error) {
+	build := version.Get()
+
+	out := &models.VersionOutput{}
+	out.Body.Version = build.Version
+	out.Body.GitSHA = build.GitSHA
+	out.Body.BuildTime = build.BuildTime
+	out.Body.GoVersion = build.GoVersion
+	out.Body.Features = build.Features
+	return out, nil
 }
 
 // ============================================================================
@@ -67,11 +232,11 @@ func Health(ctx context.Context, input *models.HealthInput) (*models.HealthOutpu
 // 4. **Deployment Systems**: CI/CD pipelines check health after deployment
 //    to verify the new version started successfully
 //
-// Advanced health checks might also:
-// - Check database connectivity (ping MongoDB)
-// - Check external dependencies (APIs, Redis, etc.)
-// - Return degraded status if some features are down
-//
-// For now, our simple health check just confirms the server is running.
+// We split the checks the way Kubernetes expects:
+// - /healthz (liveness): the process is up - never touches dependencies
+// - /readyz (readiness): MongoDB answers a ping within 2 seconds
+// - /health/details: ok/degraded/down for every registered component
+// - /health: the original simple check, kept for existing monitors; it
+//   pings MongoDB within 1 second and returns 503 when that fails
 //
 // ============================================================================
@@ -3,6 +3,8 @@ package handlers
 import (
 	// STANDARD LIBARIES
 	"context"
+	"errors"
+	"net/http"
 	"strings"
 	"testing"
 
@@ -11,11 +13,16 @@ import (
 	"github.com/danielgtaylor/huma/v2/humatest"
 
 	//  OUR OWN PACKAGES
+	"go-todo-api/internal/middleware"
 	"go-todo-api/internal/models"
 )
 
 // TestHealthHandler tests the health check endpoint
 func TestHealthHandler(t *testing.T) {
+	// Arrange: Swap in a ping that always succeeds
+	original := pingDatabase
+	pingDatabase = func(ctx context.Context) error { return nil }
+	defer func() { pingDatabase = original }()
 
 	// Act: Call the Health handler directly
 	ctx := context.Background()
@@ -36,6 +43,10 @@ func TestHealthHandler(t *testing.T) {
 		t.Error("Expected non-empty message")
 	}
 
+	if output.Status != http.StatusOK || output.Body.Database.Status != "up" {
+		t.Errorf("Expected 200 with database up, got %d %+v", output.Status, output.Body.Database)
+	}
+
 	// Log success
 	t.Logf("✅ Health check passed: %s - %s", output.Body.Status, output.Body.Message)
 }
@@ -45,6 +56,10 @@ func TestHealthHandler_Integration(t *testing.T) {
 	// This tests the FULL HTTP flow (more realistic)
 
 	// Arrange: Create test API and register endpoint
+	original := pingDatabase
+	pingDatabase = func(ctx context.Context) error { return nil }
+	defer func() { pingDatabase = original }()
+
 	_, api := humatest.New(t)
 
 	// Register the health endpoint like in main.go
@@ -75,3 +90,86 @@ func TestHealthHandler_Integration(t *testing.T) {
 
 	t.Logf("✅ Integration test passed. Response: %s", body)
 }
+
+// TestHealthHandler_DatabaseDown tests that /health returns 503 when MongoDB
+// is unreachable, with the error when error details are on
+func TestHealthHandler_DatabaseDown(t *testing.T) {
+	original := pingDatabase
+	pingDatabase = func(ctx context.Context) error { return errors.New("connection refused") }
+	defer func() { pingDatabase = original }()
+	middleware.VerboseErrors = true
+	defer func() { middleware.VerboseErrors = false }()
+
+	output, err := Health(context.Background(), &models.HealthInput{})
+	if err != nil {
+		t.Fatalf("Health handler returned error: %v", err)
+	}
+
+	if output.Status != http.StatusServiceUnavailable || output.Body.Status != "unhealthy" {
+		t.Errorf("Expected 503 unhealthy, got %d %s", output.Status, output.Body.Status)
+	}
+
+	if output.Body.Database.Status != "down" || output.Body.Database.Error == "" {
+		t.Errorf("Expected database check to be down with an error, got %+v", output.Body.Database)
+	}
+}
+
+// TestLiveness tests that /healthz reports ok without touching dependencies
+func TestLiveness(t *testing.T) {
+	output, err := Liveness(context.Background(), &models.HealthInput{})
+	if err != nil {
+		t.Fatalf("Liveness returned error: %v", err)
+	}
+
+	if output.Body.Status != "ok" {
+		t.Errorf("Expected status 'ok', got '%s'", output.Body.Status)
+	}
+}
+
+// TestReadiness_DatabaseDown tests that /readyz returns 503 when MongoDB is
+// unreachable, with the error when error details are on
+func TestReadiness_DatabaseDown(t *testing.T) {
+	// Arrange: Swap in a ping that always fails, and show error details (dev)
+	original := pingDatabase
+	pingDatabase = func(ctx context.Context) error { return errors.New("connection refused") }
+	defer func() { pingDatabase = original }()
+	middleware.VerboseErrors = true
+	defer func() { middleware.VerboseErrors = false }()
+
+	// Act
+	output, err := Readiness(context.Background(), &models.HealthInput{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Readiness returned error: %v", err)
+	}
+
+	if output.Status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", output.Status)
+	}
+
+	if output.Body.Status != "not_ready" {
+		t.Errorf("Expected 'not_ready', got '%s'", output.Body.Status)
+	}
+
+	check := output.Body.Checks["mongodb"]
+	if check.Status != "down" || check.Error == "" {
+		t.Errorf("Expected mongodb check to be down with an error, got %+v", check)
+	}
+}
+
+// TestReadiness_DatabaseUp tests that /readyz returns 200 when MongoDB answers
+func TestReadiness_DatabaseUp(t *testing.T) {
+	original := pingDatabase
+	pingDatabase = func(ctx context.Context) error { return nil }
+	defer func() { pingDatabase = original }()
+
+	output, err := Readiness(context.Background(), &models.HealthInput{})
+	if err != nil {
+		t.Fatalf("Readiness returned error: %v", err)
+	}
+
+	if output.Status != http.StatusOK || output.Body.Status != "ready" {
+		t.Errorf("Expected 200 ready, got %d %s", output.Status, output.Body.Status)
+	}
+}
@@ -0,0 +1,47 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context" // context = for managing request context
+	"log/slog"
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/jobs"   // Background job queue
+	"go-todo-api/internal/models" // Our data structures
+)
+
+// ============================================================================
+// GET JOB STATUS
+// ============================================================================
+// GetJob handles GET /jobs/{id}
+// Endpoints that start background work (webhooks, emails, imports) return a
+// job ID; clients poll this endpoint to find out how it went
+//
+// Example response:
+//
+//	{"id": "...", "type": "webhook.deliver", "status": "queued", "attempts": 2, "last_error": "connection refused"}
+func GetJob(ctx context.Context, input *models.GetJobInput) (*models.GetJobOutput, error) {
+	job, err := jobs.Get(ctx, input.ID)
+	if err != nil {
+		return nil, problem(ctx, err, "Failed to get job", slog.String("job_id", input.ID))
+	}
+
+	return &models.GetJobOutput{Body: models.JobStatus{
+		ID:          job.ID,
+		Type:        job.Type,
+		Status:      string(job.Status),
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		LastError:   job.LastError,
+		RunAt:       job.RunAt,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+		FinishedAt:  job.FinishedAt,
+	}}, nil
+}
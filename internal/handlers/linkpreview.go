@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+
+	"go-todo-api/internal/linkpreview"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+)
+
+// ============================================================================
+// LINK PREVIEWS
+// ============================================================================
+// The pages linked from a description are fetched on the job workers (see
+// internal/linkpreview): writing a description queues them, and GET
+// /tasks/{id} adds the previews that are ready. Neither waits for a page,
+// and neither fails because of one - a task without its previews is still
+// the task.
+
+// linkPreviews returns the ready previews of the links in description
+func linkPreviews(ctx context.Context, description string) []models.LinkPreview {
+	previewer := linkpreview.Default()
+	if previewer == nil {
+		return nil
+	}
+	found, err := previewer.Lookup(ctx, description)
+	if err != nil {
+		logger.WithTrace(ctx).Warn("Failed to read link previews", slog.Any("error", err))
+		return nil
+	}
+
+	var previews []models.LinkPreview
+	for _, p := range found {
+		previews = append(previews, models.LinkPreview{URL: p.URL, Title: p.Title, Description: p.Description, Image: p.Image})
+	}
+	return previews
+}
+
+// queueLinkPreviews starts fetching the pages linked from a description
+// that was just saved
+func queueLinkPreviews(ctx context.Context, description string) {
+	previewer := linkpreview.Default()
+	if previewer == nil || isDryRun(ctx) {
+		return
+	}
+	if err := previewer.Queue(ctx, description); err != nil {
+		logger.WithTrace(ctx).Warn("Failed to queue link previews", slog.Any("error", err))
+	}
+}
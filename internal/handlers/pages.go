@@ -0,0 +1,108 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context" // context = for managing request context
+	"strconv" // strconv = for the page in the ETag
+	"strings" // strings = for reading the client hint
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/middleware" // Who is calling (GetPrincipal)
+	"go-todo-api/internal/models"     // Our data structures
+	"go-todo-api/internal/repository" // The page to read (Page)
+)
+
+// ============================================================================
+// PAGES
+// ============================================================================
+// GET /tasks returns every task unless the client asks for a page with
+// ?limit= and ?offset=. Mobile clients pay for every byte on a cellular
+// connection, so when they don't ask they get a short first page instead:
+//   - a managed API key can set its own default page size, and mark itself
+//     as a mobile app's (see apikeys.ListDefaults)
+//   - otherwise a request with the Sec-CH-UA-Mobile: ?1 client hint, which
+//     mobile browsers send on their own, gets MOBILE_LIST_LIMIT tasks
+//
+// Lists carry only the summary fields whoever asks (see repository.Fields),
+// so the page size is the only thing that differs. X-Total-Count says how
+// many tasks there are in all, so a client can tell there's more to fetch.
+// Only the page is read from the database (see repository.Page), so
+// QUERY_MAX_RESULTS bounds whole lists and pages bigger than it, and a user
+// with more tasks than that can still page through them.
+
+// ListDefaults are the page sizes GET /tasks uses when the client doesn't
+// give ?limit= (0 = the whole list)
+type ListDefaults struct {
+	// MobileLimit is the page size for mobile clients (MOBILE_LIST_LIMIT)
+	MobileLimit int
+}
+
+// listDefaultsKey is the context key for the list defaults
+type listDefaultsKey struct{}
+
+// WithListDefaults applies d to the handlers called with ctx
+// app.New adds it to every request
+func WithListDefaults(ctx context.Context, d ListDefaults) context.Context {
+	return context.WithValue(ctx, listDefaultsKey{}, d)
+}
+
+// listDefaults returns the page sizes for this request (whole lists unless
+// app.New set them)
+func listDefaults(ctx context.Context) ListDefaults {
+	d, _ := ctx.Value(listDefaultsKey{}).(ListDefaults)
+	return d
+}
+
+// mobileHintHeader is the client hint the default page size depends on
+// Lists name it in Vary, so a cache doesn't hand a phone the desktop's
+// whole list, and in Accept-CH, so browsers send it.
+const mobileHintHeader = "Sec-CH-UA-Mobile"
+
+// isMobile reports whether a Sec-CH-UA-Mobile client hint says the client
+// is a mobile device ("?1"; "?0" or none means it isn't)
+func isMobile(hint string) bool {
+	return strings.TrimSpace(hint) == "?1"
+}
+
+// pageLimit returns how many tasks a list returns: the client's ?limit=,
+// else its API key's default, else the one for its kind of client (0 = all)
+func pageLimit(ctx context.Context, limit int, mobileHint string) int {
+	if limit > 0 {
+		return limit
+	}
+	p, _ := middleware.GetPrincipal(ctx)
+	if p.ListDefaults.Limit > 0 {
+		return p.ListDefaults.Limit
+	}
+	if p.ListDefaults.Mobile || isMobile(mobileHint) {
+		return listDefaults(ctx).MobileLimit
+	}
+	return 0
+}
+
+// listTotal returns how many tasks the whole list has, for X-Total-Count
+// A page that ends before its limit is the end of the list, so the total
+// is known without asking; otherwise count asks the database.
+func listTotal(ctx context.Context, tasks []models.Task, page repository.Page, count func(context.Context) (int64, error)) (int, error) {
+	ended := page.Limit == 0 || len(tasks) < page.Limit
+	if ended && (len(tasks) > 0 || page.Offset == 0) {
+		return page.Offset + len(tasks), nil
+	}
+	total, err := count(ctx)
+	return int(total), err
+}
+
+// pageKey names a page for the ETag: "" for the whole list, "@20:50" for
+// 50 tasks from the 21st
+func pageKey(offset, limit int) string {
+	if offset == 0 && limit == 0 {
+		return ""
+	}
+	return "@" + strconv.Itoa(offset) + ":" + strconv.Itoa(limit)
+}
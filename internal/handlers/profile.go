@@ -0,0 +1,159 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context" // context = for managing request context
+	"errors"  // errors = for checking which error the profile store returned
+	"log/slog"
+	"strings" // strings = for listing the supported locales
+	"time"
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/duedate"    // Reads "tomorrow at 5pm" as a time
+	"go-todo-api/internal/locale"     // How dates and numbers are written
+	"go-todo-api/internal/logger"     // Our structured logger
+	"go-todo-api/internal/middleware" // Who is calling (GetPrincipal)
+	"go-todo-api/internal/models"     // Our data structures
+	"go-todo-api/internal/profile"    // Per-user settings (time zone)
+
+	// THIRD-PARTY PACKAGES
+	"github.com/danielgtaylor/huma/v2" // Huma = REST API framework with error helpers
+)
+
+// callerLocation returns the caller's time zone, which decides what "today"
+// and "tomorrow" mean for their due dates
+// UTC when there's no caller or profiles aren't set up (see profile.Init).
+func callerLocation(ctx context.Context) (*time.Location, error) {
+	p, ok := middleware.GetPrincipal(ctx)
+	profiles := profile.Default()
+	if !ok || profiles == nil {
+		return time.UTC, nil
+	}
+	loc, err := profiles.Location(ctx, p.UserID)
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to read the caller's time zone", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to read your profile", err)
+	}
+	return loc, nil
+}
+
+// callerFormats returns how to write dates for the caller: in the locale
+// their Accept-Language header asks for if it's a supported one, else their
+// profile's, and in their time zone
+func callerFormats(ctx context.Context, acceptLanguage string) (locale.Locale, *time.Location, error) {
+	var found profile.Profile // Default locale, UTC
+	p, ok := middleware.GetPrincipal(ctx)
+	if profiles := profile.Default(); ok && profiles != nil {
+		var err error
+		if found, err = profiles.Get(ctx, p.UserID); err != nil {
+			logger.WithTrace(ctx).Error("Failed to read the caller's profile", slog.Any("error", err))
+			return locale.Locale{}, nil, huma.Error500InternalServerError("Failed to read your profile", err)
+		}
+	}
+	if fromHeader, ok := locale.FromAcceptLanguage(acceptLanguage); ok {
+		return fromHeader, found.Location(), nil
+	}
+	return found.Formats(), found.Location(), nil
+}
+
+// parseDue reads a due date the caller typed ("tomorrow at 5pm", "2025-02-01"...)
+// in their time zone, and returns it in UTC
+// Text it doesn't understand is a 422 pointing at body.due.
+func parseDue(ctx context.Context, text string) (time.Time, error) {
+	loc, err := callerLocation(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	due, err := duedate.Parse(text, time.Now(), loc)
+	if err != nil {
+		return time.Time{}, huma.Error422UnprocessableEntity("Invalid due date",
+			&huma.ErrorDetail{Location: "body.due", Message: err.Error(), Value: text})
+	}
+	return due.UTC(), nil
+}
+
+// profiles returns the profiles, or a 503 before they're set up (they need
+// MongoDB - see profile.Init)
+func profiles() (*profile.Profiles, error) {
+	p := profile.Default()
+	if p == nil {
+		return nil, huma.Error503ServiceUnavailable("Profiles are not available (no database)")
+	}
+	return p, nil
+}
+
+// toProfile is the response form of a profile
+func toProfile(p profile.Profile) models.Profile {
+	out := models.Profile{UserID: p.UserID, TimeZone: p.Location().String(), Locale: p.Formats().String(), AutoArchiveDays: p.AutoArchiveDays}
+	if !p.UpdatedAt.IsZero() {
+		out.UpdatedAt = &p.UpdatedAt
+	}
+	return out
+}
+
+// ============================================================================
+// PROFILE - GET/PUT /me/profile
+// ============================================================================
+
+// GetProfile handles GET /me/profile
+//
+// Example response: {"user_id": "alice", "time_zone": "Europe/Paris"}
+func GetProfile(ctx context.Context, input *models.GetProfileInput) (*models.ProfileOutput, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p, err := profiles()
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := p.Get(ctx, caller.UserID)
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to read profile", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to read your profile", err)
+	}
+	return &models.ProfileOutput{Body: toProfile(found)}, nil
+}
+
+// UpdateProfile handles PUT /me/profile
+// Changing the time zone doesn't move existing due dates: they're stored as
+// instants, and only new "tomorrow"s and date-only due dates use the new zone
+//
+// Example request: {"time_zone": "Europe/Paris", "auto_archive_days": 30}
+func UpdateProfile(ctx context.Context, input *models.UpdateProfileInput) (*models.ProfileOutput, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p, err := profiles()
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := p.Save(ctx, profile.Profile{
+		UserID:          caller.UserID,
+		TimeZone:        input.Body.TimeZone,
+		Locale:          input.Body.Locale,
+		AutoArchiveDays: input.Body.AutoArchiveDays,
+	})
+	if errors.Is(err, profile.ErrInvalidTimeZone) {
+		return nil, huma.Error422UnprocessableEntity("Unknown time zone",
+			&huma.ErrorDetail{Location: "body.time_zone", Message: "must be an IANA name like Europe/Paris", Value: input.Body.TimeZone})
+	}
+	if errors.Is(err, profile.ErrInvalidLocale) {
+		return nil, huma.Error422UnprocessableEntity("Unsupported locale",
+			&huma.ErrorDetail{Location: "body.locale", Message: "must be close to one of " + strings.Join(locale.Supported(), ", "), Value: input.Body.Locale})
+	}
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to save profile", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to save your profile", err)
+	}
+	return &models.ProfileOutput{Body: toProfile(saved)}, nil
+}
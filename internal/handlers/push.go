@@ -0,0 +1,263 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context" // context = for managing request context
+	"errors"  // errors = for checking which error the dispatcher returned
+	"log/slog"
+	"net/mail" // net/mail = for checking email addresses
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/logger" // Our structured logger
+	"go-todo-api/internal/models" // Our data structures
+	"go-todo-api/internal/push"   // Web Push and FCM notifications
+
+	// THIRD-PARTY PACKAGES
+	"github.com/danielgtaylor/huma/v2" // Huma = REST API framework with error helpers
+)
+
+// pushDispatcher returns the notification dispatcher, or a 503 before it's
+// set up (it needs MongoDB - see push.Init)
+func pushDispatcher() (*push.Dispatcher, error) {
+	d := push.Default()
+	if d == nil {
+		return nil, huma.Error503ServiceUnavailable("Push notifications are not available (no database)")
+	}
+	return d, nil
+}
+
+// toPushSubscription is the response form of a subscription (without its keys)
+func toPushSubscription(sub push.Subscription) models.PushSubscription {
+	return models.PushSubscription{
+		ID:        sub.ID,
+		Type:      sub.Type,
+		Endpoint:  sub.Endpoint,
+		CreatedAt: sub.CreatedAt,
+	}
+}
+
+// ============================================================================
+// PUSH SUBSCRIPTIONS - GET/POST/DELETE /me/push-subscriptions
+// ============================================================================
+
+// ListPushSubscriptions handles GET /me/push-subscriptions
+// Also returns the VAPID key a browser needs to subscribe
+func ListPushSubscriptions(ctx context.Context, input *models.ListPushSubscriptionsInput) (*models.ListPushSubscriptionsOutput, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, err := pushDispatcher()
+	if err != nil {
+		return nil, err
+	}
+	subs, err := d.Subscriptions(ctx, caller.UserID)
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to list push subscriptions", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to list push subscriptions", err)
+	}
+
+	out := &models.ListPushSubscriptionsOutput{}
+	out.Body.VAPIDPublicKey = d.VAPIDPublicKey()
+	out.Body.WebPush = d.Enabled(push.TypeWebPush)
+	out.Body.FCM = d.Enabled(push.TypeFCM)
+	out.Body.Subscriptions = make([]models.PushSubscription, 0, len(subs))
+	for _, sub := range subs {
+		out.Body.Subscriptions = append(out.Body.Subscriptions, toPushSubscription(sub))
+	}
+	return out, nil
+}
+
+// CreatePushSubscription handles POST /me/push-subscriptions
+//
+// Example request (a browser): {"type": "webpush", "endpoint": "https://...", "keys": {"p256dh": "...", "auth": "..."}}
+// Example request (an app):    {"type": "fcm", "token": "dQw4w9WgXcQ:APA91b..."}
+func CreatePushSubscription(ctx context.Context, input *models.CreatePushSubscriptionInput) (*models.PushSubscriptionOutput, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, err := pushDispatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	sub := push.Subscription{
+		UserID:   caller.UserID,
+		Type:     input.Body.Type,
+		Endpoint: input.Body.Endpoint,
+		Token:    input.Body.Token,
+	}
+	if input.Body.Keys != nil {
+		sub.P256dh = input.Body.Keys.P256dh
+		sub.Auth = input.Body.Keys.Auth
+	}
+
+	saved, err := d.Subscribe(ctx, sub)
+	switch {
+	case errors.Is(err, push.ErrInvalid):
+		return nil, huma.Error422UnprocessableEntity(err.Error()) // Says what's wrong with it
+	case errors.Is(err, push.ErrNotConfigured):
+		return nil, huma.Error422UnprocessableEntity("This server can't send " + sub.Type + " notifications")
+	case err != nil:
+		logger.WithTrace(ctx).Error("Failed to save push subscription", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to save push subscription", err)
+	}
+
+	logger.WithTrace(ctx).Info("Push subscription saved",
+		slog.String("subscription_id", saved.ID), slog.String("type", saved.Type))
+	return &models.PushSubscriptionOutput{Body: toPushSubscription(*saved)}, nil
+}
+
+// DeletePushSubscription handles DELETE /me/push-subscriptions/{id}
+// Only your own devices can be removed
+func DeletePushSubscription(ctx context.Context, input *models.PushSubscriptionIDInput) (*struct{}, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, err := pushDispatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.Unsubscribe(ctx, input.ID, caller.UserID); err != nil {
+		return nil, problem(ctx, err, "Failed to delete push subscription")
+	}
+	return nil, nil
+}
+
+// TestPushNotification handles POST /me/push-subscriptions/test
+// Queues a notification to every one of your devices, to check they work
+func TestPushNotification(ctx context.Context, input *models.TestPushInput) (*models.TestPushOutput, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, err := pushDispatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := d.Notify(ctx, caller.UserID, push.Message{
+		Event: push.EventTest,
+		Title: "Test notification",
+		Body:  "Notifications from your TODO list will show up like this.",
+	})
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to queue test notification", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to queue test notification", err)
+	}
+
+	out := &models.TestPushOutput{}
+	out.Body.JobID = job.ID
+	return out, nil
+}
+
+// ============================================================================
+// NOTIFICATION SETTINGS - GET/PUT /me/notification-settings
+// ============================================================================
+
+// toNotificationSettings is the response form of a user's preferences
+func toNotificationSettings(prefs push.Preferences) models.NotificationSettings {
+	settings := models.NotificationSettings{
+		Channels: models.NotificationChannels{
+			Email: prefs.Channels.Email,
+			Push:  prefs.Channels.Push,
+			Slack: prefs.Channels.Slack,
+		},
+		Events: models.NotificationEvents{
+			Reminders:   prefs.Events.Reminders,
+			Assignments: prefs.Events.Assignments,
+			Mentions:    prefs.Events.Mentions,
+			DailyDigest: prefs.Events.DailyDigest,
+		},
+		Email: prefs.Email,
+	}
+	if !prefs.UpdatedAt.IsZero() {
+		settings.UpdatedAt = &prefs.UpdatedAt
+	}
+	return settings
+}
+
+// GetNotificationSettings handles GET /me/notification-settings
+// Everything is on until the user changes it
+func GetNotificationSettings(ctx context.Context, input *models.GetNotificationSettingsInput) (*models.NotificationSettingsOutput, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, err := pushDispatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := d.Preferences(ctx, caller.UserID)
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to read notification settings", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to read notification settings", err)
+	}
+	return &models.NotificationSettingsOutput{Body: toNotificationSettings(prefs)}, nil
+}
+
+// UpdateNotificationSettings handles PUT /me/notification-settings
+// The dispatcher checks them before sending, so they also apply to
+// notifications already queued
+//
+// Example request: {"channels": {"email": false, "push": true, "slack": false},
+// "events": {"reminders": true, "assignments": true, "mentions": false}}
+func UpdateNotificationSettings(ctx context.Context, input *models.UpdateNotificationSettingsInput) (*models.NotificationSettingsOutput, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, err := pushDispatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the address here, so a typo is a 422 now rather than a digest
+	// that never arrives
+	body := input.Body
+	if body.Email != "" {
+		addr, err := mail.ParseAddress(body.Email)
+		if err != nil || addr.Name != "" {
+			return nil, huma.Error422UnprocessableEntity("Invalid email address",
+				&huma.ErrorDetail{Location: "body.email", Message: "must be a plain address like alice@example.com", Value: body.Email})
+		}
+		if err := checkEmailDomain(ctx, body.Email); err != nil {
+			return nil, err
+		}
+	}
+	if body.Events.DailyDigest && (body.Email == "" || !body.Channels.Email) {
+		return nil, huma.Error422UnprocessableEntity("The daily digest is sent by email",
+			&huma.ErrorDetail{Location: "body.email", Message: "set email and turn on channels.email to get the digest"})
+	}
+
+	prefs, err := d.SetPreferences(ctx, push.Preferences{
+		UserID: caller.UserID,
+		Channels: push.ChannelPrefs{
+			Email: input.Body.Channels.Email,
+			Push:  input.Body.Channels.Push,
+			Slack: input.Body.Channels.Slack,
+		},
+		Events: push.EventPrefs{
+			Reminders:   input.Body.Events.Reminders,
+			Assignments: input.Body.Events.Assignments,
+			Mentions:    input.Body.Events.Mentions,
+			DailyDigest: input.Body.Events.DailyDigest,
+		},
+		Email: input.Body.Email,
+	})
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to save notification settings", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to save notification settings", err)
+	}
+	return &models.NotificationSettingsOutput{Body: toNotificationSettings(prefs)}, nil
+}
@@ -0,0 +1,141 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context" // context = for managing request context
+	"log/slog"
+	"time"
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/logger"     // Our structured logger
+	"go-todo-api/internal/middleware" // Who is calling (GetPrincipal)
+	"go-todo-api/internal/models"     // Our data structures
+	"go-todo-api/internal/recording"  // Recorded request/response pairs
+
+	// THIRD-PARTY PACKAGES
+	"github.com/danielgtaylor/huma/v2" // Huma = REST API framework with error helpers
+)
+
+// recorder returns the recorder, or a 503 when recording is off (it needs
+// MongoDB - see recording.Init)
+func recorder() (*recording.Recorder, error) {
+	r := recording.Default()
+	if r == nil {
+		return nil, huma.Error503ServiceUnavailable("Recording is not available (no database)")
+	}
+	return r, nil
+}
+
+// toRecordingTarget is the response form of a target
+func toRecordingTarget(t recording.Target) models.RecordingTarget {
+	return models.RecordingTarget{ID: t.ID, APIKeyID: t.APIKeyID, RequestID: t.RequestID, Until: t.Until, CreatedBy: t.CreatedBy}
+}
+
+// ============================================================================
+// RECORDING TARGETS - /admin/recordings/targets
+// ============================================================================
+
+// StartRecording handles POST /admin/recordings/targets
+// Records every request of one API key, or with one X-Request-ID, that this
+// instance serves for the next few minutes (like the log level, it's per
+// process).
+//
+// Example request:  POST /admin/recordings/targets with body: {"api_key_id": "6900d436e231fdbb964c3c1c", "minutes": 15}
+func StartRecording(ctx context.Context, input *models.StartRecordingInput) (*models.RecordingTargetOutput, error) {
+	r, err := recorder()
+	if err != nil {
+		return nil, err
+	}
+	caller, _ := middleware.GetPrincipal(ctx)
+	target, err := r.Start(recording.Target{
+		APIKeyID:  input.Body.APIKeyID,
+		RequestID: input.Body.RequestID,
+		CreatedBy: caller.UserID,
+	}, time.Duration(input.Body.Minutes)*time.Minute)
+	if err != nil {
+		return nil, huma.Error422UnprocessableEntity("Set exactly one of api_key_id and request_id")
+	}
+
+	// Logged at warn: full bodies of someone's requests are being kept
+	logger.WithTrace(ctx).Warn("Recording started",
+		slog.String("target", target.ID), slog.String("api_key_id", target.APIKeyID),
+		slog.String("request_id", target.RequestID), slog.Time("until", target.Until), slog.String("by", caller.UserID))
+	return &models.RecordingTargetOutput{Body: toRecordingTarget(target)}, nil
+}
+
+// ListRecordingTargets handles GET /admin/recordings/targets
+func ListRecordingTargets(ctx context.Context, input *models.ListRecordingTargetsInput) (*models.ListRecordingTargetsOutput, error) {
+	r, err := recorder()
+	if err != nil {
+		return nil, err
+	}
+	out := &models.ListRecordingTargetsOutput{}
+	out.Body.Targets = []models.RecordingTarget{}
+	for _, t := range r.Targets() {
+		out.Body.Targets = append(out.Body.Targets, toRecordingTarget(t))
+	}
+	return out, nil
+}
+
+// StopRecording handles DELETE /admin/recordings/targets/{id}
+// What was recorded so far is kept.
+func StopRecording(ctx context.Context, input *models.RecordingTargetIDInput) (*models.DeletedOutput, error) {
+	r, err := recorder()
+	if err != nil {
+		return nil, err
+	}
+	if !r.Stop(input.ID) {
+		return nil, huma.Error404NotFound("Recording target not found")
+	}
+	logger.WithTrace(ctx).Warn("Recording stopped", slog.String("target", input.ID))
+	out := &models.DeletedOutput{}
+	out.Body.Message = "Recording stopped"
+	out.Body.ID = input.ID
+	return out, nil
+}
+
+// ============================================================================
+// RECORDINGS - GET /admin/recordings
+// ============================================================================
+
+// ListRecordings handles GET /admin/recordings
+func ListRecordings(ctx context.Context, input *models.ListRecordingsInput) (*models.ListRecordingsOutput, error) {
+	r, err := recorder()
+	if err != nil {
+		return nil, err
+	}
+	recordings, err := r.List(ctx, input.TargetID, input.Limit)
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to list recordings", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to list recordings", err)
+	}
+
+	out := &models.ListRecordingsOutput{}
+	out.Body.Recordings = make([]models.Recording, 0, len(recordings))
+	for _, rec := range recordings {
+		out.Body.Recordings = append(out.Body.Recordings, models.Recording{
+			ID:              rec.ID.Hex(),
+			TargetID:        rec.TargetID,
+			At:              rec.At,
+			RequestID:       rec.RequestID,
+			UserID:          rec.UserID,
+			APIKeyID:        rec.APIKeyID,
+			Method:          rec.Method,
+			URL:             rec.URL,
+			RequestHeaders:  rec.RequestHeaders,
+			RequestBody:     rec.RequestBody,
+			Status:          rec.Status,
+			ResponseHeaders: rec.ResponseHeaders,
+			ResponseBody:    rec.ResponseBody,
+			Truncated:       rec.Truncated,
+			DurationMs:      milliseconds(rec.Duration),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// ============================================================================
+// SANDBOX - POST /sandbox/reset
+// ============================================================================
+// Sandbox API keys (POST /admin/apikeys with "sandbox": true) use the task
+// endpoints like any other key, but their tasks live in a collection of
+// their own: integrators can create, break and delete whatever they like
+// without touching real data, then start over with one call.
+
+// ResetSandbox handles POST /sandbox/reset
+// Deletes every task in the caller's sandbox; only sandbox keys may call it
+func ResetSandbox(ctx context.Context, input *struct{}) (*models.ResetSandboxOutput, error) {
+	sandbox := sandboxRepository(ctx)
+	if sandbox == nil {
+		return nil, huma.Error403Forbidden("Only sandbox API keys can reset their sandbox")
+	}
+	if err := sandbox.DeleteAll(ctx); err != nil {
+		logger.WithTrace(ctx).Error("Failed to reset sandbox", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to reset sandbox", err)
+	}
+
+	logger.WithTrace(ctx).Info("Sandbox reset")
+	out := &models.ResetSandboxOutput{}
+	out.Body.Message = "Sandbox reset"
+	return out, nil
+}
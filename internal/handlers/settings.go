@@ -0,0 +1,162 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context" // context = for managing request context
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/logger"     // Our structured logger
+	"go-todo-api/internal/middleware" // Who is calling (GetPrincipal)
+	"go-todo-api/internal/models"     // Our data structures
+	"go-todo-api/internal/settings"   // Organization-wide settings
+
+	// THIRD-PARTY PACKAGES
+	"github.com/danielgtaylor/huma/v2" // Huma = REST API framework with error helpers
+)
+
+// orgSettings returns the organization's settings, or a 503 before they're
+// set up (they need MongoDB - see settings.Init)
+func orgSettings() (*settings.Settings, error) {
+	s := settings.Default()
+	if s == nil {
+		return nil, huma.Error503ServiceUnavailable("Settings are not available (no database)")
+	}
+	return s, nil
+}
+
+// toOrgSettings is the response form of the settings
+// Lists are never null, so clients can range over them.
+func toOrgSettings(o settings.Org) models.OrgSettings {
+	out := models.OrgSettings{
+		ReminderLeadMinutes: o.ReminderLeadMinutes,
+		AllowedTags:         append([]string{}, o.AllowedTags...),
+		RetentionDays:       o.RetentionDays,
+		AllowedEmailDomains: append([]string{}, o.AllowedEmailDomains...),
+		Anomaly:             models.AnomalyThresholds(o.Anomaly),
+		UpdatedBy:           o.UpdatedBy,
+	}
+	if !o.UpdatedAt.IsZero() {
+		out.UpdatedAt = &o.UpdatedAt
+	}
+	return out
+}
+
+// ============================================================================
+// SETTINGS - GET/PUT /admin/settings
+// ============================================================================
+
+// GetOrgSettings handles GET /admin/settings
+func GetOrgSettings(ctx context.Context, input *models.GetOrgSettingsInput) (*models.OrgSettingsOutput, error) {
+	s, err := orgSettings()
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.Get(ctx)
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to read the settings", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to read the settings", err)
+	}
+	return &models.OrgSettingsOutput{Body: toOrgSettings(org)}, nil
+}
+
+// SetOrgSettings handles PUT /admin/settings
+// It replaces every setting: fields left out go back to their defaults.
+// Other instances see the change within settings.DefaultTTL.
+//
+// Example request:  PUT /admin/settings with body: {"reminder_lead_minutes": 30, "allowed_email_domains": ["example.com"]}
+func SetOrgSettings(ctx context.Context, input *models.SetOrgSettingsInput) (*models.OrgSettingsOutput, error) {
+	s, err := orgSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := cleanList(input.Body.AllowedTags, "allowed_tags", checkTag)
+	if err != nil {
+		return nil, err
+	}
+	domains, err := cleanList(input.Body.AllowedEmailDomains, "allowed_email_domains", checkDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	caller, _ := middleware.GetPrincipal(ctx)
+	org, err := s.Save(ctx, settings.Org{
+		ReminderLeadMinutes: input.Body.ReminderLeadMinutes,
+		AllowedTags:         tags,
+		RetentionDays:       input.Body.RetentionDays,
+		AllowedEmailDomains: domains,
+		Anomaly:             settings.AnomalyThresholds(input.Body.Anomaly),
+		UpdatedBy:           caller.UserID,
+	})
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to save the settings", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to save the settings", err)
+	}
+
+	// Logged at warn, like a log level change: it affects every user
+	logger.WithTrace(ctx).Warn("Settings changed", slog.String("by", caller.UserID))
+	return &models.OrgSettingsOutput{Body: toOrgSettings(org)}, nil
+}
+
+// cleanList trims, lowercases and checks each value of a settings list,
+// dropping duplicates. A bad value is a 422 pointing at it.
+func cleanList(values []string, field string, check func(string) string) ([]string, error) {
+	var out []string
+	for i, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if problem := check(v); problem != "" {
+			return nil, huma.Error422UnprocessableEntity("Invalid settings",
+				&huma.ErrorDetail{Location: fmt.Sprintf("body.%s[%d]", field, i), Message: problem, Value: values[i]})
+		}
+		if !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// checkTag says what's wrong with a tag, or "" if nothing
+func checkTag(tag string) string {
+	if tag == "" || len(tag) > 50 || strings.ContainsAny(tag, " ,\t\n") {
+		return "must be 1 to 50 characters, without spaces or commas"
+	}
+	return ""
+}
+
+// checkDomain says what's wrong with an email domain, or "" if nothing
+func checkDomain(domain string) string {
+	if len(domain) > 253 || !strings.Contains(domain, ".") || strings.ContainsAny(domain, "@ \t\n") ||
+		strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "must be a domain like example.com, without the @"
+	}
+	return ""
+}
+
+// checkEmailDomain refuses an address outside the organization's allowed
+// email domains (a 422 pointing at body.email)
+func checkEmailDomain(ctx context.Context, address string) error {
+	s := settings.Default()
+	if s == nil {
+		return nil
+	}
+	org, err := s.Get(ctx)
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to read the settings", slog.Any("error", err))
+		return huma.Error500InternalServerError("Failed to read the settings", err)
+	}
+	if !org.EmailAllowed(address) {
+		return huma.Error422UnprocessableEntity("Email domain not allowed",
+			&huma.ErrorDetail{Location: "body.email", Message: "must be at one of: " + strings.Join(org.AllowedEmailDomains, ", "), Value: address})
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+
+	"github.com/danielgtaylor/huma/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ============================================================================
+// SYNC - POST /sync
+// ============================================================================
+// Sync lets an offline-first client catch up in one request
+//
+// The client sends the changes it made while offline and the token from its
+// last sync. The server applies the changes in order, each exactly as the
+// matching endpoint would (POST /tasks, PUT /tasks/{id}, DELETE /tasks/{id}:
+// same validation, quota and hooks), and answers with a result per change.
+//
+// Tasks carry no version of their own, so conflicts are found by comparing
+// the task on the server with the copy the client last got (an update's
+// base). The request's strategy decides what a conflict does (see
+// resolveConflicts); by default the last write wins, and so does an update
+// without a base. A task deleted on the server stays deleted - changing it
+// gets a 404 result, which tells the client to drop its copy.
+//
+// The token is the repository's Version. When it's unchanged apart from the
+// client's own writes, the client is up to date and only gets the results;
+// otherwise (or on a first sync) it gets every task (full: true) to replace
+// its copy with. There's no per-task change log to send a delta from - and
+// a full list is also the only way to tell the client about deletions.
+//
+// A dry run previews each change against the stored tasks, so a change can't
+// build on an earlier one in the same request (say, updating a task the
+// request creates): that one gets a 404 result.
+//
+// A sync that updates or deletes more than BULK_CONFIRM_ABOVE tasks needs
+// ?confirm=<count> (see bulk.go): a client whose local copy went wrong
+// mustn't be able to wipe out the server's in one go.
+//
+// Example request:  POST /sync {"token": "42", "changes": [{"op": "update", "id": "6900d436e231fdbb964c3c1c", "completed": true}]}
+// Example response: {"token": "43", "results": [{"op": "update", "id": "...", "status": 200, "task": {...}}], "full": false}
+func Sync(ctx context.Context, input *models.SyncInput) (*models.SyncOutput, error) {
+	tracer := otel.Tracer("handlers")
+	ctx, handlerSpan := tracer.Start(ctx, "Sync")
+	defer handlerSpan.End()
+	ctx = withDryRun(ctx, input.DryRun)
+
+	handlerSpan.SetAttributes(
+		attribute.Int("sync.changes", len(input.Body.Changes)),
+		attribute.String("sync.strategy", input.Body.Strategy),
+	)
+
+	if err := confirmBulk(ctx, input.Confirm, syncedTasks(input.Body.Changes)); err != nil {
+		return nil, err
+	}
+
+	repo := taskRepository(ctx)
+	before, err := repo.Version(ctx)
+	if err != nil {
+		handlerSpan.RecordError(err)
+		logger.WithTrace(ctx).Error("Failed to read the task version", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to sync", err)
+	}
+
+	// ----------------------------------------------------------------------------
+	// STEP 1: APPLY THE CLIENT'S CHANGES, IN ORDER
+	// ----------------------------------------------------------------------------
+	out := &models.SyncOutput{}
+	out.Body.Results = make([]models.SyncResult, 0, len(input.Body.Changes))
+	var writes int64 // Version bumps our own changes caused
+	for _, change := range input.Body.Changes {
+		result, n := applySyncChange(ctx, input.Body.Strategy, change)
+		out.Body.Results = append(out.Body.Results, result)
+		if !isDryRun(ctx) {
+			writes += n
+		}
+	}
+
+	// ----------------------------------------------------------------------------
+	// STEP 2: WORK OUT WHAT THE CLIENT IS MISSING
+	// ----------------------------------------------------------------------------
+	// Read after the writes: the version is bumped after each write, so the
+	// list below has at least everything this version counts
+	// Once changes are saved the client must get their results, or its retry
+	// would apply them again: if this step fails, it gets them with its own
+	// token back, which makes its next sync a full one
+	after, err := repo.Version(ctx)
+	var tasks []models.Task
+	if err == nil {
+		out.Body.Token = strconv.FormatInt(after, 10)
+		// Up to date: the client had the latest version, and nobody else
+		// wrote while its changes were applied
+		if input.Body.Token != strconv.FormatInt(before, 10) || after != before+writes {
+			tasks, err = allTasks(ctx, repo)
+		}
+	}
+	switch {
+	case err != nil && writes == 0:
+		handlerSpan.RecordError(err)
+		return nil, problem(ctx, err, "Failed to sync")
+	case err != nil:
+		handlerSpan.RecordError(err)
+		logger.WithTrace(ctx).Error("Failed to read the tasks after a sync's changes; the client gets a full sync next time",
+			slog.Int64("writes", writes), slog.Any("error", err))
+		out.Body.Token = input.Body.Token
+	case tasks != nil:
+		out.Body.Full = true
+		out.Body.Tasks = tasks
+	}
+	handlerSpan.SetAttributes(attribute.Bool("sync.full", out.Body.Full))
+
+	logger.WithTrace(ctx).Info("Synced a client",
+		slog.Int("changes", len(input.Body.Changes)), slog.Bool("full", out.Body.Full))
+	return out, nil
+}
+
+// allTasks reads every task for a full sync
+// It streams them like GET /export: a sync that saved the client's changes
+// can't then fail on QUERY_MAX_RESULTS.
+func allTasks(ctx context.Context, repo repository.TaskRepository) ([]models.Task, error) {
+	seq, err := repo.Stream(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []models.Task{}
+	for task, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// syncedTasks lists the tasks the changes update or delete, once each
+// (as a delete if any of the changes deletes it)
+func syncedTasks(changes []models.SyncChange) []bulkTask {
+	var tasks []bulkTask
+	seen := make(map[string]int) // Task ID → index in tasks
+	for i, change := range changes {
+		if change.Op != models.SyncUpdate && change.Op != models.SyncDelete {
+			continue
+		}
+		if j, ok := seen[change.ID]; ok {
+			if change.Op == models.SyncDelete {
+				tasks[j].Op = change.Op
+			}
+			continue
+		}
+		seen[change.ID] = len(tasks)
+		tasks = append(tasks, bulkTask{ID: change.ID, Op: change.Op, Location: "body.changes[" + strconv.Itoa(i) + "].id"})
+	}
+	return tasks
+}
+
+// applySyncChange applies one change through the matching handler
+// It returns the change's result and how many writes it made.
+func applySyncChange(ctx context.Context, strategy string, change models.SyncChange) (models.SyncResult, int64) {
+	result := models.SyncResult{Op: change.Op, ClientID: change.ClientID, ID: change.ID}
+
+	switch change.Op {
+	case models.SyncCreate:
+		if change.Title == nil {
+			return syncFailed(result, huma.Error422UnprocessableEntity("A new task needs a title")), 0
+		}
+		in := &models.CreateTaskInput{}
+		in.Body.Title = *change.Title
+		if change.Description != nil {
+			in.Body.Description = *change.Description
+		}
+		if change.Due != nil {
+			in.Body.Due = *change.Due
+		}
+		created, err := CreateTask(ctx, in)
+		if err != nil {
+			return syncFailed(result, err), 0
+		}
+		result.ID = created.Body.ID.String()
+		result.Status = http.StatusCreated
+		result.Task = &created.Body
+
+		// Created already done: one more write, as a separate PUT would
+		if change.Completed == nil || !*change.Completed {
+			return result, 1
+		}
+		// On a dry run the task was never saved, so there's nothing to complete
+		if isDryRun(ctx) {
+			now := time.Now().UTC()
+			created.Body.Completed, created.Body.CompletedAt = true, &now
+			return result, 0
+		}
+		completed, err := setCompleted(ctx, "CompleteTask", created.Body.ID, true)
+		if err != nil {
+			return syncFailed(result, err), 1
+		}
+		result.Task = &completed.Body
+		return result, 2
+
+	case models.SyncUpdate:
+		if change.ID == "" {
+			return syncFailed(result, huma.Error422UnprocessableEntity("An update needs the task's id")), 0
+		}
+		id, err := models.ParseTaskID(change.ID)
+		if err != nil {
+			return syncFailed(result, huma.Error400BadRequest("Invalid task ID format")), 0
+		}
+		conflicts, err := resolveConflicts(ctx, strategy, id, change)
+		result.Conflicts = conflicts
+		if err != nil {
+			return syncFailed(result, err), 0
+		}
+		in := &models.UpdateTaskInput{ID: id}
+		in.Body.Title = change.Title
+		in.Body.Description = change.Description
+		in.Body.Completed = change.Completed
+		in.Body.Due = change.Due
+		updated, err := UpdateTask(ctx, in)
+		if err != nil {
+			return syncFailed(result, err), 0
+		}
+		result.Status = http.StatusOK
+		result.Task = &updated.Body
+		return result, 1
+
+	case models.SyncDelete:
+		if change.ID == "" {
+			return syncFailed(result, huma.Error422UnprocessableEntity("A delete needs the task's id")), 0
+		}
+		id, err := models.ParseTaskID(change.ID)
+		if err != nil {
+			return syncFailed(result, huma.Error400BadRequest("Invalid task ID format")), 0
+		}
+		if _, err := DeleteTask(ctx, &models.DeleteTaskInput{ID: id}); err != nil {
+			return syncFailed(result, err), 0
+		}
+		result.Status = http.StatusOK
+		return result, 1
+	}
+
+	// Huma checks op against its enum first; this is for direct callers
+	return syncFailed(result, huma.Error422UnprocessableEntity("Unknown op "+strconv.Quote(change.Op))), 0
+}
+
+// resolveConflicts compares an update with the task on the server
+// It returns the fields the server changed since the update's base, and an
+// error when the strategy refuses the update:
+//
+//	last-write-wins  never (the client's fields overwrite the server's)
+//	merge            409 when the client changed one of those fields too
+//	reject           409 when there are any
+//
+// merge and reject need a base to compare with (422 without one).
+func resolveConflicts(ctx context.Context, strategy string, id models.TaskID, change models.SyncChange) ([]models.SyncConflict, error) {
+	if change.Base == nil {
+		if strategy == models.SyncMerge || strategy == models.SyncReject {
+			return nil, huma.Error422UnprocessableEntity("An update needs a base with the " + strategy + " strategy")
+		}
+		return nil, nil
+	}
+
+	server, err := taskRepository(ctx).Get(ctx, id)
+	if err != nil {
+		return nil, problem(ctx, err, "Failed to fetch task", slog.String("id", change.ID))
+	}
+
+	conflicts := divergedFields(*change.Base, *server, change)
+	overlap := slices.ContainsFunc(conflicts, func(c models.SyncConflict) bool { return c.Client != nil })
+	switch {
+	case strategy == models.SyncReject && len(conflicts) > 0:
+		return conflicts, huma.Error409Conflict("The task changed on the server since the client's base")
+	case strategy == models.SyncMerge && overlap:
+		return conflicts, huma.Error409Conflict("The client and the server changed the same fields")
+	}
+	return conflicts, nil
+}
+
+// divergedFields lists the fields of server that differ from base, with the
+// client's value on those the change sets to something else
+func divergedFields(base, server models.Task, change models.SyncChange) []models.SyncConflict {
+	var conflicts []models.SyncConflict
+	add := func(field string, baseValue, serverValue any, clientSet bool, clientValue any) {
+		c := models.SyncConflict{Field: field, Base: baseValue, Server: serverValue}
+		if clientSet {
+			c.Client = clientValue
+		}
+		conflicts = append(conflicts, c)
+	}
+
+	if base.Title != server.Title {
+		add("title", base.Title, server.Title,
+			change.Title != nil && *change.Title != server.Title, deref(change.Title))
+	}
+	if base.Description != server.Description {
+		add("description", base.Description, server.Description,
+			change.Description != nil && *change.Description != server.Description, deref(change.Description))
+	}
+	if base.Completed != server.Completed {
+		add("completed", base.Completed, server.Completed,
+			change.Completed != nil && *change.Completed != server.Completed, deref(change.Completed))
+	}
+	// A due is a phrase read in the caller's time zone, so any due the
+	// client sent counts as overlapping
+	if !sameTime(base.DueAt, server.DueAt) {
+		add("due_at", base.DueAt, server.DueAt, change.Due != nil, deref(change.Due))
+	}
+	return conflicts
+}
+
+// deref returns *p, or the zero value when p is nil
+func deref[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
+	}
+	return v
+}
+
+// sameTime reports whether two optional times are both absent or equal
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// syncFailed fills in the result of a change that wasn't applied, with the
+// status and message its own endpoint would have answered with
+func syncFailed(result models.SyncResult, err error) models.SyncResult {
+	result.Status = http.StatusInternalServerError
+	var statusErr huma.StatusError
+	if errors.As(err, &statusErr) {
+		result.Status = statusErr.GetStatus()
+	}
+	result.Error = err.Error()
+	return result
+}
@@ -10,25 +10,213 @@ package handlers
 import (
 	// STANDARD LIBRARY PACKAGES
 	"context" // context = for managing request timeouts and cancellation
+	"errors"  // errors = for checking which error the repository returned
+	"fmt"     // fmt = for putting the limits in error messages
+	"io"      // io = the response body the export writes to
 	"log/slog"
-	"time" // time = for working with time durations and timeouts
+	"net/http" // net/http = for the ETag and Cache-Control headers
+	"slices"   // slices = for checking which fields ?include= asks for
+	"strconv"  // strconv = for turning the list version into an ETag
+	"strings"
+	"time" // time = for what "today" and "overdue" mean
 
 	// OUR OWN PACKAGES
-	"go-todo-api/internal/database" // Our database connection code
-	"go-todo-api/internal/logger"   // Our structured logger
-	"go-todo-api/internal/models"   // Our data structures (Task, Input/Output types)
+	"go-todo-api/internal/anomaly"    // Unusual usage, like many deletions at once
+	"go-todo-api/internal/database"   // Our database connection code
+	"go-todo-api/internal/duedate"    // Start of the caller's day, for ?due=today
+	"go-todo-api/internal/events"     // Task events, published by the writes
+	"go-todo-api/internal/hooks"      // Deployment-specific logic around changes
+	"go-todo-api/internal/logger"     // Our structured logger
+	"go-todo-api/internal/markdown"   // Descriptions rendered as safe HTML (?render=html)
+	"go-todo-api/internal/middleware" // Who is calling (for quotas)
+	"go-todo-api/internal/models"     // Our data structures (Task, Input/Output types)
+	"go-todo-api/internal/repository" // Where tasks are stored (MongoDB in production)
 
 	// THIRD-PARTY PACKAGES
-	"github.com/danielgtaylor/huma/v2"           // Huma = REST API framework with error helpers
-	"go.mongodb.org/mongo-driver/bson"           // bson = MongoDB's query language (like SQL)
-	"go.mongodb.org/mongo-driver/bson/primitive" // primitive = MongoDB types (ObjectID)
-	"go.mongodb.org/mongo-driver/mongo"          // mongo = MongoDB driver for Go
+	"github.com/danielgtaylor/huma/v2" // Huma = REST API framework with error helpers
 
 	// OPEN TELEMETRY SPAN PACKAGES
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 )
 
+// ============================================================================
+// TASK REPOSITORY
+// ============================================================================
+// taskRepoKey is the context key for a per-request repository
+type taskRepoKey struct{}
+
+// WithTaskRepository makes the handlers called with ctx use repo instead of MongoDB
+// Tests use it to run against a fake, or against a collection of their own
+// (see testutil.IsolatedCollection), in parallel
+func WithTaskRepository(ctx context.Context, repo repository.TaskRepository) context.Context {
+	return context.WithValue(ctx, taskRepoKey{}, repo)
+}
+
+// taskCacheKey is the context key for the list micro-cache
+type taskCacheKey struct{}
+
+// WithTaskCache puts cache in front of the repository for handlers called with ctx
+// app.New adds it to every request when TASKS_CACHE_TTL is set
+func WithTaskCache(ctx context.Context, cache *repository.TaskCache) context.Context {
+	return context.WithValue(ctx, taskCacheKey{}, cache)
+}
+
+// statsCacheKey is the context key for the counts snapshot
+type statsCacheKey struct{}
+
+// WithStatsCache answers GET /stats from cache's snapshot for handlers
+// called with ctx (see repository.StatsCache)
+// app.New adds it to every request unless STATS_CACHE_TTL=0
+func WithStatsCache(ctx context.Context, cache *repository.StatsCache) context.Context {
+	return context.WithValue(ctx, statsCacheKey{}, cache)
+}
+
+// readGroupKey is the context key for the shared (singleflight) reads
+type readGroupKey struct{}
+
+// WithReadGroup makes identical list queries running at the same time share
+// one database query (see repository.ReadGroup)
+// app.New adds it to every request
+func WithReadGroup(ctx context.Context, reads *repository.ReadGroup) context.Context {
+	return context.WithValue(ctx, readGroupKey{}, reads)
+}
+
+// basePathKey is the context key for the prefix the API is mounted under
+type basePathKey struct{}
+
+// WithBasePath tells handlers called with ctx that the API lives under
+// basePath (e.g. "/api"), so the URLs they hand out (Location) include it
+// app.New adds it to every request when BASE_PATH is set
+func WithBasePath(ctx context.Context, basePath string) context.Context {
+	return context.WithValue(ctx, basePathKey{}, basePath)
+}
+
+// taskLocation is the URL of a task, for the Location header
+// e.g. /api/tasks/6900d436e231fdbb964c3c1c under BASE_PATH=/api
+func taskLocation(ctx context.Context, id string) string {
+	basePath, _ := ctx.Value(basePathKey{}).(string)
+	return basePath + "/tasks/" + id
+}
+
+// sandboxesKey is the context key for where sandbox keys keep their tasks
+type sandboxesKey struct{}
+
+// WithSandboxes makes sandbox API keys calling handlers with ctx use
+// sandboxes instead of their MongoDB collections
+func WithSandboxes(ctx context.Context, sandboxes repository.Sandboxes) context.Context {
+	return context.WithValue(ctx, sandboxesKey{}, sandboxes)
+}
+
+// sandboxRepository returns the caller's sandbox, or nil when the caller
+// isn't using a sandbox key
+func sandboxRepository(ctx context.Context) repository.SandboxRepository {
+	p, ok := middleware.GetPrincipal(ctx)
+	if !ok || !p.Sandbox {
+		return nil
+	}
+	sandboxes, ok := ctx.Value(sandboxesKey{}).(repository.Sandboxes)
+	if !ok {
+		sandboxes = repository.NewMongoSandboxes(database.GetCollection().Database())
+	}
+	return sandboxes.For(p.KeyID)
+}
+
+// dryRunKey is the context key marking a dry run (see models.DryRun)
+type dryRunKey struct{}
+
+// withDryRun makes the task writes of handlers called with ctx stop short of
+// the database, when the input asked for it
+func withDryRun(ctx context.Context, input models.DryRun) context.Context {
+	if !input.IsDryRun() {
+		return ctx
+	}
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// isDryRun reports whether nothing may be written for this request
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// taskRepository returns the repository to use for this request
+// The MongoDB repository is created on each call because the collection only
+// exists once the database is connected (which Lambda does lazily)
+func taskRepository(ctx context.Context) repository.TaskRepository {
+	// Sandboxes skip the shared reads and the cache: both are keyed by the
+	// query alone, so they'd hand one sandbox's list to another
+	if sandbox := sandboxRepository(ctx); sandbox != nil {
+		return dryRunRepository(ctx, sandbox)
+	}
+	repo, ok := ctx.Value(taskRepoKey{}).(repository.TaskRepository)
+	if !ok {
+		repo = repository.NewMongoTaskRepository(database.GetCollection())
+	}
+	// Writes that reach the store publish the task events (see internal/events)
+	repo = events.Default().Wrap(repo)
+	// The cache goes in front, so only its misses reach the shared reads
+	if reads, ok := ctx.Value(readGroupKey{}).(*repository.ReadGroup); ok {
+		repo = reads.Wrap(repo)
+	}
+	if cache, ok := ctx.Value(taskCacheKey{}).(*repository.TaskCache); ok {
+		repo = cache.Wrap(repo)
+	}
+	if cache, ok := ctx.Value(statsCacheKey{}).(*repository.StatsCache); ok {
+		repo = cache.Wrap(repo)
+	}
+	return dryRunRepository(ctx, repo)
+}
+
+// dryRunRepository stops repo's writes on a dry run
+func dryRunRepository(ctx context.Context, repo repository.TaskRepository) repository.TaskRepository {
+	if isDryRun(ctx) {
+		return repository.DryRun(repo)
+	}
+	return repo
+}
+
+// ============================================================================
+// HTTP CACHING FOR THE LIST
+// ============================================================================
+// listCacheControl lets browsers and proxies keep the list, but only for this
+// client (private: it's behind an API key) and only after checking it's
+// still current (no-cache = revalidate with If-None-Match every time)
+const listCacheControl = "private, no-cache"
+
+// listETag names one version of one filtered list, e.g. "42-all", "42-true",
+// or "42-all+description" when the list has the descriptions too, and
+// "42-all@0:50" for a page of it
+func listETag(version int64, completed *bool, fields repository.Fields, offset, limit int) string {
+	filter := "all"
+	if completed != nil {
+		filter = strconv.FormatBool(*completed)
+	}
+	if fields.Description {
+		filter += "+description"
+	}
+	filter += pageKey(offset, limit)
+	return `"` + strconv.FormatInt(version, 10) + "-" + filter + `"`
+}
+
+// listFields turns ?include=description into the fields to ask the
+// repository for (Huma has already rejected unknown names)
+func listFields(include []string) repository.Fields {
+	return repository.Fields{Description: slices.Contains(include, "description")}
+}
+
+// etagMatches reports whether an If-None-Match header names etag
+// The header may list several ETags, weak ones (W/"...") or "*"
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
 // ============================================================================
 // GET ALL TASKS - LIST OPERATION (WITH FILTERING)
 // ============================================================================
@@ -40,9 +228,10 @@ import (
 // - Output: *models.GetTasksOutput (contains array of tasks) + error
 //
 // Example requests:
-// GET /tasks                    → Returns all tasks
+// GET /tasks                    → Returns all tasks (id, title, completed)
 // GET /tasks?completed=true     → Returns only completed tasks
 // GET /tasks?completed=false    → Returns only incomplete tasks
+// GET /tasks?include=description → Returns all tasks with their descriptions
 func GetAllTasks(ctx context.Context, input *models.GetTasksInput) (*models.GetTasksOutput, error) {
 	// ----------------------------------------------------------------------------
 	// STEP 1: CREATE A TRACER
@@ -63,141 +252,375 @@ func GetAllTasks(ctx context.Context, input *models.GetTasksInput) (*models.GetT
 	// ----------------------------------------------------------------------------
 	// STEP 3: BUILD FILTER AND ADD ATTRIBUTES
 	// ----------------------------------------------------------------------------
-	// Build the MongoDB filter
+	// Build the filter: nil = all tasks, otherwise only completed/incomplete ones
 	// SetAttributes adds metadata to the span
-	filter := bson.M{}
-	switch input.Completed {
-	case "true":
-		filter["completed"] = true
-		handlerSpan.SetAttributes(attribute.String("filter.completed", input.Completed))
-	case "false":
-		filter["completed"] = false
-		handlerSpan.SetAttributes(attribute.String("filter.completed", input.Completed))
-	}
-
-	// ----------------------------------------------------------------------------
-	// STEP 4: CREATE DATABASE SPAN
-	// ----------------------------------------------------------------------------
-	// Create a child span for the database query
-	collection := database.GetCollection()
-	ctx, dbSpan := tracer.Start(ctx, "MongoDB.Find")
-	dbSpan.SetAttributes( // adds 3 tags: the database type, which collection and what operation
-		attribute.String("db.system", "mongodb"),
-		attribute.String("db.collection", "tasks"),
-		attribute.String("db.operation", "find"),
-	)
+	completed := input.Completed.Ptr()
+	if completed != nil {
+		handlerSpan.SetAttributes(attribute.Bool("filter.completed", *completed))
+	}
+
+	// Lists carry only the summary fields unless the client asks for more
+	// (see repository.Fields), so mobile clients download less
+	fields := listFields(input.Include)
+	handlerSpan.SetAttributes(attribute.Bool("fields.description", fields.Description))
+
+	// Mobile clients that don't ask for a page get a short one (see pages.go)
+	offset, limit := input.Offset, pageLimit(ctx, input.Limit, input.Mobile)
+	handlerSpan.SetAttributes(attribute.Int("page.offset", offset), attribute.Int("page.limit", limit))
+
+	// ----------------------------------------------------------------------------
+	// STEP 4: GET THE REPOSITORY
+	// ----------------------------------------------------------------------------
+	// The database span itself is created by the MongoDB command monitor
+	// (internal/database/monitor.go) as a child of the span in ctx.
+	// ctx also carries the request deadline (see middleware/timeout.go),
+	// so the query is cancelled if the request takes too long
+	repo := taskRepository(ctx)
 
-	// Create database timeout context from the SPAN context
-	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second) // Use span's ctx
-	defer cancel()
+	// ?due= lists depend on the clock as well as on the tasks, so they get
+	// no ETag (see dueTasks)
+	if input.Due != "" {
+		handlerSpan.SetAttributes(attribute.String("filter.due", input.Due))
+		return dueTasks(ctx, repo, input.Due, fields, offset, limit)
+	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 5: EXECUTE QUERY AND END SPAN
+	// STEP 5: CHECK THE CLIENT'S COPY (ETAG)
 	// ----------------------------------------------------------------------------
-	cursor, err := collection.Find(dbCtx, filter)
-	dbSpan.End() // Stop the database timer immediately. We manually end it here (not defer) because we want precise timing.
+	// The ETag names this version of the list. A client that sends it back in
+	// If-None-Match gets 304 Not Modified (no body) while nothing changed,
+	// which costs one tiny read instead of the whole list
+	version, err := repo.Version(ctx)
+	if err != nil {
+		handlerSpan.RecordError(err)
+		logger.WithTrace(ctx).Error("Failed to read tasks version", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to fetch tasks from the database", err)
+	}
+	etag := listETag(version, completed, fields, offset, limit)
+	if etagMatches(input.IfNoneMatch, etag) {
+		handlerSpan.SetAttributes(attribute.Bool("cache.not_modified", true))
+		return nil, huma.ErrorWithHeaders(huma.Status304NotModified(), http.Header{
+			"ETag":          {etag},
+			"Cache-Control": {listCacheControl},
+			"Vary":          {mobileHintHeader},
+			"Accept-Ch":     {mobileHintHeader},
+		})
+	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 6: RECORD ERRORS
+	// STEP 6: EXECUTE QUERY
+	// ----------------------------------------------------------------------------
+	// Only the page is read (see pages.go)
+	page := repository.Page{Offset: offset, Limit: limit}
+	tasks, err := repo.List(ctx, completed, fields, page)
+
+	// ----------------------------------------------------------------------------
+	// STEP 7: RECORD ERRORS
 	// ----------------------------------------------------------------------------
 	// If there's an error, RecordError() marks the span as failed.
 	// The span will show up red in Jaeger and an error message is attached to the span.
 	if err != nil {
 		handlerSpan.RecordError(err) // Record error on span
-		return nil, huma.Error500InternalServerError("Failed to fetch tasks from the database")
-	}
-	defer cursor.Close(dbCtx)
-
-	// Decode results
-	var tasks []models.Task
-	if err = cursor.All(dbCtx, &tasks); err != nil {
-		handlerSpan.RecordError(err)
-		return nil, huma.Error500InternalServerError("Failed to decode tasks")
+		return nil, listError(ctx, err, "Failed to fetch tasks")
 	}
 
+	// An empty list is returned as [] rather than null
 	if tasks == nil {
 		tasks = []models.Task{}
 	}
+	total, err := listTotal(ctx, tasks, page, func(ctx context.Context) (int64, error) {
+		return repo.Count(ctx, completed)
+	})
+	if err != nil {
+		handlerSpan.RecordError(err)
+		return nil, listError(ctx, err, "Failed to count tasks")
+	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 7: ADD RESULT METRICS
+	// STEP 8: ADD RESULT METRICS
 	// ----------------------------------------------------------------------------
 	// Add result count to span
 	handlerSpan.SetAttributes(attribute.Int("result.count", len(tasks)))
 
 	// Log with trace context for correlation in Grafana
 	log := logger.WithTrace(ctx)
-	if input.Completed != "" {
+	if completed != nil {
 		log.Info("Retrieved tasks from MongoDB",
 			slog.Int("count", len(tasks)),
-			slog.String("filter", input.Completed))
+			slog.Bool("filter", *completed))
 	} else {
 		log.Info("Retrieved tasks from MongoDB",
 			slog.Int("count", len(tasks)))
 	}
 
-	return &models.GetTasksOutput{Body: tasks}, nil
+	return &models.GetTasksOutput{
+		ETag: etag, CacheControl: listCacheControl, TotalCount: total,
+		Vary: []string{mobileHintHeader}, AcceptCH: mobileHintHeader, Body: tasks,
+	}, nil
+}
+
+// listError answers a list that failed: 422 when it matched more tasks than
+// a list may return, 504 when MongoDB stopped the query (see
+// repository.Limits), otherwise what problem says. The first two say what
+// to do instead.
+func listError(ctx context.Context, err error, message string) error {
+	switch {
+	case errors.Is(err, repository.ErrTooManyResults):
+		logger.WithTrace(ctx).Warn(message+": too many results", slog.Any("error", err))
+		return huma.Error422UnprocessableEntity(fmt.Sprintf(
+			"More than %d tasks match. Page through them with ?limit= and ?offset=, narrow the list with ?completed= or ?due=, or download every task from GET /export",
+			repository.DefaultLimits().MaxResults))
+	case errors.Is(err, repository.ErrQueryTimeout):
+		logger.WithTrace(ctx).Warn(message+": query took too long", slog.Any("error", err))
+		return huma.Error504GatewayTimeout(fmt.Sprintf(
+			"The database stopped this query after %s. Narrow the list with ?completed= or ?due=, or download every task from GET /export",
+			repository.DefaultLimits().MaxTime))
+	default:
+		return problem(ctx, err, message)
+	}
+}
+
+// dueTasks handles GET /tasks?due=today and ?due=overdue: the open tasks due
+// later today or already past due, soonest first, paged like the rest
+// "Today" is the caller's today (see callerLocation), from midnight to midnight.
+func dueTasks(ctx context.Context, repo repository.TaskRepository, due string, fields repository.Fields, offset, limit int) (*models.GetTasksOutput, error) {
+	loc, err := callerLocation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+
+	var from, to time.Time // A zero from = no lower bound
+	switch due {
+	case "today":
+		from = duedate.StartOfDay(now)
+		to = from.AddDate(0, 0, 1)
+	default: // "overdue" (the enum allows nothing else)
+		to = now
+	}
+
+	page := repository.Page{Offset: offset, Limit: limit}
+	tasks, err := repo.ListDue(ctx, from, to, fields, page)
+	if err != nil {
+		return nil, listError(ctx, err, "Failed to fetch due tasks")
+	}
+	total, err := listTotal(ctx, tasks, page, func(ctx context.Context) (int64, error) {
+		return repo.CountDue(ctx, from, to)
+	})
+	if err != nil {
+		return nil, listError(ctx, err, "Failed to count due tasks")
+	}
+	logger.WithTrace(ctx).Info("Retrieved due tasks", slog.String("due", due), slog.Int("count", len(tasks)))
+	return &models.GetTasksOutput{
+		CacheControl: listCacheControl, TotalCount: total,
+		Vary: []string{mobileHintHeader}, AcceptCH: mobileHintHeader, Body: tasks,
+	}, nil
 }
 
 // ============================================================================
-// GET TASK BY ID - SPECIFIC TASK FILTERING
+// EXPORT (NDJSON OR CSV) - ALL TASKS, ONE LINE AT A TIME
 // ============================================================================
+// GET /export writes one task per line (newline-delimited JSON, NDJSON) as
+// they come out of the MongoDB cursor, instead of loading every task into a
+// []Task and encoding it at the end like GetAllTasks does. Memory stays flat
+// for any number of tasks, and the client can start reading straight away:
+//
+//	curl -H "X-API-Key: $API_KEY" localhost:8080/export > tasks.ndjson
+//	{"id":"6900d436e231fdbb964c3c1c","title":"Buy milk",...}
+//	{"id":"6900d436e231fdbb964c3c1d","title":"Walk the dog",...}
+//
+// ?format=csv writes the same tasks as CSV rows instead (see export.go).
+//
+// The route gets a longer deadline and no response buffering (see
+// middleware.TimeoutExcept and app.New).
 
-func GetTaskByID(ctx context.Context, input *models.GetTaskInput) (*models.GetTaskOutput, error) {
+// NDJSONContentType is the media type of the NDJSON export
+const NDJSONContentType = "application/x-ndjson"
+
+// exportFlushEvery is how many tasks are written between flushes
+// Flushing every line would mean a network write per task
+const exportFlushEvery = 100
+
+// ExportTasks streams every task (or only completed/incomplete ones) as NDJSON,
+// or as CSV with ?format=csv (see export.go)
+// Once the first line is sent the status is 200 and can't change, so an error
+// while reading ends the stream early: the client sees a last line that is
+// missing or cut off, and the error is logged and recorded on the span.
+func ExportTasks(ctx context.Context, input *models.ExportTasksInput) (*huma.StreamResponse, error) {
 	// ----------------------------------------------------------------------------
-	// STEP 1: CONVERT STRING ID TO MONGODB OBJECTID
+	// STEP 1: CREATE THE SPAN
 	// ----------------------------------------------------------------------------
-	// The ID comes from the URL as a string like "6900d436e231fdbb964c3c1c"
-	// MongoDB needs this as an ObjectID type, so we convert it
-	// primitive.ObjectIDFromHex() converts 24-character hex string → ObjectID
-	objectID, err := primitive.ObjectIDFromHex(input.ID)
+	// The span is ended by the stream, not by this function, so it covers the
+	// whole export rather than just starting the query
+	ctx, span := otel.Tracer("handlers").Start(ctx, "ExportTasks")
+
+	completed := input.Completed.Ptr()
+	if completed != nil {
+		span.SetAttributes(attribute.Bool("filter.completed", *completed))
+	}
+
+	// ----------------------------------------------------------------------------
+	// STEP 2: START THE QUERY
+	// ----------------------------------------------------------------------------
+	// Errors up to here (e.g. the database is down) still get a proper 500
+	tasks, err := taskRepository(ctx).Stream(ctx, completed)
 	if err != nil {
-		// If the ID is not a valid 24-character hex string, return HTTP 400 error
-		// Example invalid IDs: "123", "abc", "6900d436e231fdbb964c3c1" (too short)
-		return nil, huma.Error400BadRequest("Invalid task ID format")
+		span.RecordError(err)
+		span.End()
+		logger.WithTrace(ctx).Error("Failed to start task export", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to export tasks", err)
 	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 2: CREATE DATABASE CONTEXT WITH TIMEOUT
+	// STEP 3: WORK OUT HOW TO WRITE THEM
 	// ----------------------------------------------------------------------------
-	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	contentType, filename := NDJSONContentType, "tasks.ndjson"
+	newWriter := func(w io.Writer) (exportWriter, error) { return newNDJSONExport(w), nil }
+	var language string
+	if input.Format == "csv" {
+		formats, loc, err := callerFormats(ctx, input.AcceptLanguage)
+		if err != nil {
+			span.RecordError(err)
+			span.End()
+			return nil, err
+		}
+		contentType, filename, language = CSVContentType, "tasks.csv", formats.String()
+		newWriter = func(w io.Writer) (exportWriter, error) { return newCSVExport(w, formats, loc) }
+	}
+	span.SetAttributes(attribute.String("export.format", input.Format))
 
 	// ----------------------------------------------------------------------------
-	// STEP 3: QUERY DATABASE FOR THE SPECIFIC TASK
+	// STEP 4: STREAM ONE TASK PER LINE
 	// ----------------------------------------------------------------------------
-	// Create a variable to hold the result
-	var task models.Task
+	return &huma.StreamResponse{Body: func(hctx huma.Context) {
+		defer span.End()
 
-	// Get the collection and find one document that matches the ID
-	collection := database.GetCollection()
-	// bson.M{"_id": objectID} = filter that matches documents where _id field equals objectID
-	// This is like: SELECT * FROM tasks WHERE _id = objectID (in SQL)
-	// .Decode(&task) = put the result into our task variable
-	err = collection.FindOne(dbCtx, bson.M{"_id": objectID}).Decode(&task)
+		hctx.SetHeader("Content-Type", contentType)
+		hctx.SetHeader("Content-Disposition", `attachment; filename="`+filename+`"`)
+		if language != "" {
+			hctx.SetHeader("Content-Language", language)
+		}
+		hctx.SetStatus(http.StatusOK)
+
+		w := hctx.BodyWriter()
+		flusher, _ := w.(http.Flusher)
+		out, err := newWriter(w)
+		if err != nil {
+			logger.WithTrace(ctx).Warn("Task export not started: client stopped reading", slog.Any("error", err))
+			return
+		}
+		flush := func() error {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		}
+
+		count := 0
+		for task, err := range tasks {
+			if err != nil {
+				span.RecordError(err)
+				logger.WithTrace(ctx).Error("Task export failed part way",
+					slog.Int("count", count), slog.Any("error", err))
+				_ = flush() // Still send the tasks already written
+				return
+			}
+			if err := out.Write(task); err != nil {
+				// The client went away - nobody is left to tell
+				logger.WithTrace(ctx).Warn("Task export not finished: client stopped reading",
+					slog.Int("count", count), slog.Any("error", err))
+				return
+			}
+			count++
+			if count%exportFlushEvery == 0 {
+				if err := flush(); err != nil {
+					logger.WithTrace(ctx).Warn("Task export not finished: client stopped reading",
+						slog.Int("count", count), slog.Any("error", err))
+					return
+				}
+			}
+		}
+		if err := flush(); err != nil {
+			logger.WithTrace(ctx).Warn("Task export not finished: client stopped reading",
+				slog.Int("count", count), slog.Any("error", err))
+			return
+		}
+
+		span.SetAttributes(attribute.Int("result.count", count))
+		logger.WithTrace(ctx).Info("Exported tasks", slog.Int("count", count))
+	}}, nil
+}
+
+// ============================================================================
+// TASK COUNTS - SIDEBAR BADGES AND DASHBOARDS
+// ============================================================================
+
+// GetStats returns how many tasks are open and completed
+// The counts are kept up to date by stats.Counter, so this is one small read
+// however many tasks there are (see repository.MongoTaskRepository.Stats),
+// and repository.StatsCache makes it one read per STATS_CACHE_TTL however
+// many dashboards ask
+func GetStats(ctx context.Context, input *struct{}) (*models.GetStatsOutput, error) {
+	stats, err := taskRepository(ctx).Stats(ctx)
+	if errors.Is(err, repository.ErrQueryTimeout) {
+		logger.WithTrace(ctx).Warn("Counting the tasks took too long", slog.Any("error", err))
+		return nil, huma.Error504GatewayTimeout("Counting the tasks took too long. Try again in a few seconds: the counts are kept up to date in the background")
+	}
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to read task counts", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to read task counts", err)
+	}
+	return &models.GetStatsOutput{Body: stats}, nil
+}
 
+// ============================================================================
+// GET TASK BY ID - SPECIFIC TASK FILTERING
+// ============================================================================
+
+func GetTaskByID(ctx context.Context, input *models.GetTaskInput) (*models.GetTaskOutput, error) {
 	// ----------------------------------------------------------------------------
-	// STEP 4: HANDLE ERRORS
+	// STEP 1: THE TASK ID
+	// ----------------------------------------------------------------------------
+	// The ID comes from the URL as a string like "6900d436e231fdbb964c3c1c"
+	// (or a UUID). Huma has already parsed it into a models.TaskID (see
+	// models/taskid.go), or answered HTTP 400 for one that isn't an ID
+	id := input.ID
+
+	// ----------------------------------------------------------------------------
+	// STEP 2: QUERY DATABASE FOR THE SPECIFIC TASK
+	// ----------------------------------------------------------------------------
+	// Get returns the task with this ID
+	// This is like: SELECT * FROM tasks WHERE _id = id (in SQL)
+	task, err := taskRepository(ctx).Get(ctx, id)
+
+	// ----------------------------------------------------------------------------
+	// STEP 3: HANDLE ERRORS
 	// ----------------------------------------------------------------------------
 	if err != nil {
-		// Check if the error is "no documents found"
-		if err == mongo.ErrNoDocuments {
-			// Task with this ID doesn't exist → return HTTP 404 error
-			return nil, huma.Error404NotFound("Task not found")
-		}
-		// Any other error (database connection issue, etc.) → HTTP 500 error
-		return nil, huma.Error500InternalServerError("Failed to fetch task")
+		// No task with this ID → HTTP 404 (repository.ErrNotFound is a
+		// "not found" domain error); anything else (database connection
+		// issue, etc.) → HTTP 500. problem() decides, see errors.go
+		return nil, problem(ctx, err, "Failed to fetch task", slog.String("id", input.ID.String()))
 	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 5: LOG SUCCESS AND RETURN RESULT
+	// STEP 4: LOG SUCCESS AND RETURN RESULT
 	// ----------------------------------------------------------------------------
-	// .Hex() converts ObjectID back to string for logging
 	logger.WithTrace(ctx).Info("Retrieved task by ID",
-		slog.String("id", objectID.Hex()))
+		slog.String("id", id.String()))
+
+	// Descriptions are Markdown; clients that would rather not render it
+	// themselves (and get escaping wrong) ask for the HTML
+	if input.Render == "html" {
+		task.DescriptionHTML = markdown.HTML(task.Description)
+	}
+	task.LinkPreviews = linkPreviews(ctx, task.Description)
 
 	// Return the output struct with the task we found
-	return &models.GetTaskOutput{Body: task}, nil
+	return &models.GetTaskOutput{Body: *task}, nil
 }
 
 // ============================================================================
@@ -210,13 +633,28 @@ func GetTaskByID(ctx context.Context, input *models.GetTaskInput) (*models.GetTa
 // - Input: context.Context + *models.CreateTaskInput (contains title & description)
 // - Output: *models.CreateTaskOutput (contains newly created task with ID) + error
 //
+// The response is 201 Created with a Location header pointing at the new task,
+// so clients can follow it instead of building the URL themselves.
+//
 // Example request:  POST /tasks with body: {"title": "Buy milk", "description": "From the store"}
-// Example response: {"id": "6900d436e231fdbb964c3c1c", "title": "Buy milk", "description": "From the store", "completed": false}
+// Example response: 201, Location: /tasks/6900d436e231fdbb964c3c1c
+//
+//	{"id": "6900d436e231fdbb964c3c1c", "title": "Buy milk", "description": "From the store", "completed": false}
 func CreateTask(ctx context.Context, input *models.CreateTaskInput) (*models.CreateTaskOutput, error) {
 	// Create tracer and handler span
 	tracer := otel.Tracer("handlers")
 	ctx, handlerSpan := tracer.Start(ctx, "CreateTask")
 	defer handlerSpan.End()
+	ctx = withDryRun(ctx, input.DryRun)
+
+	// ----------------------------------------------------------------------------
+	// STEP 0: CHECK THE CALLER'S TASK QUOTA
+	// ----------------------------------------------------------------------------
+	// A user who already has QUOTA_MAX_TASKS tasks gets 403 (see usage.go)
+	caller, _ := middleware.GetPrincipal(ctx)
+	if err := checkTaskQuota(ctx, caller); err != nil {
+		return nil, problem(ctx, err, "Failed to check the task quota")
+	}
 
 	// ----------------------------------------------------------------------------
 	// STEP 1: CREATE NEW TASK STRUCT FROM INPUT
@@ -228,6 +666,16 @@ func CreateTask(ctx context.Context, input *models.CreateTaskInput) (*models.Cre
 		Title:       input.Body.Title,       // From request body
 		Description: input.Body.Description, // From request body (can be empty)
 		Completed:   false,                  // Always starts as not completed
+		OwnerID:     caller.UserID,          // Counted against the caller's quota
+	}
+
+	// An optional due date, read in the caller's time zone (see profile.go)
+	if input.Body.Due != "" {
+		due, err := parseDue(ctx, input.Body.Due)
+		if err != nil {
+			return nil, err
+		}
+		newTask.DueAt = &due
 	}
 
 	// Add task attributes to span
@@ -236,62 +684,68 @@ func CreateTask(ctx context.Context, input *models.CreateTaskInput) (*models.Cre
 		attribute.Bool("task.completed", false),
 	)
 
-	// ----------------------------------------------------------------------------
-	// STEP 2: CREATE DATABASE CONTEXT WITH TIMEOUT
-	// ----------------------------------------------------------------------------
-	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	// Deployment hooks may adjust the task or refuse it (see internal/hooks)
+	if err := hooks.Default().BeforeCreate(ctx, &newTask); err != nil {
+		return nil, hookError(ctx, err)
+	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 3: INSERT THE NEW TASK INTO MONGODB
+	// STEP 2: INSERT THE NEW TASK INTO THE DATABASE
 	// ----------------------------------------------------------------------------
-	// Create database span
-	_, dbSpan := tracer.Start(ctx, "MongoDB.InsertOne")
-	dbSpan.SetAttributes(
-		attribute.String("db.system", "mongodb"),
-		attribute.String("db.collection", "tasks"),
-	)
-
-	collection := database.GetCollection()
-	// InsertOne() adds the newTask to the database
-	// It returns:
-	//   - result.InsertedID = the auto-generated MongoDB ID for this document
-	//   - err = any error that occurred during insertion
-	result, err := collection.InsertOne(dbCtx, newTask)
+	// Create() adds the newTask to the database and sets newTask.ID to the
+	// ID MongoDB generated for it (that's why we pass a pointer: &newTask)
+	err := taskRepository(ctx).Create(ctx, &newTask)
 
 	// Error recorded and will be visible in Jaeger
 	if err != nil {
 		handlerSpan.RecordError(err)
-		dbSpan.End()
-		// If insertion fails (database down, disk full, etc.) → HTTP 500 error
-		return nil, huma.Error500InternalServerError("Failed to create task in database")
+		// A unique index rejected the task (repository.ErrDuplicate) → HTTP
+		// 409 Conflict; database down, disk full, etc. → HTTP 500 error
+		return nil, problem(ctx, err, "Failed to create task in database")
 	}
-	// End the span once the task has been added to the db
-	dbSpan.End()
 
 	// ----------------------------------------------------------------------------
-	// STEP 4: SET THE AUTO-GENERATED ID ON OUR TASK
+	// STEP 3: RECORD THE AUTO-GENERATED ID
 	// ----------------------------------------------------------------------------
-	// MongoDB generated an ID and put it in result.InsertedID
-	// result.InsertedID is type interface{}, so we need to convert it
-	// .(primitive.ObjectID) = type assertion (like casting in other languages)
-	// This says: "I know this is an ObjectID, treat it as one"
-	newTask.ID = result.InsertedID.(primitive.ObjectID)
-
 	// Record the generated ID in the span
-	handlerSpan.SetAttributes(attribute.String("task.id", newTask.ID.Hex()))
+	handlerSpan.SetAttributes(attribute.String("task.id", newTask.ID.String()))
 
 	// ----------------------------------------------------------------------------
-	// STEP 5: LOG SUCCESS AND RETURN THE NEW TASK
+	// STEP 4: LOG SUCCESS AND RETURN THE NEW TASK
 	// ----------------------------------------------------------------------------
 	// Structured logging
 	logger.WithTrace(ctx).Info("Created new task",
 		slog.String("title", newTask.Title),
-		slog.String("id", newTask.ID.Hex()))
+		slog.String("id", newTask.ID.String()),
+		slog.Bool("dry_run", isDryRun(ctx)))
+
+	queueLinkPreviews(ctx, newTask.Description)
+
+	// A dry run answers 200 with the task it would have created: there's
+	// nothing at its URL
+	if isDryRun(ctx) {
+		return &models.CreateTaskOutput{Status: http.StatusOK, Body: newTask}, nil
+	}
 
-	// Return the complete task (now with its ID) to the client
-	// HTTP status will be 201 Created (set in main.go with DefaultStatus)
-	return &models.CreateTaskOutput{Body: newTask}, nil
+	// Return the complete task (now with its ID) to the client, and where it lives
+	// with 201 Created (Status overrides the DefaultStatus set in routes.go)
+	return &models.CreateTaskOutput{Status: http.StatusCreated, Location: taskLocation(ctx, newTask.ID.String()), Body: newTask}, nil
+}
+
+// hookError turns a BeforeCreate hook's error into a response: a Rejection
+// is the client's problem (422), anything else is ours (500)
+func hookError(ctx context.Context, err error) error {
+	var rejection *hooks.Rejection
+	if errors.As(err, &rejection) {
+		location := "body"
+		if rejection.Field != "" {
+			location += "." + rejection.Field
+		}
+		return huma.Error422UnprocessableEntity("Task rejected",
+			&huma.ErrorDetail{Location: location, Message: rejection.Message})
+	}
+	logger.WithTrace(ctx).Error("Task hook failed", slog.Any("error", err))
+	return huma.Error500InternalServerError("Failed to create task", err)
 }
 
 // ============================================================================
@@ -316,128 +770,108 @@ func UpdateTask(ctx context.Context, input *models.UpdateTaskInput) (*models.Upd
 	tracer := otel.Tracer("handlers")
 	ctx, handlerSpan := tracer.Start(ctx, "UpdateTask")
 	defer handlerSpan.End()
+	ctx = withDryRun(ctx, input.DryRun)
 
 	// Add task ID to span attributes
-	handlerSpan.SetAttributes(attribute.String("task.id", input.ID))
+	handlerSpan.SetAttributes(attribute.String("task.id", input.ID.String()))
 
 	// ----------------------------------------------------------------------------
-	// STEP 1: CONVERT STRING ID TO MONGODB OBJECTID
+	// STEP 1: THE TASK ID (parsed by Huma, see GetTaskByID)
 	// ----------------------------------------------------------------------------
-	objectID, err := primitive.ObjectIDFromHex(input.ID)
-	if err != nil {
-		return nil, huma.Error400BadRequest("Invalid task ID format")
-	}
+	id := input.ID
 
 	// ----------------------------------------------------------------------------
-	// STEP 2: CREATE DATABASE CONTEXT WITH TIMEOUT
+	// STEP 2: COLLECT ONLY THE PROVIDED FIELDS
 	// ----------------------------------------------------------------------------
-	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	collection := database.GetCollection()
-
-	// ----------------------------------------------------------------------------
-	// STEP 3: CHECK IF TASK EXISTS (OPTIONAL BUT GOOD PRACTICE)
-	// ----------------------------------------------------------------------------
-	// Create span for FindOne operation
-	_, findSpan := tracer.Start(ctx, "MongoDB.FindONe")
-	findSpan.SetAttributes(
-		attribute.String("db.system", "mongodb"),
-		attribute.String("db.collection", "tasks"),
-		attribute.String("db.operation", "findOne"),
-	)
-
-	// Find the existing task first to verify it exists
-	// This gives us a better error message if the task doesn't exist
-	var existingTask models.Task
-	err = collection.FindOne(dbCtx, bson.M{"_id": objectID}).Decode(&existingTask)
-	if err != nil {
-		findSpan.End()
-		handlerSpan.RecordError(err)
-		if err == mongo.ErrNoDocuments {
-			return nil, huma.Error404NotFound("Task not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to fetch task")
-	}
-
-	findSpan.End()
-
-	// ----------------------------------------------------------------------------
-	// STEP 4: BUILD UPDATE DOCUMENT WITH ONLY PROVIDED FIELDS
-	// ----------------------------------------------------------------------------
-	// MongoDB update format: { "$set": { "field1": "value1", "field2": "value2" } }
-	// $set = MongoDB operator that updates specific fields without replacing entire document
-	update := bson.M{"$set": bson.M{}} // Create empty update document
-
-	// Check each field to see if it was provided in the request
 	// Remember: input.Body.Title is a *string (pointer)
 	// If pointer is nil, field was not sent in request
 	// If pointer is not nil, field was sent (even if empty string)
-
-	if input.Body.Title != nil {
-		// *input.Body.Title = dereference the pointer to get actual string value
-		update["$set"].(bson.M)["title"] = *input.Body.Title
-	}
-	if input.Body.Description != nil {
-		update["$set"].(bson.M)["description"] = *input.Body.Description
+	// The repository only changes the fields that are not nil
+	changes := repository.TaskChanges{
+		Title:       input.Body.Title,
+		Description: input.Body.Description,
+		Completed:   input.Body.Completed,
 	}
-	if input.Body.Completed != nil {
-		// *input.Body.Completed = dereference the pointer to get actual bool value
-		update["$set"].(bson.M)["completed"] = *input.Body.Completed
+	// due: "" removes the due date, anything else is parsed like on create
+	if input.Body.Due != nil {
+		if *input.Body.Due == "" {
+			changes.ClearDueAt = true
+		} else {
+			due, err := parseDue(ctx, *input.Body.Due)
+			if err != nil {
+				return nil, err
+			}
+			changes.DueAt = &due
+		}
 	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 5: VALIDATE THAT AT LEAST ONE FIELD WAS PROVIDED
+	// STEP 3: VALIDATE THAT AT LEAST ONE FIELD WAS PROVIDED
 	// ----------------------------------------------------------------------------
 	// If client sent empty body {}, there's nothing to update
-	if len(update["$set"].(bson.M)) == 0 {
+	if changes.Empty() {
 		return nil, huma.Error400BadRequest("No fields to update")
 	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 6: PERFORM THE UPDATE IN MONGODB
+	// STEP 4: PERFORM THE UPDATE
 	// ----------------------------------------------------------------------------
-	// Create span for UpdateOne operation
-	_, updateSpan := tracer.Start(ctx, "MongoDB.UpdateOne")
-	updateSpan.SetAttributes(
-		attribute.String("db.system", "mongodb"),
-		attribute.String("db.collection", "tasks"),
-		attribute.String("db.operation", "updateOne"),
-	)
-
-	// UpdateOne(filter, update) updates the first document matching the filter
-	// Returns result with MatchedCount (how many docs matched) and ModifiedCount
-	result, err := collection.UpdateOne(dbCtx, bson.M{"_id": objectID}, update)
+	// Update() changes the fields and returns the complete, up-to-date task,
+	// so we don't need a second query to send it back to the client
+	updatedTask, err := taskRepository(ctx).Update(ctx, id, changes)
 	if err != nil {
-		updateSpan.End()
 		handlerSpan.RecordError(err)
-		return nil, huma.Error500InternalServerError("Failed to update task")
-	}
-	updateSpan.End()
-
-	// Add modified count to span
-	handlerSpan.SetAttributes(attribute.Int64("result.modifiedCount", result.ModifiedCount))
-
-	// Double-check that a document was actually matched (should always be 1)
-	if result.MatchedCount == 0 {
-		return nil, huma.Error404NotFound("Task not found")
+		return nil, problem(ctx, err, "Failed to update task", slog.String("id", input.ID.String()))
 	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 7: FETCH THE UPDATED TASK TO RETURN IT
+	// STEP 5: LOG SUCCESS AND RETURN UPDATED TASK
 	// ----------------------------------------------------------------------------
-	// After updating, get the latest version of the task from database
-	// This ensures we return the complete, up-to-date task to the client
-	var updatedTask models.Task
-	collection.FindOne(dbCtx, bson.M{"_id": objectID}).Decode(&updatedTask)
+	logger.WithTrace(ctx).Info("Updated task",
+		slog.String("id", id.String()), slog.Bool("dry_run", isDryRun(ctx)))
+	if changes.Description != nil {
+		queueLinkPreviews(ctx, updatedTask.Description)
+	}
+	return &models.UpdateTaskOutput{Body: *updatedTask}, nil
+}
+
+// ============================================================================
+// COMPLETE / REOPEN TASK
+// ============================================================================
+// CompleteTask marks a task as done: POST /tasks/{id}/complete
+// ReopenTask marks it as open again: POST /tasks/{id}/reopen
+//
+// Both are shortcuts for PUT /tasks/{id} with {"completed": true|false}, so
+// clients don't have to build a body for the most common change. The
+// repository stamps completed_at when an open task is completed (completing
+// it again keeps the first stamp) and clears it on reopen, and the
+// AfterUpdate hooks run just like after a PUT.
+func CompleteTask(ctx context.Context, input *models.TaskStateInput) (*models.TaskStateOutput, error) {
+	return setCompleted(withDryRun(ctx, input.DryRun), "CompleteTask", input.ID, true)
+}
+
+// ReopenTask marks a completed task as open again (see CompleteTask)
+func ReopenTask(ctx context.Context, input *models.TaskStateInput) (*models.TaskStateOutput, error) {
+	return setCompleted(withDryRun(ctx, input.DryRun), "ReopenTask", input.ID, false)
+}
+
+// setCompleted sets a task's completed flag and returns the saved task
+func setCompleted(ctx context.Context, operation string, id models.TaskID, completed bool) (*models.TaskStateOutput, error) {
+	tracer := otel.Tracer("handlers")
+	ctx, handlerSpan := tracer.Start(ctx, operation)
+	defer handlerSpan.End()
+
+	handlerSpan.SetAttributes(attribute.String("task.id", id.String()), attribute.Bool("task.completed", completed))
+
+	task, err := taskRepository(ctx).Update(ctx, id, repository.TaskChanges{Completed: &completed})
+	if err != nil {
+		handlerSpan.RecordError(err)
+		return nil, problem(ctx, err, "Failed to update task", slog.String("id", id.String()), slog.Bool("completed", completed))
+	}
 
-	// ----------------------------------------------------------------------------
-	// STEP 8: LOG SUCCESS AND RETURN UPDATED TASK
-	// ----------------------------------------------------------------------------
 	logger.WithTrace(ctx).Info("Updated task",
-		slog.String("id", objectID.Hex()),
-		slog.Int64("modified_count", result.ModifiedCount))
-	return &models.UpdateTaskOutput{Body: updatedTask}, nil
+		slog.String("id", id.String()), slog.Bool("completed", completed), slog.Bool("dry_run", isDryRun(ctx)))
+	return &models.TaskStateOutput{Body: *task}, nil
 }
 
 // ============================================================================
@@ -457,77 +891,54 @@ func DeleteTask(ctx context.Context, input *models.DeleteTaskInput) (*models.Del
 	tracer := otel.Tracer("handlers")
 	ctx, handlerSpan := tracer.Start(ctx, "DeleteTask")
 	defer handlerSpan.End()
+	ctx = withDryRun(ctx, input.DryRun)
 
 	// Add task ID to span attributes
-	handlerSpan.SetAttributes(attribute.String("task.id", input.ID))
+	handlerSpan.SetAttributes(attribute.String("task.id", input.ID.String()))
 
 	// ----------------------------------------------------------------------------
-	// STEP 1: CONVERT STRING ID TO MONGODB OBJECTID
+	// STEP 1: THE TASK ID (parsed by Huma, see GetTaskByID)
 	// ----------------------------------------------------------------------------
-	objectID, err := primitive.ObjectIDFromHex(input.ID)
-	if err != nil {
-		handlerSpan.RecordError(err)
-		return nil, huma.Error400BadRequest("Invalid task ID format")
-	}
+	id := input.ID
 
 	// ----------------------------------------------------------------------------
-	// STEP 2: CREATE DATABASE CONTEXT WITH TIMEOUT
+	// STEP 2: DELETE THE TASK FROM THE DATABASE
 	// ----------------------------------------------------------------------------
-	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	// Delete() removes the task with this ID
+	err := taskRepository(ctx).Delete(ctx, id)
 
 	// ----------------------------------------------------------------------------
-	// STEP 3: DELETE THE TASK FROM MONGODB
+	// STEP 3: CHECK IF TASK WAS ACTUALLY DELETED
 	// ----------------------------------------------------------------------------
-	// Create span for DeleteOne operation
-	_, deleteSpan := tracer.Start(ctx, "MongoDB.DeleteOne")
-	deleteSpan.SetAttributes(
-		attribute.String("db.system", "mongodb"),
-		attribute.String("db.collection", "tasks"),
-		attribute.String("db.operation", "deleteOne"),
-	)
-	collection := database.GetCollection()
-	// DeleteOne(filter) removes the first document that matches the filter
-	// Returns result with DeletedCount (how many documents were deleted)
-	// Should be either 0 (not found) or 1 (successfully deleted)
-	result, err := collection.DeleteOne(dbCtx, bson.M{"_id": objectID})
 	if err != nil {
-		deleteSpan.End()
 		handlerSpan.RecordError(err)
-		// Database error during deletion → HTTP 500 error
-		return nil, huma.Error500InternalServerError("Failed to delete task")
+		// ErrNotFound = no task with that ID existed → HTTP 404; a database
+		// error during deletion → HTTP 500
+		return nil, problem(ctx, err, "Failed to delete task", slog.String("id", input.ID.String()))
 	}
 
-	deleteSpan.End()
-
-	// Add deleted count to span
-	handlerSpan.SetAttributes(attribute.Int64("result.deletedCount", result.DeletedCount))
-
-	// ----------------------------------------------------------------------------
-	// STEP 4: CHECK IF TASK WAS ACTUALLY DELETED
-	// ----------------------------------------------------------------------------
-	// If DeletedCount is 0, no document with that ID existed
-	if result.DeletedCount == 0 {
-		return nil, huma.Error404NotFound("Task not found")
+	// Many deletions at once raise an alert (see internal/anomaly)
+	if detector := anomaly.Default(); detector != nil && !isDryRun(ctx) {
+		caller, _ := middleware.GetPrincipal(ctx)
+		detector.Deleted(caller.UsageKey())
 	}
 
 	// ----------------------------------------------------------------------------
-	// STEP 5: LOG SUCCESS AND RETURN CONFIRMATION
+	// STEP 4: LOG SUCCESS AND RETURN CONFIRMATION
 	// ----------------------------------------------------------------------------
 	logger.WithTrace(ctx).Info("Deleted task",
-		slog.String("id", objectID.Hex()),
-		slog.Int64("deleted_count", result.DeletedCount))
+		slog.String("id", id.String()), slog.Bool("dry_run", isDryRun(ctx)))
 
 	// Return a success message with the deleted task's ID
 	// This uses an anonymous struct (defined inline without a type name)
 	// The struct is defined in models.DeleteTaskOutput, but we create it here
 	return &models.DeleteTaskOutput{
 		Body: struct {
-			Message string `json:"message" doc:"Success message"`
-			ID      string `json:"id" doc:"Deleted task ID"`
+			Message string `json:"message" doc:"Success message" example:"Task deleted successfully"`
+			ID      string `json:"id" doc:"Deleted task ID" example:"6900d436e231fdbb964c3c1c"`
 		}{
 			Message: "Task deleted successfully", // Success message
-			ID:      input.ID,                    // Echo back the ID that was deleted
+			ID:      input.ID.String(),           // Echo back the ID that was deleted
 		},
 	}, nil
 }
@@ -538,11 +949,14 @@ func DeleteTask(ctx context.Context, input *models.DeleteTaskInput) (*models.Del
 //
 // Each handler follows the same pattern:
 // 1. Validate input (convert IDs, check formats)
-// 2. Create database context with timeout (prevents hanging)
-// 3. Perform database operation (Find, Insert, Update, Delete)
-// 4. Handle errors (404, 400, 500)
+// 2. Use the request context (it carries the request timeout)
+// 3. Call the task repository (List, Get, Create, Update, Delete)
+// 4. Handle errors (400, 404, 409, 500) - 500s are logged with the error
 // 5. Log success and return result
 //
+// All logging goes through logger.WithTrace(ctx) so every line carries the
+// trace_id/span_id of the request and can be opened from Grafana/Loki.
+//
 // Huma automatically:
 // - Validates request against struct tags (minLength, maxLength)
 // - Converts JSON request body to Input structs
@@ -553,6 +967,7 @@ func DeleteTask(ctx context.Context, input *models.DeleteTaskInput) (*models.Del
 // Error codes used:
 // - 400 Bad Request: Invalid input (bad ID format, validation failed)
 // - 404 Not Found: Task doesn't exist
+// - 409 Conflict: Task breaks a unique index
 // - 500 Internal Server Error: Database or server error
 //
 // ============================================================================
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/testutil"
+)
+
+// These benchmarks call the task handlers against the in-memory repository,
+// so they measure the handler's own cost (parsing, logging, building the
+// response) without MongoDB's network time. allocs/op is the number to watch:
+// it should only go up when a change means to do more work per request.
+//
+// Run with: make bench
+// or:       go test ./internal/handlers -run '^$' -bench . -benchmem -short
+//
+// Compare before and after a change with benchstat:
+//
+//	go test ./internal/handlers -run '^$' -bench . -benchmem -short -count 10 > old.txt
+//	(make the change)
+//	go test ./internal/handlers -run '^$' -bench . -benchmem -short -count 10 > new.txt
+//	benchstat old.txt new.txt
+
+// benchmarkTasks is how many tasks BenchmarkGetAllTasks lists
+const benchmarkTasks = 100
+
+// benchmarkContext returns a context whose handlers use a new in-memory
+// repository holding n tasks
+// The handlers still log every request, but to io.Discard: the cost of
+// building the log line is measured, the terminal isn't flooded.
+func benchmarkContext(b *testing.B, n int) context.Context {
+	b.Helper()
+
+	previous := logger.Log
+	logger.Log = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	b.Cleanup(func() { logger.Log = previous })
+
+	repo := repository.NewMemoryTaskRepository()
+	tasks := make([]models.Task, n)
+	for i := range tasks {
+		tasks[i] = testutil.NewTask(testutil.WithTitle(fmt.Sprintf("Task %d", i)))
+	}
+	testutil.CreateTasks(b, repo, tasks...)
+
+	return WithTaskRepository(context.Background(), repo)
+}
+
+// BenchmarkGetAllTasks measures listing every task (GET /tasks)
+func BenchmarkGetAllTasks(b *testing.B) {
+	ctx := benchmarkContext(b, benchmarkTasks)
+	input := &models.GetTasksInput{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		output, err := GetAllTasks(ctx, input)
+		if err != nil {
+			b.Fatalf("GetAllTasks returned error: %v", err)
+		}
+		if len(output.Body) != benchmarkTasks {
+			b.Fatalf("Expected %d tasks, got %d", benchmarkTasks, len(output.Body))
+		}
+	}
+}
+
+// BenchmarkGetAllTasks_Filtered measures listing with ?completed=false
+func BenchmarkGetAllTasks_Filtered(b *testing.B) {
+	ctx := benchmarkContext(b, benchmarkTasks)
+	input := &models.GetTasksInput{Completed: models.Some(false)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := GetAllTasks(ctx, input); err != nil {
+			b.Fatalf("GetAllTasks returned error: %v", err)
+		}
+	}
+}
+
+// BenchmarkCreateTask measures creating a task (POST /tasks)
+// Every iteration adds a task to the repository, which is what a real
+// server does too.
+func BenchmarkCreateTask(b *testing.B) {
+	ctx := benchmarkContext(b, 0)
+	input := testutil.CreateTaskInput(
+		testutil.WithTitle("Benchmark task"),
+		testutil.WithDescription("Created by BenchmarkCreateTask"),
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := CreateTask(ctx, input); err != nil {
+			b.Fatalf("CreateTask returned error: %v", err)
+		}
+	}
+}
@@ -2,38 +2,126 @@ package handlers
 
 import (
 	"context"
+	"flag"
 	"os"
 	"testing"
 
 	"go-todo-api/internal/database"
 	"go-todo-api/internal/logger"
 	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/testutil"
 
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// mongoUnavailable says why the MongoDB integration tests are skipped
+// Empty means TestMain connected to a throwaway database
+var mongoUnavailable string
+
+// useMemory runs the integration tests on in-memory repositories instead,
+// so they run without Docker: DB_DRIVER=memory go test ./internal/handlers
+var useMemory = os.Getenv("DB_DRIVER") == "memory"
+
 // TestMain runs before all tests and handles setup/teardown
 func TestMain(m *testing.M) {
 
 	// Setup: Initialise logger first (database.Connect needs it)
 	logger.Init()
 
-	// Setup: Connect to MongoDB before running tests
-	database.Connect()
+	// Setup: Start a throwaway MongoDB and connect to it
+	// The integration tests delete every task, so they never run against the
+	// MONGO_URI from .env - only against a container (or TEST_MONGO_URI).
+	// In short mode only the unit tests run (they use a fake repository,
+	// see tasks_unit_test.go), so no database is needed
+	flag.Parse()
+	stopMongo := func() {}
+	if testing.Short() {
+		mongoUnavailable = "short mode"
+	} else if useMemory {
+		mongoUnavailable = "DB_DRIVER=memory"
+	} else if uri, stop, err := testutil.StartMongo(context.Background()); err != nil {
+		mongoUnavailable = err.Error()
+	} else {
+		stopMongo = stop
+		os.Setenv("MONGO_URI", uri)
+		database.Connect()
+	}
 
 	// Run all tests
 	code := m.Run()
 
-	// Teardown: Close connection after all tests
-	database.Close()
+	// Teardown: Close connection and remove the container after all tests
+	if mongoUnavailable == "" {
+		database.Close()
+	}
+	stopMongo()
 
 	// Exit with test result code
 	os.Exit(code)
 }
 
-// Note: These tests require MongoDB to be running
-// Run with: go test /internal handlers -v
+// isolatedTasks gives a test its own empty tasks collection (an in-memory
+// repository with DB_DRIVER=memory)
+// The returned ctx makes the handlers use it, so tests never see each
+// other's tasks and can run in parallel
+func isolatedTasks(t *testing.T) (context.Context, testTasks) {
+	t.Helper()
+	if useMemory {
+		repo := repository.NewMemoryTaskRepository()
+		return WithTaskRepository(context.Background(), repo), testTasks{repo: repo}
+	}
+	requireMongo(t)
+
+	collection := testutil.IsolatedCollection(t, database.GetCollection())
+	repo := repository.NewMongoTaskRepository(collection)
+	return WithTaskRepository(context.Background(), repo), testTasks{repo: repo, collection: collection}
+}
+
+// testTasks is the store isolatedTasks gave a test
+type testTasks struct {
+	repo       repository.TaskRepository
+	collection *mongo.Collection // nil with DB_DRIVER=memory
+}
+
+// insert adds tasks as they are, straight into the collection with MongoDB
+func (s testTasks) insert(t *testing.T, tasks ...models.Task) []models.Task {
+	t.Helper()
+	if s.collection == nil {
+		return testutil.CreateTasks(t, s.repo, tasks...)
+	}
+	return testutil.InsertTasks(t, s.collection, tasks...)
+}
+
+// count returns how many tasks there are
+func (s testTasks) count(t *testing.T) int64 {
+	t.Helper()
+	if s.collection == nil {
+		stats, err := s.repo.Stats(context.Background()) // Counted as they change
+		if err != nil {
+			t.Fatalf("Failed to count tasks: %v", err)
+		}
+		return stats.Total
+	}
+	count, err := s.collection.CountDocuments(context.Background(), bson.M{})
+	if err != nil {
+		t.Fatalf("Failed to count tasks: %v", err)
+	}
+	return count
+}
+
+// requireMongo skips an integration test when there's no throwaway MongoDB
+func requireMongo(t *testing.T) {
+	t.Helper()
+	if mongoUnavailable != "" {
+		t.Skipf("Skipping MongoDB integration test: %s", mongoUnavailable)
+	}
+}
+
+// Note: These tests need Docker (a MongoDB container is started for them)
+// or TEST_MONGO_URI; without either they are skipped, unless DB_DRIVER=memory
+// Run with: go test ./internal/handlers -v
 
 // ============================================================================
 // GET ALL TASKS - EMPTY DATABASE
@@ -41,15 +129,10 @@ func TestMain(m *testing.M) {
 
 // TestGetAllTasks_EmptyDatabase tests getting tasks when database is empty
 func TestGetAllTasks_EmptyDatabase(t *testing.T) {
-	// Skip this MongoDB integration function in short mode
-	if testing.Short() {
-		t.Skip("Skipping integration test")
-	}
+	t.Parallel()
 
-	// Arrange: Clean database
-	ctx := context.Background()
-	collection := database.GetCollection()
-	collection.DeleteMany(ctx, bson.M{}) // Clear all tasks
+	// Arrange: An empty collection of its own (skipped without a test database)
+	ctx, _ := isolatedTasks(t)
 
 	// Act: Get all tasks
 	input := &models.GetTasksInput{}
@@ -77,36 +160,17 @@ func TestGetAllTasks_EmptyDatabase(t *testing.T) {
 
 // TestGetAllTasks_WithTasks tests getting tasks when some exist
 func TestGetAllTasks_WithTasks(t *testing.T) {
-	// Skip this MongoDB integration function in short mode
-	if testing.Short() {
-		t.Skip("Skipping integration test")
-	}
+	t.Parallel()
 
-	// Arrange: Clean database and insert test tasks
-	ctx := context.Background()
-	collection := database.GetCollection()
-	collection.DeleteMany(ctx, bson.M{})
+	// Arrange: Insert test tasks
+	// (in a collection of its own; skipped without a test database)
+	ctx, tasks := isolatedTasks(t)
 
 	// Insert 2 test tasks
-	testTasks := []interface{}{
-		models.Task{
-			ID:          primitive.NewObjectID(),
-			Title:       "Test Task 1",
-			Description: "First test task",
-			Completed:   false,
-		},
-		models.Task{
-			ID:          primitive.NewObjectID(),
-			Title:       "Test Task 2",
-			Description: "Second test task",
-			Completed:   true,
-		},
-	}
-
-	_, err := collection.InsertMany(ctx, testTasks)
-	if err != nil {
-		t.Fatalf("Failed to insert test tasks: %v", err)
-	}
+	tasks.insert(t,
+		testutil.NewTask(testutil.WithTitle("Test Task 1")),
+		testutil.NewTask(testutil.WithTitle("Test Task 2"), testutil.Completed()),
+	)
 
 	// Act: Get all tasks
 	input := &models.GetTasksInput{}
@@ -121,8 +185,6 @@ func TestGetAllTasks_WithTasks(t *testing.T) {
 		t.Errorf("Expected 2 tasks, got %d", len(output.Body))
 	}
 
-	// Cleanup
-	collection.DeleteMany(ctx, bson.M{})
 	t.Log("✅ GetAllTasks with tasks passed")
 }
 
@@ -132,25 +194,20 @@ func TestGetAllTasks_WithTasks(t *testing.T) {
 
 // TestGetAllTasks_FilteredCompleted tests filtering by completed status
 func TestGetAllTasks_FilterCompleted(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test")
-	}
+	t.Parallel()
 
-	// Arrange
-	ctx := context.Background()
-	collection := database.GetCollection()
-	collection.DeleteMany(ctx, bson.M{})
+	// Arrange (in a collection of its own; skipped without a test database)
+	ctx, tasks := isolatedTasks(t)
 
 	// Insert mix of completed and incomplete tasks
-	testTasks := []interface{}{
-		models.Task{ID: primitive.NewObjectID(), Title: "Task 1", Completed: false},
-		models.Task{ID: primitive.NewObjectID(), Title: "Task 2", Completed: true},
-		models.Task{ID: primitive.NewObjectID(), Title: "Task 3", Completed: false},
-	}
-	collection.InsertMany(ctx, testTasks)
+	tasks.insert(t,
+		testutil.NewTask(testutil.WithTitle("Task 1")),
+		testutil.NewTask(testutil.WithTitle("Task 2"), testutil.Completed()),
+		testutil.NewTask(testutil.WithTitle("Task 3")),
+	)
 
 	// Act: Get only completed tasks
-	input := &models.GetTasksInput{Completed: "true"}
+	input := &models.GetTasksInput{Completed: models.Some(true)}
 	output, err := GetAllTasks(ctx, input)
 
 	// Assert
@@ -166,8 +223,6 @@ func TestGetAllTasks_FilterCompleted(t *testing.T) {
 		t.Errorf("Expected 'Task 2', got '%v'", output.Body[0])
 	}
 
-	// Cleanup
-	collection.DeleteMany(ctx, bson.M{})
 	t.Log("✅ Filter by completed passed")
 }
 
@@ -176,24 +231,15 @@ func TestGetAllTasks_FilterCompleted(t *testing.T) {
 // ============================================================================
 // TestCreateTask tests creating a new task
 func TestCreateTask(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test")
-	}
+	t.Parallel()
 
-	// Arrange
-	ctx := context.Background()
-	collection := database.GetCollection()
-	collection.DeleteMany(ctx, bson.M{})
+	// Arrange (in a collection of its own; skipped without a test database)
+	ctx, tasks := isolatedTasks(t)
 
-	input := &models.CreateTaskInput{
-		Body: struct {
-			Title       string `json:"title" doc:"Title of the task" minLength:"1" maxLength:"200" example:"Buy groceries"`
-			Description string `json:"description,omitempty" doc:"Detailed description" maxLength:"1000" example:"Buy milk, eggs, and bread"`
-		}{
-			Title:       "New Test Task",
-			Description: "Testing task creation",
-		},
-	}
+	input := testutil.CreateTaskInput(
+		testutil.WithTitle("New Test Task"),
+		testutil.WithDescription("Testing task creation"),
+	)
 
 	// Act
 	output, err := CreateTask(ctx, input)
@@ -211,9 +257,11 @@ func TestCreateTask(t *testing.T) {
 		t.Error("Expected new task to be incomplete")
 	}
 
-	// Cleanup
-	collection.DeleteMany(ctx, bson.M{})
-	t.Logf("✅ CreateTask passed. Created task with ID: %s", output.Body.ID.Hex())
+	if count := tasks.count(t); count != 1 {
+		t.Errorf("Expected 1 task in the collection, got %d", count)
+	}
+
+	t.Logf("✅ CreateTask passed. Created task with ID: %s", output.Body.ID.String())
 }
 
 // ============================================================================
@@ -221,29 +269,17 @@ func TestCreateTask(t *testing.T) {
 // ============================================================================
 // TestGetTaskByID tests retrieving a specific task
 func TestGetTaskByID(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test")
-	}
+	t.Parallel()
 
 	// Arrange: Create a task first
-	ctx := context.Background()
-	collection := database.GetCollection()
-	collection.DeleteMany(ctx, bson.M{})
+	// (in a collection of its own; skipped without a test database)
+	ctx, tasks := isolatedTasks(t)
 
-	testTask := models.Task{
-		ID:          primitive.NewObjectID(),
-		Title:       "Find Me",
-		Description: "Test finding by ID",
-		Completed:   false,
-	}
-	_, err := collection.InsertOne(ctx, testTask)
-	if err != nil {
-		t.Fatalf("Failed to insert test task: %v", err)
-	}
+	testTask := tasks.insert(t, testutil.NewTask(testutil.WithTitle("Find Me")))[0]
 
 	// Act: Get the task by ID
 	input := &models.GetTaskInput{
-		ID: testTask.ID.Hex(),
+		ID: testTask.ID,
 	}
 	output, err := GetTaskByID(ctx, input)
 
@@ -260,8 +296,6 @@ func TestGetTaskByID(t *testing.T) {
 		t.Error("ID mismatch")
 	}
 
-	// Cleanup
-	collection.DeleteMany(ctx, bson.M{})
 	t.Log("✅ GetTaskByID passed")
 }
 
@@ -270,21 +304,21 @@ func TestGetTaskByID(t *testing.T) {
 // ============================================================================
 // TestGetTaskByID tests retrieving a specific task
 func TestGetTaskByID_InvalidID(t *testing.T) {
-	// No database needed, so no testing.Short() check
-	ctx := context.Background()
+	t.Parallel()
 
-	// Create input with bad ID
+	// No database needed: the ID is rejected before the handler is called
+	// Create input with bad ID, as Huma reads it from the URL
 	input := &models.GetTaskInput{
-		ID: "invalid-id-format", // This ID will flag as invalid as it is not a valid 24 character hex string
+		ID: models.TaskID{Raw: "invalid-id-format-xxxxxx"}, // This ID will flag as invalid: 24 characters, but not hex (shorter or longer ones fail minLength/maxLength first)
 	}
 
-	// Call handler - will try to parse "invalid-id-format"
-	_, err := GetTaskByID(ctx, input) // We expect an error here and don't care about any other output
+	// Resolve is what Huma calls before the handler - it will try to parse "invalid-id-format-xxxxxx"
+	errs := input.ID.Resolve(nil)
 
 	// Assert: Check that we got an error
-	if err == nil {
+	if len(errs) == 0 {
 		// This is not the outcome we want, we want an invalid ID to return an error
-		t.Fatalf("Expected error for invalid ID, got nil")
+		t.Fatalf("Expected error for invalid ID, got none")
 	}
 
 	// If we reach here, err is not nil, which is correct
@@ -296,41 +330,25 @@ func TestGetTaskByID_InvalidID(t *testing.T) {
 // ============================================================================
 // TestUpdateTask tests updating an existing task
 func TestUpdateTask(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test")
-	}
+	t.Parallel()
 
 	// Arrange: Create a task first
-	ctx := context.Background()
-	collection := database.GetCollection()
+	// (in a collection of its own; skipped without a test database)
+	ctx, tasks := isolatedTasks(t)
 
-	// First, ensure database is completely clean
-	collection.DeleteMany(ctx, bson.M{})
-
-	testTask := models.Task{
-		ID:          primitive.NewObjectID(),
-		Title:       "Original Title",
-		Description: "Original Description",
-		Completed:   false,
-	}
-
-	_, err := collection.InsertOne(ctx, testTask)
-	if err != nil {
-		t.Fatalf("Failed to insert test task: %v", err)
-	}
+	testTask := tasks.insert(t, testutil.NewTask(
+		testutil.WithTitle("Original Title"),
+		testutil.WithDescription("Original Description"),
+	))[0]
 
 	// Act: Update the task
-	title := "Updated Title"
-	description := "Updated Description"
-	completed := true
-
 	input := &models.UpdateTaskInput{
-		ID: testTask.ID.Hex(),
+		ID: testTask.ID,
 	}
 
-	input.Body.Title = &title
-	input.Body.Description = &description
-	input.Body.Completed = &completed
+	input.Body.Title = testutil.Ptr("Updated Title")
+	input.Body.Description = testutil.Ptr("Updated Description")
+	input.Body.Completed = testutil.Ptr(true)
 
 	output, err := UpdateTask(ctx, input)
 
@@ -347,8 +365,6 @@ func TestUpdateTask(t *testing.T) {
 		t.Error("Expected task to be completed")
 	}
 
-	// Cleanup
-	collection.DeleteMany(ctx, bson.M{})
 	t.Log("✅ UpdateTask passed")
 }
 
@@ -357,32 +373,23 @@ func TestUpdateTask(t *testing.T) {
 // ============================================================================
 // TestDeleteTask tests deleting a task
 func TestDeleteTask(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test")
-	}
+	t.Parallel()
 
 	// Arrange: Create a task first
-	ctx := context.Background()
-	collection := database.GetCollection()
-	collection.DeleteMany(ctx, bson.M{})
+	// (in a collection of its own; skipped without a test database)
+	ctx, tasks := isolatedTasks(t)
 
-	testTask := models.Task{
-		ID:          primitive.NewObjectID(),
-		Title:       "Delete Me",
-		Description: "This task will be deleted",
-		Completed:   false,
-	}
-	collection.InsertOne(ctx, testTask)
+	testTask := tasks.insert(t, testutil.NewTask(testutil.WithTitle("Delete Me")))[0]
 
 	// Verify task exists
-	count, _ := collection.CountDocuments(ctx, bson.M{})
+	count := tasks.count(t)
 	if count != 1 {
 		t.Fatalf("Expected 1 task before delete, got %d", count)
 	}
 
 	// Act: Delete the task
 	input := &models.DeleteTaskInput{
-		ID: testTask.ID.Hex(),
+		ID: testTask.ID,
 	}
 	output, err := DeleteTask(ctx, input)
 
@@ -396,7 +403,7 @@ func TestDeleteTask(t *testing.T) {
 	}
 
 	// Verify task is deleted
-	count, _ = collection.CountDocuments(ctx, bson.M{})
+	count = tasks.count(t)
 	if count != 0 {
 		t.Errorf("Expected 0 tasks after delete, got %d", count)
 	}
@@ -410,17 +417,13 @@ func TestDeleteTask(t *testing.T) {
 
 // TestDeleteTask_NotFound tests deleting non-existent task
 func TestDeleteTask_NotFound(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test")
-	}
+	t.Parallel()
 
-	ctx := context.Background()
-	collection := database.GetCollection()
-	collection.DeleteMany(ctx, bson.M{})
+	ctx, _ := isolatedTasks(t)
 
 	// Try to delete task that doesn't exist
 	input := &models.DeleteTaskInput{
-		ID: primitive.NewObjectID().Hex(),
+		ID: testutil.TaskID(1),
 	}
 
 	_, err := DeleteTask(ctx, input)
@@ -432,3 +435,52 @@ func TestDeleteTask_NotFound(t *testing.T) {
 
 	t.Log("✅ DeleteTask not found error handling passed")
 }
+
+// ============================================================================
+// TASK COUNTS
+// ============================================================================
+
+// TestGetStats tests that the counts are computed until they've been stored,
+// and read back as stored afterwards (stats.Counter keeps them current)
+func TestGetStats(t *testing.T) {
+	t.Parallel()
+
+	// Arrange (in a collection of its own; skipped without a test database)
+	ctx, tasks := isolatedTasks(t)
+	tasks.insert(t,
+		testutil.NewTask(),
+		testutil.NewTask(),
+		testutil.NewTask(testutil.Completed()),
+	)
+
+	// Act + Assert: nothing stored yet, so they're counted
+	output, err := GetStats(ctx, &struct{}{})
+	if err != nil {
+		t.Fatalf("GetStats returned error: %v", err)
+	}
+	if output.Body.Open != 2 || output.Body.Completed != 1 || output.Body.Total != 3 {
+		t.Errorf("Expected 2 open, 1 completed, 3 total, got %+v", output.Body)
+	}
+
+	// Once stored, GetStats reads the stored counts - a new task only shows
+	// up after the next recount
+	// (MongoDB only: the in-memory repository counts as tasks change)
+	requireMongo(t)
+	repo := tasks.repo.(*repository.MongoTaskRepository)
+	if _, err := repo.RecountStats(ctx); err != nil {
+		t.Fatalf("RecountStats returned error: %v", err)
+	}
+	tasks.insert(t, testutil.NewTask())
+	output, _ = GetStats(ctx, &struct{}{})
+	if output.Body.Total != 3 {
+		t.Errorf("Expected the stored total of 3, got %d", output.Body.Total)
+	}
+
+	repo.RecountStats(ctx)
+	output, _ = GetStats(ctx, &struct{}{})
+	if output.Body.Open != 3 || output.Body.Total != 4 {
+		t.Errorf("Expected 3 open, 4 total after recounting, got %+v", output.Body)
+	}
+
+	t.Log("✅ GetStats passed")
+}
@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"testing"
+
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/testutil"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// These tests run the task handlers against a fake repository, so they need
+// no database and can exercise failures MongoDB won't produce on demand
+// Run with: go test ./internal/handlers -short -v
+
+// errDatabaseDown is what the fake returns to simulate an unreachable MongoDB
+var errDatabaseDown = errors.New("server selection error: connection refused")
+
+// fakeTaskRepository wraps an in-memory repository and can be told to fail
+type fakeTaskRepository struct {
+	*repository.MemoryTaskRepository
+
+	// err, when set, is returned by every method instead of touching the data
+	err error
+}
+
+// check returns the error a call should fail with before touching the data:
+// f.err, or - like the MongoDB driver - the context's error once the request
+// is cancelled or past its deadline
+func (f *fakeTaskRepository) check(ctx context.Context) error {
+	if f.err != nil {
+		return f.err
+	}
+	return ctx.Err()
+}
+
+func (f *fakeTaskRepository) List(ctx context.Context, completed *bool, fields repository.Fields, page repository.Page) ([]models.Task, error) {
+	if err := f.check(ctx); err != nil {
+		return nil, err
+	}
+	return f.MemoryTaskRepository.List(ctx, completed, fields, page)
+}
+
+func (f *fakeTaskRepository) Stream(ctx context.Context, completed *bool) (iter.Seq2[models.Task, error], error) {
+	if err := f.check(ctx); err != nil {
+		return nil, err
+	}
+	return f.MemoryTaskRepository.Stream(ctx, completed)
+}
+
+func (f *fakeTaskRepository) Get(ctx context.Context, id models.TaskID) (*models.Task, error) {
+	if err := f.check(ctx); err != nil {
+		return nil, err
+	}
+	return f.MemoryTaskRepository.Get(ctx, id)
+}
+
+func (f *fakeTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	if err := f.check(ctx); err != nil {
+		return err
+	}
+	return f.MemoryTaskRepository.Create(ctx, task)
+}
+
+func (f *fakeTaskRepository) Update(ctx context.Context, id models.TaskID, changes repository.TaskChanges) (*models.Task, error) {
+	if err := f.check(ctx); err != nil {
+		return nil, err
+	}
+	return f.MemoryTaskRepository.Update(ctx, id, changes)
+}
+
+func (f *fakeTaskRepository) Delete(ctx context.Context, id models.TaskID) error {
+	if err := f.check(ctx); err != nil {
+		return err
+	}
+	return f.MemoryTaskRepository.Delete(ctx, id)
+}
+
+func (f *fakeTaskRepository) Stats(ctx context.Context) (models.TaskStats, error) {
+	if err := f.check(ctx); err != nil {
+		return models.TaskStats{}, err
+	}
+	return f.MemoryTaskRepository.Stats(ctx)
+}
+
+func (f *fakeTaskRepository) CountOwned(ctx context.Context, ownerID string) (int64, error) {
+	if err := f.check(ctx); err != nil {
+		return 0, err
+	}
+	return f.MemoryTaskRepository.CountOwned(ctx, ownerID)
+}
+
+func (f *fakeTaskRepository) ListOwned(ctx context.Context, ownerID string) ([]models.Task, error) {
+	if err := f.check(ctx); err != nil {
+		return nil, err
+	}
+	return f.MemoryTaskRepository.ListOwned(ctx, ownerID)
+}
+
+func (f *fakeTaskRepository) Version(ctx context.Context) (int64, error) {
+	if err := f.check(ctx); err != nil {
+		return 0, err
+	}
+	return f.MemoryTaskRepository.Version(ctx)
+}
+
+// useFakeRepository returns an empty fake and a context that makes the handlers use it
+func useFakeRepository(t *testing.T) (context.Context, *fakeTaskRepository) {
+	t.Helper()
+	fake := &fakeTaskRepository{MemoryTaskRepository: repository.NewMemoryTaskRepository()}
+	return WithTaskRepository(context.Background(), fake), fake
+}
+
+// assertStatus checks that err is a Huma error with the expected HTTP status
+func assertStatus(t *testing.T, err error, want int) {
+	t.Helper()
+	var statusErr huma.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Expected a %d error, got %v", want, err)
+	}
+	if got := statusErr.GetStatus(); got != want {
+		t.Errorf("Expected status %d, got %d (%v)", want, got, err)
+	}
+}
+
+// ============================================================================
+// HAPPY PATHS
+// ============================================================================
+
+// TestTasks_CRUD_WithFakeRepository runs create → get → update → list → delete
+func TestTasks_CRUD_WithFakeRepository(t *testing.T) {
+	t.Parallel()
+
+	// Arrange
+	ctx, _ := useFakeRepository(t)
+
+	// Act + Assert: Create
+	created, err := CreateTask(ctx, testutil.CreateTaskInput(testutil.WithTitle("Unit test task")))
+	if err != nil {
+		t.Fatalf("CreateTask returned error: %v", err)
+	}
+	if created.Body.ID.IsZero() || created.Body.Completed {
+		t.Fatalf("Expected a new incomplete task with an ID, got %+v", created.Body)
+	}
+	id := created.Body.ID.String()
+
+	// Get
+	got, err := GetTaskByID(ctx, &models.GetTaskInput{ID: models.MustParseTaskID(id)})
+	if err != nil {
+		t.Fatalf("GetTaskByID returned error: %v", err)
+	}
+	if got.Body.Title != "Unit test task" {
+		t.Errorf("Expected title 'Unit test task', got '%s'", got.Body.Title)
+	}
+
+	// Update only completed; the title must stay the same
+	updateInput := &models.UpdateTaskInput{ID: models.MustParseTaskID(id)}
+	updateInput.Body.Completed = testutil.Ptr(true)
+	updated, err := UpdateTask(ctx, updateInput)
+	if err != nil {
+		t.Fatalf("UpdateTask returned error: %v", err)
+	}
+	if !updated.Body.Completed || updated.Body.Title != "Unit test task" || updated.Body.CompletedAt == nil {
+		t.Errorf("Expected completed task with the same title and a completed_at, got %+v", updated.Body)
+	}
+	// Completing it again keeps the first time
+	again, _ := UpdateTask(ctx, updateInput)
+	if again == nil || again.Body.CompletedAt == nil || !again.Body.CompletedAt.Equal(*updated.Body.CompletedAt) {
+		t.Errorf("Expected completed_at to stay %v, got %+v", updated.Body.CompletedAt, again)
+	}
+
+	// List with filters
+	done, err := GetAllTasks(ctx, &models.GetTasksInput{Completed: models.Some(true)})
+	if err != nil {
+		t.Fatalf("GetAllTasks returned error: %v", err)
+	}
+	if len(done.Body) != 1 {
+		t.Errorf("Expected 1 completed task, got %d", len(done.Body))
+	}
+	open, err := GetAllTasks(ctx, &models.GetTasksInput{Completed: models.Some(false)})
+	if err != nil {
+		t.Fatalf("GetAllTasks returned error: %v", err)
+	}
+	if len(open.Body) != 0 {
+		t.Errorf("Expected 0 incomplete tasks, got %d", len(open.Body))
+	}
+
+	// Delete
+	if _, err := DeleteTask(ctx, &models.DeleteTaskInput{ID: models.MustParseTaskID(id)}); err != nil {
+		t.Fatalf("DeleteTask returned error: %v", err)
+	}
+	_, err = GetTaskByID(ctx, &models.GetTaskInput{ID: models.MustParseTaskID(id)})
+	assertStatus(t, err, http.StatusNotFound)
+
+	t.Log("✅ CRUD against the fake repository passed")
+}
+
+// TestGetAllTasks_EmptyRepository tests that no tasks is [] rather than null
+func TestGetAllTasks_EmptyRepository(t *testing.T) {
+	t.Parallel()
+
+	ctx, _ := useFakeRepository(t)
+
+	output, err := GetAllTasks(ctx, &models.GetTasksInput{})
+	if err != nil {
+		t.Fatalf("GetAllTasks returned error: %v", err)
+	}
+	if output.Body == nil || len(output.Body) != 0 {
+		t.Errorf("Expected an empty (non-nil) list, got %#v", output.Body)
+	}
+}
+
+// ============================================================================
+// DATABASE DOWN → 500
+// ============================================================================
+
+// TestTasks_DatabaseDown tests that every handler turns a repository failure into a 500
+func TestTasks_DatabaseDown(t *testing.T) {
+	t.Parallel()
+
+	// Arrange: every repository call fails
+	ctx, fake := useFakeRepository(t)
+	fake.err = errDatabaseDown
+	id := testutil.TaskID(1).String()
+
+	createInput := testutil.CreateTaskInput()
+	updateInput := &models.UpdateTaskInput{ID: models.MustParseTaskID(id)}
+	updateInput.Body.Title = testutil.Ptr("New title")
+
+	calls := map[string]func() error{
+		"GetAllTasks": func() error { _, err := GetAllTasks(ctx, &models.GetTasksInput{}); return err },
+		"GetTaskByID": func() error {
+			_, err := GetTaskByID(ctx, &models.GetTaskInput{ID: models.MustParseTaskID(id)})
+			return err
+		},
+		"CreateTask": func() error { _, err := CreateTask(ctx, createInput); return err },
+		"UpdateTask": func() error { _, err := UpdateTask(ctx, updateInput); return err },
+		"DeleteTask": func() error {
+			_, err := DeleteTask(ctx, &models.DeleteTaskInput{ID: models.MustParseTaskID(id)})
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			// Act + Assert
+			assertStatus(t, call(), http.StatusInternalServerError)
+		})
+	}
+
+	t.Log("✅ Database failures return 500")
+}
+
+// TestTasks_ClientGone tests that the handlers query with the request's own
+// context, so a client that disconnects (or a request past its deadline)
+// cancels the database work instead of letting it run on
+func TestTasks_ClientGone(t *testing.T) {
+	t.Parallel()
+
+	// Arrange: one task, and a request whose client has gone away
+	ctx, fake := useFakeRepository(t)
+	task := testutil.NewTask(testutil.WithTitle("Keep me"))
+	if err := fake.MemoryTaskRepository.Create(ctx, &task); err != nil {
+		t.Fatalf("Failed to seed task: %v", err)
+	}
+	id := task.ID.String()
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	// Act
+	_, getErr := GetTaskByID(cancelled, &models.GetTaskInput{ID: models.MustParseTaskID(id)})
+	_, deleteErr := DeleteTask(cancelled, &models.DeleteTaskInput{ID: models.MustParseTaskID(id)})
+
+	// Assert: both calls failed and the task is still there
+	if getErr == nil || deleteErr == nil {
+		t.Errorf("Expected cancelled requests to fail, got get=%v delete=%v", getErr, deleteErr)
+	}
+	if _, err := fake.MemoryTaskRepository.Get(ctx, task.ID); err != nil {
+		t.Errorf("Expected the task to survive a cancelled delete, got %v", err)
+	}
+}
+
+// ============================================================================
+// NOT FOUND → 404
+// ============================================================================
+
+// TestTasks_NotFound tests that a missing task is a 404 for get, update and delete
+func TestTasks_NotFound(t *testing.T) {
+	t.Parallel()
+
+	ctx, _ := useFakeRepository(t)
+	id := testutil.TaskID(1).String()
+
+	_, err := GetTaskByID(ctx, &models.GetTaskInput{ID: models.MustParseTaskID(id)})
+	assertStatus(t, err, http.StatusNotFound)
+
+	updateInput := &models.UpdateTaskInput{ID: models.MustParseTaskID(id)}
+	updateInput.Body.Completed = testutil.Ptr(true)
+	_, err = UpdateTask(ctx, updateInput)
+	assertStatus(t, err, http.StatusNotFound)
+
+	_, err = DeleteTask(ctx, &models.DeleteTaskInput{ID: models.MustParseTaskID(id)})
+	assertStatus(t, err, http.StatusNotFound)
+
+	t.Log("✅ Missing tasks return 404")
+}
+
+// ============================================================================
+// DUPLICATE KEY → 409
+// ============================================================================
+
+// TestCreateTask_Duplicate tests that a unique index violation is a 409, not a 500
+func TestCreateTask_Duplicate(t *testing.T) {
+	t.Parallel()
+
+	// Arrange: the repository reports a duplicate key (wrapped, like the Mongo one)
+	ctx, fake := useFakeRepository(t)
+	fake.err = fmt.Errorf("insert: %w", repository.ErrDuplicate)
+
+	// Act
+	_, err := CreateTask(ctx, testutil.CreateTaskInput(testutil.WithTitle("Duplicate")))
+
+	// Assert
+	assertStatus(t, err, http.StatusConflict)
+	t.Log("✅ Duplicate tasks return 409")
+}
+
+// ============================================================================
+// INPUT ERRORS → 400 (THE REPOSITORY IS NEVER CALLED)
+// ============================================================================
+
+// TestTasks_BadInput tests the 400s the handlers return before touching the repository
+func TestTasks_BadInput(t *testing.T) {
+	t.Parallel()
+
+	// Arrange: any repository call would fail, so a 400 proves it wasn't called
+	ctx, fake := useFakeRepository(t)
+	fake.err = errDatabaseDown
+
+	// Invalid ID: Huma resolves the ID before calling the handler
+	invalid := &models.DeleteTaskInput{ID: models.TaskID{Raw: "not-a-valid-object-id!!!"}}
+	if errs := invalid.ID.Resolve(nil); len(errs) != 1 {
+		t.Fatalf("Expected one error for an invalid ID, got %v", errs)
+	} else {
+		assertStatus(t, errs[0], http.StatusBadRequest)
+	}
+
+	// Empty update
+	_, err := UpdateTask(ctx, &models.UpdateTaskInput{ID: testutil.TaskID(1)})
+	assertStatus(t, err, http.StatusBadRequest)
+
+	t.Log("✅ Bad input returns 400 without a database call")
+}
@@ -0,0 +1,127 @@
+// ============================================================================
+// PACKAGE DECLARATION
+// ============================================================================
+package handlers
+
+// ============================================================================
+// IMPORTS
+// ============================================================================
+import (
+	// STANDARD LIBRARY PACKAGES
+	"context" // context = for managing request context
+	"fmt"     // fmt = for the quota problem detail
+	"log/slog"
+
+	// OUR OWN PACKAGES
+	"go-todo-api/internal/domainerrors" // The quota exceeded error
+	"go-todo-api/internal/logger"       // Our structured logger
+	"go-todo-api/internal/middleware"   // Who is calling (GetPrincipal)
+	"go-todo-api/internal/models"       // Our data structures
+
+	// THIRD-PARTY PACKAGES
+	"github.com/danielgtaylor/huma/v2" // Huma = REST API framework with error helpers
+)
+
+// ============================================================================
+// QUOTAS
+// ============================================================================
+// Each user may own a limited number of tasks, so one client can't fill the
+// database for everyone. Usage is counted from the tasks themselves (each
+// task records who created it), so there's no counter to drift out of step.
+// Two creates racing at the limit can both pass the check - a quota is a
+// guard rail, not an exact count.
+
+// Quotas are the per-user limits (0 = no limit)
+// Every user gets the same limits; admins have none
+type Quotas struct {
+	// MaxTasks is how many tasks one user may own (QUOTA_MAX_TASKS)
+	MaxTasks int64
+}
+
+// quotasKey is the context key for the quotas
+type quotasKey struct{}
+
+// WithQuotas applies q to the handlers called with ctx
+// app.New adds it to every request
+func WithQuotas(ctx context.Context, q Quotas) context.Context {
+	return context.WithValue(ctx, quotasKey{}, q)
+}
+
+// quotas returns the limits for this request (none unless app.New set them)
+func quotas(ctx context.Context) Quotas {
+	q, _ := ctx.Value(quotasKey{}).(Quotas)
+	return q
+}
+
+// taskQuota returns the caller's task limit (0 = no limit)
+func taskQuota(ctx context.Context, p middleware.Principal) int64 {
+	if p.IsAdmin() {
+		return 0
+	}
+	return quotas(ctx).MaxTasks
+}
+
+// checkTaskQuota returns a quota exceeded error (403) when the caller
+// already owns as many tasks as their quota allows
+func checkTaskQuota(ctx context.Context, p middleware.Principal) error {
+	limit := taskQuota(ctx, p)
+	if limit == 0 {
+		return nil
+	}
+
+	used, err := taskRepository(ctx).CountOwned(ctx, p.UserID)
+	if err != nil {
+		return fmt.Errorf("count tasks for quota: %w", err)
+	}
+	if used < limit {
+		return nil
+	}
+
+	logger.WithTrace(ctx).Warn("Task quota exceeded", "user_id", p.UserID, "used", used, "limit", limit)
+	// The field says which quota, so clients can tell this apart from a
+	// permissions 403 (and show the user what to delete)
+	return &domainerrors.Error{
+		Kind:    domainerrors.ErrQuotaExceeded,
+		Message: fmt.Sprintf("Task quota exceeded: you have %d of %d tasks", used, limit),
+		Field:   "quota.tasks",
+		Detail:  "delete tasks to create new ones",
+		Value:   limit,
+	}
+}
+
+// currentUser returns who is calling a /me/* endpoint
+// The auth middleware always sets it; a 401 only guards against a route
+// registered outside it
+func currentUser(ctx context.Context) (middleware.Principal, error) {
+	p, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		return middleware.Principal{}, huma.Error401Unauthorized("API key required")
+	}
+	return p, nil
+}
+
+// ============================================================================
+// USAGE - GET /me/usage
+// ============================================================================
+
+// GetUsage handles GET /me/usage
+// Shows the caller how much of each quota they've used
+//
+// Example response: {"user_id": "alice", "tasks": {"used": 42, "limit": 100}}
+func GetUsage(ctx context.Context, input *models.GetUsageInput) (*models.GetUsageOutput, error) {
+	p, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := taskRepository(ctx).CountOwned(ctx, p.UserID)
+	if err != nil {
+		logger.WithTrace(ctx).Error("Failed to count tasks for usage", slog.Any("error", err))
+		return nil, huma.Error500InternalServerError("Failed to read usage", err)
+	}
+
+	out := &models.GetUsageOutput{}
+	out.Body.UserID = p.UserID
+	out.Body.Tasks = models.QuotaUsage{Used: used, Limit: taskQuota(ctx, p)}
+	return out, nil
+}
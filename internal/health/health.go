@@ -0,0 +1,171 @@
+// Package health keeps track of the health of every component of the API
+// Components (database, caches, background workers...) register a check
+// function once at startup, and the /health/details endpoint runs them all
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State is the health of one component or of the whole service
+type State string
+
+const (
+	// StateOK means the component works normally
+	StateOK State = "ok"
+	// StateDegraded means the component works but slowly or partially
+	StateDegraded State = "degraded"
+	// StateDown means the component doesn't work at all
+	StateDown State = "down"
+)
+
+// severity orders states so we can pick the worst one
+func (s State) severity() int {
+	switch s {
+	case StateOK:
+		return 0
+	case StateDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Result is what a check function reports
+type Result struct {
+	State   State
+	Message string // Human readable detail, e.g. the error or why it's degraded
+}
+
+// CheckFunc checks one component
+// It must respect ctx - the registry gives every check a short deadline
+type CheckFunc func(ctx context.Context) Result
+
+// ComponentReport is the result of one check plus its history
+type ComponentReport struct {
+	Name        string
+	State       State
+	Message     string
+	Latency     time.Duration
+	LastError   string    // Most recent non-ok message, kept after recovery
+	LastErrorAt time.Time // When LastError happened (zero if never)
+}
+
+// Report is the result of running every registered check
+type Report struct {
+	State      State // Worst state of all components
+	Components []ComponentReport
+}
+
+// component is a registered check and the last error it reported
+type component struct {
+	check       CheckFunc
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// Registry holds the registered checks
+type Registry struct {
+	mu         sync.Mutex
+	components map[string]*component
+	timeout    time.Duration
+}
+
+// NewRegistry creates an empty registry
+// timeout is the deadline each check gets when the registry runs
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{
+		components: make(map[string]*component),
+		timeout:    timeout,
+	}
+}
+
+// defaultRegistry is the registry used by the package-level functions
+var defaultRegistry = NewRegistry(2 * time.Second)
+
+// Register adds a check to the default registry
+func Register(name string, check CheckFunc) {
+	defaultRegistry.Register(name, check)
+}
+
+// Run runs every check in the default registry
+func Run(ctx context.Context) Report {
+	return defaultRegistry.Run(ctx)
+}
+
+// Register adds (or replaces) the check for a component
+func (r *Registry) Register(name string, check CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.components[name] = &component{check: check}
+}
+
+// Run runs every check concurrently and returns the combined report
+// Components are sorted by name so the output is stable
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.components))
+	for name := range r.components {
+		names = append(names, name)
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	reports := make([]ComponentReport, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			reports[i] = r.runOne(ctx, name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	overall := StateOK
+	for _, rep := range reports {
+		if rep.State.severity() > overall.severity() {
+			overall = rep.State
+		}
+	}
+
+	return Report{State: overall, Components: reports}
+}
+
+// runOne runs a single check with the registry timeout and records errors
+func (r *Registry) runOne(ctx context.Context, name string) ComponentReport {
+	r.mu.Lock()
+	c := r.components[name]
+	r.mu.Unlock()
+
+	checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	res := c.check(checkCtx)
+	latency := time.Since(start)
+
+	// A check that forgets to set a state is treated as down
+	if res.State == "" {
+		res.State = StateDown
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if res.State != StateOK {
+		c.lastError = res.Message
+		c.lastErrorAt = time.Now()
+	}
+
+	return ComponentReport{
+		Name:        name,
+		State:       res.State,
+		Message:     res.Message,
+		Latency:     latency,
+		LastError:   c.lastError,
+		LastErrorAt: c.lastErrorAt,
+	}
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestRun_WorstStateWins tests that the overall state is the worst component state
+func TestRun_WorstStateWins(t *testing.T) {
+	// Arrange
+	r := NewRegistry(time.Second)
+	r.Register("database", func(ctx context.Context) Result { return Result{State: StateOK} })
+	r.Register("cache", func(ctx context.Context) Result {
+		return Result{State: StateDegraded, Message: "slow"}
+	})
+
+	// Act
+	report := r.Run(context.Background())
+
+	// Assert
+	if report.State != StateDegraded {
+		t.Errorf("Expected overall state 'degraded', got '%s'", report.State)
+	}
+
+	if len(report.Components) != 2 || report.Components[0].Name != "cache" {
+		t.Fatalf("Expected 2 components sorted by name, got %+v", report.Components)
+	}
+}
+
+// TestRun_KeepsLastError tests that a recovered component still reports its last error
+func TestRun_KeepsLastError(t *testing.T) {
+	// Arrange: a check that fails once, then recovers
+	r := NewRegistry(time.Second)
+	failing := true
+	r.Register("database", func(ctx context.Context) Result {
+		if failing {
+			return Result{State: StateDown, Message: "connection refused"}
+		}
+		return Result{State: StateOK}
+	})
+
+	// Act
+	first := r.Run(context.Background())
+	failing = false
+	second := r.Run(context.Background())
+
+	// Assert
+	if first.State != StateDown {
+		t.Errorf("Expected first run to be 'down', got '%s'", first.State)
+	}
+
+	got := second.Components[0]
+	if got.State != StateOK {
+		t.Errorf("Expected recovered state 'ok', got '%s'", got.State)
+	}
+
+	if got.LastError != "connection refused" || got.LastErrorAt.IsZero() {
+		t.Errorf("Expected last error to be kept after recovery, got %+v", got)
+	}
+}
@@ -0,0 +1,221 @@
+// Package hooks runs custom logic around task changes without forking the handlers
+// A deployment compiles its hooks in and registers them at startup, e.g. in
+// a file of its own next to cmd/todo/main.go:
+//
+//	func init() {
+//		hooks.Register("sync-to-crm", hooks.Funcs{
+//			AfterUpdateFunc: func(ctx context.Context, task models.Task) error {
+//				_, err := jobs.Enqueue(ctx, "crm.sync", task) // Slow work goes to the job workers
+//				return err
+//			},
+//		})
+//	}
+//
+// BeforeCreate runs before a task is saved: it may change the task, or stop
+// it with a Rejection (a 422 for the client). AfterUpdate and AfterDelete run
+// once the change is saved, so their errors are only logged; they're
+// subscribers of the task events (see internal/events), so they run when the
+// event backend delivers, and for every write, a sync's included.
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+
+	"go-todo-api/internal/events"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+)
+
+// Hook is custom logic run around task changes
+// Use Funcs to implement only some of the methods.
+type Hook interface {
+	// BeforeCreate may change the new task, or return an error to stop it
+	BeforeCreate(ctx context.Context, task *models.Task) error
+
+	// AfterUpdate gets the task as it is after an update
+	AfterUpdate(ctx context.Context, task models.Task) error
+
+	// AfterDelete gets the ID of a deleted task
+	AfterDelete(ctx context.Context, id string) error
+}
+
+// Rejection is the error a BeforeCreate hook returns for a task it refuses
+// The client gets a 422 pointing at body.<Field> (or the body, when Field is empty).
+type Rejection struct {
+	Field   string // JSON field, e.g. "title"
+	Message string // What's wrong, for the client
+}
+
+func (r *Rejection) Error() string {
+	return r.Message
+}
+
+// Funcs is a Hook made of functions; the ones left nil do nothing
+type Funcs struct {
+	BeforeCreateFunc func(ctx context.Context, task *models.Task) error
+	AfterUpdateFunc  func(ctx context.Context, task models.Task) error
+	AfterDeleteFunc  func(ctx context.Context, id string) error
+}
+
+// BeforeCreate calls BeforeCreateFunc
+func (f Funcs) BeforeCreate(ctx context.Context, task *models.Task) error {
+	if f.BeforeCreateFunc == nil {
+		return nil
+	}
+	return f.BeforeCreateFunc(ctx, task)
+}
+
+// AfterUpdate calls AfterUpdateFunc
+func (f Funcs) AfterUpdate(ctx context.Context, task models.Task) error {
+	if f.AfterUpdateFunc == nil {
+		return nil
+	}
+	return f.AfterUpdateFunc(ctx, task)
+}
+
+// AfterDelete calls AfterDeleteFunc
+func (f Funcs) AfterDelete(ctx context.Context, id string) error {
+	if f.AfterDeleteFunc == nil {
+		return nil
+	}
+	return f.AfterDeleteFunc(ctx, id)
+}
+
+// ============================================================================
+// REGISTRY
+// ============================================================================
+
+// Registry holds hooks and runs them in the order they were registered
+type Registry struct {
+	mu    sync.RWMutex
+	hooks []namedHook
+}
+
+// namedHook is a hook and the name its errors are logged with
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a hook; name identifies it in logs and errors
+func (r *Registry) Register(name string, hook Hook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, namedHook{name: name, hook: hook})
+}
+
+// Len returns how many hooks are registered
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.hooks)
+}
+
+// list returns a snapshot, so hooks run without holding the lock
+func (r *Registry) list() []namedHook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.hooks
+}
+
+// BeforeCreate runs every BeforeCreate hook, stopping at the first error
+// A Rejection is returned as it is; other errors say which hook failed.
+func (r *Registry) BeforeCreate(ctx context.Context, task *models.Task) error {
+	for _, h := range r.list() {
+		if err := h.hook.BeforeCreate(ctx, task); err != nil {
+			var rejection *Rejection
+			if errors.As(err, &rejection) {
+				return rejection
+			}
+			return fmt.Errorf("hook %s: %w", h.name, err)
+		}
+	}
+	return nil
+}
+
+// AfterUpdate runs every AfterUpdate hook; errors are logged
+func (r *Registry) AfterUpdate(ctx context.Context, task models.Task) {
+	for _, h := range r.list() {
+		if err := h.hook.AfterUpdate(ctx, task); err != nil {
+			logger.WithTrace(ctx).Error("AfterUpdate hook failed",
+				slog.String("hook", h.name), slog.String("id", task.ID.String()), slog.Any("error", err))
+		}
+	}
+}
+
+// AfterDelete runs every AfterDelete hook; errors are logged
+func (r *Registry) AfterDelete(ctx context.Context, id string) {
+	for _, h := range r.list() {
+		if err := h.hook.AfterDelete(ctx, id); err != nil {
+			logger.WithTrace(ctx).Error("AfterDelete hook failed",
+				slog.String("hook", h.name), slog.String("id", id), slog.Any("error", err))
+		}
+	}
+}
+
+// ============================================================================
+// BUILT-IN HOOKS
+// ============================================================================
+
+// TitlePattern rejects new tasks whose title doesn't match pattern
+// hint tells the client what's expected, e.g. "must start with a ticket
+// number like ABC-123". bootstrap registers it from TASK_TITLE_PATTERN.
+func TitlePattern(pattern *regexp.Regexp, hint string) Hook {
+	return Funcs{BeforeCreateFunc: func(ctx context.Context, task *models.Task) error {
+		if !pattern.MatchString(task.Title) {
+			return &Rejection{Field: "title", Message: hint}
+		}
+		return nil
+	}}
+}
+
+// ============================================================================
+// DEFAULT REGISTRY
+// ============================================================================
+
+// defaultRegistry is what the task handlers run
+// Hooks are added with Register, usually from init() functions
+var defaultRegistry = NewRegistry()
+
+// Register adds a hook to the default registry
+func Register(name string, hook Hook) {
+	defaultRegistry.Register(name, hook)
+}
+
+// init runs the default registry's AfterUpdate and AfterDelete hooks for
+// the task events
+func init() {
+	events.Subscribe("hooks", func(ctx context.Context, e events.Event) error {
+		switch e.Type {
+		case events.TaskUpdated, events.TaskCompleted:
+			Default().AfterUpdate(ctx, *e.Task)
+		case events.TaskDeleted:
+			Default().AfterDelete(ctx, e.TaskID)
+		}
+		return nil
+	}, events.TaskUpdated, events.TaskCompleted, events.TaskDeleted)
+}
+
+// Init replaces the default registry; Init(nil) empties it (for tests)
+func Init(r *Registry) *Registry {
+	if r == nil {
+		r = NewRegistry()
+	}
+	defaultRegistry = r
+	return r
+}
+
+// Default returns the default registry
+func Default() *Registry {
+	return defaultRegistry
+}
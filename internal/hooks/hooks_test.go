@@ -0,0 +1,49 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go-todo-api/internal/models"
+)
+
+// TestRegistry_BeforeCreate tests that hooks run in order and the first error stops the create
+func TestRegistry_BeforeCreate(t *testing.T) {
+	// Arrange: trim the title, then require a ticket number, then a hook that breaks
+	ctx := context.Background()
+	r := NewRegistry()
+	r.Register("trim", Funcs{BeforeCreateFunc: func(ctx context.Context, task *models.Task) error {
+		task.Title = strings.TrimSpace(task.Title)
+		return nil
+	}})
+	r.Register("ticket", TitlePattern(regexp.MustCompile(`^[A-Z]+-[0-9]+ `), "must start with a ticket number"))
+	r.Register("broken", Funcs{BeforeCreateFunc: func(ctx context.Context, task *models.Task) error {
+		if task.Description == "break" {
+			return errors.New("CRM unreachable")
+		}
+		return nil
+	}})
+
+	// Act
+	good := models.Task{Title: "  OPS-12 Rotate keys "}
+	goodErr := r.BeforeCreate(ctx, &good)
+	rejectedErr := r.BeforeCreate(ctx, &models.Task{Title: "Rotate keys"})
+	brokenErr := r.BeforeCreate(ctx, &models.Task{Title: "OPS-13 Fix it", Description: "break"})
+
+	// Assert
+	if goodErr != nil || good.Title != "OPS-12 Rotate keys" {
+		t.Errorf("Expected the trimmed task to pass, got %q (%v)", good.Title, goodErr)
+	}
+	var rejection *Rejection
+	if !errors.As(rejectedErr, &rejection) || rejection.Field != "title" {
+		t.Errorf("Expected a rejection of the title, got %v", rejectedErr)
+	}
+	if brokenErr == nil || errors.As(brokenErr, &rejection) || !strings.Contains(brokenErr.Error(), "hook broken") {
+		t.Errorf("Expected an error naming the broken hook, got %v", brokenErr)
+	}
+
+	t.Logf("✅ %d hooks ran in order", r.Len())
+}
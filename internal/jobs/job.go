@@ -0,0 +1,110 @@
+// Package jobs runs work in the background, outside the request that asked for it
+// Handlers enqueue a job (e.g. "deliver this webhook", "send this email") and
+// return immediately; a pool of workers picks jobs up from a MongoDB-backed
+// queue, retries failures with backoff, and records the outcome so clients
+// can poll GET /jobs/{id}.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go-todo-api/internal/domainerrors"
+)
+
+// Status is where a job is in its lifecycle
+type Status string
+
+const (
+	// StatusQueued means the job is waiting for a worker (or for its retry time)
+	StatusQueued Status = "queued"
+	// StatusRunning means a worker is executing the job right now
+	StatusRunning Status = "running"
+	// StatusSucceeded means the handler returned nil
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed means every attempt failed - the job won't run again
+	StatusFailed Status = "failed"
+)
+
+// ErrNotFound is returned when a job ID doesn't exist
+var ErrNotFound = domainerrors.New(domainerrors.ErrNotFound, "Job not found")
+
+// ErrNotInitialized is returned by the package-level functions before Init
+var ErrNotInitialized = errors.New("jobs: not initialized")
+
+// Job is one unit of background work
+type Job struct {
+	ID          string          `bson:"_id"`
+	Type        string          `bson:"type"`    // Selects the handler, e.g. "webhook.deliver"
+	Payload     json.RawMessage `bson:"payload"` // Handler input, JSON encoded
+	Status      Status          `bson:"status"`
+	Attempts    int             `bson:"attempts"` // Attempts started so far
+	MaxAttempts int             `bson:"max_attempts"`
+	LastError   string          `bson:"last_error,omitempty"`
+	RunAt       time.Time       `bson:"run_at"`                 // Earliest time the next attempt may start
+	LockedUntil time.Time       `bson:"locked_until,omitempty"` // A running job whose lease expired is picked up again
+	CreatedAt   time.Time       `bson:"created_at"`
+	UpdatedAt   time.Time       `bson:"updated_at"`
+	FinishedAt  *time.Time      `bson:"finished_at,omitempty"` // Set once succeeded or failed
+}
+
+// Decode unmarshals the job payload into v
+//
+//	var p WebhookPayload
+//	if err := job.Decode(&p); err != nil { ... }
+func (j *Job) Decode(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Handler executes one job
+// Returning an error schedules a retry (until MaxAttempts is reached).
+// ctx is cancelled when the job's timeout expires.
+type Handler func(ctx context.Context, job *Job) error
+
+// RetryPolicy controls how a job type is retried
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first (default 5)
+	BaseDelay   time.Duration // Delay before the first retry, doubled each time (default 1s)
+	MaxDelay    time.Duration // Upper bound for the delay (default 5m)
+	Timeout     time.Duration // Time limit for a single attempt (default 1m)
+}
+
+// DefaultRetryPolicy is used for zero fields of a registered policy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   time.Second,
+	MaxDelay:    5 * time.Minute,
+	Timeout:     time.Minute,
+}
+
+// withDefaults fills zero fields from DefaultRetryPolicy
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultRetryPolicy.Timeout
+	}
+	return p
+}
+
+// Backoff returns the delay before retrying after the given attempt (1-based)
+// Attempt 1 → BaseDelay, attempt 2 → 2×BaseDelay, 3 → 4×BaseDelay... capped at MaxDelay
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import "context"
+
+// defaultPool is the pool used by the package-level functions
+// It's set once at startup by Init (like the database client)
+var defaultPool *Pool
+
+// Init creates the default pool
+// Call it once at startup, after database.Connect()
+func Init(store Store, opts Options) *Pool {
+	defaultPool = NewPool(store, opts)
+	return defaultPool
+}
+
+// Default returns the pool created by Init (nil before Init)
+func Default() *Pool {
+	return defaultPool
+}
+
+// Enqueue adds a job to the default pool's queue
+func Enqueue(ctx context.Context, jobType string, payload any) (*Job, error) {
+	if defaultPool == nil {
+		return nil, ErrNotInitialized
+	}
+	return defaultPool.Enqueue(ctx, jobType, payload)
+}
+
+// Get returns a job from the default pool's queue
+func Get(ctx context.Context, id string) (*Job, error) {
+	if defaultPool == nil {
+		return nil, ErrNotInitialized
+	}
+	return defaultPool.Get(ctx, id)
+}
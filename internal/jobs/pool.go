@@ -0,0 +1,340 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/health"
+	"go-todo-api/internal/logger"
+)
+
+// Options configures a worker pool
+type Options struct {
+	Workers      int           // Jobs run concurrently (default 4)
+	PollInterval time.Duration // How often idle workers check the queue (default 1s)
+	Lease        time.Duration // How long a claimed job is locked before another worker may retry it (default 5m)
+	Clock        clock.Clock   // Decides when jobs are due and stamps them (default clock.Real; tests use a clock.Fake)
+}
+
+// OptionsFromEnv reads the pool options from environment variables:
+//
+//	JOBS_WORKERS=4
+//	JOBS_POLL_INTERVAL=1s
+func OptionsFromEnv() Options {
+	var opts Options
+	if v, err := strconv.Atoi(os.Getenv("JOBS_WORKERS")); err == nil && v > 0 {
+		opts.Workers = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("JOBS_POLL_INTERVAL")); err == nil && v > 0 {
+		opts.PollInterval = v
+	}
+	return opts
+}
+
+// registration is a handler and its retry policy
+type registration struct {
+	handler Handler
+	policy  RetryPolicy
+}
+
+// Pool enqueues jobs and runs them on a fixed number of workers
+type Pool struct {
+	store Store
+	opts  Options
+
+	mu       sync.RWMutex
+	handlers map[string]registration
+	lastErr  error // Most recent store error seen while polling (nil once polling works again)
+
+	wake    chan struct{} // Nudges an idle worker when a job is enqueued locally
+	stop    chan struct{} // Closed by Shutdown: workers finish their job and exit
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewPool creates a pool on the given store
+// Register handlers, then call Start to begin processing
+func NewPool(store Store, opts Options) *Pool {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 5 * time.Minute
+	}
+	opts.Clock = clock.OrReal(opts.Clock)
+	return &Pool{
+		store:    store,
+		opts:     opts,
+		handlers: make(map[string]registration),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler for a job type
+// Zero fields in policy fall back to DefaultRetryPolicy.
+func (p *Pool) Register(jobType string, handler Handler, policy RetryPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[jobType] = registration{handler: handler, policy: policy.withDefaults()}
+}
+
+// Enqueue adds a job to the queue
+// payload is JSON encoded and handed to the handler via job.Decode.
+// Jobs of a type with no registered handler wait until a process that has one picks them up.
+func (p *Pool) Enqueue(ctx context.Context, jobType string, payload any) (*Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: encode payload: %w", err)
+	}
+
+	p.mu.RLock()
+	reg, ok := p.handlers[jobType]
+	p.mu.RUnlock()
+	maxAttempts := DefaultRetryPolicy.MaxAttempts
+	if ok {
+		maxAttempts = reg.policy.MaxAttempts
+	}
+
+	now := p.opts.Clock.Now().UTC()
+	job := &Job{
+		ID:          primitive.NewObjectID().Hex(),
+		Type:        jobType,
+		Payload:     raw,
+		Status:      StatusQueued,
+		MaxAttempts: maxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := p.store.Insert(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// Wake a worker now instead of waiting for the next poll
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// Get returns a job by ID, or ErrNotFound
+func (p *Pool) Get(ctx context.Context, id string) (*Job, error) {
+	return p.store.Get(ctx, id)
+}
+
+// Start launches the workers
+func (p *Pool) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return
+	}
+	p.started = true
+	p.stop = make(chan struct{})
+
+	for i := 0; i < p.opts.Workers; i++ {
+		p.wg.Add(1)
+		go p.work(p.stop)
+	}
+	logger.Log.Info("Job workers started", slog.Int("workers", p.opts.Workers))
+}
+
+// Shutdown stops claiming new jobs and waits for running jobs to finish
+// If ctx expires first, Shutdown returns ctx.Err() - the unfinished jobs are
+// retried by another worker once their lease expires.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.started {
+		p.mu.Unlock()
+		return nil
+	}
+	p.started = false
+	close(p.stop)
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.Log.Info("Job workers drained")
+		return nil
+	case <-ctx.Done():
+		logger.Log.Warn("Job workers did not drain before the deadline", slog.Any("error", ctx.Err()))
+		return ctx.Err()
+	}
+}
+
+// HealthCheck reports the pool for /health/details
+// - down:     workers not running
+// - degraded: the last poll of the queue failed
+// - ok:       workers running and the queue is reachable
+func (p *Pool) HealthCheck(ctx context.Context) health.Result {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if !p.started {
+		return health.Result{State: health.StateDown, Message: "workers not running"}
+	}
+	if p.lastErr != nil {
+		return health.Result{State: health.StateDegraded, Message: p.lastErr.Error()}
+	}
+	return health.Result{State: health.StateOK}
+}
+
+// work is the loop each worker runs until stop is closed by Shutdown
+func (p *Pool) work(stop <-chan struct{}) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		ran := p.runNext()
+		if ran {
+			continue // There may be more due jobs - check again straight away
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-p.wake:
+		case <-time.After(p.opts.PollInterval):
+		}
+	}
+}
+
+// runNext claims and runs one job
+// Returns true if a job was run
+func (p *Pool) runNext() bool {
+	p.mu.RLock()
+	types := make([]string, 0, len(p.handlers))
+	for t := range p.handlers {
+		types = append(types, t)
+	}
+	p.mu.RUnlock()
+	if len(types) == 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	now := p.opts.Clock.Now().UTC()
+	job, err := p.store.Claim(ctx, types, now, now.Add(p.opts.Lease))
+	cancel()
+
+	p.mu.Lock()
+	p.lastErr = err
+	p.mu.Unlock()
+
+	if err != nil {
+		logger.Log.Error("Failed to claim job", slog.Any("error", err))
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	p.run(job)
+	return true
+}
+
+// run executes a claimed job and records the outcome
+func (p *Pool) run(job *Job) {
+	p.mu.RLock()
+	reg := p.handlers[job.Type]
+	p.mu.RUnlock()
+
+	// The job gets its own context: Shutdown lets running jobs finish
+	// instead of cancelling them halfway through
+	ctx, cancel := context.WithTimeout(context.Background(), reg.policy.Timeout)
+	defer cancel()
+
+	ctx, span := otel.Tracer("jobs").Start(ctx, "job "+job.Type)
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("job.id", job.ID),
+		attribute.String("job.type", job.Type),
+		attribute.Int("job.attempt", job.Attempts),
+	)
+
+	start := time.Now()
+	err := callHandler(ctx, reg.handler, job)
+	elapsed := time.Since(start)
+
+	now := p.opts.Clock.Now().UTC()
+	job.UpdatedAt = now
+	job.LockedUntil = time.Time{}
+
+	attrs := []any{
+		slog.String("job_id", job.ID),
+		slog.String("job_type", job.Type),
+		slog.Int("attempt", job.Attempts),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+
+	switch {
+	case err == nil:
+		job.Status = StatusSucceeded
+		job.LastError = ""
+		job.FinishedAt = &now
+		logger.WithTrace(ctx).Info("Job succeeded", attrs...)
+
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = StatusFailed
+		job.LastError = err.Error()
+		job.FinishedAt = &now
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		logger.WithTrace(ctx).Error("Job failed permanently", append(attrs, slog.Any("error", err))...)
+
+	default:
+		job.Status = StatusQueued
+		job.LastError = err.Error()
+		job.RunAt = now.Add(reg.policy.Backoff(job.Attempts))
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		logger.WithTrace(ctx).Warn("Job failed, will retry", append(attrs,
+			slog.Any("error", err),
+			slog.Time("retry_at", job.RunAt),
+		)...)
+	}
+
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer saveCancel()
+	if err := p.store.Update(saveCtx, job); err != nil {
+		logger.Log.Error("Failed to save job result", slog.String("job_id", job.ID), slog.Any("error", err))
+	}
+}
+
+// callHandler runs the handler, turning a panic into an error
+// so one bad job can't kill the worker goroutine (and the process)
+func callHandler(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return handler(ctx, job)
+}
@@ -0,0 +1,175 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/logger"
+)
+
+// waitForStatus polls the store until the job reaches want or the test times out
+func waitForStatus(t *testing.T, p *Pool, id string, want Status) *Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := p.Get(context.Background(), id)
+		if err == nil && job.Status == want {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Job %s did not reach status '%s'", id, want)
+	return nil
+}
+
+// TestPool_RunsJob tests that an enqueued job is run with its payload
+func TestPool_RunsJob(t *testing.T) {
+	// Arrange
+	logger.Init()
+	p := NewPool(NewMemoryStore(), Options{Workers: 2, PollInterval: 10 * time.Millisecond})
+
+	var got string
+	p.Register("email.send", func(ctx context.Context, job *Job) error {
+		var payload struct{ To string }
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		got = payload.To
+		return nil
+	}, RetryPolicy{})
+	p.Start()
+	defer p.Shutdown(context.Background())
+
+	// Act
+	job, err := p.Enqueue(context.Background(), "email.send", map[string]string{"To": "ada@example.com"})
+	if err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+
+	// Assert
+	done := waitForStatus(t, p, job.ID, StatusSucceeded)
+	if got != "ada@example.com" {
+		t.Errorf("Expected handler to receive 'ada@example.com', got '%s'", got)
+	}
+	if done.Attempts != 1 || done.FinishedAt == nil {
+		t.Errorf("Expected 1 attempt and a finish time, got %+v", done)
+	}
+
+	t.Logf("✅ Job %s succeeded", done.ID)
+}
+
+// TestPool_RetriesThenFails tests that a failing job is retried up to MaxAttempts
+func TestPool_RetriesThenFails(t *testing.T) {
+	// Arrange
+	logger.Init()
+	p := NewPool(NewMemoryStore(), Options{Workers: 1, PollInterval: 5 * time.Millisecond})
+
+	var calls atomic.Int32
+	p.Register("webhook.deliver", func(ctx context.Context, job *Job) error {
+		calls.Add(1)
+		return errors.New("connection refused")
+	}, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	p.Start()
+	defer p.Shutdown(context.Background())
+
+	// Act
+	job, _ := p.Enqueue(context.Background(), "webhook.deliver", nil)
+
+	// Assert
+	failed := waitForStatus(t, p, job.ID, StatusFailed)
+	if calls.Load() != 3 || failed.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d calls and %d attempts", calls.Load(), failed.Attempts)
+	}
+	if failed.LastError != "connection refused" {
+		t.Errorf("Expected last error 'connection refused', got '%s'", failed.LastError)
+	}
+}
+
+// TestPool_RetryWaitsForBackoff tests that a failed job isn't run again until
+// its backoff has passed, using a fake clock instead of waiting an hour
+func TestPool_RetryWaitsForBackoff(t *testing.T) {
+	// Arrange
+	logger.Init()
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	p := NewPool(NewMemoryStore(), Options{Workers: 1, PollInterval: 5 * time.Millisecond, Clock: fake})
+
+	var calls atomic.Int32
+	p.Register("report.build", func(ctx context.Context, job *Job) error {
+		if calls.Add(1) == 1 {
+			return errors.New("temporarily unavailable")
+		}
+		return nil
+	}, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour})
+	p.Start()
+	defer p.Shutdown(context.Background())
+
+	// Act: the first attempt fails
+	job, _ := p.Enqueue(context.Background(), "report.build", nil)
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	queued := waitForStatus(t, p, job.ID, StatusQueued)
+
+	// Assert: retry scheduled an hour later, and not run while the clock stands still
+	if want := fake.Now().UTC().Add(time.Hour); !queued.RunAt.Equal(want) {
+		t.Errorf("Expected the retry at %v, got %v", want, queued.RunAt)
+	}
+	time.Sleep(30 * time.Millisecond) // several polls
+	if calls.Load() != 1 {
+		t.Fatalf("Expected no retry before the backoff passed, got %d calls", calls.Load())
+	}
+
+	// An hour later (instantly) the retry runs
+	fake.Advance(time.Hour)
+	done := waitForStatus(t, p, job.ID, StatusSucceeded)
+	if done.Attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", done.Attempts)
+	}
+
+	t.Logf("✅ Job retried after an hour of fake time")
+}
+
+// TestPool_ShutdownDrains tests that Shutdown waits for the running job
+func TestPool_ShutdownDrains(t *testing.T) {
+	// Arrange
+	logger.Init()
+	p := NewPool(NewMemoryStore(), Options{Workers: 1, PollInterval: 5 * time.Millisecond})
+
+	started := make(chan struct{})
+	p.Register("import.csv", func(ctx context.Context, job *Job) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}, RetryPolicy{})
+	p.Start()
+	job, _ := p.Enqueue(context.Background(), "import.csv", nil)
+	<-started
+
+	// Act
+	err := p.Shutdown(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+	done, _ := p.Get(context.Background(), job.ID)
+	if done.Status != StatusSucceeded {
+		t.Errorf("Expected running job to finish before Shutdown returned, got '%s'", done.Status)
+	}
+}
+
+// TestRetryPolicy_Backoff tests exponential backoff with a cap
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := policy.Backoff(i + 1); got != want {
+			t.Errorf("Attempt %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+}
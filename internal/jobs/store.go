@@ -0,0 +1,203 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store persists the queue
+// MongoStore is used in production; MemoryStore in tests
+type Store interface {
+	// Insert adds a new job
+	Insert(ctx context.Context, job *Job) error
+
+	// Claim atomically picks the next due job of one of the given types,
+	// marks it running until leaseUntil and counts the attempt
+	// Returns nil (and no error) when nothing is due.
+	Claim(ctx context.Context, types []string, now, leaseUntil time.Time) (*Job, error)
+
+	// Update saves the outcome of an attempt (status, errors, next run time)
+	Update(ctx context.Context, job *Job) error
+
+	// Get returns a job by ID, or ErrNotFound
+	Get(ctx context.Context, id string) (*Job, error)
+}
+
+// ============================================================================
+// MONGODB STORE
+// ============================================================================
+
+// finishedJobTTL is how long succeeded/failed jobs stay queryable
+const finishedJobTTL = 7 * 24 * time.Hour
+
+// MongoStore keeps jobs in a MongoDB collection
+// Claim uses FindOneAndUpdate, so any number of workers (and replicas) can
+// poll the same collection without running a job twice.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store on the given collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// EnsureIndexes creates the indexes the queue needs
+// - status + run_at: makes Claim fast
+// - finished_at TTL: MongoDB deletes finished jobs after finishedJobTTL
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "type", Value: 1}, {Key: "run_at", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "finished_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(finishedJobTTL.Seconds())),
+		},
+	})
+	return err
+}
+
+// Insert adds a new job
+func (s *MongoStore) Insert(ctx context.Context, job *Job) error {
+	_, err := s.collection.InsertOne(ctx, job)
+	return err
+}
+
+// Claim picks the oldest due job: queued and due, or running with an expired
+// lease (its worker crashed or was killed mid-job)
+func (s *MongoStore) Claim(ctx context.Context, types []string, now, leaseUntil time.Time) (*Job, error) {
+	filter := bson.M{
+		"type": bson.M{"$in": types},
+		"$or": bson.A{
+			bson.M{"status": StatusQueued, "run_at": bson.M{"$lte": now}},
+			bson.M{"status": StatusRunning, "locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": StatusRunning, "locked_until": leaseUntil, "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job Job
+	err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Update saves the outcome of an attempt
+func (s *MongoStore) Update(ctx context.Context, job *Job) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": job.ID}, job)
+	return err
+}
+
+// Get returns a job by ID
+func (s *MongoStore) Get(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ============================================================================
+// IN-MEMORY STORE
+// ============================================================================
+
+// MemoryStore keeps jobs in a map
+// Jobs are lost on restart and not shared between processes - use it in tests
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*Job)}
+}
+
+// Insert adds a new job
+func (s *MemoryStore) Insert(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *job
+	s.jobs[job.ID] = &copied
+	return nil
+}
+
+// Claim picks the oldest due job
+func (s *MemoryStore) Claim(ctx context.Context, types []string, now, leaseUntil time.Time) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[string]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	var due []*Job
+	for _, job := range s.jobs {
+		if !wanted[job.Type] {
+			continue
+		}
+		queued := job.Status == StatusQueued && !job.RunAt.After(now)
+		expired := job.Status == StatusRunning && !job.LockedUntil.After(now)
+		if queued || expired {
+			due = append(due, job)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+
+	job := due[0]
+	job.Status = StatusRunning
+	job.LockedUntil = leaseUntil
+	job.UpdatedAt = now
+	job.Attempts++
+
+	copied := *job
+	return &copied, nil
+}
+
+// Update saves the outcome of an attempt
+func (s *MemoryStore) Update(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.ID]; !ok {
+		return ErrNotFound
+	}
+	copied := *job
+	s.jobs[job.ID] = &copied
+	return nil
+}
+
+// Get returns a job by ID
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
@@ -0,0 +1,421 @@
+// Package linkpreview shows what the links in task descriptions point at
+// For each http(s) URL in a description the job workers fetch the page and
+// keep its title, description and image (from its Open Graph tags, or
+// <title> and <meta name="description">) in the "link_previews" collection.
+// Reading a task never waits for a page: links without a preview yet are
+// queued and show up on a later read.
+//
+// Pages are fetched with the outbound client, which won't connect to the
+// private network - the URLs come from users.
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// JobFetch fetches the page behind one URL and saves its preview
+const JobFetch = "linkpreview.fetch"
+
+const (
+	// MaxLinks is how many links of a description get a preview
+	MaxLinks = 5
+	// TTL is how long a preview is used before the page is fetched again
+	TTL = 7 * 24 * time.Hour
+	// failedTTL is how long a page that couldn't be previewed is left alone
+	failedTTL = time.Hour
+	// requeueAfter stops one instance queuing the same URL on every read
+	// while its job waits for a worker
+	requeueAfter = time.Minute
+	// maxBody is how much of a page is read: the <head> is near the start
+	maxBody = 512 << 10
+)
+
+// Preview is what a page says about itself
+type Preview struct {
+	URL         string    `bson:"_id"`
+	Title       string    `bson:"title,omitempty"`
+	Description string    `bson:"description,omitempty"`
+	Image       string    `bson:"image,omitempty"`
+	Error       string    `bson:"error,omitempty"` // Why there's no preview (not HTML, 404...)
+	FetchedAt   time.Time `bson:"fetched_at"`
+}
+
+// ============================================================================
+// FINDING LINKS
+// ============================================================================
+
+// linkRe matches an http(s) URL in text or Markdown ([text](url), <url>)
+var linkRe = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+// Links returns the first MaxLinks distinct http(s) URLs in text, without
+// the punctuation that ends the sentence around them
+func Links(text string) []string {
+	var links []string
+	for _, link := range linkRe.FindAllString(text, -1) {
+		link = strings.TrimRight(link, ".,:;!?*_~")
+		u, err := url.Parse(link)
+		if err != nil || u.Host == "" || slices.Contains(links, link) {
+			continue
+		}
+		links = append(links, link)
+		if len(links) == MaxLinks {
+			break
+		}
+	}
+	return links
+}
+
+// ============================================================================
+// PREVIEWER
+// ============================================================================
+
+// Previewer looks up previews and fetches the missing ones on the workers
+type Previewer struct {
+	store  Store
+	client *http.Client
+	clock  clock.Clock
+	pool   *jobs.Pool // Set by Register; nil = the default pool
+
+	mu     sync.Mutex
+	queued map[string]time.Time // URL → when this instance last queued it
+}
+
+// New creates a previewer that keeps previews in store and fetches pages
+// with client; a nil clock is the real one
+func New(store Store, client *http.Client, c clock.Clock) *Previewer {
+	return &Previewer{store: store, client: client, clock: clock.OrReal(c), queued: map[string]time.Time{}}
+}
+
+// Register adds the fetch job handler to a worker pool
+// Call it before pool.Start(), on every process that runs workers.
+func (p *Previewer) Register(pool *jobs.Pool) {
+	p.pool = pool
+	pool.Register(JobFetch, p.fetch, jobs.RetryPolicy{MaxAttempts: 2, Timeout: 30 * time.Second})
+}
+
+// fetchPayload is the JobFetch payload
+type fetchPayload struct {
+	URL string `json:"url"`
+}
+
+// Lookup returns the previews of the links in text, in the order they
+// appear, and queues a fetch for the links that have none or an old one
+// Links that couldn't be previewed are left out.
+func (p *Previewer) Lookup(ctx context.Context, text string) ([]Preview, error) {
+	links := Links(text)
+	if len(links) == 0 {
+		return nil, nil
+	}
+	found, err := p.store.Get(ctx, links)
+	if err != nil {
+		return nil, err
+	}
+
+	var previews []Preview
+	for _, link := range links {
+		preview, ok := found[link]
+		if !ok || p.stale(preview) {
+			p.queue(ctx, link)
+		}
+		if ok && preview.Error == "" {
+			previews = append(previews, preview)
+		}
+	}
+	return previews, nil
+}
+
+// Queue queues a fetch for each link in text without a fresh preview
+// The handlers call it after a description is written, so the previews are
+// usually ready by the time the task is read.
+func (p *Previewer) Queue(ctx context.Context, text string) error {
+	_, err := p.Lookup(ctx, text)
+	return err
+}
+
+// stale reports whether a preview should be fetched again
+func (p *Previewer) stale(preview Preview) bool {
+	ttl := TTL
+	if preview.Error != "" {
+		ttl = failedTTL
+	}
+	return p.clock.Now().Sub(preview.FetchedAt) >= ttl
+}
+
+// queue enqueues a fetch of link, unless this instance just did
+// A failure is logged: the preview is only missing for now.
+func (p *Previewer) queue(ctx context.Context, link string) {
+	p.mu.Lock()
+	now := p.clock.Now()
+	if last, ok := p.queued[link]; ok && now.Sub(last) < requeueAfter {
+		p.mu.Unlock()
+		return
+	}
+	p.queued[link] = now
+	for queuedLink, at := range p.queued {
+		if now.Sub(at) >= requeueAfter {
+			delete(p.queued, queuedLink)
+		}
+	}
+	p.mu.Unlock()
+
+	var err error
+	if p.pool != nil {
+		_, err = p.pool.Enqueue(ctx, JobFetch, fetchPayload{URL: link})
+	} else {
+		_, err = jobs.Enqueue(ctx, JobFetch, fetchPayload{URL: link})
+	}
+	if err != nil {
+		logger.WithTrace(ctx).Warn("Failed to queue a link preview", slog.String("url", link), slog.Any("error", err))
+	}
+}
+
+// fetch handles JobFetch
+// A page that can't be previewed gets a preview with its Error, so it isn't
+// fetched again for a while; only saving the preview is retried.
+func (p *Previewer) fetch(ctx context.Context, job *jobs.Job) error {
+	var payload fetchPayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+	preview, err := p.Fetch(ctx, payload.URL)
+	if err != nil {
+		logger.WithTrace(ctx).Info("No link preview", slog.String("url", payload.URL), slog.Any("error", err))
+		preview = Preview{URL: payload.URL, Error: err.Error()}
+	}
+	preview.FetchedAt = p.clock.Now().UTC()
+	return p.store.Save(ctx, preview)
+}
+
+// errNotHTML is returned for links to images, PDFs and other files
+var errNotHTML = errors.New("linkpreview: not an HTML page")
+
+// Fetch reads the preview of the page at link
+func (p *Previewer) Fetch(ctx context.Context, link string) (Preview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "go-todo-api link preview")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Preview{}, fmt.Errorf("linkpreview: %s answered %d", link, resp.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Preview{}, errNotHTML
+	}
+
+	// Relative image URLs are relative to where the redirects ended
+	preview := parse(io.LimitReader(resp.Body, maxBody), resp.Request.URL)
+	preview.URL = link
+	return preview, nil
+}
+
+// parse reads a page's <head> for its preview
+// Open Graph tags win over <title> and <meta name="description">.
+func parse(r io.Reader, base *url.URL) Preview {
+	var preview Preview
+	var title, description string
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return finish(preview, title, description, base)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Body:
+				return finish(preview, title, description, base)
+			case atom.Title:
+				if title == "" && z.Next() == html.TextToken {
+					title = string(z.Text())
+				}
+			case atom.Meta:
+				var key, content string
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = z.TagAttr()
+					switch strings.ToLower(string(k)) {
+					case "property", "name":
+						key = strings.ToLower(string(v))
+					case "content":
+						content = string(v)
+					}
+				}
+				switch key {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "og:image":
+					preview.Image = content
+				case "description":
+					description = content
+				}
+			}
+
+		case html.EndTagToken:
+			if name, _ := z.TagName(); atom.Lookup(name) == atom.Head {
+				return finish(preview, title, description, base)
+			}
+		}
+	}
+}
+
+// finish fills the gaps in preview and tidies it up
+func finish(preview Preview, title, description string, base *url.URL) Preview {
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	if preview.Description == "" {
+		preview.Description = description
+	}
+	preview.Title = clean(preview.Title, 200)
+	preview.Description = clean(preview.Description, 500)
+
+	// Only an http(s) image: clients put it in an <img>
+	if preview.Image != "" {
+		image, err := base.Parse(strings.TrimSpace(preview.Image))
+		if err == nil && (image.Scheme == "https" || image.Scheme == "http") {
+			preview.Image = image.String()
+		} else {
+			preview.Image = ""
+		}
+	}
+	return preview
+}
+
+// clean collapses whitespace and cuts s to max characters
+func clean(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
+
+// ============================================================================
+// STORES
+// ============================================================================
+
+// Store keeps the previews, one per URL
+type Store interface {
+	// Get returns the saved previews of urls, by URL
+	Get(ctx context.Context, urls []string) (map[string]Preview, error)
+	// Save adds or replaces the preview of its URL
+	Save(ctx context.Context, preview Preview) error
+}
+
+// MongoStore keeps the previews in a collection, with the URL as _id
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store on collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// Get returns the saved previews of urls
+func (s *MongoStore) Get(ctx context.Context, urls []string) (map[string]Preview, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"_id": bson.M{"$in": urls}})
+	if err != nil {
+		return nil, err
+	}
+	var previews []Preview
+	if err := cursor.All(ctx, &previews); err != nil {
+		return nil, err
+	}
+	found := make(map[string]Preview, len(previews))
+	for _, preview := range previews {
+		found[preview.URL] = preview
+	}
+	return found, nil
+}
+
+// Save upserts the preview
+func (s *MongoStore) Save(ctx context.Context, preview Preview) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": preview.URL}, preview, options.Replace().SetUpsert(true))
+	return err
+}
+
+// MemoryStore keeps the previews in memory - use it in tests
+type MemoryStore struct {
+	mu       sync.Mutex
+	previews map[string]Preview
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{previews: map[string]Preview{}}
+}
+
+// Get returns the saved previews of urls
+func (s *MemoryStore) Get(ctx context.Context, urls []string) (map[string]Preview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := map[string]Preview{}
+	for _, u := range urls {
+		if preview, ok := s.previews[u]; ok {
+			found[u] = preview
+		}
+	}
+	return found, nil
+}
+
+// Save adds or replaces the preview
+func (s *MemoryStore) Save(ctx context.Context, preview Preview) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.previews[preview.URL] = preview
+	return nil
+}
+
+// ============================================================================
+// DEFAULT PREVIEWER
+// ============================================================================
+
+// defaultPreviewer is used by the handlers
+// It's set once at startup by Init (nil = no link previews)
+var defaultPreviewer *Previewer
+
+// Init sets the default previewer; Init(nil) turns link previews off
+func Init(p *Previewer) *Previewer {
+	defaultPreviewer = p
+	return p
+}
+
+// Default returns the previewer set by Init (nil before Init)
+func Default() *Previewer {
+	return defaultPreviewer
+}
@@ -0,0 +1,156 @@
+package linkpreview
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/outbound"
+)
+
+// testPage is the <head> of a page with both Open Graph and plain tags
+const testPage = `<!doctype html>
+<html><head>
+<title>Plain &amp; simple</title>
+<meta name="description" content="The plain description">
+<meta property="og:title" content="Oat Milk">
+<meta property="og:description" content="  Creamy,
+  and good in coffee ">
+<meta property="og:image" content="/img/oat.png">
+</head><body><h1>Ignored</h1></body></html>`
+
+// TestLinks tests finding the URLs in a description
+func TestLinks(t *testing.T) {
+	text := "See https://example.com/a, (https://example.com/b) and <http://example.com/c>\n" +
+		"again https://example.com/a; not ftp://example.com or example.com"
+
+	got := Links(text)
+
+	want := []string{"https://example.com/a", "https://example.com/b", "http://example.com/c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Links() = %v, want %v", got, want)
+	}
+
+	t.Logf("✅ Found %d links", len(got))
+}
+
+// TestPreviewer_Fetch tests reading a page's preview
+func TestPreviewer_Fetch(t *testing.T) {
+	// Arrange
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, testPage)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/file.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	p := New(NewMemoryStore(), outbound.NewClient(outbound.Options{AllowPrivate: true}), nil)
+
+	// Act
+	preview, err := p.Fetch(context.Background(), server.URL+"/moved")
+	_, pdfErr := p.Fetch(context.Background(), server.URL+"/file.pdf")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	want := Preview{
+		URL:         server.URL + "/moved",
+		Title:       "Oat Milk",
+		Description: "Creamy, and good in coffee",
+		Image:       server.URL + "/img/oat.png",
+	}
+	if preview != want {
+		t.Errorf("Fetch() = %+v, want %+v", preview, want)
+	}
+	if pdfErr != errNotHTML {
+		t.Errorf("Expected errNotHTML for a PDF, got %v", pdfErr)
+	}
+
+	t.Logf("✅ Preview: %q", preview.Title)
+}
+
+// TestPreviewer_FetchBlocksPrivateAddresses tests that a link can't make the
+// server fetch from its own network
+func TestPreviewer_FetchBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("The private server was reached")
+	}))
+	defer server.Close()
+	p := New(NewMemoryStore(), outbound.NewClient(outbound.Options{}), nil)
+
+	_, err := p.Fetch(context.Background(), server.URL)
+
+	if err == nil {
+		t.Fatal("Expected an error fetching from the loopback address")
+	}
+
+	t.Logf("✅ Refused: %v", err)
+}
+
+// TestPreviewer_Lookup tests that looking up a description queues its links
+// and returns the previews once they're fetched
+func TestPreviewer_Lookup(t *testing.T) {
+	// Arrange
+	logger.Init()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, testPage)
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	p := New(store, outbound.NewClient(outbound.Options{AllowPrivate: true}), nil)
+	pool := jobs.NewPool(jobs.NewMemoryStore(), jobs.Options{Workers: 2, PollInterval: 10 * time.Millisecond})
+	p.Register(pool)
+	pool.Start()
+	defer pool.Shutdown(context.Background())
+	description := "Recipe: " + server.URL + "/page (old link: " + server.URL + "/missing)"
+
+	// Act
+	first, err := p.Lookup(context.Background(), description)
+	if err != nil {
+		t.Fatalf("Lookup returned error: %v", err)
+	}
+
+	// Assert
+	if len(first) != 0 {
+		t.Errorf("Expected no previews before the fetch, got %+v", first)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		saved, _ := store.Get(context.Background(), Links(description))
+		if len(saved) == 2 {
+			if saved[server.URL+"/missing"].Error == "" {
+				t.Error("Expected the missing page to be saved with its error")
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("The previews weren't fetched, got %+v", saved)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	previews, _ := p.Lookup(context.Background(), description)
+	if len(previews) != 1 || previews[0].Title != "Oat Milk" {
+		t.Errorf("Expected the page's preview only, got %+v", previews)
+	}
+
+	t.Logf("✅ Previews ready: %+v", previews)
+}
@@ -0,0 +1,177 @@
+// Package locale formats dates and numbers the way a user's language writes
+// them, for what people read rather than programs: the CSV export and the
+// daily digest. The JSON the API returns keeps RFC 3339 and plain numbers.
+//
+// A locale comes from an Accept-Language header or a user's profile, and is
+// matched to the closest one supported ("de-AT" gets German, "en-AU"
+// English). Only the formats change - the text around them stays English.
+package locale
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// ErrUnsupported is returned by Parse for a tag that isn't one, or is a
+// language none of the supported locales is close to
+var ErrUnsupported = errors.New("unsupported locale: use " + strings.Join(Supported(), ", "))
+
+// Locale is a way of writing dates and numbers
+// The zero value is Default.
+type Locale struct {
+	f *formats
+}
+
+// formats are a locale's names and layouts
+type formats struct {
+	tag      language.Tag
+	months   [12]string
+	weekdays [7]string // Sunday first, like time.Weekday
+	longDate string    // With {weekday}, {day} and {month}, e.g. "{weekday}, {day} {month}"
+	date     string    // time.Format layout
+	clock    string    // time.Format layout
+}
+
+// The supported locales; the first is the default
+// "en" writes the day first, as the digest always has; "en-US" the month.
+var all = []*formats{
+	{
+		tag:      language.English,
+		months:   [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		weekdays: [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		longDate: "{weekday}, {day} {month}",
+		date:     "02/01/2006",
+		clock:    "15:04",
+	},
+	{
+		tag:      language.AmericanEnglish,
+		months:   [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		weekdays: [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		longDate: "{weekday}, {month} {day}",
+		date:     "01/02/2006",
+		clock:    "3:04 PM",
+	},
+	{
+		tag:      language.German,
+		months:   [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		weekdays: [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		longDate: "{weekday}, {day}. {month}",
+		date:     "02.01.2006",
+		clock:    "15:04",
+	},
+	{
+		tag:      language.French,
+		months:   [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		weekdays: [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		longDate: "{weekday} {day} {month}",
+		date:     "02/01/2006",
+		clock:    "15:04",
+	},
+	{
+		tag:      language.Spanish,
+		months:   [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		weekdays: [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		longDate: "{weekday}, {day} de {month}",
+		date:     "02/01/2006",
+		clock:    "15:04",
+	},
+}
+
+// matcher picks the closest supported locale for a tag
+var matcher = func() language.Matcher {
+	tags := make([]language.Tag, len(all))
+	for i, f := range all {
+		tags[i] = f.tag
+	}
+	return language.NewMatcher(tags)
+}()
+
+// Default is the locale of callers who didn't pick one
+var Default = Locale{f: all[0]}
+
+// Supported returns the tags of the supported locales
+func Supported() []string {
+	tags := make([]string, len(all))
+	for i, f := range all {
+		tags[i] = f.tag.String()
+	}
+	return tags
+}
+
+// Parse returns the supported locale closest to a BCP 47 tag, e.g. "fr-CA"
+func Parse(tag string) (Locale, error) {
+	t, err := language.Parse(tag)
+	if err != nil {
+		return Default, ErrUnsupported
+	}
+	return match(t)
+}
+
+// FromAcceptLanguage returns the supported locale the client prefers most
+// ok is false when the header names none of them (or is empty).
+func FromAcceptLanguage(header string) (l Locale, ok bool) {
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 {
+		return Default, false
+	}
+	l, err = match(tags...)
+	return l, err == nil
+}
+
+// match returns the supported locale closest to the tags, in order of preference
+func match(tags ...language.Tag) (Locale, error) {
+	_, i, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default, ErrUnsupported
+	}
+	return Locale{f: all[i]}, nil
+}
+
+// formats returns the locale's formats (Default's for the zero value)
+func (l Locale) formats() *formats {
+	if l.f == nil {
+		return Default.f
+	}
+	return l.f
+}
+
+// String returns the locale's tag, e.g. "de"
+func (l Locale) String() string {
+	return l.formats().tag.String()
+}
+
+// LongDate writes a day in words, e.g. "Friday, 31 January"
+func (l Locale) LongDate(t time.Time) string {
+	f := l.formats()
+	return strings.NewReplacer(
+		"{weekday}", f.weekdays[t.Weekday()],
+		"{day}", strconv.Itoa(t.Day()),
+		"{month}", f.months[t.Month()-1],
+	).Replace(f.longDate)
+}
+
+// Date writes a day in digits, e.g. "31/01/2025"
+func (l Locale) Date(t time.Time) string {
+	return t.Format(l.formats().date)
+}
+
+// Time writes a time of day, e.g. "17:00" or "5:00 PM"
+func (l Locale) Time(t time.Time) string {
+	return t.Format(l.formats().clock)
+}
+
+// DateTime writes a day and a time, e.g. "31/01/2025 17:00"
+func (l Locale) DateTime(t time.Time) string {
+	return l.Date(t) + " " + l.Time(t)
+}
+
+// Number writes a whole number with the locale's digit grouping, e.g.
+// "12,345" or "12.345"
+func (l Locale) Number(n int) string {
+	return message.NewPrinter(l.formats().tag).Sprintf("%d", n)
+}
@@ -0,0 +1,55 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLocale tests the formats of a few locales, and how tags are matched
+func TestLocale(t *testing.T) {
+	// Arrange: Friday 31 January 2025, 5pm
+	at := time.Date(2025, 1, 31, 17, 0, 0, 0, time.UTC)
+	tests := []struct {
+		tag                   string
+		want                  string // The matched locale
+		longDate, dateTime, n string
+	}{
+		{"en", "en", "Friday, 31 January", "31/01/2025 17:00", "12,345"},
+		{"en-US", "en-US", "Friday, January 31", "01/31/2025 5:00 PM", "12,345"},
+		{"de-AT", "de", "Freitag, 31. Januar", "31.01.2025 17:00", "12.345"},
+		{"fr-CA", "fr", "vendredi 31 janvier", "31/01/2025 17:00", "12 345"},
+		{"es", "es", "viernes, 31 de enero", "31/01/2025 17:00", "12.345"},
+	}
+
+	for _, tt := range tests {
+		// Act
+		l, err := Parse(tt.tag)
+
+		// Assert
+		if err != nil || l.String() != tt.want {
+			t.Errorf("Parse(%q): expected %s, got %s (err %v)", tt.tag, tt.want, l, err)
+			continue
+		}
+		if got := l.LongDate(at); got != tt.longDate {
+			t.Errorf("%s: expected %q, got %q", tt.tag, tt.longDate, got)
+		}
+		if got := l.DateTime(at); got != tt.dateTime {
+			t.Errorf("%s: expected %q, got %q", tt.tag, tt.dateTime, got)
+		}
+		if got := l.Number(12345); got != tt.n {
+			t.Errorf("%s: expected %q, got %q", tt.tag, tt.n, got)
+		}
+	}
+
+	if _, err := Parse("ja"); err == nil {
+		t.Error("Expected Japanese to be unsupported")
+	}
+	if l, ok := FromAcceptLanguage("ja, de-CH;q=0.8, en;q=0.5"); !ok || l.String() != "de" {
+		t.Errorf("Expected German from the header, got %s (ok %v)", l, ok)
+	}
+	if _, ok := FromAcceptLanguage(""); ok {
+		t.Error("Expected no locale from an empty header")
+	}
+
+	t.Log("✅ Dates and numbers written per locale")
+}
@@ -1,25 +1,76 @@
 package logger
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+
+	"go-todo-api/internal/config"
 )
 
 // Global logger instance
 // All parts of the app will use this single logger
 var Log *slog.Logger
 
+// Level is the minimum level the logger writes
+// It's a LevelVar so it can be changed while the server is running
+// (e.g. switch to debug during an incident without redeploying)
+var Level = new(slog.LevelVar)
+
 // Init initialises the structured logger
 // Call lthis once at startup before using log
 func Init() {
-	// Create a JSON handler that writes to stdout (console)
-	// JSON format makes it easy for Loki to parse
-	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo, // Log Info, Warn, Error (skip Debug in production)
-	})
+	// Start at the level from LOG_LEVEL (debug, info, warn, error), default info
+	if err := SetLevel(os.Getenv("LOG_LEVEL")); err != nil {
+		Level.Set(slog.LevelInfo)
+	}
+
+	// The format comes from the environment profile (APP_ENV / LOG_FORMAT):
+	// JSON in staging and prod because it's easy for Loki to parse,
+	// key=value text in dev because it's easy to read in a terminal
+	profile, profileErr := config.LoadProfile()
+	if profileErr != nil {
+		profile.LogFormat = "json" // main reports the bad setting and exits
+	}
+
+	options := &slog.HandlerOptions{
+		Level: Level, // Read on every log call, so SetLevel takes effect immediately
+	}
+	var handler slog.Handler
+	if profile.LogFormat == "text" {
+		handler = slog.NewTextHandler(os.Stdout, options)
+	} else {
+		handler = slog.NewJSONHandler(os.Stdout, options)
+	}
 
 	// Create the logger with our handler
 	Log = slog.New(handler)
 
-	Log.Info("Logger initialised", "format", "json")
+	Log.Info("Logger initialised", "format", profile.LogFormat, "level", Level.Level().String())
+}
+
+// SetLevel changes the log level at runtime
+// Accepts debug, info, warn or error (case-insensitive); empty means info
+func SetLevel(name string) error {
+	var level slog.Level
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		level = slog.LevelDebug
+	case "info", "":
+		level = slog.LevelInfo
+	case "warn", "warning":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		return fmt.Errorf("unknown log level %q (use debug, info, warn or error)", name)
+	}
+	Level.Set(level)
+	return nil
+}
+
+// LevelName returns the current level as a lowercase string (e.g. "debug")
+func LevelName() string {
+	return strings.ToLower(Level.Level().String())
 }
@@ -0,0 +1,515 @@
+// Package markdown renders task descriptions written in Markdown as HTML
+//
+// Web clients put the HTML straight into a page, so it has to be safe there.
+// Instead of rendering and then sanitizing, the renderer only ever writes
+// tags of its own (the short list below) and escapes every piece of text it
+// copies from the source: HTML in a description comes out as text, and a
+// link is only kept when its URL is http, https or mailto.
+//
+// It covers what people write in short notes, not all of CommonMark:
+//
+//	# Heading (to ######)    > quote    --- (a rule)    ``` fenced code ```
+//	- item, * item, + item, 1. item (lists don't nest)
+//	**bold** __bold__ *em* _em_ ~~strike~~ `code`
+//	[text](https://example.com "title")  <https://example.com>  https://example.com
+//
+// As in CommonMark, lines of a paragraph are joined; end one with two
+// spaces or a backslash to keep the break.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HTML renders src as HTML that is safe to put in a page as it is
+// e.g. "Buy **oat** milk <script>" → "<p>Buy <strong>oat</strong> milk &lt;script&gt;</p>\n"
+func HTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	var b strings.Builder
+	blocks(&b, strings.Split(src, "\n"))
+	return b.String()
+}
+
+// ============================================================================
+// BLOCKS
+// ============================================================================
+
+var (
+	headingRe = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	ruleRe    = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	bulletRe  = regexp.MustCompile(`^ {0,3}[-*+][ \t]+(.*)$`)
+	numberRe  = regexp.MustCompile(`^ {0,3}(\d{1,9})[.)][ \t]+(.*)$`)
+	fenceRe   = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	quoteRe   = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+)
+
+// blocks writes lines as headings, rules, code, quotes, lists and paragraphs
+func blocks(b *strings.Builder, lines []string) {
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>")
+			paragraph(b, para)
+			b.WriteString("</p>\n")
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+
+		case fenceRe.MatchString(line):
+			flush()
+			fence := fenceRe.FindStringSubmatch(line)[1]
+			var code []string
+			for i++; i < len(lines) && !closesFence(lines[i], fence); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code>")
+			for _, l := range code {
+				b.WriteString(html.EscapeString(l))
+				b.WriteString("\n")
+			}
+			b.WriteString("</code></pre>\n")
+
+		case headingRe.MatchString(line):
+			flush()
+			m := headingRe.FindStringSubmatch(line)
+			level := len(m[1])
+			fmt.Fprintf(b, "<h%d>", level)
+			inline(b, m[2], false)
+			fmt.Fprintf(b, "</h%d>\n", level)
+
+		case ruleRe.MatchString(line):
+			flush()
+			b.WriteString("<hr>\n")
+
+		case quoteRe.MatchString(line):
+			flush()
+			var quoted []string
+			for ; i < len(lines); i++ {
+				m := quoteRe.FindStringSubmatch(lines[i])
+				if m == nil {
+					break
+				}
+				quoted = append(quoted, m[1])
+			}
+			i--
+			b.WriteString("<blockquote>\n")
+			blocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case bulletRe.MatchString(line) || numberRe.MatchString(line):
+			flush()
+			i = list(b, lines, i) - 1
+
+		default:
+			para = append(para, line)
+		}
+	}
+	flush()
+}
+
+// closesFence reports whether line ends a code block opened with fence
+func closesFence(line, fence string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) >= len(fence) && strings.Trim(line, fence[:1]) == ""
+}
+
+// list writes the list that starts at lines[i] and returns the index of the
+// line after it
+func list(b *strings.Builder, lines []string, i int) int {
+	ordered := !bulletRe.MatchString(lines[i])
+	if ordered {
+		start, _ := strconv.Atoi(numberRe.FindStringSubmatch(lines[i])[1])
+		if start != 1 {
+			fmt.Fprintf(b, "<ol start=\"%d\">\n", start)
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	var item []string
+	flush := func() {
+		if item != nil {
+			b.WriteString("<li>")
+			paragraph(b, item)
+			b.WriteString("</li>\n")
+		}
+	}
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if text, ok := listItem(line, ordered); ok {
+			flush()
+			item = []string{text}
+			continue
+		}
+		// An indented line carries on the item, and a blank line doesn't end
+		// the list if another item follows it
+		blank := strings.TrimSpace(line) == ""
+		if !blank && (line[0] == ' ' || line[0] == '\t') {
+			item = append(item, line)
+			continue
+		}
+		if blank && i+1 < len(lines) {
+			if _, ok := listItem(lines[i+1], ordered); ok {
+				continue
+			}
+		}
+		break
+	}
+	flush()
+
+	if ordered {
+		b.WriteString("</ol>\n")
+	} else {
+		b.WriteString("</ul>\n")
+	}
+	return i
+}
+
+// listItem returns the text of a list item of the given kind
+func listItem(line string, ordered bool) (string, bool) {
+	if ruleRe.MatchString(line) {
+		return "", false
+	}
+	if ordered {
+		if m := numberRe.FindStringSubmatch(line); m != nil {
+			return m[2], true
+		}
+		return "", false
+	}
+	if m := bulletRe.FindStringSubmatch(line); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
+// paragraph writes the lines of a paragraph (or list item), keeping the
+// breaks marked with two trailing spaces or a backslash
+func paragraph(b *strings.Builder, lines []string) {
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " \t")
+		last := i == len(lines)-1
+		hard := !last && (strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\"))
+		line = strings.TrimRight(line, " \t")
+		if hard {
+			line = strings.TrimSuffix(line, "\\")
+		}
+		inline(b, line, false)
+		switch {
+		case hard:
+			b.WriteString("<br>\n")
+		case !last:
+			b.WriteString("\n")
+		}
+	}
+}
+
+// ============================================================================
+// INLINE
+// ============================================================================
+
+// special are the characters that may start inline markup
+const special = "\\`[<*_~h"
+
+// inline writes one line's text with its emphasis, code and links
+// Inside a link's text (inLink) nothing becomes a link again.
+func inline(b *strings.Builder, s string, inLink bool) {
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			if n, code, ok := codeSpan(s[i:]); ok {
+				b.WriteString("<code>")
+				b.WriteString(html.EscapeString(code))
+				b.WriteString("</code>")
+				i += n
+				continue
+			}
+			// Unmatched backticks are text, all of them
+			run := runLength(s[i:], '`')
+			b.WriteString(s[i : i+run])
+			i += run
+			continue
+
+		case c == '[' && !inLink:
+			if n, text, href, title, ok := link(s[i:]); ok {
+				if u, ok := safeURL(href); ok {
+					writeAnchor(b, u, title)
+					inline(b, text, true)
+					b.WriteString("</a>")
+				} else {
+					inline(b, text, true) // Keep the text, drop the link
+				}
+				i += n
+				continue
+			}
+
+		case c == '<' && !inLink:
+			if end := strings.IndexByte(s[i:], '>'); end > 0 {
+				if u, ok := safeURL(s[i+1 : i+end]); ok && !strings.ContainsAny(s[i+1:i+end], " <") {
+					writeAnchor(b, u, "")
+					b.WriteString(html.EscapeString(s[i+1 : i+end]))
+					b.WriteString("</a>")
+					i += end + 1
+					continue
+				}
+			}
+
+		case c == 'h' && !inLink && (i == 0 || !isWordChar(s[i-1])):
+			if raw := bareURL(s[i:]); raw != "" {
+				if u, ok := safeURL(raw); ok {
+					writeAnchor(b, u, "")
+					b.WriteString(html.EscapeString(raw))
+					b.WriteString("</a>")
+					i += len(raw)
+					continue
+				}
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			if n, tag, inner, ok := emphasis(s, i); ok {
+				b.WriteString("<" + tag + ">")
+				inline(b, inner, inLink)
+				b.WriteString("</" + tag + ">")
+				i += n
+				continue
+			}
+		}
+
+		// Plain text up to the next character that may start markup
+		j := i + 1
+		for j < len(s) && strings.IndexByte(special, s[j]) < 0 {
+			j++
+		}
+		b.WriteString(html.EscapeString(s[i:j]))
+		i = j
+	}
+}
+
+// codeSpan reads a code span at the start of s: `code`, or code between
+// longer runs of backticks when it has a backtick in it
+func codeSpan(s string) (n int, code string, ok bool) {
+	run := runLength(s, '`')
+	for from := run; from < len(s); {
+		k := strings.IndexByte(s[from:], '`')
+		if k < 0 {
+			return 0, "", false
+		}
+		k += from
+		closing := runLength(s[k:], '`')
+		if closing == run {
+			code = s[run:k]
+			if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			return k + closing, code, true
+		}
+		from = k + closing
+	}
+	return 0, "", false
+}
+
+// link reads [text](url "title") at the start of s
+func link(s string) (n int, text, href, title string, ok bool) {
+	// The text ends at the matching ]
+	depth, end := 0, -1
+	for i := 0; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return 0, "", "", "", false
+	}
+	text = s[1:end]
+
+	// Then the destination, an optional title, and )
+	rest := s[end+2:]
+	i := skipSpaces(rest, 0)
+	if i < len(rest) && rest[i] == '<' {
+		close := strings.IndexByte(rest[i:], '>')
+		if close < 0 {
+			return 0, "", "", "", false
+		}
+		href = rest[i+1 : i+close]
+		i += close + 1
+	} else {
+		start, parens := i, 0
+		for ; i < len(rest) && rest[i] > ' '; i++ {
+			if rest[i] == '(' {
+				parens++
+			} else if rest[i] == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			}
+		}
+		href = rest[start:i]
+	}
+	i = skipSpaces(rest, i)
+	if i < len(rest) && (rest[i] == '"' || rest[i] == '\'') {
+		close := strings.IndexByte(rest[i+1:], rest[i])
+		if close < 0 {
+			return 0, "", "", "", false
+		}
+		title = rest[i+1 : i+1+close]
+		i = skipSpaces(rest, i+close+2)
+	}
+	if i >= len(rest) || rest[i] != ')' {
+		return 0, "", "", "", false
+	}
+	return end + 2 + i + 1, text, href, title, true
+}
+
+// bareURL returns the http(s) URL at the start of s, without the
+// punctuation that ends the sentence around it
+func bareURL(s string) string {
+	if !strings.HasPrefix(s, "https://") && !strings.HasPrefix(s, "http://") {
+		return ""
+	}
+	end := strings.IndexAny(s, " \t<")
+	if end < 0 {
+		end = len(s)
+	}
+	raw := strings.TrimRight(s[:end], ".,:;!?'\"*_~")
+	// A ) closes the URL's own ( - or the sentence's, e.g. (see https://x.com)
+	for strings.HasSuffix(raw, ")") && strings.Count(raw, ")") > strings.Count(raw, "(") {
+		raw = strings.TrimRight(raw[:len(raw)-1], ".,:;!?'\"*_~")
+	}
+	if strings.Contains(raw[strings.Index(raw, "://")+3:], ".") {
+		return raw
+	}
+	return ""
+}
+
+// emphasis reads **strong**, *em*, __strong__, _em_ or ~~del~~ at s[i]
+func emphasis(s string, i int) (n int, tag, inner string, ok bool) {
+	c := s[i]
+	run := runLength(s[i:], c)
+	width := 1
+	switch {
+	case c == '~':
+		if run < 2 {
+			return 0, "", "", false
+		}
+		width, tag = 2, "del"
+	case run >= 2:
+		width, tag = 2, "strong"
+	default:
+		tag = "em"
+	}
+	// snake_case isn't emphasis
+	if c == '_' && i > 0 && isWordChar(s[i-1]) {
+		return 0, "", "", false
+	}
+	delim := s[i : i+width]
+	rest := s[i+width:]
+	if rest == "" || rest[0] == ' ' || rest[0] == '\t' {
+		return 0, "", "", false
+	}
+
+	// The closing delimiter doesn't follow a space, and _ doesn't close
+	// inside a word either
+	for from := 1; from < len(rest); {
+		k := strings.Index(rest[from:], delim)
+		if k < 0 {
+			return 0, "", "", false
+		}
+		k += from
+		after := k + width
+		if rest[k-1] != ' ' && rest[k-1] != '\t' && (c != '_' || after >= len(rest) || !isWordChar(rest[after])) {
+			return width + after, tag, rest[:k], true
+		}
+		from = k + 1
+	}
+	return 0, "", "", false
+}
+
+// writeAnchor opens a link to an URL that passed safeURL
+// nofollow: descriptions are user content; noopener/noreferrer: the linked
+// page gets no handle on the app's window, nor its URL
+func writeAnchor(b *strings.Builder, href, title string) {
+	b.WriteString(`<a href="`)
+	b.WriteString(html.EscapeString(href))
+	b.WriteString(`"`)
+	if title != "" {
+		b.WriteString(` title="`)
+		b.WriteString(html.EscapeString(title))
+		b.WriteString(`"`)
+	}
+	b.WriteString(` rel="nofollow noopener noreferrer">`)
+}
+
+// safeURL returns href if a link may point at it: http and https URLs with
+// a host, and mailto. javascript:, data: and the like never are.
+func safeURL(href string) (string, bool) {
+	if href == "" || strings.ContainsFunc(href, func(r rune) bool { return r <= ' ' || r == 0x7f }) {
+		return "", false
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+	case "mailto":
+		if u.Opaque == "" {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
+
+// runLength counts how many times c repeats at the start of s
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+// skipSpaces returns the index of the first non-space at or after i
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// isWordChar reports whether c is part of a word (letters, digits, and any
+// non-ASCII byte)
+func isWordChar(c byte) bool {
+	return c >= 0x80 || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
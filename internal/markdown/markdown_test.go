@@ -0,0 +1,79 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestHTML tests the Markdown people write in task descriptions
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"paragraph", "Buy milk", "<p>Buy milk</p>\n"},
+		{"soft and hard breaks", "one\ntwo  \nthree", "<p>one\ntwo<br>\nthree</p>\n"},
+		{"emphasis", "**oat** *or* __soy__ _milk_ ~~cow~~", "<p><strong>oat</strong> <em>or</em> <strong>soy</strong> <em>milk</em> <del>cow</del></p>\n"},
+		{"snake_case", "call some_function_name", "<p>call some_function_name</p>\n"},
+		{"code", "run `go test ./...` then ``a ` b``", "<p>run <code>go test ./...</code> then <code>a ` b</code></p>\n"},
+		{"heading", "## Groceries ##", "<h2>Groceries</h2>\n"},
+		{"not a heading", "#hashtag", "<p>#hashtag</p>\n"},
+		{"rule", "above\n\n---\n\nbelow", "<p>above</p>\n<hr>\n<p>below</p>\n"},
+		{"bullets", "- milk\n- eggs\n  (free range)\n\n- bread", "<ul>\n<li>milk</li>\n<li>eggs\n(free range)</li>\n<li>bread</li>\n</ul>\n"},
+		{"numbers", "3. three\n4. four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>\n"},
+		{"quote", "> **Note**\n> twice", "<blockquote>\n<p><strong>Note</strong>\ntwice</p>\n</blockquote>\n"},
+		{"fenced code", "```go\nif a < b {\n```", "<pre><code>if a &lt; b {\n</code></pre>\n"},
+		{"link", `[the shop](https://example.com/shop "Open") now`, `<p><a href="https://example.com/shop" title="Open" rel="nofollow noopener noreferrer">the shop</a> now</p>` + "\n"},
+		{"autolink", "<https://example.com>", `<p><a href="https://example.com" rel="nofollow noopener noreferrer">https://example.com</a></p>` + "\n"},
+		{"bare URL", "(see https://example.com/a_(b).)", `<p>(see <a href="https://example.com/a_(b)" rel="nofollow noopener noreferrer">https://example.com/a_(b)</a>.)</p>` + "\n"},
+		{"mailto", "[mail](mailto:ops@example.com)", `<p><a href="mailto:ops@example.com" rel="nofollow noopener noreferrer">mail</a></p>` + "\n"},
+		{"escapes", `\*not em\* & 1 < 2`, "<p>*not em* &amp; 1 &lt; 2</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.src); got != tt.want {
+				t.Errorf("HTML(%q)\n got: %q\nwant: %q", tt.src, got, tt.want)
+			}
+		})
+	}
+
+	t.Log("✅ Markdown rendered")
+}
+
+// TestHTML_Safe tests that nothing in a description can run script in the
+// page showing it
+func TestHTML_Safe(t *testing.T) {
+	attacks := []string{
+		`<script>alert(1)</script>`,
+		`<img src=x onerror=alert(1)>`,
+		`[click](javascript:alert(1))`,
+		`[click](JaVaScRiPt:alert(1))`,
+		`[click](java	script:alert(1))`,
+		`[click](data:text/html;base64,PHNjcmlwdD4=)`,
+		`[click](vbscript:msgbox)`,
+		`<javascript:alert(1)>`,
+		`[x](https://example.com" onmouseover="alert(1))`,
+		`[x](https://example.com "a\" onmouseover=\"alert(1)")`,
+		"```\n</code><script>alert(1)</script>\n```",
+		"**<b onclick=alert(1)>x</b>**",
+		`[<img src=x onerror=alert(1)>](https://example.com)`,
+		"`<script>`",
+		`> <iframe src="https://evil.example">`,
+		`https://example.com/"><script>alert(1)</script>`,
+	}
+
+	for _, src := range attacks {
+		t.Run(src, func(t *testing.T) {
+			got := strings.ToLower(HTML(src))
+			for _, bad := range []string{"<script", "<img", "<iframe", "<b ", `href="javascript`, `href="data`, `href="vbscript`, `" on`} {
+				if strings.Contains(got, bad) {
+					t.Errorf("HTML(%q) = %q, contains %q", src, got, bad)
+				}
+			}
+		})
+	}
+
+	t.Logf("✅ %d attacks rendered harmless", len(attacks))
+}
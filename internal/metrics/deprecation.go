@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeprecationUsage counts calls to deprecated operations, per operation and
+// caller, so we know who still has to move before an endpoint is retired
+// It's in memory and per instance, like the SLO tracker.
+type DeprecationUsage struct {
+	mu    sync.Mutex
+	calls map[deprecatedCall]*DeprecatedUse
+}
+
+type deprecatedCall struct {
+	operationID string
+	caller      string
+}
+
+// DeprecatedUse is how one caller uses one deprecated operation
+type DeprecatedUse struct {
+	OperationID string
+	Caller      string // Principal.UserID ("" for anonymous calls)
+	Requests    int64
+	LastSeen    time.Time
+}
+
+// NewDeprecationUsage creates an empty tracker
+func NewDeprecationUsage() *DeprecationUsage {
+	return &DeprecationUsage{calls: make(map[deprecatedCall]*DeprecatedUse)}
+}
+
+// Default tracker shared by the middleware and GET /admin/deprecations
+var defaultDeprecations = NewDeprecationUsage()
+
+// Deprecations returns the default tracker
+func Deprecations() *DeprecationUsage {
+	return defaultDeprecations
+}
+
+// Record counts one call
+func (u *DeprecationUsage) Record(operationID, caller string, at time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	key := deprecatedCall{operationID: operationID, caller: caller}
+	use, ok := u.calls[key]
+	if !ok {
+		use = &DeprecatedUse{OperationID: operationID, Caller: caller}
+		u.calls[key] = use
+	}
+	use.Requests++
+	use.LastSeen = at
+}
+
+// Report returns every operation and caller seen, sorted by operation then caller
+func (u *DeprecationUsage) Report() []DeprecatedUse {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	uses := make([]DeprecatedUse, 0, len(u.calls))
+	for _, use := range u.calls {
+		uses = append(uses, *use)
+	}
+	sort.Slice(uses, func(i, j int) bool {
+		if uses[i].OperationID != uses[j].OperationID {
+			return uses[i].OperationID < uses[j].OperationID
+		}
+		return uses[i].Caller < uses[j].Caller
+	})
+	return uses
+}
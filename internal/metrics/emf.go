@@ -0,0 +1,115 @@
+// Package metrics records API metrics
+// This file writes CloudWatch Embedded Metric Format (EMF) log lines.
+// In Lambda, anything printed to stdout goes to CloudWatch Logs, and
+// CloudWatch automatically turns EMF lines into real metrics - no agent,
+// no Prometheus, no PutMetricData calls.
+//
+// Format reference:
+// https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CloudWatch metric units used by this API
+const (
+	UnitMilliseconds = "Milliseconds"
+	UnitCount        = "Count"
+)
+
+// Metric is one value to publish
+type Metric struct {
+	Name  string
+	Unit  string
+	Value float64
+}
+
+// EMFLogger writes metrics as EMF JSON lines
+type EMFLogger struct {
+	mu         sync.Mutex
+	w          io.Writer
+	namespace  string
+	dimensions map[string]string
+}
+
+// NewEMFLogger creates an EMF logger
+// - w: where to write (os.Stdout in Lambda)
+// - namespace: the CloudWatch namespace, e.g. "GoTodoAPI"
+// - dimensions: values every metric is split by, e.g. {"FunctionName": "..."}
+func NewEMFLogger(w io.Writer, namespace string, dimensions map[string]string) *EMFLogger {
+	return &EMFLogger{w: w, namespace: namespace, dimensions: dimensions}
+}
+
+// emfMetricDefinition describes one metric in the _aws metadata block
+type emfMetricDefinition struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// emfDirective tells CloudWatch which fields of the line are metrics
+type emfDirective struct {
+	Namespace  string                `json:"Namespace"`
+	Dimensions [][]string            `json:"Dimensions"`
+	Metrics    []emfMetricDefinition `json:"Metrics"`
+}
+
+// emfMetadata is the "_aws" block at the top of every EMF line
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+// Emit writes all metrics as a single EMF line
+//
+// Example output:
+//
+//	{"_aws":{"Timestamp":1700000000000,"CloudWatchMetrics":[{"Namespace":"GoTodoAPI",
+//	 "Dimensions":[["FunctionName"]],"Metrics":[{"Name":"InvocationLatency","Unit":"Milliseconds"}]}]},
+//	 "FunctionName":"go-todo-api-dev-api","InvocationLatency":12.5}
+func (l *EMFLogger) Emit(metrics ...Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	// Dimension keys sorted so the output is stable
+	dimensionKeys := make([]string, 0, len(l.dimensions))
+	for k := range l.dimensions {
+		dimensionKeys = append(dimensionKeys, k)
+	}
+	sort.Strings(dimensionKeys)
+
+	directive := emfDirective{
+		Namespace:  l.namespace,
+		Dimensions: [][]string{dimensionKeys},
+	}
+
+	// The metric values and dimension values are top-level fields
+	line := make(map[string]any, len(metrics)+len(l.dimensions)+1)
+	for k, v := range l.dimensions {
+		line[k] = v
+	}
+	for _, m := range metrics {
+		directive.Metrics = append(directive.Metrics, emfMetricDefinition{Name: m.Name, Unit: m.Unit})
+		line[m.Name] = m.Value
+	}
+	line["_aws"] = emfMetadata{
+		Timestamp:         time.Now().UnixMilli(),
+		CloudWatchMetrics: []emfDirective{directive},
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	// One write per line so concurrent emits never interleave
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// TestEMFLogger_Emit tests that Emit writes a valid EMF line
+func TestEMFLogger_Emit(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	emf := NewEMFLogger(&buf, "GoTodoAPI", map[string]string{"FunctionName": "todo"})
+
+	// Act
+	err := emf.Emit(
+		Metric{Name: "InvocationLatency", Unit: UnitMilliseconds, Value: 12.5},
+		Metric{Name: "ColdStart", Unit: UnitCount, Value: 1},
+	)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Emit returned error: %v", err)
+	}
+
+	var line struct {
+		AWS struct {
+			Timestamp         int64 `json:"Timestamp"`
+			CloudWatchMetrics []struct {
+				Namespace  string     `json:"Namespace"`
+				Dimensions [][]string `json:"Dimensions"`
+				Metrics    []struct {
+					Name string `json:"Name"`
+					Unit string `json:"Unit"`
+				} `json:"Metrics"`
+			} `json:"CloudWatchMetrics"`
+		} `json:"_aws"`
+		FunctionName      string  `json:"FunctionName"`
+		InvocationLatency float64 `json:"InvocationLatency"`
+		ColdStart         float64 `json:"ColdStart"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Output is not JSON: %v (%s)", err, buf.String())
+	}
+
+	directive := line.AWS.CloudWatchMetrics[0]
+	if directive.Namespace != "GoTodoAPI" || len(directive.Metrics) != 2 {
+		t.Errorf("Unexpected directive: %+v", directive)
+	}
+
+	if directive.Dimensions[0][0] != "FunctionName" || line.FunctionName != "todo" {
+		t.Errorf("Expected FunctionName dimension, got %+v / %s", directive.Dimensions, line.FunctionName)
+	}
+
+	if line.InvocationLatency != 12.5 || line.ColdStart != 1 || line.AWS.Timestamp == 0 {
+		t.Errorf("Unexpected metric values: %+v", line)
+	}
+}
@@ -0,0 +1,205 @@
+// This file tracks latency and errors per API operation and compares them
+// against service level objectives (SLOs)
+//
+// Two objectives are tracked for every operation:
+// - Availability: e.g. 99.9% of requests must not return a 5xx
+// - Latency: e.g. 99% of requests must finish within 300ms
+//
+// "Error budget burn" is how fast we're using up the allowed failures:
+// 1.0 means exactly on target, 2.0 means failing twice as often as allowed.
+
+package metrics
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sampleWindow is how many recent latencies we keep per operation
+// Percentiles are computed over this window, so they reflect recent traffic
+const sampleWindow = 2048
+
+// SLOConfig holds the objectives
+type SLOConfig struct {
+	AvailabilityTarget float64                  // Fraction of non-5xx responses, e.g. 0.999
+	LatencyTarget      time.Duration            // Latency threshold, e.g. 300ms
+	LatencyObjective   float64                  // Fraction of requests that must beat LatencyTarget, e.g. 0.99
+	LatencyOverrides   map[string]time.Duration // Per-operation latency thresholds
+}
+
+// SLOConfigFromEnv reads the objectives from environment variables:
+//
+//	SLO_AVAILABILITY_TARGET=0.999
+//	SLO_LATENCY_TARGET_MS=300
+//	SLO_LATENCY_OBJECTIVE=0.99
+//	SLO_LATENCY_OVERRIDES=list-tasks=200,create-task=500
+func SLOConfigFromEnv() SLOConfig {
+	cfg := SLOConfig{
+		AvailabilityTarget: 0.999,
+		LatencyTarget:      300 * time.Millisecond,
+		LatencyObjective:   0.99,
+		LatencyOverrides:   map[string]time.Duration{},
+	}
+
+	if v, err := strconv.ParseFloat(os.Getenv("SLO_AVAILABILITY_TARGET"), 64); err == nil && v > 0 && v < 1 {
+		cfg.AvailabilityTarget = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("SLO_LATENCY_TARGET_MS")); err == nil && v > 0 {
+		cfg.LatencyTarget = time.Duration(v) * time.Millisecond
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("SLO_LATENCY_OBJECTIVE"), 64); err == nil && v > 0 && v < 1 {
+		cfg.LatencyObjective = v
+	}
+	for _, pair := range strings.Split(os.Getenv("SLO_LATENCY_OVERRIDES"), ",") {
+		op, ms, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			continue
+		}
+		if v, err := strconv.Atoi(ms); err == nil && v > 0 {
+			cfg.LatencyOverrides[op] = time.Duration(v) * time.Millisecond
+		}
+	}
+
+	return cfg
+}
+
+// operationStats holds the counters for one operation
+type operationStats struct {
+	requests int64
+	errors   int64
+	slow     int64
+	samples  []time.Duration // Ring buffer of recent latencies
+	next     int             // Next slot to overwrite once the buffer is full
+}
+
+// SLOTracker records requests per operation
+type SLOTracker struct {
+	mu         sync.Mutex
+	cfg        SLOConfig
+	operations map[string]*operationStats
+}
+
+// NewSLOTracker creates a tracker with the given objectives
+func NewSLOTracker(cfg SLOConfig) *SLOTracker {
+	return &SLOTracker{cfg: cfg, operations: make(map[string]*operationStats)}
+}
+
+// Default tracker shared by the middleware and the /admin/slo endpoint
+var (
+	defaultSLO     *SLOTracker
+	defaultSLOOnce sync.Once
+)
+
+// SLO returns the default tracker, created from the environment on first use
+func SLO() *SLOTracker {
+	defaultSLOOnce.Do(func() {
+		defaultSLO = NewSLOTracker(SLOConfigFromEnv())
+	})
+	return defaultSLO
+}
+
+// latencyTarget returns the latency threshold for an operation
+func (t *SLOTracker) latencyTarget(operationID string) time.Duration {
+	if d, ok := t.cfg.LatencyOverrides[operationID]; ok {
+		return d
+	}
+	return t.cfg.LatencyTarget
+}
+
+// Observe records one request
+// failed should be true for responses that count against availability (5xx)
+func (t *SLOTracker) Observe(operationID string, latency time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, ok := t.operations[operationID]
+	if !ok {
+		stats = &operationStats{samples: make([]time.Duration, 0, sampleWindow)}
+		t.operations[operationID] = stats
+	}
+
+	stats.requests++
+	if failed {
+		stats.errors++
+	}
+	if latency > t.latencyTarget(operationID) {
+		stats.slow++
+	}
+
+	if len(stats.samples) < sampleWindow {
+		stats.samples = append(stats.samples, latency)
+	} else {
+		stats.samples[stats.next] = latency
+		stats.next = (stats.next + 1) % sampleWindow
+	}
+}
+
+// OperationReport is the SLO status of one operation
+type OperationReport struct {
+	OperationID        string
+	Requests           int64
+	Errors             int64
+	P50, P95, P99      time.Duration
+	LatencyTarget      time.Duration
+	LatencyObjective   float64
+	SlowRatio          float64 // Fraction of requests slower than LatencyTarget
+	LatencyBudgetBurn  float64 // SlowRatio / allowed slow ratio
+	AvailabilityTarget float64
+	ErrorRatio         float64 // Fraction of requests that failed
+	ErrorBudgetBurn    float64 // ErrorRatio / allowed error ratio
+}
+
+// Report returns the SLO status of every operation, sorted by operation ID
+func (t *SLOTracker) Report() []OperationReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reports := make([]OperationReport, 0, len(t.operations))
+	for id, stats := range t.operations {
+		sorted := append([]time.Duration(nil), stats.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		r := OperationReport{
+			OperationID:        id,
+			Requests:           stats.requests,
+			Errors:             stats.errors,
+			P50:                percentile(sorted, 0.50),
+			P95:                percentile(sorted, 0.95),
+			P99:                percentile(sorted, 0.99),
+			LatencyTarget:      t.latencyTarget(id),
+			LatencyObjective:   t.cfg.LatencyObjective,
+			AvailabilityTarget: t.cfg.AvailabilityTarget,
+		}
+		if stats.requests > 0 {
+			r.SlowRatio = float64(stats.slow) / float64(stats.requests)
+			r.ErrorRatio = float64(stats.errors) / float64(stats.requests)
+		}
+		r.LatencyBudgetBurn = r.SlowRatio / (1 - t.cfg.LatencyObjective)
+		r.ErrorBudgetBurn = r.ErrorRatio / (1 - t.cfg.AvailabilityTarget)
+
+		reports = append(reports, r)
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].OperationID < reports[j].OperationID })
+	return reports
+}
+
+// percentile returns the p-th percentile (0-1) of sorted latencies
+// Uses the nearest-rank method; returns 0 for an empty slice
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
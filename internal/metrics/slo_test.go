@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSLOTracker_Report tests percentiles and budget burn for one operation
+func TestSLOTracker_Report(t *testing.T) {
+	// Arrange: 100 requests of 1..100ms, 2 of them failed, target 90ms
+	tracker := NewSLOTracker(SLOConfig{
+		AvailabilityTarget: 0.99,
+		LatencyTarget:      90 * time.Millisecond,
+		LatencyObjective:   0.95,
+	})
+	for i := 1; i <= 100; i++ {
+		tracker.Observe("list-tasks", time.Duration(i)*time.Millisecond, i <= 2)
+	}
+
+	// Act
+	reports := tracker.Report()
+
+	// Assert
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 operation, got %d", len(reports))
+	}
+	r := reports[0]
+
+	if r.P50 != 50*time.Millisecond || r.P99 != 99*time.Millisecond {
+		t.Errorf("Expected p50=50ms p99=99ms, got p50=%v p99=%v", r.P50, r.P99)
+	}
+
+	// 10 of 100 requests were slower than 90ms, 5% allowed → burn 2.0
+	if r.SlowRatio != 0.10 || r.LatencyBudgetBurn < 1.99 || r.LatencyBudgetBurn > 2.01 {
+		t.Errorf("Expected slow ratio 0.10 and burn 2.0, got %v and %v", r.SlowRatio, r.LatencyBudgetBurn)
+	}
+
+	// 2 of 100 failed, 1% allowed → burn 2.0
+	if r.ErrorRatio != 0.02 || r.ErrorBudgetBurn < 1.99 || r.ErrorBudgetBurn > 2.01 {
+		t.Errorf("Expected error ratio 0.02 and burn 2.0, got %v and %v", r.ErrorRatio, r.ErrorBudgetBurn)
+	}
+
+	t.Logf("✅ SLO report: p50=%v p99=%v error burn=%.2f", r.P50, r.P99, r.ErrorBudgetBurn)
+}
+
+// TestSLOConfigFromEnv_Overrides tests per-operation latency overrides
+func TestSLOConfigFromEnv_Overrides(t *testing.T) {
+	// Arrange
+	t.Setenv("SLO_LATENCY_OVERRIDES", "list-tasks=200, create-task=500,bogus")
+
+	// Act
+	cfg := SLOConfigFromEnv()
+
+	// Assert
+	if cfg.LatencyOverrides["list-tasks"] != 200*time.Millisecond || cfg.LatencyOverrides["create-task"] != 500*time.Millisecond {
+		t.Errorf("Unexpected overrides: %v", cfg.LatencyOverrides)
+	}
+	if cfg.LatencyTarget != 300*time.Millisecond {
+		t.Errorf("Expected default latency target 300ms, got %v", cfg.LatencyTarget)
+	}
+}
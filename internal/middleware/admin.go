@@ -0,0 +1,36 @@
+// This middleware keeps the /admin/* endpoints to admins
+// Auth only decides who the caller is; a user's own API key is valid for the
+// task endpoints, but mustn't be able to disable other users' keys.
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// RequireAdmin answers 403 Forbidden unless the caller is an admin
+// (see Principal.IsAdmin). Add it to an operation's Middlewares.
+func RequireAdmin(api huma.API) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		p, ok := GetPrincipal(ctx.Context())
+		if !ok || !p.IsAdmin() {
+			writeHumaProblem(ctx, http.StatusForbidden, CodeAdminRequired, "This endpoint needs the admin role")
+			return
+		}
+		next(ctx)
+	}
+}
+
+// AdminOnly is RequireAdmin for plain handlers, e.g. the admin listener's
+// /debug/pprof, which isn't a Huma operation. Use it after Auth.
+func AdminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := GetPrincipal(r.Context()); !ok || !p.IsAdmin() {
+			writeProblem(w, http.StatusForbidden, CodeAdminRequired, "This endpoint needs the admin role")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,14 +1,32 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-todo-api/internal/apikeys"
+	"go-todo-api/internal/logger"
 )
 
 // Auth checks if the request has a valid API key
 // This protects endpoints from unauthorised access
+//
+// Behind an API Gateway JWT authorizer the token was already validated by
+// API Gateway, so its claims are used instead (see authorizer.go).
+// Either way the caller is stored as a Principal (GetPrincipal).
 func Auth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Step 0: Trust the claims of an API Gateway authorizer
+		if p, ok := principalFromAuthorizer(r.Context()); ok {
+			serveAs(next, w, r, p)
+			return
+		}
+
 		// Step 1: Get the API key from environment variable
 		// In production, this would come from secure storage
 		validAPIKey := os.Getenv("API_KEY")
@@ -20,23 +38,102 @@ func Auth(next http.Handler) http.Handler {
 		// Step 3: Check if API key is missing
 		if requestAPIKey == "" {
 			// Return 401 Unauthorised
-			http.Error(w, "API key required", http.StatusUnauthorized)
+			writeProblem(w, http.StatusUnauthorized, CodeAPIKeyRequired, "API key required")
 			return
 		}
 
-		// Step 4: Check if API key is invalid
-		if requestAPIKey != validAPIKey {
+		// Step 4: The shared API key is the operator's own, so it's an admin
+		if requestAPIKey == validAPIKey {
+			serveAs(next, w, r, Principal{UserID: "api-key", Source: "api-key", Role: apikeys.RoleAdmin})
+			return
+		}
+
+		// Step 5: Otherwise it has to be a managed key (see internal/apikeys),
+		// once the key store is set up
+		keys := apikeys.Default()
+		if keys == nil {
 			// Return 403 Forbidden
-			http.Error(w, "Invalid API key", http.StatusForbidden)
+			writeProblem(w, http.StatusForbidden, CodeInvalidAPIKey, "Invalid API key")
+			return
+		}
+		key, err := keys.Authenticate(r.Context(), requestAPIKey, getIP(r))
+		switch {
+		case errors.Is(err, apikeys.ErrInvalid):
+			writeProblem(w, http.StatusForbidden, CodeInvalidAPIKey, "Invalid API key")
+			return
+		case errors.Is(err, apikeys.ErrDisabled):
+			writeProblem(w, http.StatusForbidden, CodeAPIKeyDisabled, "API key disabled")
+			return
+		case err != nil:
+			// The key store (MongoDB) couldn't be read - not the client's fault
+			logger.WithTrace(r.Context()).Error("Failed to check API key", "error", err)
+			writeProblem(w, http.StatusServiceUnavailable, CodeAuthUnavailable, "Could not check the API key")
 			return
 		}
 
-		// Step 5: API key is valid - allow request to continue
-		next.ServeHTTP(w, r)
+		// Step 6: API key is valid - allow request to continue
+		serveAs(next, w, r, Principal{UserID: key.UserID, Source: "api-key", KeyID: key.ID, Role: key.Role, Sandbox: key.Sandbox, ListDefaults: key.List})
 	})
 }
 
+// serveAs continues the request with p as its Principal
+// The user ID is added to the span so traces can be filtered by caller
+func serveAs(next http.Handler, w http.ResponseWriter, r *http.Request, p Principal) {
+	trace.SpanFromContext(r.Context()).SetAttributes(
+		attribute.String("enduser.id", p.UserID),
+		attribute.String("auth.source", p.Source),
+	)
+	if p.KeyID != "" {
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("auth.key_id", p.KeyID))
+	}
+	next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), p)))
+}
+
 // AuthChi is the Chi-compatible version
 func AuthChi(next http.Handler) http.Handler {
 	return Auth(next)
 }
+
+// AuthExemption is a kind of request that doesn't need an API key
+// e.g. {GET /health} for load balancer probes, {OPTIONS *} for CORS preflights
+type AuthExemption struct {
+	// Method is the HTTP method, or "*" (or empty) for any method
+	Method string
+
+	// Path is the full request path (base path included), a prefix ending
+	// in "/*" like /docs/assets/*, or "*" for every path
+	Path string
+}
+
+// Matches reports whether r is exempt
+func (e AuthExemption) Matches(r *http.Request) bool {
+	if e.Method != "" && e.Method != "*" && e.Method != r.Method {
+		return false
+	}
+	switch {
+	case e.Path == "*":
+		return true
+	case strings.HasSuffix(e.Path, "/*"):
+		return strings.HasPrefix(r.URL.Path, strings.TrimSuffix(e.Path, "*"))
+	default:
+		return r.URL.Path == e.Path
+	}
+}
+
+// AuthExcept is Auth for every request but the exempt ones, which are
+// passed through without a Principal (AUTH_EXEMPT, see config.Load)
+// With no exemptions it's the same as Auth.
+func AuthExcept(exempt ...AuthExemption) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authenticated := Auth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, e := range exempt {
+				if e.Matches(r) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	"go-todo-api/internal/apikeys"
+)
+
+// TestAuth_APIKeyPrincipal tests that a valid API key becomes the api-key Principal
+func TestAuth_APIKeyPrincipal(t *testing.T) {
+	// Arrange
+	t.Setenv("API_KEY", "test-key")
+	var got Principal
+	handler := Auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = GetPrincipal(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.Header.Set("X-API-Key", "test-key")
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+	if got.Source != "api-key" || !got.IsAdmin() {
+		t.Errorf("Expected an admin api-key Principal, got %+v", got)
+	}
+}
+
+// TestAuth_ManagedKey tests that a managed key becomes its user's Principal, until it's disabled
+func TestAuth_ManagedKey(t *testing.T) {
+	// Arrange
+	t.Setenv("API_KEY", "test-key")
+	keys := apikeys.Init(apikeys.NewMemoryStore())
+	t.Cleanup(func() { apikeys.Init(nil) })
+	key, secret, _ := keys.Create(context.Background(), "ci", "alice", apikeys.RoleUser)
+
+	var got Principal
+	handler := Auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = GetPrincipal(r.Context())
+	}))
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set("X-API-Key", secret)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Act
+	enabledCode := request()
+	_ = keys.SetDisabled(context.Background(), key.ID, true)
+	disabledCode := request()
+
+	// Assert
+	if enabledCode != http.StatusOK {
+		t.Errorf("Expected status 200 with a managed key, got %d", enabledCode)
+	}
+	if got.UserID != "alice" || got.KeyID != key.ID || got.IsAdmin() {
+		t.Errorf("Expected alice's non-admin Principal, got %+v", got)
+	}
+	if disabledCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 once the key is disabled, got %d", disabledCode)
+	}
+
+	t.Logf("✅ Managed key: %d, then %d once disabled", enabledCode, disabledCode)
+}
+
+// TestAuth_ProblemCodes tests that rejections are problem+json with a code for the reason
+func TestAuth_ProblemCodes(t *testing.T) {
+	// Arrange
+	t.Setenv("API_KEY", "test-key")
+	handler := Auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		key        string
+		wantStatus int
+		wantCode   string
+	}{
+		{"", http.StatusUnauthorized, CodeAPIKeyRequired},
+		{"wrong-key", http.StatusForbidden, CodeInvalidAPIKey},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set("X-API-Key", tt.key)
+		rec := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(rec, req)
+
+		// Assert
+		var body Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Response is not JSON: %v (%s)", err, rec.Body.String())
+		}
+		if rec.Code != tt.wantStatus || body.Status != tt.wantStatus || body.Code != tt.wantCode {
+			t.Errorf("Key %q: expected %d %s, got %d %+v", tt.key, tt.wantStatus, tt.wantCode, rec.Code, body)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Errorf("Expected problem+json content type, got '%s'", ct)
+		}
+	}
+}
+
+// TestAuthExcept tests that exempt requests skip the API key check and others don't
+func TestAuthExcept(t *testing.T) {
+	// Arrange
+	t.Setenv("API_KEY", "test-key")
+	handler := AuthExcept(
+		AuthExemption{Method: http.MethodGet, Path: "/health"},
+		AuthExemption{Method: http.MethodGet, Path: "/docs/*"},
+		AuthExemption{Method: http.MethodOptions, Path: "*"},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/health", http.StatusOK},
+		{http.MethodGet, "/docs/assets/app.js", http.StatusOK},
+		{http.MethodOptions, "/tasks", http.StatusOK},
+		{http.MethodPost, "/health", http.StatusUnauthorized},
+		{http.MethodGet, "/healthz", http.StatusUnauthorized},
+		{http.MethodGet, "/tasks", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		rec := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(rec, req)
+
+		// Assert
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.want, rec.Code)
+		}
+	}
+}
+
+// TestAuth_JWTAuthorizerClaims tests that API Gateway JWT claims are used without an API key
+func TestAuth_JWTAuthorizerClaims(t *testing.T) {
+	// Arrange: an HTTP API event as the Lambda adapter would turn it into a request
+	t.Setenv("API_KEY", "test-key")
+	event := events.APIGatewayV2HTTPRequest{
+		Version:  "2.0",
+		RawPath:  "/tasks",
+		RouteKey: "GET /tasks",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodGet, Path: "/tasks"},
+			Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+					Claims: map[string]string{"sub": "user-123", "scope": "tasks/read tasks/write"},
+				},
+			},
+		},
+	}
+	accessor := core.RequestAccessorV2{}
+	req, err := accessor.EventToRequestWithContext(context.Background(), event)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+
+	var got Principal
+	handler := Auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = GetPrincipal(r.Context())
+	}))
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 without an API key, got %d", rec.Code)
+	}
+	if got.UserID != "user-123" || got.Source != "jwt" {
+		t.Errorf("Expected the JWT Principal for user-123, got %+v", got)
+	}
+	if !got.HasScope("tasks/write") {
+		t.Errorf("Expected the tasks/write scope, got %v", got.Scopes)
+	}
+
+	t.Logf("✅ JWT Principal: %s with scopes %v", got.UserID, got.Scopes)
+}
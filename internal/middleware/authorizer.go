@@ -0,0 +1,66 @@
+// This file reads the caller from an API Gateway authorizer
+// When a JWT authorizer (e.g. Cognito) is attached to the API Gateway route,
+// API Gateway has already validated the token before Lambda is invoked and
+// passes the claims in the event - not in a header, so clients can't forge
+// them. The Lambda adapter puts the event's request context on the request
+// context, which is where these functions look.
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+// principalFromAuthorizer builds the Principal from authorizer claims
+// ok is false when the request didn't come through an authorizer
+// (cmd/api, ALB, or a route without one), so Auth falls back to the API key.
+func principalFromAuthorizer(ctx context.Context) (Principal, bool) {
+	// HTTP API (payload v2): requestContext.authorizer.jwt
+	if rc, ok := core.GetAPIGatewayV2ContextFromContext(ctx); ok {
+		if rc.Authorizer == nil || rc.Authorizer.JWT == nil {
+			return Principal{}, false
+		}
+		claims := rc.Authorizer.JWT.Claims
+		return newJWTPrincipal(claims["sub"], rc.Authorizer.JWT.Scopes, claims["scope"])
+	}
+
+	// REST API (payload v1) with a Cognito authorizer: requestContext.authorizer.claims
+	if rc, ok := core.GetAPIGatewayContextFromContext(ctx); ok {
+		claims, _ := rc.Authorizer["claims"].(map[string]interface{})
+		if claims == nil {
+			return Principal{}, false
+		}
+		return newJWTPrincipal(claimString(claims["sub"]), nil, claimString(claims["scope"]))
+	}
+
+	return Principal{}, false
+}
+
+// newJWTPrincipal combines the route's granted scopes with the token's
+// space-separated "scope" claim (Cognito access tokens use the claim)
+func newJWTPrincipal(sub string, scopes []string, scopeClaim string) (Principal, bool) {
+	if sub == "" {
+		return Principal{}, false
+	}
+
+	p := Principal{UserID: sub, Source: "jwt"}
+	p.Scopes = append(p.Scopes, scopes...)
+	for _, scope := range strings.Fields(scopeClaim) {
+		if !p.HasScope(scope) {
+			p.Scopes = append(p.Scopes, scope)
+		}
+	}
+	return p, true
+}
+
+// claimString turns a v1 claim (decoded as interface{}) into a string
+func claimString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,86 @@
+// This file works out which IP address a request came from
+// Rate limits, access logs and API key "last used from" all key on it, so a
+// client must not be able to pick its own address by sending a header
+
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies are the networks of the proxies in front of the API (load
+// balancers, API Gateway, nginx), set once at startup (TRUSTED_PROXIES)
+// X-Forwarded-For is only believed when the connection comes from one of
+// them. Empty (the default) means no proxy: the headers are
+// ignored and the connection's own address is the client.
+var TrustedProxies []netip.Prefix
+
+// getIP returns the client IP address of the request
+//
+// Each proxy appends the address it received the request from to
+// X-Forwarded-For, so the header reads "client, proxy1, proxy2" and only the
+// entries added by our own proxies can be trusted - anything to their left
+// may have been sent by the client. Walking from the right, the first
+// address that isn't a trusted proxy is the client:
+//
+//	RemoteAddr 10.0.0.5 (ALB, trusted)
+//	X-Forwarded-For: 1.2.3.4, 198.51.100.7, 10.0.0.9
+//	→ 198.51.100.7 (1.2.3.4 was written by the client and is ignored)
+//
+// When no hop names a client, the proxy itself is. X-Real-IP is never read:
+// a proxy that doesn't set it passes on whatever the client sent, so
+// configure nginx with proxy_add_x_forwarded_for instead.
+func getIP(r *http.Request) string {
+	remote := remoteIP(r.RemoteAddr)
+	if !isTrustedProxy(remote) {
+		return remote
+	}
+
+	// Every X-Forwarded-For header, in order, as one list
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break // A garbled hop: don't trust anything to its left
+		}
+		if !trusted(addr) {
+			return addr.Unmap().String()
+		}
+	}
+	return remote
+}
+
+// remoteIP strips the port from RemoteAddr
+// "192.0.2.1:12345" → "192.0.2.1", "[2001:db8::1]:443" → "2001:db8::1"
+// Lambda adapters set it without a port, so a bare address is kept as it is
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// isTrustedProxy reports whether ip (from remoteIP) is one of TrustedProxies
+func isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	return err == nil && trusted(addr)
+}
+
+// trusted reports whether addr is in TrustedProxies
+func trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
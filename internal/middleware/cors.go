@@ -7,7 +7,10 @@ package middleware
 // ============================================================================
 // IMPORTS
 // ============================================================================
-import "net/http" // net/http = for HTTP types and constants
+import (
+	"net/http" // net/http = for HTTP types and constants
+	"slices"   // slices = for checking the allow list
+)
 
 // ============================================================================
 // CORS MIDDLEWARE
@@ -116,6 +119,45 @@ func CORSChi(next http.Handler) http.Handler {
 	return CORS(next) // Just call the standard CORS function
 }
 
+// ============================================================================
+// CORS WITH AN ALLOW LIST
+// ============================================================================
+// CORSWithOrigins is CORS for a fixed list of origins (see the best practice
+// below). The list comes from the environment profile (CORS_ALLOWED_ORIGINS):
+//   - ["*"]  → behaves exactly like CORS (any origin)
+//   - a list → only those origins get Access-Control-Allow-Origin back
+//   - empty  → no origin does, so browsers only allow same-origin calls
+//
+// Requests from other origins still reach the handler - CORS is enforced
+// by the browser, which drops the response when the header is missing.
+func CORSWithOrigins(origins []string) func(http.Handler) http.Handler {
+	if slices.Contains(origins, "*") {
+		return CORS
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The answer depends on Origin, so caches must keep one copy per origin
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin != "" && slices.Contains(origins, origin) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			}
+
+			// Answer preflights here whether or not the origin is allowed
+			if r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // ============================================================================
 // CORS SECURITY CONSIDERATIONS
 // ============================================================================
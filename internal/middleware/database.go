@@ -0,0 +1,44 @@
+// This middleware makes sure MongoDB is connected before a request needs it
+// Lambda connects lazily (see database.EnsureConnected), so the first
+// request of a cold start may be the one that opens the connection
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go-todo-api/internal/database"
+	"go-todo-api/internal/logger"
+)
+
+// databaseWait is the longest a request waits for MongoDB to connect
+const databaseWait = 5 * time.Second
+
+// RequireDatabase answers 503 Service Unavailable (with Retry-After) when
+// MongoDB can't be reached, instead of letting the handler fail or the
+// function crash. The liveness probe (/healthz) is let through - the
+// process itself is fine.
+func RequireDatabase(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), databaseWait)
+		defer cancel()
+		if err := database.EnsureConnected(ctx); err != nil {
+			logger.WithTrace(r.Context()).Error("Database unavailable",
+				"error", err,
+				"request_id", GetRequestID(r.Context()),
+			)
+			w.Header().Set("Retry-After", "5")
+			writeProblem(w, http.StatusServiceUnavailable, CodeDatabaseUnavailable, "The database is temporarily unavailable, please retry")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
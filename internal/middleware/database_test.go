@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-todo-api/internal/logger"
+)
+
+// TestRequireDatabase_Returns503 tests that an unreachable database is a 503, not a crash
+func TestRequireDatabase_Returns503(t *testing.T) {
+	// Arrange: no MONGO_URI, so connecting fails
+	logger.Init()
+	t.Setenv("MONGO_URI", "")
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	handler := RequireDatabase(next)
+
+	// Act
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+
+	// Assert
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	if called {
+		t.Error("Expected the handler not to run without a database")
+	}
+
+	// The liveness probe doesn't need the database
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if !called {
+		t.Error("Expected /healthz to reach the handler")
+	}
+
+	t.Log("✅ Database down: 503 with Retry-After, /healthz still served")
+}
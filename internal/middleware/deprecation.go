@@ -0,0 +1,84 @@
+// This file marks operations as deprecated and tells their callers so
+// A deprecated operation still works, but every response carries:
+//
+//	Deprecation: @1735689600                          (RFC 9745: since when)
+//	Sunset: Wed, 01 Jul 2026 00:00:00 GMT             (RFC 8594: when it stops working)
+//	Link: </tasks/{id}>; rel="successor-version"      (what to use instead)
+//
+// and each call is counted per caller (GET /admin/deprecations), so we can
+// see who still has to move before the endpoint is removed.
+
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"go-todo-api/internal/metrics"
+)
+
+// deprecationKey is where Deprecate keeps the details in Operation.Metadata
+const deprecationKey = "deprecation"
+
+// Deprecation says when an operation was deprecated and what replaces it
+type Deprecation struct {
+	Since     time.Time // When it was deprecated
+	Sunset    time.Time // When it will be removed (zero = not decided yet)
+	Successor string    // Path of the operation to use instead (optional)
+}
+
+// Deprecate marks op as deprecated: the OpenAPI spec says so, the
+// description says what to do, and DeprecationHeaders adds the headers
+//
+//	huma.Register(api, middleware.Deprecate(huma.Operation{...}, middleware.Deprecation{
+//		Since: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Successor: "/tasks/{id}",
+//	}), handler)
+func Deprecate(op huma.Operation, d Deprecation) huma.Operation {
+	op.Deprecated = true
+	if op.Metadata == nil {
+		op.Metadata = make(map[string]any)
+	}
+	op.Metadata[deprecationKey] = d
+
+	note := "Deprecated since " + d.Since.UTC().Format(time.DateOnly)
+	if !d.Sunset.IsZero() {
+		note += ", removed on " + d.Sunset.UTC().Format(time.DateOnly)
+	}
+	if d.Successor != "" {
+		note += "; use " + d.Successor + " instead"
+	}
+	if op.Description != "" {
+		op.Description += ". "
+	}
+	op.Description += note + "."
+	return op
+}
+
+// DeprecationHeaders adds the deprecation headers to the responses of
+// operations marked with Deprecate, and counts their calls in usage
+// Register it with: api.UseMiddleware(middleware.DeprecationHeaders(metrics.Deprecations()))
+func DeprecationHeaders(usage *metrics.DeprecationUsage) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		d, ok := ctx.Operation().Metadata[deprecationKey].(Deprecation)
+		if !ok {
+			next(ctx)
+			return
+		}
+
+		// Set before the handler runs: headers can't be added once it writes
+		ctx.SetHeader("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		if !d.Sunset.IsZero() {
+			ctx.SetHeader("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != "" {
+			ctx.AppendHeader("Link", "<"+d.Successor+`>; rel="successor-version"`)
+		}
+
+		caller, _ := GetPrincipal(ctx.Context())
+		usage.Record(ctx.Operation().OperationID, caller.UserID, time.Now().UTC())
+		next(ctx)
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"go-todo-api/internal/metrics"
+)
+
+// TestDeprecationHeaders tests that only deprecated operations get the
+// headers, and that their calls are counted per caller
+func TestDeprecationHeaders(t *testing.T) {
+	// Arrange: /old is deprecated in favour of /new
+	_, api := humatest.New(t)
+	usage := metrics.NewDeprecationUsage()
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		next(huma.WithContext(ctx, withPrincipal(ctx.Context(), Principal{UserID: "alice"})))
+	})
+	api.UseMiddleware(DeprecationHeaders(usage))
+
+	type output struct{ Body string }
+	handler := func(ctx context.Context, _ *struct{}) (*output, error) { return &output{Body: "ok"}, nil }
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	old := Deprecate(huma.Operation{OperationID: "old", Method: http.MethodGet, Path: "/old", Description: "The old way"},
+		Deprecation{Since: since, Sunset: sunset, Successor: "/new"})
+	huma.Register(api, old, handler)
+	huma.Register(api, huma.Operation{OperationID: "new", Method: http.MethodGet, Path: "/new"}, handler)
+
+	// Act
+	oldResp := api.Get("/old")
+	api.Get("/old")
+	newResp := api.Get("/new")
+
+	// Assert
+	if got := oldResp.Header().Get("Deprecation"); got != "@1735689600" {
+		t.Errorf("Expected Deprecation @1735689600, got %q", got)
+	}
+	if got := oldResp.Header().Get("Sunset"); got != "Wed, 01 Jul 2026 00:00:00 GMT" {
+		t.Errorf("Expected the sunset as an HTTP date, got %q", got)
+	}
+	if got := oldResp.Header().Get("Link"); got != `</new>; rel="successor-version"` {
+		t.Errorf("Expected a successor link, got %q", got)
+	}
+	if newResp.Header().Get("Deprecation") != "" || newResp.Header().Get("Sunset") != "" {
+		t.Errorf("Expected no deprecation headers on /new, got %v", newResp.Header())
+	}
+
+	op := api.OpenAPI().Paths["/old"].Get
+	if !op.Deprecated || !strings.Contains(op.Description, "use /new instead") {
+		t.Errorf("Expected the spec to mark /old deprecated, got %v %q", op.Deprecated, op.Description)
+	}
+
+	report := usage.Report()
+	if len(report) != 1 || report[0].OperationID != "old" || report[0].Caller != "alice" || report[0].Requests != 2 {
+		t.Errorf("Expected 2 calls to old by alice, got %+v", report)
+	}
+
+	t.Log("✅ Deprecated operations answer with Deprecation, Sunset and Link")
+}
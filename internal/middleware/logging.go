@@ -10,26 +10,75 @@ package middleware
 // IMPORTS
 // ============================================================================
 import (
-	"log"        // log = for printing log messages to console
-	"net/http"   // net/http = for HTTP types (Handler, ResponseWriter, Request)
-	"time"       // time = for measuring request duration
+	"crypto/sha256" // sha256 = for fingerprinting API keys so raw keys never hit the logs
+	"encoding/hex"  // hex = for printing the fingerprint as text
+	"log/slog"      // slog = structured logging attributes
+	"net/http"      // net/http = for HTTP types (Handler, ResponseWriter, Request)
+	"time"          // time = for measuring request duration
+
+	"go-todo-api/internal/logger" // Our structured logger
 )
 
+// ============================================================================
+// RESPONSE WRITER WRAPPER
+// ============================================================================
+// responseRecorder wraps http.ResponseWriter so we can see what the handler
+// sent back. The standard ResponseWriter doesn't expose the status code or
+// how many bytes were written, so we intercept WriteHeader() and Write().
+type responseRecorder struct {
+	http.ResponseWriter
+	status int   // HTTP status code sent to the client
+	bytes  int64 // Number of body bytes written
+}
+
+// WriteHeader records the status code before passing it on
+func (rw *responseRecorder) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the body size before passing it on
+// If the handler never called WriteHeader, Go sends 200 OK implicitly
+func (rw *responseRecorder) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Flush lets streaming handlers push data through the wrapper
+func (rw *responseRecorder) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the original writer to http.ResponseController
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // ============================================================================
 // LOGGING MIDDLEWARE
 // ============================================================================
-// Logging logs information about each HTTP request
-// This helps with debugging and monitoring by showing: method, path, and how long the request took
+// Logging writes one structured access log record for each HTTP request
+// This helps with debugging and monitoring by showing: method, path, status,
+// response size, how long the request took and who made it
 //
 // What it does:
 // 1. Records the start time of the request
-// 2. Calls the next handler (your actual route handler)
-// 3. After the handler finishes, logs the request details
+// 2. Wraps the ResponseWriter to capture the status code and bytes written
+// 3. Calls the next handler (your actual route handler)
+// 4. After the handler finishes, logs the request details as JSON
 //
-// Output format:
-//   GET /tasks 5.234ms
-//   POST /tasks 12.456ms
-//   PUT /tasks/123 3.789ms
+// Output format (one JSON line per request):
+//   {"level":"INFO","msg":"HTTP request","method":"GET","path":"/tasks",
+//    "status":200,"bytes":512,"duration_ms":5.2,"request_id":"...",
+//    "remote_ip":"203.0.113.7","api_key":"9f86d081","trace_id":"..."}
 //
 // Middleware Pattern:
 // Middleware in Go uses the "wrapper" pattern:
@@ -52,27 +101,51 @@ func Logging(next http.Handler) http.Handler {
 		// time.Now() = current time (like Date.now() in JavaScript)
 		start := time.Now()
 
+		// Wrap the writer so we can read the status code and size afterwards
+		rec := &responseRecorder{ResponseWriter: w}
+
 		// --------------------------------------------------------------------
 		// RUN THE ACTUAL HANDLER
 		// --------------------------------------------------------------------
 		// next.ServeHTTP() calls the next handler in the chain
 		// This is where your route handler (GetAllTasks, CreateTask, etc.) runs
 		// When this returns, the request has been fully processed
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(rec, r)
 
 		// --------------------------------------------------------------------
 		// AFTER THE HANDLER RUNS
 		// --------------------------------------------------------------------
-		// Log the request details
-		// time.Since(start) = how long since start time (duration)
-		// r.Method = HTTP method (GET, POST, PUT, DELETE)
-		// r.URL.Path = request path (/tasks, /tasks/123, etc.)
-		//
-		// Example output: GET /tasks 5.234ms
-		log.Printf("%s %s %s", r.Method, r.URL.Path, time.Since(start))
+		// A handler that wrote nothing at all still produced a 200 OK
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		// WithTrace adds trace_id/span_id so the log line links to the trace
+		logger.WithTrace(r.Context()).LogAttrs(r.Context(), slog.LevelInfo, "HTTP request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("bytes", rec.bytes),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("request_id", GetRequestID(r.Context())),
+			slog.String("remote_ip", getIP(r)),
+			slog.String("api_key", apiKeyFingerprint(r.Header.Get("X-API-Key"))),
+			slog.String("user_agent", r.UserAgent()),
+		)
 	})
 }
 
+// apiKeyFingerprint returns a short, non-reversible identifier for an API key
+// so we can tell clients apart in the logs without leaking their secrets
+func apiKeyFingerprint(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
+
 // ============================================================================
 // CHI-COMPATIBLE WRAPPER
 // ============================================================================
@@ -109,9 +182,7 @@ func LoggingChi(next http.Handler) http.Handler {
 // 4. **Compliance**: Many regulations require request logs
 //    - GDPR, HIPAA, SOC 2 often require audit trails
 //
-// In production, you'd typically:
-// - Send logs to a centralized logging system (like ELK Stack, Datadog)
-// - Add more details (user ID, request ID, status code)
-// - Use structured logging (JSON format) for easier parsing
+// Our access logs are JSON (via slog) so Promtail/Loki can parse them, and they
+// carry the request ID and trace ID so one request can be followed end to end.
 //
 // ============================================================================
@@ -0,0 +1,69 @@
+// This file defines who made a request, as decided by Auth
+
+package middleware
+
+import (
+	"context"
+	"slices"
+
+	"go-todo-api/internal/anomaly"
+	"go-todo-api/internal/apikeys"
+)
+
+// Principal is the caller of a request
+type Principal struct {
+	// UserID identifies the caller: the JWT "sub" claim, the owner of a
+	// managed API key, or "api-key" for the shared API_KEY
+	UserID string
+
+	// Scopes are the OAuth scopes granted to the caller (empty for the API key)
+	Scopes []string
+
+	// Source is how the caller was authenticated: "jwt" or "api-key"
+	Source string
+
+	// KeyID is the managed API key used (see internal/apikeys), if any
+	KeyID string
+
+	// Role is the managed key's role; the shared API_KEY is an admin
+	Role string
+
+	// Sandbox is set for sandbox keys: their tasks live apart from the real
+	// ones (see repository.Sandboxes)
+	Sandbox bool
+
+	// ListDefaults are the managed key's defaults for GET /tasks pages
+	// (see handlers/pages.go)
+	ListDefaults apikeys.ListDefaults
+}
+
+// IsAdmin reports whether the caller may use the /admin/* endpoints:
+// the shared API_KEY, an admin key, or a JWT with the "admin" scope
+func (p Principal) IsAdmin() bool {
+	return p.Role == apikeys.RoleAdmin || p.HasScope("admin")
+}
+
+// UsageKey is who the anomaly detector counts the caller's usage for
+func (p Principal) UsageKey() anomaly.Caller {
+	return anomaly.Caller{KeyID: p.KeyID, UserID: p.UserID}
+}
+
+// HasScope reports whether the caller was granted scope
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// principalKey is the context key for the Principal
+type principalKey struct{}
+
+// withPrincipal stores the Principal in the request context
+func withPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// GetPrincipal returns the caller of the request
+// ok is false for routes that don't go through Auth
+func GetPrincipal(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
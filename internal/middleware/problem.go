@@ -0,0 +1,89 @@
+// This file writes RFC 7807 problem+json error bodies from middleware
+// Huma already does this for handler errors; middleware runs outside Huma,
+// so it needs its own helper to produce the same shape
+
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// VerboseErrors adds the underlying error to 500 responses
+// It's set from the environment profile (on in dev, off elsewhere) because
+// error messages can contain internals like collection names or hosts
+var VerboseErrors bool
+
+// defaultNewError is Huma's original error constructor, wrapped by NewError
+var defaultNewError = huma.NewError
+
+// NewError builds handler errors; app.New installs it as huma.NewError
+// Handlers pass the cause along, e.g.
+//
+//	huma.Error500InternalServerError("Failed to fetch tasks", err)
+//
+// and outside dev the cause is dropped from 5xx responses (it's still logged).
+// 4xx details (like validation errors) are meant for the client and always kept.
+func NewError(status int, msg string, errs ...error) huma.StatusError {
+	if status >= http.StatusInternalServerError && !VerboseErrors {
+		errs = nil
+	}
+	return defaultNewError(status, msg, errs...)
+}
+
+// Problem codes tell clients why middleware rejected a request without
+// parsing the detail text, e.g. to back off on RATE_LIMITED but ask for a new
+// key on INVALID_API_KEY
+const (
+	CodeAPIKeyRequired      = "API_KEY_REQUIRED"     // 401: no X-API-Key header
+	CodeInvalidAPIKey       = "INVALID_API_KEY"      // 403: the key doesn't exist
+	CodeAPIKeyDisabled      = "API_KEY_DISABLED"     // 403: the key was disabled by an admin
+	CodeAdminRequired       = "ADMIN_REQUIRED"       // 403: the key works but isn't an admin's
+	CodeAuthUnavailable     = "AUTH_UNAVAILABLE"     // 503: the key store couldn't be read
+	CodeRateLimited         = "RATE_LIMITED"         // 429: too many requests from this IP
+	CodeTimeout             = "TIMEOUT"              // 504: past the request deadline
+	CodeDatabaseUnavailable = "DATABASE_UNAVAILABLE" // 503: MongoDB is unreachable
+	CodeInternalError       = "INTERNAL_ERROR"       // 500: a handler panicked
+)
+
+// Problem is the body of a middleware error
+// It's huma.ErrorModel (the format of every handler error) plus a code
+type Problem struct {
+	huma.ErrorModel
+	Code string `json:"code" doc:"Machine-readable reason" example:"RATE_LIMITED"`
+}
+
+// writeProblem writes an application/problem+json error response
+// The body uses huma.ErrorModel so clients see exactly the same format as
+// errors returned by handlers, with a code for the reason:
+//
+//	{"title": "Too Many Requests", "status": 429, "detail": "...", "code": "RATE_LIMITED"}
+func writeProblem(w http.ResponseWriter, status int, code, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(newProblem(status, code, detail))
+}
+
+// writeHumaProblem is writeProblem for Huma middleware (RequireAdmin), which
+// huma.WriteErr would answer without a code
+func writeHumaProblem(ctx huma.Context, status int, code, detail string) {
+	ctx.SetHeader("Content-Type", "application/problem+json")
+	ctx.SetHeader("X-Content-Type-Options", "nosniff")
+	ctx.SetStatus(status)
+	json.NewEncoder(ctx.BodyWriter()).Encode(newProblem(status, code, detail))
+}
+
+// newProblem is the body of a middleware error with the given code
+func newProblem(status int, code, detail string) Problem {
+	return Problem{
+		ErrorModel: huma.ErrorModel{
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: detail,
+		},
+		Code: code,
+	}
+}
@@ -4,156 +4,291 @@
 package middleware
 
 import (
+	"container/list"
+	"context"
+	"hash/maphash"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
 
+	"go-todo-api/internal/clock"
 	"go-todo-api/internal/logger"
 )
 
+// ============================================================================
+// CONFIGURATION
+// ============================================================================
+
+// RateLimitConfig sets how many requests each client IP may make
+// The zero value is the production default: 10 requests/second, bursts of 20
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained rate per IP (default 10)
+	RequestsPerSecond float64
+
+	// Burst is how many requests an IP may make at once before the rate
+	// applies (default 20)
+	Burst int
+
+	// Disabled turns rate limiting off, e.g. for local dev or a seed script
+	// that creates thousands of tasks
+	Disabled bool
+
+	// MaxClients caps how many IPs are tracked at once (default 10,000)
+	// Past it the least recently seen IP is forgotten - it just starts again
+	// with a full burst - so a flood of spoofed X-Forwarded-For addresses
+	// can't grow memory without bound (Lambda has 128MB to spare, not more)
+	MaxClients int
+
+	// Clock refills the buckets (default clock.Real; tests use a clock.Fake)
+	Clock clock.Clock
+
+	// Store shares the counts between every instance of the API (see
+	// rateLimitStore.go). nil keeps them in this process: with 3 replicas
+	// behind a load balancer, an IP may then make 3 times the limit.
+	Store RateLimitStore
+}
+
+// DefaultRateLimit is what a zero RateLimitConfig means
+var DefaultRateLimit = RateLimitConfig{RequestsPerSecond: 10, Burst: 20, MaxClients: 10_000}
+
+// withDefaults fills in the zero fields
+func (c RateLimitConfig) withDefaults() RateLimitConfig {
+	if c.RequestsPerSecond <= 0 {
+		c.RequestsPerSecond = DefaultRateLimit.RequestsPerSecond
+	}
+	if c.Burst <= 0 {
+		c.Burst = DefaultRateLimit.Burst
+	}
+	if c.MaxClients <= 0 {
+		c.MaxClients = DefaultRateLimit.MaxClients
+	}
+	c.Clock = clock.OrReal(c.Clock)
+	return c
+}
+
 // ============================================================================
 // RATE LIMITER STORAGE
 // ============================================================================
+// Each IP gets a token bucket (rate.Limiter). The buckets live in a fixed
+// number of shards, each a map plus a list in least-recently-seen order (an
+// LRU). That gives:
+//   - bounded memory: a full shard forgets its least recently seen IP
+//   - no sweeping: stale IPs collect at the back of the list and are dropped
+//     a couple at a time as new requests come in
+//   - less lock contention: requests from different IPs mostly lock
+//     different shards
+
+// rateLimitShards is how many independently locked shards a limiter has
+const rateLimitShards = 16
+
+// staleAfter is how long an IP is remembered after its last request
+// By then its bucket is full again, so forgetting it changes nothing
+const staleAfter = 3 * time.Minute
+
 // 'visitor' tracks rate limit state for each IP address
 type visitor struct {
+	ip       string
 	limiter  *rate.Limiter // the actual rate limiter
 	lastSeen time.Time     // Last time we saw a request from this IP
 }
 
-// rateLimiter manages rate limiters for all IP addresses
-type rateLimiter struct {
-	visitors map[string]*visitor // Map of IP addresses to visitors
-	mu       sync.RWMutex        // Lock for thread-safe access
-	rate     rate.Limit          // Requests per second allowed
-	burst    int                 // Maximum burst size
+// visitorShard is one LRU of visitors
+type visitorShard struct {
+	mu       sync.Mutex
+	visitors map[string]*list.Element // IP → element in order (Value is *visitor)
+	order    *list.List               // Most recently seen at the front
+	capacity int
 }
 
-// Global rate limiter instance
-var limiter *rateLimiter
+// RateLimiter limits requests per IP address
+// Each one has its own counts, so two routers (or two tests) built with
+// NewRateLimiter never use up each other's budget.
+type RateLimiter struct {
+	cfg    RateLimitConfig
+	seed   maphash.Seed // Random per limiter, so nobody can aim IPs at one shard
+	shards [rateLimitShards]visitorShard
+}
 
-// init runs when package is imported
-// Sets up rate limiter with default values: 10 req/sec, burst of 20
-func init() {
-	limiter = &rateLimiter{
-		visitors: make(map[string]*visitor),
-		rate:     rate.Limit(10), // 10 requests per second
-		burst:    20,             // Allow bursts up to 20 requests
+// NewRateLimiter creates a limiter with no visitors yet
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	cfg = cfg.withDefaults()
+	rl := &RateLimiter{cfg: cfg, seed: maphash.MakeSeed()}
+
+	// Split MaxClients between the shards, rounding up
+	capacity := (cfg.MaxClients + rateLimitShards - 1) / rateLimitShards
+	for i := range rl.shards {
+		rl.shards[i] = newVisitorShard(capacity)
 	}
+	return rl
+}
 
-	// Start cleanup goroutine to remove old visitors (prevent memory leaks)
-	go limiter.cleanupVisitors()
+// newVisitorShard creates an empty shard that remembers up to capacity IPs
+func newVisitorShard(capacity int) visitorShard {
+	return visitorShard{
+		visitors: make(map[string]*list.Element),
+		order:    list.New(),
+		capacity: capacity,
+	}
 }
 
+// defaultLimiter backs the package-level RateLimit and RateLimitChi
+var defaultLimiter = NewRateLimiter(RateLimitConfig{})
+
 // ============================================================================
 // RATE LIMITER METHODS
 // ============================================================================
 
-// getVisitor returns the rate limiter for an IP address
-// Creates a new limiter if one doesn't exist
-func (rl *rateLimiter) getVisitor(ip string) *rate.Limiter {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	v, exists := rl.visitors[ip]
-	if !exists {
-		// Create new rate limiter for this IP
-		limiter := rate.NewLimiter(rl.rate, rl.burst)
-		rl.visitors[ip] = &visitor{limiter, time.Now()}
-		return limiter
+// allow reports whether ip may make a request now
+// With a Store the shared count decides; if the store can't be reached the
+// IP is limited by this process's count instead, rather than not at all.
+func (rl *RateLimiter) allow(ctx context.Context, ip string) bool {
+	if rl.cfg.Store != nil {
+		storeCtx, cancel := context.WithTimeout(ctx, rateLimitStoreTimeout)
+		allowed, err := rl.cfg.Store.Take(storeCtx, ip, rl.cfg.Clock.Now(), rl.cfg.RequestsPerSecond, rl.cfg.Burst)
+		cancel()
+		if err == nil {
+			return allowed
+		}
+		logger.WithTrace(ctx).Warn("Shared rate limit unavailable, using this instance's count", "error", err)
+	}
+
+	shard := &rl.shards[maphash.String(rl.seed, ip)%rateLimitShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := rl.cfg.Clock.Now()
+	v := shard.visit(ip, now, rl.cfg)
+
+	// AllowN with the limiter's clock rather than Allow(), which reads time.Now()
+	return v.limiter.AllowN(now, 1)
+}
+
+// visit returns ip's visitor, marked as seen at now
+// An IP seen for the first time gets a new bucket, making room by forgetting
+// the least recently seen IP when the shard is full. The caller holds shard.mu.
+func (shard *visitorShard) visit(ip string, now time.Time, cfg RateLimitConfig) *visitor {
+	shard.removeStale(now)
+
+	var v *visitor
+	if element, exists := shard.visitors[ip]; exists {
+		v = element.Value.(*visitor)
+		shard.order.MoveToFront(element)
+	} else {
+		if shard.order.Len() >= shard.capacity {
+			shard.remove(shard.order.Back())
+		}
+		v = &visitor{ip: ip, limiter: rate.NewLimiter(rate.Limit(cfg.RequestsPerSecond), cfg.Burst)}
+		shard.visitors[ip] = shard.order.PushFront(v)
 	}
 
 	// Update last seen time
-	v.lastSeen = time.Now()
-	return v.limiter
+	v.lastSeen = now
+	return v
 }
 
-// cleanupVisitors removes visitors that haven't been seen in 3 minutes
-// This prevents memory leaks from accumulating stale visitors
-func (rl *rateLimiter) cleanupVisitors() {
-	for {
-		time.Sleep(time.Minute) // Run every minute
+// removeStale forgets up to two IPs last seen more than staleAfter ago
+// Removing two for every request that arrives is enough to keep up with new
+// IPs, without one unlucky request paying for a big cleanup.
+// The caller holds shard.mu.
+func (shard *visitorShard) removeStale(now time.Time) {
+	for i := 0; i < 2; i++ {
+		oldest := shard.order.Back()
+		if oldest == nil || now.Sub(oldest.Value.(*visitor).lastSeen) <= staleAfter {
+			return
+		}
+		shard.remove(oldest)
+	}
+}
 
-		rl.mu.Lock()
-		for ip, v := range rl.visitors {
-			if time.Since(v.lastSeen) > 3*time.Minute {
-				delete(rl.visitors, ip)
-			}
+// Reset forgets what a client IP has used up, so its next request starts
+// with a full burst again (see POST /admin/apikeys/{id}/reset-limits)
+// It reports whether the IP was being tracked, here or in the Store.
+func (rl *RateLimiter) Reset(ctx context.Context, ip string) bool {
+	shard := &rl.shards[maphash.String(rl.seed, ip)%rateLimitShards]
+	shard.mu.Lock()
+	element, ok := shard.visitors[ip]
+	if ok {
+		shard.remove(element)
+	}
+	shard.mu.Unlock()
+
+	if rl.cfg.Store != nil {
+		shared, err := rl.cfg.Store.Reset(ctx, ip)
+		if err != nil {
+			logger.WithTrace(ctx).Warn("Failed to reset the shared rate limit", "ip", ip, "error", err)
 		}
-		rl.mu.Unlock()
+		ok = ok || shared
 	}
+	return ok
+}
+
+// remove forgets one visitor. The caller holds shard.mu.
+func (shard *visitorShard) remove(element *list.Element) {
+	shard.order.Remove(element)
+	delete(shard.visitors, element.Value.(*visitor).ip)
 }
 
 // ============================================================================
 // MIDDLEWARE FUNCTIONS
 // ============================================================================
 
-// RateLimit middleware limits requests per IP address
-// Returns 429 Too Many Requests if the limit is exceeded
-func RateLimit(next http.Handler) http.Handler {
+// Handler returns the middleware: 429 Too Many Requests once an IP is over
+// its limit. With Disabled set it passes every request straight through.
+func (rl *RateLimiter) Handler(next http.Handler) http.Handler {
+	if rl.cfg.Disabled {
+		return next
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Extract IP address from request
 		ip := getIP(r)
 
-		// Get rate limiter for this IP
-		limiter := limiter.getVisitor(ip)
-
 		// Check if request is allowed
-		if !limiter.Allow() {
+		if !rl.allow(r.Context(), ip) {
 			// Rate limit exceeded
-			logger.Log.Warn("Rate limit exceeded",
+			logger.WithTrace(r.Context()).Warn("Rate limit exceeded",
 				"ip", ip,
 				"path", r.URL.Path,
 				"method", r.Method,
 			)
 
 			// Return 429 Too Many Requests
-			http.Error(w, "Rate limit exceeded. Please try again later.", http.StatusTooManyRequests)
+			writeProblem(w, http.StatusTooManyRequests, CodeRateLimited, "Rate limit exceeded. Please try again later.")
 			return
 		}
 
 		// Request allowed - continue to next handler
 		next.ServeHTTP(w, r)
-
 	})
 }
 
-// RateLimitChi is the Chi-compatible version
-func RateLimitChi(next http.Handler) http.Handler {
-	return RateLimit(next)
-}
-
-// ============================================================================
-// HELPER FUNCTIONS
-// ============================================================================
-// getIP extracts the client IP address from the request
-// Handles various proxy headers and formats
-func getIP(r *http.Request) string {
-	// Try X-Forwarded-For header (set by proxies/load balancers)
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		return forwarded
-	}
-
-	// Try X-Real-IP header (set by some proxies)
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
-	}
-
-	// Fall back to RemoteAddr (direct connection)
-	ip := r.RemoteAddr
-
-	// Remove port if present
-	// Example: "192.168.1.1:12345" should be "192.168.1.1"
-	if idx := len(ip) - 1; idx >= 0 {
-		for i := idx; i >= 0; i-- {
-			if ip[i] == ':' {
-				return ip[:i]
+// HandlerExcept is Handler for every path but the given ones, which are
+// passed through without being counted (e.g. the OpenAPI spec, which docs
+// tools poll and which is cheap to serve)
+func (rl *RateLimiter) HandlerExcept(paths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limited := rl.Handler(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(paths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
 			}
-		}
+			limited.ServeHTTP(w, r)
+		})
 	}
+}
+
+// RateLimit limits requests per IP address with the default settings
+// (10 requests/second, bursts of 20), shared by everything that uses it
+// Use NewRateLimiter(cfg).Handler for other settings or separate counts.
+func RateLimit(next http.Handler) http.Handler {
+	return defaultLimiter.Handler(next)
+}
 
-	return ip
+// RateLimitChi is the Chi-compatible version
+func RateLimitChi(next http.Handler) http.Handler {
+	return RateLimit(next)
 }
@@ -0,0 +1,155 @@
+// Shared rate limit counts, so several instances of the API behind one load
+// balancer enforce one limit per IP between them (RATE_LIMIT_STORE=mongo)
+
+package middleware
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// rateLimitStoreTimeout is the longest a request waits for the shared count
+// before it's counted by this instance alone
+const rateLimitStoreTimeout = 250 * time.Millisecond
+
+// RateLimitStore keeps the rate limit counts every instance shares
+//
+// Both implementations use the same bucket as rate.Limiter, kept as one
+// timestamp per IP (GCRA): tat, the "theoretical arrival time" when the
+// bucket will be full again. A request costs 1/rps seconds; it's allowed
+// while it doesn't push tat more than burst requests ahead of now.
+type RateLimitStore interface {
+	// Take counts one request from key at now and reports whether it's
+	// within rps requests per second, bursts of burst
+	Take(ctx context.Context, key string, now time.Time, rps float64, burst int) (bool, error)
+
+	// Reset forgets key's count; it reports whether there was one
+	Reset(ctx context.Context, key string) (bool, error)
+}
+
+// gcraCost returns how long one request uses up, and how far ahead of now
+// a full burst reaches
+func gcraCost(rps float64, burst int) (interval, tolerance time.Duration) {
+	interval = time.Duration(math.Ceil(float64(time.Second) / rps))
+	return interval, interval * time.Duration(burst)
+}
+
+// ============================================================================
+// MONGODB STORE
+// ============================================================================
+
+// MongoRateLimitStore keeps one document per IP: {_id, tat, expires_at}
+// Take is a single findOneAndUpdate, so concurrent requests on different
+// instances can't both spend the last token.
+type MongoRateLimitStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRateLimitStore creates a store on the given collection
+func NewMongoRateLimitStore(collection *mongo.Collection) *MongoRateLimitStore {
+	return &MongoRateLimitStore{collection: collection}
+}
+
+// EnsureIndexes creates a TTL index, so IPs whose bucket is full again are
+// deleted (like the in-process limiter forgets them after staleAfter)
+func (s *MongoRateLimitStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Take counts a request (see RateLimitStore)
+func (s *MongoRateLimitStore) Take(ctx context.Context, key string, now time.Time, rps float64, burst int) (bool, error) {
+	interval, tolerance := gcraCost(rps, burst)
+	nowNanos := now.UnixNano()
+
+	// tat is in Unix nanoseconds; a new document has none, and $max of a
+	// missing field and now is now
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"next": bson.M{"$add": bson.A{bson.M{"$max": bson.A{"$tat", nowNanos}}, int64(interval)}}}}},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$lte": bson.A{bson.M{"$subtract": bson.A{"$next", nowNanos}}, int64(tolerance)}}}}},
+		{{Key: "$set", Value: bson.M{
+			"tat":        bson.M{"$cond": bson.A{"$allowed", "$next", "$tat"}},
+			"expires_at": now.Add(tolerance + time.Minute).UTC(),
+		}}},
+		{{Key: "$unset", Value: "next"}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"allowed": 1})
+
+	var result struct {
+		Allowed bool `bson:"allowed"`
+	}
+	err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&result)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another instance inserted the IP's first request at the same moment
+		err = s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&result)
+	}
+	if err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+// Reset deletes key's document
+func (s *MongoRateLimitStore) Reset(ctx context.Context, key string) (bool, error) {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// ============================================================================
+// IN-MEMORY STORE
+// ============================================================================
+
+// MemoryRateLimitStore keeps the counts in a map - use it in tests, where
+// two RateLimiters sharing one stand in for two instances
+// It never forgets an IP, so it isn't for production.
+type MemoryRateLimitStore struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{tats: make(map[string]time.Time)}
+}
+
+// Take counts a request (see RateLimitStore)
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, now time.Time, rps float64, burst int) (bool, error) {
+	interval, tolerance := gcraCost(rps, burst)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tat := s.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(interval)
+	if next.Sub(now) > tolerance {
+		return false, nil
+	}
+	s.tats[key] = next
+	return true, nil
+}
+
+// Reset forgets key's count
+func (s *MemoryRateLimitStore) Reset(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.tats[key]
+	delete(s.tats, key)
+	return ok, nil
+}
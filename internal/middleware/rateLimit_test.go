@@ -0,0 +1,305 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/logger"
+)
+
+// newFakeRateLimiter returns a limiter with the default settings on a fake clock
+func newFakeRateLimiter() (*RateLimiter, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	return NewRateLimiter(RateLimitConfig{Clock: fake}), fake
+}
+
+// okHandler answers 200 to everything
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+// sendFrom makes n requests from ip and returns how many got 429
+func sendFrom(handler http.Handler, ip string, n int) int {
+	limited := 0
+	for i := 0; i < n; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.RemoteAddr = ip + ":41234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code == http.StatusTooManyRequests {
+			limited++
+		}
+	}
+	return limited
+}
+
+// TestRateLimit_BurstThenRefill tests that an IP gets its burst of 20, then
+// 10 more requests for every second that passes
+func TestRateLimit_BurstThenRefill(t *testing.T) {
+	// Arrange
+	logger.Init()
+	rl, fake := newFakeRateLimiter()
+	handler := rl.Handler(okHandler)
+
+	// Act + Assert: the burst, then nothing until time passes
+	if limited := sendFrom(handler, "203.0.113.7", 21); limited != 1 {
+		t.Fatalf("Expected only the 21st request to get 429, got %d limited", limited)
+	}
+
+	// Another IP has its own budget
+	if limited := sendFrom(handler, "203.0.113.8", 1); limited != 0 {
+		t.Errorf("Expected another IP not to be limited, got %d limited", limited)
+	}
+
+	// One second later there are 10 more
+	fake.Advance(time.Second)
+	if limited := sendFrom(handler, "203.0.113.7", 11); limited != 1 {
+		t.Errorf("Expected 10 requests allowed after a second, got %d of 11 limited", limited)
+	}
+
+	t.Log("✅ Burst used up and refilled without sleeping")
+}
+
+// tracked returns how many IPs rl currently remembers
+func tracked(rl *RateLimiter) int {
+	n := 0
+	for i := range rl.shards {
+		n += rl.shards[i].order.Len()
+	}
+	return n
+}
+
+// TestVisitorShard_EvictsLeastRecentlySeen tests that a full shard forgets
+// the IP it saw longest ago, not the one that was added first
+func TestVisitorShard_EvictsLeastRecentlySeen(t *testing.T) {
+	// Arrange
+	cfg := DefaultRateLimit
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	shard := newVisitorShard(2)
+	shard.visit("203.0.113.7", now, cfg)
+	shard.visit("203.0.113.8", now, cfg)
+
+	// Act: .7 comes back, then a third IP needs room
+	shard.visit("203.0.113.7", now, cfg)
+	shard.visit("203.0.113.9", now, cfg)
+
+	// Assert
+	if _, ok := shard.visitors["203.0.113.8"]; ok {
+		t.Error("Expected the least recently seen IP (.8) to be forgotten")
+	}
+	if _, ok := shard.visitors["203.0.113.7"]; !ok {
+		t.Error("Expected the IP seen again (.7) to be kept")
+	}
+	if shard.order.Len() != 2 {
+		t.Errorf("Expected the shard to stay at 2 IPs, got %d", shard.order.Len())
+	}
+}
+
+// TestVisitorShard_RemoveStale tests that IPs not seen for 3 minutes are
+// forgotten when the next request comes in, without a sweep
+func TestVisitorShard_RemoveStale(t *testing.T) {
+	// Arrange
+	cfg := DefaultRateLimit
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	shard := newVisitorShard(100)
+	shard.visit("203.0.113.7", now, cfg)
+	shard.visit("203.0.113.8", now.Add(2*time.Minute), cfg)
+
+	// Act
+	shard.visit("203.0.113.9", now.Add(3*time.Minute+30*time.Second), cfg)
+
+	// Assert
+	if _, ok := shard.visitors["203.0.113.7"]; ok {
+		t.Error("Expected the IP last seen 3.5 minutes ago to be removed")
+	}
+	if _, ok := shard.visitors["203.0.113.8"]; !ok {
+		t.Error("Expected the IP seen 90 seconds ago to be kept")
+	}
+}
+
+// TestRateLimit_MaxClients tests that a flood of different IPs (e.g. spoofed
+// X-Forwarded-For headers) can't make the limiter remember more than MaxClients
+func TestRateLimit_MaxClients(t *testing.T) {
+	// Arrange
+	logger.Init()
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	rl := NewRateLimiter(RateLimitConfig{MaxClients: 160, Clock: fake})
+	handler := rl.Handler(okHandler)
+
+	// Act: 10,000 IPs, one request each
+	for i := 0; i < 10_000; i++ {
+		sendFrom(handler, fmt.Sprintf("198.51.%d.%d", i/256, i%256), 1)
+	}
+
+	// Assert
+	if got := tracked(rl); got > 160 {
+		t.Errorf("Expected at most 160 IPs remembered, got %d", got)
+	}
+
+	// The IPs still remembered are rate limited as usual
+	if limited := sendFrom(handler, "203.0.113.7", 21); limited != 1 {
+		t.Errorf("Expected only the 21st request to get 429, got %d limited", limited)
+	}
+
+	t.Logf("✅ 10,000 IPs seen, %d remembered", tracked(rl))
+}
+
+// TestNewRateLimiter_Config tests custom settings, disabling, and that
+// limiters don't share counts
+func TestNewRateLimiter_Config(t *testing.T) {
+	logger.Init()
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name        string
+		cfg         RateLimitConfig
+		wantLimited int // of 10 requests at once
+	}{
+		{"burst of 5", RateLimitConfig{RequestsPerSecond: 1, Burst: 5, Clock: fake}, 5},
+		{"default burst of 20", RateLimitConfig{Clock: fake}, 0},
+		{"disabled", RateLimitConfig{RequestsPerSecond: 1, Burst: 1, Disabled: true, Clock: fake}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: every case uses the same IP, each with a limiter of its own
+			handler := NewRateLimiter(tt.cfg).Handler(okHandler)
+
+			// Act
+			limited := sendFrom(handler, "203.0.113.7", 10)
+
+			// Assert
+			if limited != tt.wantLimited {
+				t.Errorf("Expected %d of 10 requests limited, got %d", tt.wantLimited, limited)
+			}
+		})
+	}
+}
+
+// TestRateLimit_SharedStore tests that instances sharing a store enforce one
+// limit between them, and fall back to their own count when it's down
+func TestRateLimit_SharedStore(t *testing.T) {
+	// Arrange: two replicas behind a load balancer
+	logger.Init()
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	store := NewMemoryRateLimitStore()
+	replicaA := NewRateLimiter(RateLimitConfig{Clock: fake, Store: store})
+	replicaB := NewRateLimiter(RateLimitConfig{Clock: fake, Store: store})
+
+	// Act + Assert: the burst of 20 is split between them
+	if limited := sendFrom(replicaA.Handler(okHandler), "203.0.113.7", 15); limited != 0 {
+		t.Fatalf("Expected 15 requests allowed on replica A, got %d limited", limited)
+	}
+	if limited := sendFrom(replicaB.Handler(okHandler), "203.0.113.7", 10); limited != 5 {
+		t.Errorf("Expected 5 of 10 requests limited on replica B, got %d", limited)
+	}
+
+	// One second later there are 10 more, wherever they're sent
+	fake.Advance(time.Second)
+	if limited := sendFrom(replicaB.Handler(okHandler), "203.0.113.7", 11); limited != 1 {
+		t.Errorf("Expected 10 requests allowed after a second, got %d of 11 limited", limited)
+	}
+
+	// A reset on either replica clears the shared count
+	if !replicaA.Reset(context.Background(), "203.0.113.7") {
+		t.Error("Expected Reset to find the shared count")
+	}
+	if limited := sendFrom(replicaB.Handler(okHandler), "203.0.113.7", 20); limited != 0 {
+		t.Errorf("Expected a full burst after Reset, got %d limited", limited)
+	}
+
+	// A store that's down leaves each replica limiting on its own
+	down := NewRateLimiter(RateLimitConfig{Clock: fake, Store: failingRateLimitStore{}})
+	if limited := sendFrom(down.Handler(okHandler), "203.0.113.7", 21); limited != 1 {
+		t.Errorf("Expected the local limit while the store is down, got %d of 21 limited", limited)
+	}
+
+	t.Log("✅ One limit across replicas")
+}
+
+// failingRateLimitStore is a shared store that can't be reached
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, time.Time, float64, int) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func (failingRateLimitStore) Reset(context.Context, string) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+// TestRateLimit_ProblemJSON tests that a limited request gets a RATE_LIMITED problem+json
+func TestRateLimit_ProblemJSON(t *testing.T) {
+	// Arrange
+	logger.Init()
+	rl, _ := newFakeRateLimiter()
+	handler := rl.Handler(okHandler)
+	sendFrom(handler, "203.0.113.7", 20)
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.RemoteAddr = "203.0.113.7:41234"
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(rec, req)
+
+	// Assert
+	var body Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Response is not JSON: %v (%s)", err, rec.Body.String())
+	}
+	if rec.Code != http.StatusTooManyRequests || body.Code != CodeRateLimited {
+		t.Errorf("Expected 429 RATE_LIMITED, got %d %+v", rec.Code, body)
+	}
+}
+
+// TestGetIP tests that forwarding headers only count when they come from a trusted proxy
+func TestGetIP(t *testing.T) {
+	// Arrange: an ALB in 10.0.0.0/8
+	original := TrustedProxies
+	TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	defer func() { TrustedProxies = original }()
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:41234", nil, "", "203.0.113.7"},
+		{"direct IPv6 client", "[2001:db8::1]:443", nil, "", "2001:db8::1"},
+		{"spoofed header from an untrusted client", "203.0.113.7:41234", []string{"198.51.100.1"}, "", "203.0.113.7"},
+		{"through the proxy", "10.0.0.5:41234", []string{"198.51.100.7"}, "", "198.51.100.7"},
+		{"client-supplied hop is skipped", "10.0.0.5:41234", []string{"1.2.3.4, 198.51.100.7, 10.0.0.9"}, "", "198.51.100.7"},
+		{"several headers form one chain", "10.0.0.5:41234", []string{"1.2.3.4", "198.51.100.7"}, "", "198.51.100.7"},
+		{"X-Real-IP is ignored", "10.0.0.5:41234", nil, "198.51.100.8", "10.0.0.5"},
+		{"only trusted hops", "10.0.0.5:41234", []string{"10.0.0.9"}, "198.51.100.8", "10.0.0.5"},
+		{"garbled hop", "10.0.0.5:41234", []string{"198.51.100.7, nonsense"}, "198.51.100.8", "10.0.0.5"},
+		{"no port (Lambda)", "10.0.0.5", []string{"198.51.100.7"}, "", "198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, header := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", header)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			// Act + Assert
+			if got := getIP(req); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
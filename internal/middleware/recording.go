@@ -0,0 +1,143 @@
+// This middleware saves full request/response pairs of the callers an admin
+// chose to record (see internal/recording)
+// It runs after Auth, so it knows the caller's API key; requests nobody is
+// recording pass straight through, unwrapped.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/recording"
+)
+
+// recordTimeout bounds saving a recording, after the response went out
+const recordTimeout = 2 * time.Second
+
+// Record saves the exchanges of requests matching a recording target
+func Record(next http.Handler) http.Handler {
+	return RecordUnder("")(next)
+}
+
+// RecordUnder is Record for an API mounted at basePath (BASE_PATH)
+// chi leaves the base path in r.URL.Path, so it's stripped before deciding
+// which bodies hold secrets.
+func RecordUnder(basePath string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := recording.Default()
+			if recorder == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			caller, _ := GetPrincipal(r.Context())
+			requestID := GetRequestID(r.Context())
+			target, ok := recorder.Match(caller.KeyID, requestID)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Keep the start of the body, and hand the handler all of it
+			var requestBody []byte
+			if r.Body != nil {
+				requestBody, _ = io.ReadAll(io.LimitReader(r.Body, recording.MaxBody+1))
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(requestBody), r.Body), r.Body}
+			}
+
+			start := time.Now()
+			rw := &capturingWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			truncated := len(requestBody) > recording.MaxBody || rw.truncated
+			if len(requestBody) > recording.MaxBody {
+				requestBody = requestBody[:recording.MaxBody]
+			}
+			path := strings.TrimPrefix(r.URL.Path, basePath)
+			status := rw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			rec := &recording.Recording{
+				TargetID:        target.ID,
+				At:              start.UTC(),
+				RequestID:       requestID,
+				UserID:          caller.UserID,
+				APIKeyID:        caller.KeyID,
+				Method:          r.Method,
+				URL:             r.URL.RequestURI(),
+				RequestHeaders:  recording.Redact(r.Header),
+				RequestBody:     recording.RedactBody(path, string(requestBody)),
+				Status:          status,
+				ResponseHeaders: recording.Redact(w.Header()),
+				ResponseBody:    recording.RedactBody(path, rw.body.String()),
+				Truncated:       truncated,
+				Duration:        time.Since(start),
+			}
+
+			// The client already has its response; don't let its disconnect
+			// cancel the save
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), recordTimeout)
+			defer cancel()
+			if err := recorder.Save(ctx, rec); err != nil {
+				logger.WithTrace(ctx).Warn("Failed to save a recording",
+					slog.String("target", target.ID), slog.Any("error", err))
+			}
+		})
+	}
+}
+
+// readCloser reads from one reader and closes another (the original body)
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// capturingWriter keeps the status and the start of the body it passes on
+type capturingWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+// WriteHeader records the status code before passing it on
+func (cw *capturingWriter) WriteHeader(code int) {
+	if cw.status == 0 {
+		cw.status = code
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+// Write keeps up to recording.MaxBody bytes before passing them on
+func (cw *capturingWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if room := recording.MaxBody - cw.body.Len(); room < len(b) {
+		cw.body.Write(b[:max(room, 0)])
+		cw.truncated = true
+	} else {
+		cw.body.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers (GET /export) push data through the wrapper
+func (cw *capturingWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the original writer to http.ResponseController
+func (cw *capturingWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
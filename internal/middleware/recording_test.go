@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/recording"
+)
+
+// TestRecord tests that only the target's requests are saved, whole but
+// without secrets, and that the handler still gets the full body
+func TestRecord(t *testing.T) {
+	// Arrange: record requests sent with X-Request-ID: ticket-42
+	logger.Init()
+	store := recording.NewMemoryStore()
+	recorder := recording.Init(recording.New(store, nil))
+	t.Cleanup(func() { recording.Init(nil) })
+	target, _ := recorder.Start(recording.Target{RequestID: "ticket-42"}, time.Minute)
+
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	})
+	handler := Chain(echo, RequestID, Record)
+	send := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks?x=1", strings.NewReader(`{"title": "Buy milk"}`))
+		req.Header.Set(RequestIDHeader, requestID)
+		req.Header.Set("X-API-Key", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	recorded := send("ticket-42")
+	send("someone-else")
+
+	// Assert
+	if recorded.Code != http.StatusCreated || recorded.Body.String() != `{"title": "Buy milk"}` {
+		t.Errorf("Expected the handler to get the whole body, got %d %q", recorded.Code, recorded.Body.String())
+	}
+	saved, _ := store.List(context.Background(), "", 10)
+	if len(saved) != 1 {
+		t.Fatalf("Expected only the target's request recorded, got %d", len(saved))
+	}
+	got := saved[0]
+	if got.TargetID != target.ID || got.Method != http.MethodPost || got.URL != "/tasks?x=1" || got.Status != http.StatusCreated {
+		t.Errorf("Expected the request line and status recorded, got %+v", got)
+	}
+	if got.RequestBody != `{"title": "Buy milk"}` || got.ResponseBody != `{"title": "Buy milk"}` {
+		t.Errorf("Expected both bodies recorded, got %q and %q", got.RequestBody, got.ResponseBody)
+	}
+	if key := got.RequestHeaders.Get("X-API-Key"); key != "[redacted]" {
+		t.Errorf("Expected the API key redacted, got %q", key)
+	}
+
+	t.Log("✅ Recorded the target's exchange, without its API key")
+}
+
+// TestRecord_SecretBodies tests that the bodies of endpoints handing out
+// API keys aren't saved
+func TestRecord_SecretBodies(t *testing.T) {
+	// Arrange: record an admin creating a key
+	logger.Init()
+	store := recording.NewMemoryStore()
+	recorder := recording.Init(recording.New(store, nil))
+	t.Cleanup(func() { recording.Init(nil) })
+	_, _ = recorder.Start(recording.Target{RequestID: "ticket-42"}, time.Minute)
+
+	createKey := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "6900d436e231fdbb964c3c1c", "key": "tk_6900d436e231fdbb964c3c1c_secret"}`))
+	})
+	handler := Chain(createKey, RequestID, Record)
+	req := httptest.NewRequest(http.MethodPost, "/admin/apikeys", strings.NewReader(`{"name": "ci", "user_id": "alice"}`))
+	req.Header.Set(RequestIDHeader, "ticket-42")
+
+	// Act
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	// Assert
+	if !strings.Contains(rec.Body.String(), "tk_") {
+		t.Errorf("Expected the client to still get its key, got %q", rec.Body.String())
+	}
+	saved, _ := store.List(context.Background(), "", 10)
+	if len(saved) != 1 {
+		t.Fatalf("Expected the request recorded, got %d", len(saved))
+	}
+	if got := saved[0]; got.RequestBody != "[redacted]" || got.ResponseBody != "[redacted]" {
+		t.Errorf("Expected both bodies redacted, got %q and %q", got.RequestBody, got.ResponseBody)
+	}
+
+	t.Log("✅ Recorded the key's creation, without the key")
+}
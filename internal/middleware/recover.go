@@ -0,0 +1,71 @@
+// This middleware stops a panic in one request from killing the connection
+// and turns it into a normal 500 error response
+
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-todo-api/internal/logger"
+)
+
+// Recover catches panics from the handlers it wraps
+// When a handler panics it:
+// 1. Logs the panic value and stack trace with the request ID and trace ID
+// 2. Records the panic as an error on the active span (shows red in Jaeger)
+// 3. Returns a 500 problem+json body instead of dropping the connection
+//
+// It should run inside Tracing, RequestID and Logging so the span, request ID
+// and access log all see the 500.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			// http.ErrAbortHandler is the standard way to abort a response on
+			// purpose - let net/http handle it as it normally would
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			err := fmt.Errorf("panic: %v", rec)
+			stack := debug.Stack()
+
+			logger.WithTrace(r.Context()).Error("Recovered from panic",
+				slog.String("error", err.Error()),
+				slog.String("request_id", GetRequestID(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("stack", string(stack)),
+			)
+
+			span := trace.SpanFromContext(r.Context())
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+
+			// Don't leak the panic message to clients - it can contain internals
+			// (unless VerboseErrors is on, which is only the default in dev)
+			detail := "An unexpected error occurred"
+			if VerboseErrors {
+				detail = err.Error()
+			}
+			writeProblem(w, http.StatusInternalServerError, CodeInternalError, detail)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RecoverChi is the Chi-compatible version
+func RecoverChi(next http.Handler) http.Handler {
+	return Recover(next)
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-todo-api/internal/logger"
+)
+
+// TestRecover_ReturnsProblemJSON tests that a panicking handler becomes a 500 problem+json
+func TestRecover_ReturnsProblemJSON(t *testing.T) {
+	// Arrange: Logger is needed to log the panic
+	logger.Init()
+
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("something went very wrong")
+	})
+	handler := Chain(panicking, RequestID, Recover)
+
+	// Act
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+
+	// Assert
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected problem+json content type, got '%s'", ct)
+	}
+
+	var body struct {
+		Status int    `json:"status"`
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Response is not JSON: %v", err)
+	}
+
+	if body.Status != 500 {
+		t.Errorf("Expected status 500 in body, got %d", body.Status)
+	}
+}
@@ -0,0 +1,55 @@
+// This middleware gives every request an ID so log lines can be tied together
+
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header used to accept and return request IDs
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key for the request ID
+// Using an unexported type prevents collisions with other packages
+type requestIDKey struct{}
+
+// RequestID reuses the caller's X-Request-ID (e.g. from a load balancer)
+// or generates a new one, stores it in the request context and echoes it
+// back in the response so clients can quote it in bug reports
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDChi is the Chi-compatible version
+func RequestIDChi(next http.Handler) http.Handler {
+	return RequestID(next)
+}
+
+// GetRequestID returns the request ID stored in the context
+// Returns an empty string if the RequestID middleware did not run
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns 16 random bytes as a hex string
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
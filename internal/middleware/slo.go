@@ -0,0 +1,24 @@
+// This middleware feeds every request into the SLO tracker
+// It's a Huma middleware (not a plain net/http one) because it needs the
+// operation ID of the matched route, e.g. "list-tasks"
+
+package middleware
+
+import (
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"go-todo-api/internal/metrics"
+)
+
+// SLOTracking records latency and 5xx errors per operation ID
+// Register it with: api.UseMiddleware(middleware.SLOTracking(metrics.SLO()))
+func SLOTracking(tracker *metrics.SLOTracker) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		start := time.Now()
+		next(ctx)
+
+		tracker.Observe(ctx.Operation().OperationID, time.Since(start), ctx.Status() >= 500)
+	}
+}
@@ -0,0 +1,180 @@
+// This middleware gives every request a deadline
+// The deadline is attached to the request context, so handlers and MongoDB
+// calls made with that context are cancelled when it passes. If the handler
+// hasn't answered by then, the client gets a 504 problem+json response.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultRequestTimeout is used when no timeout is configured
+const DefaultRequestTimeout = 15 * time.Second
+
+// Timeout returns middleware that limits each request to d
+//
+// The handler runs in its own goroutine and writes into a buffer. Whichever
+// finishes first wins: the handler's buffered response is sent, or - once
+// the deadline passes - a 504 is sent and later writes are discarded.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	if d <= 0 {
+		d = DefaultRequestTimeout
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header), status: http.StatusOK}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					// Hand panics back to this goroutine so the Recover
+					// middleware (which wraps us) can turn them into a 500
+					if rec := recover(); rec != nil {
+						panicked <- rec
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case rec := <-panicked:
+				panic(rec)
+
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				// The handler returned, but only because a database call hit the
+				// deadline - report that as a timeout rather than a server error
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) && tw.status >= 500 {
+					writeProblem(w, http.StatusGatewayTimeout, CodeTimeout, "The request took too long to complete")
+					return
+				}
+
+				dst := w.Header()
+				for k, v := range tw.header {
+					dst[k] = v
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.buf.Bytes())
+
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					writeProblem(w, http.StatusGatewayTimeout, CodeTimeout, "The request took too long to complete")
+				}
+				// Otherwise the client went away - nobody is listening for a response
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the handler's response until we know it finished in time
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	wrote    bool
+	timedOut bool
+}
+
+// Header returns the buffered headers
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write buffers body bytes, or fails once the request has timed out
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wrote = true
+	return tw.buf.Write(b)
+}
+
+// WriteHeader records the status code (only the first call counts)
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wrote {
+		return
+	}
+	tw.wrote = true
+	tw.status = status
+}
+
+// ============================================================================
+// STREAMING RESPONSES
+// ============================================================================
+// Timeout buffers the whole response, which is exactly what a streaming
+// endpoint (GET /export) must not do: the client would get nothing until the
+// last task was read, and the server would hold every task in memory anyway.
+
+// DefaultStreamTimeout is the deadline for streaming endpoints
+// Exports of many tasks legitimately take longer than a normal request
+const DefaultStreamTimeout = 5 * time.Minute
+
+// StreamTimeout returns middleware that only puts a deadline of d on the
+// request context, without buffering
+// Past the deadline the handler's queries are cancelled and the stream ends
+// where it got to - a 504 can't be sent once a 200 has started. The server's
+// write timeout (HTTP_WRITE_TIMEOUT) is moved to the same deadline, or it
+// would cut the stream off first.
+func StreamTimeout(d time.Duration) func(http.Handler) http.Handler {
+	if d <= 0 {
+		d = DefaultStreamTimeout
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			// Fails harmlessly where there is no connection deadline (Lambda, tests)
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// TimeoutExcept uses Timeout(d) for every request except those to
+// streamPaths, which get StreamTimeout(streamD) instead
+func TimeoutExcept(d, streamD time.Duration, streamPaths ...string) func(http.Handler) http.Handler {
+	buffered, streamed := Timeout(d), StreamTimeout(streamD)
+
+	return func(next http.Handler) http.Handler {
+		bufferedNext, streamedNext := buffered(next), streamed(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(streamPaths, r.URL.Path) {
+				streamedNext.ServeHTTP(w, r)
+				return
+			}
+			bufferedNext.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TimeoutChi is the Chi-compatible version with the default timeout
+func TimeoutChi(next http.Handler) http.Handler {
+	return Timeout(DefaultRequestTimeout)(next)
+}
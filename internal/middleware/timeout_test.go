@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestTimeout_SlowHandlerGets504 tests that a handler past the deadline returns 504 problem+json
+func TestTimeout_SlowHandlerGets504(t *testing.T) {
+	// Arrange: a handler that waits for its context like a MongoDB call would
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		http.Error(w, "database timeout", http.StatusInternalServerError)
+	})
+	handler := Timeout(20 * time.Millisecond)(slow)
+
+	// Act
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+
+	// Assert
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status 504, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected problem+json content type, got '%s'", ct)
+	}
+
+	t.Logf("✅ Slow request returned %d", rec.Code)
+}
+
+// TestTimeout_FastHandlerPassesThrough tests that responses within the deadline are unchanged
+func TestTimeout_FastHandlerPassesThrough(t *testing.T) {
+	// Arrange
+	fast := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("Expected the request context to have a deadline")
+		}
+		w.Header().Set("Location", "/tasks/1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"1"}`))
+	})
+	handler := Timeout(time.Second)(fast)
+
+	// Act
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tasks", nil))
+
+	// Assert
+	if rec.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", rec.Code)
+	}
+	if rec.Header().Get("Location") != "/tasks/1" || rec.Body.String() != `{"id":"1"}` {
+		t.Errorf("Expected headers and body to pass through, got %v %q", rec.Header(), rec.Body.String())
+	}
+}
+
+// TestTimeoutExcept_StreamsWithoutBuffering tests that streaming paths write
+// straight to the client, with a deadline, while other paths are buffered
+func TestTimeoutExcept_StreamsWithoutBuffering(t *testing.T) {
+	// Arrange: the handler reports whether its first line reached the client
+	// before it returned
+	rec := httptest.NewRecorder()
+	var reachedClient bool
+	streaming := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("Expected the streaming request to have a deadline")
+		}
+		w.Write([]byte("{\"title\":\"one\"}\n"))
+		reachedClient = rec.Body.Len() > 0
+	})
+	handler := TimeoutExcept(time.Second, time.Minute, "/export")(streaming)
+
+	// Act + Assert: the streaming path
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if !reachedClient {
+		t.Error("Expected /export to be written through, not buffered")
+	}
+
+	// Any other path is buffered as before
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	if reachedClient {
+		t.Error("Expected /tasks to be buffered until the handler returned")
+	}
+	if rec.Body.Len() == 0 {
+		t.Error("Expected the buffered body to be sent")
+	}
+}
@@ -0,0 +1,176 @@
+// This middleware decides what happens to JSON fields the API doesn't know
+// Huma's request schemas don't allow additional properties, so by default a
+// body like {"title": "Buy milk", "descripton": "2 litres"} is a 422 listing
+// body.descripton - the typo is caught instead of the description being
+// silently dropped. Deployments with older clients that send extra fields
+// can switch to ignoring them (JSON_UNKNOWN_FIELDS=ignore), and any client
+// can pick per request with the standard Prefer header (RFC 7240):
+//
+//	Prefer: handling=strict     reject unknown fields (422)
+//	Prefer: handling=lenient    drop them and carry on
+//
+// The response then says which one was used: Preference-Applied: handling=...
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// UnknownFields returns the Huma middleware for unknown request body fields
+// lenientByDefault applies when the client doesn't send Prefer: handling=...
+// registry resolves the $refs in the request schemas (api.OpenAPI().Components.Schemas).
+// Register it with: api.UseMiddleware(middleware.UnknownFields(registry, lenientByDefault))
+func UnknownFields(registry huma.Registry, lenientByDefault bool) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		lenient := lenientByDefault
+		if handling, asked := preferredHandling(ctx); asked {
+			lenient = handling == "lenient"
+			ctx.SetHeader("Preference-Applied", "handling="+handling)
+		}
+
+		schema := jsonBodySchema(ctx.Operation())
+		if !lenient || schema == nil {
+			next(ctx) // Strict: Huma's validation rejects the unknown fields
+			return
+		}
+		next(&bodyContext{humaContext: ctx, body: dropUnknownFields(ctx, registry, schema)})
+	}
+}
+
+// preferredHandling reads handling=strict|lenient from the Prefer header(s)
+func preferredHandling(ctx huma.Context) (handling string, ok bool) {
+	ctx.EachHeader(func(name, value string) {
+		if !strings.EqualFold(name, "Prefer") {
+			return
+		}
+		for _, pref := range strings.Split(value, ",") {
+			token, _, _ := strings.Cut(pref, ";") // Ignore parameters
+			key, val, _ := strings.Cut(strings.TrimSpace(token), "=")
+			val = strings.ToLower(strings.Trim(strings.TrimSpace(val), `"`))
+			if strings.EqualFold(strings.TrimSpace(key), "handling") && (val == "strict" || val == "lenient") {
+				handling, ok = val, true
+			}
+		}
+	})
+	return handling, ok
+}
+
+// jsonBodySchema returns the schema of an operation's JSON body (nil = no body)
+func jsonBodySchema(op *huma.Operation) *huma.Schema {
+	if op == nil || op.RequestBody == nil {
+		return nil
+	}
+	if media, ok := op.RequestBody.Content["application/json"]; ok {
+		return media.Schema
+	}
+	return nil
+}
+
+// dropUnknownFields reads the body and returns it without the fields its
+// schema doesn't have
+// Anything it can't handle (not JSON, too large) is passed on unchanged, for
+// Huma to report as usual.
+func dropUnknownFields(ctx huma.Context, registry huma.Registry, schema *huma.Schema) io.Reader {
+	body := ctx.BodyReader()
+	if body == nil {
+		return nil
+	}
+	limit := ctx.Operation().MaxBodyBytes
+	if limit <= 0 {
+		limit = 1 << 20 // Huma's default
+	}
+	raw, err := io.ReadAll(io.LimitReader(body, limit))
+	if err != nil || int64(len(raw)) == limit {
+		// Let Huma see the same bytes, and the rest, and answer 413/408
+		return io.MultiReader(bytes.NewReader(raw), body)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber() // Keep numbers exactly as sent
+	var value any
+	if decoder.Decode(&value) != nil {
+		return bytes.NewReader(raw)
+	}
+	if !pruneValue(registry, schema, value) {
+		return bytes.NewReader(raw) // Nothing unknown: don't re-encode
+	}
+	cleaned, err := json.Marshal(value)
+	if err != nil {
+		return bytes.NewReader(raw)
+	}
+	return bytes.NewReader(cleaned)
+}
+
+// pruneValue deletes the object keys schema doesn't allow, at any depth
+// It reports whether it deleted anything.
+func pruneValue(registry huma.Registry, schema *huma.Schema, value any) bool {
+	if schema == nil {
+		return false
+	}
+	if schema.Ref != "" {
+		if schema = registry.SchemaFromRef(schema.Ref); schema == nil {
+			return false
+		}
+	}
+
+	pruned := false
+	switch v := value.(type) {
+	case map[string]any:
+		closed := schema.AdditionalProperties == false
+		for key, child := range v {
+			if property := findProperty(schema, key); property != nil {
+				pruned = pruneValue(registry, property, child) || pruned
+				continue
+			}
+			if closed {
+				delete(v, key)
+				pruned = true
+			} else if values, ok := schema.AdditionalProperties.(*huma.Schema); ok {
+				pruned = pruneValue(registry, values, child) || pruned // A map's values
+			}
+		}
+	case []any:
+		for _, item := range v {
+			pruned = pruneValue(registry, schema.Items, item) || pruned
+		}
+	}
+	return pruned
+}
+
+// findProperty looks a key up like Huma's validation does: exact match
+// first, then ignoring case (unless huma.ValidateStrictCasing is on)
+func findProperty(schema *huma.Schema, key string) *huma.Schema {
+	if property, ok := schema.Properties[key]; ok {
+		return property
+	}
+	if huma.ValidateStrictCasing {
+		return nil
+	}
+	for name, property := range schema.Properties {
+		if strings.EqualFold(name, key) {
+			return property
+		}
+	}
+	return nil
+}
+
+// humaContext lets bodyContext embed huma.Context: embedded under its own
+// name, the field would clash with the interface's Context() method
+type humaContext = huma.Context
+
+// bodyContext is a huma.Context with its body replaced
+type bodyContext struct {
+	humaContext
+	body io.Reader
+}
+
+// BodyReader returns the replaced body
+func (c *bodyContext) BodyReader() io.Reader {
+	return c.body
+}
@@ -0,0 +1,24 @@
+// This middleware counts each caller's requests for the anomaly detector
+// (see internal/anomaly), which alerts on sudden bursts
+// It runs after Auth, so it knows who the caller is; unauthenticated
+// requests (AUTH_EXEMPT) aren't counted.
+
+package middleware
+
+import (
+	"net/http"
+
+	"go-todo-api/internal/anomaly"
+)
+
+// WatchUsage counts the request against its caller
+func WatchUsage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if detector := anomaly.Default(); detector != nil {
+			if caller, ok := GetPrincipal(r.Context()); ok {
+				detector.Request(caller.UsageKey())
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,327 @@
+package models
+
+import "time"
+
+// GetLogLevelInput is the input for reading the current log level
+type GetLogLevelInput struct {
+}
+
+// SetLogLevelInput is the input for changing the log level at runtime
+type SetLogLevelInput struct {
+	Body struct {
+		Level string `json:"level" doc:"New minimum log level" enum:"debug,info,warn,error" example:"debug"`
+	}
+}
+
+// LogLevelOutput is the response for the log level endpoints
+type LogLevelOutput struct {
+	Body struct {
+		Level string `json:"level" doc:"Current minimum log level" example:"info"`
+	}
+}
+
+// SLOReportInput is the input for the SLO report endpoint
+type SLOReportInput struct {
+}
+
+// OperationSLO is the SLO status of one API operation
+type OperationSLO struct {
+	OperationID        string  `json:"operation_id" doc:"Huma operation ID" example:"list-tasks"`
+	Requests           int64   `json:"requests" doc:"Requests since startup" example:"1200"`
+	Errors             int64   `json:"errors" doc:"5xx responses since startup" example:"1"`
+	P50Ms              float64 `json:"p50_ms" doc:"Median latency over recent requests" example:"4.2"`
+	P95Ms              float64 `json:"p95_ms" doc:"95th percentile latency" example:"18.9"`
+	P99Ms              float64 `json:"p99_ms" doc:"99th percentile latency" example:"42.0"`
+	LatencyTargetMs    float64 `json:"latency_target_ms" doc:"Latency threshold for this operation" example:"300"`
+	LatencyObjective   float64 `json:"latency_objective" doc:"Fraction of requests that must beat the threshold" example:"0.99"`
+	SlowRatio          float64 `json:"slow_ratio" doc:"Fraction of requests slower than the threshold" example:"0.004"`
+	LatencyBudgetBurn  float64 `json:"latency_budget_burn" doc:"Latency budget burn rate (1.0 = exactly on target)" example:"0.4"`
+	AvailabilityTarget float64 `json:"availability_target" doc:"Fraction of requests that must not fail" example:"0.999"`
+	ErrorRatio         float64 `json:"error_ratio" doc:"Fraction of requests that returned 5xx" example:"0.0008"`
+	ErrorBudgetBurn    float64 `json:"error_budget_burn" doc:"Error budget burn rate (1.0 = exactly on target)" example:"0.8"`
+}
+
+// SLOReportOutput is the response for the SLO report endpoint
+type SLOReportOutput struct {
+	Body struct {
+		Operations []OperationSLO `json:"operations" doc:"SLO status per operation"`
+	}
+}
+
+// DeprecationsInput is the input for the deprecated endpoint usage report
+type DeprecationsInput struct {
+}
+
+// DeprecatedUse is how much one caller still uses one deprecated operation
+type DeprecatedUse struct {
+	OperationID string    `json:"operation_id" doc:"The deprecated operation" example:"list-tasks-legacy"`
+	Caller      string    `json:"caller,omitempty" doc:"User ID of the caller (absent for anonymous calls)" example:"alice"`
+	Requests    int64     `json:"requests" doc:"Calls since this instance started" example:"12"`
+	LastSeen    time.Time `json:"last_seen" doc:"When the caller last used it" example:"2025-01-31T12:00:00Z"`
+}
+
+// DeprecationsOutput is the response for the deprecated endpoint usage report
+type DeprecationsOutput struct {
+	Body struct {
+		Uses []DeprecatedUse `json:"uses" doc:"Calls to deprecated operations, by operation and caller"`
+	}
+}
+
+// SchedulerInput is the input for the scheduler status endpoint
+type SchedulerInput struct {
+}
+
+// ScheduledTask is the status of one periodic task
+type ScheduledTask struct {
+	Name           string     `json:"name" doc:"Task name" example:"trash-purge"`
+	Schedule       string     `json:"schedule" doc:"Cron expression" example:"0 3 * * *"`
+	Runs           int64      `json:"runs" doc:"Runs on this instance since startup" example:"12"`
+	Failures       int64      `json:"failures" doc:"Runs on this instance that failed" example:"0"`
+	Skipped        int64      `json:"skipped" doc:"Ticks run by another instance instead" example:"24"`
+	LastRun        *time.Time `json:"last_run,omitempty" doc:"When this instance last ran the task" example:"2025-01-31T12:00:00Z"`
+	LastDurationMs float64    `json:"last_duration_ms" doc:"Duration of the last run" example:"142.5"`
+	LastError      string     `json:"last_error,omitempty" doc:"Error from the last run, if it failed" example:"context deadline exceeded"`
+	NextRun        *time.Time `json:"next_run,omitempty" doc:"Next scheduled tick" example:"2025-01-31T12:05:00Z"`
+}
+
+// SchedulerOutput is the response for the scheduler status endpoint
+type SchedulerOutput struct {
+	Body struct {
+		Tasks []ScheduledTask `json:"tasks" doc:"Registered periodic tasks"`
+	}
+}
+
+// DBPoolInput is the input for the connection pool endpoint
+type DBPoolInput struct {
+}
+
+// DBPoolOutput is the response for the connection pool endpoint
+type DBPoolOutput struct {
+	Body struct {
+		Checkouts         int64   `json:"checkouts" doc:"Connections handed to a command since startup" example:"15230"`
+		CheckoutFailures  int64   `json:"checkout_failures" doc:"Checkouts that timed out or failed" example:"0"`
+		AvgCheckoutWaitMs float64 `json:"avg_checkout_wait_ms" doc:"Average time a command waited for a connection" example:"0.12"`
+		MaxCheckoutWaitMs float64 `json:"max_checkout_wait_ms" doc:"Longest time a command waited for a connection" example:"48.3"`
+		Created           int64   `json:"connections_created" doc:"Connections opened since startup" example:"12"`
+		Closed            int64   `json:"connections_closed" doc:"Connections closed since startup" example:"2"`
+		Open              int64   `json:"connections_open" doc:"Connections open now" example:"10"`
+		InUse             int64   `json:"connections_in_use" doc:"Connections checked out now" example:"3"`
+		Cleared           int64   `json:"pool_cleared" doc:"Times the pool was cleared after an error" example:"0"`
+	}
+}
+
+// APIKey is a managed API key, without its secret
+type APIKey struct {
+	ID        string    `json:"id" doc:"Key ID (also part of the key itself)" example:"6900d436e231fdbb964c3c1c"`
+	Name      string    `json:"name" doc:"What the key is for" example:"mobile app"`
+	UserID    string    `json:"user_id" doc:"User the key belongs to" example:"alice"`
+	Role      string    `json:"role" doc:"What the key may do" enum:"admin,user" example:"user"`
+	Disabled  bool      `json:"disabled" doc:"Disabled keys are refused with 403" example:"false"`
+	Sandbox   bool      `json:"sandbox" doc:"The key's tasks live in a sandbox of their own" example:"false"`
+	Mobile    bool      `json:"mobile" doc:"A mobile app's key: its task lists get the mobile page size (MOBILE_LIST_LIMIT)" example:"false"`
+	ListLimit int       `json:"list_limit,omitempty" doc:"Tasks per GET /tasks list when the request doesn't give ?limit= (absent = the default for the kind of client)" example:"25"`
+	CreatedAt time.Time `json:"created_at" doc:"When the key was created" example:"2025-01-31T12:00:00Z"`
+}
+
+// ListAPIKeysInput is the input for listing API keys
+type ListAPIKeysInput struct {
+	UserID string `query:"user_id" doc:"Only list this user's keys (optional)" example:"alice"`
+}
+
+// ListAPIKeysOutput is the response for listing API keys
+type ListAPIKeysOutput struct {
+	Body struct {
+		Keys []APIKey `json:"keys" doc:"API keys, oldest first"`
+	}
+}
+
+// CreateAPIKeyInput is the input for creating an API key
+type CreateAPIKeyInput struct {
+	Body struct {
+		Name      string `json:"name" doc:"What the key is for" minLength:"1" maxLength:"100" example:"mobile app"`
+		UserID    string `json:"user_id" doc:"User the key belongs to" minLength:"1" maxLength:"100" example:"alice"`
+		Role      string `json:"role,omitempty" doc:"What the key may do" enum:"admin,user" default:"user" example:"user"`
+		Sandbox   bool   `json:"sandbox,omitempty" doc:"Keep the key's tasks in a sandbox of their own, apart from the real ones (user keys only)" example:"false"`
+		Mobile    bool   `json:"mobile,omitempty" doc:"The key is a mobile app's: its task lists get the mobile page size, client hint or not" example:"false"`
+		ListLimit int    `json:"list_limit,omitempty" doc:"Tasks per GET /tasks list when the request doesn't give ?limit= (0 = the default for the kind of client)" minimum:"0" maximum:"10000" example:"25"`
+	}
+}
+
+// CreateAPIKeyOutput is the response for creating an API key
+type CreateAPIKeyOutput struct {
+	Body struct {
+		APIKey
+		Key string `json:"key" doc:"The key to send as X-API-Key - shown only this once" example:"tk_6900d436e231fdbb964c3c1c_9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	}
+}
+
+// APIKeyIDInput is the input for the endpoints acting on one API key
+type APIKeyIDInput struct {
+	ID string `path:"id" doc:"Key ID" minLength:"24" maxLength:"24" example:"6900d436e231fdbb964c3c1c"`
+}
+
+// APIKeyOutput is the response for disabling or enabling an API key
+type APIKeyOutput struct {
+	Body APIKey
+}
+
+// User is someone with managed API keys
+type User struct {
+	ID           string   `json:"id" doc:"User ID" example:"alice"`
+	Keys         int      `json:"keys" doc:"How many keys the user has" example:"2"`
+	DisabledKeys int      `json:"disabled_keys" doc:"How many of them are disabled" example:"0"`
+	Disabled     bool     `json:"disabled" doc:"Every key of the user is disabled" example:"false"`
+	Roles        []string `json:"roles" doc:"Roles of the user's keys" example:"[\"user\"]"`
+}
+
+// ListUsersInput is the input for listing users
+type ListUsersInput struct {
+}
+
+// ListUsersOutput is the response for listing users
+type ListUsersOutput struct {
+	Body struct {
+		Users []User `json:"users" doc:"Users with API keys, sorted by ID"`
+	}
+}
+
+// UserIDInput is the input for the endpoints acting on one user
+type UserIDInput struct {
+	ID string `path:"id" doc:"User ID" minLength:"1" maxLength:"100" example:"alice"`
+}
+
+// UserOutput is the response for disabling or enabling a user
+type UserOutput struct {
+	Body User
+}
+
+// DeletedOutput is the response for deleting an API key or a user
+type DeletedOutput struct {
+	Body struct {
+		Message string `json:"message" doc:"Success message" example:"API key deleted"`
+		ID      string `json:"id" doc:"ID of what was deleted" example:"6900d436e231fdbb964c3c1c"`
+	}
+}
+
+// ResetLimitsOutput is the response for resetting rate limits
+type ResetLimitsOutput struct {
+	Body struct {
+		ResetIPs []string `json:"reset_ips" doc:"Client IPs whose rate limit was reset (where the keys were last used on this instance)" example:"[\"203.0.113.7\"]"`
+	}
+}
+
+// OrgSettings are the organization-wide settings
+type OrgSettings struct {
+	ReminderLeadMinutes int               `json:"reminder_lead_minutes" doc:"How long before a task is due reminders go out, in minutes (0 = at the due time)" minimum:"0" maximum:"10080" example:"30"`
+	AllowedTags         []string          `json:"allowed_tags" doc:"Tags tasks may carry (empty = any)" maxItems:"100" example:"[\"work\", \"home\"]"`
+	RetentionDays       int               `json:"retention_days" doc:"How long archived tasks are kept, in days (0 = forever)" minimum:"0" maximum:"3650" example:"365"`
+	AllowedEmailDomains []string          `json:"allowed_email_domains" doc:"Domains notification emails may go to; subdomains are included (empty = any)" maxItems:"100" example:"[\"example.com\"]"`
+	Anomaly             AnomalyThresholds `json:"anomaly" doc:"When a caller's usage is unusual enough to alert on"`
+	UpdatedAt           *time.Time        `json:"updated_at,omitempty" doc:"When they were last saved (absent if never)" example:"2025-01-31T12:00:00Z"`
+	UpdatedBy           string            `json:"updated_by,omitempty" doc:"Who saved them last" example:"alice"`
+}
+
+// AnomalyThresholds decide when a caller's usage is unusual enough to alert on
+// (0 = the default)
+type AnomalyThresholds struct {
+	SpikeFactor  int  `json:"spike_factor" doc:"Alert when a caller sends this many times their usual requests in a minute (0 = 10)" example:"10"`
+	MinRequests  int  `json:"min_requests" doc:"Never alert on fewer requests than this in a minute (0 = 100)" example:"100"`
+	MaxDeletions int  `json:"max_deletions" doc:"Alert when a caller deletes this many tasks in a minute (0 = 50)" example:"50"`
+	Off          bool `json:"off" doc:"Usage alerts are off" example:"false"`
+}
+
+// GetOrgSettingsInput is the input for reading the organization's settings
+type GetOrgSettingsInput struct {
+}
+
+// SetOrgSettingsInput is the input for replacing the organization's settings
+type SetOrgSettingsInput struct {
+	Body struct {
+		ReminderLeadMinutes int      `json:"reminder_lead_minutes,omitempty" doc:"How long before a task is due reminders go out, in minutes" minimum:"0" maximum:"10080" example:"30"`
+		AllowedTags         []string `json:"allowed_tags,omitempty" doc:"Tags tasks may carry (leave out for any)" maxItems:"100" example:"[\"work\", \"home\"]"`
+		RetentionDays       int      `json:"retention_days,omitempty" doc:"How long archived tasks are kept, in days (0 = forever)" minimum:"0" maximum:"3650" example:"365"`
+		AllowedEmailDomains []string `json:"allowed_email_domains,omitempty" doc:"Domains notification emails may go to, like example.com (leave out for any)" maxItems:"100" example:"[\"example.com\"]"`
+		Anomaly             struct {
+			SpikeFactor  int  `json:"spike_factor,omitempty" doc:"Alert when a caller sends this many times their usual requests in a minute (default 10)" minimum:"0" maximum:"1000" example:"10"`
+			MinRequests  int  `json:"min_requests,omitempty" doc:"Never alert on fewer requests than this in a minute (default 100)" minimum:"0" maximum:"1000000" example:"100"`
+			MaxDeletions int  `json:"max_deletions,omitempty" doc:"Alert when a caller deletes this many tasks in a minute (default 50)" minimum:"0" maximum:"1000000" example:"50"`
+			Off          bool `json:"off,omitempty" doc:"Turn usage alerts off" example:"false"`
+		} `json:"anomaly,omitempty" doc:"When a caller's usage is unusual enough to alert on (leave out for the defaults)"`
+	}
+}
+
+// OrgSettingsOutput is the response for the settings endpoints
+type OrgSettingsOutput struct {
+	Body OrgSettings
+}
+
+// RecordingTarget is a caller whose requests are being recorded
+type RecordingTarget struct {
+	ID        string    `json:"id" doc:"Target ID" example:"9f86d081884c7d65"`
+	APIKeyID  string    `json:"api_key_id,omitempty" doc:"The managed API key being recorded" example:"6900d436e231fdbb964c3c1c"`
+	RequestID string    `json:"request_id,omitempty" doc:"The X-Request-ID being recorded" example:"support-ticket-4711"`
+	Until     time.Time `json:"until" doc:"When recording stops" example:"2025-01-31T12:15:00Z"`
+	CreatedBy string    `json:"created_by,omitempty" doc:"Admin who started it" example:"api-key"`
+}
+
+// StartRecordingInput is the input for starting to record a caller
+type StartRecordingInput struct {
+	Body struct {
+		APIKeyID  string `json:"api_key_id,omitempty" doc:"Record this managed API key's requests" minLength:"24" maxLength:"24" example:"6900d436e231fdbb964c3c1c"`
+		RequestID string `json:"request_id,omitempty" doc:"Record requests sent with this X-Request-ID" maxLength:"128" example:"support-ticket-4711"`
+		Minutes   int    `json:"minutes,omitempty" doc:"How long to record" default:"15" minimum:"1" maximum:"1440" example:"15"`
+	}
+}
+
+// RecordingTargetOutput is the response for starting to record a caller
+type RecordingTargetOutput struct {
+	Body RecordingTarget
+}
+
+// ListRecordingTargetsInput is the input for listing the callers being recorded
+type ListRecordingTargetsInput struct {
+}
+
+// ListRecordingTargetsOutput is the response for listing the callers being recorded
+type ListRecordingTargetsOutput struct {
+	Body struct {
+		Targets []RecordingTarget `json:"targets" doc:"Active targets, soonest to end first"`
+	}
+}
+
+// RecordingTargetIDInput is the input for stopping a recording
+type RecordingTargetIDInput struct {
+	ID string `path:"id" doc:"Target ID" maxLength:"64" example:"9f86d081884c7d65"`
+}
+
+// Recording is one recorded request and its response
+type Recording struct {
+	ID              string              `json:"id" doc:"Recording ID" example:"67a1b2c3d4e5f60718293a4b"`
+	TargetID        string              `json:"target_id" doc:"The target that recorded it" example:"9f86d081884c7d65"`
+	At              time.Time           `json:"at" doc:"When the request came in" example:"2025-01-31T12:01:02Z"`
+	RequestID       string              `json:"request_id" doc:"Its X-Request-ID" example:"support-ticket-4711"`
+	UserID          string              `json:"user_id,omitempty" doc:"Who made it" example:"alice"`
+	APIKeyID        string              `json:"api_key_id,omitempty" doc:"The managed API key it used" example:"6900d436e231fdbb964c3c1c"`
+	Method          string              `json:"method" doc:"HTTP method" example:"PUT"`
+	URL             string              `json:"url" doc:"Path and query" example:"/tasks/6900d436e231fdbb964c3c1c"`
+	RequestHeaders  map[string][]string `json:"request_headers" doc:"Request headers (secrets redacted)" example:"{\"Content-Type\": [\"application/json\"], \"X-Api-Key\": [\"[redacted]\"]}"`
+	RequestBody     string              `json:"request_body,omitempty" doc:"Request body" example:"{\"completed\": true}"`
+	Status          int                 `json:"status" doc:"Response status" example:"200"`
+	ResponseHeaders map[string][]string `json:"response_headers" doc:"Response headers (secrets redacted)" example:"{\"Content-Type\": [\"application/json\"]}"`
+	ResponseBody    string              `json:"response_body,omitempty" doc:"Response body" example:"{\"id\": \"6900d436e231fdbb964c3c1c\", \"completed\": true}"`
+	Truncated       bool                `json:"truncated,omitempty" doc:"A body was cut at 64 KiB" example:"false"`
+	DurationMs      float64             `json:"duration_ms" doc:"How long it took" example:"4.2"`
+}
+
+// ListRecordingsInput is the input for listing recordings
+type ListRecordingsInput struct {
+	TargetID string `query:"target_id" doc:"Only this target's recordings (optional)" maxLength:"64" example:"9f86d081884c7d65"`
+	Limit    int    `query:"limit" doc:"How many to return, newest first" default:"50" minimum:"1" maximum:"200" example:"50"`
+}
+
+// ListRecordingsOutput is the response for listing recordings
+type ListRecordingsOutput struct {
+	Body struct {
+		Recordings []Recording `json:"recordings" doc:"Recordings, newest first"`
+	}
+}
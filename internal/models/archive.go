@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ArchivedTask is a task moved out of the task list by auto-archiving
+type ArchivedTask struct {
+	Task
+	ArchivedAt time.Time `json:"archived_at" doc:"When it was archived" example:"2025-03-02T03:30:00Z"`
+	Reason     string    `json:"archive_reason" doc:"Why it was archived" example:"Completed 30 days ago; your profile archives completed tasks after 30 days"`
+}
+
+// ListArchivedTasksInput is the input for listing your archived tasks
+type ListArchivedTasksInput struct {
+	Limit int `query:"limit" doc:"How many to return, most recently archived first" default:"50" minimum:"1" maximum:"200" example:"50"`
+}
+
+// ListArchivedTasksOutput is the response for listing your archived tasks
+type ListArchivedTasksOutput struct {
+	Body []ArchivedTask
+}
+
+// ActivityEntry is something the server did to one of your tasks on its own
+type ActivityEntry struct {
+	ID        string    `json:"id" doc:"Entry ID" example:"67a1b2c3d4e5f60718293a4b"`
+	Action    string    `json:"action" doc:"What happened" enum:"archived" example:"archived"`
+	TaskID    string    `json:"task_id" doc:"The task it happened to" example:"6900d436e231fdbb964c3c1c"`
+	TaskTitle string    `json:"task_title" doc:"The task's title at the time" example:"Buy groceries"`
+	Reason    string    `json:"reason" doc:"Why it happened" example:"Completed 30 days ago; your profile archives completed tasks after 30 days"`
+	At        time.Time `json:"at" doc:"When it happened" example:"2025-03-02T03:30:00Z"`
+}
+
+// ListActivityInput is the input for listing your activity
+type ListActivityInput struct {
+	Limit int `query:"limit" doc:"How many entries to return, newest first" default:"50" minimum:"1" maximum:"200" example:"50"`
+}
+
+// ListActivityOutput is the response for listing your activity
+type ListActivityOutput struct {
+	Body []ActivityEntry
+}
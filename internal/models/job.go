@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// JobStatus is the public view of a background job
+type JobStatus struct {
+	ID          string     `json:"id" doc:"Job ID" example:"6900d436e231fdbb964c3c1c"`
+	Type        string     `json:"type" doc:"What the job does" example:"webhook.deliver"`
+	Status      string     `json:"status" doc:"Where the job is in its lifecycle" enum:"queued,running,succeeded,failed" example:"queued"`
+	Attempts    int        `json:"attempts" doc:"Attempts started so far" example:"1"`
+	MaxAttempts int        `json:"max_attempts" doc:"Attempts allowed before the job fails for good" example:"5"`
+	LastError   string     `json:"last_error,omitempty" doc:"Error from the most recent failed attempt" example:"connection refused"`
+	RunAt       time.Time  `json:"run_at" doc:"Earliest time the next attempt may start" example:"2025-01-31T12:00:30Z"`
+	CreatedAt   time.Time  `json:"created_at" doc:"When the job was enqueued" example:"2025-01-31T12:00:00Z"`
+	UpdatedAt   time.Time  `json:"updated_at" doc:"When the job last changed" example:"2025-01-31T12:00:05Z"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty" doc:"When the job succeeded or failed for good" example:"2025-01-31T12:01:00Z"`
+}
+
+// GetJobInput is the input for getting a job's status
+type GetJobInput struct {
+	ID string `path:"id" doc:"Job ID" minLength:"24" maxLength:"24" example:"6900d436e231fdbb964c3c1c"`
+}
+
+// GetJobOutput is the response for getting a job's status
+type GetJobOutput struct {
+	Body JobStatus
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"reflect"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// OptionalParam is a query parameter that may be left out
+// A plain bool can't tell ?completed=false from no ?completed at all, and
+// Huma doesn't accept pointers as parameters, so the value comes with IsSet.
+// Huma parses into Value with T's own rules (a bool must be true or false,
+// anything else is a 422) and documents the parameter as a T.
+type OptionalParam[T any] struct {
+	Value T
+	IsSet bool
+}
+
+// Some returns a parameter set to value (for tests and handlers calling handlers)
+func Some[T any](value T) OptionalParam[T] {
+	return OptionalParam[T]{Value: value, IsSet: true}
+}
+
+// Schema documents the parameter as a T (huma.SchemaProvider)
+func (o OptionalParam[T]) Schema(r huma.Registry) *huma.Schema {
+	return huma.SchemaFromType(r, reflect.TypeOf(o.Value))
+}
+
+// Receiver tells Huma to parse the parameter into Value (huma.ParamWrapper)
+func (o *OptionalParam[T]) Receiver() reflect.Value {
+	return reflect.ValueOf(o).Elem().Field(0)
+}
+
+// OnParamSet records whether the request had the parameter (huma.ParamReactor)
+func (o *OptionalParam[T]) OnParamSet(isSet bool, parsed any) {
+	o.IsSet = isSet
+}
+
+// Ptr returns nil when the parameter wasn't set, otherwise its value
+func (o OptionalParam[T]) Ptr() *T {
+	if !o.IsSet {
+		return nil
+	}
+	value := o.Value
+	return &value
+}
+
+// DryRun is embedded in the inputs of the endpoints that change tasks
+// With either parameter the request is checked and answered as usual, but
+// nothing is saved - for validating a form, or previewing an import.
+type DryRun struct {
+	DryRunQuery  bool `query:"dry_run" doc:"Check the request and return the would-be result without saving anything" example:"true"`
+	DryRunHeader bool `header:"Dry-Run" doc:"Same as ?dry_run=true" example:"true"`
+}
+
+// IsDryRun reports whether the request asked for a dry run
+func (d DryRun) IsDryRun() bool {
+	return d.DryRunQuery || d.DryRunHeader
+}
+
+// BulkConfirm is embedded in the inputs of the endpoints that can change
+// many tasks at once: above BULK_CONFIRM_ABOVE tasks they only go through
+// with the count the first, refused, request reported
+type BulkConfirm struct {
+	Confirm int `query:"confirm" minimum:"0" doc:"How many tasks the request changes, to confirm a change to more than BULK_CONFIRM_ABOVE at once (the 428 answer says how many)" example:"120"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Profile is a user's settings
+type Profile struct {
+	UserID          string     `json:"user_id" doc:"Who you are (the owner of your API key)" example:"alice"`
+	TimeZone        string     `json:"time_zone" doc:"Your time zone (IANA name): what today, tomorrow and overdue mean for your due dates, and when your digest arrives" example:"Europe/Paris"`
+	Locale          string     `json:"locale" doc:"How dates and numbers are written in your digest and CSV exports (a CSV export's Accept-Language comes first)" example:"fr"`
+	AutoArchiveDays int        `json:"auto_archive_days" doc:"Completed tasks move to /me/archived-tasks this many days after completion (0 = never)" example:"30"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" doc:"When you last changed it (absent while you have the defaults)" example:"2025-01-31T12:00:00Z"`
+}
+
+// GetProfileInput is the input for reading your profile (nothing to send)
+type GetProfileInput struct{}
+
+// UpdateProfileInput is the input for replacing your profile
+type UpdateProfileInput struct {
+	Body struct {
+		TimeZone        string `json:"time_zone,omitempty" doc:"IANA time zone name (default UTC)" maxLength:"64" example:"Europe/Paris"`
+		Locale          string `json:"locale,omitempty" doc:"BCP 47 language tag, matched to the closest supported one: en, en-US, de, fr or es (default en)" maxLength:"35" example:"fr-FR"`
+		AutoArchiveDays int    `json:"auto_archive_days,omitempty" doc:"Archive completed tasks this many days after completion (default 0 = never)" minimum:"0" maximum:"3650" example:"30"`
+	}
+}
+
+// ProfileOutput is the response for reading or replacing your profile
+type ProfileOutput struct {
+	Body Profile
+}
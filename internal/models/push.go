@@ -0,0 +1,103 @@
+package models
+
+import "time"
+
+// PushSubscription is a device that gets the user's notifications
+type PushSubscription struct {
+	ID        string    `json:"id" doc:"Subscription ID (the same device always gets the same ID)" example:"3f2a9c0e1b7d4a5e6f708192"`
+	Type      string    `json:"type" doc:"Where notifications are sent" enum:"webpush,fcm" example:"webpush"`
+	Endpoint  string    `json:"endpoint,omitempty" doc:"Push service URL (webpush only)" example:"https://fcm.googleapis.com/fcm/send/dQw4w9WgXcQ"`
+	CreatedAt time.Time `json:"created_at" doc:"When the device subscribed" example:"2025-01-31T12:00:00Z"`
+}
+
+// ListPushSubscriptionsInput is the input for listing your devices (nothing to send)
+type ListPushSubscriptionsInput struct{}
+
+// ListPushSubscriptionsOutput is the response for listing your devices
+// It also tells a browser what it needs to subscribe
+type ListPushSubscriptionsOutput struct {
+	Body struct {
+		VAPIDPublicKey string             `json:"vapid_public_key,omitempty" doc:"applicationServerKey for PushManager.subscribe() (absent when Web Push is off)" example:"BOr8jh..."`
+		WebPush        bool               `json:"webpush" doc:"Whether the server can send Web Push" example:"true"`
+		FCM            bool               `json:"fcm" doc:"Whether the server can send to FCM device tokens" example:"false"`
+		Subscriptions  []PushSubscription `json:"subscriptions" doc:"Your subscribed devices, oldest first"`
+	}
+}
+
+// PushKeys are a browser's encryption keys, as PushSubscription.toJSON() gives them
+type PushKeys struct {
+	P256dh string `json:"p256dh" doc:"Browser public key, base64url" minLength:"1" maxLength:"200" example:"BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"`
+	Auth   string `json:"auth" doc:"Browser auth secret, base64url" minLength:"1" maxLength:"100" example:"BTBZMqHH6r4Tts7J_aSIgg"`
+}
+
+// CreatePushSubscriptionInput is the input for subscribing a device
+// A browser can send PushSubscription.toJSON() with "type": "webpush" added
+type CreatePushSubscriptionInput struct {
+	Body struct {
+		Type           string    `json:"type" doc:"webpush for a browser, fcm for a Firebase device token" enum:"webpush,fcm" example:"webpush"`
+		Endpoint       string    `json:"endpoint,omitempty" doc:"Push service URL (webpush)" maxLength:"2048" example:"https://fcm.googleapis.com/fcm/send/dQw4w9WgXcQ"`
+		ExpirationTime *int64    `json:"expirationTime,omitempty" doc:"Sent by browsers; ignored" required:"false" nullable:"true" example:"1735689600000"`
+		Keys           *PushKeys `json:"keys,omitempty" doc:"Browser encryption keys (webpush)"`
+		Token          string    `json:"token,omitempty" doc:"FCM registration token (fcm)" maxLength:"4096" example:"dQw4w9WgXcQ:APA91bHun4MxP5egoKMwt2KZFBaFUH"`
+	}
+}
+
+// PushSubscriptionOutput is the response for subscribing a device
+type PushSubscriptionOutput struct {
+	Body PushSubscription
+}
+
+// PushSubscriptionIDInput is the input for unsubscribing a device
+type PushSubscriptionIDInput struct {
+	ID string `path:"id" doc:"Subscription ID" minLength:"1" maxLength:"64" example:"3f2a9c0e1b7d4a5e6f708192"`
+}
+
+// TestPushInput is the input for sending yourself a test notification (nothing to send)
+type TestPushInput struct{}
+
+// TestPushOutput is the response for sending yourself a test notification
+type TestPushOutput struct {
+	Body struct {
+		JobID string `json:"job_id" doc:"Background job sending it - poll GET /jobs/{id}" example:"6900d436e231fdbb964c3c1c"`
+	}
+}
+
+// NotificationChannels are where a user wants notifications
+type NotificationChannels struct {
+	Email bool `json:"email" doc:"Email to your address (the daily digest)" example:"true"`
+	Push  bool `json:"push" doc:"Web Push and FCM devices (see /me/push-subscriptions)" example:"true"`
+	Slack bool `json:"slack" doc:"Slack (stored for when Slack notifications are added)" example:"false"`
+}
+
+// NotificationEvents are what a user wants to be notified about
+type NotificationEvents struct {
+	Reminders   bool `json:"reminders" doc:"A task is due soon" example:"true"`
+	Assignments bool `json:"assignments" doc:"A task was assigned to you" example:"true"`
+	Mentions    bool `json:"mentions" doc:"Someone mentioned you" example:"false"`
+	DailyDigest bool `json:"daily_digest" doc:"A morning email (in your time zone - see /me/profile) of what's due and yesterday's completions (off unless you turn it on; needs email)" required:"false" example:"true"`
+}
+
+// NotificationSettings are a user's notification preferences
+type NotificationSettings struct {
+	Channels  NotificationChannels `json:"channels" doc:"Channels to notify you on"`
+	Events    NotificationEvents   `json:"events" doc:"Events to notify you about"`
+	Email     string               `json:"email,omitempty" doc:"Where email notifications go" example:"alice@example.com"`
+	UpdatedAt *time.Time           `json:"updated_at,omitempty" doc:"When you last changed them (absent while you have the defaults)" example:"2025-01-31T12:00:00Z"`
+}
+
+// GetNotificationSettingsInput is the input for reading your notification settings (nothing to send)
+type GetNotificationSettingsInput struct{}
+
+// UpdateNotificationSettingsInput is the input for replacing your notification settings
+type UpdateNotificationSettingsInput struct {
+	Body struct {
+		Channels NotificationChannels `json:"channels" doc:"Channels to notify you on"`
+		Events   NotificationEvents   `json:"events" doc:"Events to notify you about"`
+		Email    string               `json:"email,omitempty" doc:"Where email notifications go" maxLength:"254" example:"alice@example.com"`
+	}
+}
+
+// NotificationSettingsOutput is the response for reading or replacing your notification settings
+type NotificationSettingsOutput struct {
+	Body NotificationSettings
+}
@@ -0,0 +1,8 @@
+package models
+
+// ResetSandboxOutput is the response for emptying a sandbox
+type ResetSandboxOutput struct {
+	Body struct {
+		Message string `json:"message" doc:"Success message" example:"Sandbox reset"`
+	}
+}
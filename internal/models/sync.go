@@ -0,0 +1,74 @@
+package models
+
+// Operations in SyncChange.Op
+const (
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+)
+
+// Conflict strategies in SyncInput.Body.Strategy
+// They decide what happens to an update whose task the server changed since
+// the client's base copy.
+const (
+	SyncLastWriteWins = "last-write-wins" // Apply the client's fields anyway
+	SyncMerge         = "merge"           // Apply it if the two changed different fields, else 409
+	SyncReject        = "reject"          // 409 if the server changed anything
+)
+
+// SyncChange is one change an offline client made to its copy of the tasks
+// create takes the fields of a new task (title is required) and a client_id
+// for matching the result to the client's local task; update takes the id
+// and the fields that changed (and base, to detect conflicts); delete only
+// the id.
+type SyncChange struct {
+	Op          string  `json:"op" doc:"What the client did" enum:"create,update,delete" example:"update"`
+	ClientID    string  `json:"client_id,omitempty" doc:"The client's own ID for a task it created, echoed in the result" maxLength:"64" example:"local-7"`
+	ID          string  `json:"id,omitempty" doc:"Task ID (update and delete)" pattern:"^([0-9a-f]{24}|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$" example:"6900d436e231fdbb964c3c1c"`
+	Title       *string `json:"title,omitempty" doc:"Title of the task" minLength:"1" maxLength:"200" example:"Buy groceries"`
+	Description *string `json:"description,omitempty" doc:"Detailed description; an empty string clears it" maxLength:"1000" example:"Buy milk, eggs, and bread"`
+	Completed   *bool   `json:"completed,omitempty" doc:"Whether the task is completed" example:"true"`
+	Due         *string `json:"due,omitempty" doc:"Due date, in the same forms as on create; an empty string removes it" maxLength:"64" example:"friday"`
+	Base        *Task   `json:"base,omitempty" doc:"The task as the client last got it from the server (update). Needed by the merge and reject strategies; with it every result reports what the server changed since."`
+}
+
+// SyncConflict is a field of a task the server changed since the client's base
+type SyncConflict struct {
+	Field  string `json:"field" doc:"The field" enum:"title,description,completed,due_at" example:"title"`
+	Base   any    `json:"base" doc:"Its value in the client's base copy" example:"\"Buy milk\""`
+	Server any    `json:"server" doc:"Its value on the server now" example:"\"Buy oat milk\""`
+	Client any    `json:"client,omitempty" doc:"The value the client set, when it changed the field too (for due, the due it sent)" example:"\"Buy milk and eggs\""`
+}
+
+// SyncResult is what became of one SyncChange, in the same order
+type SyncResult struct {
+	Op       string `json:"op" doc:"The change's operation" example:"update"`
+	ClientID string `json:"client_id,omitempty" doc:"The change's client_id" example:"local-7"`
+	ID       string `json:"id,omitempty" doc:"Task ID (for a create, the ID the server gave the task)" example:"6900d436e231fdbb964c3c1c"`
+	Status   int    `json:"status" doc:"HTTP status the change would have got on its own: 200 or 201 when it was applied" example:"200"`
+	Error    string `json:"error,omitempty" doc:"Why the change wasn't applied" example:"Task not found"`
+	Task     *Task  `json:"task,omitempty" doc:"The task as saved (create and update)"`
+
+	Conflicts []SyncConflict `json:"conflicts,omitempty" doc:"Fields the server changed since the update's base: the ones with client set overlap the client's change"`
+}
+
+// SyncInput is the input for syncing an offline client
+type SyncInput struct {
+	DryRun
+	BulkConfirm
+	Body struct {
+		Token    string       `json:"token,omitempty" doc:"Token from the client's last sync; leave it out on the first one" maxLength:"32" example:"42"`
+		Strategy string       `json:"strategy,omitempty" doc:"What to do with an update whose task the server changed since its base: last-write-wins applies it, merge applies it unless both changed the same field, reject refuses it. Refused updates get a 409 result listing the conflicts." enum:"last-write-wins,merge,reject" default:"last-write-wins" example:"merge"`
+		Changes  []SyncChange `json:"changes" doc:"The client's changes since its last sync, oldest first" maxItems:"500"`
+	}
+}
+
+// SyncOutput is the response for a sync
+type SyncOutput struct {
+	Body struct {
+		Token   string       `json:"token" doc:"Send this with the next sync" example:"45"`
+		Results []SyncResult `json:"results" doc:"One result per change, in order"`
+		Full    bool         `json:"full" doc:"The server's tasks changed since the token (or there was no token): tasks is every task, replacing the client's copy" example:"false"`
+		Tasks   []Task       `json:"tasks,omitempty" doc:"Every task, when full is true"`
+	}
+}
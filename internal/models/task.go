@@ -2,43 +2,95 @@ package models
 
 // THIRD PARTY IMPORTS
 import (
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
 )
 
 // Task represents a todo item in our application
 type Task struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id" doc:"Unique identifier for the task"` // Mongodb-specific data type for unique IDs. It is a 12-byte string. MongoDB creates it automatically.
-	Title       string             `json:"title" doc:"Title of the task" minLength:"1" maxLength:"200"`
-	Description string             `json:"description,omitempty" doc:"Detailed description of the task" maxLength:"1000"`
-	Completed   bool               `json:"completed" doc:"Whether the task is completed"`
+	ID              TaskID        `bson:"_id,omitempty" json:"id" doc:"Unique identifier for the task: an ObjectID, or a UUIDv7 with TASK_ID_FORMAT=uuid" example:"6900d436e231fdbb964c3c1c"` // Generated by the repository when the task is created (see GenerateTaskID).
+	Title           string        `json:"title" doc:"Title of the task" minLength:"1" maxLength:"200" example:"Buy groceries"`
+	Description     string        `json:"description,omitempty" doc:"Detailed description of the task (left out of lists unless include=description)" maxLength:"1000" example:"Buy milk, eggs, and bread"`
+	DescriptionHTML string        `bson:"-" json:"description_html,omitempty" readOnly:"true" doc:"The description's Markdown as HTML that is safe to show as it is (only with render=html)" example:"<p>Buy <strong>oat</strong> milk</p>"`
+	Completed       bool          `json:"completed" doc:"Whether the task is completed" example:"false"`
+	CompletedAt     *time.Time    `bson:"completed_at,omitempty" json:"completed_at,omitempty" doc:"When the task was completed (absent while it's open)" example:"2025-01-31T17:30:00Z"`
+	DueAt           *time.Time    `bson:"due_at,omitempty" json:"due_at,omitempty" doc:"When the task is due (absent if it has no due date)" example:"2025-02-01T22:59:59Z"`
+	LinkPreviews    []LinkPreview `bson:"-" json:"link_previews,omitempty" readOnly:"true" doc:"What the links in the description point at, when their pages have been fetched (GET /tasks/{id} only)"`
+	OwnerID         string        `bson:"owner_id,omitempty" json:"-"` // User who created it (see Principal.UserID), counted against their quota. Not part of the API.
+}
+
+// LinkPreview is the title, description and image of a page linked from a
+// task's description
+type LinkPreview struct {
+	URL         string `json:"url" doc:"The link, as written in the description" example:"https://example.com/recipes/pancakes"`
+	Title       string `json:"title,omitempty" doc:"The page's title" example:"Fluffy pancakes"`
+	Description string `json:"description,omitempty" doc:"The page's summary of itself" example:"Ready in 20 minutes, with three ingredients."`
+	Image       string `json:"image,omitempty" doc:"URL of the page's preview image" example:"https://example.com/img/pancakes.jpg"`
 }
 
 // CreateTaskInput is the input for creating a new task
 type CreateTaskInput struct {
+	DryRun
 	Body struct {
 		Title       string `json:"title" doc:"Title of the task" minLength:"1" maxLength:"200" example:"Buy groceries"`
 		Description string `json:"description,omitempty" doc:"Detailed description" maxLength:"1000" example:"Buy milk, eggs, and bread"`
+		Due         string `json:"due,omitempty" doc:"When it's due, in your time zone (see /me/profile): RFC 3339, a local time (2025-02-01 17:00), a date (due at the end of it), or a phrase like today, tomorrow at 5pm, friday, in 3 days" maxLength:"64" example:"tomorrow at 5pm"`
 	}
 }
 
-// CreateTaskOutput is the response for creating a task
+// CreateTaskOutput is the response for creating a task (201 Created)
 type CreateTaskOutput struct {
-	Body Task
+	Status   int    // 201, or 200 on a dry run, which creates nothing
+	Location string `header:"Location" doc:"URL of the new task (not sent on a dry run)" example:"/tasks/6900d436e231fdbb964c3c1c"`
+	Body     Task
 }
 
 // GetTasksInput is the input for getting all tasks with optional filters
 type GetTasksInput struct {
-	Completed string `query:"completed" doc:"Filter tasks by completion status (optional)" example:"true" enum:"true,false"`
+	Completed   OptionalParam[bool] `query:"completed" doc:"Filter tasks by completion status (optional)" example:"true"`
+	Include     []string            `query:"include" doc:"Heavy fields to add to the summary (id, title, completed), comma-separated" example:"description" enum:"description"`
+	Due         string              `query:"due" doc:"Only open tasks due today or already overdue, in your time zone (see /me/profile), soonest first" example:"today" enum:"today,overdue"`
+	IfNoneMatch string              `header:"If-None-Match" doc:"ETag of the list the client already has: 304 Not Modified while it's still current" example:"\"42-all\""`
+	Limit       int                 `query:"limit" doc:"Return at most this many tasks (absent: every task, or MOBILE_LIST_LIMIT for mobile clients and the API key's own default if it has one)" minimum:"0" example:"50"`
+	Offset      int                 `query:"offset" doc:"Skip this many tasks first, to fetch the pages after the first" minimum:"0" example:"0"`
+	Mobile      string              `header:"Sec-CH-UA-Mobile" doc:"Client hint sent by mobile browsers: ?1 gets the shorter mobile page when there's no limit" example:"?1"`
 }
 
 // GetTasksOutput is the response for getting all tasks
+// ETag changes whenever any task is created, updated or deleted
 type GetTasksOutput struct {
-	Body []Task
+	ETag         string   `header:"ETag" doc:"Version of this list, to send back in If-None-Match" example:"\"42-all\""`
+	CacheControl string   `header:"Cache-Control" doc:"Clients may keep the list but must revalidate it" example:"private, no-cache"`
+	TotalCount   int      `header:"X-Total-Count" doc:"How many tasks the whole list has (more than the body holds when it's a page)" example:"120"`
+	Vary         []string `header:"Vary" doc:"The default page size depends on the Sec-CH-UA-Mobile client hint, so caches must keep a copy per value" example:"Sec-CH-UA-Mobile"`
+	AcceptCH     string   `header:"Accept-CH" doc:"Asks browsers to send the Sec-CH-UA-Mobile client hint" example:"Sec-CH-UA-Mobile"`
+	Body         []Task
+}
+
+// ExportTasksInput is the input for exporting tasks as NDJSON or CSV
+// The response has no Output struct: it's streamed (see handlers.ExportTasks)
+type ExportTasksInput struct {
+	Completed      OptionalParam[bool] `query:"completed" doc:"Only export completed or incomplete tasks (optional)" example:"false"`
+	Format         string              `query:"format" enum:"ndjson,csv" default:"ndjson" example:"csv" doc:"ndjson for programs (RFC 3339 dates), csv for spreadsheets (dates in your locale and time zone)"`
+	AcceptLanguage string              `header:"Accept-Language" doc:"Locale for the CSV's dates (optional; defaults to your profile's)" example:"de-DE"`
+}
+
+// TaskStats counts the tasks by status
+type TaskStats struct {
+	Open      int64     `json:"open" bson:"open" doc:"Tasks not completed yet" example:"12"`
+	Completed int64     `json:"completed" bson:"completed" doc:"Completed tasks" example:"30"`
+	Total     int64     `json:"total" bson:"total" doc:"All tasks" example:"42"`
+	CountedAt time.Time `json:"counted_at" bson:"counted_at" doc:"When the tasks were last counted" example:"2025-01-01T09:00:00Z"`
+}
+
+// GetStatsOutput is the response for the task counts
+type GetStatsOutput struct {
+	Body TaskStats
 }
 
 // GetTaskInput is the input for getting a single task
 type GetTaskInput struct {
-	ID string `path:"id" doc:"Task ID" minLength:"24" maxLength:"24"`
+	ID     TaskID `path:"id" doc:"Task ID" minLength:"24" maxLength:"36" example:"6900d436e231fdbb964c3c1c"`
+	Render string `query:"render" doc:"html adds description_html: the description's Markdown rendered and sanitized" enum:"html" example:"html"`
 }
 
 // GetTaskOutput is the response for getting a single task
@@ -48,11 +100,13 @@ type GetTaskOutput struct {
 
 // UpdateTaskInput is the input for updating a task
 type UpdateTaskInput struct {
-	ID   string `path:"id" doc:"Task ID" minLength:"24" maxLength:"24"`
+	DryRun
+	ID   TaskID `path:"id" doc:"Task ID" minLength:"24" maxLength:"36" example:"6900d436e231fdbb964c3c1c"`
 	Body struct {
-		Title       *string `json:"title,omitempty" doc:"Title of the task" minLength:"1" maxLength:"200"`
-		Description *string `json:"description,omitempty" doc:"Detailed description" maxLength:"1000"`
-		Completed   *bool   `json:"completed,omitempty" doc:"Whether the task is completed"`
+		Title       *string `json:"title,omitempty" doc:"Title of the task" minLength:"1" maxLength:"200" example:"Buy groceries"`
+		Description *string `json:"description,omitempty" doc:"Detailed description" maxLength:"1000" example:"Buy milk, eggs, and bread"`
+		Completed   *bool   `json:"completed,omitempty" doc:"Whether the task is completed" example:"true"`
+		Due         *string `json:"due,omitempty" doc:"New due date, in the same forms as on create; an empty string removes it" maxLength:"64" example:"friday"`
 	}
 }
 
@@ -61,16 +115,28 @@ type UpdateTaskOutput struct {
 	Body Task
 }
 
+// TaskStateInput is the input for completing or reopening a task
+type TaskStateInput struct {
+	DryRun
+	ID TaskID `path:"id" doc:"Task ID" minLength:"24" maxLength:"36" example:"6900d436e231fdbb964c3c1c"`
+}
+
+// TaskStateOutput is the task after it was completed or reopened
+type TaskStateOutput struct {
+	Body Task
+}
+
 // DeleteTaskInput is the input for deleting a task
 type DeleteTaskInput struct {
-	ID string `path:"id" doc:"Task ID" minLength:"24" maxLength:"24"`
+	DryRun
+	ID TaskID `path:"id" doc:"Task ID" minLength:"24" maxLength:"36" example:"6900d436e231fdbb964c3c1c"`
 }
 
 // DeleteTaskOutput is the response for deleting a task
 type DeleteTaskOutput struct {
 	Body struct {
-		Message string `json:"message" doc:"Success message"`
-		ID      string `json:"id" doc:"Deleted task ID"`
+		Message string `json:"message" doc:"Success message" example:"Task deleted successfully"`
+		ID      string `json:"id" doc:"Deleted task ID" example:"6900d436e231fdbb964c3c1c"`
 	}
 }
 
@@ -80,9 +146,70 @@ type HealthInput struct {
 }
 
 // HealthOutput is the response for the health check
+// Status sets the HTTP status code: 200 when MongoDB answers, 503 when not
 type HealthOutput struct {
+	Status int
+	Body   struct {
+		Status   string          `json:"status" doc:"Health status" enum:"healthy,unhealthy" example:"healthy"`
+		Message  string          `json:"message" doc:"Health message" example:"Server is running with MongoDB!"`
+		Database DependencyCheck `json:"database" doc:"Result of pinging MongoDB"`
+	}
+}
+
+// LivenessOutput is the response for the liveness probe (/healthz)
+type LivenessOutput struct {
+	Body struct {
+		Status string `json:"status" doc:"Process status" example:"ok"`
+	}
+}
+
+// DependencyCheck is the result of checking one dependency (e.g. MongoDB)
+type DependencyCheck struct {
+	Status    string  `json:"status" doc:"Dependency status" enum:"up,down" example:"up"`
+	LatencyMs float64 `json:"latency_ms" doc:"How long the check took in milliseconds" example:"1.7"`
+	Error     string  `json:"error,omitempty" doc:"Why the check failed (only when the server shows error details)" example:"server selection error: context deadline exceeded"`
+}
+
+// ReadinessOutput is the response for the readiness probe (/readyz)
+// Status sets the HTTP status code: 200 when ready, 503 when not
+type ReadinessOutput struct {
+	Status int
+	Body   struct {
+		Status string                     `json:"status" doc:"Overall readiness" enum:"ready,not_ready" example:"ready"`
+		Checks map[string]DependencyCheck `json:"checks" doc:"Result of each dependency check"`
+	}
+}
+
+// ComponentHealth is the health of one component in /health/details
+type ComponentHealth struct {
+	Status      string     `json:"status" doc:"Component status" enum:"ok,degraded,down" example:"ok"`
+	LatencyMs   float64    `json:"latency_ms" doc:"How long the check took in milliseconds" example:"1.7"`
+	Message     string     `json:"message,omitempty" doc:"Detail about the current status" example:"2 of 4 workers busy"`
+	LastError   string     `json:"last_error,omitempty" doc:"Most recent error reported by this component" example:"connection refused"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty" doc:"When the most recent error happened" example:"2025-01-31T12:00:00Z"`
+}
+
+// HealthDetailsOutput is the response for the deep health check
+// Status sets the HTTP status code: 200 for ok/degraded, 503 for down
+type HealthDetailsOutput struct {
+	Status int
+	Body   struct {
+		Status     string                     `json:"status" doc:"Overall status (worst component)" enum:"ok,degraded,down" example:"ok"`
+		Components map[string]ComponentHealth `json:"components" doc:"Status of each component"`
+	}
+}
+
+// VersionInput is the input for the version endpoint
+type VersionInput struct {
+}
+
+// VersionOutput is the response for the version endpoint
+type VersionOutput struct {
 	Body struct {
-		Status  string `json:"status" doc:"Health status" example:"healthy"`
-		Message string `json:"message" doc:"Health message" example:"Server is running with MongoDB!"`
+		Version   string   `json:"version" doc:"API release version" example:"1.0.0"`
+		GitSHA    string   `json:"git_sha" doc:"Commit the binary was built from" example:"3cea4fa"`
+		BuildTime string   `json:"build_time" doc:"When the binary was built (UTC)" example:"2025-01-31T12:00:00Z"`
+		GoVersion string   `json:"go_version" doc:"Go toolchain version" example:"go1.24.0"`
+		Features  []string `json:"features" doc:"Feature flags enabled at build time" example:"[\"tracing\"]"`
 	}
 }
@@ -0,0 +1,264 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ============================================================================
+// TASK IDS
+// ============================================================================
+// A task's ID is either a MongoDB ObjectID ("6900d436e231fdbb964c3c1c", the
+// default) or a UUIDv7 ("0192f0c1-6a3e-7c4d-9b1a-5f2e8d7c6b5a", with
+// TASK_ID_FORMAT=uuid). Both start with their creation time, so they sort
+// the same way. Either kind is accepted whatever the setting, so switching
+// it leaves the existing tasks where they are: it only decides what new
+// tasks get. ObjectIDs are stored as ObjectIDs, UUIDs as strings.
+
+// The TASK_ID_FORMAT values
+const (
+	IDFormatObjectID = "objectid"
+	IDFormatUUID     = "uuid"
+)
+
+// useUUIDs is whether GenerateTaskID makes UUIDs (see InitTaskIDFormat)
+var useUUIDs atomic.Bool
+
+// InitTaskIDFormat picks the kind of ID new tasks get
+// Call it at startup; anything but IDFormatUUID means ObjectIDs.
+func InitTaskIDFormat(format string) {
+	useUUIDs.Store(format == IDFormatUUID)
+}
+
+// TaskID is a task's ID
+// In a URL it's parsed once, before the handler runs: Huma reads the path
+// parameter into Raw, and Resolve turns it into the ID or answers 400, so
+// handlers only ever see IDs that parsed.
+type TaskID struct {
+	Raw string // As sent, e.g. "6900d436e231fdbb964c3c1c"; empty for IDs that weren't
+
+	value string             // The ID as the API shows it; "" = no ID
+	oid   primitive.ObjectID // Set when it's an ObjectID
+}
+
+// NewTaskID wraps an ObjectID (for tests and IDs read from MongoDB)
+func NewTaskID(oid primitive.ObjectID) TaskID {
+	return TaskID{value: oid.Hex(), oid: oid}
+}
+
+// GenerateTaskID returns an ID for a new task, of the kind TASK_ID_FORMAT picks
+func GenerateTaskID() TaskID {
+	if useUUIDs.Load() {
+		return newUUIDv7(time.Now())
+	}
+	return NewTaskID(primitive.NewObjectID())
+}
+
+// ParseTaskID parses an ID sent by a client: an ObjectID or a UUID
+func ParseTaskID(s string) (TaskID, error) {
+	switch len(s) {
+	case 24:
+		oid, err := primitive.ObjectIDFromHex(s)
+		if err == nil {
+			return NewTaskID(oid), nil
+		}
+	case 36:
+		if isUUID(s) {
+			return TaskID{value: strings.ToLower(s)}, nil
+		}
+	}
+	return TaskID{}, fmt.Errorf("invalid task ID %q: must be an ObjectID (24 hex characters) or a UUID", s)
+}
+
+// MustParseTaskID is ParseTaskID for IDs known to be valid; it panics
+// otherwise (for tests)
+func MustParseTaskID(s string) TaskID {
+	id, err := ParseTaskID(s)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// String returns the ID as the API shows it (as sent, for one that didn't parse)
+func (t TaskID) String() string {
+	if t.value == "" {
+		return t.Raw
+	}
+	return t.value
+}
+
+// IsZero reports whether there is no ID (a task not saved yet)
+// It also makes bson's omitempty leave it out, so MongoDB assigns none.
+func (t TaskID) IsZero() bool {
+	return t.value == ""
+}
+
+// IsUUID reports whether the ID is a UUID rather than an ObjectID
+func (t TaskID) IsUUID() bool {
+	return t.value != "" && t.oid.IsZero()
+}
+
+// Time returns when the ID was generated, which both kinds record
+func (t TaskID) Time() time.Time {
+	if !t.IsUUID() {
+		return t.oid.Timestamp()
+	}
+	raw, _ := hex.DecodeString(strings.ReplaceAll(t.value, "-", "")[:12])
+	var ms [8]byte
+	copy(ms[2:], raw)
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(ms[:])))
+}
+
+// MarshalJSON writes the ID as a string
+func (t TaskID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.value)
+}
+
+// UnmarshalJSON reads an ID written by MarshalJSON ("" = no ID)
+func (t *TaskID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*t = TaskID{}
+		return nil
+	}
+	parsed, err := ParseTaskID(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// MarshalBSONValue stores an ObjectID as an ObjectID and a UUID as a string
+func (t TaskID) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if t.IsUUID() {
+		return bson.MarshalValue(t.value)
+	}
+	return bson.MarshalValue(t.oid)
+}
+
+// UnmarshalBSONValue reads an ID stored by MarshalBSONValue
+func (t *TaskID) UnmarshalBSONValue(typ bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: typ, Value: data}
+	switch typ {
+	case bson.TypeObjectID:
+		*t = NewTaskID(raw.ObjectID())
+		return nil
+	case bson.TypeString:
+		parsed, err := ParseTaskID(raw.StringValue())
+		if err != nil {
+			return err
+		}
+		*t = parsed
+		return nil
+	default:
+		return fmt.Errorf("task ID stored as %s", typ)
+	}
+}
+
+// Schema documents the ID as a string (huma.SchemaProvider)
+func (t TaskID) Schema(r huma.Registry) *huma.Schema {
+	return huma.SchemaFromType(r, reflect.TypeOf(t.Raw))
+}
+
+// Receiver tells Huma to read the parameter into Raw (huma.ParamWrapper)
+func (t *TaskID) Receiver() reflect.Value {
+	return reflect.ValueOf(t).Elem().Field(0)
+}
+
+// Resolve parses Raw, or answers 400 (huma.Resolver)
+// Huma runs it on the path parameter before the handler.
+func (t *TaskID) Resolve(ctx huma.Context) []error {
+	if !t.IsZero() {
+		return nil
+	}
+	switch len(t.Raw) {
+	case 24, 36:
+	default:
+		// Too short or too long for either kind: the same 422 as minLength
+		// and maxLength (which allow both lengths, and what's in between)
+		if len(t.Raw) > 24 && len(t.Raw) < 36 {
+			return []error{&huma.ErrorDetail{Location: "path.id", Message: "expected length 24 or 36", Value: t.Raw}}
+		}
+		return nil // minLength/maxLength already said so
+	}
+	parsed, err := ParseTaskID(t.Raw)
+	if err != nil {
+		return []error{&invalidTaskID{value: t.Raw}}
+	}
+	parsed.Raw = t.Raw
+	*t = parsed
+	return nil
+}
+
+// invalidTaskID is the 400 for an ID that isn't one
+type invalidTaskID struct {
+	value string
+}
+
+func (e *invalidTaskID) Error() string {
+	return "Invalid task ID format"
+}
+
+// GetStatus makes it a 400 instead of the usual 422 (huma.StatusError)
+func (e *invalidTaskID) GetStatus() int {
+	return http.StatusBadRequest
+}
+
+// ErrorDetail points at the parameter (huma.ErrorDetailer)
+func (e *invalidTaskID) ErrorDetail() *huma.ErrorDetail {
+	return &huma.ErrorDetail{Location: "path.id", Message: "must be an ObjectID (24 hex characters) or a UUID", Value: e.value}
+}
+
+// ============================================================================
+// UUIDv7
+// ============================================================================
+
+// newUUIDv7 creates a UUIDv7 (RFC 9562): 48 bits of Unix milliseconds, the
+// version and variant bits, and 74 random bits
+func newUUIDv7(now time.Time) TaskID {
+	var u [16]byte
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(now.UnixMilli()))
+	copy(u[:6], ms[2:])
+	_, _ = rand.Read(u[6:]) // Never fails (crypto/rand)
+	u[6] = u[6]&0x0f | 0x70 // Version 7
+	u[8] = u[8]&0x3f | 0x80 // Variant 10
+
+	h := hex.EncodeToString(u[:])
+	return TaskID{value: h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]}
+}
+
+// isUUID reports whether s is a UUID in its 8-4-4-4-12 hex form
+func isUUID(s string) bool {
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+				return false
+			}
+		}
+	}
+	return len(s) == 36
+}
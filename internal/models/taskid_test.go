@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestGenerateTaskID_UUID tests that TASK_ID_FORMAT=uuid makes UUIDv7s that
+// parse, record their time and survive JSON and BSON
+func TestGenerateTaskID_UUID(t *testing.T) {
+	// Arrange
+	InitTaskIDFormat(IDFormatUUID)
+	t.Cleanup(func() { InitTaskIDFormat(IDFormatObjectID) })
+	before := time.Now().Add(-time.Millisecond)
+
+	// Act
+	id := GenerateTaskID()
+
+	// Assert
+	s := id.String()
+	if len(s) != 36 || s[14] != '7' || !id.IsUUID() {
+		t.Fatalf("Expected a UUIDv7, got %q", s)
+	}
+	if at := id.Time(); at.Before(before) || at.After(time.Now()) {
+		t.Errorf("Expected the ID's time to be now, got %v", at)
+	}
+	if parsed, err := ParseTaskID(s); err != nil || parsed != id {
+		t.Errorf("Expected %q to parse back, got %v (err %v)", s, parsed, err)
+	}
+
+	var fromJSON struct{ ID TaskID }
+	data, _ := json.Marshal(struct{ ID TaskID }{id})
+	if err := json.Unmarshal(data, &fromJSON); err != nil || fromJSON.ID != id {
+		t.Errorf("Expected %q back from JSON, got %v (err %v)", s, fromJSON.ID, err)
+	}
+
+	doc, _ := bson.Marshal(bson.M{"_id": id})
+	if kind := bson.Raw(doc).Lookup("_id").Type; kind != bson.TypeString {
+		t.Errorf("Expected the UUID stored as a string, got %s", kind)
+	}
+	var fromBSON struct {
+		ID TaskID `bson:"_id"`
+	}
+	if err := bson.Unmarshal(doc, &fromBSON); err != nil || fromBSON.ID != id {
+		t.Errorf("Expected %q back from BSON, got %v (err %v)", s, fromBSON.ID, err)
+	}
+
+	t.Log("✅ UUIDv7 generated, parsed and stored")
+}
+
+// TestParseTaskID tests that both kinds of ID parse, whatever the format
+func TestParseTaskID(t *testing.T) {
+	tests := []struct {
+		in    string
+		valid bool
+	}{
+		{"6900d436e231fdbb964c3c1c", true},
+		{"0192F0C1-6A3E-7C4D-9B1A-5F2E8D7C6B5A", true},
+		{"invalid-id-format-xxxxxx", false},
+		{"0192f0c1x6a3e-7c4d-9b1a-5f2e8d7c6b5a", false},
+		{"abc", false},
+	}
+	for _, tt := range tests {
+		id, err := ParseTaskID(tt.in)
+		if (err == nil) != tt.valid {
+			t.Errorf("ParseTaskID(%q): expected valid=%v, got err %v", tt.in, tt.valid, err)
+		}
+		if tt.valid && id.IsUUID() && id.String() != "0192f0c1-6a3e-7c4d-9b1a-5f2e8d7c6b5a" {
+			t.Errorf("Expected the UUID lowercased, got %q", id)
+		}
+	}
+
+	t.Log("✅ ObjectIDs and UUIDs parsed, anything else rejected")
+}
@@ -0,0 +1,21 @@
+package models
+
+// QuotaUsage is how much of one quota a user has used
+type QuotaUsage struct {
+	Used  int64 `json:"used" doc:"How many the user has now" example:"42"`
+	Limit int64 `json:"limit" doc:"How many the user may have (0 = no limit)" example:"100"`
+}
+
+// Usage is the caller's usage of their quotas
+type Usage struct {
+	UserID string     `json:"user_id" doc:"Who is calling" example:"alice"`
+	Tasks  QuotaUsage `json:"tasks" doc:"Tasks the user has created"`
+}
+
+// GetUsageInput is the input for reading your own usage (nothing to send)
+type GetUsageInput struct{}
+
+// GetUsageOutput is the response for reading your own usage
+type GetUsageOutput struct {
+	Body Usage
+}
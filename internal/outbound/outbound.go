@@ -0,0 +1,155 @@
+// Package outbound is the HTTP client for requests the API makes to other
+// services (push services, integrations)
+//
+// Some of those URLs come from users - a browser picks its Web Push endpoint
+// - so a user could point the server at itself or at something on the
+// private network (server-side request forgery: the admin port, a database,
+// the cloud metadata service at 169.254.169.254). The client refuses to
+// connect to private, loopback and link-local addresses. The check is made
+// on the address actually dialled, after DNS, so a public name that resolves
+// to 10.0.0.5 (or a redirect to one) is refused too.
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// ErrBlocked is returned when a request would connect to an address that
+// isn't on the public internet
+var ErrBlocked = errors.New("outbound: address not allowed")
+
+// Options configures the client
+type Options struct {
+	Timeout         time.Duration // Whole request, body included (default 15s)
+	MaxConnsPerHost int           // Connections open to one host at a time (default 16)
+	AllowPrivate    bool          // Allow private and loopback addresses (local development, tests)
+}
+
+// OptionsFromEnv reads:
+//
+//	OUTBOUND_TIMEOUT=15s
+//	OUTBOUND_MAX_CONNS_PER_HOST=16
+//	OUTBOUND_ALLOW_PRIVATE=true   (local development only: push to a test server on localhost)
+func OptionsFromEnv() Options {
+	var opts Options
+	if v, err := time.ParseDuration(os.Getenv("OUTBOUND_TIMEOUT")); err == nil && v > 0 {
+		opts.Timeout = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OUTBOUND_MAX_CONNS_PER_HOST")); err == nil && v > 0 {
+		opts.MaxConnsPerHost = v
+	}
+	opts.AllowPrivate, _ = strconv.ParseBool(os.Getenv("OUTBOUND_ALLOW_PRIVATE"))
+	return opts
+}
+
+// NewClient creates a client with opts
+// Requests are traced (a client span per request, and the trace context is
+// passed on), and follow at most 5 redirects.
+func NewClient(opts Options) *http.Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 15 * time.Second
+	}
+	if opts.MaxConnsPerHost <= 0 {
+		opts.MaxConnsPerHost = 16
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil, // A proxy would make the dialled address the proxy's
+		DialContext:           NewDialer(opts).DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   4,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: otelhttp.NewTransport(transport),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("outbound: stopped after 5 redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// NewDialer returns a dialer that refuses non-public addresses (unless
+// opts.AllowPrivate), for clients that don't speak HTTP
+func NewDialer(opts Options) *net.Dialer {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}
+	if !opts.AllowPrivate {
+		dialer.ControlContext = checkAddress
+	}
+	return dialer
+}
+
+// checkAddress runs just before each connection, with the resolved address
+func checkAddress(_ context.Context, network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlocked, address)
+	}
+	if !Public(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s is not a public address", ErrBlocked, addrPort.Addr())
+	}
+	return nil
+}
+
+// notPublic are ranges that aren't caught by the netip.Addr methods
+var notPublic = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),  // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // Reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64: could reach any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"), // Local-use NAT64
+}
+
+// Public reports whether addr is on the public internet
+// e.g. 93.184.215.14 is; 10.0.0.5, 127.0.0.1, 169.254.169.254 and ::1 aren't
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() ||
+		addr.IsLinkLocalUnicast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range notPublic {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================================
+// DEFAULT CLIENT
+// ============================================================================
+
+// defaultClient is replaced by Init at startup, before anything sends
+var defaultClient = NewClient(Options{})
+
+// Init sets the client Client returns
+func Init(opts Options) *http.Client {
+	defaultClient = NewClient(opts)
+	return defaultClient
+}
+
+// Client returns the client set by Init, or one with the default options
+func Client() *http.Client {
+	return defaultClient
+}
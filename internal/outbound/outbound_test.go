@@ -0,0 +1,68 @@
+package outbound
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+// TestPublic tests which addresses count as the public internet
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"10.0.0.5", false},
+		{"172.16.3.4", false},
+		{"192.168.1.1", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false}, // Cloud metadata service
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false}, // IPv4-mapped loopback
+		{"64:ff9b::a00:5", false},   // NAT64 for 10.0.0.5
+		{"224.0.0.1", false},        // Multicast
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := Public(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("Public(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	t.Log("✅ Private, loopback and link-local addresses aren't public")
+}
+
+// TestNewClient_BlocksPrivateAddresses tests that the client won't connect to
+// a server on the loopback address unless told it may
+func TestNewClient_BlocksPrivateAddresses(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// Act
+	_, blockedErr := NewClient(Options{}).Get(server.URL)
+	resp, allowedErr := NewClient(Options{AllowPrivate: true}).Get(server.URL)
+
+	// Assert
+	if !errors.Is(blockedErr, ErrBlocked) {
+		t.Errorf("Expected ErrBlocked connecting to %s, got %v", server.URL, blockedErr)
+	}
+	if allowedErr != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected 204 with AllowPrivate, got %v", allowedErr)
+	}
+	resp.Body.Close()
+
+	t.Logf("✅ Loopback refused by default: %v", blockedErr)
+}
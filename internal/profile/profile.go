@@ -0,0 +1,256 @@
+// Package profile keeps per-user settings that aren't about notifications
+// The user's time zone decides what "today" means for them: where date-only
+// and "tomorrow" due dates fall, what's overdue or due today, and when their
+// daily digest goes out. Their locale decides how dates and numbers are
+// written in what they read (the digest, CSV exports). AutoArchiveDays tells
+// internal/archive when to move their completed tasks out of the way.
+package profile
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	_ "time/tzdata" // The Alpine image has no zoneinfo; embed it for time zones
+
+	"go-todo-api/internal/domainerrors"
+	"go-todo-api/internal/locale"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned when a user never saved a profile
+var ErrNotFound = errors.New("profile: not found")
+
+// ErrInvalidTimeZone is returned by Save for a name that isn't an IANA zone
+var ErrInvalidTimeZone = domainerrors.New(domainerrors.ErrValidation, "Unknown time zone")
+
+// ErrInvalidLocale is returned by Save for a locale none of the supported
+// ones is close to (see locale.Supported)
+var ErrInvalidLocale = domainerrors.New(domainerrors.ErrValidation, "Unsupported locale")
+
+// Profile is a user's settings
+type Profile struct {
+	UserID          string    `bson:"_id"`
+	TimeZone        string    `bson:"time_zone,omitempty"`         // IANA name, e.g. Europe/Paris ("" = UTC)
+	Locale          string    `bson:"locale,omitempty"`            // BCP 47 tag, e.g. fr-FR ("" = locale.Default)
+	AutoArchiveDays int       `bson:"auto_archive_days,omitempty"` // Archive tasks this long after completion (0 = never)
+	UpdatedAt       time.Time `bson:"updated_at"`
+}
+
+// Location is the user's time zone (UTC when unset or unknown)
+func (p Profile) Location() *time.Location {
+	if loc, err := LoadLocation(p.TimeZone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// Formats is how dates and numbers are written for the user (locale.Default
+// when unset or no longer supported)
+func (p Profile) Formats() locale.Locale {
+	if l, err := locale.Parse(p.Locale); err == nil {
+		return l
+	}
+	return locale.Default
+}
+
+// LoadLocation is time.LoadLocation without "Local", which would be the
+// server's zone rather than anything the user chose
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+	return loc, nil
+}
+
+// Store persists the profiles
+type Store interface {
+	// Get returns a user's profile, or ErrNotFound
+	Get(ctx context.Context, userID string) (*Profile, error)
+
+	// Save adds or replaces a user's profile
+	Save(ctx context.Context, p *Profile) error
+
+	// ListAutoArchive returns the profiles with AutoArchiveDays set
+	ListAutoArchive(ctx context.Context) ([]Profile, error)
+}
+
+// ============================================================================
+// PROFILES
+// ============================================================================
+
+// Profiles reads and updates users' profiles
+type Profiles struct {
+	store Store
+}
+
+// New creates a Profiles on a store
+func New(store Store) *Profiles {
+	return &Profiles{store: store}
+}
+
+// Get returns a user's profile (an empty one, meaning UTC, if they never
+// saved one)
+func (p *Profiles) Get(ctx context.Context, userID string) (Profile, error) {
+	profile, err := p.store.Get(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return Profile{UserID: userID}, nil
+	}
+	if err != nil {
+		return Profile{}, err
+	}
+	return *profile, nil
+}
+
+// Location returns a user's time zone
+func (p *Profiles) Location(ctx context.Context, userID string) (*time.Location, error) {
+	profile, err := p.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return profile.Location(), nil
+}
+
+// AutoArchivers returns the profiles of the users who turned auto-archiving on
+func (p *Profiles) AutoArchivers(ctx context.Context) ([]Profile, error) {
+	return p.store.ListAutoArchive(ctx)
+}
+
+// Save checks and saves a user's profile
+func (p *Profiles) Save(ctx context.Context, profile Profile) (Profile, error) {
+	if profile.TimeZone != "" {
+		if _, err := LoadLocation(profile.TimeZone); err != nil {
+			return Profile{}, err
+		}
+	}
+	if profile.Locale != "" {
+		if _, err := locale.Parse(profile.Locale); err != nil {
+			return Profile{}, ErrInvalidLocale
+		}
+	}
+	profile.UpdatedAt = time.Now().UTC()
+	if err := p.store.Save(ctx, &profile); err != nil {
+		return Profile{}, err
+	}
+	return profile, nil
+}
+
+// ============================================================================
+// MONGODB STORE
+// ============================================================================
+
+// MongoStore keeps profiles in a MongoDB collection, one document per user
+// (keyed by user ID, so it needs no extra index)
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store on the given collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// Get returns a user's profile
+func (s *MongoStore) Get(ctx context.Context, userID string) (*Profile, error) {
+	var p Profile
+	err := s.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Save upserts by user ID
+func (s *MongoStore) Save(ctx context.Context, p *Profile) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": p.UserID}, p, options.Replace().SetUpsert(true))
+	return err
+}
+
+// ListAutoArchive returns the profiles with AutoArchiveDays set
+func (s *MongoStore) ListAutoArchive(ctx context.Context) ([]Profile, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"auto_archive_days": bson.M{"$gt": 0}})
+	if err != nil {
+		return nil, err
+	}
+	var profiles []Profile
+	if err := cursor.All(ctx, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// ============================================================================
+// IN-MEMORY STORE
+// ============================================================================
+
+// MemoryStore keeps profiles in a map - use it in tests
+type MemoryStore struct {
+	mu       sync.Mutex
+	profiles map[string]Profile
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{profiles: make(map[string]Profile)}
+}
+
+// Get returns a user's profile
+func (s *MemoryStore) Get(ctx context.Context, userID string) (*Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.profiles[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &p, nil
+}
+
+// Save adds or replaces a user's profile
+func (s *MemoryStore) Save(ctx context.Context, p *Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles[p.UserID] = *p
+	return nil
+}
+
+// ListAutoArchive returns the profiles with AutoArchiveDays set
+func (s *MemoryStore) ListAutoArchive(ctx context.Context) ([]Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var profiles []Profile
+	for _, p := range s.profiles {
+		if p.AutoArchiveDays > 0 {
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles, nil
+}
+
+// ============================================================================
+// DEFAULT PROFILES
+// ============================================================================
+
+// defaultProfiles is used by the handlers
+// The archive and the digest are created with it too, so it's set before them
+var defaultProfiles *Profiles
+
+// Init sets the default profiles; Init(nil) turns them off (everyone is on UTC)
+func Init(p *Profiles) *Profiles {
+	defaultProfiles = p
+	return p
+}
+
+// Default returns the profiles set by Init (nil before Init)
+func Default() *Profiles {
+	return defaultProfiles
+}
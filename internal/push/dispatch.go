@@ -0,0 +1,302 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"go-todo-api/internal/domainerrors"
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/logger"
+)
+
+// Job types the Dispatcher registers
+const (
+	// JobNotify looks up a user's subscriptions and queues a delivery to each
+	JobNotify = "push.notify"
+	// JobDeliver sends one message to one subscription, retried on its own
+	// so a flaky device doesn't cause duplicates on the others
+	JobDeliver = "push.deliver"
+)
+
+// MaxSubscriptions is how many devices one user can subscribe
+// Every notification is sent to each of them, so without a limit one key
+// could have the workers post to any number of endpoints.
+const MaxSubscriptions = 20
+
+// ErrInvalid is returned by Subscribe for a subscription that can't work
+var ErrInvalid = domainerrors.New(domainerrors.ErrValidation, "Invalid push subscription")
+
+// Sender delivers a message to one subscription
+// It returns ErrGone when the subscription no longer exists.
+type Sender interface {
+	Send(ctx context.Context, sub Subscription, msg Message) error
+}
+
+// Dispatcher manages subscriptions and sends notifications to them
+type Dispatcher struct {
+	store   Store
+	prefs   PreferenceStore
+	webPush *WebPush          // nil when VAPID isn't configured
+	senders map[string]Sender // By subscription type; only the configured ones
+	pool    *jobs.Pool        // Set by Register; nil = the default pool
+}
+
+// NewDispatcher creates a dispatcher; either sender may be nil (not configured)
+// A nil prefs keeps preferences in memory (for tests).
+func NewDispatcher(store Store, prefs PreferenceStore, webPush *WebPush, fcm *FCM) *Dispatcher {
+	if prefs == nil {
+		prefs = NewMemoryPreferenceStore()
+	}
+	d := &Dispatcher{store: store, prefs: prefs, webPush: webPush, senders: map[string]Sender{}}
+	if webPush != nil {
+		d.senders[TypeWebPush] = webPush
+	}
+	if fcm != nil {
+		d.senders[TypeFCM] = fcm
+	}
+	return d
+}
+
+// SetSender replaces the sender for a type of subscription (for tests)
+func (d *Dispatcher) SetSender(typ string, sender Sender) {
+	d.senders[typ] = sender
+}
+
+// Enabled reports whether subscriptions of a type can be sent to
+func (d *Dispatcher) Enabled(typ string) bool {
+	return d.senders[typ] != nil
+}
+
+// VAPIDPublicKey is the key browsers subscribe with ("" without Web Push)
+func (d *Dispatcher) VAPIDPublicKey() string {
+	if d.webPush == nil {
+		return ""
+	}
+	return d.webPush.PublicKey()
+}
+
+// ============================================================================
+// SUBSCRIPTIONS
+// ============================================================================
+
+// Subscribe saves where a user's notifications go
+// A device that subscribes again (same endpoint or token) replaces its
+// previous subscription, even if another user had it. A user with
+// MaxSubscriptions devices gets ErrInvalid for a new one.
+func (d *Dispatcher) Subscribe(ctx context.Context, sub Subscription) (*Subscription, error) {
+	if err := validate(sub); err != nil {
+		return nil, err
+	}
+	if !d.Enabled(sub.Type) {
+		return nil, fmt.Errorf("%w: %s", ErrNotConfigured, sub.Type)
+	}
+
+	sub.ID = subscriptionID(sub.Type, sub.target())
+	existing, err := d.store.List(ctx, sub.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= MaxSubscriptions && !slices.ContainsFunc(existing, func(s Subscription) bool { return s.ID == sub.ID }) {
+		return nil, fmt.Errorf("%w: at most %d devices can be subscribed; delete one first", ErrInvalid, MaxSubscriptions)
+	}
+	sub.CreatedAt = time.Now().UTC()
+	if err := d.store.Save(ctx, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// validate checks a subscription has what its type needs
+func validate(sub Subscription) error {
+	switch sub.Type {
+	case TypeWebPush:
+		u, err := url.Parse(sub.Endpoint)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("%w: endpoint must be an https URL", ErrInvalid)
+		}
+		if key, err := decodeKey(sub.P256dh); err != nil || len(key) != 65 {
+			return fmt.Errorf("%w: keys.p256dh must be a base64url P-256 public key", ErrInvalid)
+		}
+		if auth, err := decodeKey(sub.Auth); err != nil || len(auth) != 16 {
+			return fmt.Errorf("%w: keys.auth must be a base64url 16-byte secret", ErrInvalid)
+		}
+	case TypeFCM:
+		if sub.Token == "" {
+			return fmt.Errorf("%w: token is required for fcm", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalid, sub.Type)
+	}
+	return nil
+}
+
+// Subscriptions lists a user's subscriptions
+func (d *Dispatcher) Subscriptions(ctx context.Context, userID string) ([]Subscription, error) {
+	return d.store.List(ctx, userID)
+}
+
+// Unsubscribe removes one of a user's subscriptions, or returns ErrNotFound
+func (d *Dispatcher) Unsubscribe(ctx context.Context, id, userID string) error {
+	return d.store.Delete(ctx, id, userID)
+}
+
+// ============================================================================
+// PREFERENCES
+// ============================================================================
+
+// Preferences returns a user's notification preferences (the defaults if
+// they never saved any)
+func (d *Dispatcher) Preferences(ctx context.Context, userID string) (Preferences, error) {
+	prefs, err := d.prefs.GetPreferences(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return DefaultPreferences(userID), nil
+	}
+	if err != nil {
+		return Preferences{}, err
+	}
+	return *prefs, nil
+}
+
+// SetPreferences saves a user's notification preferences
+// They apply to notifications not yet sent, including ones already queued.
+func (d *Dispatcher) SetPreferences(ctx context.Context, prefs Preferences) (Preferences, error) {
+	prefs.UpdatedAt = time.Now().UTC()
+	if err := d.prefs.SavePreferences(ctx, &prefs); err != nil {
+		return Preferences{}, err
+	}
+	return prefs, nil
+}
+
+// ============================================================================
+// SENDING
+// ============================================================================
+
+// notifyPayload is the JobNotify payload
+type notifyPayload struct {
+	UserID  string  `json:"user_id"`
+	Message Message `json:"message"`
+}
+
+// deliverPayload is the JobDeliver payload
+type deliverPayload struct {
+	SubscriptionID string  `json:"subscription_id"`
+	Message        Message `json:"message"`
+}
+
+// Notify queues msg for every device of a user and returns at once
+// Features that have something to tell a user (reminders, assignments)
+// call this; the job workers do the sending, if the user's preferences
+// allow push notifications about msg.Event.
+func (d *Dispatcher) Notify(ctx context.Context, userID string, msg Message) (*jobs.Job, error) {
+	return d.enqueue(ctx, JobNotify, notifyPayload{UserID: userID, Message: msg})
+}
+
+// Register adds the push job handlers to a worker pool
+// Call it before pool.Start(), on every process that runs workers.
+func (d *Dispatcher) Register(pool *jobs.Pool) {
+	d.pool = pool
+	pool.Register(JobNotify, d.fanOut, jobs.RetryPolicy{})
+	pool.Register(JobDeliver, d.deliver, jobs.RetryPolicy{Timeout: 30 * time.Second})
+}
+
+// enqueue adds a job to the registered pool, or the default one (Lambda
+// enqueues without running workers)
+func (d *Dispatcher) enqueue(ctx context.Context, jobType string, payload any) (*jobs.Job, error) {
+	if d.pool != nil {
+		return d.pool.Enqueue(ctx, jobType, payload)
+	}
+	return jobs.Enqueue(ctx, jobType, payload)
+}
+
+// fanOut handles JobNotify
+func (d *Dispatcher) fanOut(ctx context.Context, job *jobs.Job) error {
+	var p notifyPayload
+	if err := job.Decode(&p); err != nil {
+		return err
+	}
+	// Checked when sending rather than when queuing, so turning something
+	// off also stops notifications already in the queue
+	prefs, err := d.Preferences(ctx, p.UserID)
+	if err != nil {
+		return err
+	}
+	if !prefs.Allows(ChannelPush, p.Message.Event) {
+		logger.WithTrace(ctx).Debug("Push notification turned off by the user",
+			slog.String("user_id", p.UserID), slog.String("event", p.Message.Event))
+		return nil
+	}
+
+	subs, err := d.store.List(ctx, p.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if !d.Enabled(sub.Type) {
+			// Credentials were removed after the device subscribed
+			logger.WithTrace(ctx).Warn("Skipping push subscription: sender not configured",
+				slog.String("subscription_id", sub.ID), slog.String("type", sub.Type))
+			continue
+		}
+		if _, err := d.enqueue(ctx, JobDeliver, deliverPayload{SubscriptionID: sub.ID, Message: p.Message}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver handles JobDeliver
+func (d *Dispatcher) deliver(ctx context.Context, job *jobs.Job) error {
+	var p deliverPayload
+	if err := job.Decode(&p); err != nil {
+		return err
+	}
+	sub, err := d.store.Get(ctx, p.SubscriptionID)
+	if errors.Is(err, ErrNotFound) {
+		return nil // Unsubscribed since it was queued
+	}
+	if err != nil {
+		return err
+	}
+	sender := d.senders[sub.Type]
+	if sender == nil {
+		return fmt.Errorf("%w: %s", ErrNotConfigured, sub.Type)
+	}
+
+	err = sender.Send(ctx, *sub, p.Message)
+	if errors.Is(err, ErrGone) {
+		// The browser or app dropped it - stop sending there
+		logger.WithTrace(ctx).Info("Push subscription gone, deleting it",
+			slog.String("subscription_id", sub.ID), slog.String("user_id", sub.UserID))
+		if err := d.store.Delete(ctx, sub.ID, ""); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+	return err
+}
+
+// ============================================================================
+// DEFAULT DISPATCHER
+// ============================================================================
+
+// defaultDispatcher is used by the /me/push-subscriptions endpoints
+// initPush sets it even without VAPID or FCM settings, so subscribing
+// says which sender isn't configured rather than answering 503
+var defaultDispatcher *Dispatcher
+
+// Init sets the default dispatcher; Init(nil) turns push off (for tests)
+func Init(d *Dispatcher) *Dispatcher {
+	defaultDispatcher = d
+	return d
+}
+
+// Default returns the dispatcher set by Init (nil before Init)
+func Default() *Dispatcher {
+	return defaultDispatcher
+}
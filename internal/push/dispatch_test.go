@@ -0,0 +1,218 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/jobs"
+	"go-todo-api/internal/logger"
+)
+
+// fakeSender records what it was asked to send and can be told a device is gone
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []string // Subscription IDs
+	gone map[string]bool
+}
+
+func (f *fakeSender) Send(ctx context.Context, sub Subscription, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.gone[sub.ID] {
+		return ErrGone
+	}
+	f.sent = append(f.sent, sub.ID)
+	return nil
+}
+
+func (f *fakeSender) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent)
+}
+
+// browserKeys returns a p256dh key and auth secret like a browser's
+func browserKeys(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()), base64.RawURLEncoding.EncodeToString(auth)
+}
+
+// newTestDispatcher returns a dispatcher with fake senders for both types, running on its own pool
+func newTestDispatcher(t *testing.T) (*Dispatcher, *fakeSender, *MemoryStore) {
+	t.Helper()
+	logger.Init()
+	store := NewMemoryStore()
+	sender := &fakeSender{gone: map[string]bool{}}
+	d := NewDispatcher(store, nil, nil, nil)
+	d.SetSender(TypeWebPush, sender)
+	d.SetSender(TypeFCM, sender)
+
+	pool := jobs.NewPool(jobs.NewMemoryStore(), jobs.Options{Workers: 2, PollInterval: 5 * time.Millisecond})
+	d.Register(pool)
+	pool.Start()
+	t.Cleanup(func() { pool.Shutdown(context.Background()) })
+	return d, sender, store
+}
+
+// TestDispatcher_Subscribe tests validation, and that a device subscribing again replaces itself
+func TestDispatcher_Subscribe(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	d, _, _ := newTestDispatcher(t)
+	p256dh, auth := browserKeys(t)
+	browser := Subscription{UserID: "alice", Type: TypeWebPush, Endpoint: "https://push.example.com/abc", P256dh: p256dh, Auth: auth}
+
+	// Act
+	first, err := d.Subscribe(ctx, browser)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	again, _ := d.Subscribe(ctx, browser)
+	subs, _ := d.Subscriptions(ctx, "alice")
+
+	// Assert
+	if first.ID != again.ID || len(subs) != 1 {
+		t.Errorf("Expected one subscription for the same browser, got %d", len(subs))
+	}
+	invalid := []Subscription{
+		{UserID: "alice", Type: TypeWebPush, Endpoint: "http://push.example.com/abc", P256dh: p256dh, Auth: auth},
+		{UserID: "alice", Type: TypeWebPush, Endpoint: "https://push.example.com/abc", P256dh: "short", Auth: auth},
+		{UserID: "alice", Type: TypeFCM},
+		{UserID: "alice", Type: "sms", Token: "123"},
+	}
+	for _, sub := range invalid {
+		if _, err := d.Subscribe(ctx, sub); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected ErrInvalid for %+v, got %v", sub, err)
+		}
+	}
+	if _, err := NewDispatcher(NewMemoryStore(), nil, nil, nil).Subscribe(ctx, browser); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Expected ErrNotConfigured without VAPID keys, got %v", err)
+	}
+}
+
+// TestDispatcher_SubscribeLimit tests that a user can't subscribe more than MaxSubscriptions devices
+func TestDispatcher_SubscribeLimit(t *testing.T) {
+	// Arrange: alice has as many devices as she can
+	ctx := context.Background()
+	d, _, _ := newTestDispatcher(t)
+	for i := range MaxSubscriptions {
+		if _, err := d.Subscribe(ctx, Subscription{UserID: "alice", Type: TypeFCM, Token: fmt.Sprintf("token-%d", i)}); err != nil {
+			t.Fatalf("Subscribe %d failed: %v", i, err)
+		}
+	}
+
+	// Act
+	_, extraErr := d.Subscribe(ctx, Subscription{UserID: "alice", Type: TypeFCM, Token: "one-too-many"})
+	_, againErr := d.Subscribe(ctx, Subscription{UserID: "alice", Type: TypeFCM, Token: "token-0"})
+	_, bobErr := d.Subscribe(ctx, Subscription{UserID: "bob", Type: TypeFCM, Token: "bobs-token"})
+	subs, _ := d.Subscriptions(ctx, "alice")
+
+	// Assert
+	if !errors.Is(extraErr, ErrInvalid) {
+		t.Errorf("Expected ErrInvalid past %d devices, got %v", MaxSubscriptions, extraErr)
+	}
+	if againErr != nil {
+		t.Errorf("Expected a device subscribing again to be replaced, got %v", againErr)
+	}
+	if bobErr != nil {
+		t.Errorf("Expected other users to be unaffected, got %v", bobErr)
+	}
+	if len(subs) != MaxSubscriptions {
+		t.Errorf("Expected alice to keep %d subscriptions, got %d", MaxSubscriptions, len(subs))
+	}
+}
+
+// TestDispatcher_Notify tests that a notification reaches every device and gone ones are removed
+func TestDispatcher_Notify(t *testing.T) {
+	// Arrange: alice has a browser and a phone; the phone's app was uninstalled
+	ctx := context.Background()
+	d, sender, store := newTestDispatcher(t)
+	p256dh, auth := browserKeys(t)
+	_, _ = d.Subscribe(ctx, Subscription{UserID: "alice", Type: TypeWebPush, Endpoint: "https://push.example.com/abc", P256dh: p256dh, Auth: auth})
+	phone, _ := d.Subscribe(ctx, Subscription{UserID: "alice", Type: TypeFCM, Token: "device-token"})
+	_, _ = d.Subscribe(ctx, Subscription{UserID: "bob", Type: TypeFCM, Token: "bobs-token"})
+	sender.gone[phone.ID] = true
+
+	// Act
+	if _, err := d.Notify(ctx, "alice", Message{Event: EventReminder, Title: "Buy milk", Body: "Due in 1 hour"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	// Assert
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := store.Get(ctx, phone.ID); errors.Is(err, ErrNotFound) && sender.count() == 1 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sender.count() != 1 {
+		t.Errorf("Expected one delivery (alice's browser), got %d", sender.count())
+	}
+	if _, err := store.Get(ctx, phone.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the uninstalled phone's subscription to be deleted, got %v", err)
+	}
+	if subs, _ := store.List(ctx, "bob"); len(subs) != 1 {
+		t.Error("Expected bob's subscription to be left alone")
+	}
+
+	t.Logf("✅ Delivered to %d device, removed 1 gone", sender.count())
+}
+
+// TestDispatcher_Preferences tests that turned-off notifications aren't sent, but test ones are
+func TestDispatcher_Preferences(t *testing.T) {
+	// Arrange: alice doesn't want reminders
+	ctx := context.Background()
+	d, sender, _ := newTestDispatcher(t)
+	_, _ = d.Subscribe(ctx, Subscription{UserID: "alice", Type: TypeFCM, Token: "device-token"})
+	prefs, _ := d.Preferences(ctx, "alice")
+	if !prefs.Allows(ChannelPush, EventReminder) {
+		t.Fatal("Expected everything on by default")
+	}
+	prefs.Events.Reminders = false
+	if _, err := d.SetPreferences(ctx, prefs); err != nil {
+		t.Fatalf("SetPreferences failed: %v", err)
+	}
+
+	// Act
+	reminder, _ := d.Notify(ctx, "alice", Message{Event: EventReminder, Title: "Buy milk"})
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, _ := d.pool.Get(ctx, reminder.ID); job.Status == jobs.StatusSucceeded {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	remindersSent := sender.count()
+	_, _ = d.Notify(ctx, "alice", Message{Event: EventTest, Title: "Test"})
+	for time.Now().Before(deadline) && sender.count() == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Assert
+	if remindersSent != 0 {
+		t.Errorf("Expected no reminder to be sent, got %d", remindersSent)
+	}
+	if sender.count() != 1 {
+		t.Errorf("Expected the test notification to be sent, got %d", sender.count())
+	}
+	saved, _ := d.Preferences(ctx, "alice")
+	if saved.Events.Reminders || !saved.Events.Assignments || saved.UpdatedAt.IsZero() {
+		t.Errorf("Expected saved preferences with only reminders off, got %+v", saved)
+	}
+
+	t.Log("✅ Reminder skipped, test notification sent")
+}
@@ -0,0 +1,221 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/outbound"
+)
+
+// ============================================================================
+// FIREBASE CLOUD MESSAGING
+// ============================================================================
+// Mobile apps get a device token from FCM. Sending is a POST to FCM's HTTP
+// v1 API, authorized with a short-lived OAuth token that we get by signing
+// a JWT with the Firebase project's service account key.
+
+// fcmScope is the OAuth scope for sending messages
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// fcmBaseURL is FCM's HTTP v1 API
+const fcmBaseURL = "https://fcm.googleapis.com/v1"
+
+// serviceAccount is the part of a service account key file we need
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCM sends to Firebase device tokens
+type FCM struct {
+	account serviceAccount
+	key     *rsa.PrivateKey
+	client  *http.Client
+	baseURL string // fcmBaseURL, or a test server
+
+	mu      sync.Mutex
+	token   string    // Cached OAuth access token
+	expires time.Time // When to get a new one
+}
+
+// FCMFromEnv reads the service account key file named by FCM_CREDENTIALS_FILE
+// (Firebase console → Project settings → Service accounts → Generate new
+// private key). It returns nil (FCM off) when the variable isn't set.
+func FCMFromEnv(client *http.Client) (*FCM, error) {
+	path := strings.TrimSpace(os.Getenv("FCM_CREDENTIALS_FILE"))
+	if path == "" {
+		return nil, nil
+	}
+	credentials, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("push: read FCM_CREDENTIALS_FILE: %w", err)
+	}
+	return NewFCM(credentials, client)
+}
+
+// NewFCM creates a sender from a service account key file's contents
+func NewFCM(credentials []byte, client *http.Client) (*FCM, error) {
+	var account serviceAccount
+	if err := json.Unmarshal(credentials, &account); err != nil {
+		return nil, fmt.Errorf("push: invalid FCM credentials: %w", err)
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" || account.TokenURI == "" {
+		return nil, errors.New("push: FCM credentials need project_id, client_email and token_uri")
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("push: FCM credentials have no PEM private_key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("push: invalid FCM private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("push: FCM private key is not an RSA key")
+	}
+	if client == nil {
+		client = outbound.Client()
+	}
+
+	return &FCM{account: account, key: key, client: client, baseURL: fcmBaseURL}, nil
+}
+
+// Send posts msg to the device
+func (f *FCM) Send(ctx context.Context, sub Subscription, msg Message) error {
+	token, err := f.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	// notification is shown by the OS; data is for the app (all strings)
+	body, err := json.Marshal(map[string]any{
+		"message": map[string]any{
+			"token":        sub.Token,
+			"notification": map[string]string{"title": msg.Title, "body": msg.Body},
+			"data":         map[string]string{"event": msg.Event, "url": msg.URL},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := f.baseURL + "/projects/" + url.PathEscape(f.account.ProjectID) + "/messages:send"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	// An uninstalled app's token comes back as 404 UNREGISTERED
+	var problem struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&problem)
+	if resp.StatusCode == http.StatusNotFound || problem.Error.Status == "UNREGISTERED" {
+		return ErrGone
+	}
+	return fmt.Errorf("push: FCM returned %s: %s", resp.Status, problem.Error.Message)
+}
+
+// accessToken returns a cached OAuth token, getting a new one shortly
+// before the old one expires (they last an hour)
+func (f *FCM) accessToken(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.token != "" && time.Now().Before(f.expires) {
+		return f.token, nil
+	}
+
+	assertion, err := f.assertion()
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("push: FCM token request returned %s", resp.Status)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("push: decode FCM token: %w", err)
+	}
+	f.token = result.AccessToken
+	f.expires = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return f.token, nil
+}
+
+// assertion is the JWT (RS256) exchanged for an access token
+func (f *FCM) assertion() (string, error) {
+	now := time.Now()
+	claims, err := json.Marshal(map[string]any{
+		"iss":   f.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   f.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) +
+		"." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,214 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Channels a user can be notified on
+// Only ChannelPush is sent today; the others are stored so a user's choice is
+// already there when email and Slack delivery are added.
+const (
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+	ChannelSlack = "slack"
+)
+
+// EventMention is a notification that someone mentioned the user
+const EventMention = "mention"
+
+// EventDigest is the daily digest email (see internal/digest)
+const EventDigest = "digest"
+
+// Preferences are which channels a user wants notifications on, and about what
+// A user who never saved any gets DefaultPreferences (everything on except
+// the daily digest, which needs an email address).
+type Preferences struct {
+	UserID    string       `bson:"_id"`
+	Channels  ChannelPrefs `bson:"channels"`
+	Events    EventPrefs   `bson:"events"`
+	Email     string       `bson:"email,omitempty"` // Where email notifications go
+	UpdatedAt time.Time    `bson:"updated_at"`
+}
+
+// ChannelPrefs turn each channel on or off
+type ChannelPrefs struct {
+	Email bool `bson:"email"`
+	Push  bool `bson:"push"`
+	Slack bool `bson:"slack"`
+}
+
+// EventPrefs turn each kind of notification on or off
+type EventPrefs struct {
+	Reminders   bool `bson:"reminders"`
+	Assignments bool `bson:"assignments"`
+	Mentions    bool `bson:"mentions"`
+	DailyDigest bool `bson:"daily_digest"` // Opt-in
+}
+
+// DefaultPreferences are what a user gets until they change them
+func DefaultPreferences(userID string) Preferences {
+	return Preferences{
+		UserID:   userID,
+		Channels: ChannelPrefs{Email: true, Push: true, Slack: true},
+		Events:   EventPrefs{Reminders: true, Assignments: true, Mentions: true},
+	}
+}
+
+// Allows reports whether the user wants a notification about event on channel
+// Test notifications are always allowed on a channel that's on - the user
+// asked for them.
+func (p Preferences) Allows(channel, event string) bool {
+	var on bool
+	switch channel {
+	case ChannelEmail:
+		on = p.Channels.Email
+	case ChannelPush:
+		on = p.Channels.Push
+	case ChannelSlack:
+		on = p.Channels.Slack
+	}
+	if !on {
+		return false
+	}
+
+	switch event {
+	case EventReminder:
+		return p.Events.Reminders
+	case EventAssignment:
+		return p.Events.Assignments
+	case EventMention:
+		return p.Events.Mentions
+	case EventDigest:
+		return p.Events.DailyDigest
+	case EventTest:
+		return true
+	}
+	return false
+}
+
+// PreferenceStore persists users' notification preferences
+type PreferenceStore interface {
+	// GetPreferences returns a user's saved preferences, or ErrNotFound
+	GetPreferences(ctx context.Context, userID string) (*Preferences, error)
+
+	// SavePreferences adds or replaces a user's preferences
+	SavePreferences(ctx context.Context, prefs *Preferences) error
+
+	// DigestRecipients returns the preferences of every user who wants the
+	// daily digest by email and gave an address
+	DigestRecipients(ctx context.Context) ([]Preferences, error)
+}
+
+// wantsDigest reports whether a user should get the daily digest
+func wantsDigest(p Preferences) bool {
+	return p.Allows(ChannelEmail, EventDigest) && p.Email != ""
+}
+
+// ============================================================================
+// MONGODB STORE
+// ============================================================================
+
+// MongoPreferenceStore keeps preferences in a MongoDB collection, one
+// document per user (keyed by user ID, so it needs no extra index)
+type MongoPreferenceStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoPreferenceStore creates a store on the given collection
+func NewMongoPreferenceStore(collection *mongo.Collection) *MongoPreferenceStore {
+	return &MongoPreferenceStore{collection: collection}
+}
+
+// GetPreferences returns a user's preferences
+func (s *MongoPreferenceStore) GetPreferences(ctx context.Context, userID string) (*Preferences, error) {
+	var prefs Preferences
+	err := s.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&prefs)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// SavePreferences upserts by user ID
+func (s *MongoPreferenceStore) SavePreferences(ctx context.Context, prefs *Preferences) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": prefs.UserID}, prefs, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DigestRecipients finds the users who opted in to the daily digest
+// It scans the collection (one small document per user who changed their
+// settings), once an hour.
+func (s *MongoPreferenceStore) DigestRecipients(ctx context.Context) ([]Preferences, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{
+		"channels.email":      true,
+		"events.daily_digest": true,
+		"email":               bson.M{"$gt": ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	recipients := []Preferences{}
+	if err := cursor.All(ctx, &recipients); err != nil {
+		return nil, err
+	}
+	return recipients, nil
+}
+
+// ============================================================================
+// IN-MEMORY STORE
+// ============================================================================
+
+// MemoryPreferenceStore keeps preferences in a map - use it in tests
+type MemoryPreferenceStore struct {
+	mu    sync.Mutex
+	prefs map[string]Preferences
+}
+
+// NewMemoryPreferenceStore creates an empty in-memory store
+func NewMemoryPreferenceStore() *MemoryPreferenceStore {
+	return &MemoryPreferenceStore{prefs: make(map[string]Preferences)}
+}
+
+// GetPreferences returns a user's preferences
+func (s *MemoryPreferenceStore) GetPreferences(ctx context.Context, userID string) (*Preferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs, ok := s.prefs[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &prefs, nil
+}
+
+// SavePreferences adds or replaces a user's preferences
+func (s *MemoryPreferenceStore) SavePreferences(ctx context.Context, prefs *Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prefs[prefs.UserID] = *prefs
+	return nil
+}
+
+// DigestRecipients returns the users who opted in to the daily digest
+func (s *MemoryPreferenceStore) DigestRecipients(ctx context.Context) ([]Preferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recipients := []Preferences{}
+	for _, prefs := range s.prefs {
+		if wantsDigest(prefs) {
+			recipients = append(recipients, prefs)
+		}
+	}
+	return recipients, nil
+}
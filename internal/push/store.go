@@ -0,0 +1,163 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store persists the subscriptions
+// MongoStore is used in production; MemoryStore in tests
+type Store interface {
+	// Save adds a subscription, or replaces the one for the same device
+	Save(ctx context.Context, sub *Subscription) error
+
+	// Get returns a subscription by ID, or ErrNotFound
+	Get(ctx context.Context, id string) (*Subscription, error)
+
+	// List returns a user's subscriptions, oldest first
+	List(ctx context.Context, userID string) ([]Subscription, error)
+
+	// Delete removes a subscription, or returns ErrNotFound
+	// With a userID, only that user's subscription is removed.
+	Delete(ctx context.Context, id, userID string) error
+}
+
+// ============================================================================
+// MONGODB STORE
+// ============================================================================
+
+// MongoStore keeps subscriptions in a MongoDB collection
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store on the given collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// EnsureIndexes creates the index for listing a user's subscriptions
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	return err
+}
+
+// Save upserts by ID (which is derived from the device)
+func (s *MongoStore) Save(ctx context.Context, sub *Subscription) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": sub.ID}, sub, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Get returns a subscription by ID
+func (s *MongoStore) Get(ctx context.Context, id string) (*Subscription, error) {
+	var sub Subscription
+	err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&sub)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// List returns a user's subscriptions, oldest first
+func (s *MongoStore) List(ctx context.Context, userID string) ([]Subscription, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	subs := []Subscription{}
+	if err := cursor.All(ctx, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// Delete removes a subscription (only the user's own, with a userID)
+func (s *MongoStore) Delete(ctx context.Context, id, userID string) error {
+	filter := bson.M{"_id": id}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+	result, err := s.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ============================================================================
+// IN-MEMORY STORE
+// ============================================================================
+
+// MemoryStore keeps subscriptions in a map
+// Subscriptions are lost on restart and not shared between processes - use it in tests
+type MemoryStore struct {
+	mu   sync.Mutex
+	subs map[string]Subscription
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{subs: make(map[string]Subscription)}
+}
+
+// Save adds or replaces a subscription
+func (s *MemoryStore) Save(ctx context.Context, sub *Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs[sub.ID] = *sub
+	return nil
+}
+
+// Get returns a subscription by ID
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &sub, nil
+}
+
+// List returns a user's subscriptions, oldest first
+func (s *MemoryStore) List(ctx context.Context, userID string) ([]Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subs := []Subscription{}
+	for _, sub := range s.subs {
+		if sub.UserID == userID {
+			subs = append(subs, sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].CreatedAt.Before(subs[j].CreatedAt) })
+	return subs, nil
+}
+
+// Delete removes a subscription (only the user's own, with a userID)
+func (s *MemoryStore) Delete(ctx context.Context, id, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[id]
+	if !ok || (userID != "" && sub.UserID != userID) {
+		return ErrNotFound
+	}
+	delete(s.subs, id)
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package push sends notifications to users' browsers and phones
+// A client registers where it can be reached - a Web Push subscription from
+// a browser's PushManager, or a Firebase Cloud Messaging (FCM) device token
+// from a mobile app - and the Dispatcher delivers notifications there from
+// the background job workers, so a slow or unreachable push service never
+// holds up a request.
+package push
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go-todo-api/internal/domainerrors"
+)
+
+// Types of subscription
+const (
+	// TypeWebPush is a browser subscription (RFC 8030), sent with VAPID
+	TypeWebPush = "webpush"
+	// TypeFCM is a Firebase Cloud Messaging device token (Android, iOS)
+	TypeFCM = "fcm"
+)
+
+// Events a notification can be about
+const (
+	EventReminder   = "reminder"   // A task is due soon
+	EventAssignment = "assignment" // A task was assigned to the user
+	EventTest       = "test"       // Sent on request, to check a device works
+)
+
+var (
+	// ErrNotFound is returned when no subscription has the given ID
+	ErrNotFound = domainerrors.New(domainerrors.ErrNotFound, "Push subscription not found")
+
+	// ErrGone is returned by a Sender when the push service says the
+	// subscription no longer exists (the user unsubscribed or uninstalled);
+	// the Dispatcher deletes it
+	ErrGone = errors.New("push: subscription expired or unsubscribed")
+
+	// ErrNotConfigured is returned when there are no credentials for a type
+	// of subscription (VAPID_* for Web Push, FCM_CREDENTIALS_FILE for FCM)
+	ErrNotConfigured = domainerrors.New(domainerrors.ErrValidation, "Push sender not configured")
+)
+
+// Subscription is a place a user's notifications are delivered to
+type Subscription struct {
+	ID     string `bson:"_id"`
+	UserID string `bson:"user_id"`
+	Type   string `bson:"type"` // TypeWebPush or TypeFCM
+
+	// Web Push: the push service URL, and the browser's keys for encrypting
+	// the payload (base64url, as PushSubscription.toJSON() gives them)
+	Endpoint string `bson:"endpoint,omitempty"`
+	P256dh   string `bson:"p256dh,omitempty"`
+	Auth     string `bson:"auth,omitempty"`
+
+	// FCM: the device registration token
+	Token string `bson:"token,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// target is what identifies the device to its push service
+func (s Subscription) target() string {
+	if s.Type == TypeFCM {
+		return s.Token
+	}
+	return s.Endpoint
+}
+
+// subscriptionID derives the ID from the device, so a browser that
+// subscribes again replaces its old subscription instead of adding a second
+func subscriptionID(typ, target string) string {
+	sum := sha256.Sum256([]byte(typ + ":" + target))
+	return hex.EncodeToString(sum[:12])
+}
+
+// Message is what a device shows
+type Message struct {
+	Event string `json:"event"`         // EventReminder, EventAssignment, ...
+	Title string `json:"title"`         // First line of the notification
+	Body  string `json:"body"`          // The text under it
+	URL   string `json:"url,omitempty"` // Opened when the notification is tapped
+}
@@ -0,0 +1,265 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-todo-api/internal/outbound"
+)
+
+// ============================================================================
+// WEB PUSH
+// ============================================================================
+// A browser subscription is a URL at the browser vendor's push service plus
+// two keys. Sending is a POST to that URL with:
+//   - the payload encrypted for the browser (RFC 8291, "aes128gcm"), so the
+//     push service can't read it
+//   - a VAPID token (RFC 8292) signed with our key, so the push service
+//     knows the message comes from the server the browser subscribed to
+// Both only need the standard library.
+
+// webPushTTL is how long the push service keeps a message for a device
+// that's offline. Reminders are stale after a day.
+const webPushTTL = 24 * time.Hour
+
+// recordSize is the aes128gcm record size; a notification is one record
+const recordSize = 4096
+
+// WebPush sends to browser subscriptions
+type WebPush struct {
+	key       *ecdsa.PrivateKey
+	publicKey string // Uncompressed P-256 point, base64url: what browsers subscribe with
+	subject   string // mailto: or https: contact for the push service operator
+	client    *http.Client
+}
+
+// WebPushFromEnv reads the VAPID key pair:
+//
+//	VAPID_PRIVATE_KEY=<base64url private key>   (e.g. from npx web-push generate-vapid-keys)
+//	VAPID_SUBJECT=mailto:ops@example.com
+//
+// It returns nil (Web Push off) when VAPID_PRIVATE_KEY isn't set. The public
+// key is derived from the private one.
+func WebPushFromEnv(client *http.Client) (*WebPush, error) {
+	privateKey := strings.TrimSpace(os.Getenv("VAPID_PRIVATE_KEY"))
+	if privateKey == "" {
+		return nil, nil
+	}
+	return NewWebPush(privateKey, strings.TrimSpace(os.Getenv("VAPID_SUBJECT")), client)
+}
+
+// NewWebPush creates a sender from a base64url VAPID private key
+func NewWebPush(privateKey, subject string, client *http.Client) (*WebPush, error) {
+	d, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(privateKey, "="))
+	if err != nil {
+		return nil, fmt.Errorf("push: invalid VAPID private key: %w", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("push: invalid VAPID private key: %w", err)
+	}
+	if !strings.HasPrefix(subject, "mailto:") && !strings.HasPrefix(subject, "https:") {
+		return nil, fmt.Errorf("push: VAPID subject %q must be a mailto: or https: URL", subject)
+	}
+	if client == nil {
+		client = outbound.Client()
+	}
+
+	// The same key signs (ECDSA) what browsers see as an ECDH public key
+	public := ecdhKey.PublicKey().Bytes() // 0x04 || X || Y
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}
+
+	return &WebPush{
+		key:       key,
+		publicKey: base64.RawURLEncoding.EncodeToString(public),
+		subject:   subject,
+		client:    client,
+	}, nil
+}
+
+// PublicKey is the applicationServerKey browsers subscribe with
+func (w *WebPush) PublicKey() string {
+	return w.publicKey
+}
+
+// Send encrypts msg for the subscription and posts it to its push service
+func (w *WebPush) Send(ctx context.Context, sub Subscription, msg Message) error {
+	uaPublic, err := decodeKey(sub.P256dh)
+	if err != nil {
+		return fmt.Errorf("push: invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeKey(sub.Auth)
+	if err != nil {
+		return fmt.Errorf("push: invalid auth secret: %w", err)
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	body, err := encrypt(payload, uaPublic, authSecret, nil, nil)
+	if err != nil {
+		return err
+	}
+	token, err := w.vapidToken(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(webPushTTL.Seconds())))
+	req.Header.Set("Authorization", "vapid t="+token+", k="+w.publicKey)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrGone
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("push: push service returned %s", resp.Status)
+	}
+	return nil
+}
+
+// vapidToken is a JWT (ES256) for the push service at endpoint, valid 12h
+func (w *WebPush) vapidToken(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("push: invalid endpoint %q", endpoint)
+	}
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": w.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`)) +
+		"." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, w.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	// ES256 signatures are r and s as two 32-byte big-endian numbers
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// encrypt encrypts plaintext for a browser (RFC 8291) as one aes128gcm record:
+//
+//	salt (16) | record size (4) | key length (1) | our public key (65) | ciphertext
+//
+// asPrivate and salt are random when nil; tests pass the RFC's example values.
+func encrypt(plaintext, uaPublic, authSecret []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	ua, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("push: invalid p256dh key: %w", err)
+	}
+	if asPrivate == nil {
+		// A new key pair for every message
+		if asPrivate, err = ecdh.P256().GenerateKey(rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+	if salt == nil {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	// Combine the shared secret with the browser's auth secret...
+	ecdhSecret, err := asPrivate.ECDH(ua)
+	if err != nil {
+		return nil, err
+	}
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+
+	// ...then derive this message's key and nonce (RFC 8188)
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	// 0x02 marks the last (and only) record
+	record := append(append([]byte{}, plaintext...), 0x02)
+	if len(record)+gcm.Overhead() > recordSize {
+		return nil, fmt.Errorf("push: payload too large (%d bytes)", len(plaintext))
+	}
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// decodeKey decodes a browser key, with or without base64 padding
+func decodeKey(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,96 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdh"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestEncrypt_RFC8291Example tests the payload encryption against the worked example in RFC 8291 Appendix A
+func TestEncrypt_RFC8291Example(t *testing.T) {
+	// Arrange
+	b64 := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("Bad test vector %q: %v", s, err)
+		}
+		return b
+	}
+	asPrivate, err := ecdh.P256().NewPrivateKey(b64("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatalf("Bad application server key: %v", err)
+	}
+	uaPublic := b64("BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4")
+	authSecret := b64("BTBZMqHH6r4Tts7J_aSIgg")
+	salt := b64("DGv6ra1nlYgDCS1FRnbzlw")
+
+	// Act
+	got, err := encrypt([]byte("When I grow up, I want to be a watermelon"), uaPublic, authSecret, asPrivate, salt)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("encrypt returned error: %v", err)
+	}
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if base64.RawURLEncoding.EncodeToString(got) != want {
+		t.Errorf("Expected the RFC's ciphertext\n got %s\nwant %s", base64.RawURLEncoding.EncodeToString(got), want)
+	}
+
+	t.Logf("✅ %d-byte message matches RFC 8291", len(got))
+}
+
+// TestWebPush_Send tests the request a push service receives, and that 410 Gone means ErrGone
+func TestWebPush_Send(t *testing.T) {
+	// Arrange: a push service that accepts one subscription and has forgotten another
+	var got *http.Request
+	var body []byte
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	// The RFC 8291 application server key doubles as a VAPID key
+	w, err := NewWebPush("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw", "mailto:ops@example.com", server.Client())
+	if err != nil {
+		t.Fatalf("NewWebPush failed: %v", err)
+	}
+	p256dh, auth := browserKeys(t)
+	sub := Subscription{Type: TypeWebPush, Endpoint: server.URL + "/ok", P256dh: p256dh, Auth: auth}
+
+	// Act
+	err = w.Send(context.Background(), sub, Message{Event: EventTest, Title: "Hi"})
+	sub.Endpoint = server.URL + "/gone"
+	goneErr := w.Send(context.Background(), sub, Message{Event: EventTest, Title: "Hi"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if w.PublicKey() != "BP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A8" {
+		t.Errorf("Expected the public key derived from the private key, got %s", w.PublicKey())
+	}
+	if got.Header.Get("Content-Encoding") != "aes128gcm" || got.Header.Get("TTL") == "" {
+		t.Errorf("Expected aes128gcm with a TTL, got headers %v", got.Header)
+	}
+	if auth := got.Header.Get("Authorization"); !strings.HasPrefix(auth, "vapid t=") || !strings.HasSuffix(auth, ", k="+w.PublicKey()) {
+		t.Errorf("Expected a VAPID Authorization header, got %q", auth)
+	}
+	if len(body) < 16+4+1+65+16 {
+		t.Errorf("Expected an encrypted record, got %d bytes", len(body))
+	}
+	if !errors.Is(goneErr, ErrGone) {
+		t.Errorf("Expected ErrGone for 410, got %v", goneErr)
+	}
+}
@@ -0,0 +1,330 @@
+// Package recording keeps full request/response pairs of chosen callers, to
+// reproduce problems a client reports but we can't see in the logs
+// An admin starts recording an API key's requests, or a request ID the
+// client agreed to send (X-Request-ID), for a few minutes
+// (POST /admin/recordings/targets); middleware.Record saves every matching
+// exchange, and GET /admin/recordings shows them.
+//
+// Secrets in headers (API keys, tokens, cookies) are never saved, nor are
+// the bodies of the endpoints that hand out secrets; other bodies are cut at
+// MaxBody. In MongoDB the recordings go to a capped collection,
+// so the oldest are dropped on their own.
+package recording
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/clock"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxBody is how much of a request or response body is kept
+const MaxBody = 64 << 10
+
+// CollectionSize is the size of the capped MongoDB collection
+const CollectionSize = 64 << 20
+
+// memoryLimit is how many recordings MemoryStore keeps
+const memoryLimit = 500
+
+// ErrNoTarget is returned by Start for a target that names no caller
+var ErrNoTarget = errors.New("recording: a target needs an API key ID or a request ID")
+
+// Target says whose requests to record, until when
+// Exactly one of APIKeyID and RequestID is set.
+type Target struct {
+	ID        string
+	APIKeyID  string // A managed API key (see internal/apikeys)
+	RequestID string // An X-Request-ID the client will send
+	Until     time.Time
+	CreatedBy string // User ID of the admin who started it
+}
+
+// Recording is one request and the response it got
+type Recording struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	TargetID        string             `bson:"target_id"`
+	At              time.Time          `bson:"at"`
+	RequestID       string             `bson:"request_id"`
+	UserID          string             `bson:"user_id,omitempty"`
+	APIKeyID        string             `bson:"api_key_id,omitempty"`
+	Method          string             `bson:"method"`
+	URL             string             `bson:"url"` // Path and query
+	RequestHeaders  http.Header        `bson:"request_headers"`
+	RequestBody     string             `bson:"request_body,omitempty"`
+	Status          int                `bson:"status"`
+	ResponseHeaders http.Header        `bson:"response_headers"`
+	ResponseBody    string             `bson:"response_body,omitempty"`
+	Truncated       bool               `bson:"truncated,omitempty"` // A body was longer than MaxBody
+	Duration        time.Duration      `bson:"duration"`
+}
+
+// secretHeaders are replaced by "[redacted]" in recordings
+var secretHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "Set-Cookie", "X-Api-Key"}
+
+// Redact returns a copy of h without the secrets
+func Redact(h http.Header) http.Header {
+	out := h.Clone()
+	for name := range out {
+		if slices.Contains(secretHeaders, http.CanonicalHeaderKey(name)) {
+			out[name] = []string{"[redacted]"}
+		}
+	}
+	return out
+}
+
+// secretPaths start the paths whose bodies are replaced by "[redacted]":
+// creating an API key answers with the key itself
+var secretPaths = []string{"/admin/apikeys"}
+
+// RedactBody returns body, or "[redacted]" for a request to path that can
+// carry a secret
+func RedactBody(path, body string) string {
+	for _, prefix := range secretPaths {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return "[redacted]"
+		}
+	}
+	return body
+}
+
+// Store persists the recordings
+type Store interface {
+	// Save adds a recording and sets its ID
+	Save(ctx context.Context, r *Recording) error
+
+	// List returns the most recent recordings, newest first (only the
+	// target's when targetID isn't empty)
+	List(ctx context.Context, targetID string, limit int) ([]Recording, error)
+}
+
+// ============================================================================
+// RECORDER
+// ============================================================================
+
+// Recorder holds the targets and saves their exchanges
+// The targets live in memory: like the log level, they're set on one
+// instance and only record the requests that instance serves.
+type Recorder struct {
+	store Store
+	clock clock.Clock
+
+	mu      sync.Mutex
+	targets map[string]Target
+}
+
+// New creates a recorder saving to store (a nil clock is the real one)
+func New(store Store, c clock.Clock) *Recorder {
+	return &Recorder{store: store, clock: clock.OrReal(c), targets: make(map[string]Target)}
+}
+
+// Start records the target's requests for d, and returns it with its ID
+func (r *Recorder) Start(t Target, d time.Duration) (Target, error) {
+	if (t.APIKeyID == "") == (t.RequestID == "") {
+		return Target{}, ErrNoTarget
+	}
+	t.ID = newID()
+	t.Until = r.clock.Now().Add(d).UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.targets[t.ID] = t
+	return t, nil
+}
+
+// Stop stops a target; false if there was none with that ID
+func (r *Recorder) Stop(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.targets[id]
+	delete(r.targets, id)
+	return ok
+}
+
+// Targets returns the active targets, soonest to end first
+func (r *Recorder) Targets() []Target {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropExpired()
+
+	out := make([]Target, 0, len(r.targets))
+	for _, t := range r.targets {
+		out = append(out, t)
+	}
+	slices.SortFunc(out, func(a, b Target) int { return a.Until.Compare(b.Until) })
+	return out
+}
+
+// Match returns the active target a request with this API key and request
+// ID belongs to, if any
+func (r *Recorder) Match(apiKeyID, requestID string) (Target, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.targets) == 0 {
+		return Target{}, false
+	}
+	r.dropExpired()
+
+	for _, t := range r.targets {
+		if (t.APIKeyID != "" && t.APIKeyID == apiKeyID) || (t.RequestID != "" && t.RequestID == requestID) {
+			return t, true
+		}
+	}
+	return Target{}, false
+}
+
+// Save stores a recording
+func (r *Recorder) Save(ctx context.Context, rec *Recording) error {
+	return r.store.Save(ctx, rec)
+}
+
+// List returns the most recent recordings (of one target, if targetID is set)
+func (r *Recorder) List(ctx context.Context, targetID string, limit int) ([]Recording, error) {
+	return r.store.List(ctx, targetID, limit)
+}
+
+// dropExpired forgets the targets whose time is up; call it with mu held
+func (r *Recorder) dropExpired() {
+	now := r.clock.Now()
+	for id, t := range r.targets {
+		if !now.Before(t.Until) {
+			delete(r.targets, id)
+		}
+	}
+}
+
+// newID returns 8 random bytes as a hex string
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ============================================================================
+// MONGODB STORE
+// ============================================================================
+
+// MongoStore keeps recordings in a capped MongoDB collection
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store on the given collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// EnsureCollection creates the collection, capped at CollectionSize bytes
+// It does nothing if the collection already exists.
+func (s *MongoStore) EnsureCollection(ctx context.Context) error {
+	db := s.collection.Database()
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": s.collection.Name()})
+	if err != nil || len(names) > 0 {
+		return err
+	}
+	return db.CreateCollection(ctx, s.collection.Name(),
+		options.CreateCollection().SetCapped(true).SetSizeInBytes(CollectionSize))
+}
+
+// Save inserts a recording
+func (s *MongoStore) Save(ctx context.Context, r *Recording) error {
+	result, err := s.collection.InsertOne(ctx, r)
+	if err != nil {
+		return err
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		r.ID = id
+	}
+	return nil
+}
+
+// List returns the most recent recordings, newest first
+// A capped collection keeps insertion order, so $natural is "by time".
+func (s *MongoStore) List(ctx context.Context, targetID string, limit int) ([]Recording, error) {
+	filter := bson.M{}
+	if targetID != "" {
+		filter["target_id"] = targetID
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "$natural", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var recordings []Recording
+	if err := cursor.All(ctx, &recordings); err != nil {
+		return nil, err
+	}
+	return recordings, nil
+}
+
+// ============================================================================
+// IN-MEMORY STORE
+// ============================================================================
+
+// MemoryStore keeps the last few hundred recordings in memory - use it in tests
+type MemoryStore struct {
+	mu         sync.Mutex
+	recordings []Recording
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Save adds a recording, dropping the oldest past the limit
+func (s *MemoryStore) Save(ctx context.Context, r *Recording) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.ID = primitive.NewObjectID()
+	s.recordings = append(s.recordings, *r)
+	if len(s.recordings) > memoryLimit {
+		s.recordings = s.recordings[len(s.recordings)-memoryLimit:]
+	}
+	return nil
+}
+
+// List returns the most recent recordings, newest first
+func (s *MemoryStore) List(ctx context.Context, targetID string, limit int) ([]Recording, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Recording
+	for i := len(s.recordings) - 1; i >= 0 && len(out) < limit; i-- {
+		if targetID == "" || s.recordings[i].TargetID == targetID {
+			out = append(out, s.recordings[i])
+		}
+	}
+	return out, nil
+}
+
+// ============================================================================
+// DEFAULT RECORDER
+// ============================================================================
+
+// defaultRecorder is used by middleware.Record and the admin endpoints
+// Setting it doesn't record anything: that starts when an admin turns
+// recording on for a caller
+var defaultRecorder *Recorder
+
+// Init sets the default recorder; Init(nil) turns recording off
+func Init(r *Recorder) *Recorder {
+	defaultRecorder = r
+	return r
+}
+
+// Default returns the recorder set by Init (nil when recording is off)
+func Default() *Recorder {
+	return defaultRecorder
+}
@@ -0,0 +1,42 @@
+package recording
+
+import (
+	"testing"
+	"time"
+
+	"go-todo-api/internal/clock"
+)
+
+// TestRecorder_Targets tests matching by API key or request ID, and that
+// targets stop on their own
+func TestRecorder_Targets(t *testing.T) {
+	// Arrange
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	r := New(NewMemoryStore(), fake)
+	byKey, _ := r.Start(Target{APIKeyID: "key-1"}, 15*time.Minute)
+	_, _ = r.Start(Target{RequestID: "ticket-42"}, time.Hour)
+
+	// Act + Assert
+	if _, err := r.Start(Target{APIKeyID: "key-2", RequestID: "both"}, time.Minute); err != ErrNoTarget {
+		t.Errorf("Expected ErrNoTarget naming two callers, got %v", err)
+	}
+	if got, ok := r.Match("key-1", "anything"); !ok || got.ID != byKey.ID {
+		t.Errorf("Expected key-1 to match its target, got %+v %v", got, ok)
+	}
+	if _, ok := r.Match("", "ticket-42"); !ok {
+		t.Error("Expected the request ID to match")
+	}
+	if _, ok := r.Match("key-2", "other"); ok {
+		t.Error("Expected no match for another caller")
+	}
+
+	fake.Advance(15 * time.Minute)
+	if _, ok := r.Match("key-1", ""); ok {
+		t.Error("Expected key-1's target to have ended")
+	}
+	if targets := r.Targets(); len(targets) != 1 || targets[0].RequestID != "ticket-42" {
+		t.Errorf("Expected only the request ID target left, got %+v", targets)
+	}
+
+	t.Log("✅ Recording targets matched and expired")
+}
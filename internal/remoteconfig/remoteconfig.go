@@ -0,0 +1,270 @@
+// Package remoteconfig loads secrets and feature flags from AWS at runtime
+// instead of baking them into the Lambda's environment variables:
+//
+//	SSM Parameter Store → MONGO_URI, API_KEY, ... (copied into the environment)
+//	AWS AppConfig       → feature flags (Flag("new-ui"))
+//
+// It talks to the AWS Parameters and Secrets Lambda extension (localhost:2773)
+// and the AppConfig Lambda extension (localhost:2772) over plain HTTP, so the
+// binary doesn't need the AWS SDK. Both extensions are Lambda layers that
+// cache values and handle IAM signing for us.
+package remoteconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/logger"
+)
+
+// Options says what to load and from where
+type Options struct {
+	// Parameters maps environment variables to SSM parameter names,
+	// e.g. {"MONGO_URI": "/todo/prod/mongo-uri"}. SecureString values are decrypted.
+	Parameters map[string]string
+
+	// AppConfigPath is the AppConfig configuration to read flags from:
+	// "<application>/<environment>/<profile>" (empty = no feature flags)
+	AppConfigPath string
+
+	// TTL is how long loaded values are used before Refresh fetches them again
+	TTL time.Duration
+
+	// ParametersURL and AppConfigURL are the extensions' base URLs
+	ParametersURL string
+	AppConfigURL  string
+
+	// Clock decides when the TTL has passed (default clock.Real)
+	Clock clock.Clock
+}
+
+// OptionsFromEnv reads:
+//
+//	SSM_PARAMETERS=MONGO_URI=/todo/prod/mongo-uri,API_KEY=/todo/prod/api-key
+//	APPCONFIG_APPLICATION=go-todo-api APPCONFIG_ENVIRONMENT=prod APPCONFIG_PROFILE=flags
+//	REMOTE_CONFIG_TTL=5m
+//
+// The extension ports come from the variables the extensions themselves use
+// (PARAMETERS_SECRETS_EXTENSION_HTTP_PORT, AWS_APPCONFIG_EXTENSION_HTTP_PORT).
+func OptionsFromEnv() Options {
+	opts := Options{
+		Parameters:    map[string]string{},
+		TTL:           5 * time.Minute,
+		ParametersURL: "http://localhost:" + envOr("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "2773"),
+		AppConfigURL:  "http://localhost:" + envOr("AWS_APPCONFIG_EXTENSION_HTTP_PORT", "2772"),
+	}
+
+	for _, pair := range strings.Split(os.Getenv("SSM_PARAMETERS"), ",") {
+		envName, paramName, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && envName != "" && paramName != "" {
+			opts.Parameters[strings.TrimSpace(envName)] = strings.TrimSpace(paramName)
+		}
+	}
+
+	application := os.Getenv("APPCONFIG_APPLICATION")
+	environment := os.Getenv("APPCONFIG_ENVIRONMENT")
+	profile := os.Getenv("APPCONFIG_PROFILE")
+	if application != "" && environment != "" && profile != "" {
+		opts.AppConfigPath = application + "/" + environment + "/" + profile
+	}
+
+	if v, err := time.ParseDuration(os.Getenv("REMOTE_CONFIG_TTL")); err == nil && v > 0 {
+		opts.TTL = v
+	}
+	return opts
+}
+
+// Enabled reports whether there's anything to load
+func (o Options) Enabled() bool {
+	return len(o.Parameters) > 0 || o.AppConfigPath != ""
+}
+
+// Loader fetches the configured values and keeps them fresh
+type Loader struct {
+	opts   Options
+	client *http.Client
+
+	mu       sync.RWMutex
+	loadedAt time.Time
+	flags    map[string]bool
+}
+
+// New creates a Loader; nothing is fetched until Load
+func New(opts Options) *Loader {
+	opts.Clock = clock.OrReal(opts.Clock)
+	return &Loader{
+		opts:   opts,
+		client: &http.Client{Timeout: 5 * time.Second},
+		flags:  map[string]bool{},
+	}
+}
+
+// Load fetches every parameter and the feature flags
+// Parameters are copied into the environment (os.Setenv), so code that reads
+// MONGO_URI or API_KEY keeps working unchanged. Call it once at cold start,
+// before database.Connect(); an error means the function can't start.
+func (l *Loader) Load(ctx context.Context) error {
+	values := make(map[string]string, len(l.opts.Parameters))
+	for envName, paramName := range l.opts.Parameters {
+		value, err := l.getParameter(ctx, paramName)
+		if err != nil {
+			return fmt.Errorf("load %s from SSM parameter %s: %w", envName, paramName, err)
+		}
+		values[envName] = value
+	}
+
+	var flags map[string]bool
+	if l.opts.AppConfigPath != "" {
+		var err error
+		if flags, err = l.getFlags(ctx); err != nil {
+			return fmt.Errorf("load feature flags from AppConfig %s: %w", l.opts.AppConfigPath, err)
+		}
+	}
+
+	// Only apply once everything loaded, so a failure never leaves a mix
+	for envName, value := range values {
+		os.Setenv(envName, value)
+	}
+
+	l.mu.Lock()
+	if flags != nil {
+		l.flags = flags
+	}
+	l.loadedAt = l.opts.Clock.Now()
+	l.mu.Unlock()
+
+	logger.Log.Info("Remote configuration loaded",
+		"parameters", sortedKeys(values),
+		"flags", len(flags),
+	)
+	return nil
+}
+
+// Refresh reloads the values once the TTL has passed
+// A failed refresh is logged and the previous values are kept, so a brief
+// SSM or AppConfig outage doesn't take the API down.
+func (l *Loader) Refresh(ctx context.Context) {
+	l.mu.RLock()
+	fresh := l.opts.Clock.Now().Sub(l.loadedAt) < l.opts.TTL
+	l.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	if err := l.Load(ctx); err != nil {
+		logger.Log.Warn("Remote configuration refresh failed, keeping previous values", "error", err)
+
+		// Don't retry on every request while it's down
+		l.mu.Lock()
+		l.loadedAt = l.opts.Clock.Now()
+		l.mu.Unlock()
+	}
+}
+
+// Flag reports whether a feature flag is on (unknown flags are off)
+func (l *Loader) Flag(name string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.flags[name]
+}
+
+// getParameter reads one parameter through the Parameters and Secrets extension
+// GET /systemsmanager/parameters/get?name=...&withDecryption=true
+func (l *Loader) getParameter(ctx context.Context, name string) (string, error) {
+	endpoint := l.opts.ParametersURL + "/systemsmanager/parameters/get?withDecryption=true&name=" + url.QueryEscape(name)
+
+	var body struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	if err := l.getJSON(ctx, endpoint, &body); err != nil {
+		return "", err
+	}
+	return body.Parameter.Value, nil
+}
+
+// getFlags reads the feature flags through the AppConfig extension
+// GET /applications/<app>/environments/<env>/configurations/<profile>
+//
+// Both AppConfig formats are accepted:
+//
+//	feature flag profile  {"new-ui": {"enabled": true}}
+//	freeform JSON         {"new-ui": true}
+func (l *Loader) getFlags(ctx context.Context) (map[string]bool, error) {
+	parts := strings.SplitN(l.opts.AppConfigPath, "/", 3)
+	endpoint := fmt.Sprintf("%s/applications/%s/environments/%s/configurations/%s",
+		l.opts.AppConfigURL, url.PathEscape(parts[0]), url.PathEscape(parts[1]), url.PathEscape(parts[2]))
+
+	var body map[string]json.RawMessage
+	if err := l.getJSON(ctx, endpoint, &body); err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]bool, len(body))
+	for name, raw := range body {
+		var enabled bool
+		if json.Unmarshal(raw, &enabled) == nil {
+			flags[name] = enabled
+			continue
+		}
+		var flag struct {
+			Enabled bool `json:"enabled"`
+		}
+		if json.Unmarshal(raw, &flag) == nil {
+			flags[name] = flag.Enabled
+		}
+	}
+	return flags, nil
+}
+
+// getJSON makes an authenticated GET request to an extension and decodes the body
+func (l *Loader) getJSON(ctx context.Context, endpoint string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	// The Parameters and Secrets extension only answers callers that know
+	// the function's session token (AppConfig ignores the header)
+	req.Header.Set("X-Aws-Parameters-Secrets-Token", os.Getenv("AWS_SESSION_TOKEN"))
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("extension returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// envOr returns the environment variable, or fallback when it's empty
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// sortedKeys lists the names of the loaded parameters (never their values)
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
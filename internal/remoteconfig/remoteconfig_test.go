@@ -0,0 +1,162 @@
+package remoteconfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/logger"
+)
+
+// fakeExtensions serves the two Lambda extension APIs
+func fakeExtensions(t *testing.T, mongoURI *string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Aws-Parameters-Secrets-Token") != "session-token" {
+			http.Error(w, "missing token", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/systemsmanager/parameters/get":
+			if r.URL.Query().Get("name") != "/todo/test/mongo-uri" {
+				http.Error(w, "parameter not found", http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"Parameter": {"Name": "/todo/test/mongo-uri", "Value": "` + *mongoURI + `"}}`))
+		case "/applications/todo/environments/test/configurations/flags":
+			w.Write([]byte(`{"new-ui": {"enabled": true}, "bulk-import": false}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// TestLoad_ParametersAndFlags tests that parameters land in the environment and flags are readable
+func TestLoad_ParametersAndFlags(t *testing.T) {
+	// Arrange
+	logger.Init()
+	t.Setenv("AWS_SESSION_TOKEN", "session-token")
+	t.Setenv("MONGO_URI", "")
+	mongoURI := "mongodb://from-ssm:27017"
+	srv := fakeExtensions(t, &mongoURI)
+	defer srv.Close()
+
+	loader := New(Options{
+		Parameters:    map[string]string{"MONGO_URI": "/todo/test/mongo-uri"},
+		AppConfigPath: "todo/test/flags",
+		TTL:           time.Minute,
+		ParametersURL: srv.URL,
+		AppConfigURL:  srv.URL,
+	})
+
+	// Act
+	err := loader.Load(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := os.Getenv("MONGO_URI"); got != mongoURI {
+		t.Errorf("Expected MONGO_URI '%s', got '%s'", mongoURI, got)
+	}
+	if !loader.Flag("new-ui") || loader.Flag("bulk-import") || loader.Flag("unknown") {
+		t.Errorf("Expected only new-ui to be on")
+	}
+
+	t.Logf("✅ Loaded MONGO_URI and %d flags", len(loader.flags))
+}
+
+// TestRefresh_KeepsValuesOnFailure tests that a failed refresh doesn't clear what was loaded
+func TestRefresh_KeepsValuesOnFailure(t *testing.T) {
+	// Arrange
+	logger.Init()
+	t.Setenv("AWS_SESSION_TOKEN", "session-token")
+	mongoURI := "mongodb://first:27017"
+	srv := fakeExtensions(t, &mongoURI)
+
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	loader := New(Options{
+		Parameters:    map[string]string{"MONGO_URI": "/todo/test/mongo-uri"},
+		TTL:           time.Minute,
+		ParametersURL: srv.URL,
+		Clock:         fake,
+	})
+	if err := loader.Load(context.Background()); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	// Act - the extension goes away before the TTL refresh
+	srv.Close()
+	fake.Advance(2 * time.Minute)
+	loader.Refresh(context.Background())
+
+	// Assert
+	if got := os.Getenv("MONGO_URI"); got != "mongodb://first:27017" {
+		t.Errorf("Expected the previous MONGO_URI to be kept, got '%s'", got)
+	}
+}
+
+// TestRefresh_WaitsForTTL tests that Refresh only fetches again once the TTL has passed
+func TestRefresh_WaitsForTTL(t *testing.T) {
+	// Arrange
+	logger.Init()
+	t.Setenv("AWS_SESSION_TOKEN", "session-token")
+	mongoURI := "mongodb://first:27017"
+	srv := fakeExtensions(t, &mongoURI)
+	defer srv.Close()
+
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	loader := New(Options{
+		Parameters:    map[string]string{"MONGO_URI": "/todo/test/mongo-uri"},
+		TTL:           5 * time.Minute,
+		ParametersURL: srv.URL,
+		Clock:         fake,
+	})
+	if err := loader.Load(context.Background()); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	mongoURI = "mongodb://rotated:27017"
+
+	// Act + Assert: still fresh after 4 minutes
+	fake.Advance(4 * time.Minute)
+	loader.Refresh(context.Background())
+	if got := os.Getenv("MONGO_URI"); got != "mongodb://first:27017" {
+		t.Errorf("Expected no refresh before the TTL, got MONGO_URI '%s'", got)
+	}
+
+	// Past 5 minutes the rotated value is picked up
+	fake.Advance(time.Minute)
+	loader.Refresh(context.Background())
+	if got := os.Getenv("MONGO_URI"); got != "mongodb://rotated:27017" {
+		t.Errorf("Expected the rotated MONGO_URI after the TTL, got '%s'", got)
+	}
+
+	t.Log("✅ Refreshed exactly when the TTL passed")
+}
+
+// TestOptionsFromEnv tests parsing the parameter mapping and AppConfig path
+func TestOptionsFromEnv(t *testing.T) {
+	// Arrange
+	t.Setenv("SSM_PARAMETERS", "MONGO_URI=/todo/prod/mongo-uri, API_KEY=/todo/prod/api-key")
+	t.Setenv("APPCONFIG_APPLICATION", "go-todo-api")
+	t.Setenv("APPCONFIG_ENVIRONMENT", "prod")
+	t.Setenv("APPCONFIG_PROFILE", "flags")
+
+	// Act
+	opts := OptionsFromEnv()
+
+	// Assert
+	if opts.Parameters["API_KEY"] != "/todo/prod/api-key" || len(opts.Parameters) != 2 {
+		t.Errorf("Unexpected parameters: %v", opts.Parameters)
+	}
+	if opts.AppConfigPath != "go-todo-api/prod/flags" {
+		t.Errorf("Expected AppConfig path 'go-todo-api/prod/flags', got '%s'", opts.AppConfigPath)
+	}
+	if !opts.Enabled() {
+		t.Error("Expected options to be enabled")
+	}
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/models"
+)
+
+// ============================================================================
+// MICRO-CACHE FOR LISTS
+// ============================================================================
+// A dashboard that polls GET /tasks every second from 200 browser tabs sends
+// 200 identical queries per second. TaskCache answers List and Version from
+// memory for a short TTL (e.g. 1s), so MongoDB sees one query per filter per
+// TTL instead. Get is never cached.
+//
+// The cache is per process: a write through this process clears it at once,
+// a write through another instance (or Lambda) shows up within the TTL.
+// Keep the TTL short - it's how stale a list may be.
+
+// TaskCache holds the cached lists and version, shared by every repository
+// it wraps
+type TaskCache struct {
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu      sync.Mutex
+	lists   map[string]cachedList // by filter and page: "all", "true@0:20", ... (see listKey)
+	version *cachedVersion
+
+	// generation counts Clear calls, so a read that started before a write
+	// doesn't put what it read back into the cache after the write
+	generation uint64
+}
+
+type cachedList struct {
+	tasks   []models.Task
+	expires time.Time
+}
+
+type cachedVersion struct {
+	version int64
+	expires time.Time
+}
+
+// NewTaskCache creates an empty cache whose entries live for ttl
+func NewTaskCache(ttl time.Duration, clk clock.Clock) *TaskCache {
+	return &TaskCache{ttl: ttl, clock: clock.OrReal(clk), lists: map[string]cachedList{}}
+}
+
+// Wrap returns a repository that reads lists and the version through the
+// cache and clears it on every write
+func (c *TaskCache) Wrap(inner TaskRepository) TaskRepository {
+	return &cachedTaskRepository{TaskRepository: inner, cache: c}
+}
+
+// Clear drops every cached entry
+func (c *TaskCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lists = map[string]cachedList{}
+	c.version = nil
+	c.generation++
+}
+
+// listKey names the list a filter, projection and page return, e.g. "all",
+// "true+description", "all@0:20"
+func listKey(completed *bool, fields Fields, page Page) string {
+	switch {
+	case completed == nil:
+		return "all" + fields.key() + page.key()
+	case *completed:
+		return "true" + fields.key() + page.key()
+	default:
+		return "false" + fields.key() + page.key()
+	}
+}
+
+// cachedTaskRepository is the repository returned by TaskCache.Wrap
+type cachedTaskRepository struct {
+	TaskRepository // Get, Stream (exports) and the counts go straight through
+	cache          *TaskCache
+}
+
+// List returns the cached list while it's fresh
+// Callers get their own copy, so changing it can't change the cache.
+func (r *cachedTaskRepository) List(ctx context.Context, completed *bool, fields Fields, page Page) ([]models.Task, error) {
+	c := r.cache
+	key := listKey(completed, fields, page)
+
+	c.mu.Lock()
+	entry, ok := c.lists[key]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && c.clock.Now().Before(entry.expires) {
+		return slices.Clone(entry.tasks), nil
+	}
+
+	tasks, err := r.TaskRepository.List(ctx, completed, fields, page)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.lists[key] = cachedList{tasks: slices.Clone(tasks), expires: c.clock.Now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return tasks, nil
+}
+
+// Version returns the cached version while it's fresh
+func (r *cachedTaskRepository) Version(ctx context.Context) (int64, error) {
+	c := r.cache
+
+	c.mu.Lock()
+	entry := c.version
+	generation := c.generation
+	c.mu.Unlock()
+	if entry != nil && c.clock.Now().Before(entry.expires) {
+		return entry.version, nil
+	}
+
+	version, err := r.TaskRepository.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.version = &cachedVersion{version: version, expires: c.clock.Now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return version, nil
+}
+
+// Create, Update and Delete clear the cache even when they fail: a failed
+// write may still have changed something (see MongoTaskRepository.bumpVersion)
+
+func (r *cachedTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	defer r.cache.Clear()
+	return r.TaskRepository.Create(ctx, task)
+}
+
+func (r *cachedTaskRepository) Update(ctx context.Context, id models.TaskID, changes TaskChanges) (*models.Task, error) {
+	defer r.cache.Clear()
+	return r.TaskRepository.Update(ctx, id, changes)
+}
+
+func (r *cachedTaskRepository) Delete(ctx context.Context, id models.TaskID) error {
+	defer r.cache.Clear()
+	return r.TaskRepository.Delete(ctx, id)
+}
@@ -0,0 +1,121 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/testutil"
+)
+
+// countingRepository counts the List calls that reach the store
+type countingRepository struct {
+	*repository.MemoryTaskRepository
+	lists int
+}
+
+func (r *countingRepository) List(ctx context.Context, completed *bool, fields repository.Fields, page repository.Page) ([]models.Task, error) {
+	r.lists++
+	return r.MemoryTaskRepository.List(ctx, completed, fields, page)
+}
+
+// TestTaskCache_ListUntilExpiry tests that lists come from memory until the
+// TTL has passed, separately for each filter
+func TestTaskCache_ListUntilExpiry(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	inner := &countingRepository{MemoryTaskRepository: repository.NewMemoryTaskRepository()}
+	testutil.CreateTasks(t, inner, testutil.NewTask())
+	repo := repository.NewTaskCache(time.Second, fake).Wrap(inner)
+
+	// Act: the same list three times, then another filter
+	for i := 0; i < 3; i++ {
+		if _, err := repo.List(ctx, nil, repository.Fields{}, repository.Page{}); err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+	}
+	repo.List(ctx, testutil.Ptr(true), repository.Fields{}, repository.Page{})
+
+	// Assert
+	if inner.lists != 2 {
+		t.Fatalf("Expected 2 queries (one per filter), got %d", inner.lists)
+	}
+
+	// After the TTL the store is asked again
+	fake.Advance(time.Second)
+	repo.List(ctx, nil, repository.Fields{}, repository.Page{})
+	if inner.lists != 3 {
+		t.Errorf("Expected a new query after the TTL, got %d queries", inner.lists)
+	}
+
+	t.Logf("✅ %d queries for 5 lists", inner.lists)
+}
+
+// TestTaskCache_ClearedOnWrite tests that a write through the cache is seen
+// by the next list, without waiting for the TTL
+func TestTaskCache_ClearedOnWrite(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	repo := repository.NewTaskCache(time.Minute, fake).Wrap(repository.NewMemoryTaskRepository())
+	before, _ := repo.Version(ctx)
+	repo.List(ctx, nil, repository.Fields{}, repository.Page{})
+
+	// Act
+	testutil.CreateTasks(t, repo, testutil.NewTask())
+
+	// Assert
+	tasks, _ := repo.List(ctx, nil, repository.Fields{}, repository.Page{})
+	if len(tasks) != 1 {
+		t.Errorf("Expected the new task in the list, got %d tasks", len(tasks))
+	}
+	if after, _ := repo.Version(ctx); after == before {
+		t.Errorf("Expected the version to change after a write, still %d", after)
+	}
+}
+
+// TestTaskCache_ReturnsCopies tests that changing a returned list doesn't
+// change what the next caller gets
+func TestTaskCache_ReturnsCopies(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	inner := repository.NewMemoryTaskRepository()
+	testutil.CreateTasks(t, inner, testutil.NewTask(testutil.WithTitle("Original")))
+	repo := repository.NewTaskCache(time.Minute, nil).Wrap(inner)
+
+	// Act
+	first, _ := repo.List(ctx, nil, repository.Fields{}, repository.Page{})
+	first[0].Title = "Changed"
+	second, _ := repo.List(ctx, nil, repository.Fields{}, repository.Page{})
+
+	// Assert
+	if second[0].Title != "Original" {
+		t.Errorf("Expected the cached list to be unchanged, got %q", second[0].Title)
+	}
+}
+
+// TestTaskCache_SeparateFields tests that a cached summary list isn't handed
+// to a caller who asked for descriptions
+func TestTaskCache_SeparateFields(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	inner := repository.NewMemoryTaskRepository()
+	testutil.CreateTasks(t, inner, testutil.NewTask(testutil.WithDescription("Two litres")))
+	repo := repository.NewTaskCache(time.Minute, nil).Wrap(inner)
+
+	// Act
+	summary, _ := repo.List(ctx, nil, repository.Fields{}, repository.Page{})
+	full, _ := repo.List(ctx, nil, repository.AllFields, repository.Page{})
+
+	// Assert
+	if summary[0].Description != "" {
+		t.Errorf("Expected no description in the summary, got %q", summary[0].Description)
+	}
+	if full[0].Description != "Two litres" {
+		t.Errorf("Expected the description, got %q", full[0].Description)
+	}
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-todo-api/internal/models"
+)
+
+// ============================================================================
+// DRY RUN
+// ============================================================================
+// A request with ?dry_run=true goes through the same validation, quota and
+// hooks as a real one, and gets the response it would have had, but nothing
+// is written. The handlers don't need to know: they get a repository whose
+// writes work out their result from the stored tasks and stop there.
+
+// DryRun returns a repository that reads from inner but never writes to it
+func DryRun(inner TaskRepository) TaskRepository {
+	return &dryRunTaskRepository{TaskRepository: inner}
+}
+
+// dryRunTaskRepository is the repository returned by DryRun
+type dryRunTaskRepository struct {
+	TaskRepository // Reads go straight through
+}
+
+// Create gives the task the ID it would have had, without inserting it
+func (r *dryRunTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	if task.ID.IsZero() {
+		task.ID = models.GenerateTaskID()
+		return nil
+	}
+	// A client-chosen ID must still be free
+	_, err := r.Get(ctx, task.ID)
+	switch {
+	case err == nil:
+		return ErrDuplicate
+	case errors.Is(err, ErrNotFound):
+		return nil
+	default:
+		return err
+	}
+}
+
+// Update returns the task as the changes would leave it
+func (r *dryRunTaskRepository) Update(ctx context.Context, id models.TaskID, changes TaskChanges) (*models.Task, error) {
+	task, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changes.apply(task, time.Now().UTC())
+	return task, nil
+}
+
+// Delete checks the task exists, so a dry run gets the same 404
+func (r *dryRunTaskRepository) Delete(ctx context.Context, id models.TaskID) error {
+	_, err := r.Get(ctx, id)
+	return err
+}
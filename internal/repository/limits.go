@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"go-todo-api/internal/domainerrors"
+)
+
+// ============================================================================
+// QUERY GUARDRAILS
+// ============================================================================
+// GET /tasks returns every matching task unless it's asked for a page. That's
+// fine for the lists people keep, but one client with 2 million tasks would
+// make the server decode all of them into memory, and a query stuck on a
+// cold disk would hold a connection for as long as the request lasts. Limits
+// caps both: MongoDB stops a query after MaxTime (maxTimeMS), and a list
+// returns at most MaxResults tasks - past that the caller gets an error
+// telling it to page through the list, narrow it, or stream it from
+// GET /export, which isn't capped. A page is capped like a whole list only
+// when it asks for more than MaxResults tasks.
+
+var (
+	// ErrTooManyResults is returned when a list matches more than
+	// Limits.MaxResults tasks
+	ErrTooManyResults = domainerrors.New(domainerrors.ErrValidation, "Too many tasks match")
+
+	// ErrQueryTimeout is returned when MongoDB stopped a query after
+	// Limits.MaxTime
+	ErrQueryTimeout = errors.New("repository: query took too long")
+)
+
+// Limits bound what one query may cost
+type Limits struct {
+	MaxResults int           // Most tasks one list may return (0 = no limit)
+	MaxTime    time.Duration // Longest MongoDB may work on one query (0 = no limit)
+}
+
+// defaultLimits is replaced by InitLimits at startup
+var defaultLimits = Limits{MaxResults: 10_000, MaxTime: 5 * time.Second}
+
+// InitLimits sets the limits of the repositories created from now on
+func InitLimits(l Limits) {
+	defaultLimits = l
+}
+
+// DefaultLimits returns the limits set by InitLimits (10,000 tasks and 5
+// seconds before it's called)
+func DefaultLimits() Limits {
+	return defaultLimits
+}
+
+// tooMany reports whether n tasks are over the limit
+func (l Limits) tooMany(n int) bool {
+	return l.MaxResults > 0 && n > l.MaxResults
+}
+
+// fetch returns how many tasks to read for page (0 = all): the page's
+// limit, or one more than MaxResults when that's lower - enough to tell the
+// list is over it without reading the rest
+func (l Limits) fetch(page Page) int {
+	if l.MaxResults > 0 && (page.Limit == 0 || page.Limit > l.MaxResults) {
+		return l.MaxResults + 1
+	}
+	return page.Limit
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"iter"
+	"sort"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/models"
+)
+
+// MemoryTaskRepository keeps tasks in a map
+// Tasks are lost on restart and not shared between processes - use it in tests
+type MemoryTaskRepository struct {
+	mu      sync.Mutex
+	tasks   map[string]models.Task
+	version int64
+	limits  Limits // Only MaxResults applies: nothing here is slow
+}
+
+// NewMemoryTaskRepository creates an empty in-memory repository
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{tasks: make(map[string]models.Task), limits: DefaultLimits()}
+}
+
+// SetLimits replaces the limits the repository was created with
+func (r *MemoryTaskRepository) SetLimits(l Limits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits = l
+}
+
+// List returns the page of tasks matching the filter, oldest first
+func (r *MemoryTaskRepository) List(ctx context.Context, completed *bool, fields Fields, page Page) ([]models.Task, error) {
+	tasks := r.matching(completed, fields)
+	r.mu.Lock()
+	limits := r.limits
+	r.mu.Unlock()
+	return pageOf(tasks, page, limits)
+}
+
+// Count counts the tasks matching the filter
+func (r *MemoryTaskRepository) Count(ctx context.Context, completed *bool) (int64, error) {
+	return int64(len(r.matching(completed, Fields{}))), nil
+}
+
+// pageOf cuts the page out of tasks, capped like MongoDB's (see Limits.fetch)
+func pageOf(tasks []models.Task, page Page, limits Limits) ([]models.Task, error) {
+	tasks = tasks[min(page.Offset, len(tasks)):]
+	if fetch := limits.fetch(page); fetch > 0 && fetch < len(tasks) {
+		tasks = tasks[:fetch]
+	}
+	if limits.tooMany(len(tasks)) {
+		return nil, ErrTooManyResults
+	}
+	return tasks, nil
+}
+
+// matching returns the tasks matching the filter, oldest first, however many
+func (r *MemoryTaskRepository) matching(completed *bool, fields Fields) []models.Task {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tasks := []models.Task{}
+	for _, task := range r.tasks {
+		if completed == nil || task.Completed == *completed {
+			if !fields.Description {
+				task.Description = ""
+			}
+			tasks = append(tasks, task)
+		}
+	}
+	// ObjectIDs start with their creation time, like MongoDB's natural order
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID.String() < tasks[j].ID.String() })
+	return tasks
+}
+
+// Stream returns the whole List result one task at a time
+// The tasks are in memory already, so this only exists to satisfy the interface
+// Like MongoDB's, it isn't capped by Limits.MaxResults.
+func (r *MemoryTaskRepository) Stream(ctx context.Context, completed *bool) (iter.Seq2[models.Task, error], error) {
+	tasks := r.matching(completed, AllFields)
+	return func(yield func(models.Task, error) bool) {
+		for _, task := range tasks {
+			if !yield(task, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+// Stats counts the tasks by status
+func (r *MemoryTaskRepository) Stats(ctx context.Context) (models.TaskStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := models.TaskStats{Total: int64(len(r.tasks)), CountedAt: time.Now().UTC()}
+	for _, task := range r.tasks {
+		if task.Completed {
+			stats.Completed++
+		} else {
+			stats.Open++
+		}
+	}
+	return stats, nil
+}
+
+// CountOwned counts the tasks created by a user
+func (r *MemoryTaskRepository) CountOwned(ctx context.Context, ownerID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, task := range r.tasks {
+		if task.OwnerID == ownerID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListDue returns the page of open tasks due in [from, to), soonest first
+func (r *MemoryTaskRepository) ListDue(ctx context.Context, from, to time.Time, fields Fields, page Page) ([]models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return pageOf(r.due(from, to, fields), page, r.limits)
+}
+
+// CountDue counts the open tasks due in [from, to)
+func (r *MemoryTaskRepository) CountDue(ctx context.Context, from, to time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.due(from, to, Fields{}))), nil
+}
+
+// due returns the open tasks due in [from, to), soonest first (ties oldest
+// first, so pages don't overlap); the caller holds r.mu
+func (r *MemoryTaskRepository) due(from, to time.Time, fields Fields) []models.Task {
+	tasks := []models.Task{}
+	for _, task := range r.tasks {
+		if task.Completed || task.DueAt == nil || !task.DueAt.Before(to) || task.DueAt.Before(from) {
+			continue
+		}
+		if !fields.Description {
+			task.Description = ""
+		}
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].DueAt.Equal(*tasks[j].DueAt) {
+			return tasks[i].DueAt.Before(*tasks[j].DueAt)
+		}
+		return tasks[i].ID.String() < tasks[j].ID.String()
+	})
+	return tasks
+}
+
+// ListOwned returns the tasks created by a user, oldest first
+func (r *MemoryTaskRepository) ListOwned(ctx context.Context, ownerID string) ([]models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tasks := []models.Task{}
+	for _, task := range r.tasks {
+		if task.OwnerID == ownerID {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID.String() < tasks[j].ID.String() })
+	return tasks, nil
+}
+
+// Get returns a task by ID
+func (r *MemoryTaskRepository) Get(ctx context.Context, id models.TaskID) (*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[id.String()]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &task, nil
+}
+
+// Create inserts a new task, generating an ID if it has none
+func (r *MemoryTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if task.ID.IsZero() {
+		task.ID = models.GenerateTaskID()
+	}
+	if _, ok := r.tasks[task.ID.String()]; ok {
+		return ErrDuplicate
+	}
+	r.tasks[task.ID.String()] = *task
+	r.version++
+	return nil
+}
+
+// Update changes only the given fields
+// With nothing to change it's a read, like MongoTaskRepository.Update: the
+// version stays the same.
+func (r *MemoryTaskRepository) Update(ctx context.Context, id models.TaskID, changes TaskChanges) (*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[id.String()]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if changes.Empty() {
+		return &task, nil
+	}
+	changes.apply(&task, time.Now().UTC())
+	r.tasks[id.String()] = task
+	r.version++
+	return &task, nil
+}
+
+// Delete removes a task
+func (r *MemoryTaskRepository) Delete(ctx context.Context, id models.TaskID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tasks[id.String()]; !ok {
+		return ErrNotFound
+	}
+	delete(r.tasks, id.String())
+	r.version++
+	return nil
+}
+
+// DeleteAll removes every task
+func (r *MemoryTaskRepository) DeleteAll(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.tasks)
+	r.version++
+	return nil
+}
+
+// Version returns how many writes the repository has had
+func (r *MemoryTaskRepository) Version(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.version, nil
+}
@@ -0,0 +1,428 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	"go-todo-api/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// versionsCollection holds one {_id: <collection name>, version: n} document
+// per collection, bumped after every write (see Version)
+const versionsCollection = "versions"
+
+// statsCollection holds one {_id: <collection name>, open, completed, ...}
+// document per collection, rewritten by RecountStats (see Stats)
+const statsCollection = "stats"
+
+// MongoTaskRepository keeps tasks in a MongoDB collection
+type MongoTaskRepository struct {
+	collection *mongo.Collection
+	versions   *mongo.Collection
+	stats      *mongo.Collection
+	limits     Limits
+}
+
+// NewMongoTaskRepository creates a repository on the given collection
+// The version counter and the task counts live in the "versions" and "stats"
+// collections of the same database.
+func NewMongoTaskRepository(collection *mongo.Collection) *MongoTaskRepository {
+	return &MongoTaskRepository{
+		collection: collection,
+		versions:   collection.Database().Collection(versionsCollection),
+		stats:      collection.Database().Collection(statsCollection),
+		limits:     DefaultLimits(),
+	}
+}
+
+// SetLimits replaces the limits the repository was created with
+func (r *MongoTaskRepository) SetLimits(l Limits) {
+	r.limits = l
+}
+
+// findOptions returns Find options with the query time limit, for the page
+// and capped as Limits.fetch says
+func (r *MongoTaskRepository) findOptions(fields Fields, page Page) *options.FindOptions {
+	opts := options.Find()
+	if r.limits.MaxTime > 0 {
+		opts.SetMaxTime(r.limits.MaxTime)
+	}
+	if page.Offset > 0 {
+		opts.SetSkip(int64(page.Offset))
+	}
+	if fetch := r.limits.fetch(page); fetch > 0 {
+		opts.SetLimit(int64(fetch))
+	}
+	if !fields.Description {
+		opts.SetProjection(bson.M{"description": 0})
+	}
+	return opts
+}
+
+// findTasks runs a capped Find (see findOptions) and decodes the tasks
+func (r *MongoTaskRepository) findTasks(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.Task, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, translate(err)
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, translate(err)
+	}
+	if r.limits.tooMany(len(tasks)) {
+		return nil, ErrTooManyResults
+	}
+	return tasks, nil
+}
+
+// List returns the tasks matching the filter
+// Fields left out aren't sent by MongoDB at all (a projection), so a summary
+// list costs less network and decoding, not just fewer bytes to the client
+// Past the limits it returns ErrTooManyResults or ErrQueryTimeout.
+func (r *MongoTaskRepository) List(ctx context.Context, completed *bool, fields Fields, page Page) ([]models.Task, error) {
+	// Sorting on _id (creation order for ObjectIDs) keeps each page where
+	// the last one ended; its index makes the sort free
+	opts := r.findOptions(fields, page).SetSort(bson.D{{Key: "_id", Value: 1}})
+	return r.findTasks(ctx, completedFilter(completed), opts)
+}
+
+// Count counts the tasks matching the filter
+func (r *MongoTaskRepository) Count(ctx context.Context, completed *bool) (int64, error) {
+	return r.count(ctx, completedFilter(completed))
+}
+
+// count runs CountDocuments with the query time limit
+func (r *MongoTaskRepository) count(ctx context.Context, filter bson.M) (int64, error) {
+	opts := options.Count()
+	if r.limits.MaxTime > 0 {
+		opts.SetMaxTime(r.limits.MaxTime)
+	}
+	count, err := r.collection.CountDocuments(ctx, filter, opts)
+	return count, translate(err)
+}
+
+// completedFilter matches every task, or only those with the given status
+func completedFilter(completed *bool) bson.M {
+	filter := bson.M{}
+	if completed != nil {
+		filter["completed"] = *completed
+	}
+	return filter
+}
+
+// Stream decodes tasks from the cursor as they arrive
+// The driver fetches them in batches (101 documents, then up to 16MB each),
+// so memory stays flat however many tasks there are
+func (r *MongoTaskRepository) Stream(ctx context.Context, completed *bool) (iter.Seq2[models.Task, error], error) {
+	cursor, err := r.collection.Find(ctx, completedFilter(completed))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(models.Task, error) bool) {
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+			var task models.Task
+			if err := cursor.Decode(&task); err != nil {
+				yield(models.Task{}, err)
+				return
+			}
+			if !yield(task, nil) {
+				return
+			}
+		}
+		if err := cursor.Err(); err != nil {
+			yield(models.Task{}, err)
+		}
+	}, nil
+}
+
+// CountOwned counts the tasks created by a user
+// The owner_id index (see EnsureIndexes) makes this a count of index keys
+func (r *MongoTaskRepository) CountOwned(ctx context.Context, ownerID string) (int64, error) {
+	return r.count(ctx, bson.M{"owner_id": ownerID})
+}
+
+// ListDue returns the open tasks due in [from, to), soonest first
+// The due_at index (see EnsureIndexes) holds only tasks with a due date
+func (r *MongoTaskRepository) ListDue(ctx context.Context, from, to time.Time, fields Fields, page Page) ([]models.Task, error) {
+	opts := r.findOptions(fields, page).SetSort(bson.D{{Key: "due_at", Value: 1}})
+	return r.findTasks(ctx, dueFilter(from, to), opts)
+}
+
+// CountDue counts the open tasks due in [from, to)
+func (r *MongoTaskRepository) CountDue(ctx context.Context, from, to time.Time) (int64, error) {
+	return r.count(ctx, dueFilter(from, to))
+}
+
+// dueFilter matches the open tasks due in [from, to) (a zero from = no
+// lower bound)
+func dueFilter(from, to time.Time) bson.M {
+	due := bson.M{"$lt": to}
+	if !from.IsZero() {
+		due["$gte"] = from
+	}
+	return bson.M{"completed": false, "due_at": due}
+}
+
+// ListOwned returns the tasks created by a user, oldest first
+// It uses the same owner_id index as CountOwned
+func (r *MongoTaskRepository) ListOwned(ctx context.Context, ownerID string) ([]models.Task, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if r.limits.MaxTime > 0 {
+		opts.SetMaxTime(r.limits.MaxTime)
+	}
+	cursor, err := r.collection.Find(ctx, bson.M{"owner_id": ownerID}, opts)
+	if err != nil {
+		return nil, translate(err)
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// EnsureIndexes creates the indexes for counting a user's tasks and for
+// listing what's due
+// Tasks created before owners were recorded have no owner_id, and most
+// tasks have no due date, so both indexes leave those tasks out
+func (r *MongoTaskRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "owner_id", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "completed", Value: 1}, {Key: "due_at", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"due_at": bson.M{"$exists": true}}),
+		},
+	})
+	return err
+}
+
+// Get returns a task by ID
+func (r *MongoTaskRepository) Get(ctx context.Context, id models.TaskID) (*models.Task, error) {
+	var task models.Task
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&task); err != nil {
+		return nil, translate(err)
+	}
+	return &task, nil
+}
+
+// Create inserts a new task, generating its ID if it has none
+// The ID is generated here rather than by MongoDB, so it can be a UUID
+// (TASK_ID_FORMAT=uuid).
+func (r *MongoTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	if task.ID.IsZero() {
+		task.ID = models.GenerateTaskID()
+	}
+	if _, err := r.collection.InsertOne(ctx, task); err != nil {
+		return translate(err)
+	}
+	return r.bumpVersion(ctx)
+}
+
+// Update changes only the given fields ($set) and returns the task as it is
+// afterwards, in one round trip
+// FindOneAndUpdate finds, changes and reads back the document atomically, so
+// there's no gap between "does it exist?" and "change it" for a concurrent
+// delete to fall into: it either updates the task or returns ErrNotFound.
+func (r *MongoTaskRepository) Update(ctx context.Context, id models.TaskID, changes TaskChanges) (*models.Task, error) {
+	// MongoDB rejects an empty $set - with nothing to change, just read the task
+	if changes.Empty() {
+		return r.Get(ctx, id)
+	}
+
+	// An update pipeline, so completed_at can depend on the task's current
+	// state: it's stamped when an open task is completed (not again when a
+	// completed one is), and removed when it's reopened. Client values are
+	// wrapped in $literal so a title like "$x" isn't read as a field path.
+	set := bson.M{}
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: set}}}
+	if changes.Title != nil {
+		set["title"] = bson.M{"$literal": *changes.Title}
+	}
+	if changes.Description != nil {
+		set["description"] = bson.M{"$literal": *changes.Description}
+	}
+	if changes.ClearDueAt {
+		pipeline = append(pipeline, bson.D{{Key: "$unset", Value: "due_at"}})
+	} else if changes.DueAt != nil {
+		set["due_at"] = changes.DueAt.UTC()
+	}
+	if changes.Completed != nil {
+		set["completed"] = *changes.Completed
+		if *changes.Completed {
+			set["completed_at"] = bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$completed", true}}, "$completed_at", time.Now().UTC(),
+			}}
+		} else {
+			pipeline = append(pipeline, bson.D{{Key: "$unset", Value: "completed_at"}})
+		}
+	}
+
+	var task models.Task
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		pipeline,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&task)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &task, r.bumpVersion(ctx)
+}
+
+// Delete removes a task
+func (r *MongoTaskRepository) Delete(ctx context.Context, id models.TaskID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return r.bumpVersion(ctx)
+}
+
+// DeleteAll drops the collection, and the task counts stored for it
+// The version is bumped rather than reset, so an ETag from before can't
+// match a list built after.
+func (r *MongoTaskRepository) DeleteAll(ctx context.Context) error {
+	if err := r.collection.Drop(ctx); err != nil {
+		return err
+	}
+	if _, err := r.stats.DeleteOne(ctx, bson.M{"_id": r.collection.Name()}); err != nil {
+		return err
+	}
+	return r.bumpVersion(ctx)
+}
+
+// Version reads the collection's version counter (0 before the first write)
+func (r *MongoTaskRepository) Version(ctx context.Context) (int64, error) {
+	var doc struct {
+		Version int64 `bson:"version"`
+	}
+	err := r.versions.FindOne(ctx, bson.M{"_id": r.collection.Name()}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return doc.Version, err
+}
+
+// bumpVersion increments the version counter after a write
+// It runs after the write, never before: a reader that sees the new version
+// is then sure to also see the change. If it fails the write itself still
+// happened, so the error says so.
+func (r *MongoTaskRepository) bumpVersion(ctx context.Context) error {
+	_, err := r.versions.UpdateOne(ctx,
+		bson.M{"_id": r.collection.Name()},
+		bson.M{"$inc": bson.M{"version": 1}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("task saved, but bumping its collection version failed: %w", err)
+	}
+	return nil
+}
+
+// translate maps driver errors onto the repository's errors
+func translate(err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return errors.Join(ErrDuplicate, err)
+	case isMaxTimeExpired(err):
+		return errors.Join(ErrQueryTimeout, err)
+	default:
+		return err
+	}
+}
+
+// isMaxTimeExpired reports whether MongoDB stopped a query after its maxTimeMS
+func isMaxTimeExpired(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(50) // MaxTimeMSExpired
+}
+
+// ============================================================================
+// TASK COUNTS
+// ============================================================================
+// Counting tasks is an aggregation over the whole collection - fine once,
+// too much for every sidebar badge of every client. So the counts are
+// materialized: RecountStats stores them in one document, stats.Counter calls
+// it whenever the change stream says the tasks changed, and Stats only reads
+// that document back.
+
+// Stats returns the stored counts
+// Without them (no stats.Counter has run yet, or the server is a standalone
+// MongoDB without change streams) it counts the tasks itself.
+func (r *MongoTaskRepository) Stats(ctx context.Context) (models.TaskStats, error) {
+	var stats models.TaskStats
+	err := r.stats.FindOne(ctx, bson.M{"_id": r.collection.Name()}).Decode(&stats)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return r.countStats(ctx)
+	}
+	return stats, err
+}
+
+// RecountStats counts the tasks and stores the result for Stats
+func (r *MongoTaskRepository) RecountStats(ctx context.Context) (models.TaskStats, error) {
+	stats, err := r.countStats(ctx)
+	if err != nil {
+		return stats, err
+	}
+	_, err = r.stats.ReplaceOne(ctx,
+		bson.M{"_id": r.collection.Name()},
+		stats,
+		options.Replace().SetUpsert(true),
+	)
+	return stats, err
+}
+
+// countStats counts the tasks by status in one aggregation
+func (r *MongoTaskRepository) countStats(ctx context.Context) (models.TaskStats, error) {
+	opts := options.Aggregate()
+	if r.limits.MaxTime > 0 {
+		opts.SetMaxTime(r.limits.MaxTime)
+	}
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$completed", "count": bson.M{"$sum": 1}}}},
+	}, opts)
+	if err != nil {
+		return models.TaskStats{}, translate(err)
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		Completed bool  `bson:"_id"`
+		Count     int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return models.TaskStats{}, err
+	}
+
+	stats := models.TaskStats{CountedAt: time.Now().UTC()}
+	for _, g := range groups {
+		if g.Completed {
+			stats.Completed += g.Count
+		} else {
+			stats.Open += g.Count
+		}
+	}
+	stats.Total = stats.Open + stats.Completed
+	return stats, nil
+}
@@ -0,0 +1,158 @@
+// Package repository keeps the task storage behind an interface
+// The handlers only talk to a TaskRepository, so they can be tested without
+// MongoDB: MongoTaskRepository is used in production, MemoryTaskRepository
+// (or a fake that returns errors) in tests.
+package repository
+
+import (
+	"context"
+	"iter"
+	"strconv"
+	"time"
+
+	"go-todo-api/internal/domainerrors"
+	"go-todo-api/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when no task has the given ID
+	ErrNotFound = domainerrors.New(domainerrors.ErrNotFound, "Task not found")
+
+	// ErrDuplicate is returned when a write breaks a unique index
+	ErrDuplicate = domainerrors.New(domainerrors.ErrConflict, "Task already exists")
+)
+
+// TaskChanges lists the fields to change in an update
+// nil means "leave as it is", so a client can send only the fields it wants to change
+type TaskChanges struct {
+	Title       *string
+	Description *string
+	Completed   *bool
+	DueAt       *time.Time
+	ClearDueAt  bool // Remove the due date (DueAt is ignored)
+}
+
+// Empty reports whether there is nothing to change
+func (c TaskChanges) Empty() bool {
+	return c.Title == nil && c.Description == nil && c.Completed == nil && c.DueAt == nil && !c.ClearDueAt
+}
+
+// apply makes the changes to task, as the stores do, at time now
+func (c TaskChanges) apply(task *models.Task, now time.Time) {
+	if c.Title != nil {
+		task.Title = *c.Title
+	}
+	if c.Description != nil {
+		task.Description = *c.Description
+	}
+	if c.ClearDueAt {
+		task.DueAt = nil
+	} else if c.DueAt != nil {
+		due := c.DueAt.UTC()
+		task.DueAt = &due
+	}
+	if c.Completed != nil {
+		// Stamped when an open task is completed, cleared when it's reopened
+		if *c.Completed && !task.Completed {
+			task.CompletedAt = &now
+		} else if !*c.Completed {
+			task.CompletedAt = nil
+		}
+		task.Completed = *c.Completed
+	}
+}
+
+// Fields picks the heavy fields a list includes besides the summary
+// (ID, title and completed). The zero value is the summary alone, which
+// keeps lists small for mobile clients; GET /tasks?include=description
+// asks for more.
+type Fields struct {
+	Description bool
+}
+
+// AllFields includes every field of a task
+var AllFields = Fields{Description: true}
+
+// key names the fields for cache keys and ETags: "" for the summary,
+// "+description" with descriptions
+func (f Fields) key() string {
+	if f.Description {
+		return "+description"
+	}
+	return ""
+}
+
+// Page picks part of a list: Limit tasks after skipping Offset of them.
+// The zero value is the whole list. Only the page is read from the
+// database, so a page of at most Limits.MaxResults tasks isn't capped
+// however many tasks the list has (see Limits).
+type Page struct {
+	Offset int
+	Limit  int // 0 = every task after Offset
+}
+
+// key names the page for cache keys: "" for the whole list, "@20:50" for
+// 50 tasks from the 21st
+func (p Page) key() string {
+	if p == (Page{}) {
+		return ""
+	}
+	return "@" + strconv.Itoa(p.Offset) + ":" + strconv.Itoa(p.Limit)
+}
+
+// TaskRepository stores tasks
+// Any error other than ErrNotFound and ErrDuplicate means the store itself
+// failed (database down, timeout, ...).
+type TaskRepository interface {
+	// List returns the page of all tasks, or of only those matching
+	// completed when it's not nil, oldest first, with the summary fields and
+	// the ones asked for in fields
+	List(ctx context.Context, completed *bool, fields Fields, page Page) ([]models.Task, error)
+
+	// Count counts the tasks List would return without a page
+	Count(ctx context.Context, completed *bool) (int64, error)
+
+	// Stream runs the same query as List, with every field, but hands the
+	// tasks over one at a time, so a huge result never has to fit in memory.
+	// The error return is for starting the query; an error while reading
+	// comes out of the sequence, after which it stops. Range over the sequence exactly once -
+	// that's what closes the underlying cursor.
+	Stream(ctx context.Context, completed *bool) (iter.Seq2[models.Task, error], error)
+
+	// Get returns a task by ID, or ErrNotFound
+	Get(ctx context.Context, id models.TaskID) (*models.Task, error)
+
+	// Create inserts a new task and sets its ID
+	Create(ctx context.Context, task *models.Task) error
+
+	// Update applies the changes and returns the updated task, or ErrNotFound
+	Update(ctx context.Context, id models.TaskID, changes TaskChanges) (*models.Task, error)
+
+	// Delete removes a task, or returns ErrNotFound
+	Delete(ctx context.Context, id models.TaskID) error
+
+	// ListDue returns the page of open tasks due in [from, to), soonest
+	// first, with the summary fields and the ones asked for in fields. A zero
+	// from means no lower bound (everything due before to).
+	ListDue(ctx context.Context, from, to time.Time, fields Fields, page Page) ([]models.Task, error)
+
+	// CountDue counts the tasks ListDue would return without a page
+	CountDue(ctx context.Context, from, to time.Time) (int64, error)
+
+	// Stats counts the tasks by status
+	// The MongoDB repository reads counts kept up to date by stats.Counter,
+	// so it's one small read however many tasks there are
+	Stats(ctx context.Context) (models.TaskStats, error)
+
+	// CountOwned counts the tasks created by a user, for their quota
+	CountOwned(ctx context.Context, ownerID string) (int64, error)
+
+	// ListOwned returns the tasks created by a user, with every field,
+	// oldest first (for their daily digest)
+	ListOwned(ctx context.Context, ownerID string) ([]models.Task, error)
+
+	// Version returns a number that changes whenever a task is created,
+	// updated or deleted, so clients can tell a list hasn't changed (ETag)
+	// without fetching it. It's cheaper than List: one small document.
+	Version(ctx context.Context) (int64, error)
+}
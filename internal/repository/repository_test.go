@@ -0,0 +1,125 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoRepository returns a repository on an empty collection of a
+// throwaway MongoDB, and skips the test without one (short mode, no Docker)
+func mongoRepository(t *testing.T) repository.TaskRepository {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping MongoDB integration test: short mode")
+	}
+	ctx := context.Background()
+	uri, stop, err := testutil.StartMongo(ctx)
+	if err != nil {
+		t.Skipf("Skipping MongoDB integration test: %v", err)
+	}
+	t.Cleanup(stop)
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("Failed to connect to the test MongoDB: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Disconnect(context.Background()) })
+	collection := testutil.IsolatedCollection(t, client.Database("todoapi").Collection("tasks"))
+	return repository.NewMongoTaskRepository(collection)
+}
+
+// repositories are the repositories every test here runs against
+var repositories = []struct {
+	name string
+	open func(t *testing.T) repository.TaskRepository
+}{
+	{"memory", func(*testing.T) repository.TaskRepository { return repository.NewMemoryTaskRepository() }},
+	{"mongo", mongoRepository},
+}
+
+// TestUpdate_NoChanges tests that an update with nothing to change reads the
+// task back without writing it: the version, and so every ETag, stays the same
+func TestUpdate_NoChanges(t *testing.T) {
+	for _, tt := range repositories {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			repo := tt.open(t)
+			task := testutil.CreateTasks(t, repo, testutil.NewTask(testutil.WithTitle("Buy milk")))[0]
+			before, err := repo.Version(ctx)
+			if err != nil {
+				t.Fatalf("Version returned error: %v", err)
+			}
+
+			// Act
+			got, err := repo.Update(ctx, task.ID, repository.TaskChanges{})
+
+			// Assert
+			if err != nil || got == nil || got.ID != task.ID || got.Title != "Buy milk" || got.Completed {
+				t.Fatalf("Expected the task as it was, got %+v (err %v)", got, err)
+			}
+			if after, _ := repo.Version(ctx); after != before {
+				t.Errorf("Expected version %d to stay, got %d", before, after)
+			}
+			if _, err := repo.Update(ctx, models.GenerateTaskID(), repository.TaskChanges{}); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound for a missing task, got %v", err)
+			}
+		})
+	}
+
+	t.Log("✅ Empty updates write nothing")
+}
+
+// TestList_Pages tests that a page is read on its own, oldest first, and
+// that only whole lists (and pages bigger than MaxResults) are capped
+func TestList_Pages(t *testing.T) {
+	for _, tt := range repositories {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: 5 tasks, 2 of them done, and lists capped at 3
+			ctx := context.Background()
+			repo := tt.open(t)
+			repo.(interface{ SetLimits(repository.Limits) }).SetLimits(repository.Limits{MaxResults: 3})
+			var created []models.Task
+			for i := range 5 {
+				opts := []testutil.TaskOption{testutil.WithTitle(fmt.Sprintf("Task %d", i))}
+				if i < 2 {
+					opts = append(opts, testutil.Completed())
+				}
+				created = append(created, testutil.CreateTasks(t, repo, testutil.NewTask(opts...))...)
+			}
+
+			// Act
+			_, wholeErr := repo.List(ctx, nil, repository.Fields{}, repository.Page{})
+			second, pageErr := repo.List(ctx, nil, repository.Fields{}, repository.Page{Offset: 2, Limit: 2})
+			past, _ := repo.List(ctx, nil, repository.Fields{}, repository.Page{Offset: 10, Limit: 2})
+			_, bigErr := repo.List(ctx, nil, repository.Fields{}, repository.Page{Limit: 4})
+			all, _ := repo.Count(ctx, nil)
+			open, _ := repo.Count(ctx, testutil.Ptr(false))
+
+			// Assert
+			if !errors.Is(wholeErr, repository.ErrTooManyResults) || !errors.Is(bigErr, repository.ErrTooManyResults) {
+				t.Errorf("Expected ErrTooManyResults for the whole list and a page of 4, got %v and %v", wholeErr, bigErr)
+			}
+			if pageErr != nil || len(second) != 2 || second[0].ID != created[2].ID || second[1].ID != created[3].ID {
+				t.Errorf("Expected tasks 2 and 3, got %+v (err %v)", second, pageErr)
+			}
+			if len(past) != 0 {
+				t.Errorf("Expected no tasks past the end, got %d", len(past))
+			}
+			if all != 5 || open != 3 {
+				t.Errorf("Expected counts of 5 and 3 open, got %d and %d", all, open)
+			}
+		})
+	}
+
+	t.Log("✅ Pages are read on their own")
+}
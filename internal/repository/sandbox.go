@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SandboxRepository is the task store of one sandbox API key
+// Integrators test against it without touching anyone's real tasks, and
+// empty it when they want to start over (POST /sandbox/reset).
+type SandboxRepository interface {
+	TaskRepository
+
+	// DeleteAll removes every task
+	DeleteAll(ctx context.Context) error
+}
+
+// Sandboxes hands out the task store of each sandbox API key
+type Sandboxes interface {
+	// For returns the store of the key; the same key always gets the same tasks
+	For(keyID string) SandboxRepository
+}
+
+// sandboxPrefix starts the name of every sandbox's collection
+const sandboxPrefix = "sandbox_tasks_"
+
+// MongoSandboxes keeps each sandbox in a collection of its own,
+// "sandbox_tasks_<key ID>", created by its first write
+type MongoSandboxes struct {
+	database *mongo.Database
+}
+
+// NewMongoSandboxes creates the sandboxes in database
+func NewMongoSandboxes(database *mongo.Database) *MongoSandboxes {
+	return &MongoSandboxes{database: database}
+}
+
+// For returns the repository on the key's collection
+func (s *MongoSandboxes) For(keyID string) SandboxRepository {
+	return NewMongoTaskRepository(s.database.Collection(sandboxPrefix + keyID))
+}
+
+// MemorySandboxes keeps each sandbox in a MemoryTaskRepository - use it in tests
+type MemorySandboxes struct {
+	mu    sync.Mutex
+	repos map[string]*MemoryTaskRepository
+}
+
+// NewMemorySandboxes creates sandboxes that start empty
+func NewMemorySandboxes() *MemorySandboxes {
+	return &MemorySandboxes{repos: make(map[string]*MemoryTaskRepository)}
+}
+
+// For returns the key's repository, creating it on first use
+func (s *MemorySandboxes) For(keyID string) SandboxRepository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo, ok := s.repos[keyID]
+	if !ok {
+		repo = NewMemoryTaskRepository()
+		s.repos[keyID] = repo
+	}
+	return repo
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"go-todo-api/internal/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ============================================================================
+// SHARED READS (SINGLEFLIGHT)
+// ============================================================================
+// When 50 clients ask for the full list at the same moment, 50 identical
+// queries hit MongoDB and return the same answer. ReadGroup lets the first
+// request run the query and hands its result to the other 49, which were
+// waiting for it. Unlike TaskCache nothing is kept afterwards: a request that
+// arrives once the query has finished runs a new one, so results are never
+// older than the query they came from.
+//
+// The shared query doesn't run on the first caller's context: if that client
+// hangs up, the ones still waiting shouldn't all get "context canceled".
+// Each caller still stops waiting when its own context ends.
+
+// sharedReadTimeout bounds a shared query, since it no longer has the first
+// caller's deadline (see middleware.DefaultRequestTimeout)
+const sharedReadTimeout = 15 * time.Second
+
+// ReadGroup tracks the List and Version queries in flight, shared by every
+// repository it wraps
+type ReadGroup struct {
+	group singleflight.Group
+}
+
+// NewReadGroup creates a group with no queries in flight
+func NewReadGroup() *ReadGroup {
+	return &ReadGroup{}
+}
+
+// Wrap returns a repository whose List and Version calls are shared with
+// identical ones already running
+func (g *ReadGroup) Wrap(inner TaskRepository) TaskRepository {
+	return &sharedTaskRepository{TaskRepository: inner, reads: g}
+}
+
+// share runs fn once for all concurrent callers with the same key
+func share[T any](ctx context.Context, g *ReadGroup, key string, fn func(context.Context) (T, error)) (T, bool, error) {
+	results := g.group.DoChan(key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedReadTimeout)
+		defer cancel()
+		return fn(ctx)
+	})
+
+	var zero T
+	select {
+	case res := <-results:
+		if res.Err != nil {
+			return zero, res.Shared, res.Err
+		}
+		return res.Val.(T), res.Shared, nil
+	case <-ctx.Done():
+		return zero, false, ctx.Err()
+	}
+}
+
+// sharedTaskRepository is the repository returned by ReadGroup.Wrap
+type sharedTaskRepository struct {
+	TaskRepository // Get, Stream, the counts and writes go straight through
+	reads          *ReadGroup
+}
+
+// List shares the query with identical ones in flight
+// Callers that shared a result get their own copy, so changing it can't
+// change another request's response.
+func (r *sharedTaskRepository) List(ctx context.Context, completed *bool, fields Fields, page Page) ([]models.Task, error) {
+	tasks, shared, err := share(ctx, r.reads, "list:"+listKey(completed, fields, page), func(ctx context.Context) ([]models.Task, error) {
+		return r.TaskRepository.List(ctx, completed, fields, page)
+	})
+	if shared {
+		tasks = slices.Clone(tasks)
+	}
+	return tasks, err
+}
+
+// Version shares the read with identical ones in flight
+func (r *sharedTaskRepository) Version(ctx context.Context) (int64, error) {
+	version, _, err := share(ctx, r.reads, "version", r.TaskRepository.Version)
+	return version, err
+}
@@ -0,0 +1,110 @@
+package repository_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/testutil"
+)
+
+// slowRepository holds every List call until release is closed, like a slow
+// MongoDB query, and counts how many reached it
+type slowRepository struct {
+	*repository.MemoryTaskRepository
+	release chan struct{}
+	lists   atomic.Int32
+}
+
+func (r *slowRepository) List(ctx context.Context, completed *bool, fields repository.Fields, page repository.Page) ([]models.Task, error) {
+	r.lists.Add(1)
+	select {
+	case <-r.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return r.MemoryTaskRepository.List(ctx, completed, fields, page)
+}
+
+func newSlowRepository(t *testing.T) *slowRepository {
+	inner := &slowRepository{MemoryTaskRepository: repository.NewMemoryTaskRepository(), release: make(chan struct{})}
+	testutil.CreateTasks(t, inner, testutil.NewTask())
+	return inner
+}
+
+// TestReadGroup_SharesConcurrentLists tests that a burst of identical lists
+// runs one query and everybody gets the result
+func TestReadGroup_SharesConcurrentLists(t *testing.T) {
+	// Arrange
+	inner := newSlowRepository(t)
+	reads := repository.NewReadGroup()
+
+	// Act: 10 requests, each with a repository of its own like in the handlers
+	var wg sync.WaitGroup
+	results := make([][]models.Task, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = reads.Wrap(inner).List(context.Background(), nil, repository.Fields{}, repository.Page{})
+		}()
+	}
+	time.Sleep(50 * time.Millisecond) // let them all join the first query
+	close(inner.release)
+	wg.Wait()
+
+	// Assert
+	if got := inner.lists.Load(); got != 1 {
+		t.Errorf("Expected 1 query for 10 concurrent lists, got %d", got)
+	}
+	for i, tasks := range results {
+		if len(tasks) != 1 {
+			t.Errorf("Expected request %d to get the task, got %d tasks", i, len(tasks))
+		}
+	}
+
+	t.Logf("✅ 10 lists, %d query", inner.lists.Load())
+}
+
+// TestReadGroup_CallerHangsUp tests that the first caller going away doesn't
+// fail the query for the others waiting on it
+func TestReadGroup_CallerHangsUp(t *testing.T) {
+	// Arrange
+	inner := newSlowRepository(t)
+	reads := repository.NewReadGroup()
+
+	// Act: the first caller gives up while the query is running...
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := reads.Wrap(inner).List(first, nil, repository.Fields{}, repository.Page{})
+		firstErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	second := make(chan []models.Task, 1)
+	go func() {
+		tasks, _ := reads.Wrap(inner).List(context.Background(), nil, repository.Fields{}, repository.Page{})
+		second <- tasks
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	// Assert: ...and gets its own error at once
+	if err := <-firstErr; err != context.Canceled {
+		t.Errorf("Expected the first caller to get context.Canceled, got %v", err)
+	}
+
+	// The second still gets the result of the same query
+	close(inner.release)
+	if tasks := <-second; len(tasks) != 1 {
+		t.Errorf("Expected the second caller to get the task, got %d tasks", len(tasks))
+	}
+	if got := inner.lists.Load(); got != 1 {
+		t.Errorf("Expected 1 query, got %d", got)
+	}
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/models"
+)
+
+// ============================================================================
+// STALE-WHILE-REVALIDATE FOR THE COUNTS
+// ============================================================================
+// Dashboards refresh GET /stats every second, from many tabs at once.
+// StatsCache keeps the last counts as a snapshot:
+//   - younger than the TTL: answered from memory
+//   - older, but less than 30 TTLs old: still answered from memory, and one
+//     background refresh is started for the requests that come after
+//   - older than that, or none yet: read now, one read shared by every
+//     request waiting for it
+//
+// So however many dashboards poll, MongoDB sees at most one read per TTL,
+// and no request waits for it once there is a snapshot. The counts were
+// already up to a second behind (stats.Counter waits for changes to settle);
+// the snapshot's counted_at says how old they are.
+//
+// Like TaskCache it's per process, and writes don't clear it: the counts
+// catch up within the TTL.
+
+// statsMaxStaleFactor is how many TTLs old a snapshot may be and still be
+// served while it's refreshed; older ones are read again before answering
+const statsMaxStaleFactor = 30
+
+// StatsCache holds the counts snapshot, shared by every repository it wraps
+type StatsCache struct {
+	ttl   time.Duration
+	clock clock.Clock
+	reads ReadGroup // The read when there's no snapshot to serve
+
+	mu         sync.Mutex
+	snapshot   *models.TaskStats
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+// NewStatsCache creates an empty cache whose snapshot is fresh for ttl
+func NewStatsCache(ttl time.Duration, clk clock.Clock) *StatsCache {
+	return &StatsCache{ttl: ttl, clock: clock.OrReal(clk)}
+}
+
+// Wrap returns a repository whose Stats calls go through the cache
+func (c *StatsCache) Wrap(inner TaskRepository) TaskRepository {
+	return &cachedStatsRepository{TaskRepository: inner, cache: c}
+}
+
+// cachedStatsRepository is the repository returned by StatsCache.Wrap
+type cachedStatsRepository struct {
+	TaskRepository // Everything but Stats goes straight through
+	cache          *StatsCache
+}
+
+// Stats returns the snapshot, refreshing it as described above
+func (r *cachedStatsRepository) Stats(ctx context.Context) (models.TaskStats, error) {
+	c := r.cache
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	snapshot, age := c.snapshot, now.Sub(c.fetchedAt)
+	startRefresh := snapshot != nil && age >= c.ttl && age < c.ttl*statsMaxStaleFactor && !c.refreshing
+	if startRefresh {
+		c.refreshing = true
+	}
+	c.mu.Unlock()
+
+	switch {
+	case snapshot != nil && age < c.ttl:
+		return *snapshot, nil
+	case snapshot != nil && age < c.ttl*statsMaxStaleFactor:
+		if startRefresh {
+			go r.refresh(context.WithoutCancel(ctx))
+		}
+		return *snapshot, nil
+	}
+
+	stats, _, err := share(ctx, &c.reads, "stats", r.read)
+	return stats, err
+}
+
+// refresh replaces a stale snapshot in the background
+// If it fails the stale snapshot is kept, and the next request tries again.
+func (r *cachedStatsRepository) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, sharedReadTimeout)
+	defer cancel()
+	_, err := r.read(ctx)
+
+	r.cache.mu.Lock()
+	r.cache.refreshing = false
+	r.cache.mu.Unlock()
+	if err != nil {
+		logger.WithTrace(ctx).Warn("Failed to refresh the task counts", "error", err)
+	}
+}
+
+// read reads the counts and stores them as the snapshot
+func (r *cachedStatsRepository) read(ctx context.Context) (models.TaskStats, error) {
+	fetchedAt := r.cache.clock.Now()
+	stats, err := r.TaskRepository.Stats(ctx)
+	if err != nil {
+		return models.TaskStats{}, err
+	}
+
+	r.cache.mu.Lock()
+	if fetchedAt.After(r.cache.fetchedAt) || r.cache.snapshot == nil {
+		r.cache.snapshot = &stats
+		r.cache.fetchedAt = fetchedAt
+	}
+	r.cache.mu.Unlock()
+	return stats, nil
+}
@@ -0,0 +1,118 @@
+package repository_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/clock"
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+	"go-todo-api/internal/testutil"
+)
+
+// statsRepository counts the Stats calls that reach the store, holding each
+// one until gate lets it through
+type statsRepository struct {
+	*repository.MemoryTaskRepository
+	gate  chan struct{}
+	reads atomic.Int32
+}
+
+func (r *statsRepository) Stats(ctx context.Context) (models.TaskStats, error) {
+	r.reads.Add(1)
+	<-r.gate
+	return r.MemoryTaskRepository.Stats(ctx)
+}
+
+// waitForReads waits until n reads have reached the store
+func waitForReads(t *testing.T, inner *statsRepository, n int32) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for inner.reads.Load() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d reads, got %d", n, inner.reads.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestStatsCache_StaleWhileRevalidate tests that the counts come from the
+// snapshot, that a stale one is still served while it's refreshed, and that
+// a very old one is read again
+func TestStatsCache_StaleWhileRevalidate(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	inner := &statsRepository{MemoryTaskRepository: repository.NewMemoryTaskRepository(), gate: make(chan struct{})}
+	close(inner.gate)
+	testutil.CreateTasks(t, inner, testutil.NewTask())
+	repo := repository.NewStatsCache(time.Second, fake).Wrap(inner)
+
+	// Act + Assert: one read for three requests
+	for i := 0; i < 3; i++ {
+		if stats, err := repo.Stats(ctx); err != nil || stats.Total != 1 {
+			t.Fatalf("Expected 1 task, got %+v (err %v)", stats, err)
+		}
+	}
+	if got := inner.reads.Load(); got != 1 {
+		t.Fatalf("Expected 1 read, got %d", got)
+	}
+
+	// Stale: the old counts at once, and a refresh behind them
+	testutil.CreateTasks(t, inner, testutil.NewTask())
+	fake.Advance(2 * time.Second)
+	if stats, _ := repo.Stats(ctx); stats.Total != 1 {
+		t.Errorf("Expected the stale snapshot (1 task), got %d", stats.Total)
+	}
+	waitForReads(t, inner, 2)
+	deadline := time.Now().Add(2 * time.Second)
+	for stats, _ := repo.Stats(ctx); stats.Total != 2; stats, _ = repo.Stats(ctx) {
+		if time.Now().After(deadline) {
+			t.Fatal("The refreshed counts were never served")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Too old to serve: read before answering
+	testutil.CreateTasks(t, inner, testutil.NewTask())
+	fake.Advance(time.Minute)
+	if stats, _ := repo.Stats(ctx); stats.Total != 3 {
+		t.Errorf("Expected a fresh read (3 tasks), got %d", stats.Total)
+	}
+
+	t.Logf("✅ %d reads", inner.reads.Load())
+}
+
+// TestStatsCache_CoalescesFirstRead tests that requests arriving before
+// there's a snapshot share one read
+func TestStatsCache_CoalescesFirstRead(t *testing.T) {
+	// Arrange
+	inner := &statsRepository{MemoryTaskRepository: repository.NewMemoryTaskRepository(), gate: make(chan struct{})}
+	repo := repository.NewStatsCache(time.Second, nil).Wrap(inner)
+
+	// Act: 20 dashboards at once, while the read is slow
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.Stats(context.Background()); err != nil {
+				t.Errorf("Stats failed: %v", err)
+			}
+		}()
+	}
+	waitForReads(t, inner, 1)
+	time.Sleep(20 * time.Millisecond) // Let the others join the read
+	close(inner.gate)
+	wg.Wait()
+
+	// Assert
+	if got := inner.reads.Load(); got != 1 {
+		t.Errorf("Expected 1 read for 20 requests, got %d", got)
+	}
+
+	t.Log("✅ 20 requests, 1 read")
+}
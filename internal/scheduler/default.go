@@ -0,0 +1,16 @@
+package scheduler
+
+// defaultScheduler is the scheduler used by the package-level functions
+// startScheduler sets it after registering the periodic tasks
+var defaultScheduler *Scheduler
+
+// Init creates the default scheduler
+func Init(locker Locker) *Scheduler {
+	defaultScheduler = New(locker)
+	return defaultScheduler
+}
+
+// Default returns the scheduler created by Init (nil before Init)
+func Default() *Scheduler {
+	return defaultScheduler
+}
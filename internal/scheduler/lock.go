@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Locker decides which replica runs a tick of a task
+type Locker interface {
+	// Acquire returns true for exactly one caller per (task, tick)
+	Acquire(ctx context.Context, task string, tick time.Time) (bool, error)
+}
+
+// ============================================================================
+// MONGODB LOCKER
+// ============================================================================
+
+// lockTTL is how long run records are kept before MongoDB deletes them
+const lockTTL = 7 * 24 * time.Hour
+
+// MongoLocker claims a tick by inserting a document with a unique _id
+// The first replica's insert succeeds; the others get a duplicate key error.
+type MongoLocker struct {
+	collection *mongo.Collection
+}
+
+// NewMongoLocker creates a locker on the given collection
+func NewMongoLocker(collection *mongo.Collection) *MongoLocker {
+	return &MongoLocker{collection: collection}
+}
+
+// EnsureIndexes creates a TTL index so old run records are cleaned up
+func (l *MongoLocker) EnsureIndexes(ctx context.Context) error {
+	_, err := l.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(lockTTL.Seconds())),
+	})
+	return err
+}
+
+// Acquire inserts "<task>@<tick>"; true if this replica inserted it
+func (l *MongoLocker) Acquire(ctx context.Context, task string, tick time.Time) (bool, error) {
+	_, err := l.collection.InsertOne(ctx, bson.M{
+		"_id":        runID(task, tick),
+		"task":       task,
+		"tick":       tick.UTC(),
+		"created_at": time.Now().UTC(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ============================================================================
+// IN-MEMORY LOCKER
+// ============================================================================
+
+// MemoryLocker only coordinates goroutines in one process
+// Use it for a single replica or in tests
+type MemoryLocker struct {
+	mu   sync.Mutex
+	runs map[string]bool
+}
+
+// NewMemoryLocker creates an empty in-memory locker
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{runs: make(map[string]bool)}
+}
+
+// Acquire returns true the first time it sees a (task, tick)
+func (l *MemoryLocker) Acquire(ctx context.Context, task string, tick time.Time) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id := runID(task, tick)
+	if l.runs[id] {
+		return false, nil
+	}
+	l.runs[id] = true
+	return true, nil
+}
+
+// runID identifies one tick of a task, e.g. "trash-purge@2025-01-01T03:00:00Z"
+func runID(task string, tick time.Time) string {
+	return fmt.Sprintf("%s@%s", task, tick.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,284 @@
+// Package scheduler runs periodic tasks on a cron schedule
+// Features register a task once at startup, e.g.
+//
+//	scheduler.Register("trash-purge", "0 3 * * *", purgeTrash, scheduler.TaskOptions{})
+//
+// When several replicas run the API, every replica runs the scheduler but a
+// shared lock makes sure each tick of a task runs on only one of them.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"go-todo-api/internal/health"
+	"go-todo-api/internal/logger"
+)
+
+// TaskFunc is the work a periodic task does
+type TaskFunc func(ctx context.Context) error
+
+// TaskOptions tunes one task
+type TaskOptions struct {
+	// Jitter delays each run by a random amount in [0, Jitter) so replicas
+	// and tasks sharing a schedule don't all hit the database at once
+	Jitter time.Duration
+
+	// Timeout limits a single run (default 5m)
+	Timeout time.Duration
+}
+
+// TaskStats is what the scheduler knows about a task (see GET /admin/scheduler)
+type TaskStats struct {
+	Name         string
+	Schedule     string
+	Runs         int64 // Runs on this replica
+	Failures     int64 // Runs on this replica that returned an error
+	Skipped      int64 // Ticks another replica held the lock for
+	LastRun      time.Time
+	LastDuration time.Duration
+	LastError    string
+	NextRun      time.Time
+}
+
+// task is a registered task and its stats
+type task struct {
+	name     string
+	spec     string
+	schedule cron.Schedule
+	fn       TaskFunc
+	opts     TaskOptions
+	stats    TaskStats
+}
+
+// Scheduler runs registered tasks until Shutdown
+type Scheduler struct {
+	locker Locker
+
+	mu      sync.Mutex
+	tasks   map[string]*task
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	started bool
+	rand    *rand.Rand
+}
+
+// New creates a scheduler that coordinates replicas through locker
+func New(locker Locker) *Scheduler {
+	return &Scheduler{
+		locker: locker,
+		tasks:  make(map[string]*task),
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Register adds a task
+// spec is a standard 5-field cron expression ("*/15 * * * *") or a
+// descriptor like "@hourly" or "@every 10m". Tasks must be registered before Start.
+func (s *Scheduler) Register(name, spec string, fn TaskFunc, opts TaskOptions) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("scheduler: task %q: invalid schedule %q: %w", name, spec, err)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[name] = &task{
+		name:     name,
+		spec:     spec,
+		schedule: schedule,
+		fn:       fn,
+		opts:     opts,
+		stats:    TaskStats{Name: name, Schedule: spec},
+	}
+	return nil
+}
+
+// Start launches one goroutine per task
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	s.stop = make(chan struct{})
+
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.loop(t, s.stop)
+	}
+	logger.Log.Info("Scheduler started", slog.Int("tasks", len(s.tasks)))
+}
+
+// Shutdown stops scheduling new runs and waits for running tasks to finish
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return nil
+	}
+	s.started = false
+	close(s.stop)
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the stats of every task, sorted by name
+func (s *Scheduler) Stats() []TaskStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]TaskStats, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		stats = append(stats, t.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// HealthCheck reports the scheduler for /health/details
+// - down:     the scheduler isn't running
+// - degraded: the last run of at least one task failed
+// - ok:       otherwise
+func (s *Scheduler) HealthCheck(ctx context.Context) health.Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		return health.Result{State: health.StateDown, Message: "scheduler not running"}
+	}
+	for _, t := range s.tasks {
+		if t.stats.LastError != "" {
+			return health.Result{State: health.StateDegraded, Message: t.name + ": " + t.stats.LastError}
+		}
+	}
+	return health.Result{State: health.StateOK}
+}
+
+// loop waits for each tick of a task and runs it
+func (s *Scheduler) loop(t *task, stop <-chan struct{}) {
+	defer s.wg.Done()
+
+	for {
+		next := t.schedule.Next(time.Now())
+		s.mu.Lock()
+		t.stats.NextRun = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next) + s.jitter(t.opts.Jitter))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runOnce(t, next)
+	}
+}
+
+// jitter returns a random delay in [0, max)
+func (s *Scheduler) jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.rand.Int63n(int64(max)))
+}
+
+// runOnce runs the tick scheduled at tick if this replica wins its lock
+func (s *Scheduler) runOnce(t *task, tick time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.Timeout)
+	defer cancel()
+
+	// Every replica computes the same tick time from the cron schedule,
+	// so "<task>@<tick>" identifies the run across replicas
+	acquired, err := s.locker.Acquire(ctx, t.name, tick)
+	if err != nil {
+		logger.Log.Error("Scheduler lock failed", slog.String("task", t.name), slog.Any("error", err))
+		s.record(t, 0, fmt.Errorf("lock: %w", err))
+		return
+	}
+	if !acquired {
+		s.mu.Lock()
+		t.stats.Skipped++
+		s.mu.Unlock()
+		return
+	}
+
+	ctx, span := otel.Tracer("scheduler").Start(ctx, "task "+t.name)
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("scheduler.task", t.name),
+		attribute.String("scheduler.tick", tick.UTC().Format(time.RFC3339)),
+	)
+
+	start := time.Now()
+	err = callTask(ctx, t.fn)
+	elapsed := time.Since(start)
+	s.record(t, elapsed, err)
+
+	attrs := []any{
+		slog.String("task", t.name),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		logger.WithTrace(ctx).Error("Scheduled task failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	logger.WithTrace(ctx).Info("Scheduled task finished", attrs...)
+}
+
+// record updates the stats after a run
+func (s *Scheduler) record(t *task, elapsed time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t.stats.Runs++
+	t.stats.LastRun = time.Now()
+	t.stats.LastDuration = elapsed
+	t.stats.LastError = ""
+	if err != nil {
+		t.stats.Failures++
+		t.stats.LastError = err.Error()
+	}
+}
+
+// callTask runs fn, turning a panic into an error
+func callTask(ctx context.Context, fn TaskFunc) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return fn(ctx)
+}
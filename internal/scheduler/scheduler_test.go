@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/logger"
+)
+
+// TestRunOnce_OneReplicaPerTick tests that two replicas sharing a lock run a tick only once
+func TestRunOnce_OneReplicaPerTick(t *testing.T) {
+	// Arrange: two schedulers (replicas) with the same task and a shared locker
+	logger.Init()
+	locker := NewMemoryLocker()
+	runs := 0
+	purge := func(ctx context.Context) error { runs++; return nil }
+
+	replicaA, replicaB := New(locker), New(locker)
+	for _, s := range []*Scheduler{replicaA, replicaB} {
+		if err := s.Register("trash-purge", "0 3 * * *", purge, TaskOptions{}); err != nil {
+			t.Fatalf("Register returned error: %v", err)
+		}
+	}
+	tick := time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+
+	// Act
+	replicaA.runOnce(replicaA.tasks["trash-purge"], tick)
+	replicaB.runOnce(replicaB.tasks["trash-purge"], tick)
+
+	// Assert
+	if runs != 1 {
+		t.Errorf("Expected the tick to run once, ran %d times", runs)
+	}
+	if got := replicaB.Stats()[0]; got.Skipped != 1 || got.Runs != 0 {
+		t.Errorf("Expected replica B to skip the tick, got %+v", got)
+	}
+
+	t.Logf("✅ Tick ran once across 2 replicas")
+}
+
+// TestRunOnce_RecordsFailure tests that a failing task shows up in stats and health
+func TestRunOnce_RecordsFailure(t *testing.T) {
+	// Arrange
+	logger.Init()
+	s := New(NewMemoryLocker())
+	_ = s.Register("digest-email", "@hourly", func(ctx context.Context) error {
+		return errors.New("smtp unavailable")
+	}, TaskOptions{})
+	s.Start()
+	defer s.Shutdown(context.Background())
+
+	// Act
+	s.runOnce(s.tasks["digest-email"], time.Now().Truncate(time.Hour))
+
+	// Assert
+	stats := s.Stats()[0]
+	if stats.Failures != 1 || stats.LastError != "smtp unavailable" {
+		t.Errorf("Expected 1 failure with the error kept, got %+v", stats)
+	}
+	if res := s.HealthCheck(context.Background()); res.State != "degraded" {
+		t.Errorf("Expected health 'degraded', got '%s'", res.State)
+	}
+}
+
+// TestRegister_InvalidSchedule tests that a bad cron expression is rejected at startup
+func TestRegister_InvalidSchedule(t *testing.T) {
+	s := New(NewMemoryLocker())
+
+	if err := s.Register("broken", "every tuesday", func(ctx context.Context) error { return nil }, TaskOptions{}); err == nil {
+		t.Error("Expected an error for schedule 'every tuesday'")
+	}
+}
@@ -0,0 +1,193 @@
+// Package server starts the HTTP(S) listeners for the API
+// It picks plain HTTP, HTTPS with a certificate file, or HTTPS with
+// Let's Encrypt (autocert) depending on the config, so small self-hosted
+// deployments don't need a reverse proxy in front of the API.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"go-todo-api/internal/config"
+	"go-todo-api/internal/logger"
+)
+
+// ListenAndServe serves handler according to cfg and blocks until the main
+// listener fails or ctx is cancelled (e.g. on SIGTERM)
+// With TLS on, a second plain HTTP listener redirects to HTTPS (and answers
+// Let's Encrypt HTTP-01 challenges when autocert is used).
+//
+// Once the port is bound it logs a "Server ready" line with event=ready,
+// which log pipelines and deploy scripts can wait for. A port that's already
+// in use is returned straight away instead.
+//
+// On cancellation the server stops accepting connections and waits up to
+// Limits.ShutdownTimeout for in-flight requests, then returns nil.
+func ListenAndServe(ctx context.Context, cfg config.Server, handler http.Handler) error {
+	srv := newHTTPServer(cfg.Addr(), handler, cfg.Limits)
+	var redirectSrv *http.Server
+
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	if !cfg.TLS.Enabled() {
+		// --------------------------------------------------------------------
+		// PLAIN HTTP
+		// --------------------------------------------------------------------
+		go func() { errCh <- srv.Serve(listener) }()
+	} else {
+		redirectSrv = startTLS(cfg, srv, listener, errCh)
+	}
+
+	logger.Log.Info("Server ready",
+		slog.String("event", "ready"),
+		slog.String("addr", listener.Addr().String()),
+		slog.String("url", cfg.URL()),
+		slog.Bool("tls", cfg.TLS.Enabled()),
+	)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	// ------------------------------------------------------------------------
+	// GRACEFUL SHUTDOWN
+	// ------------------------------------------------------------------------
+	logger.Log.Info("Shutting down HTTP server", slog.Duration("timeout", cfg.Limits.ShutdownTimeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Limits.ShutdownTimeout)
+	defer cancel()
+
+	if redirectSrv != nil {
+		_ = redirectSrv.Shutdown(shutdownCtx)
+	}
+	return srv.Shutdown(shutdownCtx)
+}
+
+// ListenAndServeAdmin serves the admin handler on cfg.AdminAddr() over plain
+// HTTP and blocks until the listener fails or ctx is cancelled, like
+// ListenAndServe. It returns nil straight away when the admin listener is off.
+func ListenAndServeAdmin(ctx context.Context, cfg config.Server, handler http.Handler) error {
+	addr := cfg.AdminAddr()
+	if addr == "" {
+		return nil
+	}
+
+	srv := newHTTPServer(addr, handler, cfg.Limits)
+	srv.WriteTimeout = 0 // CPU profiles stream for 30s by default
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	logger.Log.Info("Admin listener ready", slog.String("addr", listener.Addr().String()))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(listener) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Limits.ShutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// startTLS configures srv for HTTPS and starts it on listener (and the
+// redirect listener). Errors from the HTTPS listener are sent to errCh.
+// Returns the redirect server, or nil when the redirect is off.
+func startTLS(cfg config.Server, srv *http.Server, listener net.Listener, errCh chan<- error) *http.Server {
+
+	// ------------------------------------------------------------------------
+	// HTTPS
+	// ------------------------------------------------------------------------
+	srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	redirect := redirectToHTTPS(cfg.Port)
+
+	if cfg.TLS.Autocert() {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLS.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.TLS.AutocertCacheDir),
+			Email:      cfg.TLS.AutocertEmail,
+		}
+
+		// manager.TLSConfig() fetches certificates on demand and also
+		// answers the TLS-ALPN-01 challenge on the HTTPS port
+		srv.TLSConfig = manager.TLSConfig()
+		srv.TLSConfig.MinVersion = tls.VersionTLS12
+
+		// HTTP-01 challenges arrive on port 80, everything else is redirected
+		redirect = manager.HTTPHandler(redirect)
+	}
+
+	var redirectSrv *http.Server
+	if addr := cfg.RedirectAddr(); addr != "" {
+		redirectSrv = newHTTPServer(addr, redirect, cfg.Limits)
+		go serveRedirect(redirectSrv)
+	}
+
+	// Empty file names make ServeTLS use srv.TLSConfig (autocert)
+	go func() { errCh <- srv.ServeTLS(listener, cfg.TLS.CertFile, cfg.TLS.KeyFile) }()
+
+	return redirectSrv
+}
+
+// newHTTPServer creates an http.Server with our timeouts and header limit
+// http.ListenAndServe() would use Go's defaults, which never time out
+func newHTTPServer(addr string, handler http.Handler, limits config.Limits) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: limits.ReadHeaderTimeout,
+		ReadTimeout:       limits.ReadTimeout,
+		WriteTimeout:      limits.WriteTimeout,
+		IdleTimeout:       limits.IdleTimeout,
+		MaxHeaderBytes:    limits.MaxHeaderBytes,
+	}
+}
+
+// serveRedirect runs the plain HTTP listener
+// A failure here is logged but doesn't stop the HTTPS server
+func serveRedirect(srv *http.Server) {
+	logger.Log.Info("HTTP → HTTPS redirect listening", slog.String("addr", srv.Addr))
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Log.Error("HTTP redirect listener stopped",
+			slog.String("addr", srv.Addr),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// redirectToHTTPS sends every request to the same URL on https://
+// httpsPort is added to the host unless it's the default 443
+func redirectToHTTPS(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h // Drop the plain HTTP port, e.g. example.com:80 → example.com
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-todo-api/internal/config"
+	"go-todo-api/internal/logger"
+)
+
+// TestRedirectToHTTPS tests that plain HTTP requests are sent to the HTTPS URL
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsPort int
+		host      string
+		target    string
+		expected  string
+	}{
+		{"default port", 443, "todo.example.com", "/tasks?completed=true", "https://todo.example.com/tasks?completed=true"},
+		{"strips http port", 443, "todo.example.com:80", "/health", "https://todo.example.com/health"},
+		{"custom https port", 8443, "localhost:8080", "/docs", "https://localhost:8443/docs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+
+			// Act
+			redirectToHTTPS(tt.httpsPort).ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != http.StatusMovedPermanently {
+				t.Errorf("Expected status 301, got %d", rec.Code)
+			}
+			if got := rec.Header().Get("Location"); got != tt.expected {
+				t.Errorf("Expected Location '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestListenAndServe_PortInUse tests that a busy port is reported straight away
+// (before the ready event) instead of the server looking like it started
+func TestListenAndServe_PortInUse(t *testing.T) {
+	// Arrange
+	logger.Init()
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to open a listener: %v", err)
+	}
+	defer busy.Close()
+
+	cfg := config.Server{
+		Host: "127.0.0.1",
+		Port: busy.Addr().(*net.TCPAddr).Port,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Act
+	err = ListenAndServe(ctx, cfg, http.NotFoundHandler())
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error for a port that's already in use, got nil")
+	}
+
+	t.Logf("✅ Port %d in use: %v", cfg.Port, err)
+}
@@ -0,0 +1,259 @@
+// Package settings keeps the organization-wide settings admins manage at
+// /admin/settings: defaults and limits that apply to every user, unlike
+// internal/profile, which is per user.
+//
+// Every request that needs them would otherwise read MongoDB, so Settings
+// caches them in-process. Save replaces the cached copy on the instance that
+// saved; other instances (and Lambda) pick the change up when their copy is
+// older than the TTL.
+package settings
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-todo-api/internal/clock"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned by a Store that has no settings saved yet
+var ErrNotFound = errors.New("settings: not found")
+
+// DefaultTTL is how long an instance uses its cached settings
+const DefaultTTL = 30 * time.Second
+
+// documentID is the _id of the one settings document
+const documentID = "org"
+
+// Org are the organization-wide settings
+// The zero value is what an organization that never saved any gets: no
+// restrictions.
+type Org struct {
+	ReminderLeadMinutes int               `bson:"reminder_lead_minutes,omitempty"` // How long before a task is due reminders go out (0 = at the due time)
+	AllowedTags         []string          `bson:"allowed_tags,omitempty"`          // Tags tasks may carry (empty = any)
+	RetentionDays       int               `bson:"retention_days,omitempty"`        // How long archived tasks are kept (0 = forever)
+	AllowedEmailDomains []string          `bson:"allowed_email_domains,omitempty"` // Domains notification emails may go to (empty = any)
+	Anomaly             AnomalyThresholds `bson:"anomaly,omitempty"`               // When a caller's usage is unusual enough to alert on
+	UpdatedAt           time.Time         `bson:"updated_at"`
+	UpdatedBy           string            `bson:"updated_by,omitempty"` // User ID of the admin who saved them
+}
+
+// AnomalyThresholds decide when a caller's usage is unusual enough to alert
+// on (see internal/anomaly); fields left at 0 use its defaults
+type AnomalyThresholds struct {
+	SpikeFactor  int  `bson:"spike_factor,omitempty"`  // Requests in a minute, as a multiple of the caller's usual count
+	MinRequests  int  `bson:"min_requests,omitempty"`  // Fewer requests in a minute never alert, however quiet the caller usually is
+	MaxDeletions int  `bson:"max_deletions,omitempty"` // Tasks deleted in a minute
+	Off          bool `bson:"off,omitempty"`           // No alerts
+}
+
+// EmailAllowed reports whether address may receive notification emails
+// The domain is matched without case; a listed domain also allows its
+// subdomains ("example.com" allows "eu.example.com").
+func (o Org) EmailAllowed(address string) bool {
+	if len(o.AllowedEmailDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndexByte(address, '@')
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(address[at+1:])
+	return slices.ContainsFunc(o.AllowedEmailDomains, func(allowed string) bool {
+		allowed = strings.ToLower(allowed)
+		return domain == allowed || strings.HasSuffix(domain, "."+allowed)
+	})
+}
+
+// Store persists the settings
+type Store interface {
+	// Get returns the saved settings, or ErrNotFound
+	Get(ctx context.Context) (*Org, error)
+
+	// Save replaces the settings
+	Save(ctx context.Context, o *Org) error
+}
+
+// ============================================================================
+// SETTINGS
+// ============================================================================
+
+// Settings reads and updates the organization's settings through a cache
+type Settings struct {
+	store Store
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu       sync.Mutex
+	cached   Org
+	loadedAt time.Time // Zero when nothing is cached
+}
+
+// New creates a Settings on a store; ttl 0 means DefaultTTL, a nil clock the real one
+func New(store Store, ttl time.Duration, c clock.Clock) *Settings {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Settings{store: store, ttl: ttl, clock: clock.OrReal(c)}
+}
+
+// Get returns the settings, from the cache while it's fresh
+// Settings never saved are the zero Org.
+func (s *Settings) Get(ctx context.Context) (Org, error) {
+	if org, ok := s.fresh(); ok {
+		return org, nil
+	}
+
+	org, err := s.store.Get(ctx)
+	if errors.Is(err, ErrNotFound) {
+		org, err = &Org{}, nil
+	}
+	if err != nil {
+		return Org{}, err
+	}
+	s.remember(*org)
+	return *org, nil
+}
+
+// Save stores the settings and replaces this instance's cached copy
+func (s *Settings) Save(ctx context.Context, o Org) (Org, error) {
+	o.UpdatedAt = s.clock.Now().UTC()
+	if err := s.store.Save(ctx, &o); err != nil {
+		return Org{}, err
+	}
+	s.remember(o)
+	return o, nil
+}
+
+// Invalidate drops the cached copy, so the next Get reads the store
+func (s *Settings) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadedAt = time.Time{}
+}
+
+// fresh returns the cached copy while it's younger than the TTL
+func (s *Settings) fresh() (Org, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadedAt.IsZero() || s.clock.Now().Sub(s.loadedAt) >= s.ttl {
+		return Org{}, false
+	}
+	return s.cached, true
+}
+
+// remember caches o as of now
+func (s *Settings) remember(o Org) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cached = o
+	s.loadedAt = s.clock.Now()
+}
+
+// ============================================================================
+// MONGODB STORE
+// ============================================================================
+
+// MongoStore keeps the settings in one document of a MongoDB collection
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store on the given collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// Get returns the saved settings
+func (s *MongoStore) Get(ctx context.Context) (*Org, error) {
+	var o Org
+	err := s.collection.FindOne(ctx, bson.M{"_id": documentID}).Decode(&o)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Save upserts the settings document
+func (s *MongoStore) Save(ctx context.Context, o *Org) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": documentID}, o, options.Replace().SetUpsert(true))
+	return err
+}
+
+// ============================================================================
+// IN-MEMORY STORE
+// ============================================================================
+
+// MemoryStore keeps the settings in memory - use it in tests
+type MemoryStore struct {
+	mu    sync.Mutex
+	org   *Org
+	reads int
+}
+
+// NewMemoryStore creates a store with nothing saved
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Get returns the saved settings
+func (s *MemoryStore) Get(ctx context.Context) (*Org, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reads++
+	if s.org == nil {
+		return nil, ErrNotFound
+	}
+	o := *s.org
+	o.AllowedTags = slices.Clone(o.AllowedTags)
+	o.AllowedEmailDomains = slices.Clone(o.AllowedEmailDomains)
+	return &o, nil
+}
+
+// Reads returns how many times Get was called, for testing the cache
+func (s *MemoryStore) Reads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reads
+}
+
+// Save replaces the settings
+func (s *MemoryStore) Save(ctx context.Context, o *Org) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved := *o
+	saved.AllowedTags = slices.Clone(o.AllowedTags)
+	saved.AllowedEmailDomains = slices.Clone(o.AllowedEmailDomains)
+	s.org = &saved
+	return nil
+}
+
+// ============================================================================
+// DEFAULT SETTINGS
+// ============================================================================
+
+// defaultSettings is used by the handlers
+// Its cached copy lives as long as it does; changing the settings goes
+// through Save, which refreshes it
+var defaultSettings *Settings
+
+// Init sets the default settings; Init(nil) turns them off (no restrictions)
+func Init(s *Settings) *Settings {
+	defaultSettings = s
+	return s
+}
+
+// Default returns the settings set by Init (nil before Init)
+func Default() *Settings {
+	return defaultSettings
+}
@@ -0,0 +1,82 @@
+package settings
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-todo-api/internal/clock"
+)
+
+// TestSettings_Cache tests that reads are cached for the TTL, and that a
+// save replaces the cached copy at once
+func TestSettings_Cache(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	store := NewMemoryStore()
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	s := New(store, time.Minute, fake)
+
+	// Act + Assert: nothing saved yet is the zero settings, read once
+	org, err := s.Get(ctx)
+	if err != nil || org.RetentionDays != 0 || len(org.AllowedEmailDomains) != 0 {
+		t.Fatalf("Expected empty settings, got %+v, %v", org, err)
+	}
+	_, _ = s.Get(ctx)
+	if store.Reads() != 1 {
+		t.Errorf("Expected 1 store read while cached, got %d", store.Reads())
+	}
+
+	// Saved here: visible at once, without a read
+	if _, err := s.Save(ctx, Org{RetentionDays: 90}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if org, _ := s.Get(ctx); org.RetentionDays != 90 || org.UpdatedAt.IsZero() || store.Reads() != 1 {
+		t.Errorf("Expected the saved settings from the cache, got %+v after %d reads", org, store.Reads())
+	}
+
+	// Saved by another instance: seen once the TTL has passed
+	_ = store.Save(ctx, &Org{RetentionDays: 30})
+	if org, _ := s.Get(ctx); org.RetentionDays != 90 {
+		t.Errorf("Expected the cached 90 days before the TTL, got %d", org.RetentionDays)
+	}
+	fake.Advance(time.Minute)
+	if org, _ := s.Get(ctx); org.RetentionDays != 30 {
+		t.Errorf("Expected the other instance's 30 days after the TTL, got %d", org.RetentionDays)
+	}
+
+	// Invalidate forces a read
+	s.Invalidate()
+	_, _ = s.Get(ctx)
+	if store.Reads() != 3 {
+		t.Errorf("Expected a read after Invalidate, got %d reads", store.Reads())
+	}
+
+	t.Log("✅ Settings cached, replaced and invalidated")
+}
+
+// TestOrg_EmailAllowed tests matching addresses against the allowed domains
+func TestOrg_EmailAllowed(t *testing.T) {
+	org := Org{AllowedEmailDomains: []string{"example.com"}}
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{"alice@example.com", true},
+		{"alice@EXAMPLE.com", true},
+		{"bob@eu.example.com", true},
+		{"eve@badexample.com", false},
+		{"eve@example.com.evil.org", false},
+		{"no-at-sign", false},
+	}
+	for _, tt := range tests {
+		if got := org.EmailAllowed(tt.address); got != tt.want {
+			t.Errorf("EmailAllowed(%q) = %v, want %v", tt.address, got, tt.want)
+		}
+	}
+	if !(Org{}).EmailAllowed("anyone@anywhere.org") {
+		t.Error("Expected any address allowed without a domain list")
+	}
+
+	t.Log("✅ Email domains matched")
+}
@@ -0,0 +1,180 @@
+// Package stats keeps the task counts behind GET /stats up to date
+// A Counter watches the tasks collection's change stream and recounts the
+// tasks whenever something changed, storing the result in the "stats"
+// collection. GET /stats then reads one document instead of aggregating over
+// every task on every request:
+//
+//	counter := stats.NewCounter(database.GetCollection(), stats.Options{})
+//	counter.Start()
+//	defer counter.Shutdown(ctx)
+//
+// Change streams need a replica set (MongoDB Atlas always is one). On a
+// standalone server the Counter logs a warning and stops, and GET /stats
+// counts the tasks itself on every request - correct, only slower.
+//
+// Every replica running the API runs a Counter. They all write the same
+// numbers, so that's harmless; the counts are just refreshed a bit more often.
+package stats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/repository"
+)
+
+// Options tunes a Counter
+type Options struct {
+	// Debounce is how long to wait after a change before recounting, so a
+	// burst of changes (an import, a bulk update) costs one recount (default 1s)
+	Debounce time.Duration
+
+	// RetryAfter is how long to wait before watching again after the change
+	// stream failed, e.g. during a replica set election (default 5s)
+	RetryAfter time.Duration
+}
+
+// withDefaults fills in the zero fields
+func (o Options) withDefaults() Options {
+	if o.Debounce <= 0 {
+		o.Debounce = time.Second
+	}
+	if o.RetryAfter <= 0 {
+		o.RetryAfter = 5 * time.Second
+	}
+	return o
+}
+
+// Counter recounts the tasks whenever the change stream reports a change
+type Counter struct {
+	tasks *mongo.Collection
+	repo  *repository.MongoTaskRepository
+	opts  Options
+
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// NewCounter creates a counter for the tasks in collection
+// Its recounts aren't bound by repository.Limits: they run in the
+// background, and are what keeps GET /stats from counting on every request.
+func NewCounter(collection *mongo.Collection, opts Options) *Counter {
+	repo := repository.NewMongoTaskRepository(collection)
+	repo.SetLimits(repository.Limits{})
+	return &Counter{
+		tasks: collection,
+		repo:  repo,
+		opts:  opts.withDefaults(),
+	}
+}
+
+// Start begins watching in the background
+func (c *Counter) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.done.Add(1)
+	go func() {
+		defer c.done.Done()
+		c.run(ctx)
+	}()
+}
+
+// Shutdown stops watching and waits for a recount in progress to finish
+func (c *Counter) Shutdown(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+
+	finished := make(chan struct{})
+	go func() {
+		c.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run watches until ctx is cancelled, starting again after failures
+func (c *Counter) run(ctx context.Context) {
+	for {
+		err := c.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Error 40573: "The $changeStream stage is only supported on replica sets"
+		var serverErr mongo.ServerError
+		if errors.As(err, &serverErr) && serverErr.HasErrorCode(40573) {
+			logger.Log.Warn("Task counts not materialized: change streams need a replica set; GET /stats will count on every request")
+			return
+		}
+
+		logger.Log.Warn("Task change stream failed, watching again shortly",
+			"error", err, "retry_after", c.opts.RetryAfter.String())
+		if !sleep(ctx, c.opts.RetryAfter) {
+			return
+		}
+	}
+}
+
+// watch recounts once, then again after every burst of changes
+func (c *Counter) watch(ctx context.Context) error {
+	// Only the fact that something changed matters, not what changed
+	stream, err := c.tasks.Watch(ctx, mongo.Pipeline{})
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	// Tasks may have changed while nobody was watching
+	c.recount(ctx)
+
+	for stream.Next(ctx) {
+		if !sleep(ctx, c.opts.Debounce) {
+			return ctx.Err()
+		}
+		// Changes that arrived while waiting are covered by the same recount
+		for stream.TryNext(ctx) {
+		}
+		if err := stream.Err(); err != nil {
+			return err
+		}
+		c.recount(ctx)
+	}
+	return stream.Err()
+}
+
+// recount stores fresh counts, logging (not returning) failures: the next
+// change tries again, and GET /stats still has the previous counts meanwhile
+func (c *Counter) recount(ctx context.Context) {
+	stats, err := c.repo.RecountStats(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Log.Warn("Failed to recount tasks", "error", err)
+		}
+		return
+	}
+	logger.Log.Debug("Recounted tasks", "open", stats.Open, "completed", stats.Completed)
+}
+
+// sleep waits for d, or returns false if ctx ends first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
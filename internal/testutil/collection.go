@@ -0,0 +1,45 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// isolatedCount makes database names unique within one test binary
+var isolatedCount atomic.Int64
+
+// IsolatedCollection returns an empty collection that only this test uses
+// It lives in a database of its own on the same server as base, which is
+// dropped when the test ends. Tests using it can call t.Parallel() and never
+// see (or delete) anyone else's data.
+func IsolatedCollection(t testing.TB, base *mongo.Collection) *mongo.Collection {
+	t.Helper()
+
+	// MongoDB database names are at most 63 bytes and can't contain . / \ " $ or spaces
+	name := fmt.Sprintf("test_%d_%d_%s", time.Now().UnixNano(), isolatedCount.Add(1), t.Name())
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+	if len(name) > 63 {
+		name = name[:63]
+	}
+
+	db := base.Database().Client().Database(name)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := db.Drop(ctx); err != nil {
+			t.Logf("drop test database %s: %v", name, err)
+		}
+	})
+	return db.Collection(base.Name())
+}
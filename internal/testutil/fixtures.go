@@ -0,0 +1,120 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go-todo-api/internal/models"
+	"go-todo-api/internal/repository"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ============================================================================
+// TASK BUILDER
+// ============================================================================
+// NewTask builds a task with fixed defaults, changed with options:
+//
+//	testutil.NewTask()                                        // "Test task", not completed
+//	testutil.NewTask(testutil.WithTitle("Buy milk"), testutil.Completed())
+//	testutil.NewTask(testutil.WithID(testutil.TaskID(1)))     // 000000000000000000000001
+//
+// The ID is left empty unless set, so the store assigns it (see InsertTasks
+// and CreateTasks, which return the tasks with their IDs).
+
+// TaskOption changes one field of a task built by NewTask
+type TaskOption func(*models.Task)
+
+// NewTask builds a task: "Test task", a short description, not completed
+func NewTask(opts ...TaskOption) models.Task {
+	task := models.Task{
+		Title:       "Test task",
+		Description: "Created by a test",
+	}
+	for _, opt := range opts {
+		opt(&task)
+	}
+	return task
+}
+
+// WithID sets the task's ID
+func WithID(id models.TaskID) TaskOption {
+	return func(t *models.Task) { t.ID = id }
+}
+
+// WithTitle sets the task's title
+func WithTitle(title string) TaskOption {
+	return func(t *models.Task) { t.Title = title }
+}
+
+// WithDescription sets the task's description
+func WithDescription(description string) TaskOption {
+	return func(t *models.Task) { t.Description = description }
+}
+
+// Completed marks the task as done
+func Completed() TaskOption {
+	return func(t *models.Task) { t.Completed = true }
+}
+
+// TaskID returns a fixed ObjectID for n, e.g. TaskID(1) = 000000000000000000000001
+// Use it where a test needs the same ID every run (or an ID that doesn't exist)
+func TaskID(n int) models.TaskID {
+	return models.MustParseTaskID(fmt.Sprintf("%024x", n))
+}
+
+// ============================================================================
+// HANDLER INPUTS
+// ============================================================================
+
+// CreateTaskInput builds the input for handlers.CreateTask from a task's title and description
+func CreateTaskInput(opts ...TaskOption) *models.CreateTaskInput {
+	task := NewTask(opts...)
+	input := &models.CreateTaskInput{}
+	input.Body.Title = task.Title
+	input.Body.Description = task.Description
+	return input
+}
+
+// Ptr returns a pointer to v, for the optional fields of UpdateTaskInput:
+//
+//	input.Body.Completed = testutil.Ptr(true)
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// ============================================================================
+// INSERT HELPERS
+// ============================================================================
+
+// InsertTasks writes tasks straight into a MongoDB collection and returns
+// them with their IDs (new ones for tasks built without WithID)
+func InsertTasks(t testing.TB, collection *mongo.Collection, tasks ...models.Task) []models.Task {
+	t.Helper()
+	tasks = append([]models.Task(nil), tasks...)
+	docs := make([]any, len(tasks))
+	for i := range tasks {
+		if tasks[i].ID.IsZero() {
+			tasks[i].ID = models.GenerateTaskID()
+		}
+		docs[i] = tasks[i]
+	}
+	if _, err := collection.InsertMany(context.Background(), docs); err != nil {
+		t.Fatalf("Failed to insert test tasks: %v", err)
+	}
+	return tasks
+}
+
+// CreateTasks adds tasks through a repository (e.g. the in-memory one) and
+// returns them with their IDs
+func CreateTasks(t testing.TB, repo repository.TaskRepository, tasks ...models.Task) []models.Task {
+	t.Helper()
+	tasks = append([]models.Task(nil), tasks...)
+	for i := range tasks {
+		if err := repo.Create(context.Background(), &tasks[i]); err != nil {
+			t.Fatalf("Failed to create test task %q: %v", tasks[i].Title, err)
+		}
+	}
+	return tasks
+}
@@ -0,0 +1,69 @@
+// Package testutil has helpers shared by the tests of several packages
+// It is only imported from _test.go files, so none of it ends up in the binary.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	// MongoURIEnv points the integration tests at an existing, throwaway
+	// MongoDB (e.g. a CI service container) instead of starting one
+	// Never set it to a database whose data you want to keep
+	MongoURIEnv = "TEST_MONGO_URI"
+
+	// MongoImage is the image started for the integration tests
+	MongoImage = "mongo:7"
+
+	// mongoStartTimeout bounds pulling the image and waiting for MongoDB to listen
+	mongoStartTimeout = 2 * time.Minute
+)
+
+// StartMongo gives the integration tests a MongoDB of their own
+// With TEST_MONGO_URI set that database is used; otherwise a fresh container
+// is started with testcontainers-go and stop removes it again.
+//
+// An error usually means Docker isn't available: callers should skip their
+// integration tests rather than fail (see handlers.TestMain).
+func StartMongo(ctx context.Context) (uri string, stop func(), err error) {
+	if uri := os.Getenv(MongoURIEnv); uri != "" {
+		return uri, func() {}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mongoStartTimeout)
+	defer cancel()
+
+	// testcontainers panics instead of returning an error on some hosts
+	// without Docker, so turn that into an error too
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("docker not available: %v", r)
+		}
+	}()
+
+	container, err := testcontainers.Run(ctx, MongoImage,
+		testcontainers.WithExposedPorts("27017/tcp"),
+		testcontainers.WithWaitStrategy(wait.ForListeningPort("27017/tcp")),
+	)
+	if err != nil {
+		if container != nil {
+			_ = testcontainers.TerminateContainer(container)
+		}
+		return "", nil, fmt.Errorf("start %s container: %w", MongoImage, err)
+	}
+
+	uri, err = container.PortEndpoint(ctx, "27017/tcp", "mongodb")
+	if err != nil {
+		_ = testcontainers.TerminateContainer(container)
+		return "", nil, fmt.Errorf("get %s container address: %w", MongoImage, err)
+	}
+
+	stop = func() { _ = testcontainers.TerminateContainer(container) }
+	return uri, stop, nil
+}
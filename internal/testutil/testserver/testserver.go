@@ -0,0 +1,135 @@
+// Package testserver runs the complete API on a random port for black-box tests
+// Unlike humatest (see internal/app/routes_test.go), requests go over real
+// HTTP through the whole middleware stack - tracing, request IDs, rate
+// limiting, security headers, CORS and auth - in the order app.New adds them,
+// with an in-memory task store instead of MongoDB:
+//
+//	srv := testserver.Start(t, app.Options{})
+//	resp := srv.Request(t, http.MethodPost, "/tasks", map[string]any{"title": "Buy milk"})
+//
+// It lives in its own package because it imports internal/app, whose own
+// tests import internal/testutil.
+package testserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-todo-api/internal/app"
+	"go-todo-api/internal/logger"
+	"go-todo-api/internal/repository"
+)
+
+// APIKey is the key the server accepts; Client sends it with every request
+const APIKey = "test-server-key"
+
+// Server is a running API
+type Server struct {
+	// URL is where the API is served, including the base path
+	// e.g. http://127.0.0.1:38211/api
+	URL string
+
+	// Client sends X-API-Key with every request that doesn't set it already
+	Client *http.Client
+
+	// Anonymous sends requests without an API key (unless they set one)
+	Anonymous *http.Client
+
+	// Tasks is the store behind the task endpoints, for arranging data
+	// and checking what requests did to it
+	Tasks *repository.MemoryTaskRepository
+}
+
+// Start runs the app with opts on a random port until the test ends
+// opts.TaskRepository is replaced by a new in-memory store (Server.Tasks).
+// The server has a rate limiter of its own with opts.RateLimit (production
+// settings by default; set Disabled for tests that send a lot of requests).
+func Start(t testing.TB, opts app.Options) *Server {
+	t.Helper()
+
+	logger.Init()
+	t.Setenv("API_KEY", APIKey)
+
+	tasks := repository.NewMemoryTaskRepository()
+	opts.TaskRepository = tasks
+	handler, _ := app.New(opts)
+
+	// httptest listens on 127.0.0.1 with a port the OS picks
+	httpServer := httptest.NewServer(handler)
+	t.Cleanup(httpServer.Close)
+
+	base := httpServer.Client().Transport
+	return &Server{
+		URL:       httpServer.URL + opts.BasePath,
+		Client:    &http.Client{Transport: &apiKeyTransport{base: base, apiKey: APIKey}},
+		Anonymous: httpServer.Client(),
+		Tasks:     tasks,
+	}
+}
+
+// Request sends a request to path (relative to URL) with Client and returns
+// the response, whose body is closed when the test ends
+// body, when not nil, is sent as JSON.
+func (s *Server) Request(t testing.TB, method, path string, body any) *http.Response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return s.Do(t, s.Client, req)
+}
+
+// Do sends req with client (Client or Anonymous), for requests that need
+// their own headers
+// The response body is closed when the test ends
+func (s *Server) Do(t testing.TB, client *http.Client, req *http.Request) *http.Response {
+	t.Helper()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", req.Method, req.URL.Path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// DecodeJSON reads the response body into v
+func DecodeJSON(t testing.TB, resp *http.Response, v any) {
+	t.Helper()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("Failed to decode %s response: %v", resp.Status, err)
+	}
+}
+
+// apiKeyTransport adds the API key to requests that don't have one
+type apiKeyTransport struct {
+	base   http.RoundTripper
+	apiKey string
+}
+
+func (a *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("X-API-Key") != "" {
+		return a.base.RoundTrip(req)
+	}
+	// A RoundTripper must not change the request it was given
+	req = req.Clone(req.Context())
+	req.Header.Set("X-API-Key", a.apiKey)
+	return a.base.RoundTrip(req)
+}
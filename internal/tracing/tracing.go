@@ -8,13 +8,17 @@ import (
 	"time" // Working with the time durations and delays
 
 	// OUR OWN PACKAGES
-	"go-todo-api/internal/logger" // Our structured logger
+	"go-todo-api/internal/config"  // Environment profile (trace sample ratio)
+	"go-todo-api/internal/logger"  // Our structured logger
+	"go-todo-api/internal/version" // Build information (git SHA, build time)
 
 	// THIRD-PARTY LIBRARY PACKAGES
 	"go.opentelemetry.io/otel" // Exporter: Sends traces via HTTP to Jaeger/Tempo
+	"go.opentelemetry.io/otel/attribute"
 
 	// OpenTelemetry core: Main OTel packages - gives access to the global tracer
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"             // Propagation: Reads/writes trace context headers
 	"go.opentelemetry.io/otel/sdk/resource"            // Resource: Service metada
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"      // Trace provider: Core tracing functionality, creates spans
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0" // Semantic conventions: Standard attribute names for service.name, etc.
@@ -51,10 +55,17 @@ func Init(serviceName string) func() {
 	}
 
 	// Step 2: Create a resource (describes this service)
-	// This adds metadata to all traces: service name, version, etc.
+	// This adds metadata to all traces: service name, version, build info, etc.
+	// The build.* attributes match what GET /version returns, so a trace can be
+	// tied to the exact commit that produced it
+	build := version.Get()
 	res, err := resource.New(ctx, resource.WithAttributes(
 		semconv.ServiceName(ServiceName),
-		semconv.ServiceVersion("1.0.0"),
+		semconv.ServiceVersion(build.Version),
+		attribute.String("build.git_sha", build.GitSHA),
+		attribute.String("build.time", build.BuildTime),
+		attribute.String("build.go_version", build.GoVersion),
+		attribute.StringSlice("build.features", build.Features),
 	),
 	)
 	if err != nil {
@@ -62,19 +73,41 @@ func Init(serviceName string) func() {
 		log.Fatal("Failed to create resource")
 	}
 
-	// Step 3: Create a trace provider
+	// Step 3: Pick how many traces to keep
+	// Dev and staging keep every trace; prod keeps 10% (TRACE_SAMPLE_RATIO).
+	// ParentBased means a request that arrives with a sampled trace (e.g. from
+	// API Gateway) is always recorded, so we never cut a trace in half
+	sampleRatio := 1.0
+	if profile, err := config.LoadProfile(); err == nil {
+		sampleRatio = profile.TraceSampleRatio
+	}
+	sampler := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))
+
+	// Step 4: Create a trace provider
 	// This is the core of OpenTelemetry - it creates and manages spans
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),                // Send traces in batches (efficient)
-		sdktrace.WithResource(res),                    // Attach our service metadata
-		sdktrace.WithSampler(sdktrace.AlwaysSample()), // Sample 100% of traces (for learning)
+		sdktrace.WithBatcher(exporter),                 // Send traces in batches (efficient)
+		sdktrace.WithResource(res),                     // Attach our service metadata
+		sdktrace.WithSampler(sampler),                  // Keep sampleRatio of new traces
+		sdktrace.WithIDGenerator(newXRayIDGenerator()), // Trace IDs X-Ray accepts (timestamp prefix)
 	)
 
-	// Step 4: Set as a global tracer provider
+	// Step 5: Set as a global tracer provider
 	// This makes it available everywhere in your app via otel.Tracer()
 	otel.SetTracerProvider(tp)
 
-	logger.Log.Info("OpenTelemetry tracing initialized", "endpoint", otlpEndpoint, "backend", "Jaeger")
+	// Step 6: Set the global propagator
+	// Propagators read trace context from incoming headers (so we continue the
+	// caller's trace instead of starting a new one) and write it on outgoing
+	// requests. We accept W3C traceparent/baggage and AWS X-Amzn-Trace-Id, so
+	// requests coming through API Gateway/Lambda join the X-Ray trace.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+		XRayPropagator{},
+	))
+
+	logger.Log.Info("OpenTelemetry tracing initialized", "endpoint", otlpEndpoint, "backend", "Jaeger", "sample_ratio", sampleRatio)
 	// Return a cleanup function
 	// Call this when the server shuts down to flush any remaining traces
 	return func() {
@@ -0,0 +1,181 @@
+// This file lets our traces join AWS X-Ray traces
+// API Gateway and Lambda pass trace context in the X-Amzn-Trace-Id header
+// instead of the W3C traceparent header, using a slightly different ID format.
+// The propagator below translates between the two so a request that enters
+// through API Gateway keeps one trace all the way through our handlers.
+
+package tracing
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// XRayTraceHeader is the header AWS uses to carry trace context
+const XRayTraceHeader = "X-Amzn-Trace-Id"
+
+// XRayPropagator reads and writes the X-Amzn-Trace-Id header
+//
+// Header format:
+//
+//	Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1
+//
+// - Root: "1-" + 8 hex digits (epoch seconds) + "-" + 24 hex digits.
+// Joined together the 32 hex digits are exactly an OTel trace ID.
+// - Parent: 16 hex digits, the same as an OTel span ID
+// - Sampled: 1 or 0
+type XRayPropagator struct{}
+
+// Compile-time check that we implement the interface
+var _ propagation.TextMapPropagator = XRayPropagator{}
+
+// Inject writes the span context in ctx to the carrier as X-Amzn-Trace-Id
+func (XRayPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	sampled := "0"
+	if sc.IsSampled() {
+		sampled = "1"
+	}
+
+	carrier.Set(XRayTraceHeader, fmt.Sprintf("Root=%s;Parent=%s;Sampled=%s",
+		formatXRayTraceID(sc.TraceID()), sc.SpanID().String(), sampled))
+}
+
+// Extract reads X-Amzn-Trace-Id from the carrier into a remote span context
+// If the header is missing or malformed, ctx is returned unchanged
+func (XRayPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	sc, ok := parseXRayHeader(carrier.Get(XRayTraceHeader))
+	if !ok {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Fields returns the header this propagator uses
+func (XRayPropagator) Fields() []string {
+	return []string{XRayTraceHeader}
+}
+
+// parseXRayHeader turns an X-Amzn-Trace-Id value into a span context
+// Returns false if there's no usable Root and Parent
+func parseXRayHeader(header string) (trace.SpanContext, bool) {
+	if header == "" {
+		return trace.SpanContext{}, false
+	}
+
+	var cfg trace.SpanContextConfig
+	var haveTrace, haveParent bool
+
+	for _, part := range strings.Split(header, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "Root":
+			// 1-5759e988-bd862e3fe1be46a994272793
+			fields := strings.Split(value, "-")
+			if len(fields) != 3 || fields[0] != "1" || len(fields[1]) != 8 || len(fields[2]) != 24 {
+				return trace.SpanContext{}, false
+			}
+			traceID, err := trace.TraceIDFromHex(fields[1] + fields[2])
+			if err != nil {
+				return trace.SpanContext{}, false
+			}
+			cfg.TraceID = traceID
+			haveTrace = true
+
+		case "Parent":
+			spanID, err := trace.SpanIDFromHex(value)
+			if err != nil {
+				return trace.SpanContext{}, false
+			}
+			cfg.SpanID = spanID
+			haveParent = true
+
+		case "Sampled":
+			if value == "1" {
+				cfg.TraceFlags = trace.FlagsSampled
+			}
+		}
+	}
+
+	if !haveTrace || !haveParent {
+		return trace.SpanContext{}, false
+	}
+
+	cfg.Remote = true
+	sc := trace.NewSpanContext(cfg)
+	return sc, sc.IsValid()
+}
+
+// ============================================================================
+// X-RAY COMPATIBLE ID GENERATOR
+// ============================================================================
+// X-Ray only accepts trace IDs whose first 8 hex digits are the current epoch
+// seconds. OTel's default generator is fully random, so traces we start
+// ourselves (no incoming header) would be rejected by X-Ray. This generator
+// keeps the timestamp prefix and randomises the rest.
+
+// xrayIDGenerator implements sdktrace.IDGenerator
+type xrayIDGenerator struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// newXRayIDGenerator creates an ID generator seeded from crypto/rand
+func newXRayIDGenerator() *xrayIDGenerator {
+	var seed int64
+	_ = binary.Read(crand.Reader, binary.LittleEndian, &seed)
+	return &xrayIDGenerator{rand: rand.New(rand.NewSource(seed))}
+}
+
+// NewIDs returns a new X-Ray compatible trace ID and span ID
+func (g *xrayIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var tid trace.TraceID
+	binary.BigEndian.PutUint32(tid[:4], uint32(time.Now().Unix()))
+	g.rand.Read(tid[4:])
+
+	return tid, g.newSpanID()
+}
+
+// NewSpanID returns a new span ID for a span in an existing trace
+func (g *xrayIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.newSpanID()
+}
+
+// newSpanID returns a random non-zero span ID (caller holds the lock)
+func (g *xrayIDGenerator) newSpanID() trace.SpanID {
+	var sid trace.SpanID
+	for !sid.IsValid() {
+		g.rand.Read(sid[:])
+	}
+	return sid
+}
+
+// formatXRayTraceID renders an OTel trace ID in X-Ray's Root format
+// e.g. 5759e988bd862e3fe1be46a994272793 → 1-5759e988-bd862e3fe1be46a994272793
+func formatXRayTraceID(id trace.TraceID) string {
+	h := hex.EncodeToString(id[:])
+	return "1-" + h[:8] + "-" + h[8:]
+}
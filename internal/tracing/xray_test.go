@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestXRayPropagator_Extract tests reading an API Gateway trace header
+func TestXRayPropagator_Extract(t *testing.T) {
+	carrier := propagation.MapCarrier{
+		XRayTraceHeader: "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1",
+	}
+
+	ctx := XRayPropagator{}.Extract(context.Background(), carrier)
+	sc := trace.SpanContextFromContext(ctx)
+
+	if sc.TraceID().String() != "5759e988bd862e3fe1be46a994272793" {
+		t.Errorf("Unexpected trace ID: %s", sc.TraceID())
+	}
+
+	if sc.SpanID().String() != "53995c3f42cd8ad8" {
+		t.Errorf("Unexpected span ID: %s", sc.SpanID())
+	}
+
+	if !sc.IsSampled() || !sc.IsRemote() {
+		t.Error("Expected a sampled, remote span context")
+	}
+}
+
+// TestXRayPropagator_RoundTrip tests that Inject writes what Extract reads
+func TestXRayPropagator_RoundTrip(t *testing.T) {
+	tid, sid := newXRayIDGenerator().NewIDs(context.Background())
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid, TraceFlags: trace.FlagsSampled})
+
+	carrier := propagation.MapCarrier{}
+	XRayPropagator{}.Inject(trace.ContextWithSpanContext(context.Background(), sc), carrier)
+
+	got := trace.SpanContextFromContext(XRayPropagator{}.Extract(context.Background(), carrier))
+	if got.TraceID() != tid || got.SpanID() != sid {
+		t.Errorf("Round trip mismatch: header %q", carrier.Get(XRayTraceHeader))
+	}
+}
+
+// TestXRayPropagator_Malformed tests that a bad header is ignored
+func TestXRayPropagator_Malformed(t *testing.T) {
+	carrier := propagation.MapCarrier{XRayTraceHeader: "Root=garbage;Parent=53995c3f42cd8ad8"}
+
+	ctx := XRayPropagator{}.Extract(context.Background(), carrier)
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		t.Error("Expected malformed header to be ignored")
+	}
+}
@@ -0,0 +1,76 @@
+// Package version holds build information for the running binary
+// The variables are set at build time with -ldflags, for example:
+//
+//	go build -ldflags "-X go-todo-api/internal/version.GitSHA=$(git rev-parse --short HEAD) \
+//	  -X go-todo-api/internal/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ) \
+//	  -X go-todo-api/internal/version.Features=tracing,ratelimit" ./cmd/api
+//
+// The Makefile does this for you (see LDFLAGS).
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Build information injected via -ldflags
+// They must be plain string variables (not constants) for -X to work
+var (
+	// Version is the release version of the API
+	Version = "1.0.0"
+
+	// GitSHA is the commit the binary was built from
+	GitSHA = "unknown"
+
+	// BuildTime is when the binary was built (RFC 3339, UTC)
+	BuildTime = "unknown"
+
+	// Features is a comma-separated list of feature flags enabled at build time
+	Features = ""
+)
+
+// Info is a snapshot of the build information
+type Info struct {
+	Version   string
+	GitSHA    string
+	BuildTime string
+	GoVersion string
+	Features  []string
+}
+
+// Get returns the build information of the running binary
+// If GitSHA wasn't injected, it falls back to the VCS info Go embeds
+// automatically when building inside a git checkout
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		GitSHA:    GitSHA,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Features:  []string{},
+	}
+
+	if info.GitSHA == "unknown" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				switch s.Key {
+				case "vcs.revision":
+					info.GitSHA = s.Value
+				case "vcs.time":
+					if info.BuildTime == "unknown" {
+						info.BuildTime = s.Value
+					}
+				}
+			}
+		}
+	}
+
+	for _, f := range strings.Split(Features, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			info.Features = append(info.Features, f)
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,151 @@
+// Small TODO UI - talks to the same JSON API as any other client
+(function () {
+  "use strict";
+
+  var base = document.querySelector('meta[name="api-base"]').content;
+  var filter = "";
+
+  var $ = function (id) { return document.getElementById(id); };
+
+  // --------------------------------------------------------------------------
+  // API KEY
+  // --------------------------------------------------------------------------
+  // Every API call needs the X-API-Key header. The key is kept in this
+  // browser's localStorage so it only has to be entered once.
+  function apiKey() { return localStorage.getItem("todo-api-key") || ""; }
+
+  function showKeyForm() {
+    $("key-form").hidden = false;
+    $("app").hidden = true;
+  }
+
+  function showApp() {
+    $("key-form").hidden = true;
+    $("app").hidden = false;
+    load();
+  }
+
+  // --------------------------------------------------------------------------
+  // API CALLS
+  // --------------------------------------------------------------------------
+  function request(method, path, body) {
+    var opts = { method: method, headers: { "X-API-Key": apiKey() } };
+    if (body !== undefined) {
+      opts.headers["Content-Type"] = "application/json";
+      opts.body = JSON.stringify(body);
+    }
+
+    return fetch(base + path, opts).then(function (res) {
+      if (res.status === 401 || res.status === 403) {
+        localStorage.removeItem("todo-api-key");
+        showKeyForm();
+        throw new Error("Invalid API key");
+      }
+      if (res.status === 204) { return null; }
+      return res.json().then(function (data) {
+        if (!res.ok) { throw new Error(data.detail || data.title || res.statusText); }
+        return data;
+      });
+    });
+  }
+
+  function showError(err) {
+    $("error").textContent = err.message;
+    $("error").hidden = false;
+  }
+
+  function clearError() { $("error").hidden = true; }
+
+  // --------------------------------------------------------------------------
+  // RENDERING
+  // --------------------------------------------------------------------------
+  function load() {
+    var query = filter ? "?completed=" + filter : "";
+    request("GET", "/tasks" + query).then(function (tasks) {
+      clearError();
+      render(tasks || []);
+    }).catch(showError);
+  }
+
+  function render(tasks) {
+    var list = $("tasks");
+    list.textContent = "";
+    $("empty").hidden = tasks.length > 0;
+
+    tasks.forEach(function (task) {
+      var item = document.createElement("li");
+      if (task.completed) { item.className = "done"; }
+
+      var check = document.createElement("input");
+      check.type = "checkbox";
+      check.checked = task.completed;
+      check.addEventListener("change", function () {
+        request("PUT", "/tasks/" + task.id, { completed: check.checked }).then(load).catch(showError);
+      });
+
+      // textContent (never innerHTML) so task text can't inject markup
+      var text = document.createElement("div");
+      text.className = "text";
+      var title = document.createElement("div");
+      title.className = "title";
+      title.textContent = task.title;
+      text.appendChild(title);
+      if (task.description) {
+        var description = document.createElement("div");
+        description.className = "description";
+        description.textContent = task.description;
+        text.appendChild(description);
+      }
+
+      var del = document.createElement("button");
+      del.className = "delete";
+      del.textContent = "Delete";
+      del.addEventListener("click", function () {
+        request("DELETE", "/tasks/" + task.id).then(load).catch(showError);
+      });
+
+      item.appendChild(check);
+      item.appendChild(text);
+      item.appendChild(del);
+      list.appendChild(item);
+    });
+  }
+
+  // --------------------------------------------------------------------------
+  // EVENTS
+  // --------------------------------------------------------------------------
+  $("key-form").addEventListener("submit", function (e) {
+    e.preventDefault();
+    localStorage.setItem("todo-api-key", $("api-key").value);
+    $("api-key").value = "";
+    showApp();
+  });
+
+  $("forget-key").addEventListener("click", function () {
+    localStorage.removeItem("todo-api-key");
+    showKeyForm();
+  });
+
+  $("new-task").addEventListener("submit", function (e) {
+    e.preventDefault();
+    var body = { title: $("title").value };
+    if ($("description").value) { body.description = $("description").value; }
+
+    request("POST", "/tasks", body).then(function () {
+      $("title").value = "";
+      $("description").value = "";
+      load();
+    }).catch(showError);
+  });
+
+  Array.prototype.forEach.call(document.querySelectorAll("#filters [data-filter]"), function (button) {
+    button.addEventListener("click", function () {
+      document.querySelector("#filters .active").classList.remove("active");
+      button.classList.add("active");
+      filter = button.dataset.filter;
+      load();
+    });
+  });
+
+  if (apiKey()) { showApp(); } else { showKeyForm(); }
+})();
@@ -0,0 +1,58 @@
+// Package web serves the small browser UI for managing tasks
+// The HTML, CSS and JavaScript are embedded in the binary with go:embed,
+// so the UI works out of the box with no separate frontend deployment.
+package web
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// contentSecurityPolicy allows the UI's own scripts, styles and API calls
+// The API's default policy (default-src 'none') would block the page entirely.
+const contentSecurityPolicy = "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; img-src 'self' data:; form-action 'self'; frame-ancestors 'none'; base-uri 'none'"
+
+// Handler returns a handler for the UI page and its assets
+// basePath is the API prefix (e.g. "/api"); the page calls the API under it.
+//
+// Routes (relative to basePath):
+//
+//	GET /            → index.html
+//	GET /ui/app.js   → assets from the static directory
+func Handler(basePath string) http.Handler {
+	index := renderIndex(basePath)
+
+	assets, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		panic(err) // The static directory is embedded at build time, so this can't happen
+	}
+	fileServer := http.StripPrefix(basePath+"/ui/", http.FileServer(http.FS(assets)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
+
+		if r.URL.Path == basePath+"/" || r.URL.Path == basePath {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(index)
+			return
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// renderIndex fills the base path into index.html once at startup
+func renderIndex(basePath string) []byte {
+	tmpl := template.Must(template.ParseFS(staticFiles, "static/index.html"))
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct{ BasePath string }{basePath}); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}